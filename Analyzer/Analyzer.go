@@ -4,33 +4,55 @@ import (
 	"proyecto1/DiskManagement"
 	"proyecto1/FileSystem"
 	"proyecto1/Reportes"
-	"flag"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 	"bytes"
 	"io"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"unicode"
 )
 
-var re = regexp.MustCompile(`-(\w+)=("[^"]+"|\S+)`)
+// Comandos que solo leen el disco y pueden compartir el lock con otras lecturas
+var readOnlyCommands = map[string]bool{
+	"mounted":    true,
+	"rep":        true,
+	"info":       true,
+	"ls":         true,
+	"find":       true,
+	"journaling": true,
+	"export":     true,
+	"snapshots":  true,
+}
 
-// ProcessCommandForAPI processes a single command for API usage and returns the output as string
-func ProcessCommandForAPI(input string) string {
-	// Capture stdout
-	old := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+// Comandos de snapshots: trabajan sobre el disco indicado con -path
+var snapshotCommands = map[string]bool{
+	"mksnapshot": true,
+	"snapshots":  true,
+	"rollback":   true,
+	"rmsnapshot": true,
+}
 
-	// Buffer to capture output
-	var buf bytes.Buffer
-	done := make(chan bool)
+// Context holds the state shared by the commands of one caller: the session of
+// the user sending them and the writer that receives their output. login and
+// logout update the session.
+type Context struct {
+	Session *Structs.UserSession
+	Output  io.Writer
+}
+
+// NewContext creates a context for the given session (nil if nobody is logged in)
+// that writes the output of the commands to the console
+func NewContext(session *Structs.UserSession) *Context {
+	return &Context{Session: session, Output: os.Stdout}
+}
 
-	// Start a goroutine to copy the output
-	go func() {
-		io.Copy(&buf, r)
-		done <- true
-	}()
+// ProcessCommandForAPI processes the commands for API usage and returns the full
+// output log together with a structured result for every executed command
+func ProcessCommandForAPI(ctx *Context, input string) (string, []Structs.CommandResult) {
+	var log strings.Builder
+	results := make([]Structs.CommandResult, 0)
 
 	// Check if input contains multiple commands (separated by newlines)
 	lines := strings.Split(strings.TrimSpace(input), "\n")
@@ -43,722 +65,679 @@ func ProcessCommandForAPI(input string) string {
 			continue
 		}
 		
-		fmt.Printf(">>> Procesando: %s\n", line)
-		processCommand(line)
-		fmt.Println() // Add separator between commands
-	}
+		// Each command writes to its own buffer, so the output of concurrent
+		// requests never mixes
+		var output bytes.Buffer
+		console := ctx.Output
+		ctx.Output = &output
 
-	// Restore stdout
-	w.Close()
-	os.Stdout = old
-	<-done
+		fmt.Fprintf(&output, ">>> Procesando: %s\n", line)
+		result := processCommand(ctx, line)
+		fmt.Fprintln(&output) // Add separator between commands
 
-	return buf.String()
-}
+		ctx.Output = console
+		log.WriteString(output.String())
 
+		result.Output = output.String()
+		results = append(results, result)
+	}
 
+	return log.String(), results
+}
 
-func processCommand(input string) {
+// ExecuteCommand ejecuta un comando escribiendo su salida en ctx.Output (la
+// consola en la terminal interactiva) y retorna su resultado estructurado
+func ExecuteCommand(ctx *Context, input string) Structs.CommandResult {
+	return processCommand(ctx, strings.TrimSpace(input))
+}
+
+func processCommand(ctx *Context, input string) Structs.CommandResult {
 	command, params := getCommandAndParams(input)
 
 	if command == "exit" {
-		fmt.Println("Comando exit recibido")
-		return
+		fmt.Fprintln(ctx.Output, "Comando exit recibido")
+		return newCommandResult(command, params, nil, nil)
 	}
 
-	fmt.Println("Ejecutando:", command, "con parámetros:", params)
+	fmt.Fprintln(ctx.Output, "Ejecutando:", command, "con parámetros:", params)
 
-	AnalyzeCommnad(command, params)
+	unlock := lockForCommand(ctx, command, params)
+	result := AnalyzeCommnad(ctx, command, params)
+	unlock()
 	
-	fmt.Println()
+	fmt.Fprintln(ctx.Output)
+	return result
+}
+
+// lockForCommand toma el lock del disco sobre el que trabaja el comando:
+// lectura para las consultas y escritura para los que lo modifican.
+// Retorna la función que libera el lock.
+func lockForCommand(ctx *Context, command string, params string) func() {
+	// Si los parámetros no son válidos el comando lo reporta; aquí solo se
+	// usan para ubicar el disco
+	values := make(map[string]string)
+	tokens, _ := tokenizeParams(params)
+	for _, token := range tokens {
+		values[token.name] = token.value
+	}
+
+	// Ubicar el disco: por -path, por -id de una partición montada o por la
+	// partición de la sesión activa. El ID se normaliza como lo hace el
+	// esquema del comando, que lo pasa a mayúsculas.
+	diskPath := ""
+	if values["path"] != "" && (command == "mkdisk" || command == "rmdisk" || command == "fdisk" || command == "mount" || snapshotCommands[command]) {
+		diskPath = values["path"]
+	}
+	if diskPath == "" && values["id"] != "" {
+		if partition, exists := DiskManagement.GetMountedPartition(strings.ToUpper(values["id"])); exists {
+			diskPath = partition.Path
+		}
+	}
+	if diskPath == "" && ctx != nil && ctx.Session != nil {
+		if partition, exists := DiskManagement.GetMountedPartition(ctx.Session.PartitionID); exists {
+			diskPath = partition.Path
+		}
+	}
+
+	// registry revisa varios discos, exec ejecuta comandos que toman sus
+	// propios locks y help no usa ningún disco
+	if diskPath == "" || command == "registry" || command == "exec" || command == "help" {
+		return func() {}
+	}
+	if readOnlyCommands[command] {
+		return DiskManagement.RLockDisk(diskPath)
+	}
+	return DiskManagement.LockDisk(diskPath)
+}
+
+// newCommandResult construye el resultado estructurado a partir del valor retornado por el comando
+func newCommandResult(command string, params string, data map[string]interface{}, err error) Structs.CommandResult {
+	result := Structs.CommandResult{
+		Command: command,
+		Params:  params,
+		Data:    data,
+	}
+
+	if err != nil {
+		result.Status = "error"
+		result.Code = Utilities.ErrorCode(err)
+		result.Message = err.Error()
+		return result
+	}
+
+	result.Status = "ok"
+	result.Message = fmt.Sprintf("Comando '%s' ejecutado exitosamente", command)
+	return result
 }
 
+// getCommandAndParams separa el nombre del comando de sus parámetros; los
+// parámetros se conservan tal cual para respetar los espacios entre comillas
 func getCommandAndParams(input string) (string, string) {
-	parts := strings.Fields(input)
-	if len(parts) > 0 {
-		command := strings.ToLower(parts[0])
-		params := strings.Join(parts[1:], " ")
-		return command, params
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", input
 	}
-	return "", input
+	if end := strings.IndexFunc(input, unicode.IsSpace); end >= 0 {
+		return strings.ToLower(input[:end]), strings.TrimSpace(input[end:])
+	}
+	return strings.ToLower(input), ""
 }
 
-func AnalyzeCommnad(command string, params string) {
+func AnalyzeCommnad(ctx *Context, command string, params string) Structs.CommandResult {
+	var data map[string]interface{}
+	var err error
+
 	switch command {
 	case "mkdisk":
-		fn_mkdisk(params)
+		data, err = fn_mkdisk(ctx, params)
 	case "rmdisk":
-		fn_rmdisk(params)
+		data, err = fn_rmdisk(ctx, params)
 	case "fdisk":
-		fn_fdisk(params)
+		data, err = fn_fdisk(ctx, params)
 	case "mount":
-		fn_mount(params)
+		data, err = fn_mount(ctx, params)
 	case "unmount":
-		fn_unmount(params)
+		data, err = fn_unmount(ctx, params)
 	case "mounted":
-		fn_mounted(params)
+		data, err = fn_mounted(ctx, params)
+	case "registry":
+		data, err = fn_registry(ctx, params)
 	case "mkfs":
-		fn_mkfs(params)
+		data, err = fn_mkfs(ctx, params)
+	case "convert":
+		data, err = fn_convert(ctx, params)
+	case "fsck":
+		data, err = fn_fsck(ctx, params)
 	case "rep":
-		fn_rep(params)
+		data, err = fn_rep(ctx, params)
 	case "info":
-		fn_info(params)
+		data, err = fn_info(ctx, params)
 	case "ls":
-		fn_ls(params)
+		data, err = fn_ls(ctx, params)
 	case "login":
-		fn_login(params)
+		data, err = fn_login(ctx, params)
 	case "logout":
-		fn_logout(params)
+		data, err = fn_logout(ctx, params)
 	case "mkgrp":
-		fn_mkgrp(params)
+		data, err = fn_mkgrp(ctx, params)
 	case "rmgrp":
-		fn_rmgrp(params)
+		data, err = fn_rmgrp(ctx, params)
 	case "mkusr":
-		fn_mkusr(params)
+		data, err = fn_mkusr(ctx, params)
 	case "rmusr":
-		fn_rmusr(params)
+		data, err = fn_rmusr(ctx, params)
 	case "chgrp":
-		fn_chgrp(params)
+		data, err = fn_chgrp(ctx, params)
+	case "passwd":
+		data, err = fn_passwd(ctx, params)
 	case "mkfile":
-		fn_mkfile(params)
+		data, err = fn_mkfile(ctx, params)
 	case "mkdir":
-		fn_mkdir(params)
+		data, err = fn_mkdir(ctx, params)
 	case "cat":
-		fn_cat(params)
+		data, err = fn_cat(ctx, params)
 	case "remove":
-		fn_remove(params)
+		data, err = fn_remove(ctx, params)
 	case "edit":
-		fn_edit(params)
+		data, err = fn_edit(ctx, params)
+	case "write":
+		data, err = fn_write(ctx, params)
 	case "rename":
-		fn_rename(params)
+		data, err = fn_rename(ctx, params)
 	case "copy":
-		fn_copy(params)
+		data, err = fn_copy(ctx, params)
 	case "move":
-		fn_move(params)
+		data, err = fn_move(ctx, params)
+	case "export":
+		data, err = fn_export(ctx, params)
+	case "import":
+		data, err = fn_import(ctx, params)
 	case "find":
-		fn_find(params)
+		data, err = fn_find(ctx, params)
 	case "chown":
-		fn_chown(params)
+		data, err = fn_chown(ctx, params)
 	case "chmod":
-		fn_chmod(params)
+		data, err = fn_chmod(ctx, params)
 	case "loss":
-		fn_loss(params)
+		data, err = fn_loss(ctx, params)
 	case "recovery":
-		fn_recovery(params)
+		data, err = fn_recovery(ctx, params)
 	case "journaling":
-		fn_journaling(params)
+		data, err = fn_journaling(ctx, params)
+	case "mksnapshot":
+		data, err = fn_mksnapshot(ctx, params)
+	case "snapshots":
+		data, err = fn_snapshots(ctx, params)
+	case "rollback":
+		data, err = fn_rollback(ctx, params)
+	case "rmsnapshot":
+		data, err = fn_rmsnapshot(ctx, params)
+	case "exec":
+		data, err = fn_exec(ctx, params)
+	case "help":
+		data, err = fn_help(ctx, params)
 	case "exit":
-		fmt.Println("Comando exit procesado - sesión terminada")
+		fmt.Fprintln(ctx.Output, "Comando exit procesado - sesión terminada")
 	default:
-		fmt.Println("Error: Comando no reconocido.")
+		fmt.Fprintln(ctx.Output, "Error: Comando no reconocido.")
+		err = Utilities.NewCommandError(Utilities.ErrUnknownCommand, "Comando '%s' no reconocido", command)
 	}
-}
-
 
-func fn_mkdisk(params string) {
-	// Definiendo banderas
-	fs := flag.NewFlagSet("mkdisk", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout) // Para mostrar errores en stdout
-	
-	size := fs.Int("size", 0, "Size")
-	fit := fs.String("fit", "ff", "Fit (opcional, default: ff)")
-	unit := fs.String("unit", "m", "Unit (opcional, default: m)")
-	path := fs.String("path", "", "Ruta donde crear el archivo (obligatorio)")
+	return newCommandResult(command, params, data, err)
+}
 
-	// obtener valores
-	managementFlags(fs, params)
 
-	// Validar parámetros requeridos
-	if *size <= 0 {
-		fmt.Println("Error: El parámetro -size es requerido y debe ser mayor a 0")
-		fmt.Println("Uso: mkdisk -size=<tamaño> -path=<ruta> [-unit=<k|m>] [-fit=<bf|ff|wf>]")
-		return
-	}
-
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		fmt.Println("Uso: mkdisk -size=<tamaño> -path=<ruta> [-unit=<k|m>] [-fit=<bf|ff|wf>]")
-		return
+func fn_mkdisk(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "mkdisk", params)
+	if err != nil {
+		return nil, err
 	}
+	size := args.Int("size")
+	fit := args.String("fit")
+	unit := args.String("unit")
+	path := args.String("path")
 
 	// Llamar a la función
-	DiskManagement.Mkdisk(*size, *fit, *unit, *path)
+	if err := DiskManagement.Mkdisk(ctx.Output, *size, *fit, *unit, *path); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"path": *path, "size": *size, "unit": *unit, "fit": *fit}, nil
 }
 
-func fn_rmdisk(params string) {
-	// Definiendo banderas
-	fs := flag.NewFlagSet("rmdisk", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout) // Para mostrar errores en stdout
-	
-	path := fs.String("path", "", "Ruta del disco a eliminar (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		fmt.Println("Uso: rmdisk -path=<ruta_del_disco>")
-		fmt.Println("Ejemplo: rmdisk -path=\"/home/mis discos/Disco4.mia\"")
-		return
+func fn_rmdisk(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "rmdisk", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
 
 	// Llamar a la función
-	DiskManagement.Rmdisk(*path)
+	return nil, DiskManagement.Rmdisk(ctx.Output, *path)
 }
 
-func fn_fdisk(params string){
-	//Definiendo parámetros
-	fs := flag.NewFlagSet("fdisk", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	size := fs.Int("size", 0, "Tamaño de la partición")
-	path := fs.String("path", "", "Ruta del disco")
-	name := fs.String("name", "", "Nombre de la partición")
-	type_ := fs.String("type", "p", "Tipo de la partición (p/e/l) (opcional, default: p)")
-	fit := fs.String("fit", "wf", "Fit (opcional, default: ff)")
-	unit := fs.String("unit", "k", "Unit (opcional, default: k)")
-	add := fs.Int("add", 0, "Agregar o quitar espacio de la partición (opcional)")
-	delete := fs.String("delete", "", "Eliminar partición (fast/full) (opcional)")
-
-	// obtener valores
-	managementFlags(fs, params)
+func fn_fdisk(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "fdisk", params)
+	if err != nil {
+		return nil, err
+	}
+	size := args.Int("size")
+	path := args.String("path")
+	name := args.String("name")
+	type_ := args.String("type")
+	fit := args.String("fit")
+	unit := args.String("unit")
+	add := args.Int("add")
+	delete := args.String("delete")
 
 	// Verificar si es una operación de eliminación
 	if *delete != "" {
-		if *path == "" || *name == "" {
-			fmt.Println("Error: Los parámetros -path y -name son requeridos para eliminar una partición")
-			fmt.Println("Uso: fdisk -delete=<fast|full> -path=<ruta> -name=<nombre>")
-			return
+		if err := DiskManagement.FdiskDelete(ctx.Output, *path, *name, *delete); err != nil {
+			return nil, err
 		}
-		DiskManagement.FdiskDelete(*path, *name, *delete)
-		return
+		return map[string]interface{}{"path": *path, "name": *name, "delete": *delete}, nil
 	}
 
 	// Verificar si es una operación de agregar/quitar espacio
 	if *add != 0 {
-		if *path == "" || *name == "" {
-			fmt.Println("Error: Los parámetros -path y -name son requeridos para modificar el espacio de una partición")
-			fmt.Println("Uso: fdisk -add=<tamaño> -path=<ruta> -name=<nombre> [-unit=<b|k|m>]")
-			return
+		if err := DiskManagement.FdiskAdd(ctx.Output, *path, *name, *add, *unit, FileSystem.ResizeFilesystem); err != nil {
+			return nil, err
 		}
-		DiskManagement.FdiskAdd(*path, *name, *add, *unit)
-		return
+		return map[string]interface{}{"path": *path, "name": *name, "add": *add, "unit": *unit}, nil
 	}
 
 	// Validar parámetros requeridos para crear partición
 	if *size <= 0 {
-		fmt.Println("Error: El parámetro -size es requerido y debe ser mayor a 0")
-		fmt.Println("Uso: fdisk -size=<tamaño> -path=<ruta> -name=<nombre> [-unit=<b|k|m>] [-type=<p|e|l>] [-fit=<b|f|w>]")
-		return
-	}
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		fmt.Println("Uso: fdisk -size=<tamaño> -path=<ruta> -name=<nombre> [-unit=<b|k|m>] [-type=<p|e|l>] [-fit=<b|f|w>]")
-		return
+		fmt.Fprintln(ctx.Output, "Error: El parámetro -size es requerido y debe ser mayor a 0")
+		fmt.Fprintln(ctx.Output, "Uso: fdisk -size=<tamaño> -path=<ruta> -name=<nombre> [-unit=<b|k|m>] [-type=<p|e|l>] [-fit=<bf|ff|wf>]")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -size es requerido y debe ser mayor a 0")
 	}
-	if *name == "" {
-		fmt.Println("Error: El parámetro -name es requerido")
-		fmt.Println("Uso: fdisk -size=<tamaño> -path=<ruta> -name=<nombre> [-unit=<b|k|m>] [-type=<p|e|l>] [-fit=<b|f|w>]")
-		return
-	}
-
 	//llamar a la función para crear partición
-	DiskManagement.Fdisk(*size, *path, *name, *type_, *fit, *unit)
+	if err := DiskManagement.Fdisk(ctx.Output, *size, *path, *name, *type_, *fit, *unit); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"path": *path, "name": *name, "type": *type_, "size": *size, "unit": *unit, "fit": *fit}, nil
 }
 
-func fn_unmount(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("unmount", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	id := fs.String("id", "", "ID de la partición montada a desmontar (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es requerido")
-		fmt.Println("Uso: unmount -id=<id_particion>")
-		fmt.Println("Ejemplo: unmount -id=851A")
-		return
+func fn_unmount(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "unmount", params)
+	if err != nil {
+		return nil, err
 	}
+	id := args.String("id")
 
 	// Normalizar ID a mayúsculas para compatibilidad
 	normalizedID := strings.ToUpper(*id)
 
 	// Llamar la función
-	DiskManagement.Unmount(normalizedID)
+	if err := DiskManagement.Unmount(ctx.Output, normalizedID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"id": normalizedID}, nil
 }
 
-func fn_mounted(params string) {
-	fmt.Println("======INICIO MOUNTED======")
-	fmt.Println("Comando: mounted")
-	fmt.Println("Descripción: Mostrar todas las particiones montadas en el sistema")
-	fmt.Println()
-	
+func fn_mounted(ctx *Context, params string) (map[string]interface{}, error) {
 	// Este comando no acepta parámetros
-	if strings.TrimSpace(params) != "" {
-		fmt.Println("Advertencia: El comando 'mounted' no acepta parámetros. Los parámetros serán ignorados.")
-		fmt.Println()
+	if _, err := parseArgs(ctx.Output, "mounted", params); err != nil {
+		return nil, err
 	}
-	
-	DiskManagement.ShowDetailedMountedPartitions()
-	fmt.Println("======FIN MOUNTED======")
-}
 
-func fn_mount(params string){
-	//Definiendo parámetros
-	fs := flag.NewFlagSet("mount", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta donde se encuentra el disco (obligatorio)")
-	name := fs.String("name","","Nombre de la partición a montar (obligatorio)")
+	fmt.Fprintln(ctx.Output, "======INICIO MOUNTED======")
+	fmt.Fprintln(ctx.Output, "Comando: mounted")
+	fmt.Fprintln(ctx.Output, "Descripción: Mostrar todas las particiones montadas en el sistema")
+	fmt.Fprintln(ctx.Output)
+
+	DiskManagement.ShowDetailedMountedPartitions(ctx.Output)
+	fmt.Fprintln(ctx.Output, "======FIN MOUNTED======")
 
-	// obtener valores
-	managementFlags(fs, params)
+	return map[string]interface{}{"mounted": DiskManagement.GetMountedIDs()}, nil
+}
 
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		fmt.Println("Uso: mount -path=<ruta_del_disco> -name=<nombre_particion>")
-		fmt.Println("Ejemplo: mount -path=./test/A.mia -name=Particion1")
-		return
+func fn_registry(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "registry", params)
+	if err != nil {
+		return nil, err
 	}
-	if *name == "" {
-		fmt.Println("Error: El parámetro -name es requerido")
-		fmt.Println("Uso: mount -path=<ruta_del_disco> -name=<nombre_particion>")
-		fmt.Println("Ejemplo: mount -path=./test/A.mia -name=Particion1")
-		return
+	fix := args.Bool("fix")
+
+	// Llamar la función
+	entries, err := DiskManagement.Registry(ctx.Output, *fix)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"entries": entries, "fix": *fix}, nil
+}
+
+func fn_mount(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "mount", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	name := args.String("name")
 
 	//llamar a la función
-	DiskManagement.Mount(*path, *name)
+	id, err := DiskManagement.Mount(ctx.Output, *path, *name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Las particiones formateadas con el superblock anterior o con bloques de
+	// 64 bytes se migran al montarlas; si no se puede, no quedan montadas
+	if err := FileSystem.UpgradeLayout(ctx.Output, id); err != nil {
+		fmt.Fprintf(ctx.Output, "Error: %v\n", err)
+		DiskManagement.Unmount(ctx.Output, id)
+		return nil, err
+	}
+	return map[string]interface{}{"id": id, "path": *path, "name": *name}, nil
 }
 
-func fn_mkfs(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("mkfs", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	id := fs.String("id", "", "ID de la partición montada (obligatorio)")
-	type_ := fs.String("type", "full", "Tipo de formateo: full (opcional, default: full)")
-	filesystem := fs.String("fs", "2fs", "Sistema de archivos: 2fs o 3fs (opcional, default: 2fs)")
+func fn_mkfs(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "mkfs", params)
+	if err != nil {
+		return nil, err
+	}
+	id := args.String("id")
+	type_ := args.String("type")
+	filesystem := args.String("fs")
+	journal := args.Int("journal")
 
-	// obtener valores
-	managementFlags(fs, params)
+	// Normalizar ID a mayúsculas para compatibilidad
+	normalizedID := strings.ToUpper(*id)
 
-	// Validar parámetros requeridos
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es requerido")
-		fmt.Println("Uso: mkfs -id=<ID_particion> [-type=full] [-fs=2fs|3fs]")
-		fmt.Println("Ejemplo: mkfs -id=851A -type=full -fs=2fs")
-		fmt.Println("Ejemplo: mkfs -id=851A -fs=3fs")
-		return
+	// Llamar la función
+	if err := FileSystem.Mkfs(ctx.Output, normalizedID, *type_, *filesystem, int32(*journal)); err != nil {
+		return nil, err
 	}
-
-	// Validar que el tipo sea válido
-	if *type_ != "full" {
-		fmt.Printf("Error: Tipo '%s' no válido. Solo se acepta 'full'\n", *type_)
-		fmt.Println("Uso: mkfs -id=<ID_particion> [-type=full] [-fs=2fs|3fs]")
-		return
+	result := map[string]interface{}{"id": normalizedID, "fs": *filesystem, "type": *type_}
+	if *filesystem == "3fs" {
+		result["journal"] = *journal
 	}
+	return result, nil
+}
 
-	// Validar que el sistema de archivos sea válido
-	if *filesystem != "2fs" && *filesystem != "3fs" {
-		fmt.Printf("Error: Sistema de archivos '%s' no válido. Use '2fs' o '3fs'\n", *filesystem)
-		fmt.Println("Uso: mkfs -id=<ID_particion> [-type=full] [-fs=2fs|3fs]")
-		return
+func fn_convert(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "convert", params)
+	if err != nil {
+		return nil, err
 	}
+	id := args.String("id")
+	journal := args.Int("journal")
 
 	// Normalizar ID a mayúsculas para compatibilidad
 	normalizedID := strings.ToUpper(*id)
 
 	// Llamar la función
-	FileSystem.Mkfs(normalizedID, *type_, *filesystem)
+	if err := FileSystem.ConvertToExt3(ctx.Output, normalizedID, int32(*journal)); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"id": normalizedID, "fs": "3fs", "journal": *journal}, nil
 }
 
-func fn_rep(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("rep", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	name := fs.String("name", "", "Nombre del reporte (obligatorio)")
-	path := fs.String("path", "", "Ruta donde se generará el reporte (obligatorio)")
-	id := fs.String("id", "", "ID de la partición (obligatorio)")
-	path_file_ls := fs.String("path_file_ls", "", "Ruta del archivo o carpeta para reportes file y ls (opcional)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *name == "" {
-		fmt.Println("Error: El parámetro -name es obligatorio")
-		fmt.Println("Valores válidos: mbr, disk, inode, block, bm_inode, bm_block, tree, sb, file, ls")
-		fmt.Println("Uso: rep -name=<tipo_reporte> -path=<ruta_salida> -id=<id_particion> [-path_file_ls=<ruta>]")
-		return
-	}
-
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: rep -name=<tipo_reporte> -path=<ruta_salida> -id=<id_particion> [-path_file_ls=<ruta>]")
-		return
-	}
-
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es obligatorio")
-		fmt.Println("Uso: rep -name=<tipo_reporte> -path=<ruta_salida> -id=<id_particion> [-path_file_ls=<ruta>]")
-		return
-	}
-
-	// Validar que el tipo de reporte sea válido
-	validReports := []string{"mbr", "disk", "inode", "block", "bm_inode", "bm_block", "tree", "sb", "file", "ls", "journaling"}
-	reportType := strings.ToLower(*name)
-	isValid := false
-	for _, valid := range validReports {
-		if reportType == valid {
-			isValid = true
-			break
-		}
+func fn_fsck(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "fsck", params)
+	if err != nil {
+		return nil, err
 	}
+	id := args.String("id")
+	fix := args.Bool("fix")
 
-	if !isValid {
-		fmt.Printf("Error: Tipo de reporte '%s' no válido\n", *name)
-		fmt.Println("Valores válidos: mbr, disk, inode, block, bm_inode, bm_block, tree, sb, file, ls, journaling")
-		return
+	// Normalizar ID a mayúsculas para compatibilidad
+	normalizedID := strings.ToUpper(*id)
+
+	// Llamar la función
+	report, err := FileSystem.Fsck(ctx.Output, normalizedID, *fix)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"id": normalizedID, "fix": *fix, "report": report}, nil
+}
+
+func fn_rep(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "rep", params)
+	if err != nil {
+		return nil, err
 	}
+	name := args.String("name")
+	path := args.String("path")
+	id := args.String("id")
+	path_file_ls := args.String("path_file_ls")
+	rendererName := args.String("renderer")
+	formatName := args.String("format")
+
+	reportType := *name
 
 	// Validar que path_file_ls se use solo con reportes file y ls
 	if *path_file_ls != "" && reportType != "file" && reportType != "ls" {
-		fmt.Printf("Advertencia: El parámetro -path_file_ls solo funciona con reportes 'file' y 'ls', se ignorará para el reporte '%s'\n", reportType)
+		fmt.Fprintf(ctx.Output, "Advertencia: El parámetro -path_file_ls solo funciona con reportes 'file' y 'ls', se ignorará para el reporte '%s'\n", reportType)
 		*path_file_ls = ""
 	}
 
 	// Validar que para reportes file y ls se proporcione path_file_ls si es necesario
 	if (reportType == "file" || reportType == "ls") && *path_file_ls == "" {
-		fmt.Printf("Advertencia: Para el reporte '%s' se recomienda usar el parámetro -path_file_ls\n", reportType)
+		fmt.Fprintf(ctx.Output, "Advertencia: Para el reporte '%s' se recomienda usar el parámetro -path_file_ls\n", reportType)
+	}
+
+	// Validar el renderizador de la imagen
+	renderer, rendererErr := Reportes.ParseRenderer(*rendererName)
+	if rendererErr != nil {
+		fmt.Fprintf(ctx.Output, "Error: %v\n", rendererErr)
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "%v", rendererErr)
+	}
+
+	// Validar el formato de salida
+	format, formatErr := Reportes.ParseReportFormat(*formatName)
+	if formatErr != nil {
+		fmt.Fprintf(ctx.Output, "Error: %v\n", formatErr)
+		return nil, formatErr
 	}
 
 	// Normalizar ID a mayúsculas para compatibilidad
 	normalizedID := strings.ToUpper(*id)
 
-	fmt.Printf("Generando reporte '%s' con los siguientes parámetros:\n", reportType)
-	fmt.Printf("  - Ruta de salida: %s\n", *path)
-	fmt.Printf("  - ID partición: %s\n", normalizedID)
+	// Salida estructurada: los datos del reporte se escriben en -path
+	if format != Reportes.FormatImage {
+		if reportType == "file" && *path_file_ls == "" {
+			fmt.Fprintf(ctx.Output, "Error: Para el reporte FILE se requiere el parámetro -path_file_ls\n")
+			return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Para el reporte FILE se requiere el parámetro -path_file_ls")
+		}
+		if err := Reportes.WriteReportData(ctx.Output, reportType, *path, normalizedID, *path_file_ls, format); err != nil {
+			fmt.Fprintf(ctx.Output, "Error generando reporte %s: %v\n", strings.ToUpper(reportType), err)
+			return nil, err
+		}
+		return map[string]interface{}{"report": reportType, "path": *path, "id": normalizedID, "format": format}, nil
+	}
+
+	fmt.Fprintf(ctx.Output, "Generando reporte '%s' con los siguientes parámetros:\n", reportType)
+	fmt.Fprintf(ctx.Output, "  - Ruta de salida: %s\n", *path)
+	fmt.Fprintf(ctx.Output, "  - ID partición: %s\n", normalizedID)
+	fmt.Fprintf(ctx.Output, "  - Renderizador: %s\n", renderer)
 	if *path_file_ls != "" {
-		fmt.Printf("  - Archivo/Carpeta: %s\n", *path_file_ls)
+		fmt.Fprintf(ctx.Output, "  - Archivo/Carpeta: %s\n", *path_file_ls)
 	}
-	fmt.Println()
+	fmt.Fprintln(ctx.Output)
 
 	// Generar el reporte según el tipo
 	switch reportType {
 	case "mbr":
-		fmt.Printf("✓ Generando reporte MBR\n")
-		if err := Reportes.GenerateMBRReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte MBR: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte MBR\n")
+		err = Reportes.GenerateMBRReport(ctx.Output, *path, normalizedID, renderer)
 	case "disk":
-		fmt.Printf("✓ Generando reporte DISK\n")
-		if err := Reportes.GenerateDiskReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte DISK: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte DISK\n")
+		err = Reportes.GenerateDiskReport(ctx.Output, *path, normalizedID, renderer)
 	case "inode":
-		fmt.Printf("✓ Generando reporte INODE\n")
-		if err := Reportes.GenerateInodeReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte INODE: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte INODE\n")
+		err = Reportes.GenerateInodeReport(ctx.Output, *path, normalizedID, renderer)
 	case "block":
-		fmt.Printf("✓ Generando reporte BLOCK\n")
-		if err := Reportes.GenerateBlockReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte BLOCK: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte BLOCK\n")
+		err = Reportes.GenerateBlockReport(ctx.Output, *path, normalizedID, renderer)
 	case "bm_inode":
-		fmt.Printf("✓ Generando reporte BM_INODE\n")
-		if err := Reportes.GenerateBitmapInodeReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte BM_INODE: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte BM_INODE\n")
+		err = Reportes.GenerateBitmapInodeReport(ctx.Output, *path, normalizedID, renderer)
 	case "bm_block":
-		fmt.Printf("✓ Generando reporte BM_BLOCK\n")
-		if err := Reportes.GenerateBitmapBlockReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte BM_BLOCK: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte BM_BLOCK\n")
+		err = Reportes.GenerateBitmapBlockReport(ctx.Output, *path, normalizedID, renderer)
 	case "tree":
-		fmt.Printf("✓ Generando reporte TREE\n")
-		if err := Reportes.GenerateTreeReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte TREE: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte TREE\n")
+		err = Reportes.GenerateTreeReport(ctx.Output, *path, normalizedID, renderer)
 	case "sb":
-		fmt.Printf("✓ Generando reporte SB (SUPERBLOCK)\n")
-		if err := Reportes.GenerateSuperblockReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte SB: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte SB (SUPERBLOCK)\n")
+		err = Reportes.GenerateSuperblockReport(ctx.Output, *path, normalizedID, renderer)
 	case "file":
 		if *path_file_ls == "" {
-			fmt.Printf("Error: Para el reporte FILE se requiere el parámetro -path_file_ls\n")
-			fmt.Println("Uso: rep -name=file -path=<ruta_salida> -id=<id_particion> -path_file_ls=<ruta_archivo>")
-			return
-		}
-		fmt.Printf("✓ Generando reporte FILE\n")
-		if err := Reportes.GenerateFileReport(*path, normalizedID, *path_file_ls); err != nil {
-			fmt.Printf("Error generando reporte FILE: %v\n", err)
+			fmt.Fprintf(ctx.Output, "Error: Para el reporte FILE se requiere el parámetro -path_file_ls\n")
+			fmt.Fprintln(ctx.Output, "Uso: rep -name=file -path=<ruta_salida> -id=<id_particion> -path_file_ls=<ruta_archivo>")
+			return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Para el reporte FILE se requiere el parámetro -path_file_ls")
 		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte FILE\n")
+		err = Reportes.GenerateFileReport(ctx.Output, *path, normalizedID, *path_file_ls, renderer)
 	case "ls":
 		if *path_file_ls == "" {
 			*path_file_ls = "/" // Directorio raíz por defecto
 		}
-		fmt.Printf("✓ Generando reporte LS\n")
-		if err := Reportes.GenerateListReport(*path, normalizedID, *path_file_ls); err != nil {
-			fmt.Printf("Error generando reporte LS: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte LS\n")
+		err = Reportes.GenerateListReport(ctx.Output, *path, normalizedID, *path_file_ls, renderer)
 	case "journaling":
-		fmt.Printf("✓ Generando reporte JOURNALING\n")
-		if err := Reportes.GenerateJournalingReport(*path, normalizedID); err != nil {
-			fmt.Printf("Error generando reporte JOURNALING: %v\n", err)
-		}
+		fmt.Fprintf(ctx.Output, "✓ Generando reporte JOURNALING\n")
+		err = Reportes.GenerateJournalingReport(ctx.Output, *path, normalizedID, renderer)
 	default:
 		// Para otros tipos de reporte, mostrar que están pendientes
-		fmt.Printf("✓ Comando 'rep' reconocido correctamente para reporte tipo '%s'\n", reportType)
-		fmt.Println("  [Implementación de generación de reportes pendiente]")
+		fmt.Fprintf(ctx.Output, "✓ Comando 'rep' reconocido correctamente para reporte tipo '%s'\n", reportType)
+		fmt.Fprintln(ctx.Output, "  [Implementación de generación de reportes pendiente]")
 	}
-}
-
-func fn_info(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("info", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	id := fs.String("id", "", "Id de la partición montada")
 
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es requerido")
-		return
+	if err != nil {
+		fmt.Fprintf(ctx.Output, "Error generando reporte %s: %v\n", strings.ToUpper(reportType), err)
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error generando reporte %s: %v", strings.ToUpper(reportType), err)
 	}
-
-	// Llamar la función
-	FileSystem.ShowFileSystemInfo(*id)
+	return map[string]interface{}{"report": reportType, "path": *path, "id": normalizedID}, nil
 }
 
-func fn_ls(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("ls", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	id := fs.String("id", "", "Id de la partición montada")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es requerido")
-		return
+func fn_info(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "info", params)
+	if err != nil {
+		return nil, err
 	}
+	id := args.String("id")
 
 	// Llamar la función
-	FileSystem.ListRootDirectory(*id)
+	return nil, FileSystem.ShowFileSystemInfo(ctx.Output, *id)
 }
 
-func managementFlags(fs *flag.FlagSet, params string) {
-	// NO usar fs.Parse(os.Args[1:]) porque estamos en modo interactivo
-	
-	// Encontrar las banderas en el input
-	matches := re.FindAllStringSubmatch(params, -1)
-
-	// Obtener los nombres de todas las banderas
-	var flagNames []string
-	var boolFlags []string
-	fs.VisitAll(func(f *flag.Flag) {
-		flagNames = append(flagNames, f.Name)
-		// Detectar flags booleanos
-		if f.DefValue == "false" {
-			boolFlags = append(boolFlags, f.Name)
-		}
-	})
-
-	// Buscar flags booleanos simples (como -p, -r) sin valor asignado
-	boolRegex := regexp.MustCompile(`-(\w+)(?:\s|$)`)
-	boolMatches := boolRegex.FindAllStringSubmatch(params, -1)
-	
-	for _, match := range boolMatches {
-		flagName := match[1]
-		
-		// Verificar si es un flag booleano y no tiene valor asignado
-		if contains(boolFlags, flagName) {
-			// Verificar que no tenga = después
-			valueRegex := regexp.MustCompile(`-` + flagName + `=`)
-			if !valueRegex.MatchString(params) {
-				err := fs.Set(flagName, "true")
-				if err != nil {
-					fmt.Printf("Error estableciendo valor booleano para %s: %v\n", flagName, err)
-				}
-			}
-		}
-	}
-
-	// Procesar el comando ingresado con valores asignados
-	for _, match := range matches {
-		flagName := match[1]
-		flagValue := match[2]
-
-		// Remover comillas si existen
-		flagValue = strings.Trim(flagValue, "\"")
-
-		// Solo convertir a minúsculas parámetros específicos, NO paths
-		if strings.ToLower(flagName) == "unit" || strings.ToLower(flagName) == "fit" || strings.ToLower(flagName) == "type" || strings.ToLower(flagName) == "fs" {
-			flagValue = strings.ToLower(flagValue)
-		}
-
-		// Buscar el flag de manera case-insensitive
-		actualFlagName := ""
-		for _, fname := range flagNames {
-			if strings.ToLower(fname) == strings.ToLower(flagName) {
-				actualFlagName = fname
-				break
-			}
-		}
-
-		if actualFlagName != "" {
-			err := fs.Set(actualFlagName, flagValue)
-			if err != nil {
-				fmt.Printf("Error estableciendo valor para %s: %v\n", actualFlagName, err)
-			}
-		} else {
-			fmt.Println("Error: Bandera no encontrada:", flagName)
-		}
+func fn_ls(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "ls", params)
+	if err != nil {
+		return nil, err
 	}
-}
+	id := args.String("id")
 
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
-	}
-	return false
+	// Llamar la función
+	return nil, FileSystem.ListRootDirectory(ctx.Output, *id)
 }
 
-func fn_login(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("login", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	user := fs.String("user", "", "Nombre del usuario (obligatorio)")
-	pass := fs.String("pass", "", "Contraseña del usuario (obligatorio)")
-	id := fs.String("id", "", "ID de la partición montada (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *user == "" {
-		fmt.Println("Error: El parámetro -user es obligatorio")
-		fmt.Println("Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("Ejemplo: login -user=root -pass=123 -id=851A")
-		return
-	}
-	if *pass == "" {
-		fmt.Println("Error: El parámetro -pass es obligatorio")
-		fmt.Println("Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("Ejemplo: login -user=root -pass=123 -id=851A")
-		return
-	}
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es obligatorio")
-		fmt.Println("Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("Ejemplo: login -user=root -pass=123 -id=851A")
-		return
+func fn_login(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "login", params)
+	if err != nil {
+		return nil, err
 	}
+	user := args.String("user")
+	pass := args.String("pass")
+	id := args.String("id")
 
 	// Normalizar ID a mayúsculas para compatibilidad
 	normalizedID := strings.ToUpper(*id)
 
 	// Llamar la función
-	FileSystem.Login(*user, *pass, normalizedID)
+	session, err := FileSystem.Login(ctx.Output, ctx.Session, *user, *pass, normalizedID)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Session = session
+	return map[string]interface{}{
+		"username":     session.Username,
+		"user_id":      session.UserID,
+		"group_id":     session.GroupID,
+		"partition_id": session.PartitionID,
+		"token":        session.Token,
+	}, nil
 }
 
-func fn_logout(params string) {
+func fn_logout(ctx *Context, params string) (map[string]interface{}, error) {
 	// El comando logout no acepta parámetros
-	if strings.TrimSpace(params) != "" {
-		fmt.Println("Advertencia: El comando 'logout' no acepta parámetros. Los parámetros serán ignorados.")
+	if _, err := parseArgs(ctx.Output, "logout", params); err != nil {
+		return nil, err
 	}
-	
-	// Llamar la función
-	FileSystem.Logout()
-}
 
-func fn_mkgrp(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("mkgrp", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	name := fs.String("name", "", "Nombre del grupo (obligatorio)")
 
-	// obtener valores
-	managementFlags(fs, params)
+	// Llamar la función
+	if err := FileSystem.Logout(ctx.Output, ctx.Session); err != nil {
+		return nil, err
+	}
+	ctx.Session = nil
+	return nil, nil
+}
 
-	// Validar parámetros requeridos
-	if *name == "" {
-		fmt.Println("Error: El parámetro -name es obligatorio")
-		fmt.Println("Uso: mkgrp -name=<nombre_grupo>")
-		return
+func fn_mkgrp(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "mkgrp", params)
+	if err != nil {
+		return nil, err
 	}
+	name := args.String("name")
 
 	// Llamar la función
-	FileSystem.Mkgrp(*name)
+	if err := FileSystem.Mkgrp(ctx.Output, ctx.Session, *name); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"group": *name}, nil
 }
 
-func fn_rmgrp(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("rmgrp", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	name := fs.String("name", "", "Nombre del grupo a eliminar (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *name == "" {
-		fmt.Println("Error: El parámetro -name es obligatorio")
-		fmt.Println("Uso: rmgrp -name=<nombre_grupo>")
-		return
+func fn_rmgrp(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "rmgrp", params)
+	if err != nil {
+		return nil, err
 	}
+	name := args.String("name")
 
 	// Llamar la función
-	FileSystem.Rmgrp(*name)
+	return nil, FileSystem.Rmgrp(ctx.Output, ctx.Session, *name)
 }
 
-func fn_cat(params string) {
+func fn_cat(ctx *Context, params string) (map[string]interface{}, error) {
 	// Si no hay parámetros, mostrar users.txt como antes
 	if strings.TrimSpace(params) == "" {
-		fmt.Println("Advertencia: Sin parámetros especificados. Mostrará el contenido de users.txt de la sesión actual.")
-		FileSystem.CatUsersFile()
-		return
+		fmt.Fprintln(ctx.Output, "Advertencia: Sin parámetros especificados. Mostrará el contenido de users.txt de la sesión actual.")
+		return nil, FileSystem.CatUsersFile(ctx.Output, ctx.Session)
 	}
 	
-	// Definir flags para múltiples archivos
-	fs := flag.NewFlagSet("cat", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	// Crear variables para hasta 10 archivos (extensible si es necesario)
-	file1 := fs.String("file1", "", "Ruta del primer archivo")
-	file2 := fs.String("file2", "", "Ruta del segundo archivo")
-	file3 := fs.String("file3", "", "Ruta del tercer archivo")
-	file4 := fs.String("file4", "", "Ruta del cuarto archivo")
-	file5 := fs.String("file5", "", "Ruta del quinto archivo")
-	file6 := fs.String("file6", "", "Ruta del sexto archivo")
-	file7 := fs.String("file7", "", "Ruta del séptimo archivo")
-	file8 := fs.String("file8", "", "Ruta del octavo archivo")
-	file9 := fs.String("file9", "", "Ruta del noveno archivo")
-	file10 := fs.String("file10", "", "Ruta del décimo archivo")
-
-	// Obtener valores
-	managementFlags(fs, params)
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "cat", params)
+	if err != nil {
+		return nil, err
+	}
+	file1 := args.String("file1")
+	file2 := args.String("file2")
+	file3 := args.String("file3")
+	file4 := args.String("file4")
+	file5 := args.String("file5")
+	file6 := args.String("file6")
+	file7 := args.String("file7")
+	file8 := args.String("file8")
+	file9 := args.String("file9")
+	file10 := args.String("file10")
 
 	// Recopilar todas las rutas de archivos especificadas
 	var filePaths []string
@@ -772,481 +751,430 @@ func fn_cat(params string) {
 
 	// Verificar que se especificó al menos un archivo
 	if len(filePaths) == 0 {
-		fmt.Println("Error: Debe especificar al menos un archivo")
-		fmt.Println("Uso: cat -file1=/ruta/archivo1 [-file2=/ruta/archivo2] ...")
-		fmt.Println("Ejemplo: cat -file1=/home/user/docs/a.txt")
-		fmt.Println("Ejemplo: cat -file1=/home/a.txt -file2=/home/b.txt -file3=/home/c.txt")
-		return
+		fmt.Fprintln(ctx.Output, "Error: Debe especificar al menos un archivo")
+		fmt.Fprintln(ctx.Output, "Uso: cat -file1=/ruta/archivo1 [-file2=/ruta/archivo2] ...")
+		fmt.Fprintln(ctx.Output, "Ejemplo: cat -file1=/home/user/docs/a.txt")
+		fmt.Fprintln(ctx.Output, "Ejemplo: cat -file1=/home/a.txt -file2=/home/b.txt -file3=/home/c.txt")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Debe especificar al menos un archivo")
 	}
 
 	// Llamar la función de cat con múltiples archivos
-	FileSystem.Cat(filePaths)
+	return nil, FileSystem.Cat(ctx.Output, ctx.Session, filePaths)
 }
 
-func fn_mkusr(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("mkusr", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	user := fs.String("user", "", "Nombre del usuario (obligatorio, máximo 10 caracteres)")
-	pass := fs.String("pass", "", "Contraseña del usuario (obligatorio, máximo 10 caracteres)")
-	grp := fs.String("grp", "", "Grupo del usuario (obligatorio, máximo 10 caracteres)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *user == "" {
-		fmt.Println("Error: El parámetro -user es obligatorio")
-		fmt.Println("Uso: mkusr -user=<nombre_usuario> -pass=<contraseña> -grp=<nombre_grupo>")
-		return
-	}
-	if *pass == "" {
-		fmt.Println("Error: El parámetro -pass es obligatorio")
-		fmt.Println("Uso: mkusr -user=<nombre_usuario> -pass=<contraseña> -grp=<nombre_grupo>")
-		return
-	}
-	if *grp == "" {
-		fmt.Println("Error: El parámetro -grp es obligatorio")
-		fmt.Println("Uso: mkusr -user=<nombre_usuario> -pass=<contraseña> -grp=<nombre_grupo>")
-		return
+func fn_mkusr(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "mkusr", params)
+	if err != nil {
+		return nil, err
 	}
+	user := args.String("user")
+	pass := args.String("pass")
+	grp := args.String("grp")
 
 	// Llamar la función
-	FileSystem.Mkusr(*user, *pass, *grp)
+	if err := FileSystem.Mkusr(ctx.Output, ctx.Session, *user, *pass, *grp); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"user": *user, "group": *grp}, nil
 }
 
-func fn_rmusr(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("rmusr", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	user := fs.String("user", "", "Nombre del usuario a eliminar (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *user == "" {
-		fmt.Println("Error: El parámetro -user es obligatorio")
-		fmt.Println("Uso: rmusr -user=<nombre_usuario>")
-		return
+func fn_rmusr(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "rmusr", params)
+	if err != nil {
+		return nil, err
 	}
+	user := args.String("user")
 
 	// Llamar la función
-	FileSystem.Rmusr(*user)
+	return nil, FileSystem.Rmusr(ctx.Output, ctx.Session, *user)
 }
 
-func fn_chgrp(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("chgrp", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	user := fs.String("user", "", "Nombre del usuario al que cambiar el grupo (obligatorio)")
-	grp := fs.String("grp", "", "Nombre del nuevo grupo (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *user == "" {
-		fmt.Println("Error: El parámetro -user es obligatorio")
-		fmt.Println("Uso: chgrp -user=<nombre_usuario> -grp=<nombre_grupo>")
-		fmt.Println("Ejemplo: chgrp -user=juan -grp=administradores")
-		return
-	}
-	if *grp == "" {
-		fmt.Println("Error: El parámetro -grp es obligatorio")
-		fmt.Println("Uso: chgrp -user=<nombre_usuario> -grp=<nombre_grupo>")
-		fmt.Println("Ejemplo: chgrp -user=juan -grp=administradores")
-		return
+func fn_chgrp(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "chgrp", params)
+	if err != nil {
+		return nil, err
 	}
+	user := args.String("user")
+	grp := args.String("grp")
 
 	// Llamar la función
-	FileSystem.Chgrp(*user, *grp)
+	if err := FileSystem.Chgrp(ctx.Output, ctx.Session, *user, *grp); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"user": *user, "group": *grp}, nil
 }
 
-func fn_mkfile(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("mkfile", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del archivo a crear (obligatorio)")
-	r := fs.Bool("r", false, "Crear directorios padre si no existen")
-	size := fs.Int("size", 0, "Tamaño en bytes del archivo (opcional)")
-	cont := fs.String("cont", "", "Archivo con contenido a copiar (opcional)")
-
-	// obtener valores
-	managementFlags(fs, params)
+func fn_passwd(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "passwd", params)
+	if err != nil {
+		return nil, err
+	}
+	user := args.String("user")
+	old := args.String("old")
+	pass := args.String("pass")
 
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: mkfile -path=<ruta_archivo> [-r] [-size=<tamaño>] [-cont=<archivo_contenido>]")
-		fmt.Println("Ejemplo: mkfile -path=/test.txt -size=10")
-		fmt.Println("Ejemplo: mkfile -path=/archivo.txt -cont=/home/user/documento.txt")
-		fmt.Println("Ejemplo: mkfile -path=/home/user/docs/archivo.txt -r -size=100")
-		return
+	// Llamar la función
+	if err := FileSystem.Passwd(ctx.Output, ctx.Session, *user, *old, *pass); err != nil {
+		return nil, err
+	}
+	username := *user
+	if username == "" && ctx.Session != nil {
+		username = ctx.Session.Username
 	}
+	return map[string]interface{}{"user": username}, nil
+}
 
-	// Validar que el tamaño no sea negativo
-	if *size < 0 {
-		fmt.Println("Error: El tamaño del archivo no puede ser negativo")
-		fmt.Println("Uso: mkfile -path=<ruta_archivo> [-r] [-size=<tamaño>] [-cont=<archivo_contenido>]")
-		return
+func fn_mkfile(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "mkfile", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	r := args.Bool("r")
+	size := args.Int("size")
+	cont := args.String("cont")
 
 	// Llamar la función
-	FileSystem.Mkfile(*path, *r, *size, *cont)
+	inode, err := FileSystem.Mkfile(ctx.Output, ctx.Session, *path, *r, *size, *cont)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"path": *path, "inode": inode}, nil
 }
 
-func fn_mkdir(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("mkdir", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del directorio a crear (obligatorio)")
-	p := fs.Bool("p", false, "Crear directorios padre si no existen")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: mkdir -path=<ruta_directorio> [-p]")
-		fmt.Println("Ejemplo: mkdir -path=/docs")
-		fmt.Println("Ejemplo: mkdir -path=/home/user/documents -p")
-		return
+func fn_mkdir(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "mkdir", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	p := args.Bool("p")
 
 	// Llamar la función
-	FileSystem.Mkdir(*path, *p)
+	inode, err := FileSystem.Mkdir(ctx.Output, ctx.Session, *path, *p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"path": *path, "inode": inode}, nil
 }
 
-func fn_remove(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("remove", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del archivo o directorio a eliminar (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: remove -path=<ruta>")
-		fmt.Println("Ejemplo: remove -path=/home/user/docs/a.txt")
-		fmt.Println("Ejemplo: remove -path=\"/carpeta con espacios/archivo.txt\"")
-		return
+func fn_remove(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "remove", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
 
 	// Llamar la función
-	FileSystem.Remove(*path)
+	return nil, FileSystem.Remove(ctx.Output, ctx.Session, *path)
 }
 
-func fn_edit(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del archivo a editar (obligatorio)")
-	contenido := fs.String("contenido", "", "Ruta del archivo local con el nuevo contenido (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: edit -path=<ruta_archivo> -contenido=<archivo_local>")
-		fmt.Println("Ejemplo: edit -path=/home/user/docs/a.txt -contenido=/root/user/files/a.txt")
-		return
-	}
-
-	if *contenido == "" {
-		fmt.Println("Error: El parámetro -contenido es obligatorio")
-		fmt.Println("Uso: edit -path=<ruta_archivo> -contenido=<archivo_local>")
-		fmt.Println("Ejemplo: edit -path=/home/user/docs/a.txt -contenido=/root/user/files/a.txt")
-		return
+func fn_edit(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "edit", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	contenido := args.String("contenido")
 
 	// Llamar la función
-	FileSystem.Edit(*path, *contenido)
+	return nil, FileSystem.Edit(ctx.Output, ctx.Session, *path, *contenido)
 }
 
-func fn_rename(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("rename", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del archivo o directorio a renombrar (obligatorio)")
-	name := fs.String("name", "", "Nuevo nombre para el archivo o directorio (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
+func fn_write(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "write", params)
+	if err != nil {
+		return nil, err
+	}
+	path := args.String("path")
+	offset := args.Int("offset")
+	appendMode := args.Bool("append")
+	contenido := args.String("contenido")
+	texto := args.String("texto")
+
+	if (*contenido == "") == (*texto == "") {
+		fmt.Fprintln(ctx.Output, "Error: Debe indicar -contenido o -texto (solo uno)")
+		fmt.Fprintln(ctx.Output, "Uso: write -path=<ruta_archivo> [-offset=<n> | -append] (-contenido=<archivo_local> | -texto=<texto>)")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Debe indicar -contenido o -texto (solo uno)")
+	}
+
+	content := []byte(*texto)
+	if *contenido != "" {
+		data, err := os.ReadFile(*contenido)
+		if err != nil {
+			fmt.Fprintf(ctx.Output, "Error leyendo archivo de contenido '%s': %v\n", *contenido, err)
+			return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "Error leyendo archivo de contenido '%s': %v", *contenido, err)
+		}
+		content = data
+	}
 
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: rename -path=<ruta> -name=<nuevo_nombre>")
-		fmt.Println("Ejemplo: rename -path=/home/user/docs/a.txt -name=b1.txt")
-		return
+	// Llamar la función
+	written, err := FileSystem.Write(ctx.Output, ctx.Session, *path, *offset, *appendMode, content)
+	if err != nil {
+		return nil, err
 	}
+	return map[string]interface{}{"path": written.Path, "offset": written.Offset, "length": written.Length, "size": written.Size}, nil
+}
 
-	if *name == "" {
-		fmt.Println("Error: El parámetro -name es obligatorio")
-		fmt.Println("Uso: rename -path=<ruta> -name=<nuevo_nombre>")
-		fmt.Println("Ejemplo: rename -path=/home/user/docs/a.txt -name=b1.txt")
-		return
+func fn_rename(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "rename", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	name := args.String("name")
 
 	// Llamar la función
-	FileSystem.Rename(*path, *name)
+	return nil, FileSystem.Rename(ctx.Output, ctx.Session, *path, *name)
 }
 
-func fn_copy(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("copy", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del archivo o directorio a copiar (obligatorio)")
-	destino := fs.String("destino", "", "Ruta de destino donde se copiará (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: copy -path=<ruta_origen> -destino=<ruta_destino>")
-		fmt.Println("Ejemplo: copy -path=/home/user/documents -destino=/home/images")
-		return
-	}
-
-	if *destino == "" {
-		fmt.Println("Error: El parámetro -destino es obligatorio")
-		fmt.Println("Uso: copy -path=<ruta_origen> -destino=<ruta_destino>")
-		fmt.Println("Ejemplo: copy -path=/home/user/documents -destino=/home/images")
-		return
+func fn_copy(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "copy", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	destino := args.String("destino")
 
 	// Llamar la función
-	FileSystem.Copy(*path, *destino)
+	return nil, FileSystem.Copy(ctx.Output, ctx.Session, *path, *destino)
 }
 
-func fn_move(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("move", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del archivo o directorio a mover (obligatorio)")
-	destino := fs.String("destino", "", "Ruta de destino donde se moverá (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: move -path=<ruta_origen> -destino=<ruta_destino>")
-		fmt.Println("Ejemplo: move -path=/home/user/documents -destino=/home/backup")
-		return
-	}
-
-	if *destino == "" {
-		fmt.Println("Error: El parámetro -destino es obligatorio")
-		fmt.Println("Uso: move -path=<ruta_origen> -destino=<ruta_destino>")
-		fmt.Println("Ejemplo: move -path=/home/user/documents -destino=/home/backup")
-		return
+func fn_export(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "export", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	destino := args.String("destino")
 
 	// Llamar la función
-	FileSystem.Move(*path, *destino)
+	summary, err := FileSystem.Export(ctx.Output, ctx.Session, *path, *destino)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"path": *path, "destino": *destino, "summary": summary}, nil
 }
 
-func fn_find(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("find", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta donde iniciar la búsqueda (obligatorio)")
-	name := fs.String("name", "", "Patrón de búsqueda con soporte para ? y * (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
+func fn_import(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "import", params)
+	if err != nil {
+		return nil, err
+	}
+	path := args.String("path")
+	destino := args.String("destino")
 
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: find -path=<ruta_búsqueda> -name=<patrón>")
-		fmt.Println("Ejemplo: find -path=/home -name=*.txt")
-		fmt.Println("Comodines: ? (un carácter), * (uno o más caracteres)")
-		return
+	// Llamar la función
+	summary, err := FileSystem.Import(ctx.Output, ctx.Session, *path, *destino)
+	if err != nil {
+		return nil, err
 	}
+	return map[string]interface{}{"path": *path, "destino": *destino, "summary": summary}, nil
+}
 
-	if *name == "" {
-		fmt.Println("Error: El parámetro -name es obligatorio")
-		fmt.Println("Uso: find -path=<ruta_búsqueda> -name=<patrón>")
-		fmt.Println("Ejemplo: find -path=/home -name=?.txt")
-		fmt.Println("Comodines: ? (un carácter), * (uno o más caracteres)")
-		return
+func fn_move(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "move", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	destino := args.String("destino")
 
 	// Llamar la función
-	FileSystem.Find(*path, *name)
+	return nil, FileSystem.Move(ctx.Output, ctx.Session, *path, *destino)
 }
 
-func fn_chown(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("chown", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del archivo o directorio (obligatorio)")
-	r := fs.Bool("r", false, "Cambiar propietario recursivamente (opcional)")
-	usuario := fs.String("usuario", "", "Nombre del nuevo propietario (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
+func fn_find(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "find", params)
+	if err != nil {
+		return nil, err
+	}
+	path := args.String("path")
+	name := args.String("name")
 
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: chown -path=<ruta> -usuario=<usuario> [-r]")
-		fmt.Println("Ejemplo: chown -path=/home -usuario=user2 -r")
-		return
+	// Llamar la función
+	results, err := FileSystem.Find(ctx.Output, ctx.Session, *path, *name)
+	if err != nil {
+		return nil, err
 	}
+	return map[string]interface{}{"matches": results}, nil
+}
 
-	if *usuario == "" {
-		fmt.Println("Error: El parámetro -usuario es obligatorio")
-		fmt.Println("Uso: chown -path=<ruta> -usuario=<usuario> [-r]")
-		fmt.Println("Ejemplo: chown -path=/home/file.txt -usuario=user1")
-		return
+func fn_chown(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "chown", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	r := args.Bool("r")
+	usuario := args.String("usuario")
 
 	// Llamar la función
-	FileSystem.Chown(*path, *r, *usuario)
+	return nil, FileSystem.Chown(ctx.Output, ctx.Session, *path, *r, *usuario)
 }
 
-func fn_chmod(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("chmod", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	path := fs.String("path", "", "Ruta del archivo o directorio (obligatorio)")
-	ugo := fs.String("ugo", "", "Permisos en formato [0-7][0-7][0-7] (obligatorio)")
-	r := fs.Bool("r", false, "Cambiar permisos recursivamente (opcional)")
+func fn_chmod(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "chmod", params)
+	if err != nil {
+		return nil, err
+	}
+	path := args.String("path")
+	ugo := args.String("ugo")
+	r := args.Bool("r")
 
-	// obtener valores
-	managementFlags(fs, params)
+	// Llamar la función
+	return nil, FileSystem.Chmod(ctx.Output, ctx.Session, *path, *ugo, *r)
+}
 
-	// Validar parámetros requeridos
-	if *path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("Uso: chmod -path=<ruta> -ugo=<permisos> [-r]")
-		fmt.Println("Ejemplo: chmod -path=/home -ugo=764 -r")
-		return
+func fn_loss(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "loss", params)
+	if err != nil {
+		return nil, err
 	}
+	id := args.String("id")
 
-	if *ugo == "" {
-		fmt.Println("Error: El parámetro -ugo es obligatorio")
-		fmt.Println("Uso: chmod -path=<ruta> -ugo=<permisos> [-r]")
-		fmt.Println("Formato: -ugo=[0-7][0-7][0-7] (Usuario, Grupo, Otros)")
-		fmt.Println("Ejemplo: chmod -path=/home/file.txt -ugo=777")
-		return
-	}
+	// Normalizar ID a mayúsculas para compatibilidad
+	normalizedID := strings.ToUpper(*id)
 
 	// Llamar la función
-	FileSystem.Chmod(*path, *ugo, *r)
+	return nil, FileSystem.Loss(ctx.Output, normalizedID)
 }
 
-func fn_loss(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("loss", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	id := fs.String("id", "", "ID de la partición a formatear (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es obligatorio")
-		fmt.Println("Uso: loss -id=<id>")
-		fmt.Println("Ejemplo: loss -id=851A")
-		fmt.Println("\nEste comando simula un fallo en el disco formateando:")
-		fmt.Println("  - Bitmap de Inodos")
-		fmt.Println("  - Bitmap de Bloques")
-		fmt.Println("  - Área de Inodos")
-		fmt.Println("  - Área de Bloques")
-		return
+func fn_recovery(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "recovery", params)
+	if err != nil {
+		return nil, err
 	}
+	id := args.String("id")
 
 	// Normalizar ID a mayúsculas para compatibilidad
 	normalizedID := strings.ToUpper(*id)
 
 	// Llamar la función
-	FileSystem.Loss(normalizedID)
+	// Una recuperación incompleta retorna el error junto con lo reproducido
+	recovery, err := FileSystem.Recovery(ctx.Output, normalizedID)
+	if recovery == nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":           normalizedID,
+		"entries":      recovery.Entries,
+		"complete":     recovery.Complete,
+		"not_replayed": recovery.NotReplayed,
+	}, err
 }
 
-func fn_recovery(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("recovery", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	id := fs.String("id", "", "ID de la partición a recuperar (obligatorio)")
-
-	// obtener valores
-	managementFlags(fs, params)
-
-	// Validar parámetros requeridos
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es obligatorio")
-		fmt.Println("Uso: recovery -id=<id>")
-		fmt.Println("Ejemplo: recovery -id=851A")
-		fmt.Println("\nEste comando recupera el sistema de archivos EXT3 usando el journaling")
-		fmt.Println("Restaura el sistema a un estado consistente antes del último formateo")
-		return
+func fn_journaling(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "journaling", params)
+	if err != nil {
+		return nil, err
 	}
+	id := args.String("id")
 
 	// Normalizar ID a mayúsculas para compatibilidad
 	normalizedID := strings.ToUpper(*id)
 
+	// Generar el reporte en la carpeta de reportes por defecto
+	reportPath := "/home/jose/Documentos/proyecto2/reportes/journaling_report"
+	fmt.Fprintf(ctx.Output, "✓ Generando reporte JOURNALING en: %s\n", reportPath)
+	err = Reportes.GenerateJournalingReport(ctx.Output, reportPath, normalizedID, Reportes.RendererNative)
+	if err != nil {
+		fmt.Fprintf(ctx.Output, "Error al generar reporte de journaling: %v\n", err)
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error al generar reporte de journaling: %v", err)
+	}
+	return map[string]interface{}{"report": "journaling", "path": reportPath}, nil
+}
+
+func fn_mksnapshot(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "mksnapshot", params)
+	if err != nil {
+		return nil, err
+	}
+	path := args.String("path")
+	id := args.String("id")
+	name := args.String("name")
+
+	if *path == "" && *id == "" {
+		fmt.Fprintln(ctx.Output, "Uso: mksnapshot -path=<disco> -name=<nombre>  o  mksnapshot -id=<id> -name=<nombre>")
+	}
+
 	// Llamar la función
-	FileSystem.Recovery(normalizedID)
+	snapshot, err := DiskManagement.CreateSnapshot(ctx.Output, *path, strings.ToUpper(*id), *name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"snapshot": snapshot}, nil
 }
 
-func fn_journaling(params string) {
-	// Definir banderas
-	fs := flag.NewFlagSet("journaling", flag.ContinueOnError)
-	fs.SetOutput(os.Stdout)
-	
-	id := fs.String("id", "", "ID de la partición (obligatorio)")
+func fn_snapshots(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "snapshots", params)
+	if err != nil {
+		return nil, err
+	}
+	path := args.String("path")
+	id := args.String("id")
 
-	// obtener valores
-	managementFlags(fs, params)
+	// Llamar la función
+	snapshots, err := DiskManagement.ListSnapshots(ctx.Output, *path, strings.ToUpper(*id))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"snapshots": snapshots}, nil
+}
 
-	// Validar parámetros requeridos
-	if *id == "" {
-		fmt.Println("Error: El parámetro -id es obligatorio")
-		fmt.Println("Uso: journaling -id=<id>")
-		fmt.Println("Ejemplo: journaling -id=851A")
-		fmt.Println("\nEste comando genera un reporte del journaling mostrando todas las transacciones")
-		return
+func fn_rollback(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "rollback", params)
+	if err != nil {
+		return nil, err
 	}
+	path := args.String("path")
+	id := args.String("id")
+	name := args.String("name")
 
-	// Normalizar ID a mayúsculas para compatibilidad
-	normalizedID := strings.ToUpper(*id)
+	// Llamar la función
+	snapshot, discarded, err := DiskManagement.RollbackSnapshot(ctx.Output, *path, strings.ToUpper(*id), *name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"snapshot": snapshot, "discarded": discarded}, nil
+}
 
-	// Generar el reporte en la carpeta de reportes por defecto
-	reportPath := "/home/jose/Documentos/proyecto2/reportes/journaling_report"
-	fmt.Printf("✓ Generando reporte JOURNALING en: %s\n", reportPath)
-	err := Reportes.GenerateJournalingReport(reportPath, normalizedID)
+func fn_rmsnapshot(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "rmsnapshot", params)
 	if err != nil {
-		fmt.Printf("Error al generar reporte de journaling: %v\n", err)
-		return
+		return nil, err
+	}
+	path := args.String("path")
+	id := args.String("id")
+	name := args.String("name")
+
+	// Llamar la función
+	if err := DiskManagement.DeleteSnapshot(ctx.Output, *path, strings.ToUpper(*id), *name); err != nil {
+		return nil, err
 	}
+	return map[string]interface{}{"name": *name}, nil
 }
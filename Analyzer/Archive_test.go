@@ -0,0 +1,128 @@
+package Analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"testing"
+)
+
+// setupArchiveTree crea en la partición una carpeta /docs con archivos de
+// texto y binarios, una subcarpeta, permisos y un archivo de ana
+func setupArchiveTree(t *testing.T, dir string, ctx *Context) map[string][]byte {
+	t.Helper()
+	mustRun(t, ctx, "mkgrp -name=devs")
+	mustRun(t, ctx, "mkusr -user=ana -pass=123 -grp=devs")
+	binary := []byte{0x00, 0xff, 0x10, 0x80, 0xfe}
+	source := filepath.Join(dir, "binario.bin")
+	if err := os.WriteFile(source, binary, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, ctx, "mkfile -r -path=/docs/notas/a.txt -size=30")
+	mustRun(t, ctx, "mkfile -path=/docs/largo.txt -size=2000")
+	mustRun(t, ctx, fmt.Sprintf(`mkfile -path=/docs/binario.bin -cont="%s"`, source))
+	mustRun(t, ctx, "chmod -path=/docs/notas/a.txt -ugo=640")
+	mustRun(t, ctx, "chown -path=/docs/largo.txt -usuario=ana")
+	return map[string][]byte{
+		"notas/a.txt": []byte(fileContent(30)),
+		"largo.txt":   []byte(fileContent(2000)),
+		"binario.bin": binary,
+	}
+}
+
+// checkImported compara los archivos importados en base/docs con los
+// originales de /docs: contenido, permisos y dueño
+func checkImported(t *testing.T, srcID string, dstID string, base string, files map[string][]byte) {
+	t.Helper()
+	for name, want := range files {
+		got, err := FileSystem.GetFileContent(dstID, base+"/docs/"+name)
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s/docs/%s: contenido distinto (%v)", base, name, err)
+			continue
+		}
+		original, imported := findNode(t, srcID, "/docs/"+name), findNode(t, dstID, base+"/docs/"+name)
+		if imported.Permissions != original.Permissions || imported.OwnerID != original.OwnerID {
+			t.Errorf("%s/docs/%s: permisos %s y dueño %d, se esperaba %s y %d", base, name, imported.Permissions, imported.OwnerID, original.Permissions, original.OwnerID)
+		}
+	}
+	if node := findNode(t, dstID, base+"/docs/notas"); !node.IsDirectory {
+		t.Errorf("%s/docs/notas no es una carpeta", base)
+	}
+}
+
+// Exportar a un tar e importarlo en otra partición conserva contenido,
+// permisos y dueños. La carpeta exportada va con su nombre.
+func TestExportImportTar(t *testing.T) {
+	dir := useTempState(t)
+	srcID, srcSession := setupPartition(t, dir, "Origen", "-fs=2fs")
+	files := setupArchiveTree(t, dir, NewContext(srcSession))
+	dstID, dstSession := setupPartition(t, dir, "Destino", "-fs=3fs")
+	dst := NewContext(dstSession)
+	mustRun(t, dst, "mkgrp -name=devs")
+	mustRun(t, dst, "mkusr -user=ana -pass=123 -grp=devs")
+
+	archive := filepath.Join(dir, "docs.tar")
+	result := mustRun(t, NewContext(srcSession), fmt.Sprintf(`export -path=/docs -destino="%s"`, archive))
+	if summary := result.Data["summary"].(*FileSystem.ArchiveSummary); summary.Format != "tar" || summary.Files != 3 || summary.Directories != 2 {
+		t.Errorf("export: %+v", summary)
+	}
+
+	result = mustRun(t, dst, fmt.Sprintf(`import -path="%s" -destino=/restaurado`, archive))
+	if summary := result.Data["summary"].(*FileSystem.ArchiveSummary); summary.Files != 3 || summary.Directories != 2 || summary.Skipped != 0 {
+		t.Errorf("import: %+v", summary)
+	}
+	checkImported(t, srcID, dstID, "/restaurado", files)
+}
+
+// Exportar a un directorio del host escribe los archivos con sus permisos, e
+// importar ese directorio los vuelve a crear
+func TestExportImportDirectory(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	files := setupArchiveTree(t, dir, ctx)
+
+	host := filepath.Join(dir, "exportado")
+	mustRun(t, ctx, fmt.Sprintf(`export -path=/docs -destino="%s"`, host))
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(host, "docs", filepath.FromSlash(name)))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s en el host: contenido distinto (%v)", name, err)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(host, "docs", "notas", "a.txt")); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("notas/a.txt en el host: %v %v, se esperaba -rw-r-----", info.Mode(), err)
+	}
+
+	// Desde un directorio del host el dueño es quien importa
+	mustRun(t, ctx, fmt.Sprintf(`import -path="%s" -destino=/copia`, host))
+	for name, want := range files {
+		if got, err := FileSystem.GetFileContent(id, "/copia/docs/"+name); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("/copia/docs/%s: contenido distinto (%v)", name, err)
+		}
+	}
+	if node := findNode(t, id, "/copia/docs/notas/a.txt"); node.Permissions != "640" {
+		t.Errorf("/copia/docs/notas/a.txt: permisos %s, se esperaba 640", node.Permissions)
+	}
+}
+
+// Un usuario sin permiso de lectura no exporta esos archivos
+func TestExportSkipsUnreadable(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	setupArchiveTree(t, dir, NewContext(session))
+	// largo.txt es de ana; notas/a.txt es de root
+	mustRun(t, NewContext(session), "chmod -path=/docs/largo.txt -ugo=600")
+	mustRun(t, NewContext(session), "chmod -path=/docs/notas/a.txt -ugo=600")
+
+	ctx := NewContext(nil)
+	mustRun(t, ctx, "login -user=ana -pass=123 -id="+id)
+	archive := filepath.Join(dir, "ana.tar")
+	result := mustRun(t, ctx, fmt.Sprintf(`export -path=/docs -destino="%s"`, archive))
+	summary := result.Data["summary"].(*FileSystem.ArchiveSummary)
+	if summary.Files != 2 || summary.Skipped != 1 {
+		t.Errorf("export como ana: %+v, se esperaban 2 archivos y 1 omitido", summary)
+	}
+}
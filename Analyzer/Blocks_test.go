@@ -0,0 +1,45 @@
+package Analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Utilities"
+	"testing"
+)
+
+// Un archivo de más de un megabyte llega al apuntador triple indirecto y se
+// lee completo; uno más grande que MaxFileSize se rechaza
+func TestMkfileLargeFile(t *testing.T) {
+	dir := useTempState(t)
+	disk := filepath.Join(dir, "Disco.mia")
+	ctx := NewContext(nil)
+	mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=6 -unit=m -path="%s"`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=5 -unit=m -path="%s" -name=P1`, disk))
+	mounted := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P1`, disk))
+	id := mounted.Data["id"].(string)
+	mustRun(t, ctx, "mkfs -fs=2fs -id="+id)
+	mustRun(t, ctx, "login -user=root -pass=123 -id="+id)
+
+	const size = 1500000
+	mustRun(t, ctx, fmt.Sprintf("mkfile -path=/grande.txt -size=%d", size))
+	content, err := FileSystem.GetFileContent(id, "/grande.txt")
+	if err != nil || string(content) != fileContent(size) {
+		t.Errorf("/grande.txt: %d bytes leídos de %d (%v)", len(content), size, err)
+	}
+	sb, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inode := readInode(t, disk, sb, findNode(t, id, "/grande.txt").Inode); inode.I_block[14] == -1 {
+		t.Errorf("/grande.txt no usa el apuntador triple indirecto: %v", inode.I_block)
+	}
+	if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+		t.Errorf("fsck encontró inconsistencias: %+v", report.Issues)
+	}
+
+	result := runCommand(ctx, fmt.Sprintf("mkfile -path=/maximo.txt -size=%d", FileSystem.MaxFileSize+1))
+	if result.Status != "error" || result.Code != Utilities.ErrNoSpace {
+		t.Errorf("mkfile de %d bytes: estado %s, código %s", FileSystem.MaxFileSize+1, result.Status, result.Code)
+	}
+}
@@ -0,0 +1,279 @@
+package Analyzer
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"proyecto1/DiskManagement"
+	"proyecto1/FileSystem"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ============================================================================
+// AUTOCOMPLETADO PARA LA TERMINAL INTERACTIVA
+// ============================================================================
+
+// Complete calcula cómo completar la última palabra de line (el texto antes
+// del cursor). Retorna la posición, en runas, donde empieza la palabra y las
+// palabras completas que pueden reemplazarla:
+//   - la primera palabra se completa con los nombres de los comandos
+//   - -nom se completa con los parámetros del comando que aún no se usaron
+//   - -nombre=val se completa con los valores permitidos, los IDs montados o
+//     las rutas del host o de la partición, según el parámetro
+func Complete(ctx *Context, line string) (int, []string) {
+	runes := []rune(line)
+	start := wordStart(runes)
+	word := string(runes[start:])
+
+	before := strings.TrimSpace(string(runes[:start]))
+	if before == "" {
+		return start, completeCommand(word)
+	}
+
+	command, params := getCommandAndParams(before)
+	schema := findSchema(command)
+	if schema == nil || !strings.HasPrefix(word, "-") {
+		return start, nil
+	}
+
+	// Parámetros ya escritos en la línea
+	used := make(map[string]string)
+	tokens, _ := tokenizeParams(params)
+	for _, token := range tokens {
+		used[token.name] = token.value
+	}
+
+	separator := strings.Index(word, "=")
+	if separator < 0 {
+		return start, completeParamName(schema, used, word)
+	}
+
+	name := strings.ToLower(word[1:separator])
+	spec := schema.param(name)
+	if spec == nil {
+		return start, nil
+	}
+	prefix := word[:separator+1]
+	quote, value := unquotePartial(word[separator+1:])
+
+	var candidates []string
+	switch {
+	case len(spec.values) > 0:
+		candidates = matchValues(spec.values, value)
+	case command == "help" && name == "command":
+		candidates = completeCommand(value)
+	case name == "id" && spec.upper:
+		candidates = matchValues(DiskManagement.GetMountedIDs(), value)
+	case spec.path == hostPath:
+		return start, quotePaths(prefix, quote, completeHostPath(value))
+	case spec.path == partitionPath:
+		return start, quotePaths(prefix, quote, completePartitionPath(ctx, used["id"], value))
+	}
+	for i := range candidates {
+		candidates[i] = prefix + candidates[i]
+	}
+	return start, candidates
+}
+
+// wordStart retorna dónde empieza la última palabra, ignorando los espacios
+// que estén entre comillas o escapados
+func wordStart(runes []rune) int {
+	start := 0
+	var quote rune
+	escaped := false
+	for i, r := range runes {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case unicode.IsSpace(r):
+			start = i + 1
+		}
+	}
+	return start
+}
+
+// completeCommand retorna los comandos que empiezan con el prefijo
+func completeCommand(prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	var candidates []string
+	for _, schema := range commandSchemas {
+		if strings.HasPrefix(schema.name, prefix) {
+			candidates = append(candidates, schema.name)
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// completeParamName retorna los parámetros que empiezan con el prefijo y que
+// no se han usado. Los que llevan valor se completan con el =.
+func completeParamName(schema *commandSchema, used map[string]string, prefix string) []string {
+	prefix = strings.ToLower(prefix)
+	var candidates []string
+	for _, spec := range schema.params {
+		if _, exists := used[spec.name]; exists {
+			continue
+		}
+		candidate := "-" + spec.name
+		if spec.kind != paramBool {
+			candidate += "="
+		}
+		if strings.HasPrefix(candidate, prefix) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// matchValues retorna los valores que empiezan con el prefijo, sin importar
+// mayúsculas
+func matchValues(values []string, prefix string) []string {
+	var candidates []string
+	for _, value := range values {
+		if strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix)) {
+			candidates = append(candidates, value)
+		}
+	}
+	return candidates
+}
+
+// completeHostPath lista los archivos del host que continúan la ruta. Las
+// carpetas terminan en /.
+func completeHostPath(value string) []string {
+	dir, base := filepath.Split(value)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		isDir := entry.IsDir()
+		if !isDir && entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(readDir, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if isDir {
+			name += "/"
+		}
+		candidates = append(candidates, dir+name)
+	}
+	return candidates
+}
+
+// completePartitionPath lista los archivos y carpetas de la partición que
+// continúan la ruta. Usa la partición de -id o, si no se indicó, la de la
+// sesión activa.
+func completePartitionPath(ctx *Context, id string, value string) []string {
+	partitionID := strings.ToUpper(id)
+	if partitionID == "" && ctx != nil && ctx.Session != nil {
+		partitionID = ctx.Session.PartitionID
+	}
+	if partitionID == "" {
+		return nil
+	}
+	if _, mounted := DiskManagement.GetMountedPartition(partitionID); !mounted {
+		return nil
+	}
+
+	// Las rutas de la partición son absolutas
+	if value == "" {
+		value = "/"
+	}
+	if !strings.HasPrefix(value, "/") {
+		return nil
+	}
+	dir, base := path.Split(value)
+	lookup := strings.TrimSuffix(dir, "/")
+	if lookup == "" {
+		lookup = "/"
+	}
+	nodes, err := FileSystem.GetDirectoryContents(partitionID, lookup)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, node := range nodes {
+		if node.Name == "." || node.Name == ".." || !strings.HasPrefix(node.Name, base) {
+			continue
+		}
+		name := node.Name
+		if node.IsDirectory {
+			name += "/"
+		}
+		candidates = append(candidates, dir+name)
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// unquotePartial quita las comillas y los escapes de un valor que se está
+// escribiendo. Retorna la comilla abierta (o 0) y el valor.
+func unquotePartial(raw string) (rune, string) {
+	var quote rune
+	var value strings.Builder
+	runes := []rune(raw)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes) && (quote == 0 || quote == '"' && (runes[i+1] == '"' || runes[i+1] == '\\')):
+			i++
+			value.WriteRune(runes[i])
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		default:
+			value.WriteRune(r)
+		}
+	}
+	return quote, value.String()
+}
+
+// quotePaths arma las palabras completas para las rutas: dentro de las
+// comillas abiertas o, sin comillas, escapando los espacios. Los archivos
+// cierran la comilla; las carpetas la dejan abierta para seguir escribiendo.
+func quotePaths(prefix string, quote rune, paths []string) []string {
+	candidates := make([]string, 0, len(paths))
+	for _, value := range paths {
+		var word strings.Builder
+		word.WriteString(prefix)
+		if quote != 0 {
+			word.WriteRune(quote)
+		}
+		for _, r := range value {
+			switch {
+			case quote == '"' && (r == '"' || r == '\\'):
+				word.WriteRune('\\')
+			case quote == 0 && (unicode.IsSpace(r) || strings.ContainsRune("\"'\\", r)):
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+		}
+		if quote != 0 && !strings.HasSuffix(value, "/") {
+			word.WriteRune(quote)
+		}
+		candidates = append(candidates, word.String())
+	}
+	return candidates
+}
@@ -0,0 +1,68 @@
+package Analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+// Autocompletado de comandos, parámetros, valores, IDs montados y rutas del
+// host y de la partición
+func TestComplete(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkfile -r -path=/docs/ñandú.txt -size=1")
+	mustRun(t, ctx, "mkdir -path=/datos")
+
+	host := filepath.Join(dir, "mis discos")
+	if err := os.MkdirAll(host, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(host, "A.mia"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line  string
+		start string
+		want  []string
+	}{
+		{"mo", "", []string{"mount", "mounted", "move"}},
+		{"  MKDI", "  ", []string{"mkdir", "mkdisk"}},
+		{"mkdisk -size=5 -", "mkdisk -size=5 ", []string{"-unit=", "-fit=", "-path="}},
+		{"mkdir -", "mkdir ", []string{"-path=", "-p"}},
+		{"mkdisk -fit=", "mkdisk ", []string{"-fit=bf", "-fit=ff", "-fit=wf"}},
+		{"mkdisk -FIT=W", "mkdisk ", []string{"-FIT=wf"}},
+		{"help -command=mkf", "help ", []string{"-command=mkfile", "-command=mkfs"}},
+		{"unmount -id=" + id[:len(id)-1], "unmount ", []string{"-id=" + id}},
+		{"mkdisk -size=5 x", "mkdisk -size=5 ", nil},
+		{"noexiste -", "noexiste ", nil},
+
+		// Rutas del host: sin comillas se escapan los espacios; entre
+		// comillas los archivos cierran la comilla
+		{"mkdisk -path=" + dir + "/mis", "mkdisk ", []string{`-path=` + dir + `/mis\ discos/`}},
+		{`mount -path="` + host + "/A", "mount ", []string{`-path="` + host + `/A.mia"`}},
+
+		// Rutas de la partición de la sesión o de -id
+		{"cat -file1=/d", "cat ", []string{"-file1=/datos/", "-file1=/docs/"}},
+		{"cat -file1=/docs/ñandú.txt -file2=/docs/ñ", "cat -file1=/docs/ñandú.txt ", []string{"-file2=/docs/ñandú.txt"}},
+		{"rep -id=" + id + " -path_file_ls=/u", "rep -id=" + id + " ", []string{"-path_file_ls=/users.txt"}},
+	}
+	for _, tt := range tests {
+		start, candidates := Complete(ctx, tt.line)
+		if want := utf8.RuneCountInString(tt.start); start != want {
+			t.Errorf("%q: la palabra empieza en %d, se esperaba %d", tt.line, start, want)
+		}
+		if !reflect.DeepEqual(candidates, tt.want) {
+			t.Errorf("%q: %q, se esperaba %q", tt.line, candidates, tt.want)
+		}
+	}
+
+	// Sin sesión ni -id no hay partición donde buscar rutas
+	if _, candidates := Complete(NewContext(nil), "cat -file1=/"); len(candidates) != 0 {
+		t.Errorf("sin sesión se completaron rutas de la partición: %q", candidates)
+	}
+}
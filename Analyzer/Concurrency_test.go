@@ -0,0 +1,293 @@
+package Analyzer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"proyecto1/DiskManagement"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Estas pruebas se ejecutan con go test -race ./Analyzer/: los comandos de
+// distintas peticiones corren en paralelo, con un Context por petición como
+// hace el servidor HTTP.
+
+// runCommand ejecuta un comando como lo hace POST /execute
+func runCommand(ctx *Context, command string) Structs.CommandResult {
+	_, results := ProcessCommandForAPI(ctx, command)
+	if len(results) != 1 {
+		return Structs.CommandResult{Command: command, Status: "error", Message: fmt.Sprintf("%d resultados", len(results))}
+	}
+	return results[0]
+}
+
+// mustRun ejecuta un comando y detiene la prueba si falla
+func mustRun(t *testing.T, ctx *Context, command string) Structs.CommandResult {
+	t.Helper()
+	result := runCommand(ctx, command)
+	if result.Status != "ok" {
+		t.Fatalf("%s: %s\n%s", command, result.Message, result.Output)
+	}
+	return result
+}
+
+// setupPartition crea un disco con una partición montada, la formatea con
+// los parámetros de mkfs indicados y retorna el ID y la sesión de root en ella
+func setupPartition(t *testing.T, dir string, name string, format string) (string, *Structs.UserSession) {
+	t.Helper()
+	disk := filepath.Join(dir, name+".mia")
+	ctx := NewContext(nil)
+	mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=2 -unit=m -path="%s"`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=1 -unit=m -path="%s" -name=P1`, disk))
+	mounted := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P1`, disk))
+	id := mounted.Data["id"].(string)
+	mustRun(t, ctx, fmt.Sprintf("mkfs -id=%s %s", id, format))
+	mustRun(t, ctx, fmt.Sprintf("login -user=root -pass=123 -id=%s", id))
+	return id, ctx.Session
+}
+
+// useTempState guarda el estado en un directorio temporal y empieza con el
+// registro vacío para que los montajes de otras pruebas no se acumulen
+func useTempState(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	DiskManagement.SetStateFile(filepath.Join(dir, "state.json"))
+	if err := DiskManagement.LoadState(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// fileContent es el contenido que mkfile -size genera
+func fileContent(size int) string {
+	var content strings.Builder
+	for i := 0; i < size; i++ {
+		content.WriteByte(byte('0' + i%10))
+	}
+	return content.String()
+}
+
+// checkOutput verifica que la salida de un comando tenga solo lo que ese
+// comando imprimió
+func checkOutput(t *testing.T, result Structs.CommandResult, command string) {
+	if !strings.HasPrefix(result.Output, ">>> Procesando: "+command+"\n") {
+		t.Errorf("%s: la salida no empieza con el comando:\n%s", command, result.Output)
+	}
+	if strings.Count(result.Output, ">>> Procesando:") != 1 || strings.Count(result.Output, "Ejecutando:") != 1 {
+		t.Errorf("%s: la salida mezcla otros comandos:\n%s", command, result.Output)
+	}
+}
+
+// mkfile, cat y rep sobre dos discos a la vez: las escrituras de un disco
+// se serializan, las lecturas comparten el lock y cada petición recibe solo
+// su propia salida
+func TestConcurrentMkfileCatRep(t *testing.T) {
+	dir := useTempState(t)
+	type partition struct {
+		id      string
+		session *Structs.UserSession
+	}
+	partitions := make([]partition, 0, 2)
+	for i, fs := range []string{"2fs", "3fs"} {
+		id, session := setupPartition(t, dir, fmt.Sprintf("Disco%d", i+1), "-fs="+fs)
+		partitions = append(partitions, partition{id, session})
+	}
+
+	const writers, readers, reporters, rounds = 3, 3, 2, 4
+	var wg sync.WaitGroup
+	for _, part := range partitions {
+		part := part
+		for w := 0; w < writers; w++ {
+			w := w
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := NewContext(part.session)
+				for r := 0; r < rounds; r++ {
+					command := fmt.Sprintf("mkfile -path=/w%d_%d.txt -size=%d", w, r, 50+10*w+r)
+					result := runCommand(ctx, command)
+					if result.Status != "ok" {
+						t.Errorf("%s en %s: %s", command, part.id, result.Message)
+					}
+					checkOutput(t, result, command)
+				}
+			}()
+		}
+		for g := 0; g < readers; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := NewContext(part.session)
+				for r := 0; r < rounds; r++ {
+					command := "cat -file1=/users.txt"
+					result := runCommand(ctx, command)
+					if result.Status != "ok" || !strings.Contains(result.Output, "1,G,root") {
+						t.Errorf("%s en %s: %s\n%s", command, part.id, result.Message, result.Output)
+					}
+					checkOutput(t, result, command)
+				}
+			}()
+		}
+		for g := 0; g < reporters; g++ {
+			g := g
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := NewContext(nil)
+				for r := 0; r < rounds; r++ {
+					// Los reportes de datos (JSON) se calculan con el disco ya
+					// bloqueado por el comando, igual que las imágenes
+					report := filepath.Join(dir, fmt.Sprintf("tree_%s_%d_%d.svg", part.id, g, r))
+					command := fmt.Sprintf(`rep -name=tree -path="%s" -id=%s`, report, part.id)
+					if r%2 == 1 {
+						report = filepath.Join(dir, fmt.Sprintf("tree_%s_%d_%d.json", part.id, g, r))
+						command = fmt.Sprintf(`rep -name=tree -path="%s" -id=%s -format=json`, report, part.id)
+					}
+					result := runCommand(ctx, command)
+					if result.Status != "ok" {
+						t.Errorf("%s: %s", command, result.Message)
+					}
+					checkOutput(t, result, command)
+					if _, err := os.Stat(report); err != nil {
+						t.Errorf("%s: no se generó el reporte: %v", command, err)
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	// Todos los archivos quedaron completos y en su partición
+	for _, part := range partitions {
+		for w := 0; w < writers; w++ {
+			for r := 0; r < rounds; r++ {
+				path := fmt.Sprintf("/w%d_%d.txt", w, r)
+				content, err := FileSystem.GetFileContent(part.id, path)
+				if err != nil {
+					t.Errorf("%s en %s: %v", path, part.id, err)
+					continue
+				}
+				if want := fileContent(50 + 10*w + r); string(content) != want {
+					t.Errorf("%s en %s: contenido %q, se esperaba %q", path, part.id, content, want)
+				}
+			}
+		}
+	}
+}
+
+// Un cat y un mkfile sobre el mismo disco desde la misma sesión (dos
+// peticiones con el mismo token) no comparten la salida
+func TestConcurrentRequestsSameSession(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	mustRun(t, NewContext(session), "mkfile -path=/base.txt -size=20")
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		g := g
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			command := fmt.Sprintf("mkdir -path=/dir%d", g)
+			result := runCommand(NewContext(session), command)
+			if result.Status != "ok" {
+				t.Errorf("%s en %s: %s", command, id, result.Message)
+			}
+			checkOutput(t, result, command)
+		}()
+		go func() {
+			defer wg.Done()
+			command := "cat -file1=/base.txt"
+			result := runCommand(NewContext(session), command)
+			if result.Status != "ok" || !strings.Contains(result.Output, fileContent(20)) {
+				t.Errorf("%s en %s: %s\n%s", command, id, result.Message, result.Output)
+			}
+			checkOutput(t, result, command)
+		}()
+	}
+	wg.Wait()
+}
+
+// fsck con el ID en minúsculas mientras otras peticiones crean archivos en
+// el mismo disco: toma el lock del disco, así que nunca ve una escritura a
+// medias
+func TestConcurrentFsckLowercaseID(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	lowerID := strings.ToLower(id)
+
+	const writers, checkers, rounds = 3, 2, 5
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := NewContext(session)
+			for r := 0; r < rounds; r++ {
+				command := fmt.Sprintf("mkfile -path=/f%d_%d.txt -size=%d", w, r, 100+w+r)
+				if result := runCommand(ctx, command); result.Status != "ok" {
+					t.Errorf("%s: %s", command, result.Message)
+				}
+			}
+		}()
+	}
+	for c := 0; c < checkers; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := NewContext(nil)
+			for r := 0; r < rounds; r++ {
+				command := "fsck -id=" + lowerID
+				result := runCommand(ctx, command)
+				if result.Status != "ok" {
+					t.Errorf("%s: %s", command, result.Message)
+					continue
+				}
+				if report, _ := result.Data["report"].(*FileSystem.FsckReport); report == nil || !report.Clean {
+					t.Errorf("%s encontró inconsistencias durante las escrituras:\n%s", command, result.Output)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Un -id en minúsculas bloquea el mismo disco que su forma normalizada, y
+// un -id que no está montado usa el disco de la sesión
+func TestLockForCommandNormalizesID(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	partition, _ := DiskManagement.GetMountedPartition(id)
+
+	cases := []struct {
+		name string
+		ctx  *Context
+		args string
+	}{
+		{"id en minúsculas", NewContext(nil), "-id=" + strings.ToLower(id)},
+		{"id no montado con sesión", NewContext(session), "-id=ZZZ9"},
+	}
+	for _, tc := range cases {
+		unlock := lockForCommand(tc.ctx, "mkfs", tc.args)
+		acquired := make(chan struct{})
+		go func() {
+			release := DiskManagement.LockDisk(partition.Path)
+			close(acquired)
+			release()
+		}()
+		select {
+		case <-acquired:
+			t.Errorf("%s: mkfs %s no tomó el lock del disco", tc.name, tc.args)
+		case <-time.After(100 * time.Millisecond):
+		}
+		unlock()
+		<-acquired
+	}
+}
@@ -0,0 +1,120 @@
+package Analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// mkfs -type=fast no borra los bloques de datos, pero los archivos nuevos
+// no ven el contenido anterior; -type=full sí lo borra
+func TestMkfsFast(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	disk := filepath.Join(dir, "Disco.mia")
+	marker := []byte(strings.Repeat("MARCADOR-ANTIGUO ", 20))
+	source := filepath.Join(dir, "marcador.txt")
+	if err := os.WriteFile(source, marker, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, NewContext(session), fmt.Sprintf(`mkfile -path=/viejo.txt -cont="%s"`, source))
+
+	ctx := NewContext(nil)
+	mustRun(t, ctx, fmt.Sprintf("mkfs -id=%s -type=fast -fs=2fs", id))
+	if data, _ := os.ReadFile(disk); !bytes.Contains(data, marker[:40]) {
+		t.Error("mkfs -type=fast borró los bloques de datos")
+	}
+	nodes, err := FileSystem.GetDirectoryContents(id, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range nodes {
+		if node.Name != "." && node.Name != ".." && node.Name != "users.txt" {
+			t.Errorf("%s sigue en la raíz después de mkfs -type=fast", node.Name)
+		}
+	}
+
+	mustRun(t, ctx, "login -user=root -pass=123 -id="+id)
+	mustRun(t, ctx, "mkfile -path=/nuevo.txt -size=10")
+	if content, err := FileSystem.GetFileContent(id, "/nuevo.txt"); err != nil || string(content) != fileContent(10) {
+		t.Errorf("/nuevo.txt: %q %v", content, err)
+	}
+	if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+		t.Errorf("fsck encontró inconsistencias: %+v", report.Issues)
+	}
+
+	mustRun(t, ctx, fmt.Sprintf("mkfs -id=%s -type=full -fs=2fs", id))
+	if data, _ := os.ReadFile(disk); bytes.Contains(data, marker[:40]) {
+		t.Error("mkfs -type=full no borró los bloques de datos")
+	}
+}
+
+// convert pasa una partición EXT2 a EXT3 conservando archivos, directorios
+// y propietarios; el journaling empieza con la conversión
+func TestConvertKeepsFiles(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkgrp -name=devs")
+	mustRun(t, ctx, "mkusr -user=ana -pass=123 -grp=devs")
+	mustRun(t, ctx, "mkdir -p -path=/docs/viejos")
+	mustRun(t, ctx, "mkfile -path=/docs/corto.txt -size=30")
+	// Más de 12 bloques: usa apuntadores indirectos
+	mustRun(t, ctx, "mkfile -path=/docs/viejos/largo.txt -size=2000")
+	mustRun(t, ctx, "chown -path=/docs -r -usuario=ana")
+	before := findNode(t, id, "/docs/viejos/largo.txt")
+
+	mustRun(t, ctx, "convert -id="+id+" -journal=20")
+	if sb, err := FileSystem.ReadSuperblock(id); err != nil || sb.S_filesystem_type != 3 {
+		t.Fatalf("la partición no quedó en EXT3: %+v %v", sb, err)
+	}
+	for path, size := range map[string]int{"/docs/corto.txt": 30, "/docs/viejos/largo.txt": 2000} {
+		if content, err := FileSystem.GetFileContent(id, path); err != nil || string(content) != fileContent(size) {
+			t.Errorf("%s cambió con la conversión: %v", path, err)
+		}
+	}
+	if after := findNode(t, id, "/docs/viejos/largo.txt"); after.OwnerID != before.OwnerID || after.GroupID != before.GroupID {
+		t.Errorf("el propietario cambió con la conversión: %+v -> %+v", before, after)
+	}
+	if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+		t.Errorf("fsck encontró inconsistencias: %+v", report.Issues)
+	}
+
+	// La conversión es la primera entrada y las siguientes se registran
+	mustRun(t, ctx, "mkdir -path=/nuevo")
+	entries, err := FileSystem.GetJournalingData(id)
+	if err != nil || len(entries) != 2 || entries[0].Operation != "convert" || entries[1].Operation != "mkdir" {
+		t.Errorf("journaling después de convert: %+v %v", entries, err)
+	}
+}
+
+// convert solo acepta EXT2 y un journaling que quepa con los datos ocupados
+func TestConvertErrors(t *testing.T) {
+	dir := useTempState(t)
+	ext3, session := setupPartition(t, dir, "Disco3", "-fs=3fs")
+	ctx := NewContext(session)
+	ext2, _ := setupPartition(t, dir, "Disco2", "-fs=2fs")
+
+	tests := []struct {
+		command string
+		code    string
+	}{
+		{"convert -id=" + ext3 + " -journal=20", Utilities.ErrUnsupported},
+		{"convert -id=" + ext2 + " -journal=0", Utilities.ErrInvalidArgument},
+		{"convert -id=" + ext2 + " -journal=100000", Utilities.ErrNoSpace},
+	}
+	for _, tt := range tests {
+		result := runCommand(ctx, tt.command)
+		if result.Status != "error" || result.Code != tt.code {
+			t.Errorf("%s: estado %s, código %s, se esperaba %s\n%s", tt.command, result.Status, result.Code, tt.code, result.Output)
+		}
+	}
+	if sb, err := FileSystem.ReadSuperblock(ext2); err != nil || sb.S_filesystem_type != 2 {
+		t.Errorf("un convert fallido modificó la partición: %+v %v", sb, err)
+	}
+}
@@ -0,0 +1,124 @@
+package Analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Utilities"
+	"testing"
+)
+
+// writeScript escribe un script en el host y retorna su ruta
+func writeScript(t *testing.T, path string, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Las variables del script y las del entorno se sustituyen, también dentro
+// de los scripts incluidos, cuya ruta es relativa al script que los incluye
+func TestExecVariablesAndIncludes(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	t.Setenv("TAMANO", "7")
+
+	writeScript(t, filepath.Join(dir, "scripts", "sub", "incluido.smia"), `
+# Usa las variables del script principal
+mkfile -path=${CARPETA}/b.txt -size=${TAMANO}
+`)
+	main := writeScript(t, filepath.Join(dir, "scripts", "principal.smia"), `
+set CARPETA=/docs   # comentario al final
+mkdir -p \
+      -path=${CARPETA}
+exec -path=sub/incluido.smia
+mkfile -path=${CARPETA}/a.txt -size=3
+`)
+
+	result := mustRun(t, ctx, fmt.Sprintf(`exec -path="%s"`, main))
+	lines := result.Data["lines"].([]scriptLine)
+	if len(lines) != 3 || result.Data["failed"] != 0 {
+		t.Fatalf("exec: %+v", lines)
+	}
+	if lines[0].Line != 3 || lines[0].Command != "mkdir -p -path=/docs" {
+		t.Errorf("la línea continuada se registró como %d %q", lines[0].Line, lines[0].Command)
+	}
+	if filepath.Base(lines[1].Script) != "incluido.smia" || lines[1].Line != 3 {
+		t.Errorf("la línea del script incluido se registró como %s:%d", lines[1].Script, lines[1].Line)
+	}
+	for path, size := range map[string]int{"/docs/a.txt": 3, "/docs/b.txt": 7} {
+		if content, err := FileSystem.GetFileContent(id, path); err != nil || string(content) != fileContent(size) {
+			t.Errorf("%s: %q %v", path, content, err)
+		}
+	}
+}
+
+// Con -onerror=continue el script sigue después de un error; con
+// -onerror=stop se detiene, aunque el error ocurra en un script incluido
+func TestExecErrorPolicy(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+
+	writeScript(t, filepath.Join(dir, "falla.smia"), "mkdir -path=/no/existe\n")
+	main := writeScript(t, filepath.Join(dir, "principal.smia"), `
+mkfile -path=/${ARCHIVO}_1.txt -size=1
+exec -path=falla.smia
+mkfile -path=/${ARCHIVO}_2.txt -size=1
+`)
+
+	exists := func(path string) bool {
+		_, err := FileSystem.GetFileContent(id, path)
+		return err == nil
+	}
+
+	t.Setenv("ARCHIVO", "continue")
+	result := mustRun(t, ctx, fmt.Sprintf(`exec -path="%s"`, main))
+	if result.Data["passed"] != 2 || result.Data["failed"] != 1 || result.Data["stopped"] != false {
+		t.Errorf("exec -onerror=continue: %+v", result.Data)
+	}
+	if !exists("/continue_2.txt") {
+		t.Error("exec -onerror=continue no ejecutó la línea después del error")
+	}
+
+	t.Setenv("ARCHIVO", "stop")
+	result = runCommand(ctx, fmt.Sprintf(`exec -path="%s" -onerror=stop`, main))
+	if result.Status != "error" || result.Code != Utilities.ErrScriptFailed {
+		t.Errorf("exec -onerror=stop: estado %s, código %s, se esperaba %s", result.Status, result.Code, Utilities.ErrScriptFailed)
+	}
+	if !exists("/stop_1.txt") || exists("/stop_2.txt") {
+		t.Error("exec -onerror=stop no se detuvo en el error del script incluido")
+	}
+}
+
+// Una variable no definida falla solo en su línea; un script que se incluye
+// a sí mismo o que no existe falla sin ejecutar nada
+func TestExecErrors(t *testing.T) {
+	dir := useTempState(t)
+	_, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+
+	undefined := writeScript(t, filepath.Join(dir, "variable.smia"), "mkdir -path=/${NO_DEFINIDA_EN_EXEC}\nmkdir -path=/b\n")
+	result := mustRun(t, ctx, fmt.Sprintf(`exec -path="%s"`, undefined))
+	lines := result.Data["lines"].([]scriptLine)
+	if len(lines) != 2 || lines[0].Code != Utilities.ErrInvalidArgument || lines[1].Status != "ok" {
+		t.Errorf("exec con una variable no definida: %+v", lines)
+	}
+
+	recursive := writeScript(t, filepath.Join(dir, "recursivo.smia"), "exec -path=recursivo.smia\n")
+	result = mustRun(t, ctx, fmt.Sprintf(`exec -path="%s"`, recursive))
+	if lines := result.Data["lines"].([]scriptLine); len(lines) != 1 || lines[0].Code != Utilities.ErrInvalidArgument {
+		t.Errorf("exec de un script que se incluye a sí mismo: %+v", lines)
+	}
+
+	result = runCommand(ctx, fmt.Sprintf(`exec -path="%s"`, filepath.Join(dir, "no_existe.smia")))
+	if result.Status != "error" || result.Code != Utilities.ErrNotFound {
+		t.Errorf("exec de un script que no existe: estado %s, código %s", result.Status, result.Code)
+	}
+}
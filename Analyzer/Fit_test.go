@@ -0,0 +1,137 @@
+package Analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// readMBR lee el MBR del disco
+func readMBR(t *testing.T, diskPath string) Structs.MBR {
+	t.Helper()
+	file, err := os.Open(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var mbr Structs.MBR
+	if err := Utilities.ReadObject(file, &mbr, 0); err != nil {
+		t.Fatal(err)
+	}
+	return mbr
+}
+
+// partitionByName busca una partición primaria o extendida en el MBR
+func partitionByName(t *testing.T, diskPath string, name string) Structs.Partition {
+	t.Helper()
+	mbr := readMBR(t, diskPath)
+	for _, partition := range mbr.Partitions {
+		if partition.Size > 0 && strings.Trim(string(partition.Name[:]), "\x00") == name {
+			return partition
+		}
+	}
+	t.Fatalf("la partición %s no está en el MBR", name)
+	return Structs.Partition{}
+}
+
+// logicalByName busca una lógica en la lista de EBRs de la extendida y
+// retorna la posición de su EBR junto con el EBR
+func logicalByName(t *testing.T, diskPath string, extended Structs.Partition, name string) (int32, Structs.EBR) {
+	t.Helper()
+	file, err := os.Open(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	for position := extended.Start; position != -1; {
+		var ebr Structs.EBR
+		if err := Utilities.ReadObject(file, &ebr, int64(position)); err != nil {
+			t.Fatal(err)
+		}
+		if ebr.Part_size > 0 && strings.Trim(string(ebr.Part_name[:]), "\x00") == name {
+			return position, ebr
+		}
+		position = ebr.Part_next
+	}
+	t.Fatalf("la lógica %s no está en la extendida", name)
+	return 0, Structs.EBR{}
+}
+
+// Tras borrar A y C quedan tres huecos: el de A (20k), el de C (10k) y el
+// final del disco (~60k). Cada ajuste del disco elige uno distinto.
+func TestFdiskFitChoosesGap(t *testing.T) {
+	dir := useTempState(t)
+	ctx := NewContext(nil)
+	for _, fit := range []string{"ff", "bf", "wf"} {
+		disk := filepath.Join(dir, fit+".mia")
+		mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=100 -unit=k -fit=%s -path="%s"`, fit, disk))
+		for _, p := range []struct {
+			name string
+			size int
+		}{{"A", 20}, {"B", 5}, {"C", 10}, {"D", 5}} {
+			mustRun(t, ctx, fmt.Sprintf(`fdisk -size=%d -unit=k -path="%s" -name=%s`, p.size, disk, p.name))
+		}
+		a, c, d := partitionByName(t, disk, "A"), partitionByName(t, disk, "C"), partitionByName(t, disk, "D")
+		mustRun(t, ctx, fmt.Sprintf(`fdisk -delete=full -path="%s" -name=A`, disk))
+		mustRun(t, ctx, fmt.Sprintf(`fdisk -delete=full -path="%s" -name=C`, disk))
+
+		mustRun(t, ctx, fmt.Sprintf(`fdisk -size=8 -unit=k -path="%s" -name=N`, disk))
+		want := map[string]int32{"ff": a.Start, "bf": c.Start, "wf": d.Start + d.Size}[fit]
+		if got := partitionByName(t, disk, "N").Start; got != want {
+			t.Errorf("%s: la partición nueva empieza en %d, se esperaba %d", fit, got, want)
+		}
+	}
+}
+
+// Sin un hueco contiguo del tamaño pedido fdisk falla con NO_SPACE aunque
+// el espacio libre total alcance
+func TestFdiskNoContiguousSpace(t *testing.T) {
+	dir := useTempState(t)
+	ctx := NewContext(nil)
+	disk := filepath.Join(dir, "Disco.mia")
+	mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=100 -unit=k -path="%s"`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=40 -unit=k -path="%s" -name=A`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=20 -unit=k -path="%s" -name=B`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -delete=full -path="%s" -name=A`, disk))
+
+	result := runCommand(ctx, fmt.Sprintf(`fdisk -size=50 -unit=k -path="%s" -name=N`, disk))
+	if result.Status != "error" || result.Code != Utilities.ErrNoSpace {
+		t.Errorf("fdisk: estado %s, código %s, se esperaba %s\n%s", result.Status, result.Code, Utilities.ErrNoSpace, result.Output)
+	}
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=35 -unit=k -path="%s" -name=N`, disk))
+}
+
+// Las lógicas usan el ajuste de la extendida (aquí bf), no el del disco
+// (ff), y reutilizan el espacio de las lógicas borradas
+func TestFdiskLogicalUsesExtendedFit(t *testing.T) {
+	dir := useTempState(t)
+	ctx := NewContext(nil)
+	disk := filepath.Join(dir, "Disco.mia")
+	mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=100 -unit=k -fit=ff -path="%s"`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=60 -unit=k -type=e -fit=bf -path="%s" -name=E`, disk))
+	for _, l := range []struct {
+		name string
+		size int
+	}{{"L1", 10}, {"L2", 5}, {"L3", 4}, {"L4", 5}} {
+		mustRun(t, ctx, fmt.Sprintf(`fdisk -size=%d -unit=k -type=l -path="%s" -name=%s`, l.size, disk, l.name))
+	}
+	extended := partitionByName(t, disk, "E")
+	l3, _ := logicalByName(t, disk, extended, "L3")
+	l4, _ := logicalByName(t, disk, extended, "L4")
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -delete=full -path="%s" -name=L1`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -delete=full -path="%s" -name=L3`, disk))
+
+	// Los huecos son el de L1, el de L3 y el final de la extendida; el más
+	// pequeño que alcanza es el de L3
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=3 -unit=k -type=l -path="%s" -name=N`, disk))
+	position, ebr := logicalByName(t, disk, extended, "N")
+	if position != l3 || ebr.Part_start+ebr.Part_size > l4 {
+		t.Errorf("la lógica nueva quedó en %d-%d, se esperaba el hueco de L3 (%d-%d)", position, ebr.Part_start+ebr.Part_size, l3, l4)
+	}
+	logicalByName(t, disk, extended, "L2")
+	logicalByName(t, disk, extended, "L4")
+}
@@ -0,0 +1,169 @@
+package Analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"sort"
+	"testing"
+)
+
+// fsckReport ejecuta fsck y retorna su reporte
+func fsckReport(t *testing.T, ctx *Context, command string) *FileSystem.FsckReport {
+	t.Helper()
+	result := mustRun(t, ctx, command)
+	report, _ := result.Data["report"].(*FileSystem.FsckReport)
+	if report == nil {
+		t.Fatalf("%s no retornó un reporte", command)
+	}
+	return report
+}
+
+// Los archivos que llenan el bloque de un directorio agregan otro bloque; el
+// contador de bloques libres del superblock debe seguir al bitmap
+func TestFsckCleanAfterDirectoryGrows(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	for i := 0; i < 8; i++ {
+		mustRun(t, ctx, fmt.Sprintf("mkfile -path=/f%d.txt -size=%d", i, 100+i))
+		mustRun(t, ctx, fmt.Sprintf("mkdir -path=/d%d", i))
+	}
+
+	if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+		t.Errorf("fsck encontró inconsistencias: %+v", report.Issues)
+	}
+}
+
+// corruptDisk escribe un objeto directamente en el disco, sin pasar por el
+// sistema de archivos
+func corruptDisk(t *testing.T, diskPath string, data interface{}, position int32) {
+	t.Helper()
+	file, err := os.OpenFile(diskPath, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if err := Utilities.WriteObject(file, data, int64(position)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readInode lee un inodo directamente del disco
+func readInode(t *testing.T, diskPath string, sb *Structs.Superblock, inode int32) Structs.Inode {
+	t.Helper()
+	file, err := os.Open(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var result Structs.Inode
+	if err := Utilities.ReadObject(file, &result, int64(sb.S_inode_start+inode*sb.S_inode_size)); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// issueKinds retorna los tipos de inconsistencia con su estado, ordenados
+func issueKinds(report *FileSystem.FsckReport) []string {
+	var kinds []string
+	for _, issue := range report.Issues {
+		kinds = append(kinds, issue.Kind+":"+issue.Status)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// fsck encuentra los bitmaps alterados sin modificar el disco y fsck -fix
+// los repara sin tocar los archivos
+func TestFsckRepairsBitmaps(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	disk := filepath.Join(dir, "Disco.mia")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkfile -path=/a.txt -size=100")
+
+	sb, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used := readInode(t, disk, sb, findNode(t, id, "/a.txt").Inode).I_block[0]
+	corruptDisk(t, disk, byte(0), sb.S_bm_block_start+used)
+	corruptDisk(t, disk, byte(1), sb.S_bm_block_start+sb.S_blocks_count-1)
+	corruptDisk(t, disk, byte(1), sb.S_bm_inode_start+sb.S_inodes_count-1)
+
+	want := fmt.Sprint([]string{"block_bitmap_unset:found", "block_leak:found", "free_inodes_count:found", "orphan_inode:found"})
+	for i := 0; i < 2; i++ {
+		if got := fmt.Sprint(issueKinds(fsckReport(t, ctx, "fsck -id="+id))); got != want {
+			t.Fatalf("fsck: %s, se esperaba %s", got, want)
+		}
+	}
+
+	// Al liberar el inodo huérfano el contador vuelve a coincidir
+	report := fsckReport(t, ctx, "fsck -fix -id="+id)
+	want = fmt.Sprint([]string{"block_bitmap_unset:repaired", "block_leak:repaired", "orphan_inode:repaired"})
+	if got := fmt.Sprint(issueKinds(report)); got != want {
+		t.Errorf("fsck -fix: %s, se esperaba %s", got, want)
+	}
+	if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+		t.Errorf("fsck después de reparar: %+v", report.Issues)
+	}
+	if content, err := FileSystem.GetFileContent(id, "/a.txt"); err != nil || string(content) != fileContent(100) {
+		t.Errorf("/a.txt cambió con la reparación: %v", err)
+	}
+}
+
+// Una entrada de carpeta que apunta a un inodo borrado se quita de la
+// carpeta y su inodo y sus bloques quedan libres
+func TestFsckRemovesDanglingEntry(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	disk := filepath.Join(dir, "Disco.mia")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkdir -path=/docs")
+	mustRun(t, ctx, "mkfile -path=/docs/a.txt -size=100")
+	mustRun(t, ctx, "mkfile -path=/docs/b.txt -size=10")
+
+	sb, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inode := findNode(t, id, "/docs/a.txt").Inode
+	corruptDisk(t, disk, Structs.Inode{}, sb.S_inode_start+inode*sb.S_inode_size)
+
+	report := fsckReport(t, ctx, "fsck -fix -id="+id)
+	found := false
+	for _, issue := range report.Issues {
+		if issue.Kind == "dangling_entry" && issue.Path == "/docs/a.txt" && issue.Status == "repaired" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("fsck no reparó la entrada de /docs/a.txt: %+v", report.Issues)
+	}
+	if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+		t.Errorf("fsck después de reparar: %+v", report.Issues)
+	}
+	for _, node := range mustDirectory(t, id, "/docs") {
+		if node.Name == "a.txt" {
+			t.Error("la entrada de /docs/a.txt sigue en la carpeta")
+		}
+	}
+	if content, err := FileSystem.GetFileContent(id, "/docs/b.txt"); err != nil || string(content) != fileContent(10) {
+		t.Errorf("/docs/b.txt cambió con la reparación: %v", err)
+	}
+	mustRun(t, ctx, "mkfile -path=/docs/a.txt -size=20")
+}
+
+// mustDirectory lee el contenido de una carpeta
+func mustDirectory(t *testing.T, id string, path string) []FileSystem.FileSystemNode {
+	t.Helper()
+	nodes, err := FileSystem.GetDirectoryContents(id, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nodes
+}
@@ -0,0 +1,82 @@
+package Analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/DiskManagement"
+	"strings"
+	"testing"
+)
+
+// El correlativo crece mientras el ID quepa en los 4 bytes del MBR; después
+// vuelve a empezar con los correlativos cuyos IDs no están montados
+func TestMountIDReusesFreeCorrelatives(t *testing.T) {
+	dir := useTempState(t)
+	disk := filepath.Join(dir, "Disco.mia")
+	ctx := NewContext(nil)
+	mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=2 -unit=m -path="%s"`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=500 -unit=k -path="%s" -name=P1`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=500 -unit=k -path="%s" -name=P2`, disk))
+
+	// P2 se queda montada con el primer correlativo
+	kept := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P2`, disk)).Data["id"].(string)
+	seen := map[string]bool{kept: true}
+	for i := 2; i <= 9; i++ {
+		id := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P1`, disk)).Data["id"].(string)
+		if seen[id] || len(id) > 4 {
+			t.Fatalf("montaje %d: ID %s repetido o de más de 4 bytes", i, id)
+		}
+		seen[id] = true
+		mustRun(t, ctx, "unmount -id="+id)
+	}
+
+	// El décimo montaje ya no cabe con el correlativo 10: toma uno libre
+	id := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P1`, disk)).Data["id"].(string)
+	if id == kept || len(id) > 4 {
+		t.Fatalf("décimo montaje: ID %s repetido (%s sigue montado) o de más de 4 bytes", id, kept)
+	}
+	if mounted := DiskManagement.GetMountedPartitions(); len(mounted) != 2 {
+		t.Errorf("se esperaban 2 particiones montadas, hay %d", len(mounted))
+	}
+}
+
+// El prefijo deja lugar para un dígito de correlativo y la letra del disco
+func TestSetIDPrefixLength(t *testing.T) {
+	t.Cleanup(func() { DiskManagement.SetIDPrefix(DiskManagement.DefaultIDPrefix) })
+	for _, prefix := range []string{"", "ABC", "12345", "8-"} {
+		if err := DiskManagement.SetIDPrefix(prefix); err == nil {
+			t.Errorf("SetIDPrefix(%q) debería fallar", prefix)
+		}
+	}
+	for _, prefix := range []string{"7", "ZZ"} {
+		if err := DiskManagement.SetIDPrefix(prefix); err != nil {
+			t.Errorf("SetIDPrefix(%q): %v", prefix, err)
+		}
+	}
+}
+
+// Montar particiones del mismo disco con una ruta relativa y con la absoluta
+// usa la misma letra de disco
+func TestMountSameDiskDifferentPaths(t *testing.T) {
+	dir := useTempState(t)
+	disk := filepath.Join(dir, "Disco.mia")
+	ctx := NewContext(nil)
+	mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=2 -unit=m -path="%s"`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=500 -unit=k -path="%s" -name=P1`, disk))
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=500 -unit=k -path="%s" -name=P2`, disk))
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	relative, err := filepath.Rel(wd, disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P1`, disk)).Data["id"].(string)
+	second := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P2`, relative)).Data["id"].(string)
+	if first == second || !strings.HasSuffix(second, first[len(first)-1:]) {
+		t.Errorf("IDs %s y %s: se esperaba la misma letra de disco y distinto correlativo", first, second)
+	}
+}
@@ -0,0 +1,113 @@
+package Analyzer
+
+import (
+	"fmt"
+	"proyecto1/FileSystem"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// freeBlocks retorna los bloques libres que indica el superblock
+func freeBlocks(t *testing.T, id string) int32 {
+	t.Helper()
+	sb, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sb.S_free_blocks_count
+}
+
+// Los nombres de más de 12 bytes se guardan completos y funcionan con ls,
+// cat, find, rename y remove; al borrarlos se libera su bloque de nombre
+func TestLongNames(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs")
+	ctx := NewContext(session)
+	start := freeBlocks(t, id)
+
+	folder := "carpeta con nombre largo"
+	file := strings.Repeat("a", FileSystem.MaxNameLength-4) + ".txt"
+	mustRun(t, ctx, fmt.Sprintf(`mkdir -path="/%s"`, folder))
+	mustRun(t, ctx, fmt.Sprintf(`mkfile -path="/%s/%s" -size=40`, folder, file))
+	mustRun(t, ctx, fmt.Sprintf(`mkfile -path="/%s/doce_bytes.a" -size=5`, folder))
+
+	path := "/" + folder + "/" + file
+	if content, err := FileSystem.GetFileContent(id, path); err != nil || string(content) != fileContent(40) {
+		t.Errorf("%s: %q %v", path, content, err)
+	}
+	if node := findNode(t, id, path); node.Name != file {
+		t.Errorf("ls muestra %q en vez de %q", node.Name, file)
+	}
+	found := mustRun(t, ctx, `find -path=/ -name=aaaa*`)
+	if paths, _ := found.Data["matches"].([]string); len(paths) != 1 || paths[0] != path {
+		t.Errorf("find: %v, se esperaba %s", found.Data["matches"], path)
+	}
+	if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+		t.Errorf("fsck encontró inconsistencias: %+v", report.Issues)
+	}
+
+	// Renombrar de largo a corto y de corto a largo
+	mustRun(t, ctx, fmt.Sprintf(`rename -path="%s" -name=corto.txt`, path))
+	mustRun(t, ctx, fmt.Sprintf(`rename -path="/%s/corto.txt" -name=otro_nombre_largo.txt`, folder))
+	if content, err := FileSystem.GetFileContent(id, "/"+folder+"/otro_nombre_largo.txt"); err != nil || string(content) != fileContent(40) {
+		t.Errorf("el archivo renombrado: %q %v", content, err)
+	}
+	if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+		t.Errorf("fsck después de rename: %+v", report.Issues)
+	}
+
+	// Recovery reproduce los nombres largos desde el journaling
+	mustRun(t, ctx, "loss -id="+id)
+	mustRun(t, ctx, "recovery -id="+id)
+	findNode(t, id, "/"+folder+"/otro_nombre_largo.txt")
+
+	mustRun(t, ctx, fmt.Sprintf(`remove -path="/%s"`, folder))
+	if free := freeBlocks(t, id); free != start {
+		t.Errorf("después de borrar quedaron %d bloques libres, había %d", free, start)
+	}
+}
+
+// Un nombre de hasta 12 bytes no usa bloque de nombre
+func TestShortNameNeedsNoNameBlock(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkdir -path=/d")
+
+	before := freeBlocks(t, id)
+	mustRun(t, ctx, "mkfile -path=/d/doce_bytes.a -size=0")
+	short := before - freeBlocks(t, id)
+	before = freeBlocks(t, id)
+	mustRun(t, ctx, "mkfile -path=/d/trece_bytes.a -size=0")
+	long := before - freeBlocks(t, id)
+	if long != short+1 {
+		t.Errorf("el nombre de 12 bytes usó %d bloques y el de 13 usó %d", short, long)
+	}
+}
+
+// Los nombres que no se pueden guardar se rechazan sin crear nada
+func TestRejectInvalidNames(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkfile -path=/a.txt -size=1")
+	tooLong := strings.Repeat("x", FileSystem.MaxNameLength+1)
+
+	for _, command := range []string{
+		"mkdir -path=/" + tooLong,
+		"mkfile -path=/" + tooLong + " -size=1",
+		"mkfile -r -path=/ok/" + tooLong + "/a.txt -size=1",
+		"rename -path=/a.txt -name=" + tooLong,
+	} {
+		result := runCommand(ctx, command)
+		if result.Status != "error" || result.Code != Utilities.ErrInvalidArgument {
+			t.Errorf("%s: estado %s, código %s, se esperaba %s", command, result.Status, result.Code, Utilities.ErrInvalidArgument)
+		}
+	}
+	for _, node := range mustDirectory(t, id, "/") {
+		if node.Name != "." && node.Name != ".." && node.Name != "users.txt" && node.Name != "a.txt" {
+			t.Errorf("%s se creó con un nombre inválido", node.Name)
+		}
+	}
+}
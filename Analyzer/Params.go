@@ -0,0 +1,627 @@
+package Analyzer
+
+import (
+	"fmt"
+	"io"
+	"proyecto1/FileSystem"
+	"proyecto1/Utilities"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// ============================================================================
+// PARÁMETROS DE LOS COMANDOS
+// ============================================================================
+
+// Los parámetros se escriben como -nombre=valor, o -nombre para las banderas.
+// Las reglas para separarlos son las de una shell:
+//   - los espacios separan parámetros, salvo entre comillas
+//   - "..." admite \" y \\; '...' se toma literal
+//   - fuera de comillas, \ escapa el carácter siguiente
+//   - el primer = fuera de comillas separa el nombre del valor
+//   - un # al inicio de una palabra comenta el resto de la línea
+// Cada comando declara su esquema: qué parámetros acepta, de qué tipo, cuáles
+// son obligatorios y qué valores admiten. El esquema valida y genera la ayuda.
+
+// paramType es el tipo de valor de un parámetro
+type paramType int
+
+const (
+	paramString   paramType = iota // texto
+	paramInt                       // entero
+	paramUint                      // entero >= 0
+	paramPositive                  // entero > 0
+	paramBool                      // bandera sin valor, o true/false
+)
+
+var paramTypeNames = map[paramType]string{
+	paramString:   "texto",
+	paramInt:      "entero",
+	paramUint:     "entero >= 0",
+	paramPositive: "entero > 0",
+	paramBool:     "bandera",
+}
+
+// paramSpec describe un parámetro de un comando
+type paramSpec struct {
+	name     string
+	kind     paramType
+	required bool
+	def      string   // valor si no se indica
+	values   []string // valores permitidos, ya normalizados
+	lower    bool     // el valor se pasa a minúsculas
+	upper    bool     // el valor se pasa a mayúsculas (IDs de partición)
+	arg      string   // nombre del valor en la ayuda
+	help     string
+	path     pathKind // qué rutas completa la terminal interactiva
+}
+
+// pathKind indica si el valor de un parámetro es una ruta y de dónde
+type pathKind int
+
+const (
+	noPath        pathKind = iota
+	hostPath               // archivo o carpeta del sistema anfitrión
+	partitionPath          // archivo o carpeta dentro de la partición
+)
+
+// commandSchema describe un comando y sus parámetros
+type commandSchema struct {
+	name     string
+	summary  string
+	params   []paramSpec
+	examples []string
+}
+
+var paramName = regexp.MustCompile(`^-[A-Za-z_][A-Za-z0-9_-]*$`)
+
+var reportNames = []string{"mbr", "disk", "inode", "block", "bm_inode", "bm_block", "tree", "sb", "file", "ls", "journaling"}
+
+var commandSchemas = []commandSchema{
+	// Discos y particiones
+	{name: "mkdisk", summary: "Crea un disco virtual", params: []paramSpec{
+		{name: "size", kind: paramPositive, required: true, arg: "tamaño", help: "Tamaño del disco"},
+		{name: "unit", def: "m", values: []string{"k", "m"}, lower: true, help: "Unidad del tamaño"},
+		{name: "fit", def: "ff", values: []string{"bf", "ff", "wf"}, lower: true, help: "Ajuste para las particiones"},
+		{name: "path", required: true, arg: "ruta", help: "Ruta del archivo .mia a crear", path: hostPath},
+	}, examples: []string{"mkdisk -size=50 -unit=m -fit=ff -path=\"/home/mis discos/Disco1.mia\""}},
+	{name: "rmdisk", summary: "Elimina un disco virtual", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Ruta del disco a eliminar", path: hostPath},
+	}, examples: []string{"rmdisk -path=\"/home/mis discos/Disco4.mia\""}},
+	{name: "fdisk", summary: "Crea, elimina o redimensiona particiones", params: []paramSpec{
+		{name: "size", kind: paramPositive, arg: "tamaño", help: "Tamaño de la partición a crear"},
+		{name: "path", required: true, arg: "ruta", help: "Ruta del disco", path: hostPath},
+		{name: "name", required: true, arg: "nombre", help: "Nombre de la partición"},
+		{name: "type", def: "p", values: []string{"p", "e", "l"}, lower: true, help: "Primaria, extendida o lógica"},
+		{name: "fit", def: "wf", values: []string{"bf", "ff", "wf"}, lower: true, help: "Ajuste de la partición"},
+		{name: "unit", def: "k", values: []string{"b", "k", "m"}, lower: true, help: "Unidad de -size y -add"},
+		{name: "add", kind: paramInt, arg: "tamaño", help: "Espacio a agregar (o quitar si es negativo)"},
+		{name: "delete", values: []string{"fast", "full"}, lower: true, help: "Elimina la partición"},
+	}, examples: []string{
+		"fdisk -size=300 -path=/home/Disco1.mia -name=Particion1",
+		"fdisk -add=-500 -unit=k -path=/home/Disco1.mia -name=Particion1",
+		"fdisk -delete=full -path=/home/Disco1.mia -name=Particion1",
+	}},
+	{name: "mount", summary: "Monta una partición", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Ruta del disco", path: hostPath},
+		{name: "name", required: true, arg: "nombre", help: "Nombre de la partición a montar"},
+	}, examples: []string{"mount -path=./test/A.mia -name=Particion1"}},
+	{name: "unmount", summary: "Desmonta una partición", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición montada"},
+	}, examples: []string{"unmount -id=001A"}},
+	{name: "mounted", summary: "Muestra las particiones montadas"},
+	{name: "registry", summary: "Revisa el registro de discos conocidos", params: []paramSpec{
+		{name: "fix", kind: paramBool, help: "Elimina o repara las entradas desactualizadas"},
+	}},
+
+	// Sistema de archivos
+	{name: "mkfs", summary: "Formatea una partición con EXT2 o EXT3", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición montada"},
+		{name: "type", def: "full", values: []string{"full", "fast"}, lower: true, help: "Tipo de formateo"},
+		{name: "fs", def: "2fs", values: []string{"2fs", "3fs"}, lower: true, help: "Sistema de archivos"},
+		{name: "journal", kind: paramPositive, def: strconv.Itoa(FileSystem.DefaultJournalSize), help: "Entradas del journaling para 3fs"},
+	}, examples: []string{"mkfs -id=001A -type=full -fs=2fs", "mkfs -id=001A -fs=3fs -journal=100"}},
+	{name: "convert", summary: "Convierte una partición EXT2 a EXT3", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición EXT2 montada"},
+		{name: "journal", kind: paramPositive, def: strconv.Itoa(FileSystem.DefaultJournalSize), help: "Entradas del journaling"},
+	}, examples: []string{"convert -id=001A -journal=50"}},
+	{name: "fsck", summary: "Revisa la consistencia del sistema de archivos", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición montada"},
+		{name: "fix", kind: paramBool, help: "Repara las inconsistencias encontradas"},
+	}, examples: []string{"fsck -id=001A -fix"}},
+	{name: "rep", summary: "Genera un reporte de la partición", params: []paramSpec{
+		{name: "name", required: true, values: reportNames, lower: true, help: "Reporte a generar"},
+		{name: "path", required: true, arg: "ruta", help: "Archivo de salida", path: hostPath},
+		{name: "id", required: true, upper: true, help: "ID de la partición montada"},
+		{name: "path_file_ls", arg: "ruta", help: "Archivo o carpeta para los reportes file y ls", path: partitionPath},
+		{name: "renderer", def: "native", values: []string{"native", "graphviz"}, lower: true, help: "Cómo se dibuja la imagen"},
+		{name: "format", def: "image", values: []string{"image", "json", "csv"}, lower: true, help: "Salida del reporte"},
+	}, examples: []string{"rep -name=tree -path=/tmp/reportes/tree.svg -id=001A", "rep -name=ls -path=/tmp/ls.json -id=001A -path_file_ls=/home -format=json"}},
+	{name: "info", summary: "Muestra el superbloque de una partición", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición montada"},
+	}},
+	{name: "ls", summary: "Lista la raíz de una partición", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición montada"},
+	}},
+
+	// Usuarios y grupos
+	{name: "login", summary: "Inicia sesión en una partición", params: []paramSpec{
+		{name: "user", required: true, arg: "usuario", help: "Nombre del usuario"},
+		{name: "pass", required: true, arg: "contraseña", help: "Contraseña del usuario"},
+		{name: "id", required: true, upper: true, help: "ID de la partición montada"},
+	}, examples: []string{"login -user=root -pass=123 -id=001A"}},
+	{name: "logout", summary: "Cierra la sesión actual"},
+	{name: "mkgrp", summary: "Crea un grupo", params: []paramSpec{
+		{name: "name", required: true, arg: "grupo", help: "Nombre del grupo"},
+	}},
+	{name: "rmgrp", summary: "Elimina un grupo", params: []paramSpec{
+		{name: "name", required: true, arg: "grupo", help: "Nombre del grupo a eliminar"},
+	}},
+	{name: "mkusr", summary: "Crea un usuario", params: []paramSpec{
+		{name: "user", required: true, arg: "usuario", help: "Nombre del usuario (máximo 10 caracteres)"},
+		{name: "pass", required: true, arg: "contraseña", help: "Contraseña del usuario (máximo 10 caracteres)"},
+		{name: "grp", required: true, arg: "grupo", help: "Grupo del usuario (máximo 10 caracteres)"},
+	}},
+	{name: "rmusr", summary: "Elimina un usuario", params: []paramSpec{
+		{name: "user", required: true, arg: "usuario", help: "Nombre del usuario a eliminar"},
+	}},
+	{name: "chgrp", summary: "Cambia el grupo de un usuario", params: []paramSpec{
+		{name: "user", required: true, arg: "usuario", help: "Usuario al que cambiar el grupo"},
+		{name: "grp", required: true, arg: "grupo", help: "Nuevo grupo"},
+	}, examples: []string{"chgrp -user=juan -grp=administradores"}},
+	{name: "passwd", summary: "Cambia la contraseña de un usuario", params: []paramSpec{
+		{name: "user", arg: "usuario", help: "Usuario (por defecto el de la sesión)"},
+		{name: "old", arg: "contraseña", help: "Contraseña actual (obligatoria si no es root)"},
+		{name: "pass", required: true, arg: "contraseña", help: "Nueva contraseña"},
+	}, examples: []string{"passwd -old=123 -pass=nueva"}},
+
+	// Archivos y carpetas
+	{name: "mkfile", summary: "Crea un archivo", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Ruta del archivo a crear", path: partitionPath},
+		{name: "r", kind: paramBool, help: "Crea las carpetas padre si no existen"},
+		{name: "size", kind: paramUint, arg: "bytes", help: "Tamaño del archivo"},
+		{name: "cont", arg: "archivo_local", help: "Archivo del host con el contenido", path: hostPath},
+	}, examples: []string{"mkfile -path=/test.txt -size=10", "mkfile -path=/home/user/docs/archivo.txt -r -cont=/home/user/documento.txt"}},
+	{name: "mkdir", summary: "Crea una carpeta", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Ruta de la carpeta a crear", path: partitionPath},
+		{name: "p", kind: paramBool, help: "Crea las carpetas padre si no existen"},
+	}, examples: []string{"mkdir -path=/home/user/documents -p"}},
+	{name: "cat", summary: "Muestra el contenido de archivos (sin parámetros, users.txt)", params: numberedParams("file", 10, "ruta", "Archivo a mostrar", partitionPath)},
+	{name: "remove", summary: "Elimina un archivo o carpeta", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Archivo o carpeta a eliminar", path: partitionPath},
+	}, examples: []string{"remove -path=\"/carpeta con espacios/archivo.txt\""}},
+	{name: "edit", summary: "Reemplaza el contenido de un archivo", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Archivo a editar", path: partitionPath},
+		{name: "contenido", required: true, arg: "archivo_local", help: "Archivo del host con el nuevo contenido", path: hostPath},
+	}},
+	{name: "write", summary: "Escribe en un archivo desde una posición o al final", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Archivo a escribir", path: partitionPath},
+		{name: "offset", kind: paramUint, help: "Posición desde donde escribir"},
+		{name: "append", kind: paramBool, help: "Escribe al final del archivo"},
+		{name: "contenido", arg: "archivo_local", help: "Archivo del host con el contenido", path: hostPath},
+		{name: "texto", help: "Texto a escribir"},
+	}, examples: []string{"write -path=/logs/app.log -append -texto=\"nueva línea\""}},
+	{name: "rename", summary: "Cambia el nombre de un archivo o carpeta", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Archivo o carpeta a renombrar", path: partitionPath},
+		{name: "name", required: true, arg: "nombre", help: "Nuevo nombre"},
+	}},
+	{name: "copy", summary: "Copia un archivo o carpeta", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Archivo o carpeta a copiar", path: partitionPath},
+		{name: "destino", required: true, arg: "ruta", help: "Carpeta de destino", path: partitionPath},
+	}},
+	{name: "move", summary: "Mueve un archivo o carpeta", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Archivo o carpeta a mover", path: partitionPath},
+		{name: "destino", required: true, arg: "ruta", help: "Carpeta de destino", path: partitionPath},
+	}},
+	{name: "export", summary: "Copia una carpeta de la partición al host", params: []paramSpec{
+		{name: "path", def: "/", arg: "ruta", help: "Carpeta de la partición", path: partitionPath},
+		{name: "destino", required: true, arg: "directorio|archivo.tar", help: "Directorio o archivo .tar del host", path: hostPath},
+	}, examples: []string{"export -path=/home -destino=/tmp/home.tar"}},
+	{name: "import", summary: "Copia un directorio o .tar del host a la partición", params: []paramSpec{
+		{name: "path", required: true, arg: "directorio|archivo.tar", help: "Directorio o archivo .tar del host", path: hostPath},
+		{name: "destino", def: "/", arg: "ruta", help: "Carpeta de la partición", path: partitionPath},
+	}, examples: []string{"import -path=/tmp/home.tar -destino=/respaldo"}},
+	{name: "find", summary: "Busca archivos y carpetas por nombre", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Carpeta donde inicia la búsqueda", path: partitionPath},
+		{name: "name", required: true, arg: "patrón", help: "Patrón con ? (un carácter) y * (uno o más)"},
+	}, examples: []string{"find -path=/home -name=*.txt"}},
+	{name: "chown", summary: "Cambia el propietario", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Archivo o carpeta", path: partitionPath},
+		{name: "r", kind: paramBool, help: "Aplica el cambio a todo el contenido"},
+		{name: "usuario", required: true, help: "Nuevo propietario"},
+	}, examples: []string{"chown -path=/home -usuario=user2 -r"}},
+	{name: "chmod", summary: "Cambia los permisos", params: []paramSpec{
+		{name: "path", required: true, arg: "ruta", help: "Archivo o carpeta", path: partitionPath},
+		{name: "ugo", required: true, arg: "permisos", help: "Permisos [0-7][0-7][0-7] (usuario, grupo, otros)"},
+		{name: "r", kind: paramBool, help: "Aplica el cambio a todo el contenido"},
+	}, examples: []string{"chmod -path=/home -ugo=764 -r"}},
+
+	// Journaling y recuperación
+	{name: "loss", summary: "Simula la pérdida de los bitmaps y las áreas de inodos y bloques", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición"},
+	}, examples: []string{"loss -id=001A"}},
+	{name: "recovery", summary: "Recupera una partición EXT3 usando el journaling", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición"},
+	}, examples: []string{"recovery -id=001A"}},
+	{name: "journaling", summary: "Genera el reporte del journaling", params: []paramSpec{
+		{name: "id", required: true, upper: true, help: "ID de la partición"},
+	}},
+
+	// Snapshots
+	{name: "mksnapshot", summary: "Crea un snapshot de un disco o de una partición", params: []paramSpec{
+		{name: "path", arg: "ruta", help: "Disco completo", path: hostPath},
+		{name: "id", upper: true, help: "Solo la partición montada"},
+		{name: "name", required: true, arg: "nombre", help: "Nombre del snapshot"},
+	}},
+	{name: "snapshots", summary: "Lista los snapshots", params: []paramSpec{
+		{name: "path", arg: "ruta", help: "Disco", path: hostPath},
+		{name: "id", upper: true, help: "Solo los snapshots de la partición"},
+	}},
+	{name: "rollback", summary: "Restaura un snapshot", params: []paramSpec{
+		{name: "path", arg: "ruta", help: "Disco", path: hostPath},
+		{name: "id", upper: true, help: "Partición montada del disco"},
+		{name: "name", required: true, arg: "nombre", help: "Snapshot a restaurar"},
+	}},
+	{name: "rmsnapshot", summary: "Elimina un snapshot", params: []paramSpec{
+		{name: "path", arg: "ruta", help: "Disco", path: hostPath},
+		{name: "id", upper: true, help: "Partición montada del disco"},
+		{name: "name", required: true, arg: "nombre", help: "Snapshot a eliminar"},
+	}},
+
+	// Scripts y ayuda
+	{name: "exec", summary: "Ejecuta un script", params: []paramSpec{
+		{name: "path", required: true, arg: "script", help: "Ruta del script", path: hostPath},
+		{name: "onerror", def: "continue", values: []string{"continue", "stop"}, lower: true, help: "Qué hacer si un comando falla"},
+	}, examples: []string{"exec -path=test/prueba.smia -onerror=stop"}},
+	{name: "help", summary: "Muestra los comandos o la ayuda de uno", params: []paramSpec{
+		{name: "command", lower: true, arg: "comando", help: "Comando del que mostrar la ayuda"},
+	}, examples: []string{"help -command=mkdisk"}},
+	{name: "exit", summary: "Termina la sesión de comandos"},
+}
+
+// numberedParams genera parámetros opcionales nombre1..nombreN
+func numberedParams(prefix string, count int, arg string, help string, path pathKind) []paramSpec {
+	params := make([]paramSpec, 0, count)
+	for i := 1; i <= count; i++ {
+		params = append(params, paramSpec{name: prefix + strconv.Itoa(i), arg: arg, help: help, path: path})
+	}
+	return params
+}
+
+// findSchema busca el esquema de un comando
+func findSchema(command string) *commandSchema {
+	for i := range commandSchemas {
+		if commandSchemas[i].name == command {
+			return &commandSchemas[i]
+		}
+	}
+	return nil
+}
+
+func (schema *commandSchema) param(name string) *paramSpec {
+	for i := range schema.params {
+		if schema.params[i].name == name {
+			return &schema.params[i]
+		}
+	}
+	return nil
+}
+
+// usage arma la línea de uso del comando
+func (schema *commandSchema) usage() string {
+	parts := []string{schema.name}
+	for _, spec := range schema.params {
+		part := "-" + spec.name
+		switch {
+		case spec.kind == paramBool:
+		case len(spec.values) > 0:
+			part += "=" + strings.Join(spec.values, "|")
+		default:
+			part += "=<" + spec.argName() + ">"
+		}
+		if !spec.required {
+			part = "[" + part + "]"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// help arma la ayuda completa del comando
+func (schema *commandSchema) help() string {
+	var help strings.Builder
+	fmt.Fprintf(&help, "%s - %s\n", schema.name, schema.summary)
+	fmt.Fprintf(&help, "Uso: %s\n", schema.usage())
+	if len(schema.params) > 0 {
+		help.WriteString("Parámetros:\n")
+		table := tabwriter.NewWriter(&help, 0, 4, 2, ' ', 0)
+		for _, spec := range schema.params {
+			detail := "opcional"
+			if spec.required {
+				detail = "obligatorio"
+			} else if spec.def != "" {
+				detail = "default: " + spec.def
+			}
+			fmt.Fprintf(table, "  -%s\t%s\t%s\t%s\n", spec.name, paramTypeNames[spec.kind], detail, spec.help)
+		}
+		table.Flush()
+	}
+	for _, example := range schema.examples {
+		fmt.Fprintf(&help, "Ejemplo: %s\n", example)
+	}
+	return help.String()
+}
+
+func (spec *paramSpec) argName() string {
+	if spec.arg != "" {
+		return spec.arg
+	}
+	if spec.kind != paramString {
+		return "n"
+	}
+	return spec.name
+}
+
+// normalize valida el valor recibido para el parámetro y lo deja en su forma
+// canónica
+func (spec *paramSpec) normalize(token paramToken) (string, error) {
+	if spec.kind == paramBool {
+		if !token.hasValue {
+			return "true", nil
+		}
+		value, err := strconv.ParseBool(token.value)
+		if err != nil {
+			return "", fmt.Errorf("El parámetro -%s solo acepta true o false (recibido: '%s')", spec.name, token.value)
+		}
+		return strconv.FormatBool(value), nil
+	}
+	if !token.hasValue {
+		return "", fmt.Errorf("El parámetro -%s requiere un valor: -%s=<%s>", spec.name, spec.name, spec.argName())
+	}
+
+	value := token.value
+	if spec.lower {
+		value = strings.ToLower(value)
+	}
+	if spec.upper {
+		value = strings.ToUpper(value)
+	}
+
+	if spec.kind != paramString {
+		number, err := strconv.Atoi(value)
+		switch {
+		case err != nil:
+			return "", fmt.Errorf("El parámetro -%s debe ser un número entero (recibido: '%s')", spec.name, token.value)
+		case spec.kind == paramUint && number < 0:
+			return "", fmt.Errorf("El parámetro -%s no puede ser negativo (recibido: %d)", spec.name, number)
+		case spec.kind == paramPositive && number <= 0:
+			return "", fmt.Errorf("El parámetro -%s debe ser mayor a 0 (recibido: %d)", spec.name, number)
+		}
+	}
+
+	if len(spec.values) > 0 && value != "" {
+		for _, allowed := range spec.values {
+			if value == allowed {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("Valor '%s' no válido para -%s (use %s)", token.value, spec.name, strings.Join(spec.values, ", "))
+	}
+	return value, nil
+}
+
+// commandArgs son los parámetros de un comando ya validados
+type commandArgs struct {
+	schema *commandSchema
+	values map[string]string
+}
+
+// parseArgs separa y valida los parámetros de un comando con su esquema.
+// Si algo falla imprime el error y el uso del comando.
+func parseArgs(out io.Writer, command string, params string) (*commandArgs, error) {
+	schema := findSchema(command)
+	if schema == nil {
+		return nil, Utilities.NewCommandError(Utilities.ErrUnknownCommand, "Comando '%s' no reconocido", command)
+	}
+	args := &commandArgs{schema: schema, values: make(map[string]string)}
+
+	tokens, err := tokenizeParams(params)
+	if err != nil {
+		return nil, schema.paramError(out, err)
+	}
+	for _, token := range tokens {
+		spec := schema.param(token.name)
+		if spec == nil {
+			return nil, schema.paramError(out, fmt.Errorf("Parámetro -%s no reconocido por %s", token.name, command))
+		}
+		if _, repeated := args.values[spec.name]; repeated {
+			return nil, schema.paramError(out, fmt.Errorf("El parámetro -%s está repetido", spec.name))
+		}
+		value, err := spec.normalize(token)
+		if err != nil {
+			return nil, schema.paramError(out, err)
+		}
+		args.values[spec.name] = value
+	}
+
+	for _, spec := range schema.params {
+		if !spec.required {
+			continue
+		}
+		value, given := args.values[spec.name]
+		if !given {
+			return nil, schema.paramError(out, fmt.Errorf("El parámetro -%s es obligatorio", spec.name))
+		}
+		if value == "" {
+			return nil, schema.paramError(out, fmt.Errorf("El parámetro -%s no puede estar vacío", spec.name))
+		}
+	}
+	return args, nil
+}
+
+// paramError imprime el error con el uso del comando y lo retorna tipado
+func (schema *commandSchema) paramError(out io.Writer, err error) error {
+	fmt.Fprintf(out, "Error: %v\n", err)
+	fmt.Fprintf(out, "Uso: %s\n", schema.usage())
+	return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "%s", err.Error())
+}
+
+// Has indica si el parámetro se escribió en el comando
+func (args *commandArgs) Has(name string) bool {
+	_, given := args.values[name]
+	return given
+}
+
+func (args *commandArgs) value(name string) string {
+	if value, given := args.values[name]; given {
+		return value
+	}
+	if spec := args.schema.param(name); spec != nil {
+		return spec.def
+	}
+	return ""
+}
+
+// String retorna el valor de un parámetro de texto
+func (args *commandArgs) String(name string) *string {
+	value := args.value(name)
+	return &value
+}
+
+// Int retorna el valor de un parámetro entero
+func (args *commandArgs) Int(name string) *int {
+	value, _ := strconv.Atoi(args.value(name))
+	return &value
+}
+
+// Bool retorna el valor de una bandera
+func (args *commandArgs) Bool(name string) *bool {
+	value := args.value(name) == "true"
+	return &value
+}
+
+// paramToken es un parámetro separado: -nombre=valor o -nombre
+type paramToken struct {
+	name     string
+	value    string
+	hasValue bool
+}
+
+// tokenizeParams separa los parámetros de un comando con las reglas de
+// comillas y escapes de una shell
+func tokenizeParams(params string) ([]paramToken, error) {
+	var tokens []paramToken
+	var word strings.Builder
+	inWord := false
+	equals := -1
+	quote := rune(0)
+
+	flush := func() error {
+		if !inWord {
+			return nil
+		}
+		text := word.String()
+		token := paramToken{name: text}
+		if equals >= 0 {
+			token = paramToken{name: text[:equals], value: text[equals+1:], hasValue: true}
+		}
+		word.Reset()
+		inWord = false
+		equals = -1
+
+		if !strings.HasPrefix(token.name, "-") {
+			return fmt.Errorf("Argumento inesperado '%s': los parámetros se escriben como -nombre=valor", text)
+		}
+		if !paramName.MatchString(token.name) {
+			return fmt.Errorf("Nombre de parámetro no válido '%s'", token.name)
+		}
+		token.name = strings.ToLower(strings.TrimPrefix(token.name, "-"))
+		tokens = append(tokens, token)
+		return nil
+	}
+
+	runes := []rune(params)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("Barra invertida sin carácter que escapar al final")
+			}
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case unicode.IsSpace(r):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case r == '#' && !inWord:
+			i = len(runes)
+		default:
+			if r == '=' && equals < 0 {
+				equals = word.Len()
+			}
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("Comillas %c sin cerrar", quote)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// fn_help muestra la lista de comandos o la ayuda de uno, generada a partir
+// de su esquema
+func fn_help(ctx *Context, params string) (map[string]interface{}, error) {
+	args, err := parseArgs(ctx.Output, "help", params)
+	if err != nil {
+		return nil, err
+	}
+	command := args.String("command")
+
+	if *command == "" {
+		fmt.Fprintln(ctx.Output, "Comandos disponibles:")
+		table := tabwriter.NewWriter(ctx.Output, 0, 4, 2, ' ', 0)
+		names := make([]string, 0, len(commandSchemas))
+		for _, schema := range commandSchemas {
+			fmt.Fprintf(table, "  %s\t%s\n", schema.name, schema.summary)
+			names = append(names, schema.name)
+		}
+		table.Flush()
+		fmt.Fprintln(ctx.Output, "Use help -command=<comando> para ver sus parámetros")
+		return map[string]interface{}{"commands": names}, nil
+	}
+
+	schema := findSchema(*command)
+	if schema == nil {
+		fmt.Fprintf(ctx.Output, "Error: Comando '%s' no reconocido\n", *command)
+		return nil, Utilities.NewCommandError(Utilities.ErrUnknownCommand, "Comando '%s' no reconocido", *command)
+	}
+	fmt.Fprint(ctx.Output, schema.help())
+	return map[string]interface{}{"command": schema.name, "usage": schema.usage()}, nil
+}
@@ -0,0 +1,116 @@
+package Analyzer
+
+import (
+	"io"
+	"proyecto1/Utilities"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenizeParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   []paramToken
+		err    string
+	}{
+		{"vacío", "", nil, ""},
+		{"valor y bandera", "-size=10 -r", []paramToken{{"size", "10", true}, {"r", "", false}}, ""},
+		{"nombre en mayúsculas", "-PATH=/a", []paramToken{{"path", "/a", true}}, ""},
+		{"comillas dobles con espacios", `-path="/home/mis discos/a.mia"`, []paramToken{{"path", "/home/mis discos/a.mia", true}}, ""},
+		{"comillas escapadas", `-cont="dijo \"hola\""`, []paramToken{{"cont", `dijo "hola"`, true}}, ""},
+		{"barra escapada entre comillas", `-path="C:\\tmp"`, []paramToken{{"path", `C:\tmp`, true}}, ""},
+		{"otra barra entre comillas se conserva", `-path="a\nb"`, []paramToken{{"path", `a\nb`, true}}, ""},
+		{"comillas simples literales", `-cont='a "b" \n $c'`, []paramToken{{"cont", `a "b" \n $c`, true}}, ""},
+		{"igual dentro del valor", "-cont=a=b=c", []paramToken{{"cont", "a=b=c", true}}, ""},
+		{"igual entre comillas", `-cont="x=1"`, []paramToken{{"cont", "x=1", true}}, ""},
+		{"comillas en medio de la palabra", `-path=/a"b c"d`, []paramToken{{"path", "/ab cd", true}}, ""},
+		{"valor vacío entre comillas", `-cont=""`, []paramToken{{"cont", "", true}}, ""},
+		{"valor vacío entre comillas simples", `-cont=''`, []paramToken{{"cont", "", true}}, ""},
+		{"valor vacío sin comillas", "-cont=", []paramToken{{"cont", "", true}}, ""},
+		{"nombre con guiones", "-path-file-ls=/a", []paramToken{{"path-file-ls", "/a", true}}, ""},
+		{"espacio escapado", `-path=/a\ b`, []paramToken{{"path", "/a b", true}}, ""},
+		{"comentario", "-size=10 # resto", []paramToken{{"size", "10", true}}, ""},
+		{"numeral dentro del valor", "-name=a#b", []paramToken{{"name", "a#b", true}}, ""},
+		{"comillas dobles sin cerrar", `-path="/a b`, nil, "sin cerrar"},
+		{"comillas simples sin cerrar", `-cont='abc`, nil, "sin cerrar"},
+		{"barra invertida al final", `-path=/a\`, nil, "Barra invertida"},
+		{"argumento sin guion", "size=10", nil, "Argumento inesperado"},
+		{"nombre inválido", "-1size=10", nil, "no válido"},
+		{"guion solo", "- -size=1", nil, "no válido"},
+	}
+	for _, tc := range tests {
+		tokens, err := tokenizeParams(tc.params)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: %q: error %v, se esperaba uno con %q", tc.name, tc.params, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %q: %v", tc.name, tc.params, err)
+			continue
+		}
+		if !reflect.DeepEqual(tokens, tc.want) {
+			t.Errorf("%s: %q: tokens %+v, se esperaba %+v", tc.name, tc.params, tokens, tc.want)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		params  string
+		want    map[string]string
+		err     string
+	}{
+		{"valores normalizados", "mkdisk", "-size=5 -unit=M -fit=FF -path=/a.mia", map[string]string{"size": "5", "unit": "m", "fit": "ff", "path": "/a.mia"}, ""},
+		{"ID en mayúsculas", "mkfs", "-id=851a -fs=3FS", map[string]string{"id": "851A", "fs": "3fs"}, ""},
+		{"bandera", "mkdir", "-p -path=/a", map[string]string{"p": "true", "path": "/a"}, ""},
+		{"bandera con valor", "mkdir", "-p=false -path=/a", map[string]string{"p": "false", "path": "/a"}, ""},
+		{"igual dentro del valor", "mkgrp", "-name=a=b", map[string]string{"name": "a=b"}, ""},
+		{"parámetro desconocido", "mkdisk", "-size=5 -path=/a.mia -color=azul", nil, "no reconocido"},
+		{"nombre con guiones desconocido", "rep", "-name=ls -path=/r.svg -id=851A -path-file-ls=/", nil, "-path-file-ls no reconocido"},
+		{"parámetro repetido", "mkdisk", "-size=5 -size=10 -path=/a.mia", nil, "repetido"},
+		{"repetido con otra capitalización", "mkdisk", "-size=5 -SIZE=5 -path=/a.mia", nil, "repetido"},
+		{"obligatorio ausente", "mkdisk", "-path=/a.mia", nil, "-size es obligatorio"},
+		{"obligatorio vacío", "mkdisk", `-size=5 -path=""`, nil, "-path no puede estar vacío"},
+		{"valor no permitido", "mkdisk", "-size=5 -unit=g -path=/a.mia", nil, "no válido para -unit"},
+		{"entero inválido", "mkdisk", "-size=cinco -path=/a.mia", nil, "número entero"},
+		{"entero no positivo", "mkdisk", "-size=0 -path=/a.mia", nil, "mayor a 0"},
+		{"valor requerido", "mkdisk", "-size -path=/a.mia", nil, "requiere un valor"},
+		{"bandera inválida", "mkdir", "-p=quizas -path=/a", nil, "true o false"},
+		{"comillas sin cerrar", "mkdisk", `-size=5 -path="/a.mia`, nil, "sin cerrar"},
+		{"comando desconocido", "volar", "", nil, "no reconocido"},
+	}
+	for _, tc := range tests {
+		args, err := parseArgs(io.Discard, tc.command, tc.params)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: %s %s: error %v, se esperaba uno con %q", tc.name, tc.command, tc.params, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s %s: %v", tc.name, tc.command, tc.params, err)
+			continue
+		}
+		if !reflect.DeepEqual(args.values, tc.want) {
+			t.Errorf("%s: %s %s: valores %v, se esperaba %v", tc.name, tc.command, tc.params, args.values, tc.want)
+		}
+	}
+}
+
+// Los errores de parámetros son INVALID_ARGUMENT y muestran el uso
+func TestParseArgsErrorCode(t *testing.T) {
+	var out strings.Builder
+	_, err := parseArgs(&out, "mkdisk", "-size=5 -size=6 -path=/a.mia")
+	if code := Utilities.ErrorCode(err); code != Utilities.ErrInvalidArgument {
+		t.Errorf("código %s, se esperaba %s", code, Utilities.ErrInvalidArgument)
+	}
+	if !strings.Contains(out.String(), "Uso: mkdisk") {
+		t.Errorf("la salida no muestra el uso:\n%s", out.String())
+	}
+}
@@ -0,0 +1,153 @@
+package Analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// usersLine retorna la línea de users.txt del usuario indicado
+func usersLine(t *testing.T, id string, user string) string {
+	t.Helper()
+	users, err := FileSystem.GetFileContent(id, "/users.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(users), "\n") {
+		parts := strings.Split(line, ",")
+		if len(parts) == 5 && parts[1] == "U" && parts[3] == user {
+			return line
+		}
+	}
+	t.Fatalf("%s no está en users.txt:\n%s", user, users)
+	return ""
+}
+
+// mkusr guarda el hash bcrypt, nunca la contraseña
+func TestMkusrStoresHash(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkgrp -name=devs")
+	mustRun(t, ctx, "mkusr -user=ana -pass=abc -grp=devs")
+
+	line := usersLine(t, id, "ana")
+	if hash := strings.Split(line, ",")[4]; !strings.HasPrefix(hash, "$2") || len(hash) != 60 {
+		t.Errorf("users.txt no guarda un hash bcrypt: %q", line)
+	}
+}
+
+// mkfs ya escribe la contraseña de root cifrada, antes del primer login
+func TestMkfsStoresRootHash(t *testing.T) {
+	dir := useTempState(t)
+	for _, fs := range []string{"2fs", "3fs"} {
+		disk := filepath.Join(dir, "Disco"+fs+".mia")
+		ctx := NewContext(nil)
+		mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=2 -unit=m -path="%s"`, disk))
+		mustRun(t, ctx, fmt.Sprintf(`fdisk -size=1 -unit=m -path="%s" -name=P1`, disk))
+		id := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P1`, disk)).Data["id"].(string)
+		mustRun(t, ctx, fmt.Sprintf("mkfs -id=%s -fs=%s", id, fs))
+
+		users, err := FileSystem.GetFileContent(id, "/users.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(users), "root,root,123") {
+			t.Errorf("%s: users.txt guarda la contraseña de root en texto plano:\n%s", fs, users)
+		}
+		if hash := strings.Split(usersLine(t, id, "root"), ",")[4]; !strings.HasPrefix(hash, "$2") || len(hash) != 60 {
+			t.Errorf("%s: users.txt no guarda un hash bcrypt para root: %q", fs, hash)
+		}
+
+		result := mustRun(t, ctx, "login -user=root -pass=123 -id="+id)
+		if strings.Contains(result.Output, "migrada") {
+			t.Errorf("%s: el primer login migró la contraseña de root:\n%s", fs, result.Output)
+		}
+		mustRun(t, ctx, "logout")
+	}
+}
+
+// Una contraseña en texto plano de una partición anterior sigue sirviendo
+// para iniciar sesión y queda cifrada después del primer login
+func TestLoginMigratesPlaintextPassword(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	source := filepath.Join(dir, "users.txt")
+	if err := os.WriteFile(source, []byte("1,G,root\n1,U,root,root,123\n2,G,devs\n2,U,devs,ana,abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, NewContext(session), fmt.Sprintf(`edit -path=/users.txt -contenido="%s"`, source))
+
+	ctx := NewContext(nil)
+	if result := runCommand(ctx, "login -user=ana -pass=xyz -id="+id); result.Status != "error" {
+		t.Fatalf("login con contraseña incorrecta: estado %s", result.Status)
+	}
+	if line := usersLine(t, id, "ana"); line != "2,U,devs,ana,abc" {
+		t.Errorf("un login fallido modificó users.txt: %q", line)
+	}
+
+	result := mustRun(t, ctx, "login -user=ana -pass=abc -id="+id)
+	if !strings.Contains(result.Output, "migrada a formato cifrado") {
+		t.Errorf("login no indica la migración:\n%s", result.Output)
+	}
+	if hash := strings.Split(usersLine(t, id, "ana"), ",")[4]; !strings.HasPrefix(hash, "$2") {
+		t.Errorf("la contraseña no se migró: %q", hash)
+	}
+	if line := usersLine(t, id, "root"); line != "1,U,root,root,123" {
+		t.Errorf("el login de ana migró la contraseña de root: %q", line)
+	}
+
+	// Con el hash guardado se sigue iniciando sesión, sin migrar otra vez
+	mustRun(t, ctx, "logout")
+	result = mustRun(t, ctx, "login -user=ana -pass=abc -id="+id)
+	if strings.Contains(result.Output, "migrada") {
+		t.Errorf("el segundo login volvió a migrar la contraseña:\n%s", result.Output)
+	}
+}
+
+// passwd: cada usuario cambia la suya con la contraseña actual y root la de
+// cualquiera
+func TestPasswd(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	root := NewContext(session)
+	mustRun(t, root, "mkgrp -name=devs")
+	mustRun(t, root, "mkusr -user=ana -pass=abc -grp=devs")
+
+	ctx := NewContext(nil)
+	mustRun(t, ctx, "login -user=ana -pass=abc -id="+id)
+	tests := []struct {
+		command string
+		code    string
+	}{
+		{"passwd -old=mal -pass=nueva", Utilities.ErrPermissionDenied},
+		{"passwd -user=root -old=123 -pass=nueva", Utilities.ErrPermissionDenied},
+		{"passwd -old=abc -pass=", Utilities.ErrInvalidArgument},
+		{"passwd -old=abc -pass=muylarga123", Utilities.ErrInvalidArgument},
+	}
+	for _, tt := range tests {
+		result := runCommand(ctx, tt.command)
+		if result.Status != "error" || result.Code != tt.code {
+			t.Errorf("%s: estado %s, código %s, se esperaba %s", tt.command, result.Status, result.Code, tt.code)
+		}
+	}
+
+	mustRun(t, ctx, "passwd -old=abc -pass=nueva")
+	mustRun(t, ctx, "logout")
+	if result := runCommand(ctx, "login -user=ana -pass=abc -id="+id); result.Status != "error" {
+		t.Error("la contraseña anterior sigue sirviendo")
+	}
+	mustRun(t, ctx, "login -user=ana -pass=nueva -id="+id)
+	mustRun(t, ctx, "logout")
+
+	// root no necesita la contraseña actual
+	mustRun(t, root, "passwd -user=ana -pass=otra")
+	mustRun(t, ctx, "login -user=ana -pass=otra -id="+id)
+	if strings.Contains(usersLine(t, id, "ana"), "otra") {
+		t.Error("users.txt guarda la contraseña en texto plano")
+	}
+}
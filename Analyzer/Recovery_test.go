@@ -0,0 +1,176 @@
+package Analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// Con el mkfs todavía en el journaling, recovery reconstruye todo
+func TestRecoveryComplete(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkdir -path=/docs")
+	mustRun(t, ctx, "mkfile -path=/docs/a.txt -size=30")
+	mustRun(t, ctx, "loss -id="+id)
+
+	result := mustRun(t, ctx, "recovery -id="+id)
+	if complete, _ := result.Data["complete"].(bool); !complete {
+		t.Errorf("recovery debería ser completa: %v", result.Data["not_replayed"])
+	}
+	if content, err := FileSystem.GetFileContent(id, "/docs/a.txt"); err != nil || string(content) != fileContent(30) {
+		t.Errorf("/docs/a.txt no se recuperó: %q %v", content, err)
+	}
+}
+
+// Si el journaling ya sobrescribió el mkfs, recovery reproduce lo que queda
+// pero falla con INCOMPLETE e indica lo que no pudo reproducir
+func TestRecoveryJournalWithoutMkfs(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs -journal=5")
+	ctx := NewContext(session)
+	for i := 0; i < 6; i++ {
+		mustRun(t, ctx, fmt.Sprintf("mkdir -path=/d%d", i))
+	}
+	mustRun(t, ctx, "loss -id="+id)
+
+	result := runCommand(ctx, "recovery -id="+id)
+	if result.Status != "error" || result.Code != Utilities.ErrIncomplete {
+		t.Fatalf("recovery: estado %s, código %s, se esperaba %s\n%s", result.Status, result.Code, Utilities.ErrIncomplete, result.Output)
+	}
+	if complete, _ := result.Data["complete"].(bool); complete {
+		t.Error("recovery se marcó como completa")
+	}
+	if notReplayed, _ := result.Data["not_replayed"].([]string); len(notReplayed) == 0 {
+		t.Error("recovery no indica lo que no pudo reproducir")
+	}
+
+	// Las operaciones que siguen en el journaling sí se reprodujeron
+	for i := 2; i < 6; i++ {
+		if _, err := FileSystem.GetDirectoryContents(id, fmt.Sprintf("/d%d", i)); err != nil {
+			t.Errorf("/d%d no se recuperó: %v", i, err)
+		}
+	}
+}
+
+// Un archivo cuyo contenido no cabe en el journaling se rechaza antes de
+// Un contenido que no cabe en el journaling no impide crear, editar ni
+// escribir el archivo: la entrada guarda solo su tamaño y recovery la reporta
+// como no reproducida
+func TestLargeContentJournalsOnlyMetadata(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkgrp -name=devs")
+	mustRun(t, ctx, "mkusr -user=ana -pass=123 -grp=devs")
+	mustRun(t, ctx, "mkdir -path=/docs")
+
+	large := []byte(strings.Repeat("texto ", 850))
+	source := filepath.Join(dir, "grande.txt")
+	if err := os.WriteFile(source, large, 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustRun(t, ctx, fmt.Sprintf(`mkfile -path=/docs/grande.txt -cont="%s"`, source))
+	mustRun(t, ctx, fmt.Sprintf(`mkfile -path=/docs/editado.txt -size=10`))
+	mustRun(t, ctx, fmt.Sprintf(`edit -path=/docs/editado.txt -contenido="%s"`, source))
+	mustRun(t, ctx, fmt.Sprintf(`write -path=/docs/editado.txt -append -contenido="%s"`, source))
+	for path, want := range map[string][]byte{"/docs/grande.txt": large, "/docs/editado.txt": append(append([]byte(nil), large...), large...)} {
+		if content, err := FileSystem.GetFileContent(id, path); err != nil || !bytes.Equal(content, want) {
+			t.Errorf("%s: %d bytes, se esperaban %d (%v)", path, len(content), len(want), err)
+		}
+	}
+
+	mustRun(t, ctx, "loss -id="+id)
+	result := runCommand(ctx, "recovery -id="+id)
+	if result.Status != "error" || result.Code != Utilities.ErrIncomplete {
+		t.Fatalf("recovery: estado %s, código %s, se esperaba %s\n%s", result.Status, result.Code, Utilities.ErrIncomplete, result.Output)
+	}
+	notReplayed, _ := result.Data["not_replayed"].([]string)
+	if len(notReplayed) != 3 {
+		t.Errorf("recovery debería indicar mkfile, edit y write: %q", notReplayed)
+	}
+	for _, item := range notReplayed {
+		if !strings.Contains(item, "no cup") {
+			t.Errorf("razón inesperada: %s", item)
+		}
+	}
+	users, err := FileSystem.GetFileContent(id, "/users.txt")
+	if err != nil || !strings.Contains(string(users), ",G,devs") || !strings.Contains(string(users), ",devs,ana,") {
+		t.Errorf("users.txt no se recuperó:\n%s", users)
+	}
+	if _, err := FileSystem.GetFileContent(id, "/docs/grande.txt"); err != nil {
+		t.Errorf("/docs/grande.txt no se recreó: %v", err)
+	}
+}
+
+// Una operación cuya ruta no cabe en el journaling se registra solo con su
+// nombre, sin saltar la secuencia; recovery la reporta como no reproducida
+func TestRecoveryDetectsUnjournaledEntry(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs -journal=5")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkdir -path=/aaaaaaaaaa")
+	deep := "/aaaaaaaaaa" + strings.Repeat("/bbbbbbbbbb", 16)
+	mustRun(t, ctx, "mkdir -p -path="+deep)
+	mustRun(t, ctx, "loss -id="+id)
+
+	result := runCommand(ctx, "recovery -id="+id)
+	if result.Status != "error" || result.Code != Utilities.ErrIncomplete {
+		t.Fatalf("recovery: estado %s, código %s, se esperaba %s\n%s", result.Status, result.Code, Utilities.ErrIncomplete, result.Output)
+	}
+	notReplayed, _ := result.Data["not_replayed"].([]string)
+	if len(notReplayed) != 1 || !strings.Contains(notReplayed[0], "mkdir") || !strings.Contains(notReplayed[0], "no cupo en el journaling") {
+		t.Errorf("recovery debería indicar solo la operación sin registrar: %q", notReplayed)
+	}
+	if _, err := FileSystem.GetDirectoryContents(id, "/aaaaaaaaaa"); err != nil {
+		t.Errorf("/aaaaaaaaaa no se recuperó: %v", err)
+	}
+}
+
+// Los archivos y directorios que crea otro usuario se recuperan con su
+// propietario, grupo y permisos, también los directorios padre que creó -r
+func TestRecoveryRestoresOwner(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs")
+	mustRun(t, NewContext(session), "mkgrp -name=devs")
+	mustRun(t, NewContext(session), "mkusr -user=ana -pass=123 -grp=devs")
+
+	ctx := NewContext(nil)
+	mustRun(t, ctx, "login -user=ana -pass=123 -id="+id)
+	mustRun(t, ctx, "mkdir -path=/docs")
+	mustRun(t, ctx, "mkfile -r -path=/proyectos/web/a.txt -size=10")
+	uid, gid := int32(ctx.Session.UserID), int32(ctx.Session.GroupID)
+
+	mustRun(t, NewContext(session), "loss -id="+id)
+	result := mustRun(t, NewContext(session), "recovery -id="+id)
+	if complete, _ := result.Data["complete"].(bool); !complete {
+		t.Fatalf("recovery debería ser completa: %v", result.Data["not_replayed"])
+	}
+
+	for _, path := range []string{"/docs", "/proyectos", "/proyectos/web", "/proyectos/web/a.txt"} {
+		parent, name := filepath.Split(path)
+		nodes, err := FileSystem.GetDirectoryContents(id, filepath.Clean(parent))
+		if err != nil {
+			t.Fatalf("%s: %v", parent, err)
+		}
+		found := false
+		for _, node := range nodes {
+			if node.Name != name {
+				continue
+			}
+			found = true
+			if node.OwnerID != uid || node.GroupID != gid || node.Permissions != "664" {
+				t.Errorf("%s: uid %d, gid %d, permisos %s; se esperaba uid %d, gid %d, permisos 664", path, node.OwnerID, node.GroupID, node.Permissions, uid, gid)
+			}
+		}
+		if !found {
+			t.Errorf("%s no se recuperó", path)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package Analyzer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Utilities"
+	"testing"
+)
+
+// readJSONReport lee el documento JSON que escribió rep -format=json
+func readJSONReport(t *testing.T, path string, data interface{}) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := struct {
+		Report      string          `json:"report"`
+		PartitionID string          `json:"partition_id"`
+		Data        json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(content, &document); err != nil {
+		t.Fatalf("%s no es JSON válido: %v", filepath.Base(path), err)
+	}
+	if document.Report == "" || document.PartitionID == "" {
+		t.Errorf("%s no indica el reporte y la partición: %s", filepath.Base(path), content)
+	}
+	if data != nil {
+		if err := json.Unmarshal(document.Data, data); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+// readCSVReport lee la tabla que escribió rep -format=csv
+func readCSVReport(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil || len(records) == 0 {
+		t.Fatalf("%s no es CSV válido: %v", filepath.Base(path), err)
+	}
+	return records
+}
+
+// Todos los reportes se pueden pedir en JSON y en CSV
+func TestRepFormatsAllReports(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkfile -r -path=/docs/a.txt -size=20")
+
+	for _, name := range reportTypes {
+		jsonPath := filepath.Join(dir, name+".json")
+		mustRun(t, ctx, repCommand(name, jsonPath, id, "-format=json"))
+		readJSONReport(t, jsonPath, nil)
+
+		csvPath := filepath.Join(dir, name+".csv")
+		mustRun(t, ctx, repCommand(name, csvPath, id, "-format=csv"))
+		readCSVReport(t, csvPath)
+	}
+}
+
+// Los datos de los reportes coinciden con la partición
+func TestRepData(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkfile -r -path=/docs/a.txt -size=20")
+	sb, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var superblock struct {
+		InodesCount     int32 `json:"inodes_count"`
+		FreeInodesCount int32 `json:"free_inodes_count"`
+		FreeBlocksCount int32 `json:"free_blocks_count"`
+	}
+	path := filepath.Join(dir, "sb.json")
+	mustRun(t, ctx, repCommand("sb", path, id, "-format=json"))
+	readJSONReport(t, path, &superblock)
+	if superblock.InodesCount != sb.S_inodes_count || superblock.FreeInodesCount != sb.S_free_inodes_count || superblock.FreeBlocksCount != sb.S_free_blocks_count {
+		t.Errorf("sb: %+v, el superblock tiene %d inodos, %d libres y %d bloques libres", superblock, sb.S_inodes_count, sb.S_free_inodes_count, sb.S_free_blocks_count)
+	}
+
+	var inodes struct {
+		Inodes []struct {
+			Inode int32 `json:"inode"`
+		} `json:"inodes"`
+	}
+	path = filepath.Join(dir, "inode.json")
+	mustRun(t, ctx, repCommand("inode", path, id, "-format=json"))
+	readJSONReport(t, path, &inodes)
+	if used := sb.S_inodes_count - sb.S_free_inodes_count; int32(len(inodes.Inodes)) != used {
+		t.Errorf("inode: %d inodos, el bitmap tiene %d ocupados", len(inodes.Inodes), used)
+	}
+
+	var file struct {
+		Size    int    `json:"size"`
+		Content string `json:"content"`
+	}
+	path = filepath.Join(dir, "file.json")
+	mustRun(t, ctx, repCommand("file", path, id, "-format=json -path_file_ls=/docs/a.txt"))
+	readJSONReport(t, path, &file)
+	if file.Size != 20 || file.Content != fileContent(20) {
+		t.Errorf("file: %+v", file)
+	}
+
+	var journal struct {
+		Entries []struct {
+			Operation string `json:"operation"`
+			Path      string `json:"path"`
+		} `json:"entries"`
+	}
+	path = filepath.Join(dir, "journaling.json")
+	mustRun(t, ctx, repCommand("journaling", path, id, "-format=json"))
+	readJSONReport(t, path, &journal)
+	if n := len(journal.Entries); n == 0 || journal.Entries[n-1].Operation != "mkfile" || journal.Entries[n-1].Path != "/docs/a.txt" {
+		t.Errorf("journaling: %+v", journal.Entries)
+	}
+
+	// ls en CSV: encabezado y una fila por entrada
+	path = filepath.Join(dir, "ls.csv")
+	mustRun(t, ctx, repCommand("ls", path, id, "-format=csv -path_file_ls=/docs"))
+	records := readCSVReport(t, path)
+	if header := records[0]; len(header) != 10 || header[0] != "name" || header[5] != "size" {
+		t.Fatalf("ls: encabezado %v", header)
+	}
+	found := false
+	for _, row := range records[1:] {
+		if row[0] == "a.txt" {
+			found = true
+			// El reporte ls muestra el UID y el GID
+			if row[3] != "1" || row[4] != "1" || row[5] != "20" {
+				t.Errorf("ls: fila de a.txt %v", row)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("ls no tiene la fila de a.txt: %v", records)
+	}
+}
+
+// Formatos y reportes que no aplican fallan con su código
+func TestRepFormatErrors(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+
+	tests := []struct {
+		command string
+		code    string
+	}{
+		{repCommand("sb", filepath.Join(dir, "sb.xml"), id, "-format=xml"), Utilities.ErrInvalidArgument},
+		{repCommand("journaling", filepath.Join(dir, "j.json"), id, "-format=json"), Utilities.ErrUnsupported},
+		{repCommand("sb", filepath.Join(dir, "sb.json"), "999Z", "-format=json"), Utilities.ErrNotFound},
+	}
+	for _, tt := range tests {
+		result := runCommand(ctx, tt.command)
+		if result.Status != "error" || result.Code != tt.code {
+			t.Errorf("%s: estado %s, código %s, se esperaba %s", tt.command, result.Status, result.Code, tt.code)
+		}
+	}
+}
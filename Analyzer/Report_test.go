@@ -0,0 +1,129 @@
+package Analyzer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// reportTypes son los reportes de rep; file y ls usan -path_file_ls
+var reportTypes = []string{"mbr", "disk", "inode", "block", "bm_inode", "bm_block", "tree", "sb", "file", "ls", "journaling"}
+
+// repCommand arma el comando rep de un reporte hacia path. Si extra no
+// indica -path_file_ls, file usa /users.txt y ls la raíz.
+func repCommand(name string, path string, id string, extra string) string {
+	command := strings.TrimSpace(fmt.Sprintf(`rep -name=%s -path="%s" -id=%s %s`, name, path, id, extra))
+	if strings.Contains(extra, "-path_file_ls=") {
+		return command
+	}
+	switch name {
+	case "file":
+		command += " -path_file_ls=/users.txt"
+	case "ls":
+		command += " -path_file_ls=/"
+	}
+	return command
+}
+
+// checkSVG verifica que el archivo sea un SVG bien formado
+func checkSVG(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root := ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Errorf("%s no es XML válido: %v", filepath.Base(path), err)
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok && root == "" {
+			root = start.Name.Local
+		}
+	}
+	if root != "svg" {
+		t.Errorf("%s: el elemento raíz es %q, se esperaba svg", filepath.Base(path), root)
+	}
+	return string(data)
+}
+
+// Sin Graphviz en el PATH, todos los reportes se dibujan con el
+// renderizador nativo en SVG, PNG, JPG y PDF
+func TestReportsRenderWithoutGraphviz(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=3fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkfile -r -path=/docs/notas.txt -size=20")
+	t.Setenv("PATH", "")
+
+	for _, name := range reportTypes {
+		path := filepath.Join(dir, name+".svg")
+		mustRun(t, ctx, repCommand(name, path, id, ""))
+		checkSVG(t, path)
+	}
+	if svg := checkSVG(t, filepath.Join(dir, "tree.svg")); !strings.Contains(svg, "notas.txt") {
+		t.Error("el SVG del reporte tree no tiene el texto de sus nodos")
+	}
+
+	decoders := map[string]func(io.Reader) error{
+		"png": func(r io.Reader) error { _, err := png.Decode(r); return err },
+		"jpg": func(r io.Reader) error { _, err := jpeg.Decode(r); return err },
+	}
+	for ext, decode := range decoders {
+		path := filepath.Join(dir, "tree."+ext)
+		mustRun(t, ctx, repCommand("tree", path, id, ""))
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := decode(file); err != nil {
+			t.Errorf("tree.%s no es una imagen válida: %v", ext, err)
+		}
+		file.Close()
+	}
+
+	path := filepath.Join(dir, "sb.pdf")
+	mustRun(t, ctx, repCommand("sb", path, id, ""))
+	if data, err := os.ReadFile(path); err != nil || !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.HasSuffix(bytes.TrimSpace(data), []byte("%%EOF")) {
+		t.Errorf("sb.pdf no es un PDF válido: %v", err)
+	}
+}
+
+// Graphviz solo se usa con -renderer=graphviz; sin el binario queda el DOT
+// y la advertencia indica que falta Graphviz
+func TestReportRendererOption(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	t.Setenv("PATH", "")
+
+	result := runCommand(ctx, repCommand("mbr", filepath.Join(dir, "mbr.svg"), id, "-renderer=graphviz"))
+	if !strings.Contains(result.Output, "error ejecutando Graphviz") {
+		t.Errorf("rep -renderer=graphviz sin dot no lo indica:\n%s", result.Output)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mbr.svg")); err == nil {
+		t.Error("rep -renderer=graphviz sin dot generó la imagen")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mbr.dot")); err != nil {
+		t.Errorf("rep -renderer=graphviz sin dot no dejó el DOT: %v", err)
+	}
+	result = runCommand(ctx, repCommand("mbr", filepath.Join(dir, "mbr.svg"), id, "-renderer=otro"))
+	if result.Status != "error" || result.Code != Utilities.ErrInvalidArgument {
+		t.Errorf("rep -renderer=otro: estado %s, código %s, se esperaba %s", result.Status, result.Code, Utilities.ErrInvalidArgument)
+	}
+	mustRun(t, ctx, repCommand("mbr", filepath.Join(dir, "mbr.svg"), id, "-renderer=native"))
+}
@@ -0,0 +1,197 @@
+package Analyzer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"testing"
+)
+
+// checkFiles verifica que los archivos creados con mkfile -size sigan iguales
+func checkFiles(t *testing.T, id string, files map[string]int) {
+	t.Helper()
+	for path, size := range files {
+		if content, err := FileSystem.GetFileContent(id, path); err != nil || string(content) != fileContent(size) {
+			t.Errorf("%s cambió: %v", path, err)
+		}
+	}
+}
+
+// fdisk -add agranda y reduce el sistema de archivos con la partición,
+// conservando archivos y journaling
+func TestFdiskAddResizesFilesystem(t *testing.T) {
+	for _, fs := range []string{"2fs", "3fs"} {
+		t.Run(fs, func(t *testing.T) {
+			dir := useTempState(t)
+			id, session := setupPartition(t, dir, "Disco", "-fs="+fs)
+			disk := filepath.Join(dir, "Disco.mia")
+			ctx := NewContext(session)
+			files := map[string]int{"/docs/a.txt": 30, "/docs/sub/b.txt": 2000, "/c.txt": 700}
+			for path, size := range files {
+				mustRun(t, ctx, fmt.Sprintf("mkfile -r -path=%s -size=%d", path, size))
+			}
+			journal, _ := FileSystem.GetJournalingData(id)
+
+			before, err := FileSystem.ReadSuperblock(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			steps := []struct {
+				add  string
+				grow bool
+			}{{"500", true}, {"-800", false}}
+			for _, step := range steps {
+				inodes := before.S_inodes_count
+				mustRun(t, ctx, fmt.Sprintf(`fdisk -add=%s -unit=k -path="%s" -name=P1`, step.add, disk))
+				after, err := FileSystem.ReadSuperblock(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (after.S_inodes_count > inodes) != step.grow || after.S_inodes_count == inodes {
+					t.Errorf("fdisk -add=%sk: inodos %d -> %d", step.add, inodes, after.S_inodes_count)
+				}
+				if after.S_blocks_count != 3*after.S_inodes_count {
+					t.Errorf("fdisk -add=%sk: %d bloques para %d inodos", step.add, after.S_blocks_count, after.S_inodes_count)
+				}
+				checkFiles(t, id, files)
+				if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+					t.Errorf("fdisk -add=%sk: fsck encontró inconsistencias: %+v", step.add, report.Issues)
+				}
+				before = after
+			}
+
+			if entries, _ := FileSystem.GetJournalingData(id); len(entries) != len(journal) {
+				t.Errorf("el journaling tenía %d entradas y quedó con %d", len(journal), len(entries))
+			}
+			mustRun(t, ctx, "mkfile -path=/docs/nuevo.txt -size=50")
+		})
+	}
+}
+
+// Si los inodos o bloques ocupados no caben en el nuevo tamaño, fdisk -add
+// falla con NO_SPACE y no cambia la partición
+func TestFdiskAddRejectsShrinkBelowUsed(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	disk := filepath.Join(dir, "Disco.mia")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkfile -path=/grande.txt -size=20000")
+	size := partitionByName(t, disk, "P1").Size
+	before, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := runCommand(ctx, fmt.Sprintf(`fdisk -add=-1000 -unit=k -path="%s" -name=P1`, disk))
+	if result.Status != "error" || result.Code != Utilities.ErrNoSpace {
+		t.Fatalf("fdisk -add: estado %s, código %s, se esperaba %s\n%s", result.Status, result.Code, Utilities.ErrNoSpace, result.Output)
+	}
+	if got := partitionByName(t, disk, "P1").Size; got != size {
+		t.Errorf("la partición cambió de %d a %d bytes", size, got)
+	}
+	if after, err := FileSystem.ReadSuperblock(id); err != nil || after.S_inodes_count != before.S_inodes_count {
+		t.Errorf("el sistema de archivos cambió: %d -> %d inodos", before.S_inodes_count, after.S_inodes_count)
+	}
+	checkFiles(t, id, map[string]int{"/grande.txt": 20000})
+}
+
+// failTableWrite hace fallar las escrituras de length bytes en position (el
+// MBR o un EBR); las demás siguen pasando por el hook de los snapshots.
+// Retorna la función que restaura el hook anterior.
+func failTableWrite(position int64, length int64) func() {
+	previous := Utilities.SetWriteHook(nil)
+	Utilities.SetWriteHook(func(file *os.File, at int64, size int64) error {
+		if at == position && size == length {
+			return errors.New("escritura de la tabla de particiones forzada a fallar")
+		}
+		if previous != nil {
+			return previous(file, at, size)
+		}
+		return nil
+	})
+	return func() { Utilities.SetWriteHook(previous) }
+}
+
+// Si fdisk -add no puede escribir el MBR o el EBR, el sistema de archivos no
+// se redimensiona; si el sistema de archivos no se puede redimensionar, la
+// tabla vuelve al tamaño anterior
+func TestFdiskAddTableWriteFailure(t *testing.T) {
+	for _, kind := range []string{"primaria", "lógica"} {
+		t.Run(kind, func(t *testing.T) {
+			dir := useTempState(t)
+			disk := filepath.Join(dir, "Disco.mia")
+			ctx := NewContext(nil)
+			name := "P1"
+			mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=4 -unit=m -path="%s"`, disk))
+			if kind == "primaria" {
+				mustRun(t, ctx, fmt.Sprintf(`fdisk -size=1 -unit=m -path="%s" -name=P1`, disk))
+			} else {
+				name = "L1"
+				mustRun(t, ctx, fmt.Sprintf(`fdisk -size=2 -unit=m -type=e -path="%s" -name=E`, disk))
+				mustRun(t, ctx, fmt.Sprintf(`fdisk -size=1 -unit=m -type=l -path="%s" -name=L1`, disk))
+			}
+			mounted := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=%s`, disk, name))
+			id := mounted.Data["id"].(string)
+			mustRun(t, ctx, "mkfs -fs=2fs -id="+id)
+			mustRun(t, ctx, "login -user=root -pass=123 -id="+id)
+			mustRun(t, ctx, "mkfile -path=/grande.txt -size=20000")
+			// En las lógicas mkfile no actualiza los contadores del superblock:
+			// se compara contra lo que fsck ya encuentra antes de fdisk
+			issues := len(fsckReport(t, ctx, "fsck -id="+id).Issues)
+
+			// Tamaño de la partición y posición de su entrada en la tabla
+			size := func() int32 {
+				if kind == "primaria" {
+					return partitionByName(t, disk, name).Size
+				}
+				_, ebr := logicalByName(t, disk, partitionByName(t, disk, "E"), name)
+				return ebr.Part_size
+			}
+			position, length := int64(0), int64(binary.Size(Structs.MBR{}))
+			if kind == "lógica" {
+				ebrPosition, _ := logicalByName(t, disk, partitionByName(t, disk, "E"), name)
+				position, length = int64(ebrPosition), int64(binary.Size(Structs.EBR{}))
+			}
+			before, err := FileSystem.ReadSuperblock(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			original := size()
+
+			check := func(step string) {
+				t.Helper()
+				if got := size(); got != original {
+					t.Errorf("%s: la partición cambió de %d a %d bytes", step, original, got)
+				}
+				if after, err := FileSystem.ReadSuperblock(id); err != nil || after.S_inodes_count != before.S_inodes_count {
+					t.Errorf("%s: el sistema de archivos cambió: %d -> %d inodos", step, before.S_inodes_count, after.S_inodes_count)
+				}
+				checkFiles(t, id, map[string]int{"/grande.txt": 20000})
+				if report := fsckReport(t, ctx, "fsck -id="+id); len(report.Issues) != issues {
+					t.Errorf("%s: fsck encontró inconsistencias nuevas: %+v", step, report.Issues)
+				}
+			}
+
+			// La escritura de la tabla falla: no se toca el sistema de archivos
+			restore := failTableWrite(position, length)
+			result := runCommand(ctx, fmt.Sprintf(`fdisk -add=500 -unit=k -path="%s" -name=%s`, disk, name))
+			restore()
+			if result.Status != "error" || result.Code != Utilities.ErrIO {
+				t.Fatalf("fdisk -add con la tabla sin escribir: estado %s, código %s\n%s", result.Status, result.Code, result.Output)
+			}
+			check("tabla sin escribir")
+
+			// El sistema de archivos no cabe: la tabla vuelve al tamaño anterior
+			result = runCommand(ctx, fmt.Sprintf(`fdisk -add=-1000 -unit=k -path="%s" -name=%s`, disk, name))
+			if result.Status != "error" || result.Code != Utilities.ErrNoSpace {
+				t.Fatalf("fdisk -add=-1000k: estado %s, código %s\n%s", result.Status, result.Code, result.Output)
+			}
+			check("sistema de archivos sin redimensionar")
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package Analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"regexp"
+	"strings"
+)
+
+// ============================================================================
+// EJECUCIÓN DE SCRIPTS (.smia)
+// ============================================================================
+
+// Un script tiene un comando por línea y admite:
+//   - comentarios con # al inicio de la línea o después de un espacio
+//   - continuación de línea con \ al final
+//   - variables: "set NOMBRE=valor" y ${NOMBRE}; si no está definida en el
+//     script se busca en el entorno. ${SCRIPT_DIR} es la carpeta del script.
+//   - inclusión de otros scripts con exec -path=<ruta>, relativa al script
+//     que la incluye
+
+// maxScriptDepth limita la profundidad de scripts incluidos
+const maxScriptDepth = 16
+
+var scriptVariable = regexp.MustCompile(`\$\{(\w+)\}`)
+var scriptAssignment = regexp.MustCompile(`^(?i:set)\s+(\w+)=(.*)$`)
+
+// scriptLine es el resultado de una línea de un script
+type scriptLine struct {
+	Script  string `json:"script"`
+	Line    int    `json:"line"`
+	Command string `json:"command"`
+	Status  string `json:"status"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// scriptRun guarda el estado de una ejecución: las variables y el resultado
+// de cada línea, compartidos con los scripts incluidos
+type scriptRun struct {
+	ctx         *Context
+	stopOnError bool
+	variables   map[string]string
+	stack       []string
+	lines       []scriptLine
+	stopped     bool
+}
+
+// sourceLine es un comando del script ya unido con sus continuaciones
+type sourceLine struct {
+	number int
+	text   string
+}
+
+func fn_exec(ctx *Context, params string) (map[string]interface{}, error) {
+	// Validar los parámetros con el esquema del comando
+	args, err := parseArgs(ctx.Output, "exec", params)
+	if err != nil {
+		return nil, err
+	}
+	path := args.String("path")
+	mode := *args.String("onerror")
+
+	run := &scriptRun{
+		ctx:         ctx,
+		stopOnError: mode == "stop",
+		variables:   make(map[string]string),
+	}
+	if err := run.execScript(*path); err != nil {
+		fmt.Fprintf(ctx.Output, "Error: %v\n", err)
+		return nil, err
+	}
+
+	// Resumen por línea
+	passed, failed := 0, 0
+	fmt.Fprintln(ctx.Output, "======================================")
+	fmt.Fprintln(ctx.Output, "RESUMEN DEL SCRIPT:", *path)
+	fmt.Fprintln(ctx.Output, "======================================")
+	for _, line := range run.lines {
+		mark := "OK   "
+		if line.Status == "error" {
+			mark = "ERROR"
+			failed++
+		} else {
+			passed++
+		}
+		fmt.Fprintf(ctx.Output, "[%s] %s:%d  %s\n", mark, filepath.Base(line.Script), line.Line, line.Command)
+		if line.Status == "error" {
+			fmt.Fprintf(ctx.Output, "        %s: %s\n", line.Code, line.Message)
+		}
+	}
+	fmt.Fprintf(ctx.Output, "Total: %d  Correctos: %d  Fallidos: %d\n", len(run.lines), passed, failed)
+
+	data := map[string]interface{}{
+		"script":  *path,
+		"onerror": mode,
+		"total":   len(run.lines),
+		"passed":  passed,
+		"failed":  failed,
+		"stopped": run.stopped,
+		"lines":   run.lines,
+	}
+	if run.stopOnError && failed > 0 {
+		last := run.lines[len(run.lines)-1]
+		fmt.Fprintln(ctx.Output, "Ejecución detenida por el error anterior")
+		return data, Utilities.NewCommandError(Utilities.ErrScriptFailed, "El script se detuvo en %s:%d (%s)", last.Script, last.Line, last.Message)
+	}
+	return data, nil
+}
+
+// execScript ejecuta un script y los que este incluya
+func (run *scriptRun) execScript(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Ruta de script no válida: %s", path)
+	}
+	for _, open := range run.stack {
+		if open == absPath {
+			return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El script %s se incluye a sí mismo", path)
+		}
+	}
+	if len(run.stack) >= maxScriptDepth {
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Demasiados scripts anidados (máximo %d)", maxScriptDepth)
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Utilities.NewCommandError(Utilities.ErrNotFound, "El script %s no existe", path)
+		}
+		return fmt.Errorf("error leyendo el script %s: %v", path, err)
+	}
+
+	run.stack = append(run.stack, absPath)
+	defer func() { run.stack = run.stack[:len(run.stack)-1] }()
+
+	previousDir, hadDir := run.variables["SCRIPT_DIR"]
+	run.variables["SCRIPT_DIR"] = filepath.Dir(absPath)
+	defer func() {
+		if hadDir {
+			run.variables["SCRIPT_DIR"] = previousDir
+		} else {
+			delete(run.variables, "SCRIPT_DIR")
+		}
+	}()
+
+	for _, source := range splitScript(string(content)) {
+		if run.stopped {
+			break
+		}
+		run.execLine(absPath, source)
+	}
+	return nil
+}
+
+// execLine sustituye las variables de una línea y la ejecuta
+func (run *scriptRun) execLine(script string, source sourceLine) {
+	line, err := run.expand(source.text)
+	if err != nil {
+		fmt.Fprintf(run.ctx.Output, ">>> [%s:%d] %s\n", filepath.Base(script), source.number, source.text)
+		fmt.Fprintf(run.ctx.Output, "Error: %v\n\n", err)
+		run.record(script, source.number, source.text, newCommandResult("", "", nil, err))
+		return
+	}
+
+	// Definición de variables
+	if match := scriptAssignment.FindStringSubmatch(line); match != nil {
+		run.variables[match[1]] = strings.Trim(strings.TrimSpace(match[2]), "\"")
+		return
+	}
+
+	fmt.Fprintf(run.ctx.Output, ">>> [%s:%d] %s\n", filepath.Base(script), source.number, line)
+	command, params := getCommandAndParams(line)
+
+	// Scripts incluidos: la ruta es relativa al script actual
+	if command == "exec" {
+		if err := run.include(script, params); err != nil {
+			fmt.Fprintf(run.ctx.Output, "Error: %v\n\n", err)
+			run.record(script, source.number, line, newCommandResult(command, params, nil, err))
+		}
+		return
+	}
+
+	result := processCommand(run.ctx, line)
+	run.record(script, source.number, line, result)
+	if command == "exit" {
+		run.stopped = true
+	}
+}
+
+// include ejecuta el script indicado por los parámetros de un exec anidado
+func (run *scriptRun) include(script string, params string) error {
+	// -onerror se acepta pero se usa el modo del script principal
+	args, err := parseArgs(run.ctx.Output, "exec", params)
+	if err != nil {
+		return err
+	}
+	target := *args.String("path")
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(script), target)
+	}
+	return run.execScript(target)
+}
+
+// record guarda el resultado de una línea y detiene la ejecución si falló y
+// así se pidió
+func (run *scriptRun) record(script string, number int, command string, result Structs.CommandResult) {
+	run.lines = append(run.lines, scriptLine{
+		Script:  script,
+		Line:    number,
+		Command: command,
+		Status:  result.Status,
+		Code:    result.Code,
+		Message: result.Message,
+	})
+	if result.Status == "error" && run.stopOnError {
+		run.stopped = true
+	}
+}
+
+// expand reemplaza ${NOMBRE} con las variables del script o del entorno
+func (run *scriptRun) expand(line string) (string, error) {
+	var missing []string
+	expanded := scriptVariable.ReplaceAllStringFunc(line, func(reference string) string {
+		name := scriptVariable.FindStringSubmatch(reference)[1]
+		if value, ok := run.variables[name]; ok {
+			return value
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		missing = append(missing, name)
+		return reference
+	})
+	if len(missing) > 0 {
+		return "", Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Variable no definida: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// splitScript separa el script en comandos: quita los comentarios, une las
+// líneas terminadas en \ y descarta las vacías. Cada comando conserva el
+// número de la línea donde empieza.
+func splitScript(content string) []sourceLine {
+	var commands []sourceLine
+	var pending strings.Builder
+	start := 0
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, raw := range lines {
+		text := strings.TrimSpace(stripComment(raw))
+		continued := strings.HasSuffix(text, "\\")
+		if continued {
+			text = strings.TrimSpace(strings.TrimSuffix(text, "\\"))
+		}
+
+		if text != "" {
+			if pending.Len() == 0 {
+				start = i + 1
+			} else {
+				pending.WriteString(" ")
+			}
+			pending.WriteString(text)
+		}
+
+		if !continued && pending.Len() > 0 {
+			commands = append(commands, sourceLine{number: start, text: pending.String()})
+			pending.Reset()
+		}
+	}
+	if pending.Len() > 0 {
+		commands = append(commands, sourceLine{number: start, text: pending.String()})
+	}
+	return commands
+}
+
+// stripComment corta la línea en el primer # que no esté entre comillas y
+// que inicie la línea o siga a un espacio
+func stripComment(line string) string {
+	quoted := false
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '#' && !quoted && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
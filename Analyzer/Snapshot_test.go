@@ -0,0 +1,53 @@
+package Analyzer
+
+import (
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"testing"
+)
+
+// findNode busca un archivo o carpeta de la partición en el listado de su
+// directorio padre
+func findNode(t *testing.T, id string, path string) FileSystem.FileSystemNode {
+	t.Helper()
+	parent, name := filepath.Split(path)
+	nodes, err := FileSystem.GetDirectoryContents(id, filepath.Clean(parent))
+	if err != nil {
+		t.Fatalf("%s: %v", parent, err)
+	}
+	for _, node := range nodes {
+		if node.Name == name {
+			return node
+		}
+	}
+	t.Fatalf("%s no existe", path)
+	return FileSystem.FileSystemNode{}
+}
+
+// chmod y chown escriben el inodo con WriteObject, así que el snapshot guarda
+// el inodo anterior y rollback lo restaura
+func TestRollbackUndoesChmodChown(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkgrp -name=devs")
+	mustRun(t, ctx, "mkusr -user=ana -pass=123 -grp=devs")
+	mustRun(t, ctx, "mkdir -path=/docs")
+	mustRun(t, ctx, "mkfile -path=/docs/a.txt -size=20")
+	before := findNode(t, id, "/docs/a.txt")
+
+	mustRun(t, ctx, "mksnapshot -id="+id+" -name=antes")
+	mustRun(t, ctx, "chmod -path=/docs -ugo=700 -r")
+	mustRun(t, ctx, "chown -path=/docs/a.txt -usuario=ana")
+	changed := findNode(t, id, "/docs/a.txt")
+	if changed.Permissions != "700" || changed.OwnerID == before.OwnerID {
+		t.Fatalf("chmod y chown no cambiaron el archivo: permisos %s, uid %d", changed.Permissions, changed.OwnerID)
+	}
+
+	mustRun(t, ctx, "rollback -id="+id+" -name=antes")
+	for _, path := range []string{"/docs", "/docs/a.txt"} {
+		if node := findNode(t, id, path); node.Permissions != "777" || node.OwnerID != before.OwnerID {
+			t.Errorf("%s después del rollback: permisos %s, uid %d; se esperaba 777 y uid %d", path, node.Permissions, node.OwnerID, before.OwnerID)
+		}
+	}
+}
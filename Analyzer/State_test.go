@@ -0,0 +1,147 @@
+package Analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"proyecto1/DiskManagement"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// restart simula un reinicio del servidor: vacía el registro cargando un
+// archivo de estado que no existe y luego lo reconstruye desde stateFile
+func restart(t *testing.T, stateFile string) string {
+	t.Helper()
+	DiskManagement.SetStateFile(filepath.Join(t.TempDir(), "vacio.json"))
+	if err := DiskManagement.LoadState(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if mounted := DiskManagement.GetMountedPartitions(); len(mounted) != 0 {
+		t.Fatalf("el registro no quedó vacío: %v", mounted)
+	}
+
+	var out bytes.Buffer
+	DiskManagement.SetStateFile(stateFile)
+	if err := DiskManagement.LoadState(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+// registryStatus ejecuta registry y retorna el estado de cada entrada por
+// tipo e identificador (ID del montaje o letra del drive)
+func registryStatus(t *testing.T, ctx *Context, command string) map[string]string {
+	t.Helper()
+	result := mustRun(t, ctx, command)
+	status := make(map[string]string)
+	for _, entry := range result.Data["entries"].([]DiskManagement.RegistryEntry) {
+		name := entry.Id
+		if entry.Kind == "drive" {
+			name = entry.Drive
+		}
+		status[entry.Kind+" "+name] = entry.Status
+	}
+	return status
+}
+
+// El registro se guarda en el archivo de estado y vuelve igual después de un
+// reinicio; registry marca los montajes cuyo disco ya no existe y registry
+// -fix los elimina del registro y del archivo, o repara los que siguen en
+// el disco
+func TestStateSurvivesRestart(t *testing.T) {
+	dir := useTempState(t)
+	stateFile := filepath.Join(dir, "state.json")
+	ctx := NewContext(nil)
+	disks := map[string]string{}
+	ids := map[string]string{}
+	for _, name := range []string{"A", "B"} {
+		disks[name] = filepath.Join(dir, name+".mia")
+		mustRun(t, ctx, fmt.Sprintf(`mkdisk -size=2 -unit=m -path="%s"`, disks[name]))
+		mustRun(t, ctx, fmt.Sprintf(`fdisk -size=500 -unit=k -path="%s" -name=P1`, disks[name]))
+		mounted := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P1`, disks[name]))
+		ids[name] = mounted.Data["id"].(string)
+	}
+	mustRun(t, ctx, "mkfs -id="+ids["A"])
+	mustRun(t, ctx, "login -user=root -pass=123 -id="+ids["A"])
+	mustRun(t, ctx, "mkfile -path=/antes.txt -size=10")
+	mustRun(t, ctx, "logout")
+	before := DiskManagement.GetMountedPartitions()
+
+	if output := restart(t, stateFile); !strings.Contains(output, "2 particiones montadas") || strings.Contains(output, "Advertencia") {
+		t.Errorf("LoadState:\n%s", output)
+	}
+	if after := DiskManagement.GetMountedPartitions(); !reflect.DeepEqual(after, before) {
+		t.Fatalf("los montajes cambiaron con el reinicio:\n%v\n%v", before, after)
+	}
+	for name, path := range disks {
+		if got, exists := DiskManagement.GetDrivePath(name); !exists || got != path {
+			t.Errorf("drive %s: %q, se esperaba %q", name, got, path)
+		}
+	}
+	// Los IDs restaurados se usan como antes y los contadores no repiten IDs
+	mustRun(t, ctx, "login -user=root -pass=123 -id="+ids["A"])
+	mustRun(t, ctx, "mkfile -path=/despues.txt -size=10")
+	mustRun(t, ctx, "logout")
+	mustRun(t, ctx, fmt.Sprintf(`fdisk -size=500 -unit=k -path="%s" -name=P2`, disks["A"]))
+	mounted := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P2`, disks["A"]))
+	if id := mounted.Data["id"].(string); id == ids["A"] || id == ids["B"] {
+		t.Errorf("el montaje nuevo repitió el ID %s", id)
+	}
+	ids["A2"] = mounted.Data["id"].(string)
+
+	// B desaparece y A2 pierde la marca de montada en el MBR
+	if err := os.Remove(disks["B"]); err != nil {
+		t.Fatal(err)
+	}
+	mbr := readMBR(t, disks["A"])
+	for i := range mbr.Partitions {
+		if strings.Trim(string(mbr.Partitions[i].Name[:]), "\x00") == "P2" {
+			mbr.Partitions[i].Status[0] = '0'
+		}
+	}
+	corruptDisk(t, disks["A"], mbr, 0)
+
+	if output := restart(t, stateFile); !strings.Contains(output, "Advertencia: 2 montajes") {
+		t.Errorf("LoadState no avisó de los montajes desactualizados:\n%s", output)
+	}
+	want := map[string]string{
+		"mount " + ids["A"]:  "ok",
+		"mount " + ids["A2"]: "stale",
+		"mount " + ids["B"]:  "stale",
+		"drive A":            "ok",
+		"drive B":            "stale",
+	}
+	if got := registryStatus(t, ctx, "registry"); !reflect.DeepEqual(got, want) {
+		t.Errorf("registry: %v, se esperaba %v", got, want)
+	}
+	if len(DiskManagement.GetMountedPartitions()) != 3 {
+		t.Errorf("registry sin -fix cambió el registro")
+	}
+
+	want["mount "+ids["A2"]] = "repaired"
+	want["mount "+ids["B"]] = "removed"
+	want["drive B"] = "removed"
+	if got := registryStatus(t, ctx, "registry -fix"); !reflect.DeepEqual(got, want) {
+		t.Errorf("registry -fix: %v, se esperaba %v", got, want)
+	}
+
+	// Lo que -fix reconcilió queda guardado en el archivo de estado
+	if output := restart(t, stateFile); strings.Contains(output, "Advertencia") {
+		t.Errorf("LoadState después de registry -fix:\n%s", output)
+	}
+	mountedIDs := DiskManagement.GetMountedPartitions()
+	if _, exists := mountedIDs[ids["B"]]; exists || len(mountedIDs) != 2 {
+		t.Errorf("montajes después de registry -fix: %v", mountedIDs)
+	}
+	if _, exists := DiskManagement.GetDrivePath("B"); exists {
+		t.Errorf("el drive B sigue registrado")
+	}
+	want = map[string]string{"mount " + ids["A"]: "ok", "mount " + ids["A2"]: "ok", "drive A": "ok"}
+	if got := registryStatus(t, ctx, "registry"); !reflect.DeepEqual(got, want) {
+		t.Errorf("registry después de -fix: %v, se esperaba %v", got, want)
+	}
+}
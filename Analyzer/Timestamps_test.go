@@ -0,0 +1,98 @@
+package Analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// oldTimestamp es una fecha anterior a cualquier prueba, en TimestampLayout
+const oldTimestamp = "20000101 00:00:00"
+
+// inodeTimes retorna las fechas de creación, modificación y acceso del inodo
+func inodeTimes(inode Structs.Inode) (string, string, string) {
+	trim := func(stamp [17]byte) string { return strings.TrimRight(string(stamp[:]), "\x00") }
+	return trim(inode.I_ctime), trim(inode.I_mtime), trim(inode.I_atime)
+}
+
+// backdate pone oldTimestamp en las tres fechas del inodo, para ver cuáles
+// cambia el comando siguiente sin esperar a que pase un segundo
+func backdate(t *testing.T, diskPath string, sb *Structs.Superblock, inodeIndex int32) {
+	t.Helper()
+	inode := readInode(t, diskPath, sb, inodeIndex)
+	copy(inode.I_ctime[:], oldTimestamp)
+	copy(inode.I_mtime[:], oldTimestamp)
+	copy(inode.I_atime[:], oldTimestamp)
+	corruptDisk(t, diskPath, inode, sb.S_inode_start+inodeIndex*sb.S_inode_size)
+}
+
+// Crear un archivo pone la hora actual en sus tres fechas y en la de
+// modificación del directorio; escribirlo cambia modificación y acceso, y
+// leerlo solo el acceso. El explorador muestra las mismas fechas.
+func TestInodeTimestamps(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	disk := filepath.Join(dir, "Disco.mia")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkdir -path=/docs")
+	source := filepath.Join(dir, "contenido.txt")
+	if err := os.WriteFile(source, []byte("contenido nuevo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sb, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := findNode(t, id, "/docs").Inode
+	backdate(t, disk, sb, docs)
+
+	start := Utilities.CurrentTimestamp()
+	mustRun(t, ctx, "mkfile -path=/docs/a.txt -size=10")
+	node := findNode(t, id, "/docs/a.txt")
+	created, modified, accessed := inodeTimes(readInode(t, disk, sb, node.Inode))
+	if created < start || modified != created || accessed != created {
+		t.Fatalf("mkfile: creación %q, modificación %q, acceso %q; se esperaba la hora actual (desde %q) en las tres", created, modified, accessed, start)
+	}
+	if node.CreatedAt != Utilities.FormatTimestamp(created) || node.ModifiedAt != node.CreatedAt || node.AccessedAt != node.CreatedAt {
+		t.Errorf("mkfile: el nodo tiene created_at %q, modified_at %q, accessed_at %q; se esperaba %q", node.CreatedAt, node.ModifiedAt, node.AccessedAt, Utilities.FormatTimestamp(created))
+	}
+	if _, dirModified, _ := inodeTimes(readInode(t, disk, sb, docs)); dirModified < start {
+		t.Errorf("mkfile: la modificación de /docs quedó en %q", dirModified)
+	}
+
+	steps := []struct {
+		command  string
+		modified bool
+	}{
+		{fmt.Sprintf(`write -path=/docs/a.txt -offset=2 -contenido="%s"`, source), true},
+		{fmt.Sprintf(`edit -path=/docs/a.txt -contenido="%s"`, source), true},
+		{"cat -file1=/docs/a.txt", false},
+	}
+	for _, step := range steps {
+		backdate(t, disk, sb, node.Inode)
+		start := Utilities.CurrentTimestamp()
+		mustRun(t, ctx, step.command)
+
+		created, modified, accessed := inodeTimes(readInode(t, disk, sb, node.Inode))
+		if created != oldTimestamp {
+			t.Errorf("%s: la fecha de creación cambió a %q", step.command, created)
+		}
+		if accessed < start {
+			t.Errorf("%s: la fecha de acceso quedó en %q", step.command, accessed)
+		}
+		if step.modified && modified < start || !step.modified && modified != oldTimestamp {
+			t.Errorf("%s: la fecha de modificación quedó en %q", step.command, modified)
+		}
+
+		node := findNode(t, id, "/docs/a.txt")
+		if node.CreatedAt != Utilities.FormatTimestamp(oldTimestamp) || node.ModifiedAt != Utilities.FormatTimestamp(modified) || node.AccessedAt != Utilities.FormatTimestamp(accessed) {
+			t.Errorf("%s: el nodo tiene created_at %q, modified_at %q, accessed_at %q", step.command, node.CreatedAt, node.ModifiedAt, node.AccessedAt)
+		}
+	}
+}
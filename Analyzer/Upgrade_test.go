@@ -0,0 +1,221 @@
+package Analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+	"testing"
+)
+
+// downgradeLayout deja la primera partición del disco con la distribución
+// anterior a los campos del journaling en el superblock: el superblock
+// pierde sus últimos 16 bytes y todo lo que le sigue se corre hacia el inicio
+func downgradeLayout(t *testing.T, diskPath string) {
+	t.Helper()
+	file, err := os.OpenFile(diskPath, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var mbr Structs.MBR
+	var sb Structs.Superblock
+	if err := Utilities.ReadObject(file, &mbr, 0); err != nil {
+		t.Fatal(err)
+	}
+	start := int64(mbr.Partitions[0].Start)
+	if err := Utilities.ReadObject(file, &sb, start); err != nil || sb.S_magic != 0xEF53 {
+		t.Fatalf("%s no tiene un sistema de archivos", diskPath)
+	}
+
+	const journalFields = 16
+	current := int64(binary.Size(sb))
+	rest := make([]byte, int64(mbr.Partitions[0].Size)-current)
+	if _, err := file.ReadAt(rest, start+current); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteAt(rest, start+current-journalFields); err != nil {
+		t.Fatal(err)
+	}
+
+	sb.S_bm_inode_start -= journalFields
+	sb.S_bm_block_start -= journalFields
+	sb.S_inode_start -= journalFields
+	sb.S_block_start -= journalFields
+	var encoded bytes.Buffer
+	binary.Write(&encoded, binary.LittleEndian, sb)
+	if _, err := file.WriteAt(encoded.Bytes()[:current-journalFields], start); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Una partición formateada con el superblock anterior se migra al montarla
+// y conserva sus archivos, usuarios y (en EXT3) su journaling
+func TestMountUpgradesLegacyLayout(t *testing.T) {
+	for _, fs := range []string{"2fs", "3fs"} {
+		t.Run(fs, func(t *testing.T) {
+			dir := useTempState(t)
+			id, session := setupPartition(t, dir, "Disco", "-fs="+fs)
+			ctx := NewContext(session)
+			mustRun(t, ctx, "mkgrp -name=devs")
+			mustRun(t, ctx, "mkusr -user=ana -pass=abc -grp=devs")
+			mustRun(t, ctx, "mkfile -r -path=/docs/a.txt -size=100")
+			mustRun(t, ctx, "logout")
+			mustRun(t, ctx, "unmount -id="+id)
+
+			disk := filepath.Join(dir, "Disco.mia")
+			downgradeLayout(t, disk)
+			mounted := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=P1`, disk))
+			if !strings.Contains(mounted.Output, "migrada") {
+				t.Errorf("mount no migró la partición:\n%s", mounted.Output)
+			}
+			id = mounted.Data["id"].(string)
+			mustRun(t, ctx, fmt.Sprintf("login -user=ana -pass=abc -id=%s", id))
+			mustRun(t, ctx, "logout")
+			mustRun(t, ctx, fmt.Sprintf("login -user=root -pass=123 -id=%s", id))
+			mustRun(t, ctx, "mkdir -path=/docs/sub")
+
+			if content, err := FileSystem.GetFileContent(id, "/docs/a.txt"); err != nil || string(content) != fileContent(100) {
+				t.Errorf("/docs/a.txt después de migrar: %q %v", content, err)
+			}
+			fsck := mustRun(t, ctx, "fsck -id="+id)
+			if report := fsck.Data["report"].(*FileSystem.FsckReport); !report.Clean {
+				t.Errorf("fsck encontró inconsistencias después de migrar: %+v", report.Issues)
+			}
+
+			// El journaling migrado conserva las entradas desde el mkfs
+			if fs == "3fs" {
+				mustRun(t, ctx, "loss -id="+id)
+				mustRun(t, ctx, "recovery -id="+id)
+				users, err := FileSystem.GetFileContent(id, "/users.txt")
+				if err != nil || !strings.Contains(string(users), ",devs,ana,$2") {
+					t.Errorf("users.txt después de recovery:\n%s", users)
+				}
+				if _, err := FileSystem.GetDirectoryContents(id, "/docs/sub"); err != nil {
+					t.Errorf("/docs/sub no se recuperó: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// legacyDisk descomprime en dir el disco de testdata/legacy_blocks64.mia.gz,
+// creado con bloques de 64 bytes. P1 (EXT2) tiene el grupo devs, la usuaria
+// ana y los archivos de legacyFiles; P2 (EXT3) tiene /data/b.txt y
+// /data/c.txt en su journaling. Todos los archivos se crearon con mkfile
+// -size.
+func legacyDisk(t *testing.T, dir string) string {
+	t.Helper()
+	compressed, err := os.Open(filepath.Join("testdata", "legacy_blocks64.mia.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer compressed.Close()
+	reader, err := gzip.NewReader(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk := filepath.Join(dir, "Legacy.mia")
+	file, err := os.Create(disk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, reader); err != nil {
+		t.Fatal(err)
+	}
+	return disk
+}
+
+// legacyFiles son los archivos de P1 en legacyDisk con su tamaño
+func legacyFiles() map[string]int {
+	files := map[string]int{
+		"/docs/small.txt":      100,
+		"/docs/double.txt":     5000,
+		"/docs/sub/triple.txt": 30000,
+		"/docs/vacio.txt":      0,
+		"/docs/un_nombre_de_archivo_bastante_largo.txt": 10,
+	}
+	for i := 1; i <= 10; i++ {
+		files[fmt.Sprintf("/many/f%d.txt", i)] = i * 70
+	}
+	return files
+}
+
+// Una partición formateada con bloques de 64 bytes se migra al montarla a
+// bloques de 256: conserva archivos (con indirectos simples, dobles y
+// triples en la geometría anterior), nombres largos, usuarios, permisos y
+// journaling, y admite archivos más grandes que antes
+func TestMountUpgradesBlockGeometry(t *testing.T) {
+	dir := useTempState(t)
+	disk := legacyDisk(t, dir)
+	ctx := NewContext(nil)
+
+	// Sin montar, fdisk -add no redimensiona la geometría anterior
+	result := runCommand(ctx, fmt.Sprintf(`fdisk -add=500 -unit=k -path="%s" -name=P1`, disk))
+	if result.Status != "error" || result.Code != Utilities.ErrUnsupported {
+		t.Errorf("fdisk -add sin migrar: estado %s, código %s:\n%s", result.Status, result.Code, result.Output)
+	}
+
+	ids := map[string]string{}
+	for _, name := range []string{"P1", "P2"} {
+		mounted := mustRun(t, ctx, fmt.Sprintf(`mount -path="%s" -name=%s`, disk, name))
+		if !strings.Contains(mounted.Output, "migrada") {
+			t.Errorf("mount no migró %s:\n%s", name, mounted.Output)
+		}
+		ids[name] = mounted.Data["id"].(string)
+		sb, err := FileSystem.ReadSuperblock(ids[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sb.S_block_size != 256 {
+			t.Errorf("%s: bloques de %d bytes después de migrar", name, sb.S_block_size)
+		}
+	}
+
+	checkFiles(t, ids["P1"], legacyFiles())
+	checkFiles(t, ids["P2"], map[string]int{"/data/b.txt": 2000, "/data/c.txt": 700})
+	mustRun(t, ctx, "login -user=ana -pass=abc -id="+ids["P1"])
+	mustRun(t, ctx, "logout")
+	// /docs/small.txt es de ana, con permisos 640
+	users, err := FileSystem.GetFileContent(ids["P1"], "/users.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anaID := ""
+	for _, line := range strings.Split(string(users), "\n") {
+		if fields := strings.Split(line, ","); len(fields) == 5 && fields[1] == "U" && fields[3] == "ana" {
+			anaID = fields[0]
+		}
+	}
+	small := findNode(t, ids["P1"], "/docs/small.txt")
+	if fmt.Sprint(small.OwnerID) != anaID || small.Permissions != "640" {
+		t.Errorf("/docs/small.txt: dueño %d, permisos %s; se esperaba ana (%s) con 640", small.OwnerID, small.Permissions, anaID)
+	}
+
+	for name, id := range ids {
+		mustRun(t, ctx, "login -user=root -pass=123 -id="+id)
+		if report := fsckReport(t, ctx, "fsck -id="+id); !report.Clean {
+			t.Errorf("%s: fsck encontró inconsistencias después de migrar: %+v", name, report.Issues)
+		}
+		mustRun(t, ctx, "logout")
+	}
+
+	// El journaling migrado reproduce los archivos de P2
+	mustRun(t, ctx, "loss -id="+ids["P2"])
+	mustRun(t, ctx, "recovery -id="+ids["P2"])
+	checkFiles(t, ids["P2"], map[string]int{"/data/b.txt": 2000, "/data/c.txt": 700})
+
+	// Un archivo más grande que el máximo anterior
+	mustRun(t, ctx, "login -user=root -pass=123 -id="+ids["P1"])
+	mustRun(t, ctx, "mkfile -path=/grande.txt -size=400000")
+	checkFiles(t, ids["P1"], map[string]int{"/grande.txt": 400000})
+}
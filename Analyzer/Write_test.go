@@ -0,0 +1,72 @@
+package Analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"testing"
+)
+
+// usedBlocks cuenta los bloques marcados en el bitmap, leído directamente del disco
+func usedBlocks(t *testing.T, diskPath string, sb *Structs.Superblock) int {
+	t.Helper()
+	file, err := os.Open(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	bitmap := make([]byte, sb.S_blocks_count)
+	if err := Utilities.ReadObject(file, bitmap, int64(sb.S_bm_block_start)); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(bitmap, []byte{1})
+}
+
+// Un write que falla a medias libera los bloques que reservó y no cambia el
+// tamaño del archivo
+func TestWriteFailureReleasesBlocks(t *testing.T) {
+	dir := useTempState(t)
+	id, session := setupPartition(t, dir, "Disco", "-fs=2fs")
+	disk := filepath.Join(dir, "Disco.mia")
+	ctx := NewContext(session)
+	mustRun(t, ctx, "mkfile -path=/a.txt -size=300")
+
+	sb, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// El segundo bloque del archivo apunta fuera de la partición: write
+	// reserva los bloques nuevos y falla al llegar a él
+	node := findNode(t, id, "/a.txt")
+	inode := readInode(t, disk, sb, node.Inode)
+	inode.I_block[1] = sb.S_blocks_count + 5
+	corruptDisk(t, disk, inode, sb.S_inode_start+node.Inode*sb.S_inode_size)
+	before := usedBlocks(t, disk, sb)
+
+	source := filepath.Join(dir, "datos.txt")
+	if err := os.WriteFile(source, bytes.Repeat([]byte("x"), 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result := runCommand(ctx, fmt.Sprintf(`write -path=/a.txt -offset=10 -contenido="%s"`, source))
+	if result.Status != "error" || result.Code != Utilities.ErrIO {
+		t.Fatalf("write: estado %s, código %s, se esperaba %s", result.Status, result.Code, Utilities.ErrIO)
+	}
+
+	if after := usedBlocks(t, disk, sb); after != before {
+		t.Errorf("el write fallido dejó %d bloques ocupados en el bitmap", after-before)
+	}
+	after, err := FileSystem.ReadSuperblock(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.S_free_blocks_count != sb.S_free_blocks_count {
+		t.Errorf("bloques libres: %d, se esperaban %d", after.S_free_blocks_count, sb.S_free_blocks_count)
+	}
+	if size := readInode(t, disk, sb, node.Inode).I_size; size != 300 {
+		t.Errorf("tamaño del archivo: %d, se esperaban 300", size)
+	}
+}
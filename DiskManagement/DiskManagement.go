@@ -5,25 +5,26 @@ import (
 	"proyecto1/Utilities"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
 // Mapa global para asociar letras de drive con rutas de archivos
-var DrivePathMap = make(map[string]string)
+var drivePathMap = make(map[string]string)
 
 // Mapa global para particiones montadas en RAM
-var MountedPartitions = make(map[string]Structs.MountedPartition)
+var mountedPartitions = make(map[string]Structs.MountedPartition)
 
 // Contadores para generar IDs únicos por disco
-var DiskMountCounters = make(map[string]Structs.DiskCounters)
+var diskMountCounters = make(map[string]Structs.DiskCounters)
 
 // Lista ordenada de discos para mantener orden cronológico
-var DiskOrderList []string
+var diskOrderList []string
 
 // Función para registrar un drive con su ruta
-func RegisterDrive(path string) string {
+func RegisterDrive(out io.Writer, path string) string {
 	// Extraer el nombre del archivo sin extensión para usarlo como drive
 	filename := filepath.Base(path)
 	driveName := strings.ToUpper(strings.TrimSuffix(filename, filepath.Ext(filename)))
@@ -33,42 +34,50 @@ func RegisterDrive(path string) string {
 		driveName = string(driveName[0])
 	}
 	
-	DrivePathMap[driveName] = path
-	fmt.Println("Drive", driveName, "registrado con ruta:", path)
+	registryMutex.Lock()
+	drivePathMap[driveName] = path
+	saveState(out)
+	registryMutex.Unlock()
+	fmt.Fprintln(out, "Drive", driveName, "registrado con ruta:", path)
 	return driveName
 }
 
 // Función para obtener la ruta de un drive
 func GetDrivePath(drive string) (string, bool) {
-	path, exists := DrivePathMap[strings.ToUpper(drive)]
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	path, exists := drivePathMap[strings.ToUpper(drive)]
 	return path, exists
 }
 
-func Rmdisk(path string) {
-	fmt.Println("======Inicio RMDISK======")
-	fmt.Println("Path:", path)
+func Rmdisk(out io.Writer, path string) error {
+	fmt.Fprintln(out, "======Inicio RMDISK======")
+	fmt.Fprintln(out, "Path:", path)
 
 	// Validar que el path no esté vacío
 	if path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -path es requerido")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -path es requerido")
 	}
 
 	// Verificar que el archivo existe
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Printf("Error: El archivo %s no existe\n", path)
-		return
+		fmt.Fprintf(out, "Error: El archivo %s no existe\n", path)
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo %s no existe", path)
 	}
 
 	// Verificar que es un archivo .mia
 	if !strings.HasSuffix(strings.ToLower(path), ".mia") {
-		fmt.Printf("Error: El archivo %s no es un disco válido (.mia)\n", path)
-		return
+		fmt.Fprintf(out, "Error: El archivo %s no es un disco válido (.mia)\n", path)
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El archivo %s no es un disco válido (.mia)", path)
 	}
 
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
 	// Buscar y remover el drive del mapa si existe
 	var driveToRemove string
-	for drive, diskPath := range DrivePathMap {
+	for drive, diskPath := range drivePathMap {
 		if diskPath == path {
 			driveToRemove = drive
 			break
@@ -78,68 +87,83 @@ func Rmdisk(path string) {
 	// Eliminar el archivo
 	err := os.Remove(path)
 	if err != nil {
-		fmt.Printf("Error eliminando el archivo: %v\n", err)
-		return
+		fmt.Fprintf(out, "Error eliminando el archivo: %v\n", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error eliminando el archivo: %v", err)
 	}
+	removeDiskSnapshots(path)
 
 	// Remover del mapa de drives si estaba registrado
 	if driveToRemove != "" {
-		delete(DrivePathMap, driveToRemove)
-		fmt.Printf("Drive %s removido del mapa de drives\n", driveToRemove)
+		delete(drivePathMap, driveToRemove)
+		fmt.Fprintf(out, "Drive %s removido del mapa de drives\n", driveToRemove)
 	}
 
-	fmt.Printf("Disco eliminado exitosamente: %s\n", path)
-	fmt.Println("======Fin RMDISK======")
+	// Liberar la letra del disco si no le quedan montajes
+	diskInUse := false
+	for _, mounted := range mountedPartitions {
+		if diskKey(mounted.Path) == diskKey(path) {
+			diskInUse = true
+			break
+		}
+	}
+	if !diskInUse {
+		delete(diskMountCounters, diskKey(path))
+	}
+	saveState(out)
+
+	fmt.Fprintf(out, "Disco eliminado exitosamente: %s\n", path)
+	fmt.Fprintln(out, "======Fin RMDISK======")
+	return nil
 }
 
-func Mkdisk(size int, fit string, unit string, path string) {
-	fmt.Println("======Inicio MKDISK======")
-    fmt.Println("======Parámetros Recibidos======")
-	fmt.Println("Size:", size)
-	fmt.Println("Fit:", fit, "(default: ff)")
-	fmt.Println("Unit:", unit, "(default: m)")
-	fmt.Println("Path:", path)
+func Mkdisk(out io.Writer, size int, fit string, unit string, path string) error {
+	fmt.Fprintln(out, "======Inicio MKDISK======")
+    fmt.Fprintln(out, "======Parámetros Recibidos======")
+	fmt.Fprintln(out, "Size:", size)
+	fmt.Fprintln(out, "Fit:", fit, "(default: ff)")
+	fmt.Fprintln(out, "Unit:", unit, "(default: m)")
+	fmt.Fprintln(out, "Path:", path)
 
 	// validar fit = bf/ff/wf
 	if fit != "bf" && fit != "ff" && fit != "wf" {
-		fmt.Println("Error: Fit debe ser bf, ff, o wf")
-		return
+		fmt.Fprintln(out, "Error: Fit debe ser bf, ff, o wf")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Fit debe ser bf, ff, o wf")
 	}
 
 	// validar que el tamaño sea mayor a 0
 	if size <= 0 {
-		fmt.Println("Error: Tamaño debe ser mayor a 0")
-		return
+		fmt.Fprintln(out, "Error: Tamaño debe ser mayor a 0")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Tamaño debe ser mayor a 0")
 	}
 
 	// validar que unidad sea igual a k o m
 	if unit != "k" && unit != "m" {
-		fmt.Println("Error: Unidad debe ser k o m")
-		return
+		fmt.Fprintln(out, "Error: Unidad debe ser k o m")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Unidad debe ser k o m")
 	}
 
 	// validar que path no esté vacío
 	if path == "" {
-		fmt.Println("Error: La ruta del archivo es requerida")
-		return
+		fmt.Fprintln(out, "Error: La ruta del archivo es requerida")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta del archivo es requerida")
 	}
 
 	// Verificar y crear directorios si es necesario
 	dir := filepath.Dir(path)
 	if dir != "." && dir != "" {
 		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			fmt.Printf("Creando directorio: %s\n", dir)
+			fmt.Fprintf(out, "Creando directorio: %s\n", dir)
 		}
 	}
 
 	// Crear Archivo en la ruta especificada (con directorios automáticos)
 	err := Utilities.CreateFile(path)
 	if err != nil {
-		fmt.Println("Error creando archivo o directorios:", err)
-		return
+		fmt.Fprintln(out, "Error creando archivo o directorios:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error creando archivo o directorios: %v", err)
 	}
 
-	fmt.Printf("Archivo creado exitosamente en: %s\n", path)
+	fmt.Fprintf(out, "Archivo creado exitosamente en: %s\n", path)
 
 	// Definir el tamaño del archivo
 	if unit == "k" {
@@ -151,7 +175,7 @@ func Mkdisk(size int, fit string, unit string, path string) {
 	// Abrir archivo binario
 	file, err := Utilities.OpenFile(path)
 	if err != nil {
-		return
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo: %v", err)
 	}
 
 	// Buffer 1024 bytes
@@ -161,7 +185,7 @@ func Mkdisk(size int, fit string, unit string, path string) {
 	for i := 0; i < size/1024; i++ {
 		err := Utilities.WriteObject(file, zeroBuffer, int64(i*1024))
 		if err != nil {
-			return
+			return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo el disco: %v", err)
 		}
 	}
 
@@ -174,82 +198,86 @@ func Mkdisk(size int, fit string, unit string, path string) {
 
 	// Escribir MBR al archivo
 	if err := Utilities.WriteObject(file, newMBR, 0); err != nil {
-		fmt.Println("Error escribiendo MBR al archivo:", err)
-		return
+		fmt.Fprintln(out, "Error escribiendo MBR al archivo:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo MBR al archivo: %v", err)
 	}
 
 	var tempMBR Structs.MBR
 
 	// Leer MBR del archivo para verificar
 	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-		fmt.Println("Error leyendo MBR del archivo:", err)
-		return
+		fmt.Fprintln(out, "Error leyendo MBR del archivo:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo MBR del archivo: %v", err)
 	}
 
 	// Imprimir MBR para verificar
-	fmt.Println("===Data recuperada===")
-	fmt.Println("Tamaño del MBR:", tempMBR.MbrSize)
-	fmt.Println("Fit:", string(tempMBR.Fit[:]))
-	fmt.Println("Fecha de creación:", string(tempMBR.CreationDate[:]))
-	fmt.Println("Firma:", tempMBR.Signature)
+	fmt.Fprintln(out, "===Data recuperada===")
+	fmt.Fprintln(out, "Tamaño del MBR:", tempMBR.MbrSize)
+	fmt.Fprintln(out, "Fit:", string(tempMBR.Fit[:]))
+	fmt.Fprintln(out, "Fecha de creación:", string(tempMBR.CreationDate[:]))
+	fmt.Fprintln(out, "Firma:", tempMBR.Signature)
 
 	// Cerrar el archivo binario
 	defer file.Close()
 
 	// Registrar el drive en el mapa
-	RegisterDrive(path)
+	RegisterDrive(out, path)
 
-	fmt.Println("======Fin MKDISK======")
+	fmt.Fprintln(out, "======Fin MKDISK======")
+	return nil
 }
 
-func Mount(path string, name string) {
-	fmt.Println("======Inicio MOUNT======")
-	fmt.Println("Path del disco:", path)
-	fmt.Println("Nombre de partición:", name)
+func Mount(out io.Writer, path string, name string) (string, error) {
+	fmt.Fprintln(out, "======Inicio MOUNT======")
+	fmt.Fprintln(out, "Path del disco:", path)
+	fmt.Fprintln(out, "Nombre de partición:", name)
 
 	// Validar parámetros
 	if path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -path es requerido")
+		return "", Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -path es requerido")
 	}
 	if name == "" {
-		fmt.Println("Error: El parámetro -name es requerido") 
-		return
+		fmt.Fprintln(out, "Error: El parámetro -name es requerido") 
+		return "", Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -name es requerido")
 	}
 
 	// Verificar que el archivo del disco existe
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Printf("Error: El archivo %s no existe\n", path)
-		return
+		fmt.Fprintf(out, "Error: El archivo %s no existe\n", path)
+		return "", Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo %s no existe", path)
 	}
 
 	// Verificar que es un archivo .mia
 	if !strings.HasSuffix(strings.ToLower(path), ".mia") {
-		fmt.Printf("Error: El archivo %s no es un disco válido (.mia)\n", path)
-		return
+		fmt.Fprintf(out, "Error: El archivo %s no es un disco válido (.mia)\n", path)
+		return "", Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El archivo %s no es un disco válido (.mia)", path)
 	}
 
-	// Verificar si la partición ya está montada
-	for id, mounted := range MountedPartitions {
-		if mounted.Path == path && mounted.PartitionName == name {
-			fmt.Printf("Error: La partición '%s' del disco '%s' ya está montada con ID '%s'\n", name, path, id)
-			return
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	// Verificar si la partición ya está montada (aunque la ruta se haya escrito distinto)
+	for id, mounted := range mountedPartitions {
+		if diskKey(mounted.Path) == diskKey(path) && mounted.PartitionName == name {
+			fmt.Fprintf(out, "Error: La partición '%s' del disco '%s' ya está montada con ID '%s'\n", name, path, id)
+			return "", Utilities.NewCommandError(Utilities.ErrAlreadyExists, "La partición '%s' del disco '%s' ya está montada con ID '%s'", name, path, id)
 		}
 	}
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(path)
 	if err != nil {
-		fmt.Println("Error abriendo archivo:", err)
-		return
+		fmt.Fprintln(out, "Error abriendo archivo:", err)
+		return "", Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo: %v", err)
 	}
 	defer file.Close()
 
 	var tempMBR Structs.MBR
 	// Leer MBR del archivo
 	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-		fmt.Println("Error leyendo MBR del archivo:", err)
-		return
+		fmt.Fprintln(out, "Error leyendo MBR del archivo:", err)
+		return "", Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo MBR del archivo: %v", err)
 	}
 
 	// Buscar la partición por nombre
@@ -273,8 +301,8 @@ func Mount(path string, name string) {
 				partitionFound = true
 				break
 			} else {
-				fmt.Printf("Error: No se pueden montar particiones extendidas. Solo particiones primarias y lógicas.\n")
-				return
+				fmt.Fprintf(out, "Error: No se pueden montar particiones extendidas. Solo particiones primarias y lógicas.\n")
+				return "", Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se pueden montar particiones extendidas. Solo particiones primarias y lógicas.")
 			}
 		}
 	}
@@ -285,78 +313,48 @@ func Mount(path string, name string) {
 	}
 
 	if !partitionFound {
-		fmt.Printf("Error: Partición '%s' no encontrada en el disco '%s'\n", name, path)
-		return
+		fmt.Fprintf(out, "Error: Partición '%s' no encontrada en el disco '%s'\n", name, path)
+		return "", Utilities.NewCommandError(Utilities.ErrNotFound, "Partición '%s' no encontrada en el disco '%s'", name, path)
 	}
 
 	// Generar ID único para la partición
-	id := generatePartitionID(path)
+	id, correlative, err := generatePartitionID(path)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return "", err
+	}
 	mountedPartition.Id = id
+	mountedPartition.Correlative = correlative
 
 	// Actualizar el estado de la partición en el disco
 	if mountedPartition.IsLogical {
 		updateLogicalPartitionStatus(file, mountedPartition.EBRPosition, id, true)
 	} else {
-		updatePrimaryPartitionStatus(file, &tempMBR, mountedPartition.PartitionIndex, id, true)
+		updatePrimaryPartitionStatus(file, &tempMBR, mountedPartition.PartitionIndex, id, correlative, true)
 	}
 
-	// Registrar la partición como montada en RAM
-	MountedPartitions[id] = mountedPartition
+	// Registrar la partición como montada en RAM y guardar el estado
+	mountedPartitions[id] = mountedPartition
+	saveState(out)
 
-	fmt.Printf("✓ Partición '%s' montada exitosamente con ID: %s\n", name, id)
-	fmt.Printf("  - Tipo: %s\n", map[bool]string{true: "Lógica", false: "Primaria"}[mountedPartition.IsLogical])
-	fmt.Printf("  - Disco: %s\n", path)
-	fmt.Printf("  - Status: Activa\n")
-	fmt.Printf("  - Correlativo: 1\n")
+	fmt.Fprintf(out, "✓ Partición '%s' montada exitosamente con ID: %s\n", name, id)
+	fmt.Fprintf(out, "  - Tipo: %s\n", map[bool]string{true: "Lógica", false: "Primaria"}[mountedPartition.IsLogical])
+	fmt.Fprintf(out, "  - Disco: %s\n", path)
+	fmt.Fprintf(out, "  - Status: Activa\n")
+	fmt.Fprintf(out, "  - Correlativo: %d\n", correlative)
 	
 	// Mostrar información de cómo se generó el ID
-	fmt.Printf("\n--- Información del ID generado ---\n")
-	fmt.Printf("  - Carnet: 202201185 → Últimos 2 dígitos: 85\n")
-	fmt.Printf("  - Número de partición en disco: %d\n", getPartitionNumberInDisk(path)-1) // -1 porque ya se montó
-	fmt.Printf("  - Letra de disco: %c\n", id[3]) // Extraer letra directamente del ID generado
-	fmt.Printf("  - ID final: %s\n", id)
+	fmt.Fprintf(out, "\n--- Información del ID generado ---\n")
+	fmt.Fprintf(out, "  - Prefijo: %s\n", idPrefix)
+	fmt.Fprintf(out, "  - Correlativo del disco: %d\n", correlative)
+	fmt.Fprintf(out, "  - Letra de disco: %c\n", id[len(id)-1]) // La letra es el último carácter del ID
+	fmt.Fprintf(out, "  - ID final: %s\n", id)
 	
 	// Mostrar particiones montadas actualmente
-	showMountedPartitions()
-
-	fmt.Println("======FIN MOUNT======")
-}
+	showMountedPartitions(out)
 
-// Función auxiliar para obtener el número de partición en un disco específico
-func getPartitionNumberInDisk(diskPath string) int {
-	count := 0
-	for _, mounted := range MountedPartitions {
-		if mounted.Path == diskPath {
-			count++
-		}
-	}
-	return count + 1 // Retorna el siguiente número que se asignaría
-}
-
-// Función auxiliar para obtener la letra asignada a un disco
-func getDiskLetter(diskPath string) byte {
-	// Obtener lista ordenada de discos únicos por orden de primera aparición
-	var uniqueDisks []string
-	diskFirstSeen := make(map[string]int)
-	
-	orderCounter := 0
-	for _, mounted := range MountedPartitions {
-		if _, exists := diskFirstSeen[mounted.Path]; !exists {
-			diskFirstSeen[mounted.Path] = orderCounter
-			uniqueDisks = append(uniqueDisks, mounted.Path)
-			orderCounter++
-		}
-	}
-	
-	// Encontrar el índice de este disco
-	for i, disk := range uniqueDisks {
-		if disk == diskPath {
-			return byte('A' + i)
-		}
-	}
-	
-	// Si no se encontró, es un disco nuevo - asignar la siguiente letra
-	return byte('A' + len(uniqueDisks))
+	fmt.Fprintln(out, "======FIN MOUNT======")
+	return id, nil
 }
 
 // Función para buscar una partición lógica por nombre
@@ -411,55 +409,112 @@ func findLogicalPartition(file *os.File, tempMBR *Structs.MBR, name string, path
 	return Structs.MountedPartition{}, false
 }
 
-// Función para generar ID único basado en el carnet (202201185 -> 85)
-func generatePartitionID(diskPath string) string {
-	const CARNET_SUFFIX = "85" // Últimos dos dígitos del carnet 202201185
+// DefaultIDPrefix es el prefijo de los IDs de montaje si no se configura otro
+const DefaultIDPrefix = "00"
 
-	// Verificar si el disco ya está en la lista ordenada
-	diskIndex := -1
-	for i, existingDisk := range DiskOrderList {
-		if existingDisk == diskPath {
-			diskIndex = i
-			break
+// Prefijo de los IDs de montaje. Se puede cambiar con SetIDPrefix (la
+// variable de entorno MIA_ID_PREFIX en el servidor).
+var idPrefix = DefaultIDPrefix
+
+// Bytes de Partition.Id en el MBR: el ID de montaje completo debe caber
+const maxPartitionIDLength = len(Structs.Partition{}.Id)
+
+// SetIDPrefix - Cambiar el prefijo de los IDs de montaje (letras o dígitos).
+// Deja lugar en el ID para al menos un dígito de correlativo y la letra.
+func SetIDPrefix(prefix string) error {
+	prefix = strings.TrimSpace(prefix)
+	if max := maxPartitionIDLength - 2; prefix == "" || len(prefix) > max {
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El prefijo de IDs debe tener entre 1 y %d caracteres", max)
+	}
+	for _, c := range prefix {
+		if !(c >= '0' && c <= '9') && !(c >= 'A' && c <= 'Z') && !(c >= 'a' && c <= 'z') {
+			return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El prefijo de IDs solo puede tener letras y dígitos: '%s'", prefix)
 		}
 	}
-	
-	// Si es un disco nuevo, agregarlo al final de la lista
-	if diskIndex == -1 {
-		DiskOrderList = append(DiskOrderList, diskPath)
-		diskIndex = len(DiskOrderList) - 1
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	idPrefix = prefix
+	return nil
+}
+
+// generatePartitionID genera el ID de montaje: prefijo + correlativo del disco
+// + letra del disco. El correlativo de cada disco crece mientras el ID quepa
+// en Partition.Id (recortarlo podría repetir otro); después vuelve a empezar
+// en 1 y toma el primer correlativo cuyo ID no está montado. Nunca se repite
+// un ID en uso. Asume que quien la llama tiene registryMutex.
+func generatePartitionID(diskPath string) (string, int32, error) {
+	key := diskKey(diskPath)
+	counters, exists := diskMountCounters[key]
+	if !exists {
+		letter, found := nextDiskLetter()
+		if !found {
+			return "", 0, Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay letras de disco disponibles para '%s'", diskPath)
+		}
+		counters = Structs.DiskCounters{Letter: letter}
 	}
-	
-	// Calcular la letra del disco basada en su posición en la lista
-	diskLetter := byte('A' + diskIndex)
-
-	// Contar particiones ya montadas en este disco específico
-	partitionCount := 0
-	for _, mounted := range MountedPartitions {
-		if mounted.Path == diskPath {
-			partitionCount++
+
+	// Mantener el orden cronológico de los discos
+	inOrder := false
+	for _, existingDisk := range diskOrderList {
+		if diskKey(existingDisk) == key {
+			inOrder = true
+			break
 		}
 	}
-	
-	// El número de partición es el siguiente para este disco
-	partitionNumber := partitionCount + 1
+	if !inOrder {
+		diskOrderList = append(diskOrderList, diskPath)
+	}
 
-	// Generar el ID
-	id := fmt.Sprintf("%s%d%c", CARNET_SUFFIX, partitionNumber, diskLetter)
+	wrapped := false
+	for {
+		counters.PartitionNumber++
+		id := fmt.Sprintf("%s%d%c", idPrefix, counters.PartitionNumber, counters.Letter)
+		if len(id) > maxPartitionIDLength {
+			if wrapped {
+				return "", 0, Utilities.NewCommandError(Utilities.ErrNoSpace, "Todos los IDs de montaje del disco '%s' que caben en los %d bytes del MBR están en uso; desmonte una partición o use un prefijo de IDs más corto", diskPath, maxPartitionIDLength)
+			}
+			wrapped = true
+			counters.PartitionNumber = 0
+			continue
+		}
+		if _, inUse := mountedPartitions[id]; !inUse {
+			diskMountCounters[key] = counters
+			return id, int32(counters.PartitionNumber), nil
+		}
+	}
+}
 
-	return id
+// nextDiskLetter busca la primera letra que no use ningún disco registrado ni
+// ningún montaje activo. Asume que quien la llama tiene registryMutex.
+func nextDiskLetter() (byte, bool) {
+	used := make(map[byte]bool)
+	for _, counters := range diskMountCounters {
+		used[counters.Letter] = true
+	}
+	for _, mounted := range mountedPartitions {
+		if len(mounted.Id) > 0 {
+			used[mounted.Id[len(mounted.Id)-1]] = true
+		}
+	}
+	for letter := byte('A'); letter <= 'Z'; letter++ {
+		if !used[letter] {
+			return letter, true
+		}
+	}
+	return 0, false
 }
 
 // Función para actualizar el estado de una partición primaria
-func updatePrimaryPartitionStatus(file *os.File, tempMBR *Structs.MBR, partitionIndex int, id string, mount bool) error {
+func updatePrimaryPartitionStatus(file *os.File, tempMBR *Structs.MBR, partitionIndex int, id string, correlative int32, mount bool) error {
+	tempMBR.Partitions[partitionIndex].Id = [4]byte{}
 	if mount {
-		copy(tempMBR.Partitions[partitionIndex].Status[:], "1")     // Activa
-		copy(tempMBR.Partitions[partitionIndex].Id[:], id)          // Asignar ID
-		tempMBR.Partitions[partitionIndex].Correlative = 1          // Correlativo
+		copy(tempMBR.Partitions[partitionIndex].Status[:], "1")        // Activa
+		copy(tempMBR.Partitions[partitionIndex].Id[:], id)             // Asignar ID (generatePartitionID garantiza que cabe)
+		tempMBR.Partitions[partitionIndex].Correlative = correlative   // Correlativo del disco
 	} else {
-		copy(tempMBR.Partitions[partitionIndex].Status[:], "0")     // Inactiva
-		copy(tempMBR.Partitions[partitionIndex].Id[:], "")          // Limpiar ID
-		tempMBR.Partitions[partitionIndex].Correlative = 0          // Limpiar correlativo
+		copy(tempMBR.Partitions[partitionIndex].Status[:], "0")        // Inactiva
+		tempMBR.Partitions[partitionIndex].Correlative = 0             // Limpiar correlativo
 	}
 
 	// Escribir MBR actualizado al disco
@@ -484,46 +539,48 @@ func updateLogicalPartitionStatus(file *os.File, ebrPosition int32, id string, m
 }
 
 // ShowDetailedMountedPartitions - Función pública para mostrar información detallada de particiones montadas
-func ShowDetailedMountedPartitions() {
-	showDetailedMountedPartitions()
+func ShowDetailedMountedPartitions(out io.Writer) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	showDetailedMountedPartitions(out)
 }
 
 // Función para mostrar información detallada de todas las particiones montadas
-func showDetailedMountedPartitions() {
-	if len(MountedPartitions) == 0 {
-		fmt.Println("═══════════════════════════════════════")
-		fmt.Println("│        NO HAY PARTICIONES MONTADAS        │")
-		fmt.Println("═══════════════════════════════════════")
-		fmt.Println("│ No se encontraron particiones montadas    │")
-		fmt.Println("│ en el sistema actualmente.                │")
-		fmt.Println("│                                           │")
-		fmt.Println("│ Use el comando 'mount' para montar        │")
-		fmt.Println("│ particiones.                              │")
-		fmt.Println("│                                           │")
-		fmt.Println("│ Ejemplo:                                  │")
-		fmt.Println("│   mount -path=./disco.mia -name=Part1     │")
-		fmt.Println("═══════════════════════════════════════")
-		return
-	}
-
-	fmt.Println("╔═══════════════════════════════════════════════════════════════╗")
-	fmt.Println("║                    PARTICIONES MONTADAS EN EL SISTEMA                    ║")
-	fmt.Println("╠═══════════════════════════════════════════════════════════════╣")
-	fmt.Printf("║ Total de particiones montadas: %-30d ║\n", len(MountedPartitions))
-	fmt.Printf("║ Carnet del sistema: 202201185 (IDs inician con 85)           ║\n")
-	fmt.Println("╠═══════════════════════════════════════════════════════════════╣")
+func showDetailedMountedPartitions(out io.Writer) {
+	if len(mountedPartitions) == 0 {
+		fmt.Fprintln(out, "═══════════════════════════════════════")
+		fmt.Fprintln(out, "│        NO HAY PARTICIONES MONTADAS        │")
+		fmt.Fprintln(out, "═══════════════════════════════════════")
+		fmt.Fprintln(out, "│ No se encontraron particiones montadas    │")
+		fmt.Fprintln(out, "│ en el sistema actualmente.                │")
+		fmt.Fprintln(out, "│                                           │")
+		fmt.Fprintln(out, "│ Use el comando 'mount' para montar        │")
+		fmt.Fprintln(out, "│ particiones.                              │")
+		fmt.Fprintln(out, "│                                           │")
+		fmt.Fprintln(out, "│ Ejemplo:                                  │")
+		fmt.Fprintln(out, "│   mount -path=./disco.mia -name=Part1     │")
+		fmt.Fprintln(out, "═══════════════════════════════════════")
+		return
+	}
+
+	fmt.Fprintln(out, "╔═══════════════════════════════════════════════════════════════╗")
+	fmt.Fprintln(out, "║                    PARTICIONES MONTADAS EN EL SISTEMA                    ║")
+	fmt.Fprintln(out, "╠═══════════════════════════════════════════════════════════════╣")
+	fmt.Fprintf(out, "║ Total de particiones montadas: %-30d ║\n", len(mountedPartitions))
+	fmt.Fprintf(out, "║ Prefijo de IDs: %-44s ║\n", idPrefix)
+	fmt.Fprintln(out, "╠═══════════════════════════════════════════════════════════════╣")
 
 	// Agrupar particiones por disco
 	diskGroups := make(map[string][]Structs.MountedPartition)
-	for _, partition := range MountedPartitions {
+	for _, partition := range mountedPartitions {
 		diskGroups[partition.Path] = append(diskGroups[partition.Path], partition)
 	}
 
 	diskCounter := 1
 	for diskPath, partitions := range diskGroups {
-		fmt.Printf("║                                                              ║\n")
-		fmt.Printf("║ DISCO %d: %-51s ║\n", diskCounter, truncateString(diskPath, 51))
-		fmt.Printf("║ ├─ Número de particiones montadas: %-25d ║\n", len(partitions))
+		fmt.Fprintf(out, "║                                                              ║\n")
+		fmt.Fprintf(out, "║ DISCO %d: %-51s ║\n", diskCounter, truncateString(diskPath, 51))
+		fmt.Fprintf(out, "║ ├─ Número de particiones montadas: %-25d ║\n", len(partitions))
 		
 		for i, partition := range partitions {
 			typeStr := "Primaria"
@@ -532,13 +589,13 @@ func showDetailedMountedPartitions() {
 			}
 			
 			if i == len(partitions)-1 {
-				fmt.Printf("║ └─ [%s] %s - %s - %s ║\n", 
+				fmt.Fprintf(out, "║ └─ [%s] %s - %s - %s ║\n", 
 					partition.Id,
 					pad(partition.PartitionName, 15),
 					typeStr,
 					"Activa")
 			} else {
-				fmt.Printf("║ ├─ [%s] %s - %s - %s ║\n", 
+				fmt.Fprintf(out, "║ ├─ [%s] %s - %s - %s ║\n", 
 					partition.Id,
 					pad(partition.PartitionName, 15),
 					typeStr,
@@ -548,16 +605,16 @@ func showDetailedMountedPartitions() {
 		diskCounter++
 	}
 
-	fmt.Println("║                                                              ║")
-	fmt.Println("╠═══════════════════════════════════════════════════════════════╣")
-	fmt.Println("║                        INFORMACIÓN TÉCNICA                           ║")
-	fmt.Println("╠═══════════════════════════════════════════════════════════════╣")
+	fmt.Fprintln(out, "║                                                              ║")
+	fmt.Fprintln(out, "╠═══════════════════════════════════════════════════════════════╣")
+	fmt.Fprintln(out, "║                        INFORMACIÓN TÉCNICA                           ║")
+	fmt.Fprintln(out, "╠═══════════════════════════════════════════════════════════════╣")
 	
 	// Mostrar información de IDs únicos
 	uniqueLetters := make(map[byte]bool)
-	for _, partition := range MountedPartitions {
-		if len(partition.Id) >= 4 {
-			letter := partition.Id[3] // Última letra del ID
+	for _, partition := range mountedPartitions {
+		if len(partition.Id) > 0 {
+			letter := partition.Id[len(partition.Id)-1] // Última letra del ID
 			uniqueLetters[letter] = true
 		}
 	}
@@ -570,10 +627,10 @@ func showDetailedMountedPartitions() {
 		letterList += string(letter)
 	}
 	
-	fmt.Printf("║ • Letras de disco en uso: %-35s ║\n", letterList)
-	fmt.Printf("║ • Montaje en memoria RAM: Sí                                 ║\n")
-	fmt.Printf("║ • Estado en disco actualizado: Sí                           ║\n")
-	fmt.Printf("║ • IDs únicos generados: %d                                   ║\n", len(MountedPartitions))
+	fmt.Fprintf(out, "║ • Letras de disco en uso: %-35s ║\n", letterList)
+	fmt.Fprintf(out, "║ • Montaje en memoria RAM: Sí                                 ║\n")
+	fmt.Fprintf(out, "║ • Estado en disco actualizado: Sí                           ║\n")
+	fmt.Fprintf(out, "║ • IDs únicos generados: %d                                   ║\n", len(mountedPartitions))
 	
 }
 
@@ -595,145 +652,133 @@ func pad(s string, length int) string {
 }
 
 // Función para mostrar todas las particiones montadas
-func showMountedPartitions() {
-	if len(MountedPartitions) == 0 {
-		fmt.Println("No hay particiones montadas actualmente")
+func showMountedPartitions(out io.Writer) {
+	if len(mountedPartitions) == 0 {
+		fmt.Fprintln(out, "No hay particiones montadas actualmente")
 		return
 	}
 
-	fmt.Println("\n=== PARTICIONES MONTADAS ===")
-	for id, partition := range MountedPartitions {
-		fmt.Printf("ID: %s | Partición: %s | Tipo: %s | Disco: %s\n", 
+	fmt.Fprintln(out, "\n=== PARTICIONES MONTADAS ===")
+	for id, partition := range mountedPartitions {
+		fmt.Fprintf(out, "ID: %s | Partición: %s | Tipo: %s | Disco: %s\n", 
 			id, 
 			partition.PartitionName,
 			map[bool]string{true: "Lógica", false: "Primaria"}[partition.IsLogical],
 			partition.Path)
 	}
-	fmt.Println("============================\n")
+	fmt.Fprint(out, "============================\n\n")
 }
 
 // Función para desmontar una partición (para futura implementación)
-func Unmount(id string) {
-	fmt.Printf("======INICIO UNMOUNT======\n")
-	fmt.Printf("ID: %s\n", id)
+func Unmount(out io.Writer, id string) error {
+	fmt.Fprintf(out, "======INICIO UNMOUNT======\n")
+	fmt.Fprintf(out, "ID: %s\n", id)
+
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
 
 	// Buscar la partición montada
-	partition, exists := MountedPartitions[id]
+	partition, exists := mountedPartitions[id]
 	if !exists {
-		fmt.Printf("Error: No existe una partición montada con ID '%s'\n", id)
-		return
+		fmt.Fprintf(out, "Error: No existe una partición montada con ID '%s'\n", id)
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "No existe una partición montada con ID '%s'", id)
 	}
 
 	// Abrir el archivo del disco
 	file, err := Utilities.OpenFile(partition.Path)
 	if err != nil {
-		fmt.Println("Error abriendo archivo:", err)
-		return
+		fmt.Fprintln(out, "Error abriendo archivo:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo: %v", err)
 	}
 	defer file.Close()
 
 	// Actualizar estado en el disco
 	if partition.IsLogical {
 		if err := updateLogicalPartitionStatus(file, partition.EBRPosition, "", false); err != nil {
-			fmt.Println("Error actualizando estado de partición lógica:", err)
-			return
+			fmt.Fprintln(out, "Error actualizando estado de partición lógica:", err)
+			return Utilities.NewCommandError(Utilities.ErrIO, "Error actualizando estado de partición lógica: %v", err)
 		}
 	} else {
 		var tempMBR Structs.MBR
 		if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-			fmt.Println("Error leyendo MBR:", err)
-			return
+			fmt.Fprintln(out, "Error leyendo MBR:", err)
+			return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo MBR: %v", err)
 		}
 		
-		if err := updatePrimaryPartitionStatus(file, &tempMBR, partition.PartitionIndex, "", false); err != nil {
-			fmt.Println("Error actualizando estado de partición primaria:", err)
-			return
+		if err := updatePrimaryPartitionStatus(file, &tempMBR, partition.PartitionIndex, "", 0, false); err != nil {
+			fmt.Fprintln(out, "Error actualizando estado de partición primaria:", err)
+			return Utilities.NewCommandError(Utilities.ErrIO, "Error actualizando estado de partición primaria: %v", err)
 		}
 	}
 
 	// Remover de la lista de particiones montadas
-	delete(MountedPartitions, id)
+	delete(mountedPartitions, id)
 
-	// Verificar si el disco ya no tiene particiones montadas y limpiarlo de la lista ordenada
-	hasPartitionsInDisk := false
-	for _, mounted := range MountedPartitions {
-		if mounted.Path == partition.Path {
-			hasPartitionsInDisk = true
-			break
-		}
-	}
-	
 	// Si el disco ya no tiene particiones, removerlo de la lista ordenada
-	if !hasPartitionsInDisk {
-		for i, disk := range DiskOrderList {
-			if disk == partition.Path {
-				// Remover disco de la lista manteniendo el orden
-				DiskOrderList = append(DiskOrderList[:i], DiskOrderList[i+1:]...)
-				break
-			}
-		}
-	}
+	removeDiskFromOrderIfUnused(partition.Path)
+	saveState(out)
 
-	fmt.Printf("✓ Partición '%s' desmontada exitosamente\n", partition.PartitionName)
-	showMountedPartitions()
-	fmt.Println("======FIN UNMOUNT======")
+	fmt.Fprintf(out, "✓ Partición '%s' desmontada exitosamente\n", partition.PartitionName)
+	showMountedPartitions(out)
+	fmt.Fprintln(out, "======FIN UNMOUNT======")
+	return nil
 }
 
-func Fdisk(size int, path string, name string, type_ string, fit string, unit string) {
-	fmt.Println("======INICIO FDISK======")
-	fmt.Println("Tamaño: ", size)
-	fmt.Println("Path: ", path)
-	fmt.Println("Nombre: ", name)
-	fmt.Println("Tipo: ", type_, "(default: p)")
-	fmt.Println("Fit: ", fit, "(default: wf)")
-	fmt.Println("Unit: ", unit, "(default: k)")
+func Fdisk(out io.Writer, size int, path string, name string, type_ string, fit string, unit string) error {
+	fmt.Fprintln(out, "======INICIO FDISK======")
+	fmt.Fprintln(out, "Tamaño: ", size)
+	fmt.Fprintln(out, "Path: ", path)
+	fmt.Fprintln(out, "Nombre: ", name)
+	fmt.Fprintln(out, "Tipo: ", type_, "(default: p)")
+	fmt.Fprintln(out, "Fit: ", fit, "(default: wf)")
+	fmt.Fprintln(out, "Unit: ", unit, "(default: k)")
 
 	// Validar que el path no esté vacío
 	if path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -path es requerido")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -path es requerido")
 	}
 
 	// Verificar que el archivo existe
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Printf("Error: El archivo %s no existe. Debe crear el disco primero con mkdisk.\n", path)
-		return
+		fmt.Fprintf(out, "Error: El archivo %s no existe. Debe crear el disco primero con mkdisk.\n", path)
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo %s no existe. Debe crear el disco primero con mkdisk.", path)
 	}
 
 	// Verificar que es un archivo .mia
 	if !strings.HasSuffix(strings.ToLower(path), ".mia") {
-		fmt.Printf("Error: El archivo %s no es un disco válido (.mia)\n", path)
-		return
+		fmt.Fprintf(out, "Error: El archivo %s no es un disco válido (.mia)\n", path)
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El archivo %s no es un disco válido (.mia)", path)
 	}
 
 	// Validar que el nombre no esté vacío
 	if name == "" {
-		fmt.Println("Error: El parámetro -name es requerido")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -name es requerido")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -name es requerido")
 	}
 
 	// validar fit
 	if fit != "bf" && fit != "ff" && fit != "wf" {
-		fmt.Println("Error: Fit debe ser bf, ff, o wf")
-		return
+		fmt.Fprintln(out, "Error: Fit debe ser bf, ff, o wf")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Fit debe ser bf, ff, o wf")
 	}
 
 	// validate tamaño mayor a 0
 	if size <= 0 {
-		fmt.Println("Error: Tamaño debe ser mayor a 0")
-		return
+		fmt.Fprintln(out, "Error: Tamaño debe ser mayor a 0")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Tamaño debe ser mayor a 0")
 	}
 
 	// validar unidad
 	if unit != "b" && unit != "k" && unit != "m" {
-		fmt.Println("Error: Unidad debe ser b, k o m")
-		return
+		fmt.Fprintln(out, "Error: Unidad debe ser b, k o m")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Unidad debe ser b, k o m")
 	}
 
 	// validar tipo de partición
 	if type_ != "p" && type_ != "e" && type_ != "l" {
-		fmt.Println("Error: Tipo debe ser p (primaria), e (extendida) o l (lógica)")
-		return
+		fmt.Fprintln(out, "Error: Tipo debe ser p (primaria), e (extendida) o l (lógica)")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Tipo debe ser p (primaria), e (extendida) o l (lógica)")
 	}
 
 	// Definir tamaño en bytes
@@ -746,46 +791,53 @@ func Fdisk(size int, path string, name string, type_ string, fit string, unit st
 	// Abrir archivo binario usando la ruta directamente
 	file, err := Utilities.OpenFile(path)
 	if err != nil {
-		fmt.Println("Error abriendo archivo:", err)
-		return
+		fmt.Fprintln(out, "Error abriendo archivo:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo: %v", err)
 	}
 	defer file.Close()
 
 	var tempMBR Structs.MBR
 	// Leer MBR desde archivo
 	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-		fmt.Println("Error leyendo MBR del archivo :", err)
-		return
+		fmt.Fprintln(out, "Error leyendo MBR del archivo :", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo MBR del archivo : %v", err)
 	}
 
 	// VALIDACIÓN DE ESPACIO DISPONIBLE
-	if !validateDiskSpace(&tempMBR, int32(size)) {
-		fmt.Println("======FIN FDISK====== (Error de espacio insuficiente)")
-		return
+	if !validateDiskSpace(out, &tempMBR, int32(size)) {
+		fmt.Fprintln(out, "======FIN FDISK====== (Error de espacio insuficiente)")
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "Espacio insuficiente en el disco para la partición '%s'", name)
 	}
 
 	// VALIDACIONES DE TEORÍA DE PARTICIONES
 	if type_ == "p" || type_ == "e" {
 		// Para particiones primarias y extendidas
-		if !validatePrimaryExtendedPartition(&tempMBR, type_, name) {
-			fmt.Println("======FIN FDISK====== (Error de validación)")
-			return
+		if !validatePrimaryExtendedPartition(out, &tempMBR, type_, name) {
+			fmt.Fprintln(out, "======FIN FDISK====== (Error de validación)")
+			return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La partición '%s' no cumple las reglas de particionamiento", name)
+		}
+		if err := createPrimaryOrExtended(out, file, &tempMBR, size, name, type_, fit); err != nil {
+			fmt.Fprintln(out, "======FIN FDISK====== (Error de espacio insuficiente)")
+			return err
 		}
-		createPrimaryOrExtended(file, &tempMBR, size, name, type_, fit)
 	} else if type_ == "l" {
 		// Para particiones lógicas
-		if !validateLogicalPartition(&tempMBR, name) {
-			fmt.Println("======FIN FDISK====== (Error de validación)")
-			return
+		if !validateLogicalPartition(out, &tempMBR, name) {
+			fmt.Fprintln(out, "======FIN FDISK====== (Error de validación)")
+			return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La partición '%s' no cumple las reglas de particionamiento", name)
+		}
+		if err := createLogicalPartition(out, file, &tempMBR, size, name, fit); err != nil {
+			fmt.Fprintln(out, "======FIN FDISK====== (Error al crear la partición lógica)")
+			return err
 		}
-		createLogicalPartition(file, &tempMBR, size, name, fit)
 	}
 
-	fmt.Println("======FIN FDISK======")
+	fmt.Fprintln(out, "======FIN FDISK======")
+	return nil
 }
 
 // Función para validar particiones primarias y extendidas
-func validatePrimaryExtendedPartition(tempMBR *Structs.MBR, type_ string, name string) bool {
+func validatePrimaryExtendedPartition(out io.Writer, tempMBR *Structs.MBR, type_ string, name string) bool {
 	// Contar particiones primarias y extendidas ocupadas
 	primaryExtendedCount := 0
 	extendedExists := false
@@ -803,7 +855,7 @@ func validatePrimaryExtendedPartition(tempMBR *Structs.MBR, type_ string, name s
 			// Verificar que no exista una partición con el mismo nombre
 			existingName := strings.TrimSpace(strings.Trim(string(tempMBR.Partitions[i].Name[:]), "\x00"))
 			if existingName == name {
-				fmt.Printf("Error: Ya existe una partición con el nombre '%s'\n", name)
+				fmt.Fprintf(out, "Error: Ya existe una partición con el nombre '%s'\n", name)
 				return false
 			}
 		}
@@ -811,13 +863,13 @@ func validatePrimaryExtendedPartition(tempMBR *Structs.MBR, type_ string, name s
 	
 	// RESTRICCIÓN 1: La suma de primarias y extendidas debe ser como máximo 4
 	if primaryExtendedCount >= 4 {
-		fmt.Println("Error: No se pueden crear más particiones. Máximo 4 particiones primarias/extendidas permitidas")
+		fmt.Fprintln(out, "Error: No se pueden crear más particiones. Máximo 4 particiones primarias/extendidas permitidas")
 		return false
 	}
 	
 	// RESTRICCIÓN 2: Solo puede haber una partición extendida por disco
 	if type_ == "e" && extendedExists {
-		fmt.Println("Error: Solo puede haber una partición extendida por disco")
+		fmt.Fprintln(out, "Error: Solo puede haber una partición extendida por disco")
 		return false
 	}
 	
@@ -825,7 +877,7 @@ func validatePrimaryExtendedPartition(tempMBR *Structs.MBR, type_ string, name s
 }
 
 // Función para validar particiones lógicas
-func validateLogicalPartition(tempMBR *Structs.MBR, name string) bool {
+func validateLogicalPartition(out io.Writer, tempMBR *Structs.MBR, name string) bool {
 	// RESTRICCIÓN 3: No se puede crear una partición lógica si no hay una extendida
 	extendedIndex := -1
 	for i := 0; i < 4; i++ {
@@ -836,7 +888,7 @@ func validateLogicalPartition(tempMBR *Structs.MBR, name string) bool {
 	}
 	
 	if extendedIndex == -1 {
-		fmt.Println("Error: No se puede crear una partición lógica sin una partición extendida")
+		fmt.Fprintln(out, "Error: No se puede crear una partición lógica sin una partición extendida")
 		return false
 	}
 	
@@ -852,7 +904,7 @@ func logicalPartitionNameExists(tempMBR *Structs.MBR, name string, extendedIndex
 }
 
 // Función para validar que hay espacio suficiente en el disco
-func validateDiskSpace(tempMBR *Structs.MBR, newPartitionSize int32) bool {
+func validateDiskSpace(out io.Writer, tempMBR *Structs.MBR, newPartitionSize int32) bool {
 	// Calcular el espacio total usado por las particiones existentes
 	var totalUsedSpace int32 = 0
 	
@@ -869,68 +921,66 @@ func validateDiskSpace(tempMBR *Structs.MBR, newPartitionSize int32) bool {
 	
 	// Verificar si hay espacio suficiente para la nueva partición
 	if totalUsedSpace + newPartitionSize > availableSpace {
-		fmt.Printf("Error: Espacio insuficiente en el disco\n")
-		fmt.Printf("  - Tamaño total del disco: %d bytes (%.2f MB)\n", tempMBR.MbrSize, float64(tempMBR.MbrSize)/(1024*1024))
-		fmt.Printf("  - Espacio disponible para particiones: %d bytes (%.2f MB)\n", availableSpace, float64(availableSpace)/(1024*1024))
-		fmt.Printf("  - Espacio usado actualmente: %d bytes (%.2f MB)\n", totalUsedSpace, float64(totalUsedSpace)/(1024*1024))
-		fmt.Printf("  - Espacio requerido para nueva partición: %d bytes (%.2f MB)\n", newPartitionSize, float64(newPartitionSize)/(1024*1024))
-		fmt.Printf("  - Espacio libre restante: %d bytes (%.2f MB)\n", availableSpace-totalUsedSpace, float64(availableSpace-totalUsedSpace)/(1024*1024))
+		fmt.Fprintf(out, "Error: Espacio insuficiente en el disco\n")
+		fmt.Fprintf(out, "  - Tamaño total del disco: %d bytes (%.2f MB)\n", tempMBR.MbrSize, float64(tempMBR.MbrSize)/(1024*1024))
+		fmt.Fprintf(out, "  - Espacio disponible para particiones: %d bytes (%.2f MB)\n", availableSpace, float64(availableSpace)/(1024*1024))
+		fmt.Fprintf(out, "  - Espacio usado actualmente: %d bytes (%.2f MB)\n", totalUsedSpace, float64(totalUsedSpace)/(1024*1024))
+		fmt.Fprintf(out, "  - Espacio requerido para nueva partición: %d bytes (%.2f MB)\n", newPartitionSize, float64(newPartitionSize)/(1024*1024))
+		fmt.Fprintf(out, "  - Espacio libre restante: %d bytes (%.2f MB)\n", availableSpace-totalUsedSpace, float64(availableSpace-totalUsedSpace)/(1024*1024))
 		return false
 	}
 	
 	return true
 }
 
-func createPrimaryOrExtended(file *os.File, tempMBR *Structs.MBR, size int, name string, type_ string, fit string) {
-	var gap = int32(0)
-	// Iterar por las particiones para calcular espacios
-	for i := 0; i < 4; i++ {
-		if tempMBR.Partitions[i].Size != 0 {
-			gap = tempMBR.Partitions[i].Start + tempMBR.Partitions[i].Size
-		}
-	}
-
+func createPrimaryOrExtended(out io.Writer, file *os.File, tempMBR *Structs.MBR, size int, name string, type_ string, fit string) error {
 	// Buscar partición vacía en el MBR
-	var foundEmpty bool
+	slot := -1
 	for i := 0; i < 4; i++ {
 		if tempMBR.Partitions[i].Size == 0 {
-			foundEmpty = true
-			// Crear nueva partición
-			tempMBR.Partitions[i].Size = int32(size)   // Set size
-			copy(tempMBR.Partitions[i].Name[:], name)  // Set name
-			copy(tempMBR.Partitions[i].Fit[:], fit)    // Set fit
-			copy(tempMBR.Partitions[i].Status[:], "0") // Set status = 0 (inactiva)
-			copy(tempMBR.Partitions[i].Type[:], type_) // Set type
-
-			if gap > 0 {
-				tempMBR.Partitions[i].Start = gap
-			} else {
-				tempMBR.Partitions[i].Start = int32(binary.Size(*tempMBR))
-			}
-
-			// Si es partición extendida, inicializar el primer EBR
-			if type_ == "e" {
-				initializeExtendedPartition(file, tempMBR.Partitions[i].Start)
-			}
+			slot = i
 			break
 		}
 	}
+	if slot == -1 {
+		fmt.Fprintln(out, "Error: No se encontró partición vacía en el MBR")
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "No se encontró partición vacía en el MBR")
+	}
 
-	if !foundEmpty {
-		fmt.Println("Error: No se encontró partición vacía en el MBR")
-		return
+	// Elegir el hueco libre según el ajuste del disco
+	diskFit := normalizeFit(tempMBR.Fit[:])
+	space, found := pickFreeSpace(diskFreeSpaces(tempMBR), int32(size), diskFit)
+	if !found {
+		fmt.Fprintf(out, "Error: No hay un espacio libre contiguo de %d bytes en el disco\n", size)
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay un espacio libre contiguo de %d bytes en el disco", size)
+	}
+	fmt.Fprintf(out, "Hueco elegido (%s): inicio %d, tamaño %d bytes\n", diskFit, space.Start, space.Size)
+
+	// Crear nueva partición
+	tempMBR.Partitions[slot] = Structs.Partition{}
+	tempMBR.Partitions[slot].Size = int32(size)   // Set size
+	tempMBR.Partitions[slot].Start = space.Start  // Set start
+	copy(tempMBR.Partitions[slot].Name[:], name)  // Set name
+	copy(tempMBR.Partitions[slot].Fit[:], fit)    // Set fit
+	copy(tempMBR.Partitions[slot].Status[:], "0") // Set status = 0 (inactiva)
+	copy(tempMBR.Partitions[slot].Type[:], type_) // Set type
+
+	// Si es partición extendida, inicializar el primer EBR
+	if type_ == "e" {
+		initializeExtendedPartition(out, file, tempMBR.Partitions[slot].Start)
 	}
 
 	// Sobreescribir MBR en el archivo
 	if err := Utilities.WriteObject(file, *tempMBR, 0); err != nil {
-		fmt.Println("Error escribiendo MBR en el archivo:", err)
-		return
+		fmt.Fprintln(out, "Error escribiendo MBR en el archivo:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo MBR en el archivo: %v", err)
 	}
 
-	fmt.Println("Partición", type_, "creada exitosamente")
+	fmt.Fprintln(out, "Partición", type_, "creada exitosamente")
+	return nil
 }
 
-func initializeExtendedPartition(file *os.File, start int32) {
+func initializeExtendedPartition(out io.Writer, file *os.File, start int32) {
 	// Crear EBR vacío al inicio de la partición extendida
 	var emptyEBR Structs.EBR
 	copy(emptyEBR.Part_status[:], "0")
@@ -942,11 +992,11 @@ func initializeExtendedPartition(file *os.File, start int32) {
 
 	// Escribir EBR vacío al inicio de la partición extendida
 	if err := Utilities.WriteObject(file, emptyEBR, int64(start)); err != nil {
-		fmt.Println("Error inicializando partición extendida:", err)
+		fmt.Fprintln(out, "Error inicializando partición extendida:", err)
 	}
 }
 
-func createLogicalPartition(file *os.File, tempMBR *Structs.MBR, size int, name string, fit string) {
+func createLogicalPartition(out io.Writer, file *os.File, tempMBR *Structs.MBR, size int, name string, fit string) error {
 	// Buscar partición extendida
 	var extendedIndex = -1
 	for i := 0; i < 4; i++ {
@@ -957,102 +1007,87 @@ func createLogicalPartition(file *os.File, tempMBR *Structs.MBR, size int, name
 	}
 
 	if extendedIndex == -1 {
-		fmt.Println("Error: No existe partición extendida para crear partición lógica")
-		return
+		fmt.Fprintln(out, "Error: No existe partición extendida para crear partición lógica")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No existe partición extendida para crear partición lógica")
 	}
 
 	extendedPartition := tempMBR.Partitions[extendedIndex]
 
 	// Verificar que no exista una partición lógica con el mismo nombre
 	if checkLogicalPartitionNameExists(file, extendedPartition, name) {
-		fmt.Printf("Error: Ya existe una partición lógica con el nombre '%s'\n", name)
-		return
+		fmt.Fprintf(out, "Error: Ya existe una partición lógica con el nombre '%s'\n", name)
+		return Utilities.NewCommandError(Utilities.ErrAlreadyExists, "Ya existe una partición lógica con el nombre '%s'", name)
 	}
 
-	// Navegar por la lista de EBRs para encontrar espacio
-	currentEBRPos := extendedPartition.Start
-	var lastEBRPos int32 = -1
-	var newEBRPos int32
-
-	for {
-		var currentEBR Structs.EBR
-		if err := Utilities.ReadObject(file, &currentEBR, int64(currentEBRPos)); err != nil {
-			fmt.Println("Error leyendo EBR:", err)
-			return
-		}
-
-		// Si es el primer EBR y está vacío
-		if currentEBR.Part_size == 0 {
-			newEBRPos = currentEBRPos
-			break
-		}
-
-		// Si hay siguiente EBR, continuar
-		if currentEBR.Part_next != -1 {
-			lastEBRPos = currentEBRPos
-			currentEBRPos = currentEBR.Part_next
-		} else {
-			// No hay más EBRs, crear uno nuevo al final
-			newEBRPos = currentEBR.Part_start + currentEBR.Part_size
-			lastEBRPos = currentEBRPos
-			break
-		}
+	// Leer la lista de EBRs (ordenada por posición)
+	chain, err := readEBRChain(file, extendedPartition)
+	if err != nil {
+		fmt.Fprintln(out, "Error leyendo EBR:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo EBR: %v", err)
 	}
 
-	// Verificar que hay espacio suficiente en la partición extendida
-	extendedEnd := extendedPartition.Start + extendedPartition.Size
-	if newEBRPos + int32(binary.Size(Structs.EBR{})) + int32(size) > extendedEnd {
-		fmt.Println("Error: No hay espacio suficiente en la partición extendida")
-		return
+	// Elegir el hueco según el ajuste de la extendida: se necesita espacio
+	// para el EBR y los datos de la lógica
+	ebrSize := int32(binary.Size(Structs.EBR{}))
+	extendedFit := normalizeFit(extendedPartition.Fit[:])
+	space, found := pickFreeSpace(logicalFreeSpaces(chain, extendedPartition), ebrSize+int32(size), extendedFit)
+	if !found {
+		fmt.Fprintln(out, "Error: No hay espacio suficiente en la partición extendida")
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay un espacio libre contiguo de %d bytes en la partición extendida", ebrSize+int32(size))
 	}
+	fmt.Fprintf(out, "Hueco elegido (%s): inicio %d, tamaño %d bytes\n", extendedFit, space.Start, space.Size)
 
 	// Crear nuevo EBR
+	newEBRPos := space.Start
 	var newEBR Structs.EBR
 	copy(newEBR.Part_status[:], "0") // Inactiva inicialmente
 	copy(newEBR.Part_fit[:], fit)
-	newEBR.Part_start = newEBRPos + int32(binary.Size(Structs.EBR{})) // Datos después del EBR
+	newEBR.Part_start = newEBRPos + ebrSize // Datos después del EBR
 	newEBR.Part_size = int32(size)
-	newEBR.Part_next = -1 // Es el último por ahora
 	copy(newEBR.Part_name[:], name)
 
-	// Escribir el nuevo EBR
+	// Enlazar el nuevo EBR después del último EBR que está antes del hueco. Si
+	// el hueco empieza en la cabeza vacía, se reutiliza conservando su enlace.
+	prev := -1
+	for i, entry := range chain {
+		if entry.Position <= newEBRPos {
+			prev = i
+		}
+	}
+	newEBR.Part_next = chain[prev].EBR.Part_next
+
 	if err := Utilities.WriteObject(file, newEBR, int64(newEBRPos)); err != nil {
-		fmt.Println("Error escribiendo nuevo EBR:", err)
-		return
+		fmt.Fprintln(out, "Error escribiendo nuevo EBR:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo nuevo EBR: %v", err)
 	}
 
-	// Si no es el primer EBR, actualizar el anterior para que apunte al nuevo
-	if lastEBRPos != -1 && lastEBRPos != newEBRPos {
-		var lastEBR Structs.EBR
-		if err := Utilities.ReadObject(file, &lastEBR, int64(lastEBRPos)); err != nil {
-			fmt.Println("Error leyendo EBR anterior:", err)
-			return
-		}
-		
-		lastEBR.Part_next = newEBRPos
-		if err := Utilities.WriteObject(file, lastEBR, int64(lastEBRPos)); err != nil {
-			fmt.Println("Error actualizando EBR anterior:", err)
-			return
+	if chain[prev].Position != newEBRPos {
+		prevEBR := chain[prev].EBR
+		prevEBR.Part_next = newEBRPos
+		if err := Utilities.WriteObject(file, prevEBR, int64(chain[prev].Position)); err != nil {
+			fmt.Fprintln(out, "Error actualizando EBR anterior:", err)
+			return Utilities.NewCommandError(Utilities.ErrIO, "Error actualizando EBR anterior: %v", err)
 		}
 	}
 
-	fmt.Println("Partición lógica creada exitosamente")
+	fmt.Fprintln(out, "Partición lógica creada exitosamente")
+	return nil
 }
 
-func listLogicalPartitions(file *os.File, extendedPartition Structs.Partition) {
-	fmt.Println("=== Particiones Lógicas ===")
+func listLogicalPartitions(out io.Writer, file *os.File, extendedPartition Structs.Partition) {
+	fmt.Fprintln(out, "=== Particiones Lógicas ===")
 	currentEBRPos := extendedPartition.Start
 
 	for {
 		var currentEBR Structs.EBR
 		if err := Utilities.ReadObject(file, &currentEBR, int64(currentEBRPos)); err != nil {
-			fmt.Println("Error leyendo EBR:", err)
+			fmt.Fprintln(out, "Error leyendo EBR:", err)
 			break
 		}
 
 		// Si el EBR tiene datos válidos, contarlo
 		if currentEBR.Part_size > 0 {
-			fmt.Printf("Partición lógica encontrada: %s\n", string(currentEBR.Part_name[:]))
+			fmt.Fprintf(out, "Partición lógica encontrada: %s\n", string(currentEBR.Part_name[:]))
 		}
 
 		// Si no hay siguiente EBR, terminar
@@ -1064,38 +1099,43 @@ func listLogicalPartitions(file *os.File, extendedPartition Structs.Partition) {
 	}
 }
 
+// PartitionResizer ajusta el contenido de una partición (su sistema de
+// archivos) al nuevo tamaño. fdisk -add la llama después de escribir el
+// cambio en el MBR o el EBR; si retorna error se restaura el tamaño anterior.
+type PartitionResizer func(out io.Writer, file *os.File, partitionStart int32, newSize int32) error
+
 // FdiskAdd - Agregar o quitar espacio de una partición
-func FdiskAdd(path string, name string, add int, unit string) {
-	fmt.Println("======INICIO FDISK ADD======")
-	fmt.Println("Path:", path)
-	fmt.Println("Nombre:", name)
-	fmt.Println("Add:", add)
-	fmt.Println("Unit:", unit)
+func FdiskAdd(out io.Writer, path string, name string, add int, unit string, resize PartitionResizer) error {
+	fmt.Fprintln(out, "======INICIO FDISK ADD======")
+	fmt.Fprintln(out, "Path:", path)
+	fmt.Fprintln(out, "Nombre:", name)
+	fmt.Fprintln(out, "Add:", add)
+	fmt.Fprintln(out, "Unit:", unit)
 
 	// Validar parámetros
 	if path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -path es requerido")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -path es requerido")
 	}
 	if name == "" {
-		fmt.Println("Error: El parámetro -name es requerido")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -name es requerido")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -name es requerido")
 	}
 	if add == 0 {
-		fmt.Println("Error: El parámetro -add no puede ser 0")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -add no puede ser 0")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -add no puede ser 0")
 	}
 
 	// Validar unidad
 	if unit != "b" && unit != "k" && unit != "m" {
-		fmt.Println("Error: Unidad debe ser b, k o m")
-		return
+		fmt.Fprintln(out, "Error: Unidad debe ser b, k o m")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Unidad debe ser b, k o m")
 	}
 
 	// Verificar que el archivo existe
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Printf("Error: El archivo %s no existe\n", path)
-		return
+		fmt.Fprintf(out, "Error: El archivo %s no existe\n", path)
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo %s no existe", path)
 	}
 
 	// Convertir a bytes
@@ -1109,15 +1149,15 @@ func FdiskAdd(path string, name string, add int, unit string) {
 	// Abrir archivo
 	file, err := Utilities.OpenFile(path)
 	if err != nil {
-		fmt.Println("Error abriendo archivo:", err)
-		return
+		fmt.Fprintln(out, "Error abriendo archivo:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo: %v", err)
 	}
 	defer file.Close()
 
 	var tempMBR Structs.MBR
 	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-		fmt.Println("Error leyendo MBR:", err)
-		return
+		fmt.Fprintln(out, "Error leyendo MBR:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo MBR: %v", err)
 	}
 
 	// Buscar la partición
@@ -1136,13 +1176,17 @@ func FdiskAdd(path string, name string, add int, unit string) {
 
 	if !partitionFound {
 		// Buscar en particiones lógicas
-		if modifyLogicalPartitionSize(file, &tempMBR, name, int32(sizeInBytes)) {
-			fmt.Println("Partición lógica modificada exitosamente")
-			fmt.Println("======FIN FDISK ADD======")
-			return
+		found, err := modifyLogicalPartitionSize(out, file, &tempMBR, name, int32(sizeInBytes), resize)
+		if err != nil {
+			return err
 		}
-		fmt.Printf("Error: Partición '%s' no encontrada\n", name)
-		return
+		if found {
+			fmt.Fprintln(out, "Partición lógica modificada exitosamente")
+			fmt.Fprintln(out, "======FIN FDISK ADD======")
+			return nil
+		}
+		fmt.Fprintf(out, "Error: Partición '%s' no encontrada\n", name)
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "Partición '%s' no encontrada", name)
 	}
 
 	// Modificar partición primaria/extendida
@@ -1151,12 +1195,12 @@ func FdiskAdd(path string, name string, add int, unit string) {
 
 	// Validar que el nuevo tamaño sea positivo
 	if newSize <= 0 {
-		fmt.Println("Error: El nuevo tamaño resultaría en una partición negativa o vacía")
-		fmt.Printf("Tamaño actual: %d bytes, intentando %s: %d bytes\n", 
+		fmt.Fprintln(out, "Error: El nuevo tamaño resultaría en una partición negativa o vacía")
+		fmt.Fprintf(out, "Tamaño actual: %d bytes, intentando %s: %d bytes\n", 
 			partition.Size, 
 			map[bool]string{true: "agregar", false: "quitar"}[add > 0],
 			abs(sizeInBytes))
-		return
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nuevo tamaño resultaría en una partición negativa o vacía")
 	}
 
 	// Si se está agregando espacio, verificar que hay espacio disponible después de la partición
@@ -1177,75 +1221,88 @@ func FdiskAdd(path string, name string, add int, unit string) {
 		// Verificar que hay espacio suficiente
 		availableSpace := nextPartitionStart - partitionEnd
 		if int32(sizeInBytes) > availableSpace {
-			fmt.Printf("Error: No hay espacio suficiente después de la partición\n")
-			fmt.Printf("Espacio disponible: %d bytes (%.2f MB)\n", availableSpace, float64(availableSpace)/(1024*1024))
-			fmt.Printf("Espacio requerido: %d bytes (%.2f MB)\n", sizeInBytes, float64(sizeInBytes)/(1024*1024))
-			return
+			fmt.Fprintf(out, "Error: No hay espacio suficiente después de la partición\n")
+			fmt.Fprintf(out, "Espacio disponible: %d bytes (%.2f MB)\n", availableSpace, float64(availableSpace)/(1024*1024))
+			fmt.Fprintf(out, "Espacio requerido: %d bytes (%.2f MB)\n", sizeInBytes, float64(sizeInBytes)/(1024*1024))
+			return Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay espacio suficiente después de la partición")
 		}
 	}
 
-	// Aplicar el cambio
+	// Aplicar el cambio y escribir el MBR actualizado antes de tocar el
+	// sistema de archivos: si la escritura falla no cambió nada
+	oldSize := partition.Size
 	partition.Size = newSize
-
-	// Escribir MBR actualizado
 	if err := Utilities.WriteObject(file, tempMBR, 0); err != nil {
-		fmt.Println("Error escribiendo MBR actualizado:", err)
-		return
+		fmt.Fprintln(out, "Error escribiendo MBR actualizado:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo MBR actualizado: %v", err)
 	}
 
-	fmt.Printf("✓ Partición '%s' modificada exitosamente\n", name)
-	fmt.Printf("  Tamaño anterior: %d bytes (%.2f MB)\n", partition.Size - int32(sizeInBytes), float64(partition.Size - int32(sizeInBytes))/(1024*1024))
-	fmt.Printf("  Tamaño nuevo: %d bytes (%.2f MB)\n", partition.Size, float64(partition.Size)/(1024*1024))
-	fmt.Printf("  Cambio: %+d bytes (%+.2f MB)\n", sizeInBytes, float64(sizeInBytes)/(1024*1024))
-	fmt.Println("======FIN FDISK ADD======")
+	// Ajustar el sistema de archivos; si falla se restaura el tamaño anterior
+	if resize != nil && string(partition.Type[:]) != "e" {
+		if err := resize(out, file, partition.Start, newSize); err != nil {
+			partition.Size = oldSize
+			if restoreErr := Utilities.WriteObject(file, tempMBR, 0); restoreErr != nil {
+				fmt.Fprintln(out, "Error restaurando el MBR:", restoreErr)
+				return Utilities.NewCommandError(Utilities.ErrIO, "%v; además no se pudo restaurar el tamaño anterior en el MBR: %v", err, restoreErr)
+			}
+			return err
+		}
+	}
+
+	fmt.Fprintf(out, "✓ Partición '%s' modificada exitosamente\n", name)
+	fmt.Fprintf(out, "  Tamaño anterior: %d bytes (%.2f MB)\n", partition.Size - int32(sizeInBytes), float64(partition.Size - int32(sizeInBytes))/(1024*1024))
+	fmt.Fprintf(out, "  Tamaño nuevo: %d bytes (%.2f MB)\n", partition.Size, float64(partition.Size)/(1024*1024))
+	fmt.Fprintf(out, "  Cambio: %+d bytes (%+.2f MB)\n", sizeInBytes, float64(sizeInBytes)/(1024*1024))
+	fmt.Fprintln(out, "======FIN FDISK ADD======")
+	return nil
 }
 
 // FdiskDelete - Eliminar una partición
-func FdiskDelete(path string, name string, deleteType string) {
-	fmt.Println("======INICIO FDISK DELETE======")
-	fmt.Println("Path:", path)
-	fmt.Println("Nombre:", name)
-	fmt.Println("Tipo de eliminación:", deleteType)
+func FdiskDelete(out io.Writer, path string, name string, deleteType string) error {
+	fmt.Fprintln(out, "======INICIO FDISK DELETE======")
+	fmt.Fprintln(out, "Path:", path)
+	fmt.Fprintln(out, "Nombre:", name)
+	fmt.Fprintln(out, "Tipo de eliminación:", deleteType)
 
 	// Validar parámetros
 	if path == "" {
-		fmt.Println("Error: El parámetro -path es requerido")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -path es requerido")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -path es requerido")
 	}
 	if name == "" {
-		fmt.Println("Error: El parámetro -name es requerido")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -name es requerido")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -name es requerido")
 	}
 	if deleteType != "fast" && deleteType != "full" {
-		fmt.Println("Error: El parámetro -delete debe ser 'fast' o 'full'")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -delete debe ser 'fast' o 'full'")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -delete debe ser 'fast' o 'full'")
 	}
 
 	// Verificar que el archivo existe
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		fmt.Printf("Error: El archivo %s no existe\n", path)
-		return
+		fmt.Fprintf(out, "Error: El archivo %s no existe\n", path)
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo %s no existe", path)
 	}
 
 	// Advertencia (sin solicitar confirmación para evitar bloqueo en API)
-	fmt.Printf("\n⚠️  ADVERTENCIA: Eliminando la partición '%s'\n", name)
-	fmt.Printf("Tipo de eliminación: %s\n", deleteType)
+	fmt.Fprintf(out, "\n⚠️  ADVERTENCIA: Eliminando la partición '%s'\n", name)
+	fmt.Fprintf(out, "Tipo de eliminación: %s\n", deleteType)
 	if deleteType == "full" {
-		fmt.Println("Esta operación sobrescribirá los datos con \\0 (puede tardar)")
+		fmt.Fprintln(out, "Esta operación sobrescribirá los datos con \\0 (puede tardar)")
 	}
 
 	// Abrir archivo
 	file, err := Utilities.OpenFile(path)
 	if err != nil {
-		fmt.Println("Error abriendo archivo:", err)
-		return
+		fmt.Fprintln(out, "Error abriendo archivo:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo: %v", err)
 	}
 	defer file.Close()
 
 	var tempMBR Structs.MBR
 	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-		fmt.Println("Error leyendo MBR:", err)
-		return
+		fmt.Fprintln(out, "Error leyendo MBR:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo MBR: %v", err)
 	}
 
 	// Buscar la partición
@@ -1268,19 +1325,19 @@ func FdiskDelete(path string, name string, deleteType string) {
 
 	if !partitionFound {
 		// Buscar en particiones lógicas
-		if deleteLogicalPartition(file, &tempMBR, name, deleteType) {
-			fmt.Println("Partición lógica eliminada exitosamente")
-			fmt.Println("======FIN FDISK DELETE======")
-			return
+		if deleteLogicalPartition(out, file, &tempMBR, name, deleteType) {
+			fmt.Fprintln(out, "Partición lógica eliminada exitosamente")
+			fmt.Fprintln(out, "======FIN FDISK DELETE======")
+			return nil
 		}
-		fmt.Printf("Error: Partición '%s' no encontrada\n", name)
-		return
+		fmt.Fprintf(out, "Error: Partición '%s' no encontrada\n", name)
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "Partición '%s' no encontrada", name)
 	}
 
 	// Si es extendida, eliminar todas las lógicas primero
 	if isExtended {
-		fmt.Println("Eliminando particiones lógicas dentro de la partición extendida...")
-		deleteAllLogicalPartitions(file, &tempMBR, tempMBR.Partitions[partitionIndex], deleteType)
+		fmt.Fprintln(out, "Eliminando particiones lógicas dentro de la partición extendida...")
+		deleteAllLogicalPartitions(out, file, &tempMBR, tempMBR.Partitions[partitionIndex], deleteType)
 	}
 
 	// Guardar información de la partición antes de eliminar
@@ -1288,7 +1345,7 @@ func FdiskDelete(path string, name string, deleteType string) {
 
 	// Si es eliminación completa (full), llenar con ceros
 	if deleteType == "full" {
-		fmt.Println("Sobrescribiendo datos de la partición con \\0...")
+		fmt.Fprintln(out, "Sobrescribiendo datos de la partición con \\0...")
 		zeroBuffer := make([]byte, 1024)
 		bytesToWrite := int(partition.Size)
 		offset := int64(partition.Start)
@@ -1299,13 +1356,13 @@ func FdiskDelete(path string, name string, deleteType string) {
 				writeSize = bytesToWrite
 			}
 			if err := Utilities.WriteObject(file, zeroBuffer[:writeSize], offset); err != nil {
-				fmt.Println("Error sobrescribiendo datos:", err)
-				return
+				fmt.Fprintln(out, "Error sobrescribiendo datos:", err)
+				return Utilities.NewCommandError(Utilities.ErrIO, "Error sobrescribiendo datos: %v", err)
 			}
 			bytesToWrite -= writeSize
 			offset += int64(writeSize)
 		}
-		fmt.Println("Datos sobrescritos exitosamente")
+		fmt.Fprintln(out, "Datos sobrescritos exitosamente")
 	}
 
 	// Marcar la partición como vacía en el MBR
@@ -1322,14 +1379,15 @@ func FdiskDelete(path string, name string, deleteType string) {
 
 	// Escribir MBR actualizado
 	if err := Utilities.WriteObject(file, tempMBR, 0); err != nil {
-		fmt.Println("Error escribiendo MBR actualizado:", err)
-		return
+		fmt.Fprintln(out, "Error escribiendo MBR actualizado:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo MBR actualizado: %v", err)
 	}
 
-	fmt.Printf("✓ Partición '%s' eliminada exitosamente\n", name)
-	fmt.Printf("  Tipo: %s\n", string(partition.Type[:]))
-	fmt.Printf("  Tamaño liberado: %d bytes (%.2f MB)\n", partition.Size, float64(partition.Size)/(1024*1024))
-	fmt.Println("======FIN FDISK DELETE======")
+	fmt.Fprintf(out, "✓ Partición '%s' eliminada exitosamente\n", name)
+	fmt.Fprintf(out, "  Tipo: %s\n", string(partition.Type[:]))
+	fmt.Fprintf(out, "  Tamaño liberado: %d bytes (%.2f MB)\n", partition.Size, float64(partition.Size)/(1024*1024))
+	fmt.Fprintln(out, "======FIN FDISK DELETE======")
+	return nil
 }
 
 // Función auxiliar para valor absoluto
@@ -1340,8 +1398,9 @@ func abs(n int) int {
 	return n
 }
 
-// Función auxiliar para modificar el tamaño de una partición lógica
-func modifyLogicalPartitionSize(file *os.File, tempMBR *Structs.MBR, name string, sizeChange int32) bool {
+// Función auxiliar para modificar el tamaño de una partición lógica. Retorna
+// si la encontró y el error que impidió modificarla.
+func modifyLogicalPartitionSize(out io.Writer, file *os.File, tempMBR *Structs.MBR, name string, sizeChange int32, resize PartitionResizer) (bool, error) {
 	// Buscar partición extendida
 	var extendedIndex = -1
 	for i := 0; i < 4; i++ {
@@ -1352,7 +1411,7 @@ func modifyLogicalPartitionSize(file *os.File, tempMBR *Structs.MBR, name string
 	}
 
 	if extendedIndex == -1 {
-		return false
+		return false, nil
 	}
 
 	extendedPartition := tempMBR.Partitions[extendedIndex]
@@ -1372,8 +1431,8 @@ func modifyLogicalPartitionSize(file *os.File, tempMBR *Structs.MBR, name string
 
 				// Validar que el nuevo tamaño sea positivo
 				if newSize <= 0 {
-					fmt.Println("Error: El nuevo tamaño resultaría en una partición negativa o vacía")
-					return false
+					fmt.Fprintln(out, "Error: El nuevo tamaño resultaría en una partición negativa o vacía")
+					return true, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nuevo tamaño resultaría en una partición negativa o vacía")
 				}
 
 				// Si se está agregando espacio, verificar disponibilidad
@@ -1387,20 +1446,34 @@ func modifyLogicalPartitionSize(file *os.File, tempMBR *Structs.MBR, name string
 					
 					availableSpace := nextEBRStart - ebrEnd
 					if sizeChange > availableSpace {
-						fmt.Printf("Error: No hay espacio suficiente después de la partición lógica\n")
-						fmt.Printf("Espacio disponible: %d bytes\n", availableSpace)
-						return false
+						fmt.Fprintf(out, "Error: No hay espacio suficiente después de la partición lógica\n")
+						fmt.Fprintf(out, "Espacio disponible: %d bytes\n", availableSpace)
+						return true, Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay espacio suficiente después de la partición lógica")
 					}
 				}
 
-				// Aplicar cambio
+				// Aplicar cambio: el EBR se escribe antes de tocar el
+				// sistema de archivos, como el MBR en FdiskAdd
+				oldSize := currentEBR.Part_size
 				currentEBR.Part_size = newSize
 				if err := Utilities.WriteObject(file, currentEBR, int64(currentEBRPos)); err != nil {
-					fmt.Println("Error actualizando EBR:", err)
-					return false
+					fmt.Fprintln(out, "Error actualizando EBR:", err)
+					return true, Utilities.NewCommandError(Utilities.ErrIO, "Error actualizando EBR: %v", err)
 				}
 
-				return true
+				// Ajustar el sistema de archivos; si falla se restaura el EBR
+				if resize != nil {
+					if err := resize(out, file, currentEBRPos+int32(binary.Size(Structs.EBR{})), newSize); err != nil {
+						currentEBR.Part_size = oldSize
+						if restoreErr := Utilities.WriteObject(file, currentEBR, int64(currentEBRPos)); restoreErr != nil {
+							fmt.Fprintln(out, "Error restaurando el EBR:", restoreErr)
+							return true, Utilities.NewCommandError(Utilities.ErrIO, "%v; además no se pudo restaurar el tamaño anterior en el EBR: %v", err, restoreErr)
+						}
+						return true, err
+					}
+				}
+
+				return true, nil
 			}
 		}
 
@@ -1410,11 +1483,11 @@ func modifyLogicalPartitionSize(file *os.File, tempMBR *Structs.MBR, name string
 		currentEBRPos = currentEBR.Part_next
 	}
 
-	return false
+	return false, nil
 }
 
 // Función auxiliar para eliminar una partición lógica
-func deleteLogicalPartition(file *os.File, tempMBR *Structs.MBR, name string, deleteType string) bool {
+func deleteLogicalPartition(out io.Writer, file *os.File, tempMBR *Structs.MBR, name string, deleteType string) bool {
 	// Buscar partición extendida
 	var extendedIndex = -1
 	for i := 0; i < 4; i++ {
@@ -1444,7 +1517,7 @@ func deleteLogicalPartition(file *os.File, tempMBR *Structs.MBR, name string, de
 			if ebrName == name {
 				// Si es eliminación completa, llenar con ceros
 				if deleteType == "full" {
-					fmt.Println("Sobrescribiendo datos de la partición lógica con \\0...")
+					fmt.Fprintln(out, "Sobrescribiendo datos de la partición lógica con \\0...")
 					zeroBuffer := make([]byte, 1024)
 					bytesToWrite := int(currentEBR.Part_size)
 					offset := int64(currentEBR.Part_start)
@@ -1469,12 +1542,16 @@ func deleteLogicalPartition(file *os.File, tempMBR *Structs.MBR, name string, de
 					Utilities.WriteObject(file, prevEBR, int64(prevEBRPos))
 				}
 
-				// Marcar el EBR actual como vacío
+				// Marcar el EBR actual como vacío. La cabeza de la lista conserva
+				// su enlace para no perder las lógicas que siguen.
 				var emptyEBR Structs.EBR
 				copy(emptyEBR.Part_status[:], "0")
 				emptyEBR.Part_start = -1
 				emptyEBR.Part_size = 0
 				emptyEBR.Part_next = -1
+				if prevEBRPos == -1 {
+					emptyEBR.Part_next = currentEBR.Part_next
+				}
 				Utilities.WriteObject(file, emptyEBR, int64(currentEBRPos))
 
 				return true
@@ -1492,7 +1569,7 @@ func deleteLogicalPartition(file *os.File, tempMBR *Structs.MBR, name string, de
 }
 
 // Función auxiliar para eliminar todas las particiones lógicas
-func deleteAllLogicalPartitions(file *os.File, tempMBR *Structs.MBR, extendedPartition Structs.Partition, deleteType string) {
+func deleteAllLogicalPartitions(out io.Writer, file *os.File, tempMBR *Structs.MBR, extendedPartition Structs.Partition, deleteType string) {
 	currentEBRPos := extendedPartition.Start
 
 	for {
@@ -1503,7 +1580,7 @@ func deleteAllLogicalPartitions(file *os.File, tempMBR *Structs.MBR, extendedPar
 
 		if currentEBR.Part_size > 0 {
 			ebrName := strings.TrimSpace(strings.Trim(string(currentEBR.Part_name[:]), "\x00"))
-			fmt.Printf("  Eliminando partición lógica: %s\n", ebrName)
+			fmt.Fprintf(out, "  Eliminando partición lógica: %s\n", ebrName)
 
 			// Si es eliminación completa, llenar con ceros
 			if deleteType == "full" {
@@ -1530,37 +1607,37 @@ func deleteAllLogicalPartitions(file *os.File, tempMBR *Structs.MBR, extendedPar
 	}
 }
 
-func Rep(name string, path string, id string, drive string) {
-	fmt.Println("======INICIO REP======")
-	fmt.Println("Nombre:", name)
-	fmt.Println("Path:", path)
-	fmt.Println("Id:", id)
-	fmt.Println("Drive:", drive)
+func Rep(out io.Writer, name string, path string, id string, drive string) {
+	fmt.Fprintln(out, "======INICIO REP======")
+	fmt.Fprintln(out, "Nombre:", name)
+	fmt.Fprintln(out, "Path:", path)
+	fmt.Fprintln(out, "Id:", id)
+	fmt.Fprintln(out, "Drive:", drive)
 
 	if name == "mbr" && drive != "" {
-		reportMBR(drive)
+		reportMBR(out, drive)
 	} else if name == "disk" && drive != "" {
-		reportDisk(drive)
+		reportDisk(out, drive)
 	} else {
-		fmt.Println("Error: Parámetros inválidos para el reporte")
+		fmt.Fprintln(out, "Error: Parámetros inválidos para el reporte")
 	}
 
-	fmt.Println("======FIN REP======")
+	fmt.Fprintln(out, "======FIN REP======")
 }
 
-func reportMBR(drive string) {
-	fmt.Println("=== REPORTE MBR ===")
+func reportMBR(out io.Writer, drive string) {
+	fmt.Fprintln(out, "=== REPORTE MBR ===")
 	
 	// Abrir archivo binario usando el mapa de drives
 	filepath, exists := GetDrivePath(drive)
 	if !exists {
-		fmt.Printf("Error: Drive %s no encontrado. Asegúrate de haber creado el disco primero con mkdisk.\n", drive)
+		fmt.Fprintf(out, "Error: Drive %s no encontrado. Asegúrate de haber creado el disco primero con mkdisk.\n", drive)
 		return
 	}
 	
 	file, err := Utilities.OpenFile(filepath)
 	if err != nil {
-		fmt.Println("Error abriendo archivo:", err)
+		fmt.Fprintln(out, "Error abriendo archivo:", err)
 		return
 	}
 	defer file.Close()
@@ -1568,35 +1645,35 @@ func reportMBR(drive string) {
 	var tempMBR Structs.MBR
 	// Leer MBR del archivo
 	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-		fmt.Println("Error leyendo MBR del archivo", err)
+		fmt.Fprintln(out, "Error leyendo MBR del archivo", err)
 		return
 	}
 
 	// Mostrar información del MBR
-	fmt.Printf("MBR - Tamaño: %d, Fecha: %s, Fit: %s\n", tempMBR.MbrSize, string(tempMBR.CreationDate[:]), string(tempMBR.Fit[:]))
+	fmt.Fprintf(out, "MBR - Tamaño: %d, Fecha: %s, Fit: %s\n", tempMBR.MbrSize, string(tempMBR.CreationDate[:]), string(tempMBR.Fit[:]))
 
 	// Mostrar particiones lógicas si existen
 	for i := 0; i < 4; i++ {
 		if string(tempMBR.Partitions[i].Type[:]) == "e" && tempMBR.Partitions[i].Size != 0 {
-			fmt.Println("\n=== PARTICIONES LÓGICAS EN PARTICIÓN EXTENDIDA ===")
-			listLogicalPartitions(file, tempMBR.Partitions[i])
+			fmt.Fprintln(out, "\n=== PARTICIONES LÓGICAS EN PARTICIÓN EXTENDIDA ===")
+			listLogicalPartitions(out, file, tempMBR.Partitions[i])
 		}
 	}
 }
 
-func reportDisk(drive string) {
-	fmt.Println("=== REPORTE DISCO ===")
+func reportDisk(out io.Writer, drive string) {
+	fmt.Fprintln(out, "=== REPORTE DISCO ===")
 	
 	// Abrir archivo binario usando el mapa de drives
 	filepath, exists := GetDrivePath(drive)
 	if !exists {
-		fmt.Printf("Error: Drive %s no encontrado. Asegúrate de haber creado el disco primero con mkdisk.\n", drive)
+		fmt.Fprintf(out, "Error: Drive %s no encontrado. Asegúrate de haber creado el disco primero con mkdisk.\n", drive)
 		return
 	}
 	
 	file, err := Utilities.OpenFile(filepath)
 	if err != nil {
-		fmt.Println("Error abriendo archivo:", err)
+		fmt.Fprintln(out, "Error abriendo archivo:", err)
 		return
 	}
 	defer file.Close()
@@ -1604,20 +1681,20 @@ func reportDisk(drive string) {
 	var tempMBR Structs.MBR
 	// Leer MBR del archivo
 	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-		fmt.Println("Error leyendo MBR del archivo", err)
+		fmt.Fprintln(out, "Error leyendo MBR del archivo", err)
 		return
 	}
 
-	fmt.Printf("Tamaño total del disco: %d bytes\n", tempMBR.MbrSize)
-	fmt.Printf("Fecha de creación: %s\n", string(tempMBR.CreationDate[:]))
-	fmt.Printf("Fit: %s\n", string(tempMBR.Fit[:]))
+	fmt.Fprintf(out, "Tamaño total del disco: %d bytes\n", tempMBR.MbrSize)
+	fmt.Fprintf(out, "Fecha de creación: %s\n", string(tempMBR.CreationDate[:]))
+	fmt.Fprintf(out, "Fit: %s\n", string(tempMBR.Fit[:]))
 	
 	usedSpace := int32(binary.Size(tempMBR))
 	
-	fmt.Println("\n=== DISTRIBUCIÓN DEL ESPACIO ===")
+	fmt.Fprintln(out, "\n=== DISTRIBUCIÓN DEL ESPACIO ===")
 	for i := 0; i < 4; i++ {
 		if tempMBR.Partitions[i].Size != 0 {
-			fmt.Printf("Partición %d: %s (%s) - %d bytes\n", 
+			fmt.Fprintf(out, "Partición %d: %s (%s) - %d bytes\n", 
 				i+1, 
 				string(tempMBR.Partitions[i].Name[:]), 
 				string(tempMBR.Partitions[i].Type[:]),
@@ -1627,7 +1704,7 @@ func reportDisk(drive string) {
 	}
 	
 	freeSpace := tempMBR.MbrSize - usedSpace
-	fmt.Printf("Espacio libre: %d bytes\n", freeSpace)
+	fmt.Fprintf(out, "Espacio libre: %d bytes\n", freeSpace)
 }
 
 // Función para verificar si ya existe una partición lógica con el nombre dado
@@ -0,0 +1,134 @@
+package DiskManagement
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// ESPACIOS LIBRES Y AJUSTE (FIRST / BEST / WORST FIT)
+// ============================================================================
+
+// freeSpace es un hueco libre contiguo dentro del disco o de la extendida
+type freeSpace struct {
+	Start int32
+	Size  int32
+}
+
+// ebrEntry es un EBR de la lista enlazada junto con su posición en el disco
+type ebrEntry struct {
+	Position int32
+	EBR      Structs.EBR
+}
+
+// normalizeFit convierte el ajuste guardado ("bf", "ff", "wf" o su primera
+// letra en los EBR) al formato de dos letras. Por defecto es first fit.
+func normalizeFit(stored []byte) string {
+	fit := strings.ToLower(strings.TrimSpace(strings.Trim(string(stored), "\x00")))
+	switch {
+	case strings.HasPrefix(fit, "b"):
+		return "bf"
+	case strings.HasPrefix(fit, "w"):
+		return "wf"
+	default:
+		return "ff"
+	}
+}
+
+// pickFreeSpace elige un hueco de al menos size bytes según el ajuste: el
+// primero (ff), el más pequeño que alcance (bf) o el más grande (wf). Los
+// huecos deben venir ordenados por posición.
+func pickFreeSpace(spaces []freeSpace, size int32, fit string) (freeSpace, bool) {
+	chosen := -1
+	for i, space := range spaces {
+		if space.Size < size {
+			continue
+		}
+		if chosen == -1 {
+			chosen = i
+			if fit == "ff" {
+				break
+			}
+			continue
+		}
+		if (fit == "bf" && space.Size < spaces[chosen].Size) || (fit == "wf" && space.Size > spaces[chosen].Size) {
+			chosen = i
+		}
+	}
+	if chosen == -1 {
+		return freeSpace{}, false
+	}
+	return spaces[chosen], true
+}
+
+// diskFreeSpaces calcula los huecos entre las particiones primarias y
+// extendidas, desde el final del MBR hasta el final del disco
+func diskFreeSpaces(tempMBR *Structs.MBR) []freeSpace {
+	var used []Structs.Partition
+	for i := 0; i < 4; i++ {
+		if tempMBR.Partitions[i].Size > 0 {
+			used = append(used, tempMBR.Partitions[i])
+		}
+	}
+	sort.Slice(used, func(i, j int) bool { return used[i].Start < used[j].Start })
+
+	var spaces []freeSpace
+	cursor := int32(binary.Size(*tempMBR))
+	for _, partition := range used {
+		if partition.Start > cursor {
+			spaces = append(spaces, freeSpace{Start: cursor, Size: partition.Start - cursor})
+		}
+		if end := partition.Start + partition.Size; end > cursor {
+			cursor = end
+		}
+	}
+	if tempMBR.MbrSize > cursor {
+		spaces = append(spaces, freeSpace{Start: cursor, Size: tempMBR.MbrSize - cursor})
+	}
+	return spaces
+}
+
+// readEBRChain recorre la lista de EBRs de la extendida desde su cabeza
+func readEBRChain(file *os.File, extendedPartition Structs.Partition) ([]ebrEntry, error) {
+	var chain []ebrEntry
+	visited := make(map[int32]bool)
+	position := extendedPartition.Start
+
+	for position != -1 && !visited[position] {
+		visited[position] = true
+		var ebr Structs.EBR
+		if err := Utilities.ReadObject(file, &ebr, int64(position)); err != nil {
+			return nil, fmt.Errorf("error leyendo EBR en %d: %v", position, err)
+		}
+		chain = append(chain, ebrEntry{Position: position, EBR: ebr})
+		position = ebr.Part_next
+	}
+	return chain, nil
+}
+
+// logicalFreeSpaces calcula los huecos dentro de la extendida. Cada lógica
+// ocupa su EBR más sus datos; un EBR cabeza vacío se puede reutilizar.
+func logicalFreeSpaces(chain []ebrEntry, extendedPartition Structs.Partition) []freeSpace {
+	var spaces []freeSpace
+	cursor := extendedPartition.Start
+	for _, entry := range chain {
+		if entry.EBR.Part_size <= 0 {
+			continue
+		}
+		if entry.Position > cursor {
+			spaces = append(spaces, freeSpace{Start: cursor, Size: entry.Position - cursor})
+		}
+		if end := entry.EBR.Part_start + entry.EBR.Part_size; end > cursor {
+			cursor = end
+		}
+	}
+	if end := extendedPartition.Start + extendedPartition.Size; end > cursor {
+		spaces = append(spaces, freeSpace{Start: cursor, Size: end - cursor})
+	}
+	return spaces
+}
@@ -0,0 +1,141 @@
+package DiskManagement
+
+import (
+	"path/filepath"
+	"proyecto1/Structs"
+	"sort"
+	"sync"
+)
+
+// ============================================================================
+// REGISTRO DE MONTAJES Y LOCKS POR DISCO
+// ============================================================================
+
+// registryMutex protege drivePathMap, mountedPartitions, diskMountCounters y
+// diskOrderList. Las funciones públicas lo toman; las auxiliares internas
+// asumen que quien las llama ya lo tiene.
+var registryMutex sync.RWMutex
+
+// Locks de lectura/escritura por archivo de disco. Los comandos que modifican
+// un disco toman el lock de escritura y las consultas el de lectura, de modo
+// que varias lecturas pueden avanzar en paralelo.
+var (
+	diskLocks      = make(map[string]*sync.RWMutex)
+	diskLocksMutex sync.Mutex
+)
+
+// GetMountedPartition - Obtener una partición montada por su ID
+func GetMountedPartition(id string) (Structs.MountedPartition, bool) {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	partition, exists := mountedPartitions[id]
+	return partition, exists
+}
+
+// GetMountedPartitions - Obtener una copia de todas las particiones montadas
+func GetMountedPartitions() map[string]Structs.MountedPartition {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	partitions := make(map[string]Structs.MountedPartition, len(mountedPartitions))
+	for id, partition := range mountedPartitions {
+		partitions[id] = partition
+	}
+	return partitions
+}
+
+// GetMountedIDs - Obtener los IDs de las particiones montadas ordenados
+func GetMountedIDs() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	ids := make([]string, 0, len(mountedPartitions))
+	for id := range mountedPartitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GetKnownDisks - Obtener las rutas de todos los discos conocidos (con
+// particiones montadas, registrados como drive o en la lista de orden), sin
+// duplicados y respetando el orden cronológico de montaje
+func GetKnownDisks() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	seen := make(map[string]bool)
+	var disks []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			disks = append(disks, path)
+		}
+	}
+
+	for _, path := range diskOrderList {
+		add(path)
+	}
+	for _, partition := range mountedPartitions {
+		add(partition.Path)
+	}
+	for _, path := range drivePathMap {
+		add(path)
+	}
+	return disks
+}
+
+// diskKey - Normalizar la ruta de un disco para compararla (ruta absoluta y limpia)
+func diskKey(path string) string {
+	key := filepath.Clean(path)
+	if abs, err := filepath.Abs(key); err == nil {
+		key = abs
+	}
+	return key
+}
+
+// diskLock - Obtener (o crear) el lock asociado a un archivo de disco
+func diskLock(path string) *sync.RWMutex {
+	key := diskKey(path)
+
+	diskLocksMutex.Lock()
+	defer diskLocksMutex.Unlock()
+	lock, exists := diskLocks[key]
+	if !exists {
+		lock = &sync.RWMutex{}
+		diskLocks[key] = lock
+	}
+	return lock
+}
+
+// LockDisk - Tomar el lock de escritura de un disco; retorna la función que lo libera
+func LockDisk(path string) func() {
+	lock := diskLock(path)
+	lock.Lock()
+	return lock.Unlock
+}
+
+// RLockDisk - Tomar el lock de lectura de un disco; retorna la función que lo libera
+func RLockDisk(path string) func() {
+	lock := diskLock(path)
+	lock.RLock()
+	return lock.RUnlock
+}
+
+// RLockPartition - Tomar el lock de lectura del disco de una partición montada.
+// Si la partición no está montada no bloquea nada.
+func RLockPartition(id string) func() {
+	partition, exists := GetMountedPartition(id)
+	if !exists {
+		return func() {}
+	}
+	return RLockDisk(partition.Path)
+}
+
+// LockPartition - Tomar el lock de escritura del disco de una partición montada.
+// Si la partición no está montada no bloquea nada.
+func LockPartition(id string) func() {
+	partition, exists := GetMountedPartition(id)
+	if !exists {
+		return func() {}
+	}
+	return LockDisk(partition.Path)
+}
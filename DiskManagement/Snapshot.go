@@ -0,0 +1,736 @@
+package DiskManagement
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// SNAPSHOTS Y ROLLBACK DE DISCOS Y PARTICIONES
+// ============================================================================
+//
+// Un snapshot no copia el disco: guarda, la primera vez que se sobrescribe
+// cada byte de su rango, el contenido que tenía antes (copy-on-write). Las
+// escrituras pasan por Utilities.WriteObject, que avisa a recordWrite antes de
+// escribir. Cada byte modificado se guarda solo en el snapshot más reciente
+// que lo cubre, así que para volver a un snapshot se aplican los deltas desde
+// el más reciente hasta él.
+//
+// Los snapshots de un disco se guardan junto a él en <disco>.snapshots/: un
+// index.json con la lista (del más antiguo al más reciente) y un archivo
+// <n>.delta por snapshot con registros (offset int64, largo int64, bytes).
+
+// Sufijo del directorio donde se guardan los snapshots de un disco
+const snapshotDirSuffix = ".snapshots"
+
+// SnapshotInfo describe un snapshot para los comandos y la API
+type SnapshotInfo struct {
+	Name          string `json:"name"`
+	Scope         string `json:"scope"` // "disk" o "partition"
+	PartitionName string `json:"partition_name,omitempty"`
+	Start         int64  `json:"start"`
+	End           int64  `json:"end"`
+	Created       string `json:"created"`
+	Ranges        int    `json:"ranges"`      // rangos de bytes guardados
+	DeltaBytes    int64  `json:"delta_bytes"` // bytes guardados en el delta
+}
+
+// snapshotMeta es la entrada de un snapshot en index.json
+type snapshotMeta struct {
+	Name          string `json:"name"`
+	Scope         string `json:"scope"`
+	PartitionName string `json:"partition_name,omitempty"`
+	Start         int64  `json:"start"`
+	End           int64  `json:"end"`
+	Created       string `json:"created"`
+	Seq           int    `json:"seq"`
+}
+
+// snapshotIndex es el contenido de index.json
+type snapshotIndex struct {
+	NextSeq   int            `json:"next_seq"`
+	Snapshots []snapshotMeta `json:"snapshots"`
+}
+
+// byteRange es el rango de bytes [Start, End) del disco
+type byteRange struct {
+	Start int64
+	End   int64
+}
+
+// rangeSet es una lista de rangos ordenados y sin solaparse
+type rangeSet []byteRange
+
+// missing retorna las partes de [start, end) que no cubre el conjunto
+func (s rangeSet) missing(start int64, end int64) []byteRange {
+	var parts []byteRange
+	i := sort.Search(len(s), func(i int) bool { return s[i].End > start })
+	for ; i < len(s) && s[i].Start < end && start < end; i++ {
+		if s[i].Start > start {
+			parts = append(parts, byteRange{start, s[i].Start})
+		}
+		if s[i].End > start {
+			start = s[i].End
+		}
+	}
+	if start < end {
+		parts = append(parts, byteRange{start, end})
+	}
+	return parts
+}
+
+// add agrega [start, end) al conjunto uniendo los rangos que se tocan
+func (s *rangeSet) add(start int64, end int64) {
+	if start >= end {
+		return
+	}
+	ranges := *s
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].End >= start })
+	j := i
+	for j < len(ranges) && ranges[j].Start <= end {
+		if ranges[j].Start < start {
+			start = ranges[j].Start
+		}
+		if ranges[j].End > end {
+			end = ranges[j].End
+		}
+		j++
+	}
+	merged := append(rangeSet{}, ranges[:i]...)
+	merged = append(merged, byteRange{start, end})
+	*s = append(merged, ranges[j:]...)
+}
+
+// bytes retorna la cantidad de bytes cubiertos
+func (s rangeSet) bytes() int64 {
+	var total int64
+	for _, r := range s {
+		total += r.End - r.Start
+	}
+	return total
+}
+
+// clip retorna la intersección de [start, end) con r y si no está vacía
+func clip(start int64, end int64, r byteRange) (int64, int64, bool) {
+	if r.Start > start {
+		start = r.Start
+	}
+	if r.End < end {
+		end = r.End
+	}
+	return start, end, start < end
+}
+
+// snapshotState es un snapshot cargado: su entrada del índice, los rangos que
+// ya tienen guardado su contenido anterior y el delta abierto para agregar
+type snapshotState struct {
+	meta  snapshotMeta
+	saved rangeSet
+	delta *os.File
+}
+
+func (s *snapshotState) span() byteRange {
+	return byteRange{s.meta.Start, s.meta.End}
+}
+
+func (s *snapshotState) info() SnapshotInfo {
+	return SnapshotInfo{
+		Name:          s.meta.Name,
+		Scope:         s.meta.Scope,
+		PartitionName: s.meta.PartitionName,
+		Start:         s.meta.Start,
+		End:           s.meta.End,
+		Created:       s.meta.Created,
+		Ranges:        len(s.saved),
+		DeltaBytes:    s.saved.bytes(),
+	}
+}
+
+// diskSnapshots son los snapshots de un disco, del más antiguo al más reciente
+type diskSnapshots struct {
+	dir       string
+	nextSeq   int
+	snapshots []*snapshotState
+}
+
+// deltaRecord es un registro de un delta: el contenido anterior de un rango
+type deltaRecord struct {
+	Offset int64
+	Data   []byte
+}
+
+// snapshotCacheEntry guarda los snapshots cargados de un disco junto con el
+// lock que los protege a ellos y a sus deltas
+type snapshotCacheEntry struct {
+	sync.Mutex
+	disk *diskSnapshots
+}
+
+// snapshotCache tiene una entrada por disco, con la clave de diskKey: las
+// escrituras de un disco no esperan a las de otros. snapshotCacheMutex solo
+// protege el mapa. Los comandos además tienen el lock del disco, pero
+// recordWrite se llama desde cualquier escritura.
+var (
+	snapshotCacheMutex sync.Mutex
+	snapshotCache      = make(map[string]*snapshotCacheEntry)
+)
+
+func init() {
+	Utilities.SetWriteHook(recordWrite)
+}
+
+// snapshotEntry - Obtener (o crear) la entrada de un disco en snapshotCache
+func snapshotEntry(path string) *snapshotCacheEntry {
+	key := diskKey(path)
+
+	snapshotCacheMutex.Lock()
+	defer snapshotCacheMutex.Unlock()
+	entry, exists := snapshotCache[key]
+	if !exists {
+		entry = &snapshotCacheEntry{}
+		snapshotCache[key] = entry
+	}
+	return entry
+}
+
+// lockDiskSnapshots - Tomar el lock de los snapshots de un disco y obtenerlos,
+// leyéndolos del directorio la primera vez. Retorna la función que libera el lock.
+func lockDiskSnapshots(path string) (*diskSnapshots, func(), error) {
+	entry := snapshotEntry(path)
+	entry.Lock()
+	if entry.disk == nil {
+		disk, err := readDiskSnapshots(path)
+		if err != nil {
+			entry.Unlock()
+			return nil, nil, err
+		}
+		entry.disk = disk
+	}
+	return entry.disk, entry.Unlock, nil
+}
+
+// readDiskSnapshots - Leer del directorio del disco la lista de snapshots y
+// los rangos guardados en cada delta
+func readDiskSnapshots(path string) (*diskSnapshots, error) {
+	disk := &diskSnapshots{dir: diskKey(path) + snapshotDirSuffix, nextSeq: 1}
+	data, err := os.ReadFile(filepath.Join(disk.dir, "index.json"))
+	if os.IsNotExist(err) {
+		return disk, nil
+	}
+	if err != nil {
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo los snapshots de %s: %v", path, err)
+	}
+
+	var index snapshotIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Índice de snapshots de %s inválido: %v", path, err)
+	}
+	disk.nextSeq = index.NextSeq
+	for _, meta := range index.Snapshots {
+		snap := &snapshotState{meta: meta}
+		records, err := readDeltaRecords(disk.deltaPath(snap))
+		if err != nil {
+			return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el delta del snapshot '%s': %v", meta.Name, err)
+		}
+		for _, record := range records {
+			snap.saved.add(record.Offset, record.Offset+int64(len(record.Data)))
+		}
+		disk.snapshots = append(disk.snapshots, snap)
+	}
+	return disk, nil
+}
+
+func (d *diskSnapshots) deltaPath(snap *snapshotState) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%d.delta", snap.meta.Seq))
+}
+
+// find - Obtener la posición de un snapshot por nombre (-1 si no existe)
+func (d *diskSnapshots) find(name string) int {
+	for i, snap := range d.snapshots {
+		if snap.meta.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// save - Escribir index.json con la lista actual de snapshots
+func (d *diskSnapshots) save() error {
+	index := snapshotIndex{NextSeq: d.nextSeq, Snapshots: make([]snapshotMeta, 0, len(d.snapshots))}
+	for _, snap := range d.snapshots {
+		index.Snapshots = append(index.Snapshots, snap.meta)
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(d.dir, os.ModePerm); err != nil {
+		return err
+	}
+	tmpPath := filepath.Join(d.dir, "index.json.tmp")
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(d.dir, "index.json"))
+}
+
+// appendRecord - Agregar al delta del snapshot el contenido anterior de un rango
+func (d *diskSnapshots) appendRecord(snap *snapshotState, offset int64, data []byte) error {
+	if snap.delta == nil {
+		delta, err := os.OpenFile(d.deltaPath(snap), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		snap.delta = delta
+	}
+	header := [2]int64{offset, int64(len(data))}
+	if err := binary.Write(snap.delta, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := snap.delta.Write(data); err != nil {
+		return err
+	}
+	snap.saved.add(offset, offset+int64(len(data)))
+	return nil
+}
+
+// closeDelta - Cerrar el delta abierto de un snapshot
+func (snap *snapshotState) closeDelta() {
+	if snap.delta != nil {
+		snap.delta.Close()
+		snap.delta = nil
+	}
+}
+
+// readDeltaRecords - Leer todos los registros del delta (vacío si no existe)
+func readDeltaRecords(path string) ([]deltaRecord, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []deltaRecord
+	for {
+		var header [2]int64
+		if err := binary.Read(file, binary.LittleEndian, &header); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if header[1] < 0 {
+			return nil, fmt.Errorf("registro con largo inválido en el offset %d", header[0])
+		}
+		data := make([]byte, header[1])
+		if _, err := io.ReadFull(file, data); err != nil {
+			return nil, err
+		}
+		records = append(records, deltaRecord{Offset: header[0], Data: data})
+	}
+}
+
+// assign - Guardar el contenido anterior de [start, end) en los snapshots
+// desde la posición from hacia los más antiguos: cada byte va al primero que
+// lo cubre, y solo si ese snapshot no lo tenía guardado. read obtiene los
+// bytes de un rango.
+func (d *diskSnapshots) assign(from int, start int64, end int64, read func(byteRange) ([]byte, error)) error {
+	pending := []byteRange{{start, end}}
+	for i := from; i >= 0 && len(pending) > 0; i-- {
+		snap := d.snapshots[i]
+		var rest []byteRange
+		for _, r := range pending {
+			lo, hi, ok := clip(r.Start, r.End, snap.span())
+			if !ok {
+				rest = append(rest, r)
+				continue
+			}
+			if r.Start < lo {
+				rest = append(rest, byteRange{r.Start, lo})
+			}
+			if hi < r.End {
+				rest = append(rest, byteRange{hi, r.End})
+			}
+			for _, part := range snap.saved.missing(lo, hi) {
+				data, err := read(part)
+				if err != nil {
+					return err
+				}
+				if err := d.appendRecord(snap, part.Start, data); err != nil {
+					return err
+				}
+			}
+		}
+		pending = rest
+	}
+	return nil
+}
+
+// recordWrite - WriteHook de Utilities: antes de sobrescribir un rango de un
+// disco con snapshots guarda su contenido actual en el snapshot que corresponda
+func recordWrite(file *os.File, position int64, length int64) error {
+	if length <= 0 {
+		return nil
+	}
+	disk, unlock, err := lockDiskSnapshots(file.Name())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if len(disk.snapshots) == 0 {
+		return nil
+	}
+
+	// Los bytes más allá del final del archivo no existían: se guardan como ceros
+	read := func(r byteRange) ([]byte, error) {
+		data := make([]byte, r.End-r.Start)
+		if _, err := file.ReadAt(data, r.Start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return data, nil
+	}
+	if err := disk.assign(len(disk.snapshots)-1, position, position+length, read); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error guardando el snapshot del disco: %v", err)
+	}
+	return nil
+}
+
+// discard - Quitar el snapshot de la posición i. Su contenido guardado fuera
+// de reverted pasa a los snapshots anteriores, que lo necesitan para volver
+// atrás; lo que está dentro de reverted ya se aplicó al disco y se descarta.
+func (d *diskSnapshots) discard(i int, reverted byteRange) error {
+	snap := d.snapshots[i]
+	records, err := readDeltaRecords(d.deltaPath(snap))
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		start, end := record.Offset, record.Offset+int64(len(record.Data))
+		read := func(r byteRange) ([]byte, error) {
+			return record.Data[r.Start-start : r.End-start], nil
+		}
+		parts := []byteRange{{start, end}}
+		if lo, hi, ok := clip(start, end, reverted); ok {
+			parts = []byteRange{{start, lo}, {hi, end}}
+		}
+		for _, part := range parts {
+			if part.Start < part.End {
+				if err := d.assign(i-1, part.Start, part.End, read); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	snap.closeDelta()
+	if err := os.Remove(d.deltaPath(snap)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	d.snapshots = append(d.snapshots[:i], d.snapshots[i+1:]...)
+	return d.save()
+}
+
+// applyDelta - Escribir en el disco el contenido guardado del snapshot que cae
+// dentro de target. Escribe directo en el archivo para no pasar por recordWrite.
+func (d *diskSnapshots) applyDelta(file *os.File, snap *snapshotState, target byteRange) error {
+	records, err := readDeltaRecords(d.deltaPath(snap))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		start, end := record.Offset, record.Offset+int64(len(record.Data))
+		lo, hi, ok := clip(start, end, target)
+		if !ok {
+			continue
+		}
+		if _, err := file.WriteAt(record.Data[lo-start:hi-start], lo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotTarget - Ubicar el disco y, si se indicó -id, el rango de la
+// partición montada sobre el que trabaja el snapshot
+func snapshotTarget(out io.Writer, path string, id string) (string, *Structs.MountedPartition, byteRange, error) {
+	if id != "" {
+		partition, exists := GetMountedPartition(id)
+		if !exists {
+			fmt.Fprintf(out, "Error: No existe una partición montada con ID '%s'\n", id)
+			return "", nil, byteRange{}, Utilities.NewCommandError(Utilities.ErrNotFound, "No existe una partición montada con ID '%s'", id)
+		}
+		if path != "" && diskKey(path) != diskKey(partition.Path) {
+			fmt.Fprintf(out, "Error: La partición '%s' no pertenece al disco %s\n", id, path)
+			return "", nil, byteRange{}, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La partición '%s' no pertenece al disco %s", id, path)
+		}
+		span, err := partitionSpan(partition)
+		if err != nil {
+			return "", nil, byteRange{}, err
+		}
+		return partition.Path, &partition, span, nil
+	}
+
+	if path == "" {
+		fmt.Fprintln(out, "Error: Se requiere -path (disco) o -id (partición montada)")
+		return "", nil, byteRange{}, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Se requiere -path (disco) o -id (partición montada)")
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(out, "Error: El archivo %s no existe\n", path)
+		return "", nil, byteRange{}, Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo %s no existe", path)
+	}
+	if err != nil {
+		return "", nil, byteRange{}, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el disco: %v", err)
+	}
+	return path, nil, byteRange{0, info.Size()}, nil
+}
+
+// partitionSpan - Rango de bytes de una partición montada. El de una lógica
+// incluye su EBR.
+func partitionSpan(partition Structs.MountedPartition) (byteRange, error) {
+	file, err := Utilities.OpenFile(partition.Path)
+	if err != nil {
+		return byteRange{}, Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo el disco: %v", err)
+	}
+	defer file.Close()
+
+	if partition.IsLogical {
+		var ebr Structs.EBR
+		if err := Utilities.ReadObject(file, &ebr, int64(partition.EBRPosition)); err != nil {
+			return byteRange{}, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el EBR: %v", err)
+		}
+		return byteRange{int64(partition.EBRPosition), int64(ebr.Part_start) + int64(ebr.Part_size)}, nil
+	}
+
+	var mbr Structs.MBR
+	if err := Utilities.ReadObject(file, &mbr, 0); err != nil {
+		return byteRange{}, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el MBR: %v", err)
+	}
+	part := mbr.Partitions[partition.PartitionIndex]
+	return byteRange{int64(part.Start), int64(part.Start) + int64(part.Size)}, nil
+}
+
+// CreateSnapshot - Crear un snapshot del disco (-path) o de una partición
+// montada (-id). Desde este momento se guarda el contenido anterior de cada
+// byte del rango que se modifique.
+func CreateSnapshot(out io.Writer, path string, id string, name string) (SnapshotInfo, error) {
+	fmt.Fprintln(out, "======INICIO MKSNAPSHOT======")
+	fmt.Fprintln(out, "Path:", path)
+	fmt.Fprintln(out, "Id:", id)
+	fmt.Fprintln(out, "Nombre:", name)
+
+	if name == "" {
+		fmt.Fprintln(out, "Error: El parámetro -name es requerido")
+		return SnapshotInfo{}, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -name es requerido")
+	}
+	diskPath, partition, span, err := snapshotTarget(out, path, id)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+
+	disk, unlock, err := lockDiskSnapshots(diskPath)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+	defer unlock()
+	if disk.find(name) >= 0 {
+		fmt.Fprintf(out, "Error: Ya existe un snapshot '%s' en el disco %s\n", name, diskPath)
+		return SnapshotInfo{}, Utilities.NewCommandError(Utilities.ErrAlreadyExists, "Ya existe un snapshot '%s' en el disco %s", name, diskPath)
+	}
+
+	meta := snapshotMeta{
+		Name:    name,
+		Scope:   "disk",
+		Start:   span.Start,
+		End:     span.End,
+		Created: time.Now().Format("2006-01-02 15:04:05"),
+		Seq:     disk.nextSeq,
+	}
+	if partition != nil {
+		meta.Scope = "partition"
+		meta.PartitionName = partition.PartitionName
+	}
+	snap := &snapshotState{meta: meta}
+	disk.nextSeq++
+	disk.snapshots = append(disk.snapshots, snap)
+	if err := disk.save(); err != nil {
+		disk.snapshots = disk.snapshots[:len(disk.snapshots)-1]
+		return SnapshotInfo{}, Utilities.NewCommandError(Utilities.ErrIO, "Error guardando el snapshot: %v", err)
+	}
+
+	fmt.Fprintf(out, "Snapshot '%s' creado (%s, bytes %d a %d)\n", name, meta.Scope, meta.Start, meta.End)
+	fmt.Fprintln(out, "======FIN MKSNAPSHOT======")
+	return snap.info(), nil
+}
+
+// ListSnapshots - Listar los snapshots del disco, o solo los de la partición
+// si se indicó -id, del más antiguo al más reciente
+func ListSnapshots(out io.Writer, path string, id string) ([]SnapshotInfo, error) {
+	fmt.Fprintln(out, "======INICIO SNAPSHOTS======")
+	diskPath, partition, _, err := snapshotTarget(out, path, id)
+	if err != nil {
+		return nil, err
+	}
+
+	disk, unlock, err := lockDiskSnapshots(diskPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	snapshots := make([]SnapshotInfo, 0, len(disk.snapshots))
+	for _, snap := range disk.snapshots {
+		if partition != nil && snap.meta.PartitionName != partition.PartitionName {
+			continue
+		}
+		info := snap.info()
+		snapshots = append(snapshots, info)
+		scope := "disco completo"
+		if info.Scope == "partition" {
+			scope = "partición " + info.PartitionName
+		}
+		fmt.Fprintf(out, "%-16s %-24s %s  %d bytes en %d rangos\n", info.Name, scope, info.Created, info.DeltaBytes, info.Ranges)
+	}
+	if len(snapshots) == 0 {
+		fmt.Fprintln(out, "No hay snapshots")
+	}
+	fmt.Fprintln(out, "======FIN SNAPSHOTS======")
+	return snapshots, nil
+}
+
+// RollbackSnapshot - Volver el rango del snapshot al contenido que tenía al
+// crearlo. Los snapshots posteriores que se superponen con ese rango dejan de
+// ser válidos y se eliminan; el snapshot restaurado se conserva vacío.
+func RollbackSnapshot(out io.Writer, path string, id string, name string) (SnapshotInfo, []string, error) {
+	fmt.Fprintln(out, "======INICIO ROLLBACK======")
+	fmt.Fprintln(out, "Nombre:", name)
+
+	if name == "" {
+		fmt.Fprintln(out, "Error: El parámetro -name es requerido")
+		return SnapshotInfo{}, nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -name es requerido")
+	}
+	diskPath, _, _, err := snapshotTarget(out, path, id)
+	if err != nil {
+		return SnapshotInfo{}, nil, err
+	}
+
+	disk, unlock, err := lockDiskSnapshots(diskPath)
+	if err != nil {
+		return SnapshotInfo{}, nil, err
+	}
+	defer unlock()
+	target := disk.find(name)
+	if target < 0 {
+		fmt.Fprintf(out, "Error: No existe el snapshot '%s' en el disco %s\n", name, diskPath)
+		return SnapshotInfo{}, nil, Utilities.NewCommandError(Utilities.ErrNotFound, "No existe el snapshot '%s' en el disco %s", name, diskPath)
+	}
+
+	file, err := Utilities.OpenFile(diskPath)
+	if err != nil {
+		return SnapshotInfo{}, nil, Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo el disco: %v", err)
+	}
+	defer file.Close()
+
+	// Deshacer primero los cambios guardados en los snapshots posteriores
+	span := disk.snapshots[target].span()
+	var discarded []string
+	for i := len(disk.snapshots) - 1; i > target; i-- {
+		snap := disk.snapshots[i]
+		if _, _, overlaps := clip(span.Start, span.End, snap.span()); !overlaps {
+			continue
+		}
+		if err := disk.applyDelta(file, snap, span); err != nil {
+			return SnapshotInfo{}, nil, Utilities.NewCommandError(Utilities.ErrIO, "Error aplicando el snapshot '%s': %v", snap.meta.Name, err)
+		}
+		if err := disk.discard(i, span); err != nil {
+			return SnapshotInfo{}, nil, Utilities.NewCommandError(Utilities.ErrIO, "Error descartando el snapshot '%s': %v", snap.meta.Name, err)
+		}
+		discarded = append(discarded, snap.meta.Name)
+		fmt.Fprintf(out, "Snapshot posterior '%s' descartado\n", snap.meta.Name)
+	}
+
+	snap := disk.snapshots[target]
+	if err := disk.applyDelta(file, snap, span); err != nil {
+		return SnapshotInfo{}, nil, Utilities.NewCommandError(Utilities.ErrIO, "Error aplicando el snapshot '%s': %v", name, err)
+	}
+	if err := file.Sync(); err != nil {
+		return SnapshotInfo{}, nil, Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo el disco: %v", err)
+	}
+
+	// El rango ya está como al crear el snapshot: su delta vuelve a empezar
+	restored := snap.info()
+	snap.closeDelta()
+	if err := os.Remove(disk.deltaPath(snap)); err != nil && !os.IsNotExist(err) {
+		return SnapshotInfo{}, nil, Utilities.NewCommandError(Utilities.ErrIO, "Error reiniciando el delta: %v", err)
+	}
+	snap.saved = nil
+
+	fmt.Fprintf(out, "Se restauraron %d bytes en %d rangos\n", restored.DeltaBytes, restored.Ranges)
+	if snap.meta.Scope == "disk" {
+		fmt.Fprintln(out, "Advertencia: se restauró la tabla de particiones. Use 'registry' para revisar los montajes.")
+	}
+	fmt.Fprintln(out, "======FIN ROLLBACK======")
+	return restored, discarded, nil
+}
+
+// DeleteSnapshot - Eliminar un snapshot sin cambiar el disco. Lo que guardaba
+// pasa al snapshot anterior que cubre cada rango, para no perder el rollback
+// de los más antiguos.
+func DeleteSnapshot(out io.Writer, path string, id string, name string) error {
+	fmt.Fprintln(out, "======INICIO RMSNAPSHOT======")
+	fmt.Fprintln(out, "Nombre:", name)
+
+	if name == "" {
+		fmt.Fprintln(out, "Error: El parámetro -name es requerido")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -name es requerido")
+	}
+	diskPath, _, _, err := snapshotTarget(out, path, id)
+	if err != nil {
+		return err
+	}
+
+	disk, unlock, err := lockDiskSnapshots(diskPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	i := disk.find(name)
+	if i < 0 {
+		fmt.Fprintf(out, "Error: No existe el snapshot '%s' en el disco %s\n", name, diskPath)
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "No existe el snapshot '%s' en el disco %s", name, diskPath)
+	}
+	if err := disk.discard(i, byteRange{}); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error eliminando el snapshot '%s': %v", name, err)
+	}
+
+	fmt.Fprintf(out, "Snapshot '%s' eliminado\n", name)
+	fmt.Fprintln(out, "======FIN RMSNAPSHOT======")
+	return nil
+}
+
+// removeDiskSnapshots - Borrar los snapshots de un disco eliminado
+func removeDiskSnapshots(path string) {
+	entry := snapshotEntry(path)
+	entry.Lock()
+	defer entry.Unlock()
+
+	if entry.disk != nil {
+		for _, snap := range entry.disk.snapshots {
+			snap.closeDelta()
+		}
+		entry.disk = nil
+	}
+	os.RemoveAll(diskKey(path) + snapshotDirSuffix)
+}
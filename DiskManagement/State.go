@@ -0,0 +1,376 @@
+package DiskManagement
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// PERSISTENCIA DEL REGISTRO DE DISCOS Y MONTAJES
+// ============================================================================
+
+// Archivo donde se guarda el registro entre reinicios del servidor
+var stateFilePath = "mia_state.json"
+
+// registryState es el contenido del archivo de estado
+type registryState struct {
+	Drives    map[string]string               `json:"drives"`
+	Mounted   []Structs.MountedPartition      `json:"mounted"`
+	DiskOrder []string                        `json:"disk_order"`
+	Counters  map[string]Structs.DiskCounters `json:"counters,omitempty"`
+}
+
+// RegistryEntry describe una entrada del registro y su estado frente al disco
+type RegistryEntry struct {
+	Kind          string `json:"kind"` // "mount" o "drive"
+	Id            string `json:"id,omitempty"`
+	Drive         string `json:"drive,omitempty"`
+	Path          string `json:"path"`
+	PartitionName string `json:"partition_name,omitempty"`
+	Status        string `json:"status"` // ok, stale, repaired, removed
+	Reason        string `json:"reason,omitempty"`
+}
+
+// SetStateFile - Cambiar la ruta del archivo de estado
+func SetStateFile(path string) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	stateFilePath = path
+}
+
+// LoadState - Reconstruir el registro (drives, montajes y orden de discos)
+// desde el archivo de estado. Si el archivo no existe el registro queda vacío.
+func LoadState(out io.Writer) error {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	data, err := os.ReadFile(stateFilePath)
+	if os.IsNotExist(err) {
+		resetRegistry()
+		return nil
+	}
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el archivo de estado: %v", err)
+	}
+
+	var state registryState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Archivo de estado '%s' inválido: %v", stateFilePath, err)
+	}
+
+	drivePathMap = make(map[string]string)
+	for drive, path := range state.Drives {
+		drivePathMap[drive] = path
+	}
+	mountedPartitions = make(map[string]Structs.MountedPartition)
+	for _, partition := range state.Mounted {
+		mountedPartitions[partition.Id] = partition
+	}
+	diskOrderList = append([]string(nil), state.DiskOrder...)
+	diskMountCounters = make(map[string]Structs.DiskCounters)
+	for path, counters := range state.Counters {
+		diskMountCounters[path] = counters
+	}
+	if state.Counters == nil {
+		rebuildDiskCounters()
+	}
+
+	// Avisar de las entradas que ya no coinciden con los discos
+	stale := 0
+	for _, partition := range mountedPartitions {
+		if reason, _ := checkMountedPartition(partition); reason != "" {
+			stale++
+		}
+	}
+
+	fmt.Fprintf(out, "Estado restaurado desde %s: %d drives, %d particiones montadas\n",
+		stateFilePath, len(drivePathMap), len(mountedPartitions))
+	if stale > 0 {
+		fmt.Fprintf(out, "Advertencia: %d montajes no coinciden con los discos. Use 'registry -fix' para reconciliarlos.\n", stale)
+	}
+	return nil
+}
+
+// resetRegistry - Dejar el registro sin drives ni montajes.
+// Asume que quien la llama tiene registryMutex.
+func resetRegistry() {
+	drivePathMap = make(map[string]string)
+	mountedPartitions = make(map[string]Structs.MountedPartition)
+	diskOrderList = nil
+	diskMountCounters = make(map[string]Structs.DiskCounters)
+}
+
+// saveState - Guardar el registro en el archivo de estado.
+// Asume que quien la llama tiene registryMutex.
+func saveState(out io.Writer) {
+	state := registryState{
+		Drives:    drivePathMap,
+		Mounted:   make([]Structs.MountedPartition, 0, len(mountedPartitions)),
+		DiskOrder: diskOrderList,
+		Counters:  diskMountCounters,
+	}
+	for _, partition := range mountedPartitions {
+		state.Mounted = append(state.Mounted, partition)
+	}
+	sort.Slice(state.Mounted, func(i, j int) bool { return state.Mounted[i].Id < state.Mounted[j].Id })
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		fmt.Fprintln(out, "Advertencia: no se pudo serializar el estado:", err)
+		return
+	}
+
+	// Escribir en un temporal y renombrar para no dejar el archivo a medias
+	if dir := filepath.Dir(stateFilePath); dir != "." && dir != "" {
+		os.MkdirAll(dir, os.ModePerm)
+	}
+	tmpPath := stateFilePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		fmt.Fprintln(out, "Advertencia: no se pudo guardar el estado:", err)
+		return
+	}
+	if err := os.Rename(tmpPath, stateFilePath); err != nil {
+		fmt.Fprintln(out, "Advertencia: no se pudo guardar el estado:", err)
+	}
+}
+
+// rebuildDiskCounters - Reconstruir la letra y el último correlativo de cada
+// disco a partir de los montajes (estados guardados antes de los contadores).
+// Asume que quien la llama tiene registryMutex.
+func rebuildDiskCounters() {
+	for id, partition := range mountedPartitions {
+		if len(id) < 2 {
+			continue
+		}
+		if partition.Correlative == 0 {
+			number, err := strconv.Atoi(strings.TrimPrefix(id[:len(id)-1], idPrefix))
+			if err != nil {
+				continue
+			}
+			partition.Correlative = int32(number)
+			mountedPartitions[id] = partition
+		}
+
+		key := diskKey(partition.Path)
+		counters := diskMountCounters[key]
+		counters.Letter = id[len(id)-1]
+		if int(partition.Correlative) > counters.PartitionNumber {
+			counters.PartitionNumber = int(partition.Correlative)
+		}
+		diskMountCounters[key] = counters
+	}
+}
+
+// checkMountedPartition - Verificar que un montaje siga existiendo en su disco.
+// Retorna la razón si está desactualizado y si se puede reparar reescribiendo
+// el estado de montaje en el MBR/EBR.
+func checkMountedPartition(partition Structs.MountedPartition) (string, bool) {
+	if _, err := os.Stat(partition.Path); os.IsNotExist(err) {
+		return "el disco no existe", false
+	}
+
+	file, err := Utilities.OpenFile(partition.Path)
+	if err != nil {
+		return fmt.Sprintf("no se pudo abrir el disco: %v", err), false
+	}
+	defer file.Close()
+
+	if partition.IsLogical {
+		var ebr Structs.EBR
+		if err := Utilities.ReadObject(file, &ebr, int64(partition.EBRPosition)); err != nil {
+			return "no se pudo leer el EBR", false
+		}
+		ebrName := strings.TrimSpace(strings.Trim(string(ebr.Part_name[:]), "\x00"))
+		if ebr.Part_size <= 0 || ebrName != partition.PartitionName {
+			return "la partición lógica ya no existe", false
+		}
+		if ebr.Part_status[0] != '1' {
+			return "el EBR no la marca como montada", true
+		}
+		return "", false
+	}
+
+	var mbr Structs.MBR
+	if err := Utilities.ReadObject(file, &mbr, 0); err != nil {
+		return "no se pudo leer el MBR", false
+	}
+	if partition.PartitionIndex < 0 || partition.PartitionIndex > 3 {
+		return "índice de partición inválido", false
+	}
+	part := mbr.Partitions[partition.PartitionIndex]
+	partName := strings.TrimSpace(strings.Trim(string(part.Name[:]), "\x00"))
+	if part.Size <= 0 || partName != partition.PartitionName {
+		return "la partición ya no existe", false
+	}
+	partID := strings.Trim(string(part.Id[:]), "\x00")
+	if part.Status[0] != '1' || partID != partition.Id {
+		return "el MBR no la marca como montada con ese ID", true
+	}
+	return "", false
+}
+
+// repairMountedPartition - Volver a escribir el estado de montaje en el disco
+func repairMountedPartition(partition Structs.MountedPartition) error {
+	file, err := Utilities.OpenFile(partition.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if partition.IsLogical {
+		return updateLogicalPartitionStatus(file, partition.EBRPosition, partition.Id, true)
+	}
+	var mbr Structs.MBR
+	if err := Utilities.ReadObject(file, &mbr, 0); err != nil {
+		return err
+	}
+	return updatePrimaryPartitionStatus(file, &mbr, partition.PartitionIndex, partition.Id, partition.Correlative, true)
+}
+
+// removeDiskFromOrderIfUnused - Quitar un disco de la lista ordenada si ya no
+// tiene particiones montadas. Asume que quien la llama tiene registryMutex.
+func removeDiskFromOrderIfUnused(path string) {
+	key := diskKey(path)
+	for _, mounted := range mountedPartitions {
+		if diskKey(mounted.Path) == key {
+			return
+		}
+	}
+	for i, disk := range diskOrderList {
+		if diskKey(disk) == key {
+			// Remover disco de la lista manteniendo el orden
+			diskOrderList = append(diskOrderList[:i], diskOrderList[i+1:]...)
+			return
+		}
+	}
+}
+
+// Registry - Listar las entradas del registro comparándolas con los discos.
+// Con fix=true elimina los montajes y drives desactualizados y repara el
+// estado de montaje en disco cuando la partición sigue existiendo.
+func Registry(out io.Writer, fix bool) ([]RegistryEntry, error) {
+	fmt.Fprintln(out, "======INICIO REGISTRY======")
+	fmt.Fprintln(out, "Archivo de estado:", stateFilePath)
+	fmt.Fprintln(out, "Reconciliar:", fix)
+
+	// Tomar una copia para revisar los discos sin retener el registro
+	partitions := GetMountedPartitions()
+	registryMutex.RLock()
+	drives := make(map[string]string, len(drivePathMap))
+	for drive, path := range drivePathMap {
+		drives[drive] = path
+	}
+	registryMutex.RUnlock()
+
+	var entries []RegistryEntry
+	var staleIDs []string
+	var staleDrives []string
+
+	ids := make([]string, 0, len(partitions))
+	for id := range partitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	driveNames := make([]string, 0, len(drives))
+	for drive := range drives {
+		driveNames = append(driveNames, drive)
+	}
+	sort.Strings(driveNames)
+
+	for _, id := range ids {
+		partition := partitions[id]
+		entry := RegistryEntry{
+			Kind:          "mount",
+			Id:            id,
+			Path:          partition.Path,
+			PartitionName: partition.PartitionName,
+			Status:        "ok",
+		}
+
+		var reason string
+		var repairable bool
+		if fix {
+			unlock := LockDisk(partition.Path)
+			reason, repairable = checkMountedPartition(partition)
+			if reason != "" && repairable {
+				if err := repairMountedPartition(partition); err == nil {
+					entry.Status = "repaired"
+				} else {
+					repairable = false
+					reason = fmt.Sprintf("%s (no se pudo reparar: %v)", reason, err)
+				}
+			}
+			unlock()
+		} else {
+			unlock := RLockDisk(partition.Path)
+			reason, repairable = checkMountedPartition(partition)
+			unlock()
+		}
+
+		if reason != "" {
+			entry.Reason = reason
+			if entry.Status != "repaired" {
+				entry.Status = "stale"
+				if fix && !repairable {
+					entry.Status = "removed"
+					staleIDs = append(staleIDs, id)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, drive := range driveNames {
+		entry := RegistryEntry{Kind: "drive", Drive: drive, Path: drives[drive], Status: "ok"}
+		if _, err := os.Stat(entry.Path); os.IsNotExist(err) {
+			entry.Reason = "el disco no existe"
+			entry.Status = "stale"
+			if fix {
+				entry.Status = "removed"
+				staleDrives = append(staleDrives, drive)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if fix {
+		registryMutex.Lock()
+		for _, id := range staleIDs {
+			if partition, exists := mountedPartitions[id]; exists {
+				delete(mountedPartitions, id)
+				removeDiskFromOrderIfUnused(partition.Path)
+			}
+		}
+		for _, drive := range staleDrives {
+			delete(drivePathMap, drive)
+		}
+		saveState(out)
+		registryMutex.Unlock()
+	}
+
+	fmt.Fprintln(out, "\n=== REGISTRO ===")
+	for _, entry := range entries {
+		name := entry.Id
+		if entry.Kind == "drive" {
+			name = entry.Drive
+		}
+		fmt.Fprintf(out, "%-6s %-5s | %-9s | %s %s", entry.Kind, name, entry.Status, entry.Path, entry.PartitionName)
+		if entry.Reason != "" {
+			fmt.Fprintf(out, " (%s)", entry.Reason)
+		}
+		fmt.Fprintln(out)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "El registro está vacío")
+	}
+	fmt.Fprintln(out, "======FIN REGISTRY======")
+	return entries, nil
+}
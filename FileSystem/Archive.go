@@ -0,0 +1,458 @@
+package FileSystem
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"proyecto1/DiskManagement"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// COMANDOS EXPORT E IMPORT - INTERCAMBIO CON DIRECTORIOS DEL HOST Y TAR
+// ============================================================================
+
+// ArchiveSummary resume lo exportado o importado
+type ArchiveSummary struct {
+	Format      string `json:"format"` // "tar" o "directory"
+	Directories int    `json:"directories"`
+	Files       int    `json:"files"`
+	Skipped     int    `json:"skipped"`
+}
+
+// archiveEntry es una carpeta o archivo a exportar o importar. Path es
+// relativo (con "/" como separador) a la raíz exportada o al destino.
+type archiveEntry struct {
+	Path    string
+	Dir     bool
+	Content []byte
+	Mode    int64
+	ModTime time.Time
+	Uid     int
+	Gid     int
+	Uname   string
+	Gname   string
+}
+
+// isTarPath indica si la ruta del host se trata como archivo tar
+func isTarPath(hostPath string) bool {
+	return strings.HasSuffix(strings.ToLower(hostPath), ".tar")
+}
+
+// inodeMode convierte I_perm ("664") en permisos del host
+func inodeMode(inode *Structs.Inode) int64 {
+	mode, err := strconv.ParseInt(strings.Trim(string(inode.I_perm[:]), "\x00"), 8, 32)
+	if err != nil {
+		return 0664
+	}
+	return mode & 0777
+}
+
+// inodeModTime convierte I_mtime a fecha; las fechas con otro formato usan la actual
+func inodeModTime(inode *Structs.Inode) time.Time {
+	stored := strings.Trim(string(inode.I_mtime[:]), "\x00")
+	t, err := time.ParseInLocation(Utilities.TimestampLayout, stored, time.Local)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// findGroupNameByID busca el nombre de un grupo activo en users.txt
+func findGroupNameByID(usersData string, gid int) (string, bool) {
+	for _, line := range strings.Split(usersData, "\n") {
+		// Formato: GID,G,grupo
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) != 3 || strings.TrimSpace(fields[1]) != "G" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err == nil && id == gid {
+			return strings.TrimSpace(fields[2]), true
+		}
+	}
+	return "", false
+}
+
+// collectExportEntries recorre la carpeta o archivo del inodo y agrega a
+// entries lo que el usuario puede leer
+func collectExportEntries(out io.Writer, file *os.File, superblock *Structs.Superblock, session *Structs.UserSession, usersData string, inodeNum int32, relPath string, entries *[]archiveEntry, summary *ArchiveSummary) {
+	if !hasReadPermission(session.PartitionID, inodeNum, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "  Omitido (sin permiso de lectura): /%s\n", relPath)
+		summary.Skipped++
+		return
+	}
+
+	var inode Structs.Inode
+	if err := Utilities.ReadObject(file, &inode, int64(superblock.S_inode_start+inodeNum*superblock.S_inode_size)); err != nil {
+		summary.Skipped++
+		return
+	}
+
+	entry := archiveEntry{
+		Path:    relPath,
+		Dir:     inode.I_type[0] == '0',
+		Mode:    inodeMode(&inode),
+		ModTime: inodeModTime(&inode),
+		Uid:     int(inode.I_uid),
+		Gid:     int(inode.I_gid),
+	}
+	entry.Uname, _ = findUsernameByID(session.PartitionID, entry.Uid)
+	entry.Gname, _ = findGroupNameByID(usersData, entry.Gid)
+
+	if !entry.Dir {
+		content, err := readFileBlocks(file, superblock, &inode)
+		if err != nil {
+			fmt.Fprintf(out, "  Omitido (error de lectura): /%s\n", relPath)
+			summary.Skipped++
+			return
+		}
+		entry.Content = content
+		*entries = append(*entries, entry)
+		summary.Files++
+		return
+	}
+
+	// La raíz exportada no se agrega como entrada, solo su contenido
+	if relPath != "" {
+		*entries = append(*entries, entry)
+		summary.Directories++
+	}
+	children, _ := readDirectoryEntries(file, superblock, &inode, inodeNum)
+	for _, child := range children {
+		if child.Name == "." || child.Name == ".." {
+			continue
+		}
+		collectExportEntries(out, file, superblock, session, usersData, child.Inode, path.Join(relPath, child.Name), entries, summary)
+	}
+}
+
+// writeTarArchive escribe las entradas en un tar con dueño, grupo y permisos
+func writeTarArchive(destino string, entries []archiveEntry) error {
+	output, err := os.Create(destino)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	writer := tar.NewWriter(output)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:    entry.Path,
+			Mode:    entry.Mode,
+			ModTime: entry.ModTime,
+			Uid:     entry.Uid,
+			Gid:     entry.Gid,
+			Uname:   entry.Uname,
+			Gname:   entry.Gname,
+		}
+		if entry.Dir {
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(entry.Content))
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+		if !entry.Dir {
+			if _, err := writer.Write(entry.Content); err != nil {
+				return err
+			}
+		}
+	}
+	return writer.Close()
+}
+
+// writeHostDirectory escribe las entradas bajo un directorio del host. Los
+// permisos de las carpetas se aplican al final para poder escribir su contenido.
+func writeHostDirectory(destino string, entries []archiveEntry) error {
+	if err := os.MkdirAll(destino, os.ModePerm); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		target := filepath.Join(destino, filepath.FromSlash(entry.Path))
+		if entry.Dir {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(target, entry.Content, os.FileMode(entry.Mode)); err != nil {
+			return err
+		}
+		os.Chmod(target, os.FileMode(entry.Mode))
+		os.Chtimes(target, entry.ModTime, entry.ModTime)
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Dir {
+			target := filepath.Join(destino, filepath.FromSlash(entries[i].Path))
+			os.Chmod(target, os.FileMode(entries[i].Mode))
+			os.Chtimes(target, entries[i].ModTime, entries[i].ModTime)
+		}
+	}
+	return nil
+}
+
+// Export - Copiar una carpeta o archivo de la partición a un directorio del
+// host o a un archivo .tar (con dueño, grupo y permisos en las cabeceras)
+func Export(out io.Writer, session *Structs.UserSession, sourcePath string, destino string) (*ArchiveSummary, error) {
+	fmt.Fprintln(out, "======Inicio EXPORT======")
+	fmt.Fprintf(out, "Ruta: %s\n", sourcePath)
+	fmt.Fprintf(out, "Destino: %s\n", destino)
+
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "======FIN EXPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
+	}
+	if !strings.HasPrefix(sourcePath, "/") {
+		fmt.Fprintln(out, "Error: La ruta debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN EXPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta debe empezar con '/' (ruta absoluta)")
+	}
+
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
+	if !exists {
+		fmt.Fprintln(out, "Error: Partición no encontrada")
+		fmt.Fprintln(out, "======FIN EXPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "Partición no encontrada")
+	}
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		fmt.Fprintf(out, "Error: No se pudo abrir el disco: %v\n", err)
+		fmt.Fprintln(out, "======FIN EXPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el disco: %v", err)
+	}
+	defer file.Close()
+
+	superblock, err := ReadSuperblock(session.PartitionID)
+	if err != nil {
+		fmt.Fprintf(out, "Error: No se pudo leer el superblock: %v\n", err)
+		fmt.Fprintln(out, "======FIN EXPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock: %v", err)
+	}
+
+	inodeNum, err := findFileOrDirectoryByPath(file, superblock, sourcePath, session.UserID, session.GroupID)
+	if err != nil {
+		fmt.Fprintf(out, "Error: No se encontró la ruta '%s': %v\n", sourcePath, err)
+		fmt.Fprintln(out, "======FIN EXPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "No se encontró la ruta '%s'", sourcePath)
+	}
+
+	usersData, _ := readUsersFile(session.PartitionID)
+	summary := &ArchiveSummary{Format: "directory"}
+	if isTarPath(destino) {
+		summary.Format = "tar"
+	}
+
+	// La raíz de la partición se exporta por su contenido; cualquier otra
+	// ruta se exporta con su propio nombre
+	rootName := ""
+	if clean := path.Clean(sourcePath); clean != "/" {
+		rootName = path.Base(clean)
+	}
+	var entries []archiveEntry
+	collectExportEntries(out, file, superblock, session, usersData, inodeNum, rootName, &entries, summary)
+
+	if dir := filepath.Dir(destino); summary.Format == "tar" && dir != "" {
+		os.MkdirAll(dir, os.ModePerm)
+	}
+	if summary.Format == "tar" {
+		err = writeTarArchive(destino, entries)
+	} else {
+		err = writeHostDirectory(destino, entries)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Error escribiendo '%s': %v\n", destino, err)
+		fmt.Fprintln(out, "======FIN EXPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo '%s': %v", destino, err)
+	}
+
+	fmt.Fprintln(out, "=== EXPORTACIÓN COMPLETADA ===")
+	fmt.Fprintf(out, "Formato: %s\n", summary.Format)
+	fmt.Fprintf(out, "Carpetas: %d, archivos: %d, omitidos: %d\n", summary.Directories, summary.Files, summary.Skipped)
+	fmt.Fprintln(out, "======FIN EXPORT======")
+	return summary, nil
+}
+
+// readTarEntries lee las carpetas y archivos regulares de un tar
+func readTarEntries(out io.Writer, source string) ([]archiveEntry, error) {
+	input, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	var entries []archiveEntry
+	reader := tar.NewReader(input)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := archiveEntry{
+			Path:    strings.Trim(path.Clean("/"+header.Name), "/"),
+			Mode:    header.Mode & 0777,
+			ModTime: header.ModTime,
+			Uid:     header.Uid,
+			Gid:     header.Gid,
+			Uname:   header.Uname,
+			Gname:   header.Gname,
+		}
+		if entry.Path == "" {
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			entry.Dir = true
+		case tar.TypeReg, tar.TypeRegA:
+			data, err := ioutil.ReadAll(reader)
+			if err != nil {
+				return nil, err
+			}
+			entry.Content = data
+		default:
+			fmt.Fprintf(out, "  Omitido (tipo de entrada no soportado): %s\n", header.Name)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// readHostEntries lee un directorio del host; sus archivos quedan con el
+// usuario de la sesión como dueño
+func readHostEntries(out io.Writer, source string) ([]archiveEntry, error) {
+	var entries []archiveEntry
+	err := filepath.Walk(source, func(hostPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, hostPath)
+		if err != nil || rel == "." {
+			return err
+		}
+		entry := archiveEntry{
+			Path:    filepath.ToSlash(rel),
+			Dir:     info.IsDir(),
+			Mode:    int64(info.Mode().Perm()),
+			ModTime: info.ModTime(),
+		}
+		if info.Mode().IsRegular() {
+			data, err := ioutil.ReadFile(hostPath)
+			if err != nil {
+				return err
+			}
+			entry.Content = data
+		} else if !entry.Dir {
+			fmt.Fprintf(out, "  Omitido (no es archivo regular): %s\n", hostPath)
+			return nil
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// Import - Cargar un tar o un directorio del host dentro de una carpeta de la
+// partición. Cada carpeta y archivo se crea con mkdir y mkfile (quedan en el
+// journaling) y sus permisos se aplican con chmod. Si la sesión es de root,
+// el dueño guardado en el tar se aplica con chown cuando el usuario existe.
+func Import(out io.Writer, session *Structs.UserSession, source string, destino string) (*ArchiveSummary, error) {
+	fmt.Fprintln(out, "======Inicio IMPORT======")
+	fmt.Fprintf(out, "Origen: %s\n", source)
+	fmt.Fprintf(out, "Destino: %s\n", destino)
+
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "======FIN IMPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
+	}
+	if !strings.HasPrefix(destino, "/") {
+		fmt.Fprintln(out, "Error: El destino debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN IMPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El destino debe empezar con '/' (ruta absoluta)")
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		fmt.Fprintf(out, "Error: El origen '%s' no existe en el sistema local\n", source)
+		fmt.Fprintln(out, "======FIN IMPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "El origen '%s' no existe en el sistema local", source)
+	}
+
+	summary := &ArchiveSummary{Format: "directory"}
+	var entries []archiveEntry
+	if info.IsDir() {
+		entries, err = readHostEntries(out, source)
+	} else {
+		summary.Format = "tar"
+		entries, err = readTarEntries(out, source)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Error leyendo '%s': %v\n", source, err)
+		fmt.Fprintln(out, "======FIN IMPORT======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo '%s': %v", source, err)
+	}
+
+	// El destino se crea si no existe
+	destino = path.Clean(destino)
+	if exists, _ := findDirectoryInPath(session.PartitionID, destino); !exists {
+		if _, err := Mkdir(out, session, destino, true); err != nil {
+			fmt.Fprintln(out, "======FIN IMPORT======")
+			return nil, err
+		}
+	}
+
+	usersData, _ := readUsersFile(session.PartitionID)
+	for _, entry := range entries {
+		target := path.Join(destino, entry.Path)
+		if entry.Dir {
+			if exists, _ := findDirectoryInPath(session.PartitionID, target); !exists {
+				if _, err := Mkdir(out, session, target, true); err != nil {
+					fmt.Fprintf(out, "  Omitido: %s (%v)\n", target, err)
+					summary.Skipped++
+					continue
+				}
+			}
+			summary.Directories++
+		} else {
+			if _, err := createFileWithContent(out, session, target, true, entry.Content, true); err != nil {
+				fmt.Fprintf(out, "  Omitido: %s (%v)\n", target, err)
+				summary.Skipped++
+				continue
+			}
+			summary.Files++
+		}
+
+		if err := Chmod(out, session, target, fmt.Sprintf("%03o", entry.Mode), false); err != nil {
+			fmt.Fprintf(out, "  No se aplicaron los permisos de %s: %v\n", target, err)
+		}
+		if session.Username == "root" && entry.Uname != "" && entry.Uname != session.Username {
+			if found, _ := findUser(usersData, entry.Uname); found {
+				if err := Chown(out, session, target, false, entry.Uname); err != nil {
+					fmt.Fprintf(out, "  No se aplicó el dueño de %s: %v\n", target, err)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(out, "=== IMPORTACIÓN COMPLETADA ===")
+	fmt.Fprintf(out, "Formato: %s\n", summary.Format)
+	fmt.Fprintf(out, "Carpetas: %d, archivos: %d, omitidos: %d\n", summary.Directories, summary.Files, summary.Skipped)
+	fmt.Fprintln(out, "======FIN IMPORT======")
+	return summary, nil
+}
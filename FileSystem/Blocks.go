@@ -0,0 +1,500 @@
+package FileSystem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+)
+
+// ============================================================================
+// BLOQUES DE ARCHIVO: DIRECTOS E INDIRECTOS (SIMPLE, DOBLE Y TRIPLE)
+// ============================================================================
+
+const (
+	fileBlockSize    = len(Structs.Fileblock{}.B_content)     // Bytes de contenido por bloque de archivo (256)
+	directPointers   = 12                                     // I_block[0] a I_block[11]
+	pointersPerBlock = len(Structs.Pointerblock{}.B_pointers) // Apuntadores por bloque de apuntadores (64)
+)
+
+// MaxFileBlocks es la cantidad máxima de bloques de datos de un archivo:
+// 12 directos + 64 (I_block[12]) + 64² (I_block[13]) + 64³ (I_block[14])
+const MaxFileBlocks = directPointers + pointersPerBlock + pointersPerBlock*pointersPerBlock + pointersPerBlock*pointersPerBlock*pointersPerBlock
+
+// MaxFileSize es el tamaño máximo en bytes de un archivo: 68176896 bytes
+// (unos 65 MB) con bloques de 256 bytes
+const MaxFileSize = MaxFileBlocks * fileBlockSize
+
+// blocksForSize calcula cuántos bloques de datos ocupa un contenido
+func blocksForSize(size int) int {
+	return (size + fileBlockSize - 1) / fileBlockSize
+}
+
+// levelCapacity devuelve cuántos bloques de datos alcanza un apuntador
+// indirecto del nivel indicado (1 = simple, 2 = doble, 3 = triple)
+func levelCapacity(level int) int {
+	capacity := 1
+	for i := 0; i < level; i++ {
+		capacity *= pointersPerBlock
+	}
+	return capacity
+}
+
+// pointerBlocksForLevel cuenta los bloques de apuntadores que necesita un
+// apuntador indirecto del nivel indicado para alcanzar count bloques de datos
+func pointerBlocksForLevel(level int, count int) int {
+	if level == 1 {
+		return 1
+	}
+	span := levelCapacity(level - 1)
+	total := 1
+	for count > 0 {
+		take := minInt(count, span)
+		total += pointerBlocksForLevel(level-1, take)
+		count -= take
+	}
+	return total
+}
+
+// pointerBlocksFor cuenta los bloques de apuntadores que necesita un archivo
+// de dataBlocks bloques de datos
+func pointerBlocksFor(dataBlocks int) int {
+	remaining := dataBlocks - directPointers
+	total := 0
+	for level := 1; level <= 3 && remaining > 0; level++ {
+		take := minInt(remaining, levelCapacity(level))
+		total += pointerBlocksForLevel(level, take)
+		remaining -= take
+	}
+	return total
+}
+
+// blockPosition calcula la posición absoluta de un bloque en el disco
+func blockPosition(superblock *Structs.Superblock, blockIndex int32) int64 {
+	return int64(superblock.S_block_start) + int64(blockIndex)*int64(binary.Size(Structs.Fileblock{}))
+}
+
+// FileBlocks devuelve, en orden, los bloques de datos de un inodo de archivo
+// y los bloques de apuntadores (simple, doble y triple) que los alcanzan. Solo
+// recorre los bloques que cubren I_size.
+func FileBlocks(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode) ([]int32, []int32) {
+	limit := blocksForSize(int(inode.I_size))
+	var data, pointers []int32
+
+	for i := 0; i < directPointers && len(data) < limit; i++ {
+		if inode.I_block[i] != -1 {
+			data = append(data, inode.I_block[i])
+		}
+	}
+	for level := 1; level <= 3 && len(data) < limit; level++ {
+		collectIndirectBlocks(file, superblock, inode.I_block[directPointers+level-1], level, limit, &data, &pointers)
+	}
+	return data, pointers
+}
+
+// collectIndirectBlocks recorre un bloque de apuntadores del nivel indicado
+func collectIndirectBlocks(file *os.File, superblock *Structs.Superblock, blockIndex int32, level int, limit int, data *[]int32, pointers *[]int32) {
+	if blockIndex < 0 || blockIndex >= superblock.S_blocks_count {
+		return
+	}
+	var pointerBlock Structs.Pointerblock
+	if err := Utilities.ReadObject(file, &pointerBlock, blockPosition(superblock, blockIndex)); err != nil {
+		return
+	}
+	*pointers = append(*pointers, blockIndex)
+
+	for _, pointer := range pointerBlock.B_pointers {
+		if len(*data) >= limit {
+			return
+		}
+		if pointer == -1 {
+			continue
+		}
+		if level == 1 {
+			*data = append(*data, pointer)
+		} else {
+			collectIndirectBlocks(file, superblock, pointer, level-1, limit, data, pointers)
+		}
+	}
+}
+
+// readFileBlocks lee el contenido completo de un inodo de archivo
+func readFileBlocks(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode) ([]byte, error) {
+	data, _ := FileBlocks(file, superblock, inode)
+	content := make([]byte, 0, inode.I_size)
+	remaining := int(inode.I_size)
+
+	for i, blockIndex := range data {
+		var fileBlock Structs.Fileblock
+		if err := Utilities.ReadObject(file, &fileBlock, blockPosition(superblock, blockIndex)); err != nil {
+			return nil, fmt.Errorf("error leyendo bloque %d: %s", i, err.Error())
+		}
+		bytesToRead := minInt(remaining, fileBlockSize)
+		content = append(content, fileBlock.B_content[:bytesToRead]...)
+		remaining -= bytesToRead
+	}
+	return content, nil
+}
+
+// dataBlockAt devuelve el n-ésimo bloque de datos de un inodo de archivo
+// leyendo solo los bloques de apuntadores del camino hasta él (-1 si no existe)
+func dataBlockAt(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode, n int) int32 {
+	if n < directPointers {
+		return inode.I_block[n]
+	}
+	rest := n - directPointers
+	for level := 1; level <= 3; level++ {
+		if rest >= levelCapacity(level) {
+			rest -= levelCapacity(level)
+			continue
+		}
+		blockIndex := inode.I_block[directPointers+level-1]
+		for ; level > 0; level-- {
+			if blockIndex < 0 || blockIndex >= superblock.S_blocks_count {
+				return -1
+			}
+			var pointerBlock Structs.Pointerblock
+			if err := Utilities.ReadObject(file, &pointerBlock, blockPosition(superblock, blockIndex)); err != nil {
+				return -1
+			}
+			span := levelCapacity(level - 1)
+			blockIndex = pointerBlock.B_pointers[rest/span]
+			rest %= span
+		}
+		return blockIndex
+	}
+	return -1
+}
+
+// linkDataBlock deja blockIndex como n-ésimo bloque de datos del inodo. Los
+// bloques de apuntadores que falten en el camino se toman de takeBlock.
+func linkDataBlock(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode, n int, blockIndex int32, takeBlock func() int32) error {
+	if n < directPointers {
+		inode.I_block[n] = blockIndex
+		return nil
+	}
+
+	// newPointerBlock escribe un bloque de apuntadores vacío
+	newPointerBlock := func() (int32, error) {
+		pointerIndex := takeBlock()
+		var pointerBlock Structs.Pointerblock
+		for j := range pointerBlock.B_pointers {
+			pointerBlock.B_pointers[j] = -1
+		}
+		return pointerIndex, Utilities.WriteObject(file, pointerBlock, blockPosition(superblock, pointerIndex))
+	}
+
+	rest := n - directPointers
+	for level := 1; level <= 3; level++ {
+		if rest >= levelCapacity(level) {
+			rest -= levelCapacity(level)
+			continue
+		}
+		slot := &inode.I_block[directPointers+level-1]
+		if *slot == -1 {
+			pointerIndex, err := newPointerBlock()
+			if err != nil {
+				return err
+			}
+			*slot = pointerIndex
+		}
+		parent := *slot
+		for ; level > 0; level-- {
+			var pointerBlock Structs.Pointerblock
+			if err := Utilities.ReadObject(file, &pointerBlock, blockPosition(superblock, parent)); err != nil {
+				return err
+			}
+			span := levelCapacity(level - 1)
+			child := &pointerBlock.B_pointers[rest/span]
+			rest %= span
+			if level == 1 {
+				*child = blockIndex
+			} else if *child == -1 {
+				pointerIndex, err := newPointerBlock()
+				if err != nil {
+					return err
+				}
+				*child = pointerIndex
+			}
+			if err := Utilities.WriteObject(file, pointerBlock, blockPosition(superblock, parent)); err != nil {
+				return err
+			}
+			parent = *child
+		}
+		return nil
+	}
+	return fmt.Errorf("el bloque %d supera el máximo de %d bloques", n, MaxFileBlocks)
+}
+
+// readFileRange lee length bytes del archivo desde offset. Solo lee los
+// bloques de datos que cubren el rango; se corta en el final del archivo.
+func readFileRange(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode, offset int, length int) ([]byte, error) {
+	end := minInt(offset+length, int(inode.I_size))
+	if offset >= end {
+		return []byte{}, nil
+	}
+
+	content := make([]byte, 0, end-offset)
+	for n := offset / fileBlockSize; n*fileBlockSize < end; n++ {
+		blockIndex := dataBlockAt(file, superblock, inode, n)
+		if blockIndex < 0 || blockIndex >= superblock.S_blocks_count {
+			return nil, fmt.Errorf("el bloque %d del archivo no es válido", n)
+		}
+		var fileBlock Structs.Fileblock
+		if err := Utilities.ReadObject(file, &fileBlock, blockPosition(superblock, blockIndex)); err != nil {
+			return nil, fmt.Errorf("error leyendo bloque %d: %s", n, err.Error())
+		}
+		from := maxInt(offset-n*fileBlockSize, 0)
+		to := minInt(end-n*fileBlockSize, fileBlockSize)
+		content = append(content, fileBlock.B_content[from:to]...)
+	}
+	return content, nil
+}
+
+// writeFileRange escribe data en el archivo desde offset (como máximo I_size,
+// así que I_size agrega al final). Solo reescribe los bloques afectados y
+// reserva los bloques de datos y apuntadores que falten si el archivo crece.
+// Si falla a medias libera los bloques reservados y deja el inodo como estaba.
+func writeFileRange(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode, offset int, data []byte) (err error) {
+	oldSize := int(inode.I_size)
+	if offset < 0 || offset > oldSize {
+		return fmt.Errorf("el offset %d está fuera del archivo (tamaño: %d bytes)", offset, oldSize)
+	}
+	newSize := maxInt(oldSize, offset+len(data))
+	if newSize > MaxFileSize {
+		return fmt.Errorf("contenido demasiado grande. Máximo: %d bytes", MaxFileSize)
+	}
+
+	oldBlocks, newBlocks := blocksForSize(oldSize), blocksForSize(newSize)
+	needed := newBlocks - oldBlocks + pointerBlocksFor(newBlocks) - pointerBlocksFor(oldBlocks)
+	blocks, err := allocateBlocks(file, superblock, needed)
+	if err != nil {
+		return err
+	}
+	original := *inode
+	defer func() {
+		if err != nil {
+			*inode = original
+			releaseBlocks(file, superblock, &original, blocks)
+		}
+	}()
+	next := 0
+	takeBlock := func() int32 {
+		blockIndex := blocks[next]
+		next++
+		return blockIndex
+	}
+
+	end := offset + len(data)
+	for n := offset / fileBlockSize; n*fileBlockSize < end; n++ {
+		var fileBlock Structs.Fileblock
+		var blockIndex int32
+		if n < oldBlocks {
+			blockIndex = dataBlockAt(file, superblock, inode, n)
+			if blockIndex < 0 || blockIndex >= superblock.S_blocks_count {
+				return fmt.Errorf("el bloque %d del archivo no es válido", n)
+			}
+			if err := Utilities.ReadObject(file, &fileBlock, blockPosition(superblock, blockIndex)); err != nil {
+				return fmt.Errorf("error leyendo bloque %d: %s", n, err.Error())
+			}
+		} else {
+			blockIndex = takeBlock()
+			if err := linkDataBlock(file, superblock, inode, n, blockIndex, takeBlock); err != nil {
+				return fmt.Errorf("error enlazando bloque %d: %s", n, err.Error())
+			}
+		}
+
+		blockStart := n * fileBlockSize
+		from := maxInt(offset-blockStart, 0)
+		to := minInt(end-blockStart, fileBlockSize)
+		copy(fileBlock.B_content[from:to], data[blockStart+from-offset:])
+		if err := Utilities.WriteObject(file, fileBlock, blockPosition(superblock, blockIndex)); err != nil {
+			return fmt.Errorf("error escribiendo bloque %d: %s", n, err.Error())
+		}
+	}
+	inode.I_size = int32(newSize)
+	return nil
+}
+
+// allocateBlocks reserva count bloques libres en el bitmap y descuenta el
+// contador del superblock en memoria (quien llama escribe el superblock)
+func allocateBlocks(file *os.File, superblock *Structs.Superblock, count int) ([]int32, error) {
+	if count == 0 {
+		return nil, nil
+	}
+	bitmap := make([]byte, superblock.S_blocks_count)
+	if err := Utilities.ReadObject(file, bitmap, int64(superblock.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error leyendo bitmap de bloques: %s", err.Error())
+	}
+
+	blocks := make([]int32, 0, count)
+	for i := range bitmap {
+		if bitmap[i] == 0 {
+			blocks = append(blocks, int32(i))
+			bitmap[i] = 1
+			if len(blocks) == count {
+				break
+			}
+		}
+	}
+	if len(blocks) < count {
+		return nil, fmt.Errorf("no hay suficientes bloques libres (necesarios: %d, disponibles: %d)", count, len(blocks))
+	}
+
+	if err := Utilities.WriteObject(file, bitmap, int64(superblock.S_bm_block_start)); err != nil {
+		return nil, fmt.Errorf("error escribiendo bitmap de bloques: %s", err.Error())
+	}
+	superblock.S_free_blocks_count -= int32(count)
+	return blocks, nil
+}
+
+// releaseBlocks devuelve al bitmap bloques recién reservados que no llegaron
+// a usarse y quita los apuntadores a ellos de los bloques de apuntadores del
+// inodo (que ya existían en el disco). Suma los bloques al contador del
+// superblock en memoria (quien llama escribe el superblock).
+func releaseBlocks(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode, blocks []int32) {
+	if len(blocks) == 0 {
+		return
+	}
+	released := make(map[int32]bool, len(blocks))
+	for _, blockIndex := range blocks {
+		released[blockIndex] = true
+	}
+
+	var unlink func(blockIndex int32, level int)
+	unlink = func(blockIndex int32, level int) {
+		if blockIndex < 0 || blockIndex >= superblock.S_blocks_count || released[blockIndex] {
+			return
+		}
+		var pointerBlock Structs.Pointerblock
+		if err := Utilities.ReadObject(file, &pointerBlock, blockPosition(superblock, blockIndex)); err != nil {
+			return
+		}
+		changed := false
+		for j, pointer := range pointerBlock.B_pointers {
+			if released[pointer] {
+				pointerBlock.B_pointers[j] = -1
+				changed = true
+			} else if level > 1 && pointer != -1 {
+				unlink(pointer, level-1)
+			}
+		}
+		if changed {
+			Utilities.WriteObject(file, pointerBlock, blockPosition(superblock, blockIndex))
+		}
+	}
+	for level := 1; level <= 3; level++ {
+		unlink(inode.I_block[directPointers+level-1], level)
+	}
+
+	for _, blockIndex := range blocks {
+		Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+blockIndex))
+		superblock.S_free_blocks_count++
+	}
+}
+
+// writeFileBlocks reparte el contenido en bloques nuevos y deja los
+// apuntadores (directos e indirectos) en el inodo. El inodo no debe tener
+// bloques asignados; para reescribir un archivo primero se usa freeFileBlocks.
+func writeFileBlocks(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode, content []byte) error {
+	dataBlocks := blocksForSize(len(content))
+	if dataBlocks > MaxFileBlocks {
+		return fmt.Errorf("contenido demasiado grande. Máximo: %d bytes", MaxFileSize)
+	}
+
+	blocks, err := allocateBlocks(file, superblock, dataBlocks+pointerBlocksFor(dataBlocks))
+	if err != nil {
+		return err
+	}
+
+	// Los bloques se toman en orden: cada bloque de apuntadores antes que sus hijos
+	next := 0
+	written := 0
+	var writeErr error
+	takeBlock := func() int32 {
+		blockIndex := blocks[next]
+		next++
+		return blockIndex
+	}
+	writeData := func() int32 {
+		blockIndex := takeBlock()
+		var fileBlock Structs.Fileblock
+		end := minInt(written+fileBlockSize, len(content))
+		copy(fileBlock.B_content[:], content[written:end])
+		written = end
+		if err := Utilities.WriteObject(file, fileBlock, blockPosition(superblock, blockIndex)); err != nil && writeErr == nil {
+			writeErr = err
+		}
+		return blockIndex
+	}
+	var writeIndirect func(level int, count int) int32
+	writeIndirect = func(level int, count int) int32 {
+		blockIndex := takeBlock()
+		var pointerBlock Structs.Pointerblock
+		for j := range pointerBlock.B_pointers {
+			pointerBlock.B_pointers[j] = -1
+		}
+		span := levelCapacity(level - 1)
+		for j := 0; count > 0; j++ {
+			take := minInt(count, span)
+			if level == 1 {
+				pointerBlock.B_pointers[j] = writeData()
+			} else {
+				pointerBlock.B_pointers[j] = writeIndirect(level-1, take)
+			}
+			count -= take
+		}
+		if err := Utilities.WriteObject(file, pointerBlock, blockPosition(superblock, blockIndex)); err != nil && writeErr == nil {
+			writeErr = err
+		}
+		return blockIndex
+	}
+
+	for i := range inode.I_block {
+		inode.I_block[i] = -1
+	}
+	for i := 0; i < directPointers && i < dataBlocks; i++ {
+		inode.I_block[i] = writeData()
+	}
+	remaining := dataBlocks - directPointers
+	for level := 1; level <= 3 && remaining > 0; level++ {
+		take := minInt(remaining, levelCapacity(level))
+		inode.I_block[directPointers+level-1] = writeIndirect(level, take)
+		remaining -= take
+	}
+	inode.I_size = int32(len(content))
+
+	if writeErr != nil {
+		return fmt.Errorf("error escribiendo bloques del archivo: %s", writeErr.Error())
+	}
+	return nil
+}
+
+// freeFileBlocks libera en el bitmap los bloques de datos y de apuntadores de
+// un inodo de archivo y deja sus apuntadores en -1. Suma los bloques al
+// contador del superblock en memoria (quien llama escribe el superblock).
+func freeFileBlocks(file *os.File, superblock *Structs.Superblock, inode *Structs.Inode) {
+	data, pointers := FileBlocks(file, superblock, inode)
+	for _, blockIndex := range append(data, pointers...) {
+		Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+blockIndex))
+		superblock.S_free_blocks_count++
+	}
+	for i := range inode.I_block {
+		inode.I_block[i] = -1
+	}
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
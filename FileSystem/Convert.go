@@ -0,0 +1,412 @@
+package FileSystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"proyecto1/DiskManagement"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+)
+
+// ============================================================================
+// CONVERSIÓN EXT2 A EXT3 EN EL LUGAR
+// ============================================================================
+
+// partitionSize obtiene el tamaño en bytes de la partición montada
+func partitionSize(file *os.File, mountedPartition Structs.MountedPartition) (int32, error) {
+	if mountedPartition.IsLogical {
+		var ebr Structs.EBR
+		if err := Utilities.ReadObject(file, &ebr, int64(mountedPartition.EBRPosition)); err != nil {
+			return 0, err
+		}
+		return ebr.Part_size, nil
+	}
+
+	var tempMBR Structs.MBR
+	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
+		return 0, err
+	}
+	return tempMBR.Partitions[mountedPartition.PartitionIndex].Size, nil
+}
+
+// remapIndexes asigna a cada entrada ocupada del bitmap su posición en un
+// bitmap de newCount entradas. Las que ya caben conservan su índice y las que
+// quedan fuera pasan a los primeros huecos libres. Las libres quedan en -1.
+func remapIndexes(bitmap []byte, newCount int32) ([]int32, error) {
+	mapping := make([]int32, len(bitmap))
+	taken := make([]bool, newCount)
+	for i := range bitmap {
+		mapping[i] = -1
+		if bitmap[i] != 0 && int32(i) < newCount {
+			mapping[i] = int32(i)
+			taken[i] = true
+		}
+	}
+
+	next := int32(0)
+	for i := range bitmap {
+		if bitmap[i] == 0 || int32(i) < newCount {
+			continue
+		}
+		for next < newCount && taken[next] {
+			next++
+		}
+		if next == newCount {
+			return nil, fmt.Errorf("no caben todas las entradas ocupadas en %d posiciones", newCount)
+		}
+		mapping[i] = next
+		taken[next] = true
+	}
+	return mapping, nil
+}
+
+// countUsed cuenta las entradas ocupadas de un bitmap
+func countUsed(bitmap []byte) int32 {
+	used := int32(0)
+	for _, value := range bitmap {
+		if value != 0 {
+			used++
+		}
+	}
+	return used
+}
+
+// firstFree devuelve la primera entrada libre de un bitmap (o su tamaño si está lleno)
+func firstFree(bitmap []byte) int32 {
+	for i, value := range bitmap {
+		if value == 0 {
+			return int32(i)
+		}
+	}
+	return int32(len(bitmap))
+}
+
+// ConvertToExt3 convierte una partición EXT2 en EXT3 sin perder archivos.
+// Reserva el área del journaling después del superblock; si el espacio no
+// alcanza para las mismas estructuras, reduce la cantidad de inodos y bloques
+// (ver relayout).
+func ConvertToExt3(out io.Writer, id string, journalSize int32) error {
+	fmt.Fprintln(out, "======Inicio CONVERT======")
+	fmt.Fprintf(out, "Convirtiendo a EXT3 la partición ID: %s\n", id)
+
+	if journalSize <= 0 {
+		fmt.Fprintf(out, "Error: El tamaño del journaling debe ser mayor a 0 (recibido: %d)\n", journalSize)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El tamaño del journaling debe ser mayor a 0 (recibido: %d)", journalSize)
+	}
+
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
+	if !exists {
+		fmt.Fprintf(out, "Error: La partición con ID '%s' no está montada\n", id)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "La partición con ID '%s' no está montada", id)
+	}
+
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		fmt.Fprintln(out, "Error abriendo archivo del disco:", err)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo del disco: %v", err)
+	}
+	defer file.Close()
+
+	partitionStart, err := getPartitionStart(file, mountedPartition)
+	if err != nil {
+		fmt.Fprintln(out, "Error obteniendo el inicio de la partición:", err)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error obteniendo el inicio de la partición: %v", err)
+	}
+	size, err := partitionSize(file, mountedPartition)
+	if err != nil {
+		fmt.Fprintln(out, "Error obteniendo el tamaño de la partición:", err)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error obteniendo el tamaño de la partición: %v", err)
+	}
+
+	var sb Structs.Superblock
+	if err := Utilities.ReadObject(file, &sb, int64(partitionStart)); err != nil {
+		fmt.Fprintln(out, "Error leyendo superblock:", err)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo superblock: %v", err)
+	}
+	if sb.S_magic != 0xEF53 {
+		fmt.Fprintf(out, "Error: La partición '%s' no tiene un sistema de archivos (use mkfs)\n", id)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrUnsupported, "La partición '%s' no tiene un sistema de archivos", id)
+	}
+	if sb.S_filesystem_type != 2 {
+		fmt.Fprintf(out, "Error: La partición '%s' no es EXT2 (sistema de archivos tipo %d)\n", id, sb.S_filesystem_type)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrUnsupported, "La partición '%s' no es EXT2 (sistema de archivos tipo %d)", id, sb.S_filesystem_type)
+	}
+
+	// Nueva cantidad de estructuras con el mismo cálculo de mkfs para EXT3
+	superblockSize := int32(binary.Size(Structs.Superblock{}))
+	n := structureCount(size, &sb, journalSize)
+
+	fmt.Fprintf(out, "Inodos: %d -> %d\n", sb.S_inodes_count, n)
+	fmt.Fprintf(out, "Bloques: %d -> %d\n", sb.S_blocks_count, 3*n)
+
+	// Cargar en memoria bitmaps, inodos y bloques con la distribución EXT2
+	image, err := loadImage(file, &sb)
+	if err != nil {
+		fmt.Fprintln(out, "Error leyendo el sistema de archivos:", err)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el sistema de archivos: %v", err)
+	}
+	usedInodes, usedBlocks := image.used()
+	if !image.fits(n) {
+		fmt.Fprintf(out, "Error: No hay espacio para un journaling de %d entradas (ocupados: %d inodos y %d bloques)\n", journalSize, usedInodes, usedBlocks)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay espacio para un journaling de %d entradas (ocupados: %d inodos y %d bloques)", journalSize, usedInodes, usedBlocks)
+	}
+
+	// El journaling empieza vacío; la conversión es su primera entrada
+	sb.S_filesystem_type = 3
+	sb.S_journal_head = 0
+	sb.S_journal_tail = 0
+	sb.S_journal_seq = 0
+	err = relayout(file, &sb, partitionStart, n, journalSize, image)
+	if err == nil {
+		err = Utilities.WriteObject(file, make([]Structs.Journaling, journalSize), int64(partitionStart+superblockSize))
+	}
+	if err != nil {
+		fmt.Fprintln(out, "Error convirtiendo el sistema de archivos:", err)
+		fmt.Fprintln(out, "======FIN CONVERT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error convirtiendo el sistema de archivos: %v", err)
+	}
+
+	// La primera entrada del journaling registra la conversión
+	writeToJournal(out, id, "convert", "/", fmt.Sprintf("EXT2 a EXT3 - Inodes:%d Blocks:%d", n, 3*n))
+
+	fmt.Fprintln(out, "=== ESTRUCTURA DEL SISTEMA DE ARCHIVOS EXT3 ===")
+	fmt.Fprintf(out, "Superblock:        posición %d (tamaño: %d bytes)\n", partitionStart, superblockSize)
+	fmt.Fprintf(out, "Journaling:        posición %d (tamaño: %d entradas)\n", partitionStart+superblockSize, journalSize)
+	fmt.Fprintf(out, "Bitmap inodos:     posición %d (tamaño: %d bytes)\n", sb.S_bm_inode_start, n)
+	fmt.Fprintf(out, "Bitmap bloques:    posición %d (tamaño: %d bytes)\n", sb.S_bm_block_start, 3*n)
+	fmt.Fprintf(out, "Tabla de inodos:   posición %d (tamaño: %d bytes)\n", sb.S_inode_start, n*sb.S_inode_size)
+	fmt.Fprintf(out, "Bloques de datos:  posición %d (tamaño: %d bytes)\n", sb.S_block_start, 3*n*sb.S_block_size)
+	fmt.Fprintf(out, "Inodos conservados: %d, bloques conservados: %d\n", usedInodes, usedBlocks)
+	fmt.Fprintln(out, "=== PARTICIÓN CONVERTIDA A EXT3 EXITOSAMENTE ===")
+	fmt.Fprintln(out, "======FIN CONVERT======")
+	return nil
+}
+
+// fsImage guarda en memoria los bitmaps, la tabla de inodos y el área de
+// bloques de un sistema de archivos para reubicarlos
+type fsImage struct {
+	inodeBitmap []byte
+	blockBitmap []byte
+	inodes      []Structs.Inode
+	blocks      []byte
+}
+
+// loadImage lee las estructuras del sistema de archivos con su distribución actual
+func loadImage(file *os.File, sb *Structs.Superblock) (*fsImage, error) {
+	image := &fsImage{
+		inodeBitmap: make([]byte, sb.S_inodes_count),
+		blockBitmap: make([]byte, sb.S_blocks_count),
+		inodes:      make([]Structs.Inode, sb.S_inodes_count),
+		blocks:      make([]byte, sb.S_blocks_count*sb.S_block_size),
+	}
+	for _, read := range []struct {
+		data     interface{}
+		position int32
+	}{
+		{image.inodeBitmap, sb.S_bm_inode_start},
+		{image.blockBitmap, sb.S_bm_block_start},
+		{image.inodes, sb.S_inode_start},
+		{image.blocks, sb.S_block_start},
+	} {
+		if err := Utilities.ReadObject(file, read.data, int64(read.position)); err != nil {
+			return nil, err
+		}
+	}
+	return image, nil
+}
+
+// used cuenta los inodos y bloques ocupados
+func (image *fsImage) used() (int32, int32) {
+	return countUsed(image.inodeBitmap), countUsed(image.blockBitmap)
+}
+
+// fits indica si los inodos y bloques ocupados caben en n inodos y 3n bloques
+func (image *fsImage) fits(n int32) bool {
+	usedInodes, usedBlocks := image.used()
+	return n > 0 && usedInodes <= n && usedBlocks <= 3*n
+}
+
+// structureCount calcula cuántos inodos caben en una partición de size bytes
+// con el mismo cálculo de mkfs (journalSize registros de journaling, 0 en EXT2)
+func structureCount(size int32, sb *Structs.Superblock, journalSize int32) int32 {
+	superblockSize := int32(binary.Size(Structs.Superblock{}))
+	journalingSize := int32(binary.Size(Structs.Journaling{}))
+	structureSize := 1 + sb.S_inode_size + 3 + 3*sb.S_block_size
+	return (size - superblockSize - journalSize*journalingSize) / structureSize
+}
+
+// relayout escribe las estructuras cargadas en memoria con n inodos y 3n
+// bloques, después del superblock y de journalSize registros de journaling.
+// Los inodos y bloques que no caben se mueven a huecos libres y se actualizan
+// todos los apuntadores. Actualiza y escribe el superblock; el contenido del
+// journaling no se toca. El llamador debe verificar antes image.fits(n).
+func relayout(file *os.File, sb *Structs.Superblock, partitionStart int32, n int32, journalSize int32, image *fsImage) error {
+	inodeMap, err := remapIndexes(image.inodeBitmap, n)
+	if err != nil {
+		return err
+	}
+	blockMap, err := remapIndexes(image.blockBitmap, 3*n)
+	if err != nil {
+		return err
+	}
+	blockSize := sb.S_block_size
+
+	// Clasificar los bloques ocupados según su contenido: las carpetas y los
+	// bloques de apuntadores guardan índices que también se deben reubicar
+	folderBlocks := make(map[int32]bool)
+	pointerBlocks := make(map[int32]bool)
+	for i := range image.inodes {
+		if image.inodeBitmap[i] == 0 {
+			continue
+		}
+		inode := &image.inodes[i]
+		if string(inode.I_type[:1]) == "0" {
+			for _, blockIndex := range inode.I_block {
+				if blockIndex >= 0 && blockIndex < sb.S_blocks_count {
+					folderBlocks[blockIndex] = true
+				}
+			}
+			continue
+		}
+		_, pointers := FileBlocks(file, sb, inode)
+		for _, blockIndex := range pointers {
+			pointerBlocks[blockIndex] = true
+		}
+	}
+
+	remapBlock := func(blockIndex int32) int32 {
+		if blockIndex < 0 || blockIndex >= int32(len(blockMap)) {
+			return -1
+		}
+		return blockMap[blockIndex]
+	}
+
+	newInodeBitmap := make([]byte, n)
+	newBlockBitmap := make([]byte, 3*n)
+	newInodes := make([]Structs.Inode, n)
+	newBlocks := make([]byte, 3*n*blockSize)
+	for i := range newInodes {
+		for j := range newInodes[i].I_block {
+			newInodes[i].I_block[j] = -1
+		}
+	}
+
+	for i, target := range inodeMap {
+		if target == -1 {
+			continue
+		}
+		inode := image.inodes[i]
+		for j, blockIndex := range inode.I_block {
+			if blockIndex != -1 {
+				inode.I_block[j] = remapBlock(blockIndex)
+			}
+		}
+		newInodes[target] = inode
+		newInodeBitmap[target] = 1
+	}
+
+	for i, target := range blockMap {
+		if target == -1 {
+			continue
+		}
+		raw := image.blocks[int32(i)*blockSize : int32(i+1)*blockSize]
+		switch {
+		case folderBlocks[int32(i)]:
+			var folderBlock Structs.Folderblock
+			if err := binary.Read(bytes.NewReader(raw), binary.LittleEndian, &folderBlock); err != nil {
+				return err
+			}
+			for j := range folderBlock.B_content {
+				entry := &folderBlock.B_content[j]
+				if entry.B_inodo >= 0 && entry.B_inodo < int32(len(inodeMap)) {
+					entry.B_inodo = inodeMap[entry.B_inodo]
+				}
+				// Un nombre largo apunta a su bloque de nombre
+				if entry.B_inodo != -1 && isLongName(entry) {
+					binary.LittleEndian.PutUint32(entry.B_name[1:longNameHint], uint32(remapBlock(nameBlockOf(entry))))
+				}
+			}
+			raw = encodeBlock(folderBlock)
+		case pointerBlocks[int32(i)]:
+			var pointerBlock Structs.Pointerblock
+			if err := binary.Read(bytes.NewReader(raw), binary.LittleEndian, &pointerBlock); err != nil {
+				return err
+			}
+			for j, pointer := range pointerBlock.B_pointers {
+				if pointer != -1 {
+					pointerBlock.B_pointers[j] = remapBlock(pointer)
+				}
+			}
+			raw = encodeBlock(pointerBlock)
+		}
+		copy(newBlocks[target*blockSize:], raw)
+		newBlockBitmap[target] = 1
+	}
+
+	return writeImage(file, sb, partitionStart, journalSize, &fsImage{
+		inodeBitmap: newInodeBitmap,
+		blockBitmap: newBlockBitmap,
+		inodes:      newInodes,
+		blocks:      newBlocks,
+	})
+}
+
+// writeImage escribe image con la distribución actual: superblock, journalSize
+// registros de journaling (que no se tocan), bitmaps, inodos y bloques.
+// Actualiza en sb las cantidades, los libres y las posiciones.
+func writeImage(file *os.File, sb *Structs.Superblock, partitionStart int32, journalSize int32, image *fsImage) error {
+	// Distribución: Superblock, Journaling (EXT3), Bitmaps, Inodos, Bloques
+	journalingSize := int32(binary.Size(Structs.Journaling{}))
+	n := int32(len(image.inodeBitmap))
+	journalingStart := partitionStart + int32(binary.Size(Structs.Superblock{}))
+	sb.S_inodes_count = n
+	sb.S_blocks_count = 3 * n
+	sb.S_free_inodes_count = n - countUsed(image.inodeBitmap)
+	sb.S_free_blocks_count = 3*n - countUsed(image.blockBitmap)
+	sb.S_fist_ino = firstFree(image.inodeBitmap)
+	sb.S_first_blo = firstFree(image.blockBitmap)
+	sb.S_bm_inode_start = journalingStart + journalSize*journalingSize
+	sb.S_bm_block_start = sb.S_bm_inode_start + n
+	sb.S_inode_start = sb.S_bm_block_start + 3*n
+	sb.S_block_start = sb.S_inode_start + n*sb.S_inode_size
+	if journalSize > 0 {
+		sb.S_journal_count = journalSize
+	}
+	copy(sb.S_umtime[:], Utilities.CurrentTimestamp())
+
+	for _, write := range []struct {
+		data     interface{}
+		position int32
+	}{
+		{*sb, partitionStart},
+		{image.inodeBitmap, sb.S_bm_inode_start},
+		{image.blockBitmap, sb.S_bm_block_start},
+		{image.inodes, sb.S_inode_start},
+		{image.blocks, sb.S_block_start},
+	} {
+		if err := Utilities.WriteObject(file, write.data, int64(write.position)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeBlock serializa un bloque con el mismo formato que WriteObject
+func encodeBlock(block interface{}) []byte {
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, block)
+	return buffer.Bytes()
+}
@@ -0,0 +1,190 @@
+package FileSystem
+
+import (
+	"fmt"
+	"io"
+	"proyecto1/DiskManagement"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+)
+
+// ============================================================================
+// LECTURA Y ESCRITURA POR RANGOS DE BYTES
+// ============================================================================
+
+// FileRange describe un rango leído o escrito de un archivo
+type FileRange struct {
+	Path    string `json:"path"`
+	Offset  int    `json:"offset"`
+	Length  int    `json:"length"`
+	Size    int    `json:"size"` // tamaño total del archivo
+	Content []byte `json:"-"`    // la API elige cómo enviarlo (texto o base64)
+}
+
+// GetFileRange lee length bytes del archivo desde offset (length negativo
+// lee hasta el final). Solo lee los bloques que cubren el rango.
+func GetFileRange(partitionID string, filePath string, offset int, length int) (*FileRange, error) {
+	// Lectura concurrente: bloquear el disco solo en modo lectura
+	defer DiskManagement.RLockPartition(partitionID)()
+
+	exists, inodeNum := findFileInDirectory(partitionID, filePath)
+	if !exists {
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "archivo '%s' no encontrado", filePath)
+	}
+
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
+	if !exists {
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "partición no montada")
+	}
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "error abriendo disco: %s", err.Error())
+	}
+	defer file.Close()
+
+	superblock, err := ReadSuperblock(partitionID)
+	if err != nil {
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "error leyendo superblock: %s", err.Error())
+	}
+	var inode Structs.Inode
+	if err := Utilities.ReadObject(file, &inode, int64(superblock.S_inode_start+inodeNum*superblock.S_inode_size)); err != nil {
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "error leyendo inodo: %s", err.Error())
+	}
+
+	size := int(inode.I_size)
+	if offset < 0 || offset > size {
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "el offset %d está fuera del archivo (tamaño: %d bytes)", offset, size)
+	}
+	if length < 0 || offset+length > size {
+		length = size - offset
+	}
+
+	content, err := readFileRange(file, superblock, &inode, offset, length)
+	if err != nil {
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "error leyendo contenido del archivo: %s", err.Error())
+	}
+	return &FileRange{Path: filePath, Offset: offset, Length: len(content), Size: size, Content: content}, nil
+}
+
+// Write escribe content en el archivo desde offset, o al final si appendMode.
+// A diferencia de edit solo reescribe los bloques del rango y reserva los que
+// falten, sin liberar ni volver a repartir el resto del archivo.
+func Write(out io.Writer, session *Structs.UserSession, path string, offset int, appendMode bool, content []byte) (*FileRange, error) {
+	fmt.Fprintln(out, "======Inicio WRITE======")
+	fmt.Fprintf(out, "Ruta del archivo: %s\n", path)
+	if appendMode {
+		fmt.Fprintln(out, "Modo: agregar al final")
+	} else {
+		fmt.Fprintf(out, "Offset: %d\n", offset)
+	}
+	fmt.Fprintf(out, "Bytes a escribir: %d\n", len(content))
+
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
+	}
+
+	exists, inodeNum := findFileInDirectory(session.PartitionID, path)
+	if !exists {
+		fmt.Fprintf(out, "Error: El archivo '%s' no existe\n", path)
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo '%s' no existe", path)
+	}
+	if !hasWritePermission(session.PartitionID, inodeNum, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura sobre el archivo '%s'\n", path)
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura sobre el archivo '%s'", path)
+	}
+
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
+	if !exists {
+		fmt.Fprintln(out, "Error: Partición no encontrada")
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "Partición no encontrada")
+	}
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		fmt.Fprintln(out, "Error: No se pudo abrir el disco")
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el disco")
+	}
+	defer file.Close()
+
+	superblock, err := ReadSuperblock(session.PartitionID)
+	if err != nil {
+		fmt.Fprintln(out, "Error: No se pudo leer el superblock")
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock")
+	}
+	var inode Structs.Inode
+	inodePos := int64(superblock.S_inode_start + inodeNum*superblock.S_inode_size)
+	if err := Utilities.ReadObject(file, &inode, inodePos); err != nil {
+		fmt.Fprintln(out, "Error: No se pudo leer el inodo del archivo")
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el inodo del archivo")
+	}
+	if string(inode.I_type[:1]) == "0" {
+		fmt.Fprintf(out, "Error: '%s' es un directorio, no un archivo\n", path)
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "'%s' es un directorio, no un archivo", path)
+	}
+
+	if appendMode {
+		offset = int(inode.I_size)
+	}
+	if offset < 0 || offset > int(inode.I_size) {
+		fmt.Fprintf(out, "Error: El offset %d está fuera del archivo (tamaño: %d bytes)\n", offset, inode.I_size)
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El offset %d está fuera del archivo (tamaño: %d bytes)", offset, inode.I_size)
+	}
+	if offset+len(content) > MaxFileSize {
+		fmt.Fprintf(out, "Error: El archivo superaría el máximo de %d bytes\n", MaxFileSize)
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNoSpace, "El archivo superaría el máximo de %d bytes", MaxFileSize)
+	}
+
+	// Entrada del journaling (EXT3); si los datos no caben se guarda solo su tamaño
+	journalContent := fitJournalData(session.PartitionID, "write", path, fmt.Sprintf("offset=%d,", offset), content)
+
+	// Bloques nuevos que hacen falta (datos y apuntadores) si el archivo crece
+	oldBlocks := blocksForSize(int(inode.I_size))
+	newBlocks := blocksForSize(maxInt(int(inode.I_size), offset+len(content)))
+	requiredBlocks := newBlocks - oldBlocks + pointerBlocksFor(newBlocks) - pointerBlocksFor(oldBlocks)
+	if superblock.S_free_blocks_count < int32(requiredBlocks) {
+		fmt.Fprintf(out, "Error: No hay suficientes bloques libres (necesarios: %d, disponibles: %d)\n",
+			requiredBlocks, superblock.S_free_blocks_count)
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay suficientes bloques libres (necesarios: %d, disponibles: %d)", requiredBlocks, superblock.S_free_blocks_count)
+	}
+
+	if err := writeFileRange(file, superblock, &inode, offset, content); err != nil {
+		fmt.Fprintf(out, "Error: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "%s", err.Error())
+	}
+	stampInodeModified(&inode)
+	if err := Utilities.WriteObject(file, inode, inodePos); err != nil {
+		fmt.Fprintln(out, "Error: No se pudo actualizar el inodo")
+		fmt.Fprintln(out, "======FIN WRITE======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo actualizar el inodo")
+	}
+	if requiredBlocks > 0 {
+		if err := Utilities.WriteObject(file, superblock, superblockPosition(superblock)); err != nil {
+			fmt.Fprintln(out, "Error: No se pudo actualizar el superblock")
+			fmt.Fprintln(out, "======FIN WRITE======")
+			return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo actualizar el superblock: %v", err)
+		}
+	}
+
+	// Registrar en el journaling (EXT3)
+	writeToJournal(out, session.PartitionID, "write", path, journalContent)
+
+	fmt.Fprintln(out, "=== ARCHIVO ESCRITO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Bytes escritos: %d desde el offset %d\n", len(content), offset)
+	fmt.Fprintf(out, "Tamaño del archivo: %d bytes\n", inode.I_size)
+	fmt.Fprintf(out, "Bloques nuevos: %d\n", requiredBlocks)
+	fmt.Fprintln(out, "======FIN WRITE======")
+	return &FileRange{Path: path, Offset: offset, Length: len(content), Size: int(inode.I_size)}, nil
+}
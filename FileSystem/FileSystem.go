@@ -1,11 +1,13 @@
 package FileSystem
 
 import (
+	"bytes"
 	"proyecto1/Structs"
 	"proyecto1/Utilities"
 	"proyecto1/DiskManagement"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"strings"
 	"os"
 	"io/ioutil"
@@ -17,9 +19,14 @@ import (
 // ============================================================================
 
 // writeToJournal escribe una entrada en el journaling si el sistema es EXT3
-func writeToJournal(partitionID string, operation string, path string, content string) {
+func writeToJournal(out io.Writer, partitionID string, operation string, path string, content string) {
+	// Durante recovery las operaciones reproducidas no se vuelven a registrar
+	if isJournalSuspended(partitionID) {
+		return
+	}
+
 	// Buscar la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return // Si no está montada, no hacemos nada
 	}
@@ -68,62 +75,49 @@ func writeToJournal(partitionID string, operation string, path string, content s
 	superblockSize := int32(binary.Size(Structs.Superblock{}))
 	journalingStart := partition.Start + superblockSize
 	journalingSize := int32(binary.Size(Structs.Journaling{}))
-	const JOURNALING_CONSTANT = 50
-
-	// Buscar la primera entrada libre o la última entrada usada
-	var lastUsedIndex int32 = -1
-	for i := int32(0); i < JOURNALING_CONSTANT; i++ {
-		var journalEntry Structs.Journaling
-		journalPos := int64(journalingStart + i*journalingSize)
-		if err := Utilities.ReadObject(file, &journalEntry, journalPos); err != nil {
-			continue
-		}
-		if journalEntry.Count > 0 {
-			lastUsedIndex = i
+	capacity := journalCapacity(&superblock)
+
+	// Una ruta o contenido largo ocupa varios registros consecutivos. Una
+	// entrada más grande que el journaling no se guarda truncada: en su lugar
+	// va un solo registro journalOversized, que recovery reporta como no
+	// reproducido.
+	date := float32(time.Now().Unix())
+	records := encodeJournalEntry(operation, path, content, date)
+	if int32(len(records)) > capacity {
+		fmt.Fprintf(out, "Advertencia: la entrada de %s ocupa %d registros y el journaling solo tiene %d; solo se registró la operación\n", operation, len(records), capacity)
+		if len(path) > len(Structs.Information{}.Path) {
+			path = path[:len(Structs.Information{}.Path)]
+		}
+		records = encodeJournalEntry(journalOversized, path, fmt.Sprintf("op=%s,records=%d", operation, len(records)), date)
+	}
+
+	// Escribir los registros en la cola del journaling (circular); cada
+	// registro lleva el siguiente número de secuencia
+	for _, record := range records {
+		superblock.S_journal_seq++
+		record.Count = superblock.S_journal_seq
+		journalPos := int64(journalingStart + superblock.S_journal_tail*journalingSize)
+		if err := Utilities.WriteObject(file, record, journalPos); err != nil {
+			fmt.Fprintf(out, "Advertencia: Error escribiendo entrada al journaling: %v\n", err)
+			return
 		}
+		superblock.S_journal_tail = (superblock.S_journal_tail + 1) % capacity
 	}
 
-	// La nueva entrada será después de la última usada
-	newIndex := lastUsedIndex + 1
-	if newIndex >= JOURNALING_CONSTANT {
-		// Si ya se llenó el journaling, sobrescribir desde el inicio (circular)
-		newIndex = 0
+	// Con el journaling lleno, el registro más antiguo es el siguiente a sobrescribir
+	if superblock.S_journal_seq >= capacity {
+		superblock.S_journal_head = superblock.S_journal_tail
 	}
 
-	// Crear la nueva entrada de journaling
-	var newJournal Structs.Journaling
-	newJournal.Count = newIndex + 1 // Contador incremental
-	
-	// Copiar operación (máximo 10 caracteres)
-	if len(operation) > 10 {
-		operation = operation[:10]
-	}
-	copy(newJournal.Content.Operation[:], operation)
-	
-	// Copiar ruta (máximo 32 caracteres)
-	if len(path) > 32 {
-		path = path[:32]
-	}
-	copy(newJournal.Content.Path[:], path)
-	
-	// Copiar contenido (máximo 64 caracteres)
-	if len(content) > 64 {
-		content = content[:64]
-	}
-	copy(newJournal.Content.Content[:], content)
-	
-	// Asignar timestamp actual como float32
-	newJournal.Content.Date = float32(time.Now().Unix())
-
-	// Escribir la entrada al journaling
-	journalPos := int64(journalingStart + newIndex*journalingSize)
-	if err := Utilities.WriteObject(file, newJournal, journalPos); err != nil {
-		fmt.Printf("Advertencia: Error escribiendo entrada al journaling: %v\n", err)
+	if err := Utilities.WriteObject(file, superblock, int64(partition.Start)); err != nil {
+		fmt.Fprintf(out, "Advertencia: Error actualizando el superblock del journaling: %v\n", err)
 	}
 }
 
-func Mkfs(id string, type_ string, filesystem string){
-	fmt.Println("======Inicio MKFS======")
+// Mkfs formatea la partición. journalSize es el número de registros del
+// journaling (solo EXT3)
+func Mkfs(out io.Writer, id string, type_ string, filesystem string, journalSize int32) error {
+	fmt.Fprintln(out, "======Inicio MKFS======")
 	
 	// Determinar el tipo de sistema de archivos
 	var fsType string
@@ -136,32 +130,38 @@ func Mkfs(id string, type_ string, filesystem string){
 		fsTypeNum = 2
 	}
 	
-	fmt.Printf("Creando sistema de archivos %s en partición ID: %s\n", fsType, id)
-	fmt.Printf("Tipo de formateo: %s\n", type_)
+	fmt.Fprintf(out, "Creando sistema de archivos %s en partición ID: %s\n", fsType, id)
+	fmt.Fprintf(out, "Tipo de formateo: %s\n", type_)
+
+	// Validar el tamaño del journaling
+	if fsTypeNum == 3 && journalSize <= 0 {
+		fmt.Fprintf(out, "Error: El tamaño del journaling debe ser mayor a 0 (recibido: %d)\n", journalSize)
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El tamaño del journaling debe ser mayor a 0 (recibido: %d)", journalSize)
+	}
 	
 	// Verificar que la partición esté montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[id]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
 	if !exists {
-		fmt.Printf("Error: La partición con ID '%s' no está montada\n", id)
-		fmt.Println("Use el comando 'mounted' para ver las particiones disponibles")
-		return
+		fmt.Fprintf(out, "Error: La partición con ID '%s' no está montada\n", id)
+		fmt.Fprintln(out, "Use el comando 'mounted' para ver las particiones disponibles")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "La partición con ID '%s' no está montada", id)
 	}
 
-	fmt.Printf("Partición encontrada: %s en disco: %s\n", mountedPartition.PartitionName, mountedPartition.Path)
+	fmt.Fprintf(out, "Partición encontrada: %s en disco: %s\n", mountedPartition.PartitionName, mountedPartition.Path)
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(mountedPartition.Path)
 	if err != nil {
-		fmt.Println("Error abriendo archivo del disco:", err)
-		return
+		fmt.Fprintln(out, "Error abriendo archivo del disco:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo del disco: %v", err)
 	}
 	defer file.Close()
 
 	var tempMBR Structs.MBR
 	// Leer MBR del archivo
 	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
-		fmt.Println("Error leyendo MBR:", err)
-		return
+		fmt.Fprintln(out, "Error leyendo MBR:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo MBR: %v", err)
 	}
 
 	// Buscar la partición por ID para obtener sus datos
@@ -176,8 +176,8 @@ func Mkfs(id string, type_ string, filesystem string){
 		// Para particiones lógicas, necesitamos leer el EBR
 		var tempEBR Structs.EBR
 		if err := Utilities.ReadObject(file, &tempEBR, int64(mountedPartition.EBRPosition)); err != nil {
-			fmt.Println("Error leyendo EBR:", err)
-			return
+			fmt.Fprintln(out, "Error leyendo EBR:", err)
+			return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo EBR: %v", err)
 		}
 		// Crear una partición temporal con los datos del EBR
 		tempPartition := Structs.Partition{
@@ -193,7 +193,7 @@ func Mkfs(id string, type_ string, filesystem string){
 		partition = &tempPartition
 	}
 
-	fmt.Printf("Datos de la partición - Inicio: %d, Tamaño: %d bytes\n", partition.Start, partition.Size)
+	fmt.Fprintf(out, "Datos de la partición - Inicio: %d, Tamaño: %d bytes\n", partition.Start, partition.Size)
 
 	// Calcular el número de estructuras necesarias
 	superblockSize := int32(binary.Size(Structs.Superblock{}))
@@ -209,9 +209,7 @@ func Mkfs(id string, type_ string, filesystem string){
 		// Despejando n:
 		// n = (tamaño_particion - sizeof(superblock)) / (sizeof(Journaling) + 1 + 3 + sizeof(inodos) + 3*sizeof(block))
 		
-		// Constante de journaling = 50
-		const JOURNALING_CONSTANT = 50
-		journalingTotalSize := JOURNALING_CONSTANT * journalingSize
+		journalingTotalSize := journalSize * journalingSize
 		
 		// Espacio disponible (descontando superblock y journaling)
 		availableSpace := partition.Size - superblockSize - journalingTotalSize
@@ -220,24 +218,24 @@ func Mkfs(id string, type_ string, filesystem string){
 		structureSize := 1 + inodeSize + 3 + 3*blockSize
 		n = availableSpace / structureSize
 		
-		fmt.Printf("Calculando estructuras para EXT3 con journaling (constante=%d)...\n", JOURNALING_CONSTANT)
+		fmt.Fprintf(out, "Calculando estructuras para EXT3 con journaling (%d entradas)...\n", journalSize)
 	} else {
 		// Cálculo para EXT2 (sin journaling)
 		availableSpace := partition.Size - superblockSize
 		structureSize := 1 + inodeSize + 3 + 3*blockSize
 		n = availableSpace / structureSize
 		
-		fmt.Printf("Calculando estructuras para EXT2...\n")
+		fmt.Fprintf(out, "Calculando estructuras para EXT2...\n")
 	}
 
 	if n <= 0 {
-		fmt.Printf("Error: La partición es demasiado pequeña para crear un sistema de archivos\n")
-		fmt.Printf("Tamaño mínimo requerido: %d bytes\n", partition.Size)
-		return
+		fmt.Fprintf(out, "Error: La partición es demasiado pequeña para crear un sistema de archivos\n")
+		fmt.Fprintf(out, "Tamaño mínimo requerido: %d bytes\n", partition.Size)
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La partición es demasiado pequeña para crear un sistema de archivos")
 	}
 
-	fmt.Printf("Número de inodos calculado: %d\n", n)
-	fmt.Printf("Número de bloques calculado: %d\n", 3*n)
+	fmt.Fprintf(out, "Número de inodos calculado: %d\n", n)
+	fmt.Fprintf(out, "Número de bloques calculado: %d\n", 3*n)
 
 	// Crear y configurar el superblock
 	var superblock Structs.Superblock
@@ -249,7 +247,7 @@ func Mkfs(id string, type_ string, filesystem string){
 	superblock.S_free_blocks_count = 3*n - 2  // Reservamos bloques 0 y 1
 	
 	// Configurar fechas
-	currentDate := "17/10/2025"
+	currentDate := Utilities.CurrentTimestamp()
 	copy(superblock.S_mtime[:], currentDate)
 	copy(superblock.S_umtime[:], currentDate)
 	superblock.S_mnt_count = 1
@@ -262,23 +260,22 @@ func Mkfs(id string, type_ string, filesystem string){
 
 	// Calcular posiciones de las estructuras según el tipo de sistema de archivos
 	var journalingStart int32
-	const JOURNALING_CONSTANT = 50
 	
 	if fsTypeNum == 3 {
 		// EXT3: Superblock, Journaling, Bitmap inodos, Bitmap bloques, Inodos, Bloques
 		journalingStart = partition.Start + superblockSize
-		superblock.S_bm_inode_start = journalingStart + (JOURNALING_CONSTANT * journalingSize)
+		superblock.S_bm_inode_start = journalingStart + (journalSize * journalingSize)
 		superblock.S_bm_block_start = superblock.S_bm_inode_start + n
 		superblock.S_inode_start = superblock.S_bm_block_start + 3*n  
 		superblock.S_block_start = superblock.S_inode_start + n*inodeSize
 		
-		fmt.Println("=== ESTRUCTURA DEL SISTEMA DE ARCHIVOS EXT3 ===")
-		fmt.Printf("Superblock:        posición %d (tamaño: %d bytes)\n", partition.Start, superblockSize)
-		fmt.Printf("Journaling:        posición %d (tamaño: %d entradas)\n", journalingStart, JOURNALING_CONSTANT)
-		fmt.Printf("Bitmap inodos:     posición %d (tamaño: %d bytes)\n", superblock.S_bm_inode_start, n)
-		fmt.Printf("Bitmap bloques:    posición %d (tamaño: %d bytes)\n", superblock.S_bm_block_start, 3*n)
-		fmt.Printf("Tabla de inodos:   posición %d (tamaño: %d bytes)\n", superblock.S_inode_start, n*inodeSize)
-		fmt.Printf("Bloques de datos:  posición %d (tamaño: %d bytes)\n", superblock.S_block_start, 3*n*blockSize)
+		fmt.Fprintln(out, "=== ESTRUCTURA DEL SISTEMA DE ARCHIVOS EXT3 ===")
+		fmt.Fprintf(out, "Superblock:        posición %d (tamaño: %d bytes)\n", partition.Start, superblockSize)
+		fmt.Fprintf(out, "Journaling:        posición %d (tamaño: %d entradas)\n", journalingStart, journalSize)
+		fmt.Fprintf(out, "Bitmap inodos:     posición %d (tamaño: %d bytes)\n", superblock.S_bm_inode_start, n)
+		fmt.Fprintf(out, "Bitmap bloques:    posición %d (tamaño: %d bytes)\n", superblock.S_bm_block_start, 3*n)
+		fmt.Fprintf(out, "Tabla de inodos:   posición %d (tamaño: %d bytes)\n", superblock.S_inode_start, n*inodeSize)
+		fmt.Fprintf(out, "Bloques de datos:  posición %d (tamaño: %d bytes)\n", superblock.S_block_start, 3*n*blockSize)
 	} else {
 		// EXT2: Superblock, Bitmap inodos, Bitmap bloques, Inodos, Bloques
 		superblock.S_bm_inode_start = partition.Start + superblockSize
@@ -286,27 +283,29 @@ func Mkfs(id string, type_ string, filesystem string){
 		superblock.S_inode_start = superblock.S_bm_block_start + 3*n  
 		superblock.S_block_start = superblock.S_inode_start + n*inodeSize
 		
-		fmt.Println("=== ESTRUCTURA DEL SISTEMA DE ARCHIVOS EXT2 ===")
-		fmt.Printf("Superblock:        posición %d (tamaño: %d bytes)\n", partition.Start, superblockSize)
-		fmt.Printf("Bitmap inodos:     posición %d (tamaño: %d bytes)\n", superblock.S_bm_inode_start, n)
-		fmt.Printf("Bitmap bloques:    posición %d (tamaño: %d bytes)\n", superblock.S_bm_block_start, 3*n)
-		fmt.Printf("Tabla de inodos:   posición %d (tamaño: %d bytes)\n", superblock.S_inode_start, n*inodeSize)
-		fmt.Printf("Bloques de datos:  posición %d (tamaño: %d bytes)\n", superblock.S_block_start, 3*n*blockSize)
+		fmt.Fprintln(out, "=== ESTRUCTURA DEL SISTEMA DE ARCHIVOS EXT2 ===")
+		fmt.Fprintf(out, "Superblock:        posición %d (tamaño: %d bytes)\n", partition.Start, superblockSize)
+		fmt.Fprintf(out, "Bitmap inodos:     posición %d (tamaño: %d bytes)\n", superblock.S_bm_inode_start, n)
+		fmt.Fprintf(out, "Bitmap bloques:    posición %d (tamaño: %d bytes)\n", superblock.S_bm_block_start, 3*n)
+		fmt.Fprintf(out, "Tabla de inodos:   posición %d (tamaño: %d bytes)\n", superblock.S_inode_start, n*inodeSize)
+		fmt.Fprintf(out, "Bloques de datos:  posición %d (tamaño: %d bytes)\n", superblock.S_block_start, 3*n*blockSize)
 	}
 
 	// Formatear completamente la partición con ceros
 	if type_ == "full" {
-		fmt.Println("Realizando formateo completo...")
+		fmt.Fprintln(out, "Realizando formateo completo...")
 		var zeroByte byte = 0
 		for i := int32(0); i < partition.Size; i++ {
 			Utilities.WriteObject(file, zeroByte, int64(partition.Start+i))
 		}
-		fmt.Println("Formateo completo terminado.")
+		fmt.Fprintln(out, "Formateo completo terminado.")
+	} else {
+		fmt.Fprintln(out, "Realizando formateo rápido: solo se reescriben el superblock, los bitmaps y la estructura raíz")
 	}
 
 	// Inicializar journaling si es EXT3
 	if fsTypeNum == 3 {
-		fmt.Println("Inicializando journaling...")
+		fmt.Fprintln(out, "Inicializando journaling...")
 		var emptyJournal Structs.Journaling
 		emptyJournal.Count = 0
 		// Inicializar el contenido vacío
@@ -321,13 +320,13 @@ func Mkfs(id string, type_ string, filesystem string){
 		}
 		emptyJournal.Content.Date = 0.0
 		
-		// Escribir 50 entradas de journaling vacías
-		for i := int32(0); i < JOURNALING_CONSTANT; i++ {
+		// Escribir las entradas de journaling vacías
+		for i := int32(0); i < journalSize; i++ {
 			if err := Utilities.WriteObject(file, emptyJournal, int64(journalingStart+i*journalingSize)); err != nil {
-				fmt.Printf("Error inicializando journaling en posición %d: %v\n", i, err)
+				fmt.Fprintf(out, "Error inicializando journaling en posición %d: %v\n", i, err)
 			}
 		}
-		fmt.Printf("Journaling inicializado con %d entradas vacías\n", JOURNALING_CONSTANT)
+		fmt.Fprintf(out, "Journaling inicializado con %d entradas vacías\n", journalSize)
 		
 		// Escribir la primera entrada del journal con la operación mkfs
 		var mkfsJournal Structs.Journaling
@@ -339,40 +338,105 @@ func Mkfs(id string, type_ string, filesystem string){
 		mkfsJournal.Content.Date = 23102025.0 // Fecha actual
 		
 		if err := Utilities.WriteObject(file, mkfsJournal, int64(journalingStart)); err != nil {
-			fmt.Printf("Error escribiendo entrada mkfs al journal: %v\n", err)
+			fmt.Fprintf(out, "Error escribiendo entrada mkfs al journal: %v\n", err)
 		} else {
-			fmt.Println("Entrada 'mkfs' registrada en el journaling")
+			fmt.Fprintln(out, "Entrada 'mkfs' registrada en el journaling")
 		}
+
+		// La entrada mkfs ocupa el primer registro (secuencia 1)
+		superblock.S_journal_count = journalSize
+		superblock.S_journal_head = 0
+		superblock.S_journal_tail = 1 % journalSize
+		superblock.S_journal_seq = 1
+	}
+
+	// Inicializar bitmaps, tablas y la estructura inicial (raíz y users.txt)
+	if err := writeInitialStructure(out, file, &superblock, partition.Start, currentDate, type_ == "full"); err != nil {
+		fmt.Fprintln(out, "Error escribiendo superblock:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo superblock: %v", err)
+	}
+
+	fmt.Fprintf(out, "=== SISTEMA DE ARCHIVOS %s CREADO EXITOSAMENTE ===\n", fsType)
+	fmt.Fprintf(out, "Partición ID: %s\n", id)
+	fmt.Fprintf(out, "Sistema: %s\n", fsType)
+	fmt.Fprintf(out, "Inodos totales: %d\n", superblock.S_inodes_count)
+	fmt.Fprintf(out, "Inodos disponibles: %d\n", superblock.S_free_inodes_count)
+	fmt.Fprintf(out, "Bloques totales: %d\n", superblock.S_blocks_count)
+	fmt.Fprintf(out, "Bloques disponibles: %d\n", superblock.S_free_blocks_count)
+	
+	if fsTypeNum == 3 {
+		fmt.Fprintf(out, "Journaling: %d entradas inicializadas\n", journalSize)
+	}
+	
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Estructura inicial:")
+	fmt.Fprintln(out, "  / (directorio raíz)")
+	fmt.Fprintln(out, "  ├── . (enlace al directorio actual)")
+	fmt.Fprintln(out, "  ├── .. (enlace al directorio padre)")
+	fmt.Fprintln(out, "  └── users.txt (archivo de usuarios y grupos)")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "Archivo users.txt contiene:")
+	fmt.Fprintln(out, "  1,G,root        <- Grupo root (ID=1)")
+	fmt.Fprintln(out, "  1,U,root,root,<hash> <- Usuario root (ID=1, Grupo=root, Contraseña=123 cifrada con bcrypt)")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "El usuario root tiene permisos completos para modificar el sistema.")
+	fmt.Fprintln(out, "======FIN MKFS======")
+	return nil
+}
+
+// writeInitialStructure limpia bitmaps, inodos y bloques y crea la estructura
+// inicial (directorio raíz y users.txt). La usan mkfs y recovery. Con
+// clearTables en false (mkfs -type=fast) la tabla de inodos y los bloques no
+// se limpian: basta con los bitmaps para que queden libres.
+func writeInitialStructure(out io.Writer, file *os.File, superblock *Structs.Superblock, partitionStart int32, currentDate string, clearTables bool) error {
+	inodeSize := superblock.S_inode_size
+	blockSize := superblock.S_block_size
+
+	// Contenido del archivo users.txt según especificaciones; la contraseña
+	// de root se guarda cifrada como las demás (hash bcrypt)
+	rootHash, err := hashPassword("123")
+	if err != nil {
+		return err
 	}
+	usersContent := "1,G,root\n1,U,root,root," + rootHash + "\n"
+	usersBlocks := int32(blocksForSize(len(usersContent)))
+
+	// Solo quedan ocupados los inodos 0 y 1, el bloque 0 y los de users.txt
+	superblock.S_free_inodes_count = superblock.S_inodes_count - 2
+	superblock.S_free_blocks_count = superblock.S_blocks_count - 1 - usersBlocks
+	superblock.S_fist_ino = 2
+	superblock.S_first_blo = 1 + usersBlocks
 
 	// Inicializar bitmaps con ceros
-	fmt.Println("Inicializando bitmaps...")
-	for i := int32(0); i < n; i++ {
+	fmt.Fprintln(out, "Inicializando bitmaps...")
+	for i := int32(0); i < superblock.S_inodes_count; i++ {
 		Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_inode_start+i))
 	}
-	for i := int32(0); i < 3*n; i++ {
+	for i := int32(0); i < superblock.S_blocks_count; i++ {
 		Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+i))
 	}
 
-	// Inicializar tabla de inodos vacía
-	fmt.Println("Inicializando tabla de inodos...")
-	var emptyInode Structs.Inode
-	for i := int32(0); i < 15; i++ {
-		emptyInode.I_block[i] = -1
-	}
-	for i := int32(0); i < n; i++ {
-		Utilities.WriteObject(file, emptyInode, int64(superblock.S_inode_start+i*inodeSize))
-	}
+	if clearTables {
+		// Inicializar tabla de inodos vacía
+		fmt.Fprintln(out, "Inicializando tabla de inodos...")
+		var emptyInode Structs.Inode
+		for i := int32(0); i < 15; i++ {
+			emptyInode.I_block[i] = -1
+		}
+		for i := int32(0); i < superblock.S_inodes_count; i++ {
+			Utilities.WriteObject(file, emptyInode, int64(superblock.S_inode_start+i*inodeSize))
+		}
 
-	// Inicializar bloques de datos vacíos
-	fmt.Println("Inicializando bloques de datos...")
-	var emptyBlock Structs.Fileblock
-	for i := int32(0); i < 3*n; i++ {
-		Utilities.WriteObject(file, emptyBlock, int64(superblock.S_block_start+i*blockSize))
+		// Inicializar bloques de datos vacíos
+		fmt.Fprintln(out, "Inicializando bloques de datos...")
+		var emptyBlock Structs.Fileblock
+		for i := int32(0); i < superblock.S_blocks_count; i++ {
+			Utilities.WriteObject(file, emptyBlock, int64(superblock.S_block_start+i*blockSize))
+		}
 	}
 
 	// Crear estructura inicial del sistema de archivos
-	fmt.Println("Creando estructura inicial del sistema de archivos...")
+	fmt.Fprintln(out, "Creando estructura inicial del sistema de archivos...")
 	
 	// INODO 0: Directorio raíz
 	var rootInode Structs.Inode
@@ -404,8 +468,6 @@ func Mkfs(id string, type_ string, filesystem string){
 	usersInode.I_uid = 1    // Usuario root
 	usersInode.I_gid = 1    // Grupo root
 	
-	// Contenido del archivo users.txt según especificaciones
-	usersContent := "1,G,root\n1,U,root,root,123\n"
 	usersInode.I_size = int32(len(usersContent))
 	
 	copy(usersInode.I_atime[:], currentDate)
@@ -416,28 +478,26 @@ func Mkfs(id string, type_ string, filesystem string){
 	for i := 0; i < 15; i++ {
 		usersInode.I_block[i] = -1
 	}
-	usersInode.I_block[0] = 1 // Apunta al bloque 1
-
-	// BLOQUE 1: Contenido del archivo users.txt
-	var usersFileBlock Structs.Fileblock
-	copy(usersFileBlock.B_content[:len(usersContent)], usersContent)
+	for i := int32(0); i < usersBlocks; i++ {
+		usersInode.I_block[i] = 1 + i // Bloques 1, 2...
+	}
 
 	// Escribir todas las estructuras al disco
-	fmt.Println("Escribiendo estructuras al disco...")
+	fmt.Fprintln(out, "Escribiendo estructuras al disco...")
 
 	// Escribir superblock
-	if err := Utilities.WriteObject(file, superblock, int64(partition.Start)); err != nil {
-		fmt.Println("Error escribiendo superblock:", err)
-		return
+	if err := Utilities.WriteObject(file, *superblock, int64(partitionStart)); err != nil {
+		return err
 	}
 
 	// Marcar inodos 0 y 1 como ocupados en el bitmap
 	Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_inode_start+0))
 	Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_inode_start+1))
 
-	// Marcar bloques 0 y 1 como ocupados en el bitmap  
-	Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+0))
-	Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+1))
+	// Marcar el bloque 0 y los de users.txt como ocupados en el bitmap
+	for i := int32(0); i <= usersBlocks; i++ {
+		Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+i))
+	}
 
 	// Escribir inodos
 	Utilities.WriteObject(file, rootInode, int64(superblock.S_inode_start))
@@ -445,39 +505,19 @@ func Mkfs(id string, type_ string, filesystem string){
 
 	// Escribir bloques
 	Utilities.WriteObject(file, rootDirBlock, int64(superblock.S_block_start))
-	Utilities.WriteObject(file, usersFileBlock, int64(superblock.S_block_start+blockSize))
-
-	fmt.Printf("=== SISTEMA DE ARCHIVOS %s CREADO EXITOSAMENTE ===\n", fsType)
-	fmt.Printf("Partición ID: %s\n", id)
-	fmt.Printf("Sistema: %s\n", fsType)
-	fmt.Printf("Inodos totales: %d\n", superblock.S_inodes_count)
-	fmt.Printf("Inodos disponibles: %d\n", superblock.S_free_inodes_count)
-	fmt.Printf("Bloques totales: %d\n", superblock.S_blocks_count)
-	fmt.Printf("Bloques disponibles: %d\n", superblock.S_free_blocks_count)
-	
-	if fsTypeNum == 3 {
-		fmt.Printf("Journaling: %d entradas inicializadas\n", JOURNALING_CONSTANT)
+	for i := int32(0); i < usersBlocks; i++ {
+		var usersFileBlock Structs.Fileblock
+		copy(usersFileBlock.B_content[:], usersContent[i*blockSize:])
+		Utilities.WriteObject(file, usersFileBlock, int64(superblock.S_block_start+(1+i)*blockSize))
 	}
-	
-	fmt.Println("")
-	fmt.Println("Estructura inicial:")
-	fmt.Println("  / (directorio raíz)")
-	fmt.Println("  ├── . (enlace al directorio actual)")
-	fmt.Println("  ├── .. (enlace al directorio padre)")
-	fmt.Println("  └── users.txt (archivo de usuarios y grupos)")
-	fmt.Println("")
-	fmt.Println("Archivo users.txt contiene:")
-	fmt.Println("  1,G,root        <- Grupo root (ID=1)")
-	fmt.Println("  1,U,root,root,123 <- Usuario root (ID=1, Grupo=root, Contraseña=123)")
-	fmt.Println("")
-	fmt.Println("El usuario root tiene permisos completos para modificar el sistema.")
-	fmt.Println("======FIN MKFS======")
+
+	return nil
 }
 
 // Función para leer el superblock de una partición
 func ReadSuperblock(id string) (*Structs.Superblock, error) {
 	// Buscar la partición montada por ID
-	mountedPartition, exists := DiskManagement.MountedPartitions[id]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
 	if !exists {
 		return nil, fmt.Errorf("partición con ID '%s' no está montada", id)
 	}
@@ -527,69 +567,70 @@ func ReadSuperblock(id string) (*Structs.Superblock, error) {
 }
 
 // Función para mostrar información del sistema de archivos
-func ShowFileSystemInfo(id string) {
-	fmt.Println("=== INFORMACIÓN DEL SISTEMA DE ARCHIVOS ===")
+func ShowFileSystemInfo(out io.Writer, id string) error {
+	fmt.Fprintln(out, "=== INFORMACIÓN DEL SISTEMA DE ARCHIVOS ===")
 	
 	superblock, err := ReadSuperblock(id)
 	if err != nil {
-		fmt.Println("Error leyendo superblock:", err)
-		return
-	}
-
-	fmt.Printf("Tipo de sistema: EXT%d\n", superblock.S_filesystem_type)
-	fmt.Printf("Número mágico: 0x%X\n", superblock.S_magic)
-	fmt.Printf("Total de inodos: %d\n", superblock.S_inodes_count)
-	fmt.Printf("Total de bloques: %d\n", superblock.S_blocks_count)
-	fmt.Printf("Inodos libres: %d\n", superblock.S_free_inodes_count)
-	fmt.Printf("Bloques libres: %d\n", superblock.S_free_blocks_count)
-	fmt.Printf("Tamaño de inodo: %d bytes\n", superblock.S_inode_size)
-	fmt.Printf("Tamaño de bloque: %d bytes\n", superblock.S_block_size)
-	fmt.Printf("Fecha de montaje: %s\n", string(superblock.S_mtime[:]))
-	fmt.Printf("Fecha de desmontaje: %s\n", string(superblock.S_umtime[:]))
-	fmt.Printf("Contador de montajes: %d\n", superblock.S_mnt_count)
-	
-	fmt.Println("\n=== UBICACIONES DE ESTRUCTURAS ===")
-	fmt.Printf("Bitmap de inodos: posición %d\n", superblock.S_bm_inode_start)
-	fmt.Printf("Bitmap de bloques: posición %d\n", superblock.S_bm_block_start)
-	fmt.Printf("Tabla de inodos: posición %d\n", superblock.S_inode_start)
-	fmt.Printf("Bloques de datos: posición %d\n", superblock.S_block_start)
+		fmt.Fprintln(out, "Error leyendo superblock:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo superblock: %v", err)
+	}
+
+	fmt.Fprintf(out, "Tipo de sistema: EXT%d\n", superblock.S_filesystem_type)
+	fmt.Fprintf(out, "Número mágico: 0x%X\n", superblock.S_magic)
+	fmt.Fprintf(out, "Total de inodos: %d\n", superblock.S_inodes_count)
+	fmt.Fprintf(out, "Total de bloques: %d\n", superblock.S_blocks_count)
+	fmt.Fprintf(out, "Inodos libres: %d\n", superblock.S_free_inodes_count)
+	fmt.Fprintf(out, "Bloques libres: %d\n", superblock.S_free_blocks_count)
+	fmt.Fprintf(out, "Tamaño de inodo: %d bytes\n", superblock.S_inode_size)
+	fmt.Fprintf(out, "Tamaño de bloque: %d bytes\n", superblock.S_block_size)
+	fmt.Fprintf(out, "Fecha de montaje: %s\n", string(superblock.S_mtime[:]))
+	fmt.Fprintf(out, "Fecha de desmontaje: %s\n", string(superblock.S_umtime[:]))
+	fmt.Fprintf(out, "Contador de montajes: %d\n", superblock.S_mnt_count)
+	
+	fmt.Fprintln(out, "\n=== UBICACIONES DE ESTRUCTURAS ===")
+	fmt.Fprintf(out, "Bitmap de inodos: posición %d\n", superblock.S_bm_inode_start)
+	fmt.Fprintf(out, "Bitmap de bloques: posición %d\n", superblock.S_bm_block_start)
+	fmt.Fprintf(out, "Tabla de inodos: posición %d\n", superblock.S_inode_start)
+	fmt.Fprintf(out, "Bloques de datos: posición %d\n", superblock.S_block_start)
+	return nil
 }
 
 // Función para listar el contenido del directorio raíz
-func ListRootDirectory(id string) {
-	fmt.Println("=== CONTENIDO DEL DIRECTORIO RAÍZ ===")
+func ListRootDirectory(out io.Writer, id string) error {
+	fmt.Fprintln(out, "=== CONTENIDO DEL DIRECTORIO RAÍZ ===")
 	
 	// Buscar la partición montada por ID
-	mountedPartition, exists := DiskManagement.MountedPartitions[id]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
 	if !exists {
-		fmt.Printf("Error: La partición con ID '%s' no está montada\n", id)
-		fmt.Println("Use el comando 'mounted' para ver las particiones disponibles")
-		return
+		fmt.Fprintf(out, "Error: La partición con ID '%s' no está montada\n", id)
+		fmt.Fprintln(out, "Use el comando 'mounted' para ver las particiones disponibles")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "La partición con ID '%s' no está montada", id)
 	}
 	
 	// Abrir archivo del disco usando la ruta de la partición montada
 	file, err := Utilities.OpenFile(mountedPartition.Path)
 	if err != nil {
-		fmt.Println("Error abriendo archivo:", err)
-		return
+		fmt.Fprintln(out, "Error abriendo archivo:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo: %v", err)
 	}
 	defer file.Close()
 
 	superblock, err := ReadSuperblock(id)
 	if err != nil {
-		fmt.Println("Error leyendo superblock:", err)
-		return
+		fmt.Fprintln(out, "Error leyendo superblock:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo superblock: %v", err)
 	}
 
 	// Leer el inodo 0 (directorio raíz)
 	var rootInode Structs.Inode
 	inodePos := int64(superblock.S_inode_start)
 	if err := Utilities.ReadObject(file, &rootInode, inodePos); err != nil {
-		fmt.Println("Error leyendo inodo raíz:", err)
-		return
+		fmt.Fprintln(out, "Error leyendo inodo raíz:", err)
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo inodo raíz: %v", err)
 	}
 
-	fmt.Printf("Inodo raíz - UID: %d, GID: %d, Tamaño: %d, Tipo: %s\n", 
+	fmt.Fprintf(out, "Inodo raíz - UID: %d, GID: %d, Tamaño: %d, Tipo: %s\n", 
 		rootInode.I_uid, rootInode.I_gid, rootInode.I_size, string(rootInode.I_type[:]))
 
 	// Leer el bloque 0 (contenido del directorio raíz)
@@ -597,162 +638,177 @@ func ListRootDirectory(id string) {
 		var folderBlock Structs.Folderblock
 		blockPos := int64(superblock.S_block_start + rootInode.I_block[0]*int32(binary.Size(Structs.Fileblock{})))
 		if err := Utilities.ReadObject(file, &folderBlock, blockPos); err != nil {
-			fmt.Println("Error leyendo bloque de directorio:", err)
-			return
+			fmt.Fprintln(out, "Error leyendo bloque de directorio:", err)
+			return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo bloque de directorio: %v", err)
 		}
 
-		fmt.Println("\nContenido del directorio:")
+		fmt.Fprintln(out, "\nContenido del directorio:")
 		for i := 0; i < 4; i++ {
 			if folderBlock.B_content[i].B_inodo != -1 {
-				name := strings.TrimRight(string(folderBlock.B_content[i].B_name[:]), "\x00")
+				name := EntryName(file, superblock, folderBlock.B_content[i])
 				if name != "" {
-					fmt.Printf("  %s -> inodo %d\n", name, folderBlock.B_content[i].B_inodo)
+					fmt.Fprintf(out, "  %s -> inodo %d\n", name, folderBlock.B_content[i].B_inodo)
 				}
 			}
 		}
 	}
+	return nil
 }
 
 // ============================================================================
 // FUNCIONES DE AUTENTICACIÓN Y SESIONES
 // ============================================================================
 
-// Variable global para la sesión activa (solo una sesión a la vez)
-var CurrentSession *Structs.UserSession = nil
-
-// Login - Iniciar sesión en el sistema
-func Login(user string, pass string, id string) {
-	fmt.Println("======Inicio LOGIN======")
-	fmt.Printf("Usuario: %s\n", user)
-	fmt.Printf("Partición ID: %s\n", id)
+// Login - Iniciar sesión en el sistema. Retorna la nueva sesión ya registrada
+// en el almacén de sesiones; current es la sesión que ya tenía quien llama (o nil)
+func Login(out io.Writer, current *Structs.UserSession, user string, pass string, id string) (*Structs.UserSession, error) {
+	fmt.Fprintln(out, "======Inicio LOGIN======")
+	fmt.Fprintf(out, "Usuario: %s\n", user)
+	fmt.Fprintf(out, "Partición ID: %s\n", id)
 	
 	// Verificar que no haya una sesión activa
-	if CurrentSession != nil && CurrentSession.IsActive {
-		fmt.Printf("Error: Ya hay una sesión activa del usuario '%s' en la partición '%s'\n", 
-			CurrentSession.Username, CurrentSession.PartitionID)
-		fmt.Println("Debe cerrar sesión con 'logout' antes de iniciar una nueva sesión")
-		fmt.Println("======FIN LOGIN======")
-		return
+	if IsUserLoggedIn(current) {
+		fmt.Fprintf(out, "Error: Ya hay una sesión activa del usuario '%s' en la partición '%s'\n", 
+			current.Username, current.PartitionID)
+		fmt.Fprintln(out, "Debe cerrar sesión con 'logout' antes de iniciar una nueva sesión")
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrAlreadyExists, "Ya hay una sesión activa del usuario '%s' en la partición '%s'", current.Username, current.PartitionID)
 	}
 
 	// Validar parámetros obligatorios
 	if user == "" {
-		fmt.Println("Error: El parámetro -user es obligatorio")
-		fmt.Println("Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN LOGIN======")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -user es obligatorio")
+		fmt.Fprintln(out, "Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -user es obligatorio")
 	}
 	if pass == "" {
-		fmt.Println("Error: El parámetro -pass es obligatorio")
-		fmt.Println("Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN LOGIN======")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -pass es obligatorio")
+		fmt.Fprintln(out, "Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -pass es obligatorio")
 	}
 	if id == "" {
-		fmt.Println("Error: El parámetro -id es obligatorio")
-		fmt.Println("Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN LOGIN======")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -id es obligatorio")
+		fmt.Fprintln(out, "Uso: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -id es obligatorio")
 	}
 
 	// Verificar que la partición esté montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[id]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
 	if !exists {
-		fmt.Printf("Error: La partición con ID '%s' no está montada\n", id)
-		fmt.Println("Use el comando 'mounted' para ver las particiones disponibles")
-		fmt.Println("======FIN LOGIN======")
-		return
+		fmt.Fprintf(out, "Error: La partición con ID '%s' no está montada\n", id)
+		fmt.Fprintln(out, "Use el comando 'mounted' para ver las particiones disponibles")
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "La partición con ID '%s' no está montada", id)
 	}
 
-	fmt.Printf("Partición encontrada: %s en disco: %s\n", mountedPartition.PartitionName, mountedPartition.Path)
+	fmt.Fprintf(out, "Partición encontrada: %s en disco: %s\n", mountedPartition.PartitionName, mountedPartition.Path)
 
 	// Leer el archivo users.txt de la partición
 	usersData, err := readUsersFile(id)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("Asegúrese de que la partición tenga un sistema de archivos creado con 'mkfs'")
-		fmt.Println("======FIN LOGIN======")
-		return
+		fmt.Fprintf(out, "Error leyendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "Asegúrese de que la partición tenga un sistema de archivos creado con 'mkfs'")
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo users.txt: %s", err.Error())
 	}
 
 	// Buscar el usuario en los datos
 	userFound, userInfo := findUser(usersData, user)
 	if !userFound {
-		fmt.Printf("Error: El usuario '%s' no existe en el sistema\n", user)
-		fmt.Println("Verifique que el nombre de usuario sea correcto (distingue mayúsculas y minúsculas)")
-		fmt.Println("======FIN LOGIN======")
-		return
+		fmt.Fprintf(out, "Error: El usuario '%s' no existe en el sistema\n", user)
+		fmt.Fprintln(out, "Verifique que el nombre de usuario sea correcto (distingue mayúsculas y minúsculas)")
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "El usuario '%s' no existe en el sistema", user)
 	}
 
-	// Verificar la contraseña (distingue mayúsculas y minúsculas)
-	if userInfo.Password != pass {
-		fmt.Printf("Error: Contraseña incorrecta para el usuario '%s'\n", user)
-		fmt.Println("Verifique que la contraseña sea correcta (distingue mayúsculas y minúsculas)")
-		fmt.Println("======FIN LOGIN======")
-		return
+	// Verificar la contraseña contra el hash (o el texto plano de usuarios antiguos)
+	passwordOK, needsUpgrade := verifyPassword(userInfo.Password, pass)
+	if !passwordOK {
+		fmt.Fprintf(out, "Error: Contraseña incorrecta para el usuario '%s'\n", user)
+		fmt.Fprintln(out, "Verifique que la contraseña sea correcta (distingue mayúsculas y minúsculas)")
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Contraseña incorrecta para el usuario '%s'", user)
+	}
+
+	// Migrar la contraseña en texto plano a su versión cifrada
+	if needsUpgrade {
+		if err := upgradePassword(out, id, usersData, user, pass); err != nil {
+			fmt.Fprintf(out, "Advertencia: No se pudo cifrar la contraseña guardada: %v\n", err)
+		} else {
+			fmt.Fprintln(out, "La contraseña guardada en texto plano fue migrada a formato cifrado")
+		}
 	}
 
 	// Crear la sesión
-	CurrentSession = &Structs.UserSession{
+	session := &Structs.UserSession{
 		Username:    user,
 		UserID:      userInfo.ID,
 		GroupID:     getGroupID(usersData, userInfo.Group),
 		PartitionID: id,
 		IsActive:    true,
 	}
+	if err := startSession(session); err != nil {
+		fmt.Fprintf(out, "Error: No se pudo registrar la sesión: %v\n", err)
+		fmt.Fprintln(out, "======FIN LOGIN======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo registrar la sesión: %v", err)
+	}
 
-	fmt.Println("=== INICIO DE SESIÓN EXITOSO ===")
-	fmt.Printf("Usuario: %s (ID: %d)\n", CurrentSession.Username, CurrentSession.UserID)
-	fmt.Printf("Grupo: %s (ID: %d)\n", userInfo.Group, CurrentSession.GroupID)
-	fmt.Printf("Partición: %s (ID: %s)\n", mountedPartition.PartitionName, CurrentSession.PartitionID)
-	fmt.Printf("Disco: %s\n", mountedPartition.Path)
-	fmt.Println("Todas las operaciones se realizarán en esta partición hasta cerrar sesión")
-	fmt.Println("Use 'logout' para cerrar sesión")
-	fmt.Println("======FIN LOGIN======")
+	fmt.Fprintln(out, "=== INICIO DE SESIÓN EXITOSO ===")
+	fmt.Fprintf(out, "Usuario: %s (ID: %d)\n", session.Username, session.UserID)
+	fmt.Fprintf(out, "Grupo: %s (ID: %d)\n", userInfo.Group, session.GroupID)
+	fmt.Fprintf(out, "Partición: %s (ID: %s)\n", mountedPartition.PartitionName, session.PartitionID)
+	fmt.Fprintf(out, "Disco: %s\n", mountedPartition.Path)
+	fmt.Fprintln(out, "Todas las operaciones se realizarán en esta partición hasta cerrar sesión")
+	fmt.Fprintln(out, "Use 'logout' para cerrar sesión")
+	fmt.Fprintln(out, "======FIN LOGIN======")
+	return session, nil
 }
 
-// Logout - Cerrar sesión del sistema
-func Logout() {
-	fmt.Println("======Inicio LOGOUT======")
+// Logout - Cerrar la sesión indicada y eliminarla del almacén de sesiones
+func Logout(out io.Writer, session *Structs.UserSession) error {
+	fmt.Fprintln(out, "======Inicio LOGOUT======")
 	
 	// Verificar que haya una sesión activa
-	if CurrentSession == nil || !CurrentSession.IsActive {
-		fmt.Println("Error: No hay ninguna sesión activa")
-		fmt.Println("Debe iniciar sesión con el comando 'login' antes de poder cerrar sesión")
-		fmt.Println("======FIN LOGOUT======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay ninguna sesión activa")
+		fmt.Fprintln(out, "Debe iniciar sesión con el comando 'login' antes de poder cerrar sesión")
+		fmt.Fprintln(out, "======FIN LOGOUT======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay ninguna sesión activa")
 	}
 
 	// Mostrar información de la sesión que se va a cerrar
-	fmt.Printf("Cerrando sesión del usuario: %s\n", CurrentSession.Username)
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
+	fmt.Fprintf(out, "Cerrando sesión del usuario: %s\n", session.Username)
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
 	
 	// Cerrar la sesión
-	CurrentSession = nil
+	EndSession(session)
 	
-	fmt.Println("=== SESIÓN CERRADA EXITOSAMENTE ===")
-	fmt.Println("Puede iniciar una nueva sesión con el comando 'login'")
-	fmt.Println("======FIN LOGOUT======")
+	fmt.Fprintln(out, "=== SESIÓN CERRADA EXITOSAMENTE ===")
+	fmt.Fprintln(out, "Puede iniciar una nueva sesión con el comando 'login'")
+	fmt.Fprintln(out, "======FIN LOGOUT======")
+	return nil
 }
 
-// GetCurrentSession - Obtener la sesión actual (para uso en otros comandos)
-func GetCurrentSession() *Structs.UserSession {
-	if CurrentSession != nil && CurrentSession.IsActive {
-		return CurrentSession
+// IsUserLoggedIn - Verificar si la sesión corresponde a un usuario logueado
+func IsUserLoggedIn(session *Structs.UserSession) bool {
+	if session == nil {
+		return false
 	}
-	return nil
-}
 
-// IsUserLoggedIn - Verificar si hay un usuario logueado
-func IsUserLoggedIn() bool {
-	return CurrentSession != nil && CurrentSession.IsActive
+	// IsActive cambia al cerrar la sesión desde otra petición
+	sessionsMutex.RLock()
+	defer sessionsMutex.RUnlock()
+	return session.IsActive
 }
 
 // RequireLogin - Función helper para comandos que requieren login
-func RequireLogin() bool {
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: Debe iniciar sesión primero")
-		fmt.Println("Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+func RequireLogin(out io.Writer, session *Structs.UserSession) bool {
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: Debe iniciar sesión primero")
+		fmt.Fprintln(out, "Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
 		return false
 	}
 	return true
@@ -765,7 +821,7 @@ func RequireLogin() bool {
 // readUsersFile - Leer el archivo users.txt de una partición
 func readUsersFile(partitionID string) (string, error) {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return "", fmt.Errorf("partición no montada")
 	}
@@ -818,74 +874,13 @@ func readUsersFile(partitionID string) (string, error) {
 		return "", fmt.Errorf("archivo users.txt no tiene datos")
 	}
 
-	// Calcular cuántos bloques necesitamos leer
-	fileSize := usersInode.I_size
-	blocksNeeded := (fileSize + 63) / 64 // Redondear hacia arriba
-	if blocksNeeded > 28 { // Máximo 12 directos + 16 indirectos
-		return "", fmt.Errorf("archivo users.txt demasiado grande")
-	}
-
-	// Leer el contenido de todos los bloques
-	var content strings.Builder
-	bytesRead := int32(0)
-
-	// Leer bloques directos (I_block[0] a I_block[11])
-	for i := 0; i < 12 && i < int(blocksNeeded) && usersInode.I_block[i] != -1; i++ {
-		var usersBlock Structs.Fileblock
-		blockPos := int64(superblock.S_block_start + usersInode.I_block[i]*int32(binary.Size(Structs.Fileblock{})))
-		if err := Utilities.ReadObject(file, &usersBlock, blockPos); err != nil {
-			return "", fmt.Errorf("error leyendo bloque %d de users.txt: %s", i, err.Error())
-		}
-
-		// Calcular cuántos bytes leer de este bloque
-		remainingBytes := fileSize - bytesRead
-		bytesToRead := int32(64)
-		if remainingBytes < 64 {
-			bytesToRead = remainingBytes
-		}
-
-		content.WriteString(string(usersBlock.B_content[:bytesToRead]))
-		bytesRead += bytesToRead
-	}
-
-	// Si necesitamos más bloques, leer del puntero indirecto
-	if blocksNeeded > 12 && usersInode.I_block[12] != -1 {
-		// Leer el bloque de punteros indirectos
-		var indirectBlock Structs.Fileblock
-		indirectBlockPos := int64(superblock.S_block_start + usersInode.I_block[12]*int32(binary.Size(Structs.Fileblock{})))
-		if err := Utilities.ReadObject(file, &indirectBlock, indirectBlockPos); err != nil {
-			return "", fmt.Errorf("error leyendo bloque de punteros indirectos: %s", err.Error())
-		}
-
-		// Leer bloques indirectos
-		for i := 12; i < int(blocksNeeded) && i < 28; i++ {
-			indirectIndex := i - 12
-			var blockNumber int32
-			binary.Read(strings.NewReader(string(indirectBlock.B_content[indirectIndex*4:(indirectIndex+1)*4])), binary.LittleEndian, &blockNumber)
-			
-			if blockNumber == -1 {
-				break
-			}
-
-			var usersBlock Structs.Fileblock
-			blockPos := int64(superblock.S_block_start + blockNumber*int32(binary.Size(Structs.Fileblock{})))
-			if err := Utilities.ReadObject(file, &usersBlock, blockPos); err != nil {
-				return "", fmt.Errorf("error leyendo bloque indirecto %d de users.txt: %s", i, err.Error())
-			}
-
-			// Calcular cuántos bytes leer de este bloque
-			remainingBytes := fileSize - bytesRead
-			bytesToRead := int32(64)
-			if remainingBytes < 64 {
-				bytesToRead = remainingBytes
-			}
-
-			content.WriteString(string(usersBlock.B_content[:bytesToRead]))
-			bytesRead += bytesToRead
-		}
+	// Leer el contenido de todos los bloques (directos e indirectos)
+	content, err := readFileBlocks(file, &superblock, &usersInode)
+	if err != nil {
+		return "", fmt.Errorf("error leyendo users.txt: %s", err.Error())
 	}
 
-	return content.String(), nil
+	return string(content), nil
 }
 
 // findUser - Buscar un usuario en los datos del archivo users.txt
@@ -962,47 +957,47 @@ func getGroupID(usersData string, groupName string) int {
 // ============================================================================
 
 // Mkgrp - Crear un nuevo grupo en el sistema (solo root)
-func Mkgrp(groupName string) {
-	fmt.Println("======Inicio MKGRP======")
-	fmt.Printf("Nombre del grupo: %s\n", groupName)
+func Mkgrp(out io.Writer, session *Structs.UserSession, groupName string) error {
+	fmt.Fprintln(out, "======Inicio MKGRP======")
+	fmt.Fprintf(out, "Nombre del grupo: %s\n", groupName)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: Debe iniciar sesión primero")
-		fmt.Println("Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN MKGRP======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: Debe iniciar sesión primero")
+		fmt.Fprintln(out, "Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN MKGRP======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "Debe iniciar sesión primero")
 	}
 
 	// Verificar que el usuario sea root
-	if CurrentSession.Username != "root" {
-		fmt.Printf("Error: Solo el usuario 'root' puede crear grupos\n")
-		fmt.Printf("Usuario actual: %s\n", CurrentSession.Username)
-		fmt.Println("======FIN MKGRP======")
-		return
+	if session.Username != "root" {
+		fmt.Fprintf(out, "Error: Solo el usuario 'root' puede crear grupos\n")
+		fmt.Fprintf(out, "Usuario actual: %s\n", session.Username)
+		fmt.Fprintln(out, "======FIN MKGRP======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "Solo el usuario 'root' puede crear grupos")
 	}
 
 	// Validar que el nombre del grupo no esté vacío
 	if strings.TrimSpace(groupName) == "" {
-		fmt.Println("Error: El nombre del grupo no puede estar vacío")
-		fmt.Println("======FIN MKGRP======")
-		return
+		fmt.Fprintln(out, "Error: El nombre del grupo no puede estar vacío")
+		fmt.Fprintln(out, "======FIN MKGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del grupo no puede estar vacío")
 	}
 
 	// Leer el archivo users.txt actual
-	usersData, err := readUsersFile(CurrentSession.PartitionID)
+	usersData, err := readUsersFile(session.PartitionID)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN MKGRP======")
-		return
+		fmt.Fprintf(out, "Error leyendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN MKGRP======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo users.txt: %s", err.Error())
 	}
 
 	// Verificar que el grupo no exista ya
 	if groupExists(usersData, groupName) {
-		fmt.Printf("Error: El grupo '%s' ya existe en el sistema\n", groupName)
-		fmt.Println("Los nombres de grupos distinguen mayúsculas y minúsculas")
-		fmt.Println("======FIN MKGRP======")
-		return
+		fmt.Fprintf(out, "Error: El grupo '%s' ya existe en el sistema\n", groupName)
+		fmt.Fprintln(out, "Los nombres de grupos distinguen mayúsculas y minúsculas")
+		fmt.Fprintln(out, "======FIN MKGRP======")
+		return Utilities.NewCommandError(Utilities.ErrAlreadyExists, "El grupo '%s' ya existe en el sistema", groupName)
 	}
 
 	// Obtener el siguiente ID disponible para el grupo
@@ -1015,106 +1010,108 @@ func Mkgrp(groupName string) {
 	updatedUsersData := usersData + newGroupEntry + "\n"
 
 	// Escribir el contenido actualizado al archivo users.txt
-	err = writeUsersFile(CurrentSession.PartitionID, updatedUsersData)
+	err = writeUsersFile(session.PartitionID, updatedUsersData)
 	if err != nil {
-		fmt.Printf("Error escribiendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN MKGRP======")
-		return
+		fmt.Fprintf(out, "Error escribiendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN MKGRP======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo archivo users.txt: %s", err.Error())
 	}
 
 	// Registrar en el journaling (EXT3)
-	writeToJournal(CurrentSession.PartitionID, "mkgrp", "/users.txt", groupName)
-
-	fmt.Println("=== GRUPO CREADO EXITOSAMENTE ===")
-	fmt.Printf("Nombre del grupo: %s\n", groupName)
-	fmt.Printf("ID asignado: %d\n", nextGroupID)
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
-	fmt.Printf("Usuario que creó el grupo: %s\n", CurrentSession.Username)
-	fmt.Println("El grupo ha sido agregado al archivo users.txt")
-	fmt.Println("======FIN MKGRP======")
+	writeToJournal(out, session.PartitionID, "mkgrp", "/users.txt", groupName)
+
+	fmt.Fprintln(out, "=== GRUPO CREADO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Nombre del grupo: %s\n", groupName)
+	fmt.Fprintf(out, "ID asignado: %d\n", nextGroupID)
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
+	fmt.Fprintf(out, "Usuario que creó el grupo: %s\n", session.Username)
+	fmt.Fprintln(out, "El grupo ha sido agregado al archivo users.txt")
+	fmt.Fprintln(out, "======FIN MKGRP======")
+	return nil
 }
 
 // Rmgrp - Eliminar un grupo del sistema (solo root)
-func Rmgrp(groupName string) {
-	fmt.Println("======Inicio RMGRP======")
-	fmt.Printf("Nombre del grupo a eliminar: %s\n", groupName)
+func Rmgrp(out io.Writer, session *Structs.UserSession, groupName string) error {
+	fmt.Fprintln(out, "======Inicio RMGRP======")
+	fmt.Fprintf(out, "Nombre del grupo a eliminar: %s\n", groupName)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: Debe iniciar sesión primero")
-		fmt.Println("Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN RMGRP======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: Debe iniciar sesión primero")
+		fmt.Fprintln(out, "Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN RMGRP======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "Debe iniciar sesión primero")
 	}
 
 	// Verificar que el usuario sea root
-	if CurrentSession.Username != "root" {
-		fmt.Printf("Error: Solo el usuario 'root' puede eliminar grupos\n")
-		fmt.Printf("Usuario actual: %s\n", CurrentSession.Username)
-		fmt.Println("======FIN RMGRP======")
-		return
+	if session.Username != "root" {
+		fmt.Fprintf(out, "Error: Solo el usuario 'root' puede eliminar grupos\n")
+		fmt.Fprintf(out, "Usuario actual: %s\n", session.Username)
+		fmt.Fprintln(out, "======FIN RMGRP======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "Solo el usuario 'root' puede eliminar grupos")
 	}
 
 	// Validar que el nombre del grupo no esté vacío
 	if strings.TrimSpace(groupName) == "" {
-		fmt.Println("Error: El nombre del grupo no puede estar vacío")
-		fmt.Println("======FIN RMGRP======")
-		return
+		fmt.Fprintln(out, "Error: El nombre del grupo no puede estar vacío")
+		fmt.Fprintln(out, "======FIN RMGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del grupo no puede estar vacío")
 	}
 
 	// Leer el archivo users.txt actual
-	usersData, err := readUsersFile(CurrentSession.PartitionID)
+	usersData, err := readUsersFile(session.PartitionID)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN RMGRP======")
-		return
+		fmt.Fprintf(out, "Error leyendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN RMGRP======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo users.txt: %s", err.Error())
 	}
 
 	// Verificar que el grupo existe y no está ya eliminado
 	groupExists, groupID := findGroupForDeletion(usersData, groupName)
 	if !groupExists {
-		fmt.Printf("Error: El grupo '%s' no existe en el sistema\n", groupName)
-		fmt.Println("Verifique que el nombre del grupo sea correcto (distingue mayúsculas y minúsculas)")
-		fmt.Println("======FIN RMGRP======")
-		return
+		fmt.Fprintf(out, "Error: El grupo '%s' no existe en el sistema\n", groupName)
+		fmt.Fprintln(out, "Verifique que el nombre del grupo sea correcto (distingue mayúsculas y minúsculas)")
+		fmt.Fprintln(out, "======FIN RMGRP======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El grupo '%s' no existe en el sistema", groupName)
 	}
 
 	if groupID == 0 {
-		fmt.Printf("Error: El grupo '%s' ya ha sido eliminado anteriormente\n", groupName)
-		fmt.Println("======FIN RMGRP======")
-		return
+		fmt.Fprintf(out, "Error: El grupo '%s' ya ha sido eliminado anteriormente\n", groupName)
+		fmt.Fprintln(out, "======FIN RMGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El grupo '%s' ya ha sido eliminado anteriormente", groupName)
 	}
 
 	// Verificar que no sea el grupo root
 	if groupName == "root" {
-		fmt.Println("Error: No se puede eliminar el grupo 'root'")
-		fmt.Println("El grupo root es necesario para el funcionamiento del sistema")
-		fmt.Println("======FIN RMGRP======")
-		return
+		fmt.Fprintln(out, "Error: No se puede eliminar el grupo 'root'")
+		fmt.Fprintln(out, "El grupo root es necesario para el funcionamiento del sistema")
+		fmt.Fprintln(out, "======FIN RMGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se puede eliminar el grupo 'root'")
 	}
 
 	// Marcar el grupo como eliminado (cambiar ID a 0)
 	updatedUsersData := markGroupAsDeleted(usersData, groupName)
 
 	// Escribir el contenido actualizado al archivo users.txt
-	err = writeUsersFile(CurrentSession.PartitionID, updatedUsersData)
+	err = writeUsersFile(session.PartitionID, updatedUsersData)
 	if err != nil {
-		fmt.Printf("Error escribiendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN RMGRP======")
-		return
+		fmt.Fprintf(out, "Error escribiendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN RMGRP======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo archivo users.txt: %s", err.Error())
 	}
 
 	// Registrar en el journaling (EXT3)
-	writeToJournal(CurrentSession.PartitionID, "rmgrp", "/users.txt", groupName)
-
-	fmt.Println("=== GRUPO ELIMINADO EXITOSAMENTE ===")
-	fmt.Printf("Nombre del grupo: %s\n", groupName)
-	fmt.Printf("ID anterior: %d\n", groupID)
-	fmt.Println("ID actual: 0 (marcado como eliminado)")
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
-	fmt.Printf("Usuario que eliminó el grupo: %s\n", CurrentSession.Username)
-	fmt.Println("El grupo ha sido marcado como eliminado en el archivo users.txt")
-	fmt.Println("======FIN RMGRP======")
+	writeToJournal(out, session.PartitionID, "rmgrp", "/users.txt", groupName)
+
+	fmt.Fprintln(out, "=== GRUPO ELIMINADO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Nombre del grupo: %s\n", groupName)
+	fmt.Fprintf(out, "ID anterior: %d\n", groupID)
+	fmt.Fprintln(out, "ID actual: 0 (marcado como eliminado)")
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
+	fmt.Fprintf(out, "Usuario que eliminó el grupo: %s\n", session.Username)
+	fmt.Fprintln(out, "El grupo ha sido marcado como eliminado en el archivo users.txt")
+	fmt.Fprintln(out, "======FIN RMGRP======")
+	return nil
 }
 
 // findGroupForDeletion - Buscar un grupo para eliminación y obtener su ID
@@ -1243,7 +1240,7 @@ func getNextAvailableGroupID(usersData string) int {
 // writeUsersFile - Escribir contenido al archivo users.txt de una partición
 func writeUsersFile(partitionID string, content string) error {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("partición no montada")
 	}
@@ -1292,136 +1289,24 @@ func writeUsersFile(partitionID string, content string) error {
 	}
 
 	// Verificar que el contenido no exceda el tamaño máximo manejable
-	maxBlocks := int32(28) // 12 directos + 16 indirectos simples
-	maxFileSize := maxBlocks * 64 // 64 bytes por bloque
-	
-	if len(content) > int(maxFileSize) {
-		return fmt.Errorf("contenido demasiado grande para el archivo users.txt. Máximo: %d bytes", maxFileSize)
-	}
-
-	// Calcular cuántos bloques necesitamos
-	contentSize := len(content)
-	blocksNeeded := 0
-	if contentSize > 0 {
-		blocksNeeded = (contentSize + 63) / 64 // Redondear hacia arriba
-	}
-
-	// Primero, liberar bloques existentes si los hay
-	for i := 0; i < 15 && usersInode.I_block[i] != -1; i++ {
-		if i < 12 {
-			// Bloque directo
-			Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+usersInode.I_block[i]))
-			superblock.S_free_blocks_count++
-		} else if i == 12 {
-			// Bloque de punteros indirectos
-			var indirectBlock Structs.Fileblock
-			indirectBlockPos := int64(superblock.S_block_start + usersInode.I_block[i]*int32(binary.Size(Structs.Fileblock{})))
-			if err := Utilities.ReadObject(file, &indirectBlock, indirectBlockPos); err == nil {
-				// Liberar bloques indirectos
-				for j := 0; j < 16; j++ {
-					var blockNumber int32
-					binary.Read(strings.NewReader(string(indirectBlock.B_content[j*4:(j+1)*4])), binary.LittleEndian, &blockNumber)
-					if blockNumber != -1 {
-						Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+blockNumber))
-						superblock.S_free_blocks_count++
-					}
-				}
-			}
-			// Liberar el bloque de punteros indirectos
-			Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+usersInode.I_block[i]))
-			superblock.S_free_blocks_count++
-		}
-		usersInode.I_block[i] = -1
+	if len(content) > MaxFileSize {
+		return fmt.Errorf("contenido demasiado grande para el archivo users.txt. Máximo: %d bytes", MaxFileSize)
 	}
 
-	// Buscar bloques libres para el nuevo contenido
-	var dataBlocks []int32
-	var indirectBlock int32 = -1
-
-	if blocksNeeded > 0 {
-		for i := 0; i < blocksNeeded; i++ {
-			freeBlock := findFreeBlock(file, &superblock)
-			if freeBlock == -1 {
-				return fmt.Errorf("no hay suficientes bloques libres para users.txt")
-			}
-			dataBlocks = append(dataBlocks, freeBlock)
-			
-			// Marcar bloque como ocupado
-			Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+freeBlock))
-		}
-
-		// Si necesitamos más de 12 bloques, necesitamos un bloque para punteros indirectos
-		if blocksNeeded > 12 {
-			indirectBlock = findFreeBlock(file, &superblock)
-			if indirectBlock == -1 {
-				return fmt.Errorf("no hay bloque libre para punteros indirectos en users.txt")
-			}
-			// Marcar bloque indirecto como ocupado
-			Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+indirectBlock))
-		}
+	// Liberar los bloques actuales y escribir el contenido en bloques nuevos
+	freeFileBlocks(file, &superblock, &usersInode)
+	if err := writeFileBlocks(file, &superblock, &usersInode, []byte(content)); err != nil {
+		return fmt.Errorf("error escribiendo users.txt: %s", err.Error())
 	}
 
-	// Asignar bloques al inodo
-	for i, blockNum := range dataBlocks {
-		if i < 12 {
-			// Punteros directos (I_block[0] a I_block[11])
-			usersInode.I_block[i] = blockNum
-		} else if i == 12 {
-			// Primer bloque indirecto
-			usersInode.I_block[12] = indirectBlock
-			
-			// Escribir el primer puntero en el bloque indirecto
-			indirectBlockPos := int64(superblock.S_block_start + indirectBlock*int32(binary.Size(Structs.Fileblock{})))
-			Utilities.WriteObject(file, blockNum, indirectBlockPos)
-		} else {
-			// Punteros indirectos adicionales
-			indirectIndex := i - 12
-			indirectPos := int64(superblock.S_block_start + indirectBlock*int32(binary.Size(Structs.Fileblock{})) + int32(indirectIndex*4))
-			Utilities.WriteObject(file, blockNum, indirectPos)
-		}
-	}
+	// Actualizar la fecha de modificación del archivo en el inodo
+	stampInodeModified(&usersInode)
 
-	// Actualizar el tamaño del archivo en el inodo
-	usersInode.I_size = int32(contentSize)
-	
 	// Escribir el inodo actualizado
 	if err := Utilities.WriteObject(file, usersInode, inodePos); err != nil {
 		return fmt.Errorf("error escribiendo inodo users.txt: %s", err.Error())
 	}
 
-	// Escribir el contenido del archivo en los bloques
-	if contentSize > 0 {
-		bytesWritten := 0
-		for i, blockNum := range dataBlocks {
-			// Calcular cuántos bytes escribir en este bloque
-			remainingBytes := contentSize - bytesWritten
-			bytesToWrite := 64
-			if remainingBytes < 64 {
-				bytesToWrite = remainingBytes
-			}
-
-			// Crear bloque de archivo con el contenido
-			var usersBlock Structs.Fileblock
-			// Llenar con el contenido correspondiente
-			contentSlice := content[bytesWritten:bytesWritten+bytesToWrite]
-			copy(usersBlock.B_content[:], contentSlice)
-			
-			// Escribir el bloque
-			blockPos := int64(superblock.S_block_start + blockNum*int32(binary.Size(Structs.Fileblock{})))
-			if err := Utilities.WriteObject(file, usersBlock, blockPos); err != nil {
-				return fmt.Errorf("error escribiendo bloque %d de users.txt: %s", i, err.Error())
-			}
-
-			bytesWritten += bytesToWrite
-		}
-	}
-
-	// Actualizar contadores en el superblock
-	superblock.S_free_blocks_count -= int32(blocksNeeded)
-	if indirectBlock != -1 {
-		superblock.S_free_blocks_count-- // Bloque adicional para punteros indirectos
-	}
-
 	// Escribir superblock actualizado
 	if err := Utilities.WriteObject(file, superblock, int64(partition.Start)); err != nil {
 		return fmt.Errorf("error actualizando superblock: %s", err.Error())
@@ -1431,308 +1316,328 @@ func writeUsersFile(partitionID string, content string) error {
 }
 
 // CatUsersFile - Mostrar el contenido exacto del archivo users.txt
-func CatUsersFile() {
-	fmt.Println("======Inicio CAT======")
+func CatUsersFile(out io.Writer, session *Structs.UserSession) error {
+	fmt.Fprintln(out, "======Inicio CAT======")
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: Debe iniciar sesión primero")
-		fmt.Println("Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN CAT======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: Debe iniciar sesión primero")
+		fmt.Fprintln(out, "Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN CAT======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "Debe iniciar sesión primero")
 	}
 
 	// Leer el contenido del archivo users.txt
-	usersData, err := readUsersFile(CurrentSession.PartitionID)
+	usersData, err := readUsersFile(session.PartitionID)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN CAT======")
-		return
+		fmt.Fprintf(out, "Error leyendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN CAT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo users.txt: %s", err.Error())
 	}
 
-	fmt.Println("=== CONTENIDO DEL ARCHIVO users.txt ===")
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
-	fmt.Printf("Tamaño: %d bytes\n", len(usersData))
-	fmt.Println("---")
+	fmt.Fprintln(out, "=== CONTENIDO DEL ARCHIVO users.txt ===")
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
+	fmt.Fprintf(out, "Tamaño: %d bytes\n", len(usersData))
+	fmt.Fprintln(out, "---")
 	
 	// Mostrar el contenido línea por línea para mejor visualización
 	lines := strings.Split(usersData, "\n")
 	for i, line := range lines {
 		if line != "" {
-			fmt.Printf("Línea %d: %s\n", i+1, line)
+			fmt.Fprintf(out, "Línea %d: %s\n", i+1, line)
 		}
 	}
 	
-	fmt.Println("---")
-	fmt.Println("Contenido raw (con caracteres de escape):")
-	fmt.Printf("%q\n", usersData)
-	fmt.Println("======FIN CAT======")
+	fmt.Fprintln(out, "---")
+	fmt.Fprintln(out, "Contenido raw (con caracteres de escape):")
+	fmt.Fprintf(out, "%q\n", usersData)
+	fmt.Fprintln(out, "======FIN CAT======")
+	return nil
 }
 
 // Cat - Mostrar el contenido de uno o múltiples archivos
-func Cat(filePaths []string) {
-	fmt.Println("======Inicio CAT======")
+func Cat(out io.Writer, session *Structs.UserSession, filePaths []string) error {
+	fmt.Fprintln(out, "======Inicio CAT======")
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: Debe iniciar sesión primero")
-		fmt.Println("Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN CAT======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: Debe iniciar sesión primero")
+		fmt.Fprintln(out, "Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN CAT======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "Debe iniciar sesión primero")
 	}
 	
 	if len(filePaths) == 0 {
-		fmt.Println("Error: Debe especificar al menos un archivo")
-		fmt.Println("Uso: cat -file1=/ruta/archivo1 [-file2=/ruta/archivo2] ...")
-		fmt.Println("======FIN CAT======")
-		return
+		fmt.Fprintln(out, "Error: Debe especificar al menos un archivo")
+		fmt.Fprintln(out, "Uso: cat -file1=/ruta/archivo1 [-file2=/ruta/archivo2] ...")
+		fmt.Fprintln(out, "======FIN CAT======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Debe especificar al menos un archivo")
 	}
 	
 	for i, filePath := range filePaths {
 		if i > 0 {
-			fmt.Println() // Separar archivos con línea en blanco
+			fmt.Fprintln(out) // Separar archivos con línea en blanco
 		}
 		
 		// Buscar el archivo en el sistema
-		exists, inodeNum := findFileInDirectory(CurrentSession.PartitionID, filePath)
+		exists, inodeNum := findFileInDirectory(session.PartitionID, filePath)
 		if !exists {
-			fmt.Printf("Error: Archivo '%s' no encontrado\n", filePath)
+			fmt.Fprintf(out, "Error: Archivo '%s' no encontrado\n", filePath)
 			continue
 		}
 		
 		// Verificar permisos de lectura
-		if !hasReadPermission(CurrentSession.PartitionID, inodeNum, CurrentSession.UserID, CurrentSession.GroupID) {
-			fmt.Printf("Error: Sin permisos de lectura para el archivo '%s'\n", filePath)
+		if !hasReadPermission(session.PartitionID, inodeNum, session.UserID, session.GroupID) {
+			fmt.Fprintf(out, "Error: Sin permisos de lectura para el archivo '%s'\n", filePath)
 			continue
 		}
 		
 		// Leer el contenido del archivo
-		content, err := readFileContent(CurrentSession.PartitionID, inodeNum)
+		content, err := readFileContent(session.PartitionID, inodeNum)
 		if err != nil {
-			fmt.Printf("Error leyendo archivo '%s': %s\n", filePath, err.Error())
+			fmt.Fprintf(out, "Error leyendo archivo '%s': %s\n", filePath, err.Error())
 			continue
 		}
+		touchPartitionInode(session.PartitionID, inodeNum, stampInodeAccessed)
 		
 		// Mostrar el contenido
-		fmt.Printf("# %s\n", filePath)
-		if content == "" {
-			fmt.Println("(archivo vacío)")
+		fmt.Fprintf(out, "# %s\n", filePath)
+		if len(content) == 0 {
+			fmt.Fprintln(out, "(archivo vacío)")
+		} else if !isText(content) {
+			fmt.Fprintf(out, "(archivo binario de %d bytes)\n", len(content))
 		} else {
-			fmt.Print(content)
+			fmt.Fprint(out, string(content))
 			// Agregar salto de línea si el archivo no termina en uno
-			if !strings.HasSuffix(content, "\n") {
-				fmt.Println()
+			if !bytes.HasSuffix(content, []byte("\n")) {
+				fmt.Fprintln(out)
 			}
 		}
 	}
 	
-	fmt.Println("======FIN CAT======")
+	fmt.Fprintln(out, "======FIN CAT======")
+	return nil
 }
 
 // Mkusr - Crear un nuevo usuario en el sistema (solo root)
-func Mkusr(username string, password string, groupName string) {
-	fmt.Println("======Inicio MKUSR======")
-	fmt.Printf("Nombre del usuario: %s\n", username)
-	fmt.Printf("Grupo: %s\n", groupName)
+func Mkusr(out io.Writer, session *Structs.UserSession, username string, password string, groupName string) error {
+	fmt.Fprintln(out, "======Inicio MKUSR======")
+	fmt.Fprintf(out, "Nombre del usuario: %s\n", username)
+	fmt.Fprintf(out, "Grupo: %s\n", groupName)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: Debe iniciar sesión primero")
-		fmt.Println("Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN MKUSR======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: Debe iniciar sesión primero")
+		fmt.Fprintln(out, "Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "Debe iniciar sesión primero")
 	}
 
 	// Verificar que el usuario sea root
-	if CurrentSession.Username != "root" {
-		fmt.Printf("Error: Solo el usuario 'root' puede crear usuarios\n")
-		fmt.Printf("Usuario actual: %s\n", CurrentSession.Username)
-		fmt.Println("======FIN MKUSR======")
-		return
+	if session.Username != "root" {
+		fmt.Fprintf(out, "Error: Solo el usuario 'root' puede crear usuarios\n")
+		fmt.Fprintf(out, "Usuario actual: %s\n", session.Username)
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "Solo el usuario 'root' puede crear usuarios")
 	}
 
 	// Validar parámetros obligatorios
 	if strings.TrimSpace(username) == "" {
-		fmt.Println("Error: El nombre del usuario no puede estar vacío")
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintln(out, "Error: El nombre del usuario no puede estar vacío")
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del usuario no puede estar vacío")
 	}
 
 	if strings.TrimSpace(password) == "" {
-		fmt.Println("Error: La contraseña del usuario no puede estar vacía")
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintln(out, "Error: La contraseña del usuario no puede estar vacía")
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La contraseña del usuario no puede estar vacía")
 	}
 
 	if strings.TrimSpace(groupName) == "" {
-		fmt.Println("Error: El nombre del grupo no puede estar vacío")
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintln(out, "Error: El nombre del grupo no puede estar vacío")
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del grupo no puede estar vacío")
 	}
 
 	// Validar longitudes máximas
 	if len(username) > 10 {
-		fmt.Printf("Error: El nombre del usuario no puede tener más de 10 caracteres (actual: %d)\n", len(username))
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintf(out, "Error: El nombre del usuario no puede tener más de 10 caracteres (actual: %d)\n", len(username))
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del usuario no puede tener más de 10 caracteres (actual: %d)", len(username))
 	}
 
 	if len(password) > 10 {
-		fmt.Printf("Error: La contraseña no puede tener más de 10 caracteres (actual: %d)\n", len(password))
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintf(out, "Error: La contraseña no puede tener más de 10 caracteres (actual: %d)\n", len(password))
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La contraseña no puede tener más de 10 caracteres (actual: %d)", len(password))
 	}
 
 	if len(groupName) > 10 {
-		fmt.Printf("Error: El nombre del grupo no puede tener más de 10 caracteres (actual: %d)\n", len(groupName))
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintf(out, "Error: El nombre del grupo no puede tener más de 10 caracteres (actual: %d)\n", len(groupName))
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del grupo no puede tener más de 10 caracteres (actual: %d)", len(groupName))
+	}
+
+	// La contraseña se guarda cifrada en users.txt
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		fmt.Fprintf(out, "Error cifrando la contraseña: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error cifrando la contraseña: %s", err.Error())
 	}
 
+	return createUser(out, session, username, passwordHash, groupName)
+}
+
+// createUser agrega el usuario a users.txt con la contraseña ya cifrada
+func createUser(out io.Writer, session *Structs.UserSession, username string, passwordHash string, groupName string) error {
 	// Leer el archivo users.txt actual
-	usersData, err := readUsersFile(CurrentSession.PartitionID)
+	usersData, err := readUsersFile(session.PartitionID)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintf(out, "Error leyendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo users.txt: %s", err.Error())
 	}
 
 	// Verificar que el usuario no exista ya
 	if userExistsForCreation(usersData, username) {
-		fmt.Printf("Error: El usuario '%s' ya existe en el sistema\n", username)
-		fmt.Println("Los nombres de usuarios distinguen mayúsculas y minúsculas")
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintf(out, "Error: El usuario '%s' ya existe en el sistema\n", username)
+		fmt.Fprintln(out, "Los nombres de usuarios distinguen mayúsculas y minúsculas")
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrAlreadyExists, "El usuario '%s' ya existe en el sistema", username)
 	}
 
 	// Verificar que el grupo exista y no esté eliminado
 	groupExists, groupID := findActiveGroup(usersData, groupName)
 	if !groupExists {
-		fmt.Printf("Error: El grupo '%s' no existe en el sistema\n", groupName)
-		fmt.Println("Debe crear el grupo primero con el comando 'mkgrp'")
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintf(out, "Error: El grupo '%s' no existe en el sistema\n", groupName)
+		fmt.Fprintln(out, "Debe crear el grupo primero con el comando 'mkgrp'")
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El grupo '%s' no existe en el sistema", groupName)
 	}
 
 	if groupID == 0 {
-		fmt.Printf("Error: El grupo '%s' ha sido eliminado y no está disponible\n", groupName)
-		fmt.Println("Los grupos eliminados no pueden ser utilizados para crear usuarios")
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintf(out, "Error: El grupo '%s' ha sido eliminado y no está disponible\n", groupName)
+		fmt.Fprintln(out, "Los grupos eliminados no pueden ser utilizados para crear usuarios")
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El grupo '%s' ha sido eliminado y no está disponible", groupName)
 	}
 
 	// Obtener el siguiente ID disponible para el usuario
 	nextUserID := getNextAvailableUserID(usersData)
 
 	// Crear la nueva entrada del usuario
-	newUserEntry := fmt.Sprintf("%d,U,%s,%s,%s", nextUserID, groupName, username, password)
+	newUserEntry := fmt.Sprintf("%d,U,%s,%s,%s", nextUserID, groupName, username, passwordHash)
 
 	// Agregar el nuevo usuario al contenido existente
 	updatedUsersData := usersData + newUserEntry + "\n"
 
 	// Escribir el contenido actualizado al archivo users.txt
-	err = writeUsersFile(CurrentSession.PartitionID, updatedUsersData)
+	err = writeUsersFile(session.PartitionID, updatedUsersData)
 	if err != nil {
-		fmt.Printf("Error escribiendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN MKUSR======")
-		return
+		fmt.Fprintf(out, "Error escribiendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN MKUSR======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo archivo users.txt: %s", err.Error())
 	}
 
 	// Registrar en el journaling (EXT3)
-	contentInfo := fmt.Sprintf("user=%s,grp=%s", username, groupName)
-	writeToJournal(CurrentSession.PartitionID, "mkusr", "/users.txt", contentInfo)
-
-	fmt.Println("=== USUARIO CREADO EXITOSAMENTE ===")
-	fmt.Printf("Nombre del usuario: %s\n", username)
-	fmt.Printf("ID asignado: %d\n", nextUserID)
-	fmt.Printf("Grupo: %s (ID: %d)\n", groupName, groupID)
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
-	fmt.Printf("Usuario que creó la cuenta: %s\n", CurrentSession.Username)
-	fmt.Println("El usuario ha sido agregado al archivo users.txt")
-	fmt.Println("======FIN MKUSR======")
+	contentInfo := fmt.Sprintf("user=%s,hash=%s,grp=%s", username, passwordHash, groupName)
+	writeToJournal(out, session.PartitionID, "mkusr", "/users.txt", contentInfo)
+
+	fmt.Fprintln(out, "=== USUARIO CREADO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Nombre del usuario: %s\n", username)
+	fmt.Fprintf(out, "ID asignado: %d\n", nextUserID)
+	fmt.Fprintf(out, "Grupo: %s (ID: %d)\n", groupName, groupID)
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
+	fmt.Fprintf(out, "Usuario que creó la cuenta: %s\n", session.Username)
+	fmt.Fprintln(out, "El usuario ha sido agregado al archivo users.txt")
+	fmt.Fprintln(out, "======FIN MKUSR======")
+	return nil
 }
 
 // Rmusr - Eliminar un usuario del sistema (solo root)
-func Rmusr(username string) {
-	fmt.Println("======Inicio RMUSR======")
-	fmt.Printf("Nombre del usuario a eliminar: %s\n", username)
+func Rmusr(out io.Writer, session *Structs.UserSession, username string) error {
+	fmt.Fprintln(out, "======Inicio RMUSR======")
+	fmt.Fprintf(out, "Nombre del usuario a eliminar: %s\n", username)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: Debe iniciar sesión primero")
-		fmt.Println("Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
-		fmt.Println("======FIN RMUSR======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: Debe iniciar sesión primero")
+		fmt.Fprintln(out, "Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN RMUSR======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "Debe iniciar sesión primero")
 	}
 
 	// Verificar que el usuario sea root
-	if CurrentSession.Username != "root" {
-		fmt.Printf("Error: Solo el usuario 'root' puede eliminar usuarios\n")
-		fmt.Printf("Usuario actual: %s\n", CurrentSession.Username)
-		fmt.Println("======FIN RMUSR======")
-		return
+	if session.Username != "root" {
+		fmt.Fprintf(out, "Error: Solo el usuario 'root' puede eliminar usuarios\n")
+		fmt.Fprintf(out, "Usuario actual: %s\n", session.Username)
+		fmt.Fprintln(out, "======FIN RMUSR======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "Solo el usuario 'root' puede eliminar usuarios")
 	}
 
 	// Validar que el nombre del usuario no esté vacío
 	if strings.TrimSpace(username) == "" {
-		fmt.Println("Error: El nombre del usuario no puede estar vacío")
-		fmt.Println("======FIN RMUSR======")
-		return
+		fmt.Fprintln(out, "Error: El nombre del usuario no puede estar vacío")
+		fmt.Fprintln(out, "======FIN RMUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del usuario no puede estar vacío")
 	}
 
 	// Verificar que no sea el usuario root
 	if username == "root" {
-		fmt.Println("Error: No se puede eliminar el usuario 'root'")
-		fmt.Println("El usuario root es necesario para el funcionamiento del sistema")
-		fmt.Println("======FIN RMUSR======")
-		return
+		fmt.Fprintln(out, "Error: No se puede eliminar el usuario 'root'")
+		fmt.Fprintln(out, "El usuario root es necesario para el funcionamiento del sistema")
+		fmt.Fprintln(out, "======FIN RMUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se puede eliminar el usuario 'root'")
 	}
 
 	// Leer el archivo users.txt actual
-	usersData, err := readUsersFile(CurrentSession.PartitionID)
+	usersData, err := readUsersFile(session.PartitionID)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN RMUSR======")
-		return
+		fmt.Fprintf(out, "Error leyendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN RMUSR======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo users.txt: %s", err.Error())
 	}
 
 	// Verificar que el usuario existe y no está ya eliminado
 	userExists, userID := findUserForDeletion(usersData, username)
 	if !userExists {
-		fmt.Printf("Error: El usuario '%s' no existe en el sistema\n", username)
-		fmt.Println("Verifique que el nombre del usuario sea correcto (distingue mayúsculas y minúsculas)")
-		fmt.Println("======FIN RMUSR======")
-		return
+		fmt.Fprintf(out, "Error: El usuario '%s' no existe en el sistema\n", username)
+		fmt.Fprintln(out, "Verifique que el nombre del usuario sea correcto (distingue mayúsculas y minúsculas)")
+		fmt.Fprintln(out, "======FIN RMUSR======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El usuario '%s' no existe en el sistema", username)
 	}
 
 	if userID == 0 {
-		fmt.Printf("Error: El usuario '%s' ya ha sido eliminado anteriormente\n", username)
-		fmt.Println("======FIN RMUSR======")
-		return
+		fmt.Fprintf(out, "Error: El usuario '%s' ya ha sido eliminado anteriormente\n", username)
+		fmt.Fprintln(out, "======FIN RMUSR======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El usuario '%s' ya ha sido eliminado anteriormente", username)
 	}
 
 	// Marcar el usuario como eliminado (cambiar ID a 0)
 	updatedUsersData := markUserAsDeleted(usersData, username)
 
 	// Escribir el contenido actualizado al archivo users.txt
-	err = writeUsersFile(CurrentSession.PartitionID, updatedUsersData)
+	err = writeUsersFile(session.PartitionID, updatedUsersData)
 	if err != nil {
-		fmt.Printf("Error escribiendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN RMUSR======")
-		return
+		fmt.Fprintf(out, "Error escribiendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN RMUSR======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo archivo users.txt: %s", err.Error())
 	}
 
 	// Registrar en el journaling (EXT3)
-	writeToJournal(CurrentSession.PartitionID, "rmusr", "/users.txt", username)
-
-	fmt.Println("=== USUARIO ELIMINADO EXITOSAMENTE ===")
-	fmt.Printf("Nombre del usuario: %s\n", username)
-	fmt.Printf("ID anterior: %d\n", userID)
-	fmt.Println("ID actual: 0 (marcado como eliminado)")
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
-	fmt.Printf("Usuario que eliminó la cuenta: %s\n", CurrentSession.Username)
-	fmt.Println("El usuario ha sido marcado como eliminado en el archivo users.txt")
-	fmt.Println("======FIN RMUSR======")
+	writeToJournal(out, session.PartitionID, "rmusr", "/users.txt", username)
+
+	fmt.Fprintln(out, "=== USUARIO ELIMINADO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Nombre del usuario: %s\n", username)
+	fmt.Fprintf(out, "ID anterior: %d\n", userID)
+	fmt.Fprintln(out, "ID actual: 0 (marcado como eliminado)")
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
+	fmt.Fprintf(out, "Usuario que eliminó la cuenta: %s\n", session.Username)
+	fmt.Fprintln(out, "El usuario ha sido marcado como eliminado en el archivo users.txt")
+	fmt.Fprintln(out, "======FIN RMUSR======")
+	return nil
 }
 
 // ============================================================================
@@ -1899,115 +1804,116 @@ func markUserAsDeleted(usersData string, username string) string {
 }
 
 // Chgrp - Cambiar el grupo de un usuario en el sistema (solo root)
-func Chgrp(username string, newGroupName string) {
-	fmt.Println("======Inicio CHGRP======")
-	fmt.Printf("Usuario: %s\n", username)
-	fmt.Printf("Nuevo grupo: %s\n", newGroupName)
+func Chgrp(out io.Writer, session *Structs.UserSession, username string, newGroupName string) error {
+	fmt.Fprintln(out, "======Inicio CHGRP======")
+	fmt.Fprintf(out, "Usuario: %s\n", username)
+	fmt.Fprintf(out, "Nuevo grupo: %s\n", newGroupName)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: No hay una sesión activa")
-		fmt.Println("Use el comando 'login' para iniciar sesión")
-		fmt.Println("======FIN CHGRP======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 	}
 
 	// Verificar que el usuario sea root
-	if CurrentSession.Username != "root" {
-		fmt.Printf("Error: Solo el usuario root puede cambiar grupos de usuarios\n")
-		fmt.Printf("Usuario actual: %s\n", CurrentSession.Username)
-		fmt.Println("======FIN CHGRP======")
-		return
+	if session.Username != "root" {
+		fmt.Fprintf(out, "Error: Solo el usuario root puede cambiar grupos de usuarios\n")
+		fmt.Fprintf(out, "Usuario actual: %s\n", session.Username)
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "Solo el usuario root puede cambiar grupos de usuarios")
 	}
 
 	// Validar que el nombre del usuario no esté vacío
 	if strings.TrimSpace(username) == "" {
-		fmt.Println("Error: El nombre del usuario no puede estar vacío")
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintln(out, "Error: El nombre del usuario no puede estar vacío")
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del usuario no puede estar vacío")
 	}
 
 	// Validar que el nombre del grupo no esté vacío
 	if strings.TrimSpace(newGroupName) == "" {
-		fmt.Println("Error: El nombre del grupo no puede estar vacío")
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintln(out, "Error: El nombre del grupo no puede estar vacío")
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre del grupo no puede estar vacío")
 	}
 
 	// Leer el archivo users.txt actual
-	usersData, err := readUsersFile(CurrentSession.PartitionID)
+	usersData, err := readUsersFile(session.PartitionID)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintf(out, "Error leyendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo users.txt: %s", err.Error())
 	}
 
 	// Verificar que el usuario existe y no está eliminado
 	userExists, userInfo := findUser(usersData, username)
 	if !userExists {
-		fmt.Printf("Error: El usuario '%s' no existe en el sistema\n", username)
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintf(out, "Error: El usuario '%s' no existe en el sistema\n", username)
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El usuario '%s' no existe en el sistema", username)
 	}
 
 	if userInfo.ID == 0 {
-		fmt.Printf("Error: El usuario '%s' está marcado como eliminado\n", username)
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintf(out, "Error: El usuario '%s' está marcado como eliminado\n", username)
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El usuario '%s' está marcado como eliminado", username)
 	}
 
 	// Verificar que no sea el usuario root
 	if username == "root" {
-		fmt.Println("Error: No se puede cambiar el grupo del usuario root")
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintln(out, "Error: No se puede cambiar el grupo del usuario root")
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se puede cambiar el grupo del usuario root")
 	}
 
 	// Verificar que el nuevo grupo existe y no está eliminado
 	groupExists, groupID := findActiveGroup(usersData, newGroupName)
 	if !groupExists {
-		fmt.Printf("Error: El grupo '%s' no existe en el sistema\n", newGroupName)
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintf(out, "Error: El grupo '%s' no existe en el sistema\n", newGroupName)
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El grupo '%s' no existe en el sistema", newGroupName)
 	}
 
 	if groupID == 0 {
-		fmt.Printf("Error: El grupo '%s' está marcado como eliminado\n", newGroupName)
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintf(out, "Error: El grupo '%s' está marcado como eliminado\n", newGroupName)
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El grupo '%s' está marcado como eliminado", newGroupName)
 	}
 
 	// Verificar que el usuario no esté ya en ese grupo
 	if userInfo.Group == newGroupName {
-		fmt.Printf("El usuario '%s' ya pertenece al grupo '%s'\n", username, newGroupName)
-		fmt.Println("No se realizaron cambios")
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintf(out, "El usuario '%s' ya pertenece al grupo '%s'\n", username, newGroupName)
+		fmt.Fprintln(out, "No se realizaron cambios")
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return nil
 	}
 
 	// Cambiar el grupo del usuario
 	updatedUsersData := changeUserGroup(usersData, username, newGroupName)
 
 	// Escribir el contenido actualizado al archivo users.txt
-	err = writeUsersFile(CurrentSession.PartitionID, updatedUsersData)
+	err = writeUsersFile(session.PartitionID, updatedUsersData)
 	if err != nil {
-		fmt.Printf("Error escribiendo archivo users.txt: %s\n", err.Error())
-		fmt.Println("======FIN CHGRP======")
-		return
+		fmt.Fprintf(out, "Error escribiendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN CHGRP======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo archivo users.txt: %s", err.Error())
 	}
 
 	// Registrar en el journaling (EXT3)
-	contentInfo := fmt.Sprintf("%s->%s", userInfo.Group, newGroupName)
-	writeToJournal(CurrentSession.PartitionID, "chgrp", "/users.txt", contentInfo)
-
-	fmt.Println("=== GRUPO DE USUARIO CAMBIADO EXITOSAMENTE ===")
-	fmt.Printf("Usuario: %s\n", username)
-	fmt.Printf("Grupo anterior: %s\n", userInfo.Group)
-	fmt.Printf("Grupo nuevo: %s (ID: %d)\n", newGroupName, groupID)
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
-	fmt.Printf("Usuario que realizó el cambio: %s\n", CurrentSession.Username)
-	fmt.Println("El cambio de grupo ha sido registrado en el archivo users.txt")
-	fmt.Println("======FIN CHGRP======")
+	contentInfo := fmt.Sprintf("user=%s,grp=%s", username, newGroupName)
+	writeToJournal(out, session.PartitionID, "chgrp", "/users.txt", contentInfo)
+
+	fmt.Fprintln(out, "=== GRUPO DE USUARIO CAMBIADO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Usuario: %s\n", username)
+	fmt.Fprintf(out, "Grupo anterior: %s\n", userInfo.Group)
+	fmt.Fprintf(out, "Grupo nuevo: %s (ID: %d)\n", newGroupName, groupID)
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
+	fmt.Fprintf(out, "Usuario que realizó el cambio: %s\n", session.Username)
+	fmt.Fprintln(out, "El cambio de grupo ha sido registrado en el archivo users.txt")
+	fmt.Fprintln(out, "======FIN CHGRP======")
+	return nil
 }
 
 // changeUserGroup - Cambiar el grupo de un usuario en los datos del archivo users.txt
@@ -2058,174 +1964,179 @@ func changeUserGroup(usersData string, username string, newGroupName string) str
 // ============================================================================
 
 // Mkfile - Crear un archivo en el sistema de archivos
-func Mkfile(path string, r bool, size int, cont string) {
-	fmt.Println("======Inicio MKFILE======")
-	fmt.Printf("Ruta: %s\n", path)
-	fmt.Printf("Crear directorios padre: %t\n", r)
-	fmt.Printf("Tamaño: %d bytes\n", size)
+func Mkfile(out io.Writer, session *Structs.UserSession, path string, r bool, size int, cont string) (int32, error) {
+	fmt.Fprintln(out, "======Inicio MKFILE======")
+	fmt.Fprintf(out, "Ruta: %s\n", path)
+	fmt.Fprintf(out, "Crear directorios padre: %t\n", r)
+	fmt.Fprintf(out, "Tamaño: %d bytes\n", size)
 	if cont != "" {
-		fmt.Printf("Archivo contenido: %s\n", cont)
+		fmt.Fprintf(out, "Archivo contenido: %s\n", cont)
 	}
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: No hay una sesión activa")
-		fmt.Println("Use el comando 'login' para iniciar sesión")
-		fmt.Println("======FIN MKFILE======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 	}
 
 	// Validar que la ruta no esté vacía
 	if strings.TrimSpace(path) == "" {
-		fmt.Println("Error: La ruta del archivo no puede estar vacía")
-		fmt.Println("======FIN MKFILE======")
-		return
+		fmt.Fprintln(out, "Error: La ruta del archivo no puede estar vacía")
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta del archivo no puede estar vacía")
 	}
 
 	// Validar que el tamaño no sea negativo
 	if size < 0 {
-		fmt.Println("Error: El tamaño del archivo no puede ser negativo")
-		fmt.Println("======FIN MKFILE======")
-		return
+		fmt.Fprintln(out, "Error: El tamaño del archivo no puede ser negativo")
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El tamaño del archivo no puede ser negativo")
 	}
 
 	// Validar que la ruta empiece con "/"
 	if !strings.HasPrefix(path, "/") {
-		fmt.Println("Error: La ruta debe empezar con '/' (ruta absoluta)")
-		fmt.Println("======FIN MKFILE======")
-		return
+		fmt.Fprintln(out, "Error: La ruta debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta debe empezar con '/' (ruta absoluta)")
 	}
 
 	// Si se especifica contenido, validar que el archivo existe
-	var contentData string
+	var contentData []byte
 	if cont != "" {
 		if !fileExistsLocal(cont) {
-			fmt.Printf("Error: El archivo de contenido '%s' no existe en el sistema local\n", cont)
-			fmt.Println("======FIN MKFILE======")
-			return
+			fmt.Fprintf(out, "Error: El archivo de contenido '%s' no existe en el sistema local\n", cont)
+			fmt.Fprintln(out, "======FIN MKFILE======")
+			return -1, Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo de contenido '%s' no existe en el sistema local", cont)
 		}
 		
 		// Leer el contenido del archivo local
 		data, err := readLocalFile(cont)
 		if err != nil {
-			fmt.Printf("Error leyendo archivo de contenido '%s': %s\n", cont, err.Error())
-			fmt.Println("======FIN MKFILE======")
-			return
+			fmt.Fprintf(out, "Error leyendo archivo de contenido '%s': %s\n", cont, err.Error())
+			fmt.Fprintln(out, "======FIN MKFILE======")
+			return -1, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo de contenido '%s': %s", cont, err.Error())
 		}
 		contentData = data
 		size = len(contentData) // Si hay contenido, el tamaño es el del contenido
 	} else if size > 0 {
 		// Generar contenido con números 0-9
-		contentData = generateNumberContent(size)
+		contentData = []byte(generateNumberContent(size))
 	}
 
+	return createFileWithContent(out, session, path, r, contentData, cont != "")
+}
+
+// createFileWithContent crea el archivo con el contenido ya resuelto. Si
+// literal es true el contenido se guarda completo en el journaling; si no,
+// basta su tamaño para regenerarlo.
+func createFileWithContent(out io.Writer, session *Structs.UserSession, path string, r bool, contentData []byte, literal bool) (int32, error) {
 	// Verificar que el contenido no exceda el tamaño máximo manejable
-	// Con 12 punteros directos + 1 indirecto simple (16 punteros) = 28 bloques máximo
-	maxBlocks := int32(28) // 12 directos + 16 indirectos simples
-	maxFileSize := maxBlocks * 64 // 64 bytes por bloque
-	
-	if len(contentData) > int(maxFileSize) {
-		fmt.Printf("Error: El contenido del archivo (%d bytes) excede el tamaño máximo soportado (%d bytes)\n", len(contentData), maxFileSize)
-		fmt.Printf("Máximo: %d bloques de 64 bytes cada uno\n", maxBlocks)
-		fmt.Println("======FIN MKFILE======")
-		return
+	// 12 punteros directos + indirecto simple, doble y triple (ver MaxFileBlocks)
+	if len(contentData) > MaxFileSize {
+		fmt.Fprintf(out, "Error: El contenido del archivo (%d bytes) excede el tamaño máximo soportado (%d bytes)\n", len(contentData), MaxFileSize)
+		fmt.Fprintf(out, "Máximo: %d bloques de %d bytes cada uno\n", MaxFileBlocks, fileBlockSize)
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrNoSpace, "El contenido del archivo (%d bytes) excede el tamaño máximo soportado (%d bytes)", len(contentData), MaxFileSize)
 	}
 
 	// Separar la ruta en directorio padre y nombre de archivo
 	parentDir, fileName := parseFilePath(path)
 	
 	if fileName == "" {
-		fmt.Println("Error: Nombre de archivo no válido")
-		fmt.Println("======FIN MKFILE======")
-		return
+		fmt.Fprintln(out, "Error: Nombre de archivo no válido")
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Nombre de archivo no válido")
 	}
 	
-	// Validar longitud del nombre del archivo (máximo 12 caracteres)
-	if len(fileName) > 12 {
-		fmt.Printf("Error: El nombre del archivo '%s' es demasiado largo (máximo 12 caracteres)\n", fileName)
-		fmt.Printf("Longitud actual: %d caracteres\n", len(fileName))
-		fmt.Println("Sugerencia: Use un nombre más corto")
-		fmt.Println("======FIN MKFILE======")
-		return
+	// Validar los nombres de la ruta (los largos van a un bloque de nombre)
+	if err := validatePathNames(path); err != nil {
+		fmt.Fprintf(out, "Error: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, err
+	}
+
+	// Entrada del journaling (EXT3); si el contenido no cabe se guarda solo su tamaño
+	journalContent := fmt.Sprintf("%s,size=%d", journalOwner(session), len(contentData))
+	if literal {
+		journalContent = fitJournalData(session.PartitionID, "mkfile", path, journalOwner(session)+",", contentData)
 	}
 
 	// Verificar si el archivo ya existe
-	exists, _ := findInodeInDirectory(CurrentSession.PartitionID, 0, fileName, false)
+	exists, _ := findInodeInDirectory(session.PartitionID, 0, fileName, false)
 	if parentDir == "/" && exists {
 		if fileName == "users.txt" {
-			fmt.Printf("El archivo '%s' ya existe\n", path)
-			fmt.Print("¿Desea sobreescribir el archivo? (s/n): ")
-			fmt.Println("n")
-			fmt.Println("Operación cancelada")
-			fmt.Println("======FIN MKFILE======")
-			return
+			fmt.Fprintf(out, "El archivo '%s' ya existe\n", path)
+			fmt.Fprint(out, "¿Desea sobreescribir el archivo? (s/n): ")
+			fmt.Fprintln(out, "n")
+			fmt.Fprintln(out, "Operación cancelada")
+			fmt.Fprintln(out, "======FIN MKFILE======")
+			return -1, Utilities.NewCommandError(Utilities.ErrAlreadyExists, "El archivo '%s' ya existe", path)
 		}
-		fmt.Printf("Error: El archivo '%s' ya existe\n", path)
-		fmt.Println("======FIN MKFILE======")
-		return
+		fmt.Fprintf(out, "Error: El archivo '%s' ya existe\n", path)
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrAlreadyExists, "El archivo '%s' ya existe", path)
 	}
 
 	// Verificar que el directorio padre exista
-	parentExists, parentInode := findDirectoryInPath(CurrentSession.PartitionID, parentDir)
+	parentExists, parentInode := findDirectoryInPath(session.PartitionID, parentDir)
 	
 	if !parentExists {
 		if !r {
-			fmt.Printf("Error: El directorio padre '%s' no existe\n", parentDir)
-			fmt.Println("Use el parámetro -r para crear directorios padre automáticamente")
-			fmt.Println("======FIN MKFILE======")
-			return
+			fmt.Fprintf(out, "Error: El directorio padre '%s' no existe\n", parentDir)
+			fmt.Fprintln(out, "Use el parámetro -r para crear directorios padre automáticamente")
+			fmt.Fprintln(out, "======FIN MKFILE======")
+			return -1, Utilities.NewCommandError(Utilities.ErrNotFound, "El directorio padre '%s' no existe", parentDir)
 		} else {
 			// Crear directorios padre recursivamente
-			parentInode = createDirectoriesRecursively(CurrentSession.PartitionID, parentDir)
+			parentInode = createDirectoriesRecursively(out, session, session.PartitionID, parentDir)
 			if parentInode == -1 {
-				fmt.Printf("Error: No se pudo crear el directorio padre '%s'\n", parentDir)
-				fmt.Println("======FIN MKFILE======")
-				return
+				fmt.Fprintf(out, "Error: No se pudo crear el directorio padre '%s'\n", parentDir)
+				fmt.Fprintln(out, "======FIN MKFILE======")
+				return -1, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo crear el directorio padre '%s'", parentDir)
 			}
-			fmt.Printf("Directorios padre creados: %s\n", parentDir)
+			fmt.Fprintf(out, "Directorios padre creados: %s\n", parentDir)
 		}
 	}
 
 	// Verificar permisos de escritura en el directorio padre
-	if !hasWritePermission(CurrentSession.PartitionID, parentInode, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de escritura en el directorio padre '%s'\n", parentDir)
-		fmt.Println("======FIN MKFILE======")
-		return
+	if !hasWritePermission(session.PartitionID, parentInode, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura en el directorio padre '%s'\n", parentDir)
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura en el directorio padre '%s'", parentDir)
 	}
 
 	// Crear el archivo
-	fileInode := createFileInDirectory(CurrentSession.PartitionID, parentInode, fileName, contentData)
+	fileInode := createFileInDirectory(out, session, session.PartitionID, parentInode, fileName, contentData)
 	if fileInode == -1 {
-		fmt.Printf("Error: No se pudo crear el archivo '%s'\n", path)
-		fmt.Println("======FIN MKFILE======")
-		return
+		fmt.Fprintf(out, "Error: No se pudo crear el archivo '%s'\n", path)
+		fmt.Fprintln(out, "======FIN MKFILE======")
+		return -1, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo crear el archivo '%s'", path)
 	}
 
 	// Registrar en el journaling (EXT3)
-	contentPreview := fmt.Sprintf("size=%d", len(contentData))
-	if cont != "" {
-		contentPreview = fmt.Sprintf("from:%s", cont)
-	}
-	writeToJournal(CurrentSession.PartitionID, "mkfile", path, contentPreview)
-
-	fmt.Println("=== ARCHIVO CREADO EXITOSAMENTE ===")
-	fmt.Printf("Ruta: %s\n", path)
-	fmt.Printf("Tamaño: %d bytes\n", len(contentData))
-	if len(contentData) > 64 {
-		blocksUsed := (len(contentData) + 63) / 64
-		fmt.Printf("Bloques utilizados: %d bloques de 64 bytes\n", blocksUsed)
-		fmt.Printf("Espacio en disco: %d bytes\n", blocksUsed*64)
-	}
-	fmt.Printf("Propietario: %s (ID: %d)\n", CurrentSession.Username, CurrentSession.UserID)
-	fmt.Printf("Grupo: %d\n", CurrentSession.GroupID)
-	if CurrentSession != nil && CurrentSession.Username == "root" {
-		fmt.Printf("Permisos: 777 (rwxrwxrwx)\n")
+	writeToJournal(out, session.PartitionID, "mkfile", path, journalContent)
+
+	fmt.Fprintln(out, "=== ARCHIVO CREADO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Ruta: %s\n", path)
+	fmt.Fprintf(out, "Tamaño: %d bytes\n", len(contentData))
+	if len(contentData) > fileBlockSize {
+		blocksUsed := blocksForSize(len(contentData))
+		fmt.Fprintf(out, "Bloques utilizados: %d bloques de %d bytes\n", blocksUsed, fileBlockSize)
+		fmt.Fprintf(out, "Espacio en disco: %d bytes\n", blocksUsed*fileBlockSize)
+	}
+	fmt.Fprintf(out, "Propietario: %s (ID: %d)\n", session.Username, session.UserID)
+	fmt.Fprintf(out, "Grupo: %d\n", session.GroupID)
+	if session != nil && session.Username == "root" {
+		fmt.Fprintf(out, "Permisos: 777 (rwxrwxrwx)\n")
 	} else {
-		fmt.Printf("Permisos: 664 (rw-rw-r--)\n")
+		fmt.Fprintf(out, "Permisos: 664 (rw-rw-r--)\n")
 	}
-	fmt.Printf("Inodo asignado: %d\n", fileInode)
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
-	fmt.Println("======FIN MKFILE======")
+	fmt.Fprintf(out, "Inodo asignado: %d\n", fileInode)
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
+	fmt.Fprintln(out, "======FIN MKFILE======")
+	return fileInode, nil
 }
 
 // ============================================================================
@@ -2233,110 +2144,109 @@ func Mkfile(path string, r bool, size int, cont string) {
 // ============================================================================
 
 // Mkdir - Crear un directorio en el sistema de archivos
-func Mkdir(path string, p bool) {
-	fmt.Println("======Inicio MKDIR======")
-	fmt.Printf("Ruta: %s\n", path)
-	fmt.Printf("Crear directorios padre: %t\n", p)
+func Mkdir(out io.Writer, session *Structs.UserSession, path string, p bool) (int32, error) {
+	fmt.Fprintln(out, "======Inicio MKDIR======")
+	fmt.Fprintf(out, "Ruta: %s\n", path)
+	fmt.Fprintf(out, "Crear directorios padre: %t\n", p)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: No hay una sesión activa")
-		fmt.Println("Use el comando 'login' para iniciar sesión")
-		fmt.Println("======FIN MKDIR======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN MKDIR======")
+		return -1, Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 	}
 
 	// Validar que la ruta no esté vacía
 	if strings.TrimSpace(path) == "" {
-		fmt.Println("Error: La ruta del directorio no puede estar vacía")
-		fmt.Println("======FIN MKDIR======")
-		return
+		fmt.Fprintln(out, "Error: La ruta del directorio no puede estar vacía")
+		fmt.Fprintln(out, "======FIN MKDIR======")
+		return -1, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta del directorio no puede estar vacía")
 	}
 
 	// Validar que la ruta empiece con "/"
 	if !strings.HasPrefix(path, "/") {
-		fmt.Println("Error: La ruta debe empezar con '/' (ruta absoluta)")
-		fmt.Println("======FIN MKDIR======")
-		return
+		fmt.Fprintln(out, "Error: La ruta debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN MKDIR======")
+		return -1, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta debe empezar con '/' (ruta absoluta)")
 	}
 
 	// Separar la ruta en directorio padre y nombre del directorio
 	parentDir, dirName := parseFilePath(path)
 	
 	if dirName == "" {
-		fmt.Println("Error: Nombre de directorio no válido")
-		fmt.Println("======FIN MKDIR======")
-		return
+		fmt.Fprintln(out, "Error: Nombre de directorio no válido")
+		fmt.Fprintln(out, "======FIN MKDIR======")
+		return -1, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "Nombre de directorio no válido")
 	}
 	
-	// Validar longitud del nombre del directorio (máximo 12 caracteres)
-	if len(dirName) > 12 {
-		fmt.Printf("Error: El nombre del directorio '%s' es demasiado largo (máximo 12 caracteres)\n", dirName)
-		fmt.Printf("Longitud actual: %d caracteres\n", len(dirName))
-		fmt.Println("Sugerencia: Use un nombre más corto")
-		fmt.Println("======FIN MKDIR======")
-		return
+	// Validar los nombres de la ruta (los largos van a un bloque de nombre)
+	if err := validatePathNames(path); err != nil {
+		fmt.Fprintf(out, "Error: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN MKDIR======")
+		return -1, err
 	}
 
 	// Verificar si el directorio ya existe
-	exists, _ := findDirectoryInPath(CurrentSession.PartitionID, path)
+	exists, _ := findDirectoryInPath(session.PartitionID, path)
 	if exists {
-		fmt.Printf("Error: El directorio '%s' ya existe\n", path)
-		fmt.Println("======FIN MKDIR======")
-		return
+		fmt.Fprintf(out, "Error: El directorio '%s' ya existe\n", path)
+		fmt.Fprintln(out, "======FIN MKDIR======")
+		return -1, Utilities.NewCommandError(Utilities.ErrAlreadyExists, "El directorio '%s' ya existe", path)
 	}
 
 	// Verificar que el directorio padre exista
-	parentExists, parentInode := findDirectoryInPath(CurrentSession.PartitionID, parentDir)
+	parentExists, parentInode := findDirectoryInPath(session.PartitionID, parentDir)
 	
 	if !parentExists {
 		if !p {
-			fmt.Printf("Error: El directorio padre '%s' no existe\n", parentDir)
-			fmt.Println("Use el parámetro -p para crear directorios padre automáticamente")
-			fmt.Println("======FIN MKDIR======")
-			return
+			fmt.Fprintf(out, "Error: El directorio padre '%s' no existe\n", parentDir)
+			fmt.Fprintln(out, "Use el parámetro -p para crear directorios padre automáticamente")
+			fmt.Fprintln(out, "======FIN MKDIR======")
+			return -1, Utilities.NewCommandError(Utilities.ErrNotFound, "El directorio padre '%s' no existe", parentDir)
 		} else {
 			// Crear directorios padre recursivamente
-			parentInode = createDirectoriesRecursively(CurrentSession.PartitionID, parentDir)
+			parentInode = createDirectoriesRecursively(out, session, session.PartitionID, parentDir)
 			if parentInode == -1 {
-				fmt.Printf("Error: No se pudo crear el directorio padre '%s'\n", parentDir)
-				fmt.Println("======FIN MKDIR======")
-				return
+				fmt.Fprintf(out, "Error: No se pudo crear el directorio padre '%s'\n", parentDir)
+				fmt.Fprintln(out, "======FIN MKDIR======")
+				return -1, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo crear el directorio padre '%s'", parentDir)
 			}
-			fmt.Printf("Directorios padre creados: %s\n", parentDir)
+			fmt.Fprintf(out, "Directorios padre creados: %s\n", parentDir)
 		}
 	}
 
 	// Verificar permisos de escritura en el directorio padre
-	if !hasWritePermission(CurrentSession.PartitionID, parentInode, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de escritura en el directorio padre '%s'\n", parentDir)
-		fmt.Println("======FIN MKDIR======")
-		return
+	if !hasWritePermission(session.PartitionID, parentInode, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura en el directorio padre '%s'\n", parentDir)
+		fmt.Fprintln(out, "======FIN MKDIR======")
+		return -1, Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura en el directorio padre '%s'", parentDir)
 	}
 
 	// Crear el directorio
-	dirInode := createDirectoryInParent(CurrentSession.PartitionID, parentInode, dirName)
+	dirInode := createDirectoryInParent(out, session, session.PartitionID, parentInode, dirName)
 	if dirInode == -1 {
-		fmt.Printf("Error: No se pudo crear el directorio '%s'\n", path)
-		fmt.Println("======FIN MKDIR======")
-		return
+		fmt.Fprintf(out, "Error: No se pudo crear el directorio '%s'\n", path)
+		fmt.Fprintln(out, "======FIN MKDIR======")
+		return -1, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo crear el directorio '%s'", path)
 	}
 
 	// Registrar en el journaling (EXT3)
-	writeToJournal(CurrentSession.PartitionID, "mkdir", path, "directory")
-
-	fmt.Println("=== DIRECTORIO CREADO EXITOSAMENTE ===")
-	fmt.Printf("Ruta: %s\n", path)
-	fmt.Printf("Propietario: %s (ID: %d)\n", CurrentSession.Username, CurrentSession.UserID)
-	fmt.Printf("Grupo: %d\n", CurrentSession.GroupID)
-	if CurrentSession != nil && CurrentSession.Username == "root" {
-		fmt.Printf("Permisos: 777 (rwxrwxrwx)\n")
+	writeToJournal(out, session.PartitionID, "mkdir", path, journalOwner(session)+",directory")
+
+	fmt.Fprintln(out, "=== DIRECTORIO CREADO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Ruta: %s\n", path)
+	fmt.Fprintf(out, "Propietario: %s (ID: %d)\n", session.Username, session.UserID)
+	fmt.Fprintf(out, "Grupo: %d\n", session.GroupID)
+	if session != nil && session.Username == "root" {
+		fmt.Fprintf(out, "Permisos: 777 (rwxrwxrwx)\n")
 	} else {
-		fmt.Printf("Permisos: 664 (rw-rw-r--)\n")
+		fmt.Fprintf(out, "Permisos: 664 (rw-rw-r--)\n")
 	}
-	fmt.Printf("Inodo asignado: %d\n", dirInode)
-	fmt.Printf("Partición: %s\n", CurrentSession.PartitionID)
-	fmt.Println("======FIN MKDIR======")
+	fmt.Fprintf(out, "Inodo asignado: %d\n", dirInode)
+	fmt.Fprintf(out, "Partición: %s\n", session.PartitionID)
+	fmt.Fprintln(out, "======FIN MKDIR======")
+	return dirInode, nil
 }
 
 // ============================================================================
@@ -2350,12 +2260,8 @@ func fileExistsLocal(filePath string) bool {
 }
 
 // readLocalFile - Leer contenido de un archivo local
-func readLocalFile(filePath string) (string, error) {
-	content, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return "", err
-	}
-	return string(content), nil
+func readLocalFile(filePath string) ([]byte, error) {
+	return ioutil.ReadFile(filePath)
 }
 
 // generateNumberContent - Generar contenido con números 0-9 repetidos
@@ -2425,100 +2331,45 @@ func findFileInDirectory(partitionID string, filePath string) (bool, int32) {
 }
 
 // readFileContent - Leer el contenido completo de un archivo por su inodo
-func readFileContent(partitionID string, inodeNum int32) (string, error) {
+func readFileContent(partitionID string, inodeNum int32) ([]byte, error) {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
-		return "", fmt.Errorf("partición no montada")
+		return nil, fmt.Errorf("partición no montada")
 	}
 
 	// Abrir el archivo del disco
 	file, err := Utilities.OpenFile(mountedPartition.Path)
 	if err != nil {
-		return "", fmt.Errorf("error abriendo disco: %s", err.Error())
+		return nil, fmt.Errorf("error abriendo disco: %s", err.Error())
 	}
 	defer file.Close()
 
 	// Leer el superblock
 	superblock, err := ReadSuperblock(partitionID)
 	if err != nil {
-		return "", fmt.Errorf("error leyendo superblock: %s", err.Error())
+		return nil, fmt.Errorf("error leyendo superblock: %s", err.Error())
 	}
 
 	// Leer el inodo del archivo
 	var inode Structs.Inode
 	inodePos := int64(superblock.S_inode_start + inodeNum*superblock.S_inode_size)
 	if err := Utilities.ReadObject(file, &inode, inodePos); err != nil {
-		return "", fmt.Errorf("error leyendo inodo: %s", err.Error())
+		return nil, fmt.Errorf("error leyendo inodo: %s", err.Error())
 	}
 
 	// Verificar que es un archivo (no directorio)
 	if string(inode.I_type[:1]) != "1" {
-		return "", fmt.Errorf("el inodo especificado no es un archivo")
+		return nil, fmt.Errorf("el inodo especificado no es un archivo")
 	}
 
 	// Si el archivo está vacío
 	if inode.I_size == 0 {
-		return "", nil
+		return nil, nil
 	}
 
-	var content strings.Builder
-	bytesToRead := int(inode.I_size)
-	
-	// Leer los bloques directos (hasta 12 bloques)
-	for i := 0; i < 12 && i < len(inode.I_block) && inode.I_block[i] != -1 && bytesToRead > 0; i++ {
-		var fileBlock Structs.Fileblock
-		blockPos := int64(superblock.S_block_start + inode.I_block[i]*int32(binary.Size(Structs.Fileblock{})))
-		if err := Utilities.ReadObject(file, &fileBlock, blockPos); err != nil {
-			return "", fmt.Errorf("error leyendo bloque directo %d: %s", i, err.Error())
-		}
-		
-		// Calcular cuántos bytes leer de este bloque
-		bytesInThisBlock := 64
-		if bytesToRead < 64 {
-			bytesInThisBlock = bytesToRead
-		}
-		
-		content.Write(fileBlock.B_content[:bytesInThisBlock])
-		bytesToRead -= bytesInThisBlock
-	}
-	
-	// Si hay más datos, leer desde bloque indirecto (posición 12)
-	if bytesToRead > 0 && len(inode.I_block) > 12 && inode.I_block[12] != -1 {
-		// Leer el bloque de punteros indirectos
-		var indirectBlock Structs.Fileblock
-		indirectBlockPos := int64(superblock.S_block_start + inode.I_block[12]*int32(binary.Size(Structs.Fileblock{})))
-		if err := Utilities.ReadObject(file, &indirectBlock, indirectBlockPos); err != nil {
-			return "", fmt.Errorf("error leyendo bloque indirecto: %s", err.Error())
-		}
-		
-		// Leer hasta 16 bloques indirectos
-		for i := 0; i < 16 && bytesToRead > 0; i++ {
-			var blockNumber int32
-			binary.Read(strings.NewReader(string(indirectBlock.B_content[i*4:(i+1)*4])), binary.LittleEndian, &blockNumber)
-			
-			if blockNumber == -1 {
-				break
-			}
-			
-			var fileBlock Structs.Fileblock
-			blockPos := int64(superblock.S_block_start + blockNumber*int32(binary.Size(Structs.Fileblock{})))
-			if err := Utilities.ReadObject(file, &fileBlock, blockPos); err != nil {
-				return "", fmt.Errorf("error leyendo bloque indirecto %d: %s", i, err.Error())
-			}
-			
-			// Calcular cuántos bytes leer de este bloque
-			bytesInThisBlock := 64
-			if bytesToRead < 64 {
-				bytesInThisBlock = bytesToRead
-			}
-			
-			content.Write(fileBlock.B_content[:bytesInThisBlock])
-			bytesToRead -= bytesInThisBlock
-		}
-	}
-	
-	return content.String(), nil
+	// Leer los bloques directos e indirectos (simple, doble y triple)
+	return readFileBlocks(file, superblock, &inode)
 }
 
 // findDirectoryInPath - Buscar un directorio por ruta y retornar su inodo
@@ -2559,7 +2410,7 @@ func findDirectoryInPath(partitionID string, dirPath string) (bool, int32) {
 }
 
 // createDirectoriesRecursively - Crear directorios recursivamente
-func createDirectoriesRecursively(partitionID string, dirPath string) int32 {
+func createDirectoriesRecursively(out io.Writer, session *Structs.UserSession, partitionID string, dirPath string) int32 {
 	if dirPath == "/" {
 		return 0 // El directorio raíz siempre existe y es el inodo 0
 	}
@@ -2574,19 +2425,19 @@ func createDirectoriesRecursively(partitionID string, dirPath string) int32 {
 	parentDir, dirName := parseFilePath(dirPath)
 	
 	// Crear el directorio padre recursivamente
-	parentInode := createDirectoriesRecursively(partitionID, parentDir)
+	parentInode := createDirectoriesRecursively(out, session, partitionID, parentDir)
 	if parentInode == -1 {
 		return -1
 	}
 
 	// Crear este directorio
-	return createDirectoryInParent(partitionID, parentInode, dirName)
+	return createDirectoryInParent(out, session, partitionID, parentInode, dirName)
 }
 
 // findInodeInDirectory - Buscar un inodo por nombre en un directorio específico
 func findInodeInDirectory(partitionID string, dirInode int32, name string, dirsOnly bool) (bool, int32) {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return false, -1
 	}
@@ -2630,7 +2481,7 @@ func findInodeInDirectory(partitionID string, dirInode int32, name string, dirsO
 				continue // Entrada vacía
 			}
 			
-			entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			entryName := EntryName(file, superblock, folderBlock.B_content[j])
 			if entryName == name {
 				entryInode := folderBlock.B_content[j].B_inodo
 				
@@ -2656,9 +2507,9 @@ func findInodeInDirectory(partitionID string, dirInode int32, name string, dirsO
 }
 
 // createDirectoryInParent - Crear un directorio en el directorio padre especificado
-func createDirectoryInParent(partitionID string, parentInode int32, dirName string) int32 {
+func createDirectoryInParent(out io.Writer, session *Structs.UserSession, partitionID string, parentInode int32, dirName string) int32 {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return -1
 	}
@@ -2679,32 +2530,29 @@ func createDirectoryInParent(partitionID string, parentInode int32, dirName stri
 	// Buscar un inodo libre
 	freeInode := findFreeInode(file, superblock)
 	if freeInode == -1 {
-		fmt.Println("Error: No hay inodos libres disponibles")
+		fmt.Fprintln(out, "Error: No hay inodos libres disponibles")
 		return -1
 	}
 
 	// Buscar un bloque libre para el contenido del directorio
 	freeBlock := findFreeBlock(file, superblock)
 	if freeBlock == -1 {
-		fmt.Println("Error: No hay bloques libres disponibles")
+		fmt.Fprintln(out, "Error: No hay bloques libres disponibles")
 		return -1
 	}
 
 	// Crear el inodo del directorio
 	var newInode Structs.Inode
-	newInode.I_uid = int32(CurrentSession.UserID)
-	newInode.I_gid = int32(CurrentSession.GroupID)
+	newInode.I_uid = int32(session.UserID)
+	newInode.I_gid = int32(session.GroupID)
 	newInode.I_size = int32(64) // Tamaño de un bloque para el directorio
 	
 	// Configurar fechas
-	currentDate := "02/09/2025"
-	copy(newInode.I_atime[:], currentDate)
-	copy(newInode.I_ctime[:], currentDate)
-	copy(newInode.I_mtime[:], currentDate)
+	stampInodeCreated(&newInode)
 	
 	copy(newInode.I_type[:], "0")    // 0 = directorio
 	// Asignar permisos según el usuario
-	if CurrentSession != nil && CurrentSession.Username == "root" {
+	if session != nil && session.Username == "root" {
 		copy(newInode.I_perm[:], "777")  // Permisos rwxrwxrwx para root
 	} else {
 		copy(newInode.I_perm[:], "664")  // Permisos rw-rw-r-- para otros usuarios
@@ -2776,8 +2624,8 @@ func createDirectoryInParent(partitionID string, parentInode int32, dirName stri
 	}
 
 	// Agregar entrada en el directorio padre
-	if !addFileToDirectory(file, superblock, parentInode, dirName, freeInode) {
-		fmt.Println("Error: No se pudo agregar el directorio al directorio padre")
+	if !addFileToDirectory(out, file, superblock, parentInode, dirName, freeInode) {
+		fmt.Fprintln(out, "Error: No se pudo agregar el directorio al directorio padre")
 		return -1
 	}
 
@@ -2786,14 +2634,14 @@ func createDirectoryInParent(partitionID string, parentInode int32, dirName stri
 
 // hasWritePermission - Verificar si un usuario tiene permisos de escritura en un directorio
 func hasWritePermission(partitionID string, inodeNum int32, userID int, groupID int) bool {
-	// Si es root, siempre tiene permisos
-	if CurrentSession != nil && CurrentSession.Username == "root" {
+	// Si es root, siempre tiene permisos (el usuario root siempre tiene ID 1)
+	if userID == 1 {
 		return true
 	}
 	
 	// Para el directorio raíz, verificamos los permisos del inodo 0
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return false
 	}
@@ -2842,13 +2690,13 @@ func hasWritePermission(partitionID string, inodeNum int32, userID int, groupID
 
 // hasReadPermission - Verificar si un usuario tiene permisos de lectura en un archivo
 func hasReadPermission(partitionID string, inodeNum int32, userID int, groupID int) bool {
-	// Si es root, siempre tiene permisos
-	if CurrentSession != nil && CurrentSession.Username == "root" {
+	// Si es root, siempre tiene permisos (el usuario root siempre tiene ID 1)
+	if userID == 1 {
 		return true
 	}
 	
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return false
 	}
@@ -2896,9 +2744,9 @@ func hasReadPermission(partitionID string, inodeNum int32, userID int, groupID i
 }
 
 // createFileInDirectory - Crear un archivo en un directorio específico
-func createFileInDirectory(partitionID string, parentInode int32, fileName string, content string) int32 {
+func createFileInDirectory(out io.Writer, session *Structs.UserSession, partitionID string, parentInode int32, fileName string, content []byte) int32 {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return -1
 	}
@@ -2919,75 +2767,32 @@ func createFileInDirectory(partitionID string, parentInode int32, fileName strin
 	// Buscar un inodo libre
 	freeInode := findFreeInode(file, superblock)
 	if freeInode == -1 {
-		fmt.Println("Error: No hay inodos libres disponibles")
+		fmt.Fprintln(out, "Error: No hay inodos libres disponibles")
 		return -1
 	}
 
-	// Calcular cuántos bloques necesitamos
-	contentSize := len(content)
-	blocksNeeded := 0
-	if contentSize > 0 {
-		blocksNeeded = (contentSize + 63) / 64 // Redondear hacia arriba
-	}
-
-	// Verificar si hay suficientes bloques libres
-	if blocksNeeded > 0 {
+	// Verificar si hay suficientes bloques libres (datos + bloques de apuntadores)
+	blocksNeeded := blocksForSize(len(content))
+	requiredBlocks := blocksNeeded + pointerBlocksFor(blocksNeeded)
+	if requiredBlocks > 0 {
 		freeBlocksCount := countFreeBlocks(file, superblock)
-		requiredBlocks := blocksNeeded
-		if blocksNeeded > 12 {
-			requiredBlocks++ // Bloque adicional para punteros indirectos
-		}
-		
 		if freeBlocksCount < int32(requiredBlocks) {
-			fmt.Printf("Error: No hay suficientes bloques libres. Necesarios: %d, Disponibles: %d\n", requiredBlocks, freeBlocksCount)
+			fmt.Fprintf(out, "Error: No hay suficientes bloques libres. Necesarios: %d, Disponibles: %d\n", requiredBlocks, freeBlocksCount)
 			return -1
 		}
 	}
 
-	// Buscar bloques libres para el contenido del archivo
-	var dataBlocks []int32
-	var indirectBlock int32 = -1
-
-	if blocksNeeded > 0 {
-		for i := 0; i < blocksNeeded; i++ {
-			freeBlock := findFreeBlock(file, superblock)
-			if freeBlock == -1 {
-				fmt.Printf("Error: No se pudo encontrar bloque libre %d de %d\n", i+1, blocksNeeded)
-				return -1
-			}
-			dataBlocks = append(dataBlocks, freeBlock)
-			
-			// Marcar bloque como ocupado
-			Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+freeBlock))
-		}
-
-		// Si necesitamos más de 12 bloques, necesitamos un bloque para punteros indirectos
-		if blocksNeeded > 12 {
-			indirectBlock = findFreeBlock(file, superblock)
-			if indirectBlock == -1 {
-				fmt.Println("Error: No se pudo encontrar bloque para punteros indirectos")
-				return -1
-			}
-			// Marcar bloque indirecto como ocupado
-			Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+indirectBlock))
-		}
-	}
-
 	// Crear el inodo del archivo
 	var newInode Structs.Inode
-	newInode.I_uid = int32(CurrentSession.UserID)
-	newInode.I_gid = int32(CurrentSession.GroupID)
-	newInode.I_size = int32(contentSize)
+	newInode.I_uid = int32(session.UserID)
+	newInode.I_gid = int32(session.GroupID)
 	
 	// Configurar fechas
-	currentDate := "02/09/2025"
-	copy(newInode.I_atime[:], currentDate)
-	copy(newInode.I_ctime[:], currentDate)
-	copy(newInode.I_mtime[:], currentDate)
+	stampInodeCreated(&newInode)
 	
 	copy(newInode.I_type[:], "1")    // 1 = archivo regular
 	// Asignar permisos según el usuario
-	if CurrentSession != nil && CurrentSession.Username == "root" {
+	if session != nil && session.Username == "root" {
 		copy(newInode.I_perm[:], "777")  // Permisos rwxrwxrwx para root
 	} else {
 		copy(newInode.I_perm[:], "664")  // Permisos rw-rw-r-- para otros usuarios
@@ -2998,24 +2803,10 @@ func createFileInDirectory(partitionID string, parentInode int32, fileName strin
 		newInode.I_block[i] = -1
 	}
 	
-	// Asignar bloques al inodo
-	for i, blockNum := range dataBlocks {
-		if i < 12 {
-			// Punteros directos (I_block[0] a I_block[11])
-			newInode.I_block[i] = blockNum
-		} else if i == 12 {
-			// Primer bloque indirecto
-			newInode.I_block[12] = indirectBlock
-			
-			// Escribir el primer puntero en el bloque indirecto
-			indirectBlockPos := int64(superblock.S_block_start + indirectBlock*superblock.S_block_size)
-			Utilities.WriteObject(file, blockNum, indirectBlockPos)
-		} else {
-			// Punteros indirectos adicionales
-			indirectIndex := i - 12
-			indirectPos := int64(superblock.S_block_start + indirectBlock*superblock.S_block_size + int32(indirectIndex*4))
-			Utilities.WriteObject(file, blockNum, indirectPos)
-		}
+	// Escribir el contenido en bloques directos e indirectos (simple, doble y triple)
+	if err := writeFileBlocks(file, superblock, &newInode, content); err != nil {
+		fmt.Fprintf(out, "Error: %s\n", err.Error())
+		return -1
 	}
 
 	// Escribir el inodo
@@ -3024,43 +2815,11 @@ func createFileInDirectory(partitionID string, parentInode int32, fileName strin
 		return -1
 	}
 
-	// Escribir el contenido del archivo si hay contenido
-	if contentSize > 0 {
-		bytesWritten := 0
-		for i, blockNum := range dataBlocks {
-			// Calcular cuántos bytes escribir en este bloque
-			remainingBytes := contentSize - bytesWritten
-			bytesToWrite := 64
-			if remainingBytes < 64 {
-				bytesToWrite = remainingBytes
-			}
-
-			// Crear bloque de archivo con el contenido
-			var fileBlock Structs.Fileblock
-			// Llenar con el contenido correspondiente
-			contentSlice := content[bytesWritten:bytesWritten+bytesToWrite]
-			copy(fileBlock.B_content[:], contentSlice)
-			
-			// Escribir el bloque
-			blockPos := int64(superblock.S_block_start + blockNum*superblock.S_block_size)
-			if err := Utilities.WriteObject(file, fileBlock, blockPos); err != nil {
-				fmt.Printf("Error escribiendo bloque %d: %v\n", i, err)
-				return -1
-			}
-
-			bytesWritten += bytesToWrite
-		}
-	}
-
 	// Marcar inodo como ocupado
 	Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_inode_start+freeInode))
 	
 	// Actualizar contadores en el superblock
 	superblock.S_free_inodes_count--
-	superblock.S_free_blocks_count -= int32(blocksNeeded)
-	if indirectBlock != -1 {
-		superblock.S_free_blocks_count-- // Bloque adicional para punteros indirectos
-	}
 
 	// Leer la posición correcta del superblock
 	var partition *Structs.Partition = nil
@@ -3081,8 +2840,8 @@ func createFileInDirectory(partitionID string, parentInode int32, fileName strin
 	}
 
 	// Agregar entrada en el directorio padre
-	if !addFileToDirectory(file, superblock, parentInode, fileName, freeInode) {
-		fmt.Println("Error: No se pudo agregar el archivo al directorio padre")
+	if !addFileToDirectory(out, file, superblock, parentInode, fileName, freeInode) {
+		fmt.Fprintln(out, "Error: No se pudo agregar el archivo al directorio padre")
 		return -1
 	}
 
@@ -3133,7 +2892,7 @@ func countFreeBlocks(file *os.File, superblock *Structs.Superblock) int32 {
 }
 
 // addFileToDirectory - Agregar una entrada de archivo a un directorio
-func addFileToDirectory(file *os.File, superblock *Structs.Superblock, dirInode int32, fileName string, fileInode int32) bool {
+func addFileToDirectory(out io.Writer, file *os.File, superblock *Structs.Superblock, dirInode int32, fileName string, fileInode int32) bool {
 	// Leer el inodo del directorio
 	var dirInodeStruct Structs.Inode
 	inodePos := int64(superblock.S_inode_start + dirInode*superblock.S_inode_size)
@@ -3158,13 +2917,17 @@ func addFileToDirectory(file *os.File, superblock *Structs.Superblock, dirInode
 		for j := 0; j < 4; j++ {
 			if folderBlock.B_content[j].B_inodo == -1 {
 				// Entrada vacía encontrada
-				copy(folderBlock.B_content[j].B_name[:], fileName)
+				if err := setEntryName(file, superblock, &folderBlock.B_content[j], fileName); err != nil {
+					fmt.Fprintf(out, "Error: %s\n", err.Error())
+					return false
+				}
 				folderBlock.B_content[j].B_inodo = fileInode
 				
 				// Escribir el bloque actualizado
 				if err := Utilities.WriteObject(file, folderBlock, blockPos); err != nil {
 					return false
 				}
+				touchInode(file, superblock, dirInode, stampInodeModified)
 				return true
 			}
 		}
@@ -3181,14 +2944,24 @@ func addFileToDirectory(file *os.File, superblock *Structs.Superblock, dirInode
 	}
 
 	if freeSlot == -1 {
-		fmt.Println("Error: El directorio ha alcanzado el máximo de bloques (15)")
+		fmt.Fprintln(out, "Error: El directorio ha alcanzado el máximo de bloques (15)")
+		return false
+	}
+
+	// Preparar la entrada antes de buscar el bloque: un nombre largo reserva
+	// su propio bloque de nombre
+	var entry Structs.Content
+	if err := setEntryName(file, superblock, &entry, fileName); err != nil {
+		fmt.Fprintf(out, "Error: %s\n", err.Error())
 		return false
 	}
+	entry.B_inodo = fileInode
 
 	// Buscar un bloque libre
 	freeBlock := findFreeBlock(file, superblock)
 	if freeBlock == -1 {
-		fmt.Println("Error: No hay bloques libres disponibles para extender el directorio")
+		fmt.Fprintln(out, "Error: No hay bloques libres disponibles para extender el directorio")
+		clearEntryName(file, superblock, &entry)
 		return false
 	}
 
@@ -3204,8 +2977,7 @@ func addFileToDirectory(file *os.File, superblock *Structs.Superblock, dirInode
 	}
 
 	// Agregar la nueva entrada en la primera ranura
-	copy(newFolderBlock.B_content[0].B_name[:], fileName)
-	newFolderBlock.B_content[0].B_inodo = fileInode
+	newFolderBlock.B_content[0] = entry
 
 	// Escribir el nuevo bloque
 	blockPos := int64(superblock.S_block_start + freeBlock*superblock.S_block_size)
@@ -3216,17 +2988,16 @@ func addFileToDirectory(file *os.File, superblock *Structs.Superblock, dirInode
 	// Asignar el bloque al directorio
 	dirInodeStruct.I_block[freeSlot] = freeBlock
 	dirInodeStruct.I_size += 64 // Incrementar el tamaño del directorio
+	stampInodeModified(&dirInodeStruct)
 
 	// Actualizar el inodo del directorio
 	if err := Utilities.WriteObject(file, dirInodeStruct, inodePos); err != nil {
 		return false
 	}
 
-	// Marcar el bloque como ocupado
-	Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+freeBlock))
-
-	// Actualizar contador de bloques libres
-	superblock.S_free_blocks_count--
+	// Marcar el bloque como ocupado y guardar el contador en el superblock:
+	// quien llama ya escribió el suyo antes de agregar la entrada
+	markBlockAsUsed(file, superblock, freeBlock)
 
 	return true
 }
@@ -3236,99 +3007,99 @@ func addFileToDirectory(file *os.File, superblock *Structs.Superblock, dirInode
 // ============================================================================
 
 // Remove - Eliminar un archivo o directorio con validación de permisos
-func Remove(path string) {
-	fmt.Println("======Inicio REMOVE======")
-	fmt.Printf("Ruta: %s\n", path)
+func Remove(out io.Writer, session *Structs.UserSession, path string) error {
+	fmt.Fprintln(out, "======Inicio REMOVE======")
+	fmt.Fprintf(out, "Ruta: %s\n", path)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: No hay una sesión activa")
-		fmt.Println("Use el comando 'login' para iniciar sesión")
-		fmt.Println("======FIN REMOVE======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 	}
 
 	// Validar que la ruta no esté vacía
 	if strings.TrimSpace(path) == "" {
-		fmt.Println("Error: La ruta no puede estar vacía")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: La ruta no puede estar vacía")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta no puede estar vacía")
 	}
 
 	// Validar que la ruta empiece con "/"
 	if !strings.HasPrefix(path, "/") {
-		fmt.Println("Error: La ruta debe empezar con '/' (ruta absoluta)")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: La ruta debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta debe empezar con '/' (ruta absoluta)")
 	}
 
 	// No permitir eliminar la raíz
 	if path == "/" {
-		fmt.Println("Error: No se puede eliminar el directorio raíz")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se puede eliminar el directorio raíz")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se puede eliminar el directorio raíz")
 	}
 
 	// Buscar el archivo o directorio
-	exists, inodeNum := findFileInDirectory(CurrentSession.PartitionID, path)
+	exists, inodeNum := findFileInDirectory(session.PartitionID, path)
 	if !exists {
 		// Intentar buscar como directorio
-		exists, inodeNum = findDirectoryInPath(CurrentSession.PartitionID, path)
+		exists, inodeNum = findDirectoryInPath(session.PartitionID, path)
 		if !exists {
-			fmt.Printf("Error: La ruta '%s' no existe\n", path)
-			fmt.Println("======FIN REMOVE======")
-			return
+			fmt.Fprintf(out, "Error: La ruta '%s' no existe\n", path)
+			fmt.Fprintln(out, "======FIN REMOVE======")
+			return Utilities.NewCommandError(Utilities.ErrNotFound, "La ruta '%s' no existe", path)
 		}
 	}
 
 	// Leer el inodo para determinar el tipo
-	mountedPartition, exists := DiskManagement.MountedPartitions[CurrentSession.PartitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
 	if !exists {
-		fmt.Println("Error: Partición no encontrada")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: Partición no encontrada")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "Partición no encontrada")
 	}
 
 	file, err := Utilities.OpenFile(mountedPartition.Path)
 	if err != nil {
-		fmt.Println("Error: No se pudo abrir el disco")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo abrir el disco")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el disco")
 	}
 	defer file.Close()
 
-	superblock, err := ReadSuperblock(CurrentSession.PartitionID)
+	superblock, err := ReadSuperblock(session.PartitionID)
 	if err != nil {
-		fmt.Println("Error: No se pudo leer el superblock")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo leer el superblock")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock")
 	}
 
 	var inode Structs.Inode
 	inodePos := int64(superblock.S_inode_start + inodeNum*superblock.S_inode_size)
 	if err := Utilities.ReadObject(file, &inode, inodePos); err != nil {
-		fmt.Println("Error: No se pudo leer el inodo")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo leer el inodo")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el inodo")
 	}
 
 	isDirectory := string(inode.I_type[:1]) == "0"
 
 	// Verificar permisos de escritura
-	if !hasWritePermission(CurrentSession.PartitionID, inodeNum, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de escritura sobre '%s'\n", path)
-		fmt.Println("======FIN REMOVE======")
-		return
+	if !hasWritePermission(session.PartitionID, inodeNum, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura sobre '%s'\n", path)
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura sobre '%s'", path)
 	}
 
 	// Si es un directorio, validar permisos recursivamente ANTES de eliminar
 	if isDirectory {
-		canDelete, failedPath := canDeleteDirectoryRecursive(CurrentSession.PartitionID, inodeNum, path)
+		canDelete, failedPath := canDeleteDirectoryRecursive(session, session.PartitionID, inodeNum, path)
 		if !canDelete {
-			fmt.Printf("Error: No tiene permisos de escritura sobre '%s'\n", failedPath)
-			fmt.Println("No se eliminó nada")
-			fmt.Println("======FIN REMOVE======")
-			return
+			fmt.Fprintf(out, "Error: No tiene permisos de escritura sobre '%s'\n", failedPath)
+			fmt.Fprintln(out, "No se eliminó nada")
+			fmt.Fprintln(out, "======FIN REMOVE======")
+			return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura sobre '%s'", failedPath)
 		}
 	}
 
@@ -3336,18 +3107,18 @@ func Remove(path string) {
 	parentDir, itemName := parseFilePath(path)
 	
 	// Obtener el inodo del directorio padre
-	parentExists, parentInode := findDirectoryInPath(CurrentSession.PartitionID, parentDir)
+	parentExists, parentInode := findDirectoryInPath(session.PartitionID, parentDir)
 	if !parentExists {
-		fmt.Println("Error: Directorio padre no encontrado")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: Directorio padre no encontrado")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "Directorio padre no encontrado")
 	}
 
 	// Verificar permisos de escritura en el directorio padre
-	if !hasWritePermission(CurrentSession.PartitionID, parentInode, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de escritura en el directorio padre '%s'\n", parentDir)
-		fmt.Println("======FIN REMOVE======")
-		return
+	if !hasWritePermission(session.PartitionID, parentInode, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura en el directorio padre '%s'\n", parentDir)
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura en el directorio padre '%s'", parentDir)
 	}
 
 	// Eliminar según el tipo
@@ -3359,7 +3130,7 @@ func Remove(path string) {
 		if success {
 			// Remover la entrada del directorio padre
 			removeEntryFromParent(file, superblock, parentInode, itemName)
-			fmt.Printf("Directorio '%s' eliminado exitosamente\n", path)
+			fmt.Fprintf(out, "Directorio '%s' eliminado exitosamente\n", path)
 		}
 	} else {
 		success = deleteFile(file, superblock, inodeNum)
@@ -3367,19 +3138,16 @@ func Remove(path string) {
 		if success {
 			// Remover la entrada del directorio padre
 			removeEntryFromParent(file, superblock, parentInode, itemName)
-			fmt.Printf("Archivo '%s' eliminado exitosamente\n", path)
+			fmt.Fprintf(out, "Archivo '%s' eliminado exitosamente\n", path)
 		}
 	}
 
 	if !success {
-		fmt.Println("Error: No se pudo eliminar completamente")
-		fmt.Println("======FIN REMOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo eliminar completamente")
+		fmt.Fprintln(out, "======FIN REMOVE======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo eliminar completamente")
 	}
 
-	// Registrar en el journaling (EXT3)
-	writeToJournal(CurrentSession.PartitionID, "remove", path, itemType)
-
 	// Actualizar el superblock en disco
 	var partition Structs.Partition
 	var tempMBR Structs.MBR
@@ -3398,17 +3166,21 @@ func Remove(path string) {
 	
 	Utilities.WriteObject(file, superblock, superblockPos)
 
-	fmt.Println("=== ELIMINACIÓN EXITOSA ===")
-	fmt.Printf("Ruta: %s\n", path)
-	fmt.Printf("Tipo: %s\n", map[bool]string{true: "Directorio", false: "Archivo"}[isDirectory])
-	fmt.Printf("Inodos disponibles: %d\n", superblock.S_free_inodes_count)
-	fmt.Printf("Bloques disponibles: %d\n", superblock.S_free_blocks_count)
-	fmt.Println("======FIN REMOVE======")
+	// Registrar en el journaling (EXT3)
+	writeToJournal(out, session.PartitionID, "remove", path, itemType)
+
+	fmt.Fprintln(out, "=== ELIMINACIÓN EXITOSA ===")
+	fmt.Fprintf(out, "Ruta: %s\n", path)
+	fmt.Fprintf(out, "Tipo: %s\n", map[bool]string{true: "Directorio", false: "Archivo"}[isDirectory])
+	fmt.Fprintf(out, "Inodos disponibles: %d\n", superblock.S_free_inodes_count)
+	fmt.Fprintf(out, "Bloques disponibles: %d\n", superblock.S_free_blocks_count)
+	fmt.Fprintln(out, "======FIN REMOVE======")
+	return nil
 }
 
 // canDeleteDirectoryRecursive - Verificar si se puede eliminar un directorio y todo su contenido
-func canDeleteDirectoryRecursive(partitionID string, dirInode int32, dirPath string) (bool, string) {
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+func canDeleteDirectoryRecursive(session *Structs.UserSession, partitionID string, dirInode int32, dirPath string) (bool, string) {
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return false, dirPath
 	}
@@ -3445,7 +3217,7 @@ func canDeleteDirectoryRecursive(partitionID string, dirInode int32, dirPath str
 				continue
 			}
 
-			entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			entryName := EntryName(file, superblock, folderBlock.B_content[j])
 			
 			// Saltar . y ..
 			if entryName == "." || entryName == ".." {
@@ -3456,7 +3228,7 @@ func canDeleteDirectoryRecursive(partitionID string, dirInode int32, dirPath str
 			entryPath := dirPath + "/" + entryName
 
 			// Verificar permisos de escritura en esta entrada
-			if !hasWritePermission(partitionID, entryInode, CurrentSession.UserID, CurrentSession.GroupID) {
+			if !hasWritePermission(partitionID, entryInode, session.UserID, session.GroupID) {
 				return false, entryPath
 			}
 
@@ -3469,7 +3241,7 @@ func canDeleteDirectoryRecursive(partitionID string, dirInode int32, dirPath str
 
 			// Si es un directorio, verificar recursivamente
 			if string(entryInodeStruct.I_type[:1]) == "0" {
-				canDelete, failedPath := canDeleteDirectoryRecursive(partitionID, entryInode, entryPath)
+				canDelete, failedPath := canDeleteDirectoryRecursive(session, partitionID, entryInode, entryPath)
 				if !canDelete {
 					return false, failedPath
 				}
@@ -3489,14 +3261,8 @@ func deleteFile(file *os.File, superblock *Structs.Superblock, inodeNum int32) b
 		return false
 	}
 
-	// Liberar todos los bloques del archivo
-	for i := 0; i < 15 && inode.I_block[i] != -1; i++ {
-		blockNum := inode.I_block[i]
-		
-		// Marcar el bloque como libre en el bitmap
-		Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+blockNum))
-		superblock.S_free_blocks_count++
-	}
+	// Liberar todos los bloques del archivo (datos y apuntadores indirectos)
+	freeFileBlocks(file, superblock, &inode)
 
 	// Marcar el inodo como libre en el bitmap
 	Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_inode_start+inodeNum))
@@ -3528,7 +3294,7 @@ func deleteDirectoryRecursive(file *os.File, superblock *Structs.Superblock, dir
 				continue
 			}
 
-			entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			entryName := EntryName(file, superblock, folderBlock.B_content[j])
 			
 			// Saltar . y ..
 			if entryName == "." || entryName == ".." {
@@ -3551,6 +3317,9 @@ func deleteDirectoryRecursive(file *os.File, superblock *Structs.Superblock, dir
 				// Si es un archivo, eliminarlo
 				deleteFile(file, superblock, entryInode)
 			}
+
+			// Liberar el bloque de nombre de la entrada, si lo tiene
+			clearEntryName(file, superblock, &folderBlock.B_content[j])
 		}
 
 		// Liberar el bloque del directorio
@@ -3589,19 +3358,18 @@ func removeEntryFromParent(file *os.File, superblock *Structs.Superblock, parent
 				continue
 			}
 
-			currentName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			currentName := EntryName(file, superblock, folderBlock.B_content[j])
 			if currentName == entryName {
-				// Marcar la entrada como vacía
+				// Marcar la entrada como vacía y liberar su bloque de nombre
 				folderBlock.B_content[j].B_inodo = -1
-				for k := 0; k < 12; k++ {
-					folderBlock.B_content[j].B_name[k] = 0
-				}
+				clearEntryName(file, superblock, &folderBlock.B_content[j])
 
 				// Escribir el bloque actualizado
 				Utilities.WriteObject(file, folderBlock, blockPos)
 				
-				// Actualizar el tamaño del directorio padre
+				// Actualizar el tamaño y la fecha del directorio padre
 				parentInodeStruct.I_size -= 64
+				stampInodeModified(&parentInodeStruct)
 				Utilities.WriteObject(file, parentInodeStruct, inodePos)
 				
 				return true
@@ -3617,199 +3385,161 @@ func removeEntryFromParent(file *os.File, superblock *Structs.Superblock, parent
 // ============================================================================
 
 // Edit - Editar el contenido de un archivo existente
-func Edit(path string, contenidoPath string) {
-	fmt.Println("======Inicio EDIT======")
-	fmt.Printf("Ruta del archivo: %s\n", path)
-	fmt.Printf("Archivo de contenido: %s\n", contenidoPath)
+func Edit(out io.Writer, session *Structs.UserSession, path string, contenidoPath string) error {
+	fmt.Fprintln(out, "======Inicio EDIT======")
+	fmt.Fprintf(out, "Ruta del archivo: %s\n", path)
+	fmt.Fprintf(out, "Archivo de contenido: %s\n", contenidoPath)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: No hay una sesión activa")
-		fmt.Println("Use el comando 'login' para iniciar sesión")
-		fmt.Println("======FIN EDIT======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 	}
 
 	// Validar que la ruta no esté vacía
 	if strings.TrimSpace(path) == "" {
-		fmt.Println("Error: La ruta del archivo no puede estar vacía")
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintln(out, "Error: La ruta del archivo no puede estar vacía")
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta del archivo no puede estar vacía")
 	}
 
 	// Validar que la ruta empiece con "/"
 	if !strings.HasPrefix(path, "/") {
-		fmt.Println("Error: La ruta debe empezar con '/' (ruta absoluta)")
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintln(out, "Error: La ruta debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta debe empezar con '/' (ruta absoluta)")
 	}
 
 	// Verificar que el archivo de contenido local existe
 	if !fileExistsLocal(contenidoPath) {
-		fmt.Printf("Error: El archivo de contenido '%s' no existe en el sistema local\n", contenidoPath)
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintf(out, "Error: El archivo de contenido '%s' no existe en el sistema local\n", contenidoPath)
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo de contenido '%s' no existe en el sistema local", contenidoPath)
 	}
 
 	// Leer el contenido del archivo local
 	newContent, err := readLocalFile(contenidoPath)
 	if err != nil {
-		fmt.Printf("Error leyendo archivo de contenido '%s': %s\n", contenidoPath, err.Error())
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintf(out, "Error leyendo archivo de contenido '%s': %s\n", contenidoPath, err.Error())
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo de contenido '%s': %s", contenidoPath, err.Error())
 	}
 
+	return editFileContent(out, session, path, newContent)
+}
+
+// editFileContent reemplaza el contenido del archivo por newContent
+func editFileContent(out io.Writer, session *Structs.UserSession, path string, newContent []byte) error {
 	// Buscar el archivo en el sistema
-	exists, inodeNum := findFileInDirectory(CurrentSession.PartitionID, path)
+	exists, inodeNum := findFileInDirectory(session.PartitionID, path)
 	if !exists {
-		fmt.Printf("Error: El archivo '%s' no existe\n", path)
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintf(out, "Error: El archivo '%s' no existe\n", path)
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El archivo '%s' no existe", path)
 	}
 
 	// Verificar permisos de lectura
-	if !hasReadPermission(CurrentSession.PartitionID, inodeNum, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de lectura sobre el archivo '%s'\n", path)
-		fmt.Println("======FIN EDIT======")
-		return
+	if !hasReadPermission(session.PartitionID, inodeNum, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de lectura sobre el archivo '%s'\n", path)
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de lectura sobre el archivo '%s'", path)
 	}
 
 	// Verificar permisos de escritura
-	if !hasWritePermission(CurrentSession.PartitionID, inodeNum, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de escritura sobre el archivo '%s'\n", path)
-		fmt.Println("======FIN EDIT======")
-		return
+	if !hasWritePermission(session.PartitionID, inodeNum, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura sobre el archivo '%s'\n", path)
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura sobre el archivo '%s'", path)
 	}
 
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[CurrentSession.PartitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
 	if !exists {
-		fmt.Println("Error: Partición no encontrada")
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintln(out, "Error: Partición no encontrada")
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "Partición no encontrada")
 	}
 
 	// Abrir el archivo del disco
 	file, err := Utilities.OpenFile(mountedPartition.Path)
 	if err != nil {
-		fmt.Println("Error: No se pudo abrir el disco")
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo abrir el disco")
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el disco")
 	}
 	defer file.Close()
 
 	// Leer el superblock
-	superblock, err := ReadSuperblock(CurrentSession.PartitionID)
+	superblock, err := ReadSuperblock(session.PartitionID)
 	if err != nil {
-		fmt.Println("Error: No se pudo leer el superblock")
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo leer el superblock")
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock")
 	}
 
 	// Leer el inodo del archivo
 	var inode Structs.Inode
 	inodePos := int64(superblock.S_inode_start + inodeNum*superblock.S_inode_size)
 	if err := Utilities.ReadObject(file, &inode, inodePos); err != nil {
-		fmt.Println("Error: No se pudo leer el inodo del archivo")
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo leer el inodo del archivo")
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el inodo del archivo")
 	}
 
 	// Verificar que sea un archivo (no directorio)
 	if string(inode.I_type[:1]) == "0" {
-		fmt.Printf("Error: '%s' es un directorio, no un archivo\n", path)
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintf(out, "Error: '%s' es un directorio, no un archivo\n", path)
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "'%s' es un directorio, no un archivo", path)
 	}
 
 	// Guardar información del contenido antiguo
 	oldSize := inode.I_size
-	var oldBlocks []int32
-	for i := 0; i < 15 && inode.I_block[i] != -1; i++ {
-		oldBlocks = append(oldBlocks, inode.I_block[i])
-	}
-
-	// Liberar los bloques antiguos
-	for _, blockNum := range oldBlocks {
-		Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+blockNum))
-		superblock.S_free_blocks_count++
-	}
+	oldBlocks, _ := FileBlocks(file, superblock, &inode)
 
 	// Calcular cuántos bloques necesitamos para el nuevo contenido
 	contentSize := len(newContent)
-	blocksNeeded := (contentSize + 63) / 64 // Redondear hacia arriba
-	
-	if blocksNeeded > 15 {
-		fmt.Printf("Error: El contenido es demasiado grande (%d bytes)\n", contentSize)
-		fmt.Printf("Máximo soportado: 15 bloques × 64 bytes = 960 bytes\n")
-		fmt.Println("======FIN EDIT======")
-		return
-	}
+	blocksNeeded := blocksForSize(contentSize)
 
-	// Verificar que hay suficientes bloques libres
-	if superblock.S_free_blocks_count < int32(blocksNeeded) {
-		fmt.Printf("Error: No hay suficientes bloques libres (necesarios: %d, disponibles: %d)\n", 
-			blocksNeeded, superblock.S_free_blocks_count)
-		fmt.Println("======FIN EDIT======")
-		return
+	if contentSize > MaxFileSize {
+		fmt.Fprintf(out, "Error: El contenido es demasiado grande (%d bytes)\n", contentSize)
+		fmt.Fprintf(out, "Máximo soportado: %d bloques × %d bytes = %d bytes\n", MaxFileBlocks, fileBlockSize, MaxFileSize)
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "El contenido es demasiado grande (%d bytes)", contentSize)
 	}
 
-	// Asignar nuevos bloques y escribir el contenido
-	contentOffset := 0
-	for i := 0; i < blocksNeeded; i++ {
-		// Buscar un bloque libre
-		freeBlock := findFreeBlock(file, superblock)
-		if freeBlock == -1 {
-			fmt.Println("Error: No se pudo encontrar un bloque libre")
-			fmt.Println("======FIN EDIT======")
-			return
-		}
+	// Entrada del journaling (EXT3); si el contenido no cabe se guarda solo su tamaño
+	journalContent := fitJournalData(session.PartitionID, "edit", path, "", newContent)
 
-		// Crear un fileblock con el contenido
-		var fileBlock Structs.Fileblock
-		
-		// Copiar el contenido al bloque (máximo 64 bytes)
-		bytesToCopy := 64
-		remaining := contentSize - contentOffset
-		if remaining < 64 {
-			bytesToCopy = remaining
-		}
-		
-		copy(fileBlock.B_content[:], newContent[contentOffset:contentOffset+bytesToCopy])
-		contentOffset += bytesToCopy
-
-		// Escribir el bloque en el disco
-		blockPos := int64(superblock.S_block_start + freeBlock*superblock.S_block_size)
-		if err := Utilities.WriteObject(file, fileBlock, blockPos); err != nil {
-			fmt.Println("Error: No se pudo escribir el bloque")
-			fmt.Println("======FIN EDIT======")
-			return
-		}
-
-		// Marcar el bloque como ocupado
-		Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+freeBlock))
-		superblock.S_free_blocks_count--
+	// Liberar los bloques antiguos (datos y apuntadores)
+	freeFileBlocks(file, superblock, &inode)
 
-		// Asignar el bloque al inodo
-		inode.I_block[i] = freeBlock
+	// Verificar que hay suficientes bloques libres (datos + bloques de apuntadores)
+	requiredBlocks := blocksNeeded + pointerBlocksFor(blocksNeeded)
+	if superblock.S_free_blocks_count < int32(requiredBlocks) {
+		fmt.Fprintf(out, "Error: No hay suficientes bloques libres (necesarios: %d, disponibles: %d)\n", 
+			requiredBlocks, superblock.S_free_blocks_count)
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay suficientes bloques libres (necesarios: %d, disponibles: %d)", requiredBlocks, superblock.S_free_blocks_count)
 	}
 
-	// Marcar los bloques restantes como no usados
-	for i := blocksNeeded; i < 15; i++ {
-		inode.I_block[i] = -1
+	// Asignar nuevos bloques y escribir el contenido
+	if err := writeFileBlocks(file, superblock, &inode, newContent); err != nil {
+		fmt.Fprintf(out, "Error: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "%s", err.Error())
 	}
 
-	// Actualizar el tamaño del archivo
-	inode.I_size = int32(contentSize)
-
 	// Actualizar la fecha de modificación
-	copy(inode.I_mtime[:], "19/10/2025")
+	stampInodeModified(&inode)
 
 	// Escribir el inodo actualizado
 	if err := Utilities.WriteObject(file, inode, inodePos); err != nil {
-		fmt.Println("Error: No se pudo actualizar el inodo")
-		fmt.Println("======FIN EDIT======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo actualizar el inodo")
+		fmt.Fprintln(out, "======FIN EDIT======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo actualizar el inodo")
 	}
 
 	// Actualizar el superblock en el disco
@@ -3831,19 +3561,19 @@ func Edit(path string, contenidoPath string) {
 	Utilities.WriteObject(file, superblock, superblockPos)
 
 	// Registrar en el journaling (EXT3)
-	contentPreview := fmt.Sprintf("size=%d", contentSize)
-	writeToJournal(CurrentSession.PartitionID, "edit", path, contentPreview)
-
-	fmt.Println("=== ARCHIVO EDITADO EXITOSAMENTE ===")
-	fmt.Printf("Ruta: %s\n", path)
-	fmt.Printf("Tamaño anterior: %d bytes\n", oldSize)
-	fmt.Printf("Tamaño nuevo: %d bytes\n", contentSize)
-	fmt.Printf("Bloques anteriores: %d\n", len(oldBlocks))
-	fmt.Printf("Bloques nuevos: %d\n", blocksNeeded)
-	fmt.Printf("Bloques libres: %d\n", superblock.S_free_blocks_count)
-	fmt.Printf("Inodo: %d\n", inodeNum)
-	fmt.Printf("Fecha de modificación: %s\n", string(inode.I_mtime[:]))
-	fmt.Println("======FIN EDIT======")
+	writeToJournal(out, session.PartitionID, "edit", path, journalContent)
+
+	fmt.Fprintln(out, "=== ARCHIVO EDITADO EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Ruta: %s\n", path)
+	fmt.Fprintf(out, "Tamaño anterior: %d bytes\n", oldSize)
+	fmt.Fprintf(out, "Tamaño nuevo: %d bytes\n", contentSize)
+	fmt.Fprintf(out, "Bloques anteriores: %d\n", len(oldBlocks))
+	fmt.Fprintf(out, "Bloques nuevos: %d\n", blocksNeeded)
+	fmt.Fprintf(out, "Bloques libres: %d\n", superblock.S_free_blocks_count)
+	fmt.Fprintf(out, "Inodo: %d\n", inodeNum)
+	fmt.Fprintf(out, "Fecha de modificación: %s\n", string(inode.I_mtime[:]))
+	fmt.Fprintln(out, "======FIN EDIT======")
+	return nil
 }
 
 // ============================================================================
@@ -3851,73 +3581,72 @@ func Edit(path string, contenidoPath string) {
 // ============================================================================
 
 // Rename - Cambiar el nombre de un archivo o directorio
-func Rename(path string, newName string) {
-	fmt.Println("======Inicio RENAME======")
-	fmt.Printf("Ruta: %s\n", path)
-	fmt.Printf("Nuevo nombre: %s\n", newName)
+func Rename(out io.Writer, session *Structs.UserSession, path string, newName string) error {
+	fmt.Fprintln(out, "======Inicio RENAME======")
+	fmt.Fprintf(out, "Ruta: %s\n", path)
+	fmt.Fprintf(out, "Nuevo nombre: %s\n", newName)
 	
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: No hay una sesión activa")
-		fmt.Println("Use el comando 'login' para iniciar sesión")
-		fmt.Println("======FIN RENAME======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 	}
 
 	// Validar que la ruta no esté vacía
 	if strings.TrimSpace(path) == "" {
-		fmt.Println("Error: La ruta no puede estar vacía")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: La ruta no puede estar vacía")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta no puede estar vacía")
 	}
 
 	// Validar que el nuevo nombre no esté vacío
 	if strings.TrimSpace(newName) == "" {
-		fmt.Println("Error: El nuevo nombre no puede estar vacío")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: El nuevo nombre no puede estar vacío")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nuevo nombre no puede estar vacío")
 	}
 
 	// Validar que la ruta empiece con "/"
 	if !strings.HasPrefix(path, "/") {
-		fmt.Println("Error: La ruta debe empezar con '/' (ruta absoluta)")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: La ruta debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta debe empezar con '/' (ruta absoluta)")
 	}
 
 	// No permitir renombrar la raíz
 	if path == "/" {
-		fmt.Println("Error: No se puede renombrar el directorio raíz")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: No se puede renombrar el directorio raíz")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se puede renombrar el directorio raíz")
 	}
 
 	// Validar que el nuevo nombre no contenga "/"
 	if strings.Contains(newName, "/") {
-		fmt.Println("Error: El nuevo nombre no puede contener el carácter '/'")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: El nuevo nombre no puede contener el carácter '/'")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nuevo nombre no puede contener el carácter '/'")
 	}
 
-	// Validar longitud del nuevo nombre (máximo 12 caracteres para B_name)
-	if len(newName) > 12 {
-		fmt.Println("Error: El nuevo nombre es demasiado largo (máximo 12 caracteres)")
-		fmt.Printf("Longitud actual: %d caracteres\n", len(newName))
-		fmt.Println("======FIN RENAME======")
-		return
+	// Validar el nuevo nombre (los largos van a un bloque de nombre)
+	if err := ValidateEntryName(newName); err != nil {
+		fmt.Fprintf(out, "Error: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return err
 	}
 
 	// Buscar el archivo o directorio
-	exists, inodeNum := findFileInDirectory(CurrentSession.PartitionID, path)
+	exists, inodeNum := findFileInDirectory(session.PartitionID, path)
 	isFile := exists
 	
 	if !exists {
 		// Intentar buscar como directorio
-		exists, inodeNum = findDirectoryInPath(CurrentSession.PartitionID, path)
+		exists, inodeNum = findDirectoryInPath(session.PartitionID, path)
 		if !exists {
-			fmt.Printf("Error: La ruta '%s' no existe\n", path)
-			fmt.Println("======FIN RENAME======")
-			return
+			fmt.Fprintf(out, "Error: La ruta '%s' no existe\n", path)
+			fmt.Fprintln(out, "======FIN RENAME======")
+			return Utilities.NewCommandError(Utilities.ErrNotFound, "La ruta '%s' no existe", path)
 		}
 		isFile = false
 	}
@@ -3926,60 +3655,60 @@ func Rename(path string, newName string) {
 	parentDir, currentName := parseFilePath(path)
 	
 	// Obtener el inodo del directorio padre
-	parentExists, parentInode := findDirectoryInPath(CurrentSession.PartitionID, parentDir)
+	parentExists, parentInode := findDirectoryInPath(session.PartitionID, parentDir)
 	if !parentExists {
-		fmt.Println("Error: Directorio padre no encontrado")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: Directorio padre no encontrado")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "Directorio padre no encontrado")
 	}
 
 	// Verificar permisos de escritura sobre el directorio padre
 	// (Para renombrar se requiere permiso de escritura en el directorio que contiene el archivo)
-	if !hasWritePermission(CurrentSession.PartitionID, parentInode, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de escritura en el directorio '%s'\n", parentDir)
-		fmt.Println("======FIN RENAME======")
-		return
+	if !hasWritePermission(session.PartitionID, parentInode, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura en el directorio '%s'\n", parentDir)
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura en el directorio '%s'", parentDir)
 	}
 
 	// Verificar que no exista otro archivo/directorio con el nuevo nombre en el mismo directorio
-	existsWithNewName, _ := findInodeInDirectory(CurrentSession.PartitionID, parentInode, newName, false)
+	existsWithNewName, _ := findInodeInDirectory(session.PartitionID, parentInode, newName, false)
 	if existsWithNewName {
-		fmt.Printf("Error: Ya existe un archivo o directorio con el nombre '%s' en el directorio '%s'\n", newName, parentDir)
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintf(out, "Error: Ya existe un archivo o directorio con el nombre '%s' en el directorio '%s'\n", newName, parentDir)
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrAlreadyExists, "Ya existe un archivo o directorio con el nombre '%s' en el directorio '%s'", newName, parentDir)
 	}
 
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[CurrentSession.PartitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
 	if !exists {
-		fmt.Println("Error: Partición no encontrada")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: Partición no encontrada")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "Partición no encontrada")
 	}
 
 	// Abrir el archivo del disco
 	file, err := Utilities.OpenFile(mountedPartition.Path)
 	if err != nil {
-		fmt.Println("Error: No se pudo abrir el disco")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo abrir el disco")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el disco")
 	}
 	defer file.Close()
 
 	// Leer el superblock
-	superblock, err := ReadSuperblock(CurrentSession.PartitionID)
+	superblock, err := ReadSuperblock(session.PartitionID)
 	if err != nil {
-		fmt.Println("Error: No se pudo leer el superblock")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo leer el superblock")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock")
 	}
 
 	// Buscar y actualizar el nombre en el directorio padre
-	success := updateNameInParentDirectory(file, superblock, parentInode, currentName, newName)
+	success := updateNameInParentDirectory(out, file, superblock, parentInode, currentName, newName)
 	if !success {
-		fmt.Println("Error: No se pudo actualizar el nombre en el directorio padre")
-		fmt.Println("======FIN RENAME======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo actualizar el nombre en el directorio padre")
+		fmt.Fprintln(out, "======FIN RENAME======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo actualizar el nombre en el directorio padre")
 	}
 
 	// Determinar el tipo (archivo o directorio)
@@ -3991,20 +3720,21 @@ func Rename(path string, newName string) {
 
 	// Registrar en el journaling (EXT3)
 	contentInfo := fmt.Sprintf("%s->%s", currentName, newName)
-	writeToJournal(CurrentSession.PartitionID, "rename", path, contentInfo)
-
-	fmt.Println("=== RENOMBRADO EXITOSO ===")
-	fmt.Printf("Ruta original: %s\n", path)
-	fmt.Printf("Nombre anterior: %s\n", currentName)
-	fmt.Printf("Nombre nuevo: %s\n", newName)
-	fmt.Printf("Ruta nueva: %s/%s\n", parentDir, newName)
-	fmt.Printf("Tipo: %s\n", map[bool]string{true: "Archivo", false: "Directorio"}[isFile])
-	fmt.Printf("Inodo: %d\n", inodeNum)
-	fmt.Println("======FIN RENAME======")
+	writeToJournal(out, session.PartitionID, "rename", path, contentInfo)
+
+	fmt.Fprintln(out, "=== RENOMBRADO EXITOSO ===")
+	fmt.Fprintf(out, "Ruta original: %s\n", path)
+	fmt.Fprintf(out, "Nombre anterior: %s\n", currentName)
+	fmt.Fprintf(out, "Nombre nuevo: %s\n", newName)
+	fmt.Fprintf(out, "Ruta nueva: %s/%s\n", parentDir, newName)
+	fmt.Fprintf(out, "Tipo: %s\n", map[bool]string{true: "Archivo", false: "Directorio"}[isFile])
+	fmt.Fprintf(out, "Inodo: %d\n", inodeNum)
+	fmt.Fprintln(out, "======FIN RENAME======")
+	return nil
 }
 
 // updateNameInParentDirectory - Actualizar el nombre de una entrada en el directorio padre
-func updateNameInParentDirectory(file *os.File, superblock *Structs.Superblock, parentInode int32, oldName string, newName string) bool {
+func updateNameInParentDirectory(out io.Writer, file *os.File, superblock *Structs.Superblock, parentInode int32, oldName string, newName string) bool {
 	// Leer el inodo del directorio padre
 	var parentInodeStruct Structs.Inode
 	inodePos := int64(superblock.S_inode_start + parentInode*superblock.S_inode_size)
@@ -4026,21 +3756,23 @@ func updateNameInParentDirectory(file *os.File, superblock *Structs.Superblock,
 				continue
 			}
 
-			currentName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			currentName := EntryName(file, superblock, folderBlock.B_content[j])
 			if currentName == oldName {
-				// Actualizar el nombre
-				// Limpiar el nombre anterior
-				for k := 0; k < 12; k++ {
-					folderBlock.B_content[j].B_name[k] = 0
+				// Guardar el nuevo nombre antes de soltar el anterior: si no
+				// hay bloque para un nombre largo la entrada queda intacta
+				var renamed Structs.Content
+				if err := setEntryName(file, superblock, &renamed, newName); err != nil {
+					fmt.Fprintf(out, "Error: %s\n", err.Error())
+					return false
 				}
-				
-				// Copiar el nuevo nombre
-				copy(folderBlock.B_content[j].B_name[:], newName)
+				clearEntryName(file, superblock, &folderBlock.B_content[j])
+				folderBlock.B_content[j].B_name = renamed.B_name
 
 				// Escribir el bloque actualizado
 				if err := Utilities.WriteObject(file, folderBlock, blockPos); err != nil {
 					return false
 				}
+				touchInode(file, superblock, parentInode, stampInodeModified)
 				
 				// Sincronizar cambios al disco
 				file.Sync()
@@ -4057,53 +3789,53 @@ func updateNameInParentDirectory(file *os.File, superblock *Structs.Superblock,
 // ============================================================================
 
 // Copy - Copiar archivo o directorio con todo su contenido
-func Copy(path string, destino string) {
-fmt.Println("======Inicio COPY======")
-fmt.Printf("Origen: %s\n", path)
-fmt.Printf("Destino: %s\n", destino)
+func Copy(out io.Writer, session *Structs.UserSession, path string, destino string) error {
+fmt.Fprintln(out, "======Inicio COPY======")
+fmt.Fprintf(out, "Origen: %s\n", path)
+fmt.Fprintf(out, "Destino: %s\n", destino)
 
 // Verificar que haya una sesión activa
-if !IsUserLoggedIn() {
-fmt.Println("Error: No hay una sesión activa")
-fmt.Println("Use el comando 'login' para iniciar sesión")
-fmt.Println("======FIN COPY======")
-return
+if !IsUserLoggedIn(session) {
+fmt.Fprintln(out, "Error: No hay una sesión activa")
+fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 }
 
 // Validar que las rutas no estén vacías
 if strings.TrimSpace(path) == "" {
-fmt.Println("Error: La ruta de origen no puede estar vacía")
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintln(out, "Error: La ruta de origen no puede estar vacía")
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta de origen no puede estar vacía")
 }
 
 if strings.TrimSpace(destino) == "" {
-fmt.Println("Error: La ruta de destino no puede estar vacía")
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintln(out, "Error: La ruta de destino no puede estar vacía")
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta de destino no puede estar vacía")
 }
 
 // Validar que las rutas empiecen con "/"
 if !strings.HasPrefix(path, "/") {
-fmt.Println("Error: La ruta de origen debe empezar con '/' (ruta absoluta)")
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintln(out, "Error: La ruta de origen debe empezar con '/' (ruta absoluta)")
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta de origen debe empezar con '/' (ruta absoluta)")
 }
 
 if !strings.HasPrefix(destino, "/") {
-fmt.Println("Error: La ruta de destino debe empezar con '/' (ruta absoluta)")
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintln(out, "Error: La ruta de destino debe empezar con '/' (ruta absoluta)")
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta de destino debe empezar con '/' (ruta absoluta)")
 }
 
 // Buscar el archivo o directorio de origen
-existsFile, sourceInodeFile := findFileInDirectory(CurrentSession.PartitionID, path)
-existsDir, sourceInodeDir := findDirectoryInPath(CurrentSession.PartitionID, path)
+existsFile, sourceInodeFile := findFileInDirectory(session.PartitionID, path)
+existsDir, sourceInodeDir := findDirectoryInPath(session.PartitionID, path)
 
 if !existsFile && !existsDir {
-fmt.Printf("Error: La ruta de origen '%s' no existe\n", path)
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintf(out, "Error: La ruta de origen '%s' no existe\n", path)
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrNotFound, "La ruta de origen '%s' no existe", path)
 }
 
 // Determinar si es archivo o directorio
@@ -4114,59 +3846,59 @@ sourceInode = sourceInodeDir
 }
 
 // Verificar permisos de lectura sobre el origen
-if !hasReadPermission(CurrentSession.PartitionID, sourceInode, CurrentSession.UserID, CurrentSession.GroupID) {
-fmt.Printf("Error: No tiene permisos de lectura sobre '%s'\n", path)
-fmt.Println("======FIN COPY======")
-return
+if !hasReadPermission(session.PartitionID, sourceInode, session.UserID, session.GroupID) {
+fmt.Fprintf(out, "Error: No tiene permisos de lectura sobre '%s'\n", path)
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de lectura sobre '%s'", path)
 }
 
 // Verificar que el directorio destino exista
-existsDestDir, destDirInode := findDirectoryInPath(CurrentSession.PartitionID, destino)
+existsDestDir, destDirInode := findDirectoryInPath(session.PartitionID, destino)
 if !existsDestDir {
-fmt.Printf("Error: El directorio de destino '%s' no existe\n", destino)
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintf(out, "Error: El directorio de destino '%s' no existe\n", destino)
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrNotFound, "El directorio de destino '%s' no existe", destino)
 }
 
 // Verificar permisos de escritura sobre el destino
-if !hasWritePermission(CurrentSession.PartitionID, destDirInode, CurrentSession.UserID, CurrentSession.GroupID) {
-fmt.Printf("Error: No tiene permisos de escritura en el directorio de destino '%s'\n", destino)
-fmt.Println("======FIN COPY======")
-return
+if !hasWritePermission(session.PartitionID, destDirInode, session.UserID, session.GroupID) {
+fmt.Fprintf(out, "Error: No tiene permisos de escritura en el directorio de destino '%s'\n", destino)
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura en el directorio de destino '%s'", destino)
 }
 
 // Obtener el nombre del archivo/directorio a copiar
 _, sourceName := parseFilePath(path)
 
 // Verificar que no exista ya un archivo/directorio con ese nombre en destino
-existsInDest, _ := findInodeInDirectory(CurrentSession.PartitionID, destDirInode, sourceName, false)
+existsInDest, _ := findInodeInDirectory(session.PartitionID, destDirInode, sourceName, false)
 if existsInDest {
-fmt.Printf("Error: Ya existe un archivo o directorio con el nombre '%s' en '%s'\n", sourceName, destino)
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintf(out, "Error: Ya existe un archivo o directorio con el nombre '%s' en '%s'\n", sourceName, destino)
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrAlreadyExists, "Ya existe un archivo o directorio con el nombre '%s' en '%s'", sourceName, destino)
 }
 
 // Obtener información de la partición
-mountedPartition, exists := DiskManagement.MountedPartitions[CurrentSession.PartitionID]
+mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
 if !exists {
-fmt.Println("Error: Partición no encontrada")
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintln(out, "Error: Partición no encontrada")
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrNotFound, "Partición no encontrada")
 }
 
 file, err := Utilities.OpenFile(mountedPartition.Path)
 if err != nil {
-fmt.Println("Error: No se pudo abrir el disco")
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintln(out, "Error: No se pudo abrir el disco")
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el disco")
 }
 defer file.Close()
 
-superblock, err := ReadSuperblock(CurrentSession.PartitionID)
+superblock, err := ReadSuperblock(session.PartitionID)
 if err != nil {
-fmt.Println("Error: No se pudo leer el superblock")
-fmt.Println("======FIN COPY======")
-return
+fmt.Fprintln(out, "Error: No se pudo leer el superblock")
+fmt.Fprintln(out, "======FIN COPY======")
+return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock")
 }
 
 // Realizar la copia según el tipo
@@ -4175,107 +3907,66 @@ var copiedCount int
 var skippedCount int
 
 if isFile {
-success, copiedCount, skippedCount = copyFileInternal(file, superblock, sourceInode, destDirInode, sourceName)
+success, copiedCount, skippedCount = copyFileInternal(out, session, file, superblock, sourceInode, destDirInode, sourceName)
 } else {
-success, copiedCount, skippedCount = copyDirectoryInternal(file, superblock, sourceInode, destDirInode, sourceName, path, 0)
+success, copiedCount, skippedCount = copyDirectoryInternal(out, session, file, superblock, sourceInode, destDirInode, sourceName, path, 0)
 }
 
 if success {
 	// Registrar en el journaling (EXT3)
 	contentInfo := fmt.Sprintf("%s->%s", path, destino)
-	writeToJournal(CurrentSession.PartitionID, "copy", path, contentInfo)
+	writeToJournal(out, session.PartitionID, "copy", path, contentInfo)
 	
-	fmt.Println("\n=== COPIA COMPLETADA ===")
-	fmt.Printf("Origen: %s\n", path)
-	fmt.Printf("Destino: %s/%s\n", destino, sourceName)
-fmt.Printf("Tipo: %s\n", map[bool]string{true: "Archivo", false: "Directorio"}[isFile])
-fmt.Printf("Elementos copiados: %d\n", copiedCount)
+	fmt.Fprintln(out, "\n=== COPIA COMPLETADA ===")
+	fmt.Fprintf(out, "Origen: %s\n", path)
+	fmt.Fprintf(out, "Destino: %s/%s\n", destino, sourceName)
+fmt.Fprintf(out, "Tipo: %s\n", map[bool]string{true: "Archivo", false: "Directorio"}[isFile])
+fmt.Fprintf(out, "Elementos copiados: %d\n", copiedCount)
 if skippedCount > 0 {
-fmt.Printf("Elementos omitidos (sin permisos de lectura): %d\n", skippedCount)
+fmt.Fprintf(out, "Elementos omitidos (sin permisos de lectura): %d\n", skippedCount)
 }
 } else {
-fmt.Println("\nError: No se pudo completar la copia")
+fmt.Fprintln(out, "\nError: No se pudo completar la copia")
 }
 
-fmt.Println("======FIN COPY======")
+fmt.Fprintln(out, "======FIN COPY======")
+	return nil
 }
 
 // copyFileInternal - Copiar un archivo individual
-func copyFileInternal(file *os.File, superblock *Structs.Superblock, sourceInode int32, destDirInode int32, fileName string) (bool, int, int) {
+func copyFileInternal(out io.Writer, session *Structs.UserSession, file *os.File, superblock *Structs.Superblock, sourceInode int32, destDirInode int32, fileName string) (bool, int, int) {
 // Leer el inodo del archivo origen
 var srcInode Structs.Inode
 srcInodePos := int64(superblock.S_inode_start + sourceInode*superblock.S_inode_size)
 if err := Utilities.ReadObject(file, &srcInode, srcInodePos); err != nil {
-fmt.Printf("Error al leer inodo del archivo origen\n")
+fmt.Fprintf(out, "Error al leer inodo del archivo origen\n")
 return false, 0, 0
 }
 
-// Obtener el tamaño del archivo
-fileSize := srcInode.I_size
-
 // Asignar un nuevo inodo para el archivo destino
 newInodeIndex := findFreeInode(file, superblock)
 if newInodeIndex == -1 {
-fmt.Println("Error: No hay inodos disponibles")
+fmt.Fprintln(out, "Error: No hay inodos disponibles")
 return false, 0, 0
 }
 
-// Leer todo el contenido del archivo origen
-content := make([]byte, fileSize)
-bytesRead := int32(0)
-
-for i := 0; i < 15 && srcInode.I_block[i] != -1 && bytesRead < fileSize; i++ {
-var fileBlock Structs.Fileblock
-blockPos := int64(superblock.S_block_start + srcInode.I_block[i]*superblock.S_block_size)
-if err := Utilities.ReadObject(file, &fileBlock, blockPos); err != nil {
-continue
-}
-
-// Copiar el contenido del bloque
-remaining := fileSize - bytesRead
-toCopy := int32(64)
-if remaining < toCopy {
-toCopy = remaining
-}
-
-copy(content[bytesRead:bytesRead+toCopy], fileBlock.B_content[:toCopy])
-bytesRead += toCopy
-}
-
-// Calcular cuántos bloques necesitamos
-blocksNeeded := (fileSize + 63) / 64
-blockIndices := make([]int32, blocksNeeded)
-
-// Asignar bloques para el nuevo archivo
-for i := int32(0); i < blocksNeeded; i++ {
-blockIndices[i] = findFreeBlock(file, superblock)
-if blockIndices[i] == -1 {
-fmt.Println("Error: No hay bloques disponibles")
-// Liberar recursos ya asignados
-markInodeAsFree(file, superblock, newInodeIndex)
-for j := int32(0); j < i; j++ {
-markBlockAsFree(file, superblock, blockIndices[j])
-}
+// Leer todo el contenido del archivo origen (bloques directos e indirectos)
+content, err := readFileBlocks(file, superblock, &srcInode)
+if err != nil {
+fmt.Fprintf(out, "Error al leer contenido del archivo origen: %s\n", err.Error())
 return false, 0, 0
 }
-// Marcar bloque como usado
-markBlockAsUsed(file, superblock, blockIndices[i])
-}
 
 // Marcar inodo como usado
 markInodeAsUsed(file, superblock, newInodeIndex)
 
 	// Crear el nuevo inodo
 	var newInode Structs.Inode
-	newInode.I_uid = int32(CurrentSession.UserID)
-	newInode.I_gid = int32(CurrentSession.GroupID)
-	newInode.I_size = fileSize
+	newInode.I_uid = int32(session.UserID)
+	newInode.I_gid = int32(session.GroupID)
 	
 	// Configurar fechas
-	currentDate := "02/09/2025"
-	copy(newInode.I_atime[:], currentDate)
-	copy(newInode.I_ctime[:], currentDate)
-	copy(newInode.I_mtime[:], currentDate)
+	stampInodeCreated(&newInode)
 
 	for i := 0; i < 15; i++ {
 		newInode.I_block[i] = -1
@@ -4285,53 +3976,34 @@ markInodeAsUsed(file, superblock, newInodeIndex)
 
 	// Permisos: copiar los permisos del archivo original
 	copy(newInode.I_perm[:], srcInode.I_perm[:])
-// Asignar los bloques al inodo
-for i := int32(0); i < blocksNeeded && i < 15; i++ {
-newInode.I_block[i] = blockIndices[i]
-}
-
-// Escribir el contenido en los bloques del nuevo archivo
-bytesWritten := int32(0)
-for i := int32(0); i < blocksNeeded; i++ {
-var fileBlock Structs.Fileblock
-
-remaining := fileSize - bytesWritten
-toWrite := int32(64)
-if remaining < toWrite {
-toWrite = remaining
-}
-
-copy(fileBlock.B_content[:toWrite], content[bytesWritten:bytesWritten+toWrite])
-
-blockPos := int64(superblock.S_block_start + blockIndices[i]*superblock.S_block_size)
-if err := Utilities.WriteObject(file, fileBlock, blockPos); err != nil {
-fmt.Printf("Error al escribir bloque de archivo\n")
+// Asignar los bloques y escribir el contenido en el nuevo archivo
+if err := writeFileBlocks(file, superblock, &newInode, content); err != nil {
+fmt.Fprintf(out, "Error: %s\n", err.Error())
+markInodeAsFree(file, superblock, newInodeIndex)
 return false, 0, 0
 }
-
-bytesWritten += toWrite
-}
+Utilities.WriteObject(file, *superblock, superblockPosition(superblock))
 
 // Escribir el nuevo inodo
 newInodePos := int64(superblock.S_inode_start + newInodeIndex*superblock.S_inode_size)
 if err := Utilities.WriteObject(file, newInode, newInodePos); err != nil {
-fmt.Printf("Error al escribir nuevo inodo\n")
+fmt.Fprintf(out, "Error al escribir nuevo inodo\n")
 return false, 0, 0
 }
 
 	// Agregar la entrada en el directorio destino
-	if !addFileToDirectory(file, superblock, destDirInode, fileName, newInodeIndex) {
-		fmt.Printf("Error al agregar entrada al directorio destino\n")
+	if !addFileToDirectory(out, file, superblock, destDirInode, fileName, newInodeIndex) {
+		fmt.Fprintf(out, "Error al agregar entrada al directorio destino\n")
 		return false, 0, 0
 	}
 
 	return true, 1, 0
 }
 // copyDirectoryInternal - Copiar un directorio recursivamente
-func copyDirectoryInternal(file *os.File, superblock *Structs.Superblock, sourceInode int32, destDirInode int32, dirName string, originalPath string, depth int) (bool, int, int) {
+func copyDirectoryInternal(out io.Writer, session *Structs.UserSession, file *os.File, superblock *Structs.Superblock, sourceInode int32, destDirInode int32, dirName string, originalPath string, depth int) (bool, int, int) {
 // Limitar profundidad para evitar recursión infinita
 if depth > 50 {
-fmt.Println("Error: Profundidad máxima de recursión alcanzada")
+fmt.Fprintln(out, "Error: Profundidad máxima de recursión alcanzada")
 return false, 0, 0
 }
 
@@ -4341,14 +4013,14 @@ skippedCount := 0
 // Crear el nuevo directorio en el destino
 newDirInode := findFreeInode(file, superblock)
 if newDirInode == -1 {
-fmt.Println("Error: No hay inodos disponibles para el directorio")
+fmt.Fprintln(out, "Error: No hay inodos disponibles para el directorio")
 return false, 0, 0
 }
 
 // Asignar un bloque para el nuevo directorio
 newDirBlock := findFreeBlock(file, superblock)
 if newDirBlock == -1 {
-fmt.Println("Error: No hay bloques disponibles para el directorio")
+fmt.Fprintln(out, "Error: No hay bloques disponibles para el directorio")
 return false, 0, 0
 }
 
@@ -4360,21 +4032,18 @@ markBlockAsUsed(file, superblock, newDirBlock)
 var srcDirInode Structs.Inode
 srcInodePos := int64(superblock.S_inode_start + sourceInode*superblock.S_inode_size)
 if err := Utilities.ReadObject(file, &srcDirInode, srcInodePos); err != nil {
-fmt.Printf("Error al leer inodo del directorio origen\n")
+fmt.Fprintf(out, "Error al leer inodo del directorio origen\n")
 return false, 0, 0
 }
 
 	// Crear el nuevo inodo del directorio
 	var newInode Structs.Inode
-	newInode.I_uid = int32(CurrentSession.UserID)
-	newInode.I_gid = int32(CurrentSession.GroupID)
+	newInode.I_uid = int32(session.UserID)
+	newInode.I_gid = int32(session.GroupID)
 	newInode.I_size = 0
 	
 	// Configurar fechas
-	currentDate := "02/09/2025"
-	copy(newInode.I_atime[:], currentDate)
-	copy(newInode.I_ctime[:], currentDate)
-	copy(newInode.I_mtime[:], currentDate)
+	stampInodeCreated(&newInode)
 
 	for i := 0; i < 15; i++ {
 		newInode.I_block[i] = -1
@@ -4402,20 +4071,20 @@ folderBlock.B_content[1].B_inodo = destDirInode
 // Escribir el bloque del directorio
 blockPos := int64(superblock.S_block_start + newDirBlock*superblock.S_block_size)
 if err := Utilities.WriteObject(file, folderBlock, blockPos); err != nil {
-fmt.Printf("Error al escribir bloque del directorio\n")
+fmt.Fprintf(out, "Error al escribir bloque del directorio\n")
 return false, 0, 0
 }
 
 // Escribir el inodo del directorio
 newInodePos := int64(superblock.S_inode_start + newDirInode*superblock.S_inode_size)
 if err := Utilities.WriteObject(file, newInode, newInodePos); err != nil {
-fmt.Printf("Error al escribir inodo del directorio\n")
+fmt.Fprintf(out, "Error al escribir inodo del directorio\n")
 return false, 0, 0
 }
 
 	// Agregar la entrada en el directorio destino
-	if !addFileToDirectory(file, superblock, destDirInode, dirName, newDirInode) {
-		fmt.Printf("Error al agregar directorio al destino\n")
+	if !addFileToDirectory(out, file, superblock, destDirInode, dirName, newDirInode) {
+		fmt.Fprintf(out, "Error al agregar directorio al destino\n")
 		return false, 0, 0
 	}
 
@@ -4433,7 +4102,7 @@ if srcFolderBlock.B_content[j].B_inodo == -1 {
 continue
 }
 
-entryName := strings.TrimRight(string(srcFolderBlock.B_content[j].B_name[:]), "\x00")
+entryName := EntryName(file, superblock, srcFolderBlock.B_content[j])
 
 // Saltar . y ..
 if entryName == "." || entryName == ".." {
@@ -4443,8 +4112,8 @@ continue
 entryInode := srcFolderBlock.B_content[j].B_inodo
 
 // Verificar permisos de lectura sobre este elemento
-if !hasReadPermission(CurrentSession.PartitionID, entryInode, CurrentSession.UserID, CurrentSession.GroupID) {
-fmt.Printf("⚠ Omitiendo '%s' (sin permisos de lectura)\n", entryName)
+if !hasReadPermission(session.PartitionID, entryInode, session.UserID, session.GroupID) {
+fmt.Fprintf(out, "⚠ Omitiendo '%s' (sin permisos de lectura)\n", entryName)
 skippedCount++
 continue
 }
@@ -4461,8 +4130,8 @@ isFile := string(entryInodeStruct.I_type[:1]) == "1"
 
 if isFile {
 // Copiar archivo
-fmt.Printf("📄 Copiando archivo: %s\n", entryName)
-success, copied, skipped := copyFileInternal(file, superblock, entryInode, newDirInode, entryName)
+fmt.Fprintf(out, "📄 Copiando archivo: %s\n", entryName)
+success, copied, skipped := copyFileInternal(out, session, file, superblock, entryInode, newDirInode, entryName)
 if success {
 copiedCount += copied
 skippedCount += skipped
@@ -4471,9 +4140,9 @@ skippedCount++
 }
 } else {
 // Copiar subdirectorio recursivamente
-fmt.Printf("📁 Copiando directorio: %s\n", entryName)
+fmt.Fprintf(out, "📁 Copiando directorio: %s\n", entryName)
 subPath := originalPath + "/" + entryName
-success, copied, skipped := copyDirectoryInternal(file, superblock, entryInode, newDirInode, entryName, subPath, depth+1)
+success, copied, skipped := copyDirectoryInternal(out, session, file, superblock, entryInode, newDirInode, entryName, subPath, depth+1)
 if success {
 copiedCount += copied
 skippedCount += skipped
@@ -4490,83 +4159,98 @@ return true, copiedCount, skippedCount
 // Funciones auxiliares para manejo de bitmaps
 
 func markInodeAsUsed(file *os.File, superblock *Structs.Superblock, inodeIndex int32) {
-// Marcar bit como 1 en el bitmap de inodos
-bytePos := inodeIndex / 8
-bitPos := inodeIndex % 8
-
-var bitmap byte
-bitmapPos := int64(superblock.S_bm_inode_start + bytePos)
-file.Seek(bitmapPos, 0)
-binary.Read(file, binary.LittleEndian, &bitmap)
+	// Cada inodo ocupa un byte en el bitmap, igual que en findFreeInode
+	Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_inode_start+inodeIndex))
 
-bitmap |= (1 << uint(bitPos))
-
-file.Seek(bitmapPos, 0)
-binary.Write(file, binary.LittleEndian, bitmap)
-
-// Actualizar contador en superblock
-superblock.S_free_inodes_count--
-Utilities.WriteObject(file, superblock, int64(superblock.S_inode_start) - int64(binary.Size(superblock)))
+	// Actualizar contador en superblock
+	superblock.S_free_inodes_count--
+	Utilities.WriteObject(file, *superblock, superblockPosition(superblock))
 }
 
 func markBlockAsUsed(file *os.File, superblock *Structs.Superblock, blockIndex int32) {
-// Marcar bit como 1 en el bitmap de bloques
-bytePos := blockIndex / 8
-bitPos := blockIndex % 8
+	// Cada bloque ocupa un byte en el bitmap, igual que en findFreeBlock
+	Utilities.WriteObject(file, byte(1), int64(superblock.S_bm_block_start+blockIndex))
 
-var bitmap byte
-bitmapPos := int64(superblock.S_bm_block_start + bytePos)
-file.Seek(bitmapPos, 0)
-binary.Read(file, binary.LittleEndian, &bitmap)
-
-bitmap |= (1 << uint(bitPos))
-
-file.Seek(bitmapPos, 0)
-binary.Write(file, binary.LittleEndian, bitmap)
-
-// Actualizar contador en superblock
-superblock.S_free_blocks_count--
-Utilities.WriteObject(file, superblock, int64(superblock.S_inode_start) - int64(binary.Size(superblock)))
+	// Actualizar contador en superblock
+	superblock.S_free_blocks_count--
+	Utilities.WriteObject(file, *superblock, superblockPosition(superblock))
 }
 
 func markInodeAsFree(file *os.File, superblock *Structs.Superblock, inodeIndex int32) {
-// Marcar bit como 0 en el bitmap de inodos
-bytePos := inodeIndex / 8
-bitPos := inodeIndex % 8
+	Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_inode_start+inodeIndex))
 
-var bitmap byte
-bitmapPos := int64(superblock.S_bm_inode_start + bytePos)
-file.Seek(bitmapPos, 0)
-binary.Read(file, binary.LittleEndian, &bitmap)
+	// Actualizar contador en superblock
+	superblock.S_free_inodes_count++
+	Utilities.WriteObject(file, *superblock, superblockPosition(superblock))
+}
 
-bitmap &= ^(1 << uint(bitPos))
+func markBlockAsFree(file *os.File, superblock *Structs.Superblock, blockIndex int32) {
+	Utilities.WriteObject(file, byte(0), int64(superblock.S_bm_block_start+blockIndex))
 
-file.Seek(bitmapPos, 0)
-binary.Write(file, binary.LittleEndian, bitmap)
+	// Actualizar contador en superblock
+	superblock.S_free_blocks_count++
+	Utilities.WriteObject(file, *superblock, superblockPosition(superblock))
+}
 
-// Actualizar contador en superblock
-superblock.S_free_inodes_count++
-Utilities.WriteObject(file, superblock, int64(superblock.S_inode_start) - int64(binary.Size(superblock)))
+// superblockPosition calcula dónde está el superblock a partir de sus propias
+// posiciones: antes del bitmap de inodos (y del journaling en EXT3)
+func superblockPosition(superblock *Structs.Superblock) int64 {
+	position := int64(superblock.S_bm_inode_start) - int64(binary.Size(Structs.Superblock{}))
+	if superblock.S_filesystem_type == 3 {
+		position -= int64(journalCapacity(superblock)) * int64(binary.Size(Structs.Journaling{}))
+	}
+	return position
 }
 
-func markBlockAsFree(file *os.File, superblock *Structs.Superblock, blockIndex int32) {
-// Marcar bit como 0 en el bitmap de bloques
-bytePos := blockIndex / 8
-bitPos := blockIndex % 8
+// stampInodeCreated pone la hora actual en las tres fechas de un inodo nuevo
+func stampInodeCreated(inode *Structs.Inode) {
+	now := Utilities.CurrentTimestamp()
+	copy(inode.I_atime[:], now)
+	copy(inode.I_ctime[:], now)
+	copy(inode.I_mtime[:], now)
+}
 
-var bitmap byte
-bitmapPos := int64(superblock.S_bm_block_start + bytePos)
-file.Seek(bitmapPos, 0)
-binary.Read(file, binary.LittleEndian, &bitmap)
+// stampInodeModified actualiza la fecha de modificación (del contenido o de
+// los metadatos: permisos, propietario, entradas de un directorio)
+func stampInodeModified(inode *Structs.Inode) {
+	now := Utilities.CurrentTimestamp()
+	copy(inode.I_atime[:], now)
+	copy(inode.I_mtime[:], now)
+}
 
-bitmap &= ^(1 << uint(bitPos))
+// stampInodeAccessed actualiza la fecha de último acceso
+func stampInodeAccessed(inode *Structs.Inode) {
+	copy(inode.I_atime[:], Utilities.CurrentTimestamp())
+}
 
-file.Seek(bitmapPos, 0)
-binary.Write(file, binary.LittleEndian, bitmap)
+// touchPartitionInode aplica stamp a un inodo de la partición montada
+func touchPartitionInode(partitionID string, inodeIndex int32, stamp func(*Structs.Inode)) {
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
+	if !exists {
+		return
+	}
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
 
-	// Actualizar contador en superblock
-	superblock.S_free_blocks_count++
-	Utilities.WriteObject(file, superblock, int64(superblock.S_inode_start) - int64(binary.Size(superblock)))
+	superblock, err := ReadSuperblock(partitionID)
+	if err != nil {
+		return
+	}
+	touchInode(file, superblock, inodeIndex, stamp)
+}
+
+// touchInode lee un inodo, le aplica stamp y lo vuelve a escribir
+func touchInode(file *os.File, superblock *Structs.Superblock, inodeIndex int32, stamp func(*Structs.Inode)) {
+	var inode Structs.Inode
+	inodePos := int64(superblock.S_inode_start + inodeIndex*superblock.S_inode_size)
+	if err := Utilities.ReadObject(file, &inode, inodePos); err != nil {
+		return
+	}
+	stamp(&inode)
+	Utilities.WriteObject(file, inode, inodePos)
 }
 
 // ============================================================================
@@ -4574,67 +4258,67 @@ binary.Write(file, binary.LittleEndian, bitmap)
 // ============================================================================
 
 // Move - Mover un archivo o directorio a otro destino (cambia solo las referencias)
-func Move(path string, destino string) {
-	fmt.Println("======Inicio MOVE======")
-	fmt.Printf("Origen: %s\n", path)
-	fmt.Printf("Destino: %s\n", destino)
+func Move(out io.Writer, session *Structs.UserSession, path string, destino string) error {
+	fmt.Fprintln(out, "======Inicio MOVE======")
+	fmt.Fprintf(out, "Origen: %s\n", path)
+	fmt.Fprintf(out, "Destino: %s\n", destino)
 
 	// Verificar que haya una sesión activa
-	if !IsUserLoggedIn() {
-		fmt.Println("Error: No hay una sesión activa")
-		fmt.Println("Use el comando 'login' para iniciar sesión")
-		fmt.Println("======FIN MOVE======")
-		return
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 	}
 
 	// Validar que las rutas no estén vacías
 	if strings.TrimSpace(path) == "" {
-		fmt.Println("Error: La ruta de origen no puede estar vacía")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: La ruta de origen no puede estar vacía")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta de origen no puede estar vacía")
 	}
 
 	if strings.TrimSpace(destino) == "" {
-		fmt.Println("Error: La ruta de destino no puede estar vacía")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: La ruta de destino no puede estar vacía")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta de destino no puede estar vacía")
 	}
 
 	// Validar que las rutas empiecen con "/"
 	if !strings.HasPrefix(path, "/") {
-		fmt.Println("Error: La ruta de origen debe empezar con '/' (ruta absoluta)")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: La ruta de origen debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta de origen debe empezar con '/' (ruta absoluta)")
 	}
 
 	if !strings.HasPrefix(destino, "/") {
-		fmt.Println("Error: La ruta de destino debe empezar con '/' (ruta absoluta)")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: La ruta de destino debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta de destino debe empezar con '/' (ruta absoluta)")
 	}
 
 	// Validar que no intente mover la raíz
 	if path == "/" {
-		fmt.Println("Error: No se puede mover el directorio raíz")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se puede mover el directorio raíz")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se puede mover el directorio raíz")
 	}
 
 	// Validar que destino no sea subdirectorio de origen
 	if strings.HasPrefix(destino, path+"/") || destino == path {
-		fmt.Println("Error: No se puede mover un directorio dentro de sí mismo")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se puede mover un directorio dentro de sí mismo")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se puede mover un directorio dentro de sí mismo")
 	}
 
 	// Buscar el archivo o directorio de origen
-	existsFile, sourceInodeFile := findFileInDirectory(CurrentSession.PartitionID, path)
-	existsDir, sourceInodeDir := findDirectoryInPath(CurrentSession.PartitionID, path)
+	existsFile, sourceInodeFile := findFileInDirectory(session.PartitionID, path)
+	existsDir, sourceInodeDir := findDirectoryInPath(session.PartitionID, path)
 
 	if !existsFile && !existsDir {
-		fmt.Printf("Error: La ruta de origen '%s' no existe\n", path)
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintf(out, "Error: La ruta de origen '%s' no existe\n", path)
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "La ruta de origen '%s' no existe", path)
 	}
 
 	// Determinar si es archivo o directorio
@@ -4645,25 +4329,25 @@ func Move(path string, destino string) {
 	}
 
 	// Verificar permisos de escritura sobre el origen
-	if !hasWritePermission(CurrentSession.PartitionID, sourceInode, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de escritura sobre '%s'\n", path)
-		fmt.Println("======FIN MOVE======")
-		return
+	if !hasWritePermission(session.PartitionID, sourceInode, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura sobre '%s'\n", path)
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura sobre '%s'", path)
 	}
 
 	// Verificar que el directorio destino exista
-	existsDestDir, destDirInode := findDirectoryInPath(CurrentSession.PartitionID, destino)
+	existsDestDir, destDirInode := findDirectoryInPath(session.PartitionID, destino)
 	if !existsDestDir {
-		fmt.Printf("Error: El directorio de destino '%s' no existe\n", destino)
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintf(out, "Error: El directorio de destino '%s' no existe\n", destino)
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El directorio de destino '%s' no existe", destino)
 	}
 
 	// Verificar permisos de escritura sobre el destino
-	if !hasWritePermission(CurrentSession.PartitionID, destDirInode, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Printf("Error: No tiene permisos de escritura en el directorio de destino '%s'\n", destino)
-		fmt.Println("======FIN MOVE======")
-		return
+	if !hasWritePermission(session.PartitionID, destDirInode, session.UserID, session.GroupID) {
+		fmt.Fprintf(out, "Error: No tiene permisos de escritura en el directorio de destino '%s'\n", destino)
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos de escritura en el directorio de destino '%s'", destino)
 	}
 
 	// Obtener el nombre del archivo/directorio a mover y su padre
@@ -4671,84 +4355,85 @@ func Move(path string, destino string) {
 	
 	// Validar que el padre no sea vacío (no se puede mover desde raíz sin padre)
 	if parentPath == "" {
-		fmt.Println("Error: No se puede mover un elemento sin directorio padre")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se puede mover un elemento sin directorio padre")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "No se puede mover un elemento sin directorio padre")
 	}
 
 	// Buscar el directorio padre del origen
-	existsParent, parentInode := findDirectoryInPath(CurrentSession.PartitionID, parentPath)
+	existsParent, parentInode := findDirectoryInPath(session.PartitionID, parentPath)
 	if !existsParent {
-		fmt.Printf("Error: No se encontró el directorio padre '%s'\n", parentPath)
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintf(out, "Error: No se encontró el directorio padre '%s'\n", parentPath)
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "No se encontró el directorio padre '%s'", parentPath)
 	}
 
 	// Verificar que no exista ya un archivo/directorio con ese nombre en destino
-	existsInDest, _ := findInodeInDirectory(CurrentSession.PartitionID, destDirInode, sourceName, false)
+	existsInDest, _ := findInodeInDirectory(session.PartitionID, destDirInode, sourceName, false)
 	if existsInDest {
-		fmt.Printf("Error: Ya existe un archivo o directorio con el nombre '%s' en '%s'\n", sourceName, destino)
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintf(out, "Error: Ya existe un archivo o directorio con el nombre '%s' en '%s'\n", sourceName, destino)
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrAlreadyExists, "Ya existe un archivo o directorio con el nombre '%s' en '%s'", sourceName, destino)
 	}
 
 	// Obtener información de la partición
-	mountedPartition, exists := DiskManagement.MountedPartitions[CurrentSession.PartitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
 	if !exists {
-		fmt.Println("Error: Partición no encontrada")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: Partición no encontrada")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "Partición no encontrada")
 	}
 
 	file, err := Utilities.OpenFile(mountedPartition.Path)
 	if err != nil {
-		fmt.Println("Error: No se pudo abrir el disco")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo abrir el disco")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el disco")
 	}
 	defer file.Close()
 
-	superblock, err := ReadSuperblock(CurrentSession.PartitionID)
+	superblock, err := ReadSuperblock(session.PartitionID)
 	if err != nil {
-		fmt.Println("Error: No se pudo leer el superblock")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo leer el superblock")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock")
 	}
 
 	// PASO 1: Remover la entrada del directorio padre original
 	if !removeEntryFromParent(file, superblock, parentInode, sourceName) {
-		fmt.Println("Error: No se pudo remover la entrada del directorio origen")
-		fmt.Println("======FIN MOVE======")
-		return
+		fmt.Fprintln(out, "Error: No se pudo remover la entrada del directorio origen")
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo remover la entrada del directorio origen")
 	}
 
 	// PASO 2: Agregar la entrada al directorio destino
-	if !addFileToDirectory(file, superblock, destDirInode, sourceName, sourceInode) {
-		fmt.Println("Error: No se pudo agregar la entrada al directorio destino")
+	if !addFileToDirectory(out, file, superblock, destDirInode, sourceName, sourceInode) {
+		fmt.Fprintln(out, "Error: No se pudo agregar la entrada al directorio destino")
 		// Intentar restaurar la entrada en el padre original
-		addFileToDirectory(file, superblock, parentInode, sourceName, sourceInode)
-		fmt.Println("======FIN MOVE======")
-		return
+		addFileToDirectory(out, file, superblock, parentInode, sourceName, sourceInode)
+		fmt.Fprintln(out, "======FIN MOVE======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo agregar la entrada al directorio destino")
 	}
 
 	// PASO 3: Si es un directorio, actualizar la referencia ".." al nuevo padre
 	if !isFile {
 		if !updateParentReference(file, superblock, sourceInode, destDirInode) {
-			fmt.Println("Advertencia: No se pudo actualizar la referencia al padre en el directorio movido")
+			fmt.Fprintln(out, "Advertencia: No se pudo actualizar la referencia al padre en el directorio movido")
 		}
 	}
 
 	// Éxito
 	// Registrar en el journaling (EXT3)
 	contentInfo := fmt.Sprintf("%s->%s", path, destino)
-	writeToJournal(CurrentSession.PartitionID, "move", path, contentInfo)
-	
-	fmt.Println("\n=== MOVIMIENTO COMPLETADO ===")
-	fmt.Printf("Origen: %s\n", path)
-	fmt.Printf("Nuevo destino: %s/%s\n", destino, sourceName)
-	fmt.Printf("Tipo: %s\n", map[bool]string{true: "Archivo", false: "Directorio"}[isFile])
-	fmt.Println("El archivo/directorio fue movido exitosamente")
-	fmt.Println("======FIN MOVE======")
+	writeToJournal(out, session.PartitionID, "move", path, contentInfo)
+	
+	fmt.Fprintln(out, "\n=== MOVIMIENTO COMPLETADO ===")
+	fmt.Fprintf(out, "Origen: %s\n", path)
+	fmt.Fprintf(out, "Nuevo destino: %s/%s\n", destino, sourceName)
+	fmt.Fprintf(out, "Tipo: %s\n", map[bool]string{true: "Archivo", false: "Directorio"}[isFile])
+	fmt.Fprintln(out, "El archivo/directorio fue movido exitosamente")
+	fmt.Fprintln(out, "======FIN MOVE======")
+	return nil
 }
 
 // updateParentReference - Actualizar la referencia ".." de un directorio al nuevo padre
@@ -4770,7 +4455,7 @@ func updateParentReference(file *os.File, superblock *Structs.Superblock, dirIno
 
 		// Buscar la entrada ".."
 		for j := 0; j < 4; j++ {
-			entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			entryName := EntryName(file, superblock, folderBlock.B_content[j])
 			if entryName == ".." {
 				// Actualizar la referencia al nuevo padre
 				folderBlock.B_content[j].B_inodo = newParentInode
@@ -4903,7 +4588,7 @@ func findRecursive(file *os.File, superblock *Structs.Superblock, currentInode i
 
 			// Recorrer cada entrada del bloque
 			for j := 0; j < 4; j++ {
-				entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+				entryName := EntryName(file, superblock, folderBlock.B_content[j])
 				entryInode := folderBlock.B_content[j].B_inodo
 
 				// Saltar entradas vacías, "." y ".."
@@ -4927,100 +4612,101 @@ func findRecursive(file *os.File, superblock *Structs.Superblock, currentInode i
 }
 
 // Find - Buscar archivos/directorios por nombre usando patrones
-func Find(path string, name string) {
-	fmt.Println("======Inicio FIND======")
-	fmt.Printf("Ruta de búsqueda: %s\n", path)
-	fmt.Printf("Patrón: %s\n", name)
+func Find(out io.Writer, session *Structs.UserSession, path string, name string) ([]string, error) {
+	fmt.Fprintln(out, "======Inicio FIND======")
+	fmt.Fprintf(out, "Ruta de búsqueda: %s\n", path)
+	fmt.Fprintf(out, "Patrón: %s\n", name)
 
 	// Validar que hay una sesión activa
-	if CurrentSession == nil || CurrentSession.PartitionID == "" {
-		fmt.Println("Error: No hay una sesión activa")
-		fmt.Println("Use el comando 'login' para iniciar sesión")
-		fmt.Println("======FIN FIND======")
-		return
+	if session == nil || session.PartitionID == "" {
+		fmt.Fprintln(out, "Error: No hay una sesión activa")
+		fmt.Fprintln(out, "Use el comando 'login' para iniciar sesión")
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa")
 	}
 
 	// Validar parámetros
 	if path == "" {
-		fmt.Println("Error: El parámetro -path es obligatorio")
-		fmt.Println("======FIN FIND======")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -path es obligatorio")
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -path es obligatorio")
 	}
 
 	if name == "" {
-		fmt.Println("Error: El parámetro -name es obligatorio")
-		fmt.Println("======FIN FIND======")
-		return
+		fmt.Fprintln(out, "Error: El parámetro -name es obligatorio")
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -name es obligatorio")
 	}
 
 	// Validar que la ruta sea absoluta
 	if !strings.HasPrefix(path, "/") {
-		fmt.Println("Error: La ruta debe empezar con '/' (ruta absoluta)")
-		fmt.Println("======FIN FIND======")
-		return
+		fmt.Fprintln(out, "Error: La ruta debe empezar con '/' (ruta absoluta)")
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La ruta debe empezar con '/' (ruta absoluta)")
 	}
 
 	// Buscar el directorio de inicio
-	existsDir, startInode := findDirectoryInPath(CurrentSession.PartitionID, path)
+	existsDir, startInode := findDirectoryInPath(session.PartitionID, path)
 	if !existsDir {
-		fmt.Printf("Error: No se encontró la ruta: %s\n", path)
-		fmt.Println("======FIN FIND======")
-		return
+		fmt.Fprintf(out, "Error: No se encontró la ruta: %s\n", path)
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "No se encontró la ruta: %s", path)
 	}
 
 	// Verificar permisos de lectura en el directorio de inicio
-	if !hasReadPermission(CurrentSession.PartitionID, startInode, CurrentSession.UserID, CurrentSession.GroupID) {
-		fmt.Println("Error: No tienes permisos de lectura en este directorio")
-		fmt.Println("======FIN FIND======")
-		return
+	if !hasReadPermission(session.PartitionID, startInode, session.UserID, session.GroupID) {
+		fmt.Fprintln(out, "Error: No tienes permisos de lectura en este directorio")
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tienes permisos de lectura en este directorio")
 	}
 
 	// Obtener información de la partición
-	mountedPartition, exists := DiskManagement.MountedPartitions[CurrentSession.PartitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
 	if !exists {
-		fmt.Println("Error: Partición no encontrada")
-		fmt.Println("======FIN FIND======")
-		return
+		fmt.Fprintln(out, "Error: Partición no encontrada")
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "Partición no encontrada")
 	}
 
 	file, err := Utilities.OpenFile(mountedPartition.Path)
 	if err != nil {
-		fmt.Printf("Error: No se pudo abrir el disco: %v\n", err)
-		fmt.Println("======FIN FIND======")
-		return
+		fmt.Fprintf(out, "Error: No se pudo abrir el disco: %v\n", err)
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el disco: %v", err)
 	}
 	defer file.Close()
 
-	superblock, err := ReadSuperblock(CurrentSession.PartitionID)
+	superblock, err := ReadSuperblock(session.PartitionID)
 	if err != nil {
-		fmt.Printf("Error: No se pudo leer el superblock: %v\n", err)
-		fmt.Println("======FIN FIND======")
-		return
+		fmt.Fprintf(out, "Error: No se pudo leer el superblock: %v\n", err)
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock: %v", err)
 	}
 
 	// Validación adicional: verificar que el superblock no sea nil
 	if superblock == nil {
-		fmt.Println("Error: El superblock es nulo")
-		fmt.Println("======FIN FIND======")
-		return
+		fmt.Fprintln(out, "Error: El superblock es nulo")
+		fmt.Fprintln(out, "======FIN FIND======")
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El superblock es nulo")
 	}
 
 	// Realizar la búsqueda
 	results := make([]string, 0)
 	findRecursive(file, superblock, startInode, path, name, &results, 0, 
-		CurrentSession.PartitionID, CurrentSession.UserID, CurrentSession.GroupID)
+		session.PartitionID, session.UserID, session.GroupID)
 
 	// Mostrar resultados
-	fmt.Println("\n=== RESULTADOS DE LA BÚSQUEDA ===")
+	fmt.Fprintln(out, "\n=== RESULTADOS DE LA BÚSQUEDA ===")
 	if len(results) == 0 {
-		fmt.Println("No se encontraron archivos/directorios que coincidan con el patrón")
+		fmt.Fprintln(out, "No se encontraron archivos/directorios que coincidan con el patrón")
 	} else {
-		fmt.Printf("Se encontraron %d elementos:\n", len(results))
+		fmt.Fprintf(out, "Se encontraron %d elementos:\n", len(results))
 		for _, result := range results {
-			fmt.Printf("  %s\n", result)
+			fmt.Fprintf(out, "  %s\n", result)
 		}
 	}
-	fmt.Println("======FIN FIND======")
+	fmt.Fprintln(out, "======FIN FIND======")
+	return results, nil
 }
 
 // ============================================================================
@@ -5028,82 +4714,82 @@ func Find(path string, name string) {
 // ============================================================================
 
 // Chown - Cambiar el propietario de archivos y directorios
-func Chown(path string, recursive bool, usuario string) {
-	fmt.Println("======INICIO CHOWN======")
-	fmt.Println("Comando: chown")
-	fmt.Printf("Parámetros:\n")
-	fmt.Printf("  -path=%s\n", path)
-	fmt.Printf("  -r=%t\n", recursive)
-	fmt.Printf("  -usuario=%s\n", usuario)
-	fmt.Println()
+func Chown(out io.Writer, session *Structs.UserSession, path string, recursive bool, usuario string) error {
+	fmt.Fprintln(out, "======INICIO CHOWN======")
+	fmt.Fprintln(out, "Comando: chown")
+	fmt.Fprintf(out, "Parámetros:\n")
+	fmt.Fprintf(out, "  -path=%s\n", path)
+	fmt.Fprintf(out, "  -r=%t\n", recursive)
+	fmt.Fprintf(out, "  -usuario=%s\n", usuario)
+	fmt.Fprintln(out)
 
 	// Verificar que haya una sesión activa
-	if CurrentSession == nil || CurrentSession.PartitionID == "" {
-		fmt.Println("ERROR: No hay una sesión activa. Use el comando 'login' primero.")
-		fmt.Println("======FIN CHOWN======")
-		return
+	if session == nil || session.PartitionID == "" {
+		fmt.Fprintln(out, "ERROR: No hay una sesión activa. Use el comando 'login' primero.")
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa. Use el comando 'login' primero.")
 	}
 
 	// Validar parámetros requeridos
 	if path == "" {
-		fmt.Println("ERROR: El parámetro -path es obligatorio")
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintln(out, "ERROR: El parámetro -path es obligatorio")
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -path es obligatorio")
 	}
 
 	if usuario == "" {
-		fmt.Println("ERROR: El parámetro -usuario es obligatorio")
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintln(out, "ERROR: El parámetro -usuario es obligatorio")
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -usuario es obligatorio")
 	}
 
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[CurrentSession.PartitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
 	if !exists {
-		fmt.Printf("ERROR: No se encontró la partición montada con ID '%s'\n", CurrentSession.PartitionID)
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintf(out, "ERROR: No se encontró la partición montada con ID '%s'\n", session.PartitionID)
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "No se encontró la partición montada con ID '%s'", session.PartitionID)
 	}
 
 	// Leer el archivo users.txt para validar el usuario
-	usersData, err := readUsersFile(CurrentSession.PartitionID)
+	usersData, err := readUsersFile(session.PartitionID)
 	if err != nil {
-		fmt.Printf("ERROR: No se pudo leer el archivo de usuarios: %s\n", err)
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintf(out, "ERROR: No se pudo leer el archivo de usuarios: %s\n", err)
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el archivo de usuarios: %s", err)
 	}
 
 	// Verificar que el usuario existe
 	userExists, targetUser := findUser(usersData, usuario)
 	if !userExists {
-		fmt.Printf("ERROR: El usuario '%s' no existe en el sistema\n", usuario)
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintf(out, "ERROR: El usuario '%s' no existe en el sistema\n", usuario)
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El usuario '%s' no existe en el sistema", usuario)
 	}
 
 	// Abrir el archivo del disco
 	file, err := os.OpenFile(mountedPartition.Path, os.O_RDWR, 0644)
 	if err != nil {
-		fmt.Printf("ERROR: No se pudo abrir el archivo del disco: %s\n", err)
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintf(out, "ERROR: No se pudo abrir el archivo del disco: %s\n", err)
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el archivo del disco: %s", err)
 	}
 	defer file.Close()
 
 	// Leer el superblock
-	superblock, err := ReadSuperblock(CurrentSession.PartitionID)
+	superblock, err := ReadSuperblock(session.PartitionID)
 	if err != nil {
-		fmt.Printf("ERROR: No se pudo leer el superblock: %s\n", err)
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintf(out, "ERROR: No se pudo leer el superblock: %s\n", err)
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock: %s", err)
 	}
 
 	// Buscar el archivo o directorio por su ruta
-	inodeNum, err := findFileOrDirectoryByPath(file, superblock, path, CurrentSession.UserID, CurrentSession.GroupID)
+	inodeNum, err := findFileOrDirectoryByPath(file, superblock, path, session.UserID, session.GroupID)
 	if err != nil {
-		fmt.Printf("ERROR: No se encontró la ruta '%s': %s\n", path, err)
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintf(out, "ERROR: No se encontró la ruta '%s': %s\n", path, err)
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "No se encontró la ruta '%s': %s", path, err)
 	}
 
 	// Leer el inodo del archivo o directorio
@@ -5113,44 +4799,45 @@ func Chown(path string, recursive bool, usuario string) {
 
 	// Verificar permisos: root puede cambiar cualquier archivo,
 	// otros usuarios solo pueden cambiar sus propios archivos
-	isRoot := CurrentSession.UserID == 1
-	isOwner := inode.I_uid == int32(CurrentSession.UserID)
+	isRoot := session.UserID == 1
+	isOwner := inode.I_uid == int32(session.UserID)
 
 	if !isRoot && !isOwner {
-		fmt.Printf("ERROR: No tiene permisos para cambiar el propietario de '%s'\n", path)
-		fmt.Printf("Solo el propietario o root pueden cambiar el propietario de un archivo\n")
-		fmt.Println("======FIN CHOWN======")
-		return
+		fmt.Fprintf(out, "ERROR: No tiene permisos para cambiar el propietario de '%s'\n", path)
+		fmt.Fprintf(out, "Solo el propietario o root pueden cambiar el propietario de un archivo\n")
+		fmt.Fprintln(out, "======FIN CHOWN======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos para cambiar el propietario de '%s'", path)
 	}
 
 	// Cambiar el propietario
 	if recursive && inode.I_type[0] == '0' {
 		// Es un directorio y se solicitó cambio recursivo
-		fmt.Printf("Cambiando propietario recursivamente de '%s' a '%s' (UID: %d)...\n", path, usuario, targetUser.ID)
-		changeOwnerRecursive(file, superblock, inodeNum, int32(targetUser.ID), CurrentSession.UserID)
-		fmt.Printf("Propietario cambiado exitosamente de forma recursiva\n")
+		fmt.Fprintf(out, "Cambiando propietario recursivamente de '%s' a '%s' (UID: %d)...\n", path, usuario, targetUser.ID)
+		changeOwnerRecursive(file, superblock, inodeNum, int32(targetUser.ID), session.UserID)
+		fmt.Fprintf(out, "Propietario cambiado exitosamente de forma recursiva\n")
 	} else {
 		// Cambio simple (archivo o directorio sin recursión)
 		inode.I_uid = int32(targetUser.ID)
+		stampInodeModified(&inode)
 		
 		// Escribir el inodo actualizado
-		file.Seek(int64(superblock.S_inode_start)+int64(inodeNum)*int64(superblock.S_inode_size), 0)
-		binary.Write(file, binary.LittleEndian, &inode)
+		Utilities.WriteObject(file, inode, int64(superblock.S_inode_start)+int64(inodeNum)*int64(superblock.S_inode_size))
 		
 		fileType := "archivo"
 		if inode.I_type[0] == '0' {
 			fileType = "directorio"
 		}
-		fmt.Printf("Propietario del %s '%s' cambiado exitosamente a '%s' (UID: %d)\n", 
+		fmt.Fprintf(out, "Propietario del %s '%s' cambiado exitosamente a '%s' (UID: %d)\n", 
 			fileType, path, usuario, targetUser.ID)
 	}
 
 	// Registrar en el journaling (EXT3)
-	contentInfo := fmt.Sprintf("uid=%d", targetUser.ID)
-	writeToJournal(CurrentSession.PartitionID, "chown", path, contentInfo)
+	contentInfo := fmt.Sprintf("user=%s,uid=%d,r=%t", usuario, targetUser.ID, recursive)
+	writeToJournal(out, session.PartitionID, "chown", path, contentInfo)
 
-	fmt.Println()
-	fmt.Println("======FIN CHOWN======")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "======FIN CHOWN======")
+	return nil
 }
 
 // changeOwnerRecursive - Cambiar el propietario de un directorio y todo su contenido recursivamente
@@ -5162,8 +4849,8 @@ func changeOwnerRecursive(file *os.File, superblock *Structs.Superblock, inodeNu
 
 	// Cambiar el propietario del inodo actual
 	inode.I_uid = newOwnerID
-	file.Seek(int64(superblock.S_inode_start)+int64(inodeNum)*int64(superblock.S_inode_size), 0)
-	binary.Write(file, binary.LittleEndian, &inode)
+	stampInodeModified(&inode)
+	Utilities.WriteObject(file, inode, int64(superblock.S_inode_start)+int64(inodeNum)*int64(superblock.S_inode_size))
 
 	// Si es un directorio, procesar recursivamente su contenido
 	if inode.I_type[0] == '0' {
@@ -5185,7 +4872,7 @@ func changeOwnerRecursive(file *os.File, superblock *Structs.Superblock, inodeNu
 				}
 
 				// Obtener el nombre de la entrada
-				entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+				entryName := EntryName(file, superblock, folderBlock.B_content[j])
 				
 				// Saltar las entradas "." y ".."
 				if entryName == "." || entryName == ".." {
@@ -5212,7 +4899,7 @@ func changeOwnerRecursiveIndirect(file *os.File, superblock *Structs.Superblock,
 	binary.Read(file, binary.LittleEndian, &pointerBlock)
 
 	// Procesar cada puntero del bloque
-	for i := 0; i < 16; i++ {
+	for i := 0; i < len(pointerBlock.B_pointers); i++ {
 		if pointerBlock.B_pointers[i] == -1 {
 			continue
 		}
@@ -5229,7 +4916,7 @@ func changeOwnerRecursiveIndirect(file *os.File, superblock *Structs.Superblock,
 			}
 
 			// Obtener el nombre de la entrada
-			entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			entryName := EntryName(file, superblock, folderBlock.B_content[j])
 			
 			// Saltar las entradas "." y ".."
 			if entryName == "." || entryName == ".." {
@@ -5300,7 +4987,7 @@ func findFileOrDirectoryByPath(file *os.File, superblock *Structs.Superblock, pa
 					continue
 				}
 
-				entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+				entryName := EntryName(file, superblock, folderBlock.B_content[j])
 				if entryName == component {
 					nextInodeNum = folderBlock.B_content[j].B_inodo
 					found = true
@@ -5335,7 +5022,7 @@ func findInIndirectBlock(file *os.File, superblock *Structs.Superblock, pointerB
 	binary.Read(file, binary.LittleEndian, &pointerBlock)
 
 	// Buscar en cada bloque apuntado
-	for i := 0; i < 16; i++ {
+	for i := 0; i < len(pointerBlock.B_pointers); i++ {
 		if pointerBlock.B_pointers[i] == -1 {
 			continue
 		}
@@ -5351,7 +5038,7 @@ func findInIndirectBlock(file *os.File, superblock *Structs.Superblock, pointerB
 				continue
 			}
 
-			entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			entryName := EntryName(file, superblock, folderBlock.B_content[j])
 			if entryName == name {
 				return folderBlock.B_content[j].B_inodo
 			}
@@ -5366,85 +5053,85 @@ func findInIndirectBlock(file *os.File, superblock *Structs.Superblock, pointerB
 // ============================================================================
 
 // Chmod - Cambiar los permisos de archivos y directorios
-func Chmod(path string, ugo string, recursive bool) {
-	fmt.Println("======INICIO CHMOD======")
-	fmt.Println("Comando: chmod")
-	fmt.Printf("Parámetros:\n")
-	fmt.Printf("  -path=%s\n", path)
-	fmt.Printf("  -ugo=%s\n", ugo)
-	fmt.Printf("  -r=%t\n", recursive)
-	fmt.Println()
+func Chmod(out io.Writer, session *Structs.UserSession, path string, ugo string, recursive bool) error {
+	fmt.Fprintln(out, "======INICIO CHMOD======")
+	fmt.Fprintln(out, "Comando: chmod")
+	fmt.Fprintf(out, "Parámetros:\n")
+	fmt.Fprintf(out, "  -path=%s\n", path)
+	fmt.Fprintf(out, "  -ugo=%s\n", ugo)
+	fmt.Fprintf(out, "  -r=%t\n", recursive)
+	fmt.Fprintln(out)
 
 	// Verificar que haya una sesión activa
-	if CurrentSession == nil || CurrentSession.PartitionID == "" {
-		fmt.Println("ERROR: No hay una sesión activa. Use el comando 'login' primero.")
-		fmt.Println("======FIN CHMOD======")
-		return
+	if session == nil || session.PartitionID == "" {
+		fmt.Fprintln(out, "ERROR: No hay una sesión activa. Use el comando 'login' primero.")
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "No hay una sesión activa. Use el comando 'login' primero.")
 	}
 
 	// Validar parámetros requeridos
 	if path == "" {
-		fmt.Println("ERROR: El parámetro -path es obligatorio")
-		fmt.Println("======FIN CHMOD======")
-		return
+		fmt.Fprintln(out, "ERROR: El parámetro -path es obligatorio")
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -path es obligatorio")
 	}
 
 	if ugo == "" {
-		fmt.Println("ERROR: El parámetro -ugo es obligatorio")
-		fmt.Println("======FIN CHMOD======")
-		return
+		fmt.Fprintln(out, "ERROR: El parámetro -ugo es obligatorio")
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -ugo es obligatorio")
 	}
 
 	// Validar formato de permisos (debe ser exactamente 3 dígitos)
 	if len(ugo) != 3 {
-		fmt.Println("ERROR: El parámetro -ugo debe tener exactamente 3 dígitos")
-		fmt.Println("Formato: -ugo=[0-7][0-7][0-7] (Usuario, Grupo, Otros)")
-		fmt.Println("Ejemplo: -ugo=764")
-		fmt.Println("======FIN CHMOD======")
-		return
+		fmt.Fprintln(out, "ERROR: El parámetro -ugo debe tener exactamente 3 dígitos")
+		fmt.Fprintln(out, "Formato: -ugo=[0-7][0-7][0-7] (Usuario, Grupo, Otros)")
+		fmt.Fprintln(out, "Ejemplo: -ugo=764")
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El parámetro -ugo debe tener exactamente 3 dígitos")
 	}
 
 	// Validar que cada dígito esté en el rango [0-7]
 	for i, char := range ugo {
 		if char < '0' || char > '7' {
-			fmt.Printf("ERROR: El dígito en la posición %d ('%c') no está en el rango [0-7]\n", i+1, char)
-			fmt.Println("Los permisos deben ser números del 0 al 7")
-			fmt.Println("======FIN CHMOD======")
-			return
+			fmt.Fprintf(out, "ERROR: El dígito en la posición %d ('%c') no está en el rango [0-7]\n", i+1, char)
+			fmt.Fprintln(out, "Los permisos deben ser números del 0 al 7")
+			fmt.Fprintln(out, "======FIN CHMOD======")
+			return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El dígito en la posición %d ('%c') no está en el rango [0-7]", i+1, char)
 		}
 	}
 
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[CurrentSession.PartitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(session.PartitionID)
 	if !exists {
-		fmt.Printf("ERROR: No se encontró la partición montada con ID '%s'\n", CurrentSession.PartitionID)
-		fmt.Println("======FIN CHMOD======")
-		return
+		fmt.Fprintf(out, "ERROR: No se encontró la partición montada con ID '%s'\n", session.PartitionID)
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "No se encontró la partición montada con ID '%s'", session.PartitionID)
 	}
 
 	// Abrir el archivo del disco
 	file, err := os.OpenFile(mountedPartition.Path, os.O_RDWR, 0644)
 	if err != nil {
-		fmt.Printf("ERROR: No se pudo abrir el archivo del disco: %s\n", err)
-		fmt.Println("======FIN CHMOD======")
-		return
+		fmt.Fprintf(out, "ERROR: No se pudo abrir el archivo del disco: %s\n", err)
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo abrir el archivo del disco: %s", err)
 	}
 	defer file.Close()
 
 	// Leer el superblock
-	superblock, err := ReadSuperblock(CurrentSession.PartitionID)
+	superblock, err := ReadSuperblock(session.PartitionID)
 	if err != nil {
-		fmt.Printf("ERROR: No se pudo leer el superblock: %s\n", err)
-		fmt.Println("======FIN CHMOD======")
-		return
+		fmt.Fprintf(out, "ERROR: No se pudo leer el superblock: %s\n", err)
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "No se pudo leer el superblock: %s", err)
 	}
 
 	// Buscar el archivo o directorio por su ruta
-	inodeNum, err := findFileOrDirectoryByPath(file, superblock, path, CurrentSession.UserID, CurrentSession.GroupID)
+	inodeNum, err := findFileOrDirectoryByPath(file, superblock, path, session.UserID, session.GroupID)
 	if err != nil {
-		fmt.Printf("ERROR: No se encontró la ruta '%s': %s\n", path, err)
-		fmt.Println("======FIN CHMOD======")
-		return
+		fmt.Fprintf(out, "ERROR: No se encontró la ruta '%s': %s\n", path, err)
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "No se encontró la ruta '%s': %s", path, err)
 	}
 
 	// Leer el inodo del archivo o directorio
@@ -5454,29 +5141,29 @@ func Chmod(path string, ugo string, recursive bool) {
 
 	// Verificar permisos: root puede cambiar cualquier archivo,
 	// otros usuarios solo pueden cambiar sus propios archivos
-	isRoot := CurrentSession.UserID == 1
-	isOwner := inode.I_uid == int32(CurrentSession.UserID)
+	isRoot := session.UserID == 1
+	isOwner := inode.I_uid == int32(session.UserID)
 
 	if !isRoot && !isOwner {
-		fmt.Printf("ERROR: No tiene permisos para cambiar los permisos de '%s'\n", path)
-		fmt.Printf("Solo el propietario (UID: %d) o root pueden cambiar los permisos\n", inode.I_uid)
-		fmt.Println("======FIN CHMOD======")
-		return
+		fmt.Fprintf(out, "ERROR: No tiene permisos para cambiar los permisos de '%s'\n", path)
+		fmt.Fprintf(out, "Solo el propietario (UID: %d) o root pueden cambiar los permisos\n", inode.I_uid)
+		fmt.Fprintln(out, "======FIN CHMOD======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "No tiene permisos para cambiar los permisos de '%s'", path)
 	}
 
 	// Cambiar los permisos
 	if recursive && inode.I_type[0] == '0' {
 		// Es un directorio y se solicitó cambio recursivo
-		fmt.Printf("Cambiando permisos recursivamente de '%s' a '%s'...\n", path, ugo)
-		changePermissionsRecursive(file, superblock, inodeNum, ugo, CurrentSession.UserID, isRoot)
-		fmt.Printf("Permisos cambiados exitosamente de forma recursiva\n")
+		fmt.Fprintf(out, "Cambiando permisos recursivamente de '%s' a '%s'...\n", path, ugo)
+		changePermissionsRecursive(file, superblock, inodeNum, ugo, session.UserID, isRoot)
+		fmt.Fprintf(out, "Permisos cambiados exitosamente de forma recursiva\n")
 	} else {
 		// Cambio simple (archivo o directorio sin recursión)
 		copy(inode.I_perm[:], []byte(ugo))
+		stampInodeModified(&inode)
 		
 		// Escribir el inodo actualizado
-		file.Seek(int64(superblock.S_inode_start)+int64(inodeNum)*int64(superblock.S_inode_size), 0)
-		binary.Write(file, binary.LittleEndian, &inode)
+		Utilities.WriteObject(file, inode, int64(superblock.S_inode_start)+int64(inodeNum)*int64(superblock.S_inode_size))
 		
 		fileType := "archivo"
 		if inode.I_type[0] == '0' {
@@ -5485,15 +5172,16 @@ func Chmod(path string, ugo string, recursive bool) {
 		
 		// Decodificar permisos para mostrar
 		permsStr := decodePermissions(ugo)
-		fmt.Printf("Permisos del %s '%s' cambiados exitosamente a %s (%s)\n", 
+		fmt.Fprintf(out, "Permisos del %s '%s' cambiados exitosamente a %s (%s)\n", 
 			fileType, path, ugo, permsStr)
 	}
 
 	// Registrar en el journaling (EXT3)
-	writeToJournal(CurrentSession.PartitionID, "chmod", path, ugo)
+	writeToJournal(out, session.PartitionID, "chmod", path, fmt.Sprintf("ugo=%s,r=%t", ugo, recursive))
 
-	fmt.Println()
-	fmt.Println("======FIN CHMOD======")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "======FIN CHMOD======")
+	return nil
 }
 
 // changePermissionsRecursive - Cambiar los permisos de un directorio y todo su contenido recursivamente
@@ -5507,8 +5195,8 @@ func changePermissionsRecursive(file *os.File, superblock *Structs.Superblock, i
 	if isRoot || inode.I_uid == int32(currentUserID) {
 		// Cambiar los permisos del inodo actual
 		copy(inode.I_perm[:], []byte(permissions))
-		file.Seek(int64(superblock.S_inode_start)+int64(inodeNum)*int64(superblock.S_inode_size), 0)
-		binary.Write(file, binary.LittleEndian, &inode)
+		stampInodeModified(&inode)
+		Utilities.WriteObject(file, inode, int64(superblock.S_inode_start)+int64(inodeNum)*int64(superblock.S_inode_size))
 	}
 
 	// Si es un directorio, procesar recursivamente su contenido
@@ -5531,7 +5219,7 @@ func changePermissionsRecursive(file *os.File, superblock *Structs.Superblock, i
 				}
 
 				// Obtener el nombre de la entrada
-				entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+				entryName := EntryName(file, superblock, folderBlock.B_content[j])
 				
 				// Saltar las entradas "." y ".."
 				if entryName == "." || entryName == ".." {
@@ -5558,7 +5246,7 @@ func changePermissionsRecursiveIndirect(file *os.File, superblock *Structs.Super
 	binary.Read(file, binary.LittleEndian, &pointerBlock)
 
 	// Procesar cada puntero del bloque
-	for i := 0; i < 16; i++ {
+	for i := 0; i < len(pointerBlock.B_pointers); i++ {
 		if pointerBlock.B_pointers[i] == -1 {
 			continue
 		}
@@ -5575,7 +5263,7 @@ func changePermissionsRecursiveIndirect(file *os.File, superblock *Structs.Super
 			}
 
 			// Obtener el nombre de la entrada
-			entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			entryName := EntryName(file, superblock, folderBlock.B_content[j])
 			
 			// Saltar las entradas "." y ".."
 			if entryName == "." || entryName == ".." {
@@ -5620,50 +5308,34 @@ func decodePermissions(perms string) string {
 // ============================================================================
 
 // Loss - Simular un fallo en el disco formateando áreas críticas
-func Loss(id string) {
-	fmt.Println("======INICIO LOSS======")
-	fmt.Println("Comando: loss")
-	fmt.Printf("ID: %s\n", id)
+func Loss(out io.Writer, id string) error {
+	fmt.Fprintln(out, "======INICIO LOSS======")
+	fmt.Fprintln(out, "Comando: loss")
+	fmt.Fprintf(out, "ID: %s\n", id)
 	
 	// Verificar que la partición esté montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[id]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
 	if !exists {
-		fmt.Printf("ERROR: No existe una partición montada con el ID '%s'\n", id)
-		fmt.Println("======FIN LOSS======")
-		return
+		fmt.Fprintf(out, "ERROR: No existe una partición montada con el ID '%s'\n", id)
+		fmt.Fprintln(out, "======FIN LOSS======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "No existe una partición montada con el ID '%s'", id)
 	}
 	
 	// Abrir el archivo del disco
 	file, err := os.OpenFile(mountedPartition.Path, os.O_RDWR, 0644)
 	if err != nil {
-		fmt.Println("ERROR al abrir el archivo del disco:", err)
-		fmt.Println("======FIN LOSS======")
-		return
+		fmt.Fprintln(out, "ERROR al abrir el archivo del disco:", err)
+		fmt.Fprintln(out, "======FIN LOSS======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error al abrir el archivo del disco: %v", err)
 	}
 	defer file.Close()
 	
-	// Leer el MBR
-	var tempMBR Structs.MBR
-	file.Seek(0, 0)
-	binary.Read(file, binary.LittleEndian, &tempMBR)
-	
-	// Obtener la partición correcta
-	var partition Structs.Partition
-	var partitionStart int32
-	
-	if mountedPartition.IsLogical {
-		// Para particiones lógicas, leer el EBR
-		var tempEBR Structs.EBR
-		file.Seek(int64(mountedPartition.EBRPosition), 0)
-		binary.Read(file, binary.LittleEndian, &tempEBR)
-		
-		partition.Start = tempEBR.Part_start
-		partition.Size = tempEBR.Part_size
-		partitionStart = tempEBR.Part_start
-	} else {
-		// Para particiones primarias
-		partition = tempMBR.Partitions[mountedPartition.PartitionIndex]
-		partitionStart = partition.Start
+	// Obtener el inicio de la partición
+	partitionStart, err := getPartitionStart(file, mountedPartition)
+	if err != nil {
+		fmt.Fprintln(out, "ERROR leyendo la partición:", err)
+		fmt.Fprintln(out, "======FIN LOSS======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo la partición: %v", err)
 	}
 	
 	// Leer el superblock
@@ -5673,19 +5345,19 @@ func Loss(id string) {
 	
 	// Verificar que sea EXT3
 	if sb.S_filesystem_type != 3 {
-		fmt.Printf("ERROR: La partición '%s' no es EXT3 (sistema de archivos tipo %d)\n", id, sb.S_filesystem_type)
-		fmt.Println("El comando 'loss' solo funciona con particiones EXT3")
-		fmt.Println("======FIN LOSS======")
-		return
+		fmt.Fprintf(out, "ERROR: La partición '%s' no es EXT3 (sistema de archivos tipo %d)\n", id, sb.S_filesystem_type)
+		fmt.Fprintln(out, "El comando 'loss' solo funciona con particiones EXT3")
+		fmt.Fprintln(out, "======FIN LOSS======")
+		return Utilities.NewCommandError(Utilities.ErrUnsupported, "La partición '%s' no es EXT3 (sistema de archivos tipo %d)", id, sb.S_filesystem_type)
 	}
 	
-	fmt.Println("\n⚠️  ADVERTENCIA: Este comando formateará las siguientes áreas:")
-	fmt.Println("   - Bitmap de Inodos")
-	fmt.Println("   - Bitmap de Bloques")
-	fmt.Println("   - Área de Inodos")
-	fmt.Println("   - Área de Bloques")
-	fmt.Println("\n   Se simulará una pérdida total de datos.")
-	fmt.Println("   Use el comando 'recovery' para restaurar desde el journaling.\n")
+	fmt.Fprintln(out, "\n⚠️  ADVERTENCIA: Este comando formateará las siguientes áreas:")
+	fmt.Fprintln(out, "   - Bitmap de Inodos")
+	fmt.Fprintln(out, "   - Bitmap de Bloques")
+	fmt.Fprintln(out, "   - Área de Inodos")
+	fmt.Fprintln(out, "   - Área de Bloques")
+	fmt.Fprintln(out, "\n   Se simulará una pérdida total de datos.")
+	fmt.Fprint(out, "   Use el comando 'recovery' para restaurar desde el journaling.\n\n")
 	
 	// Calcular tamaños de las áreas
 	bitmapInodeSize := sb.S_inodes_count
@@ -5697,364 +5369,215 @@ func Loss(id string) {
 	zeroBuffer := make([]byte, 1024)
 	
 	// 1. Formatear Bitmap de Inodos
-	fmt.Println("Formateando Bitmap de Inodos...")
-	file.Seek(int64(partitionStart+sb.S_bm_inode_start), 0)
-	bytesToWrite := int(bitmapInodeSize)
-	for bytesToWrite > 0 {
-		writeSize := 1024
-		if bytesToWrite < 1024 {
-			writeSize = bytesToWrite
-		}
-		file.Write(zeroBuffer[:writeSize])
-		bytesToWrite -= writeSize
+	fmt.Fprintln(out, "Formateando Bitmap de Inodos...")
+	if err := zeroArea(file, zeroBuffer, sb.S_bm_inode_start, bitmapInodeSize); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error formateando el área: %v", err)
 	}
 	
 	// 2. Formatear Bitmap de Bloques
-	fmt.Println("Formateando Bitmap de Bloques...")
-	file.Seek(int64(partitionStart+sb.S_bm_block_start), 0)
-	bytesToWrite = int(bitmapBlockSize)
-	for bytesToWrite > 0 {
-		writeSize := 1024
-		if bytesToWrite < 1024 {
-			writeSize = bytesToWrite
-		}
-		file.Write(zeroBuffer[:writeSize])
-		bytesToWrite -= writeSize
+	fmt.Fprintln(out, "Formateando Bitmap de Bloques...")
+	if err := zeroArea(file, zeroBuffer, sb.S_bm_block_start, bitmapBlockSize); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error formateando el área: %v", err)
 	}
 	
 	// 3. Formatear Área de Inodos
-	fmt.Println("Formateando Área de Inodos...")
-	file.Seek(int64(partitionStart+sb.S_inode_start), 0)
-	bytesToWrite = int(inodeAreaSize)
-	for bytesToWrite > 0 {
-		writeSize := 1024
-		if bytesToWrite < 1024 {
-			writeSize = bytesToWrite
-		}
-		file.Write(zeroBuffer[:writeSize])
-		bytesToWrite -= writeSize
+	fmt.Fprintln(out, "Formateando Área de Inodos...")
+	if err := zeroArea(file, zeroBuffer, sb.S_inode_start, inodeAreaSize); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error formateando el área: %v", err)
 	}
 	
 	// 4. Formatear Área de Bloques
-	fmt.Println("Formateando Área de Bloques...")
-	file.Seek(int64(partitionStart+sb.S_block_start), 0)
-	bytesToWrite = int(blockAreaSize)
-	for bytesToWrite > 0 {
-		writeSize := 1024
-		if bytesToWrite < 1024 {
-			writeSize = bytesToWrite
-		}
-		file.Write(zeroBuffer[:writeSize])
-		bytesToWrite -= writeSize
+	fmt.Fprintln(out, "Formateando Área de Bloques...")
+	if err := zeroArea(file, zeroBuffer, sb.S_block_start, blockAreaSize); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error formateando el área: %v", err)
 	}
 	
-	fmt.Println("\n✓ Simulación de pérdida de datos completada")
-	fmt.Println("  El sistema de archivos ha sido formateado")
-	fmt.Println("  Use 'recovery -id=" + id + "' para restaurar desde el journaling")
-	fmt.Println("======FIN LOSS======")
+	fmt.Fprintln(out, "\n✓ Simulación de pérdida de datos completada")
+	fmt.Fprintln(out, "  El sistema de archivos ha sido formateado")
+	fmt.Fprintln(out, "  Use 'recovery -id=" + id + "' para restaurar desde el journaling")
+	fmt.Fprintln(out, "======FIN LOSS======")
+	return nil
+}
+
+// zeroArea escribe ceros en size bytes desde start, en trozos del tamaño del buffer
+func zeroArea(file *os.File, zeroBuffer []byte, start int32, size int32) error {
+	for offset := int32(0); offset < size; offset += int32(len(zeroBuffer)) {
+		writeSize := minInt(len(zeroBuffer), int(size-offset))
+		if err := Utilities.WriteObject(file, zeroBuffer[:writeSize], int64(start+offset)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ============================================================================
 // COMANDO RECOVERY - RECUPERAR SISTEMA DESDE JOURNALING (SOLO EXT3)
 // ============================================================================
 
-// Recovery - Recuperar el sistema de archivos desde el journaling
-func Recovery(id string) {
-	fmt.Println("======INICIO RECOVERY======")
-	fmt.Println("Comando: recovery")
-	fmt.Printf("ID: %s\n", id)
+// Recovery - Recuperar el sistema de archivos desde el journaling: recrea la
+// estructura base y vuelve a ejecutar en orden las operaciones registradas
+// después del último mkfs
+func Recovery(out io.Writer, id string) (*RecoveryResult, error) {
+	fmt.Fprintln(out, "======INICIO RECOVERY======")
+	fmt.Fprintln(out, "Comando: recovery")
+	fmt.Fprintf(out, "ID: %s\n", id)
 	
 	// Verificar que la partición esté montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[id]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
 	if !exists {
-		fmt.Printf("ERROR: No existe una partición montada con el ID '%s'\n", id)
-		fmt.Println("======FIN RECOVERY======")
-		return
+		fmt.Fprintf(out, "ERROR: No existe una partición montada con el ID '%s'\n", id)
+		fmt.Fprintln(out, "======FIN RECOVERY======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "No existe una partición montada con el ID '%s'", id)
 	}
 	
 	// Abrir el archivo del disco
 	file, err := os.OpenFile(mountedPartition.Path, os.O_RDWR, 0644)
 	if err != nil {
-		fmt.Println("ERROR al abrir el archivo del disco:", err)
-		fmt.Println("======FIN RECOVERY======")
-		return
+		fmt.Fprintln(out, "ERROR al abrir el archivo del disco:", err)
+		fmt.Fprintln(out, "======FIN RECOVERY======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error al abrir el archivo del disco: %v", err)
 	}
 	defer file.Close()
 	
-	// Leer el MBR
-	var tempMBR Structs.MBR
-	file.Seek(0, 0)
-	binary.Read(file, binary.LittleEndian, &tempMBR)
-	
-	// Obtener la partición correcta
-	var partition Structs.Partition
-	var partitionStart int32
-	
-	if mountedPartition.IsLogical {
-		// Para particiones lógicas, leer el EBR
-		var tempEBR Structs.EBR
-		file.Seek(int64(mountedPartition.EBRPosition), 0)
-		binary.Read(file, binary.LittleEndian, &tempEBR)
-		
-		partition.Start = tempEBR.Part_start
-		partition.Size = tempEBR.Part_size
-		partitionStart = tempEBR.Part_start
-	} else {
-		// Para particiones primarias
-		partition = tempMBR.Partitions[mountedPartition.PartitionIndex]
-		partitionStart = partition.Start
+	// Obtener el inicio de la partición
+	partitionStart, err := getPartitionStart(file, mountedPartition)
+	if err != nil {
+		fmt.Fprintln(out, "ERROR leyendo la partición:", err)
+		fmt.Fprintln(out, "======FIN RECOVERY======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo la partición: %v", err)
 	}
 	
 	// Leer el superblock
-	file.Seek(int64(partitionStart), 0)
 	var sb Structs.Superblock
-	binary.Read(file, binary.LittleEndian, &sb)
+	if err := Utilities.ReadObject(file, &sb, int64(partitionStart)); err != nil {
+		fmt.Fprintln(out, "ERROR leyendo el superblock:", err)
+		fmt.Fprintln(out, "======FIN RECOVERY======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el superblock: %v", err)
+	}
 	
 	// Verificar que sea EXT3
 	if sb.S_filesystem_type != 3 {
-		fmt.Printf("ERROR: La partición '%s' no es EXT3 (sistema de archivos tipo %d)\n", id, sb.S_filesystem_type)
-		fmt.Println("El comando 'recovery' solo funciona con particiones EXT3")
-		fmt.Println("======FIN RECOVERY======")
-		return
+		fmt.Fprintf(out, "ERROR: La partición '%s' no es EXT3 (sistema de archivos tipo %d)\n", id, sb.S_filesystem_type)
+		fmt.Fprintln(out, "El comando 'recovery' solo funciona con particiones EXT3")
+		fmt.Fprintln(out, "======FIN RECOVERY======")
+		return nil, Utilities.NewCommandError(Utilities.ErrUnsupported, "La partición '%s' no es EXT3 (sistema de archivos tipo %d)", id, sb.S_filesystem_type)
 	}
 	
-	fmt.Println("\n📋 Iniciando proceso de recuperación desde journaling...")
-	fmt.Printf("   Sistema de archivos: EXT3\n")
-	fmt.Printf("   Inodos totales: %d\n", sb.S_inodes_count)
-	fmt.Printf("   Bloques totales: %d\n", sb.S_blocks_count)
+	fmt.Fprintln(out, "\n📋 Iniciando proceso de recuperación desde journaling...")
+	fmt.Fprintf(out, "   Sistema de archivos: EXT3\n")
+	fmt.Fprintf(out, "   Inodos totales: %d\n", sb.S_inodes_count)
+	fmt.Fprintf(out, "   Bloques totales: %d\n", sb.S_blocks_count)
 	
 	// Leer todas las entradas del journal
-	journalStart := partitionStart + int32(binary.Size(Structs.Superblock{}))
-	journalEntries := make([]Structs.Journaling, 0)
+	fmt.Fprintln(out, "\n📖 Leyendo entradas del journaling...")
+	journalEntries := ReadJournalRecords(file, partitionStart)
+	fmt.Fprintf(out, "   Entradas encontradas en el journal: %d\n", len(journalEntries))
 	
-	fmt.Println("\n📖 Leyendo entradas del journaling...")
+	if len(journalEntries) == 0 {
+		fmt.Fprintln(out, "\n⚠️  No hay entradas en el journaling para recuperar")
+		fmt.Fprintln(out, "======FIN RECOVERY======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "No hay entradas en el journaling para recuperar")
+	}
 	
-	// Leer hasta encontrar entradas vacías o llegar al límite
-	maxEntries := 1000 // Límite de seguridad
-	for i := 0; i < maxEntries; i++ {
-		var journal Structs.Journaling
-		file.Seek(int64(journalStart)+int64(i*binary.Size(Structs.Journaling{})), 0)
-		binary.Read(file, binary.LittleEndian, &journal)
-		
-		// Si la operación está vacía, terminamos
-		if journal.Content.Operation[0] == 0 {
+	// Las operaciones a reproducir son las posteriores al último mkfs (o a la
+	// conversión desde EXT2, donde empieza el journaling de esa partición)
+	base := -1
+	for i := len(journalEntries) - 1; i >= 0; i-- {
+		operation := strings.ToLower(journalEntries[i].Operation)
+		if operation == "mkfs" || operation == "convert" {
+			base = i
 			break
 		}
-		
-		journalEntries = append(journalEntries, journal)
 	}
-	
-	fmt.Printf("   Entradas encontradas en el journal: %d\n", len(journalEntries))
-	
-	if len(journalEntries) == 0 {
-		fmt.Println("\n⚠️  No hay entradas en el journaling para recuperar")
-		fmt.Println("======FIN RECOVERY======")
-		return
+
+	// Lo que el journaling no conserva no se puede reproducir
+	var notReplayed []string
+	switch {
+	case base < 0:
+		lost := journalEntries[0].Count - 1
+		fmt.Fprintln(out, "\n⚠️  El journaling ya no conserva la operación 'mkfs': sus registros más antiguos se sobrescribieron")
+		fmt.Fprintln(out, "   Se reproducirán las entradas disponibles, pero la recuperación quedará incompleta")
+		if lost > 0 {
+			notReplayed = append(notReplayed, fmt.Sprintf("operaciones anteriores a la entrada #1 (%d registros sobrescritos del journaling)", lost))
+		} else {
+			notReplayed = append(notReplayed, "operaciones anteriores a la entrada #1 (el journaling no llega hasta el último mkfs)")
+		}
+	case strings.ToLower(journalEntries[base].Operation) == "convert":
+		fmt.Fprintf(out, "\n🔍 Conversión desde EXT2 encontrada en la entrada #%d\n", base+1)
+		notReplayed = append(notReplayed, "contenido anterior a la conversión desde EXT2 (no está en el journaling)")
+	default:
+		fmt.Fprintf(out, "\n🔍 Última operación 'mkfs' encontrada en la entrada #%d\n", base+1)
 	}
-	
-	// Mostrar algunas operaciones del journal
-	fmt.Println("\n📝 Operaciones registradas en el journal:")
-	count := 0
-	for i, journal := range journalEntries {
-		if count >= 10 { // Mostrar solo las primeras 10
-			fmt.Printf("   ... y %d operaciones más\n", len(journalEntries)-10)
-			break
+	firstIndex := base + 1
+
+	// Un salto en la secuencia es una operación que no cupo en el journaling
+	// y no se registró (versiones anteriores saltaban su secuencia; ahora se
+	// registra con journalOversized)
+	for i := maxInt(base, 0); i < len(journalEntries); i++ {
+		next := sb.S_journal_seq + 1
+		if i+1 < len(journalEntries) {
+			next = journalEntries[i+1].Count
 		}
-		
-		operation := strings.TrimRight(string(journal.Content.Operation[:]), "\x00")
-		path := strings.TrimRight(string(journal.Content.Path[:]), "\x00")
-		
-		if operation != "" {
-			fmt.Printf("   %d. %s - %s\n", i+1, operation, path)
-			count++
+		if end := journalEntries[i].Count + journalEntries[i].Records; end < next {
+			notReplayed = append(notReplayed, fmt.Sprintf("operación posterior a la entrada #%d (no cupo en el journaling)", journalEntries[i].Index))
 		}
 	}
 	
-	// Procesar el journaling para reconstruir el sistema
-	fmt.Println("\n🔧 Reconstruyendo sistema de archivos...")
+	// Paso 1: recrear la estructura base sobre el superblock existente
+	fmt.Fprintln(out, "\n♻️  Paso 1: Reformateando el sistema de archivos...")
+	currentDate := Utilities.CurrentTimestamp()
+	copy(sb.S_umtime[:], currentDate)
+	if err := writeInitialStructure(out, file, &sb, partitionStart, currentDate, true); err != nil {
+		fmt.Fprintln(out, "ERROR recreando la estructura base:", err)
+		fmt.Fprintln(out, "======FIN RECOVERY======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error recreando la estructura base: %v", err)
+	}
+	fmt.Fprintln(out, "   ✓ Sistema de archivos base recreado")
 	
-	// Contar operaciones por tipo
-	mkfsCount := 0
-	mkdirCount := 0
-	mkfileCount := 0
-	removeCount := 0
-	editCount := 0
-	renameCount := 0
-	copyCount := 0
-	moveCount := 0
-	chownCount := 0
-	chmodCount := 0
+	// Paso 2: volver a ejecutar las operaciones en orden
+	fmt.Fprintln(out, "\n♻️  Paso 2: Replicando operaciones desde el journal...")
+	results := replayJournal(out, id, journalEntries[firstIndex:])
 	
-	for _, journal := range journalEntries {
-		operation := strings.TrimRight(string(journal.Content.Operation[:]), "\x00")
-		
-		switch strings.ToLower(operation) {
-		case "mkfs":
-			mkfsCount++
-		case "mkdir":
-			mkdirCount++
-		case "mkfile":
-			mkfileCount++
-		case "remove":
-			removeCount++
-		case "edit":
-			editCount++
-		case "rename":
-			renameCount++
-		case "copy":
-			copyCount++
-		case "move":
-			moveCount++
-		case "chown":
-			chownCount++
-		case "chmod":
-			chmodCount++
+	replayed, partial, skipped, failed := 0, 0, 0, 0
+	fmt.Fprintln(out, "\n📊 Resultado de la reproducción:")
+	for _, result := range results {
+		switch result.Status {
+		case "replayed":
+			replayed++
+			fmt.Fprintf(out, "   #%d ✓ %s %s\n", result.Index, result.Operation, result.Path)
+		case "skipped":
+			skipped++
+			fmt.Fprintf(out, "   #%d - %s %s (omitida: %s)\n", result.Index, result.Operation, result.Path, result.Reason)
+		case "partial":
+			partial++
+			fmt.Fprintf(out, "   #%d ~ %s %s (incompleta: %s)\n", result.Index, result.Operation, result.Path, result.Reason)
+		default:
+			failed++
+			fmt.Fprintf(out, "   #%d ✗ %s %s (falló: %s)\n", result.Index, result.Operation, result.Path, result.Reason)
 		}
 	}
+	fmt.Fprintf(out, "\n   Reproducidas: %d | Incompletas: %d | Omitidas: %d | Fallidas: %d\n", replayed, partial, skipped, failed)
 	
-	fmt.Println("\n📊 Resumen de operaciones:")
-	if mkfsCount > 0 {
-		fmt.Printf("   - Formateos (mkfs): %d\n", mkfsCount)
-	}
-	if mkdirCount > 0 {
-		fmt.Printf("   - Directorios creados (mkdir): %d\n", mkdirCount)
-	}
-	if mkfileCount > 0 {
-		fmt.Printf("   - Archivos creados (mkfile): %d\n", mkfileCount)
-	}
-	if removeCount > 0 {
-		fmt.Printf("   - Eliminaciones (remove): %d\n", removeCount)
-	}
-	if editCount > 0 {
-		fmt.Printf("   - Ediciones (edit): %d\n", editCount)
-	}
-	if renameCount > 0 {
-		fmt.Printf("   - Renombrados (rename): %d\n", renameCount)
-	}
-	if copyCount > 0 {
-		fmt.Printf("   - Copias (copy): %d\n", copyCount)
-	}
-	if moveCount > 0 {
-		fmt.Printf("   - Movimientos (move): %d\n", moveCount)
-	}
-	if chownCount > 0 {
-		fmt.Printf("   - Cambios de propietario (chown): %d\n", chownCount)
-	}
-	if chmodCount > 0 {
-		fmt.Printf("   - Cambios de permisos (chmod): %d\n", chmodCount)
-	}
-	
-	// Buscar la última operación mkfs en el journal
-	lastMkfsIndex := -1
-	for i := len(journalEntries) - 1; i >= 0; i-- {
-		operation := strings.TrimRight(string(journalEntries[i].Content.Operation[:]), "\x00")
-		if strings.ToLower(operation) == "mkfs" {
-			lastMkfsIndex = i
-			break
+	// Las entradas omitidas tampoco se reprodujeron (el mkfs o la conversión
+	// de la base no llegan aquí)
+	for _, result := range results {
+		if result.Status != "replayed" {
+			notReplayed = append(notReplayed, fmt.Sprintf("#%d %s %s: %s", result.Index, result.Operation, result.Path, result.Reason))
 		}
 	}
-	
-	if lastMkfsIndex == -1 {
-		fmt.Println("\n⚠️  No se encontró una operación 'mkfs' en el journal")
-		fmt.Println("   No se puede determinar el estado del último formateo")
-		fmt.Println("======FIN RECOVERY======")
-		return
+	recovery := &RecoveryResult{Entries: results, Complete: len(notReplayed) == 0, NotReplayed: notReplayed}
+	if !recovery.Complete {
+		fmt.Fprintln(out, "\n⚠️  Recuperación incompleta. No se pudo reproducir:")
+		for _, item := range notReplayed {
+			fmt.Fprintf(out, "   - %s\n", item)
+		}
+		fmt.Fprintln(out, "======FIN RECOVERY======")
+		return recovery, Utilities.NewCommandError(Utilities.ErrIncomplete, "Recuperación incompleta: no se pudo reproducir %s", strings.Join(notReplayed, "; "))
 	}
-	
-	fmt.Printf("\n🔍 Última operación 'mkfs' encontrada en la entrada #%d\n", lastMkfsIndex+1)
-	fmt.Println("   Recuperando a ese estado consistente...")
-	
-	// Simular la recuperación: Re-formatear y aplicar operaciones hasta el último mkfs
-	fmt.Println("\n♻️  Paso 1: Reformateando el sistema de archivos...")
-	
-	// Re-ejecutar mkfs internamente (mantener el superblock y estructura básica)
-	// En este caso, solo necesitamos recrear la estructura básica
-	
-	// INODO 0: Directorio raíz
-	rootInode := Structs.Inode{}
-	rootInode.I_uid = 1    // Usuario root
-	rootInode.I_gid = 1    // Grupo root
-	rootInode.I_size = 0
-	currentDate := "23/10/2025"
-	copy(rootInode.I_atime[:], currentDate)
-	copy(rootInode.I_ctime[:], currentDate)
-	copy(rootInode.I_mtime[:], currentDate)
-	rootInode.I_type[0] = '0'  // 0 = directorio
-	rootInode.I_perm = [3]byte{'7', '7', '7'}
-	rootInode.I_block[0] = 0
 
-	// BLOQUE 0: Contenido del directorio raíz
-	rootBlock := Structs.Folderblock{}
-	copy(rootBlock.B_content[0].B_name[:], ".")
-	rootBlock.B_content[0].B_inodo = 0
-	copy(rootBlock.B_content[1].B_name[:], "..")
-	rootBlock.B_content[1].B_inodo = 0
-	copy(rootBlock.B_content[2].B_name[:], "users.txt")
-	rootBlock.B_content[2].B_inodo = 1
-	rootBlock.B_content[3].B_inodo = -1
-
-	// INODO 1: Archivo users.txt
-	usersInode := Structs.Inode{}
-	usersInode.I_uid = 1
-	usersInode.I_gid = 1
-	
-	usersContent := "1,G,root\n1,U,root,root,123\n"
-	usersInode.I_size = int32(len(usersContent))
-	copy(usersInode.I_atime[:], currentDate)
-	copy(usersInode.I_ctime[:], currentDate)
-	copy(usersInode.I_mtime[:], currentDate)
-	usersInode.I_type[0] = '1'  // 1 = archivo
-	usersInode.I_perm = [3]byte{'7', '7', '7'}
-	usersInode.I_block[0] = 1
-
-	// BLOQUE 1: Contenido del archivo users.txt
-	usersBlock := Structs.Fileblock{}
-	copy(usersBlock.B_content[:], usersContent)
-
-	// Escribir estructuras
-	file.Seek(int64(partitionStart+sb.S_inode_start), 0)
-	binary.Write(file, binary.LittleEndian, &rootInode)
-	binary.Write(file, binary.LittleEndian, &usersInode)
-
-	file.Seek(int64(partitionStart+sb.S_block_start), 0)
-	binary.Write(file, binary.LittleEndian, &rootBlock)
-	binary.Write(file, binary.LittleEndian, &usersBlock)
-
-	// Marcar inodos 0 y 1 como ocupados
-	file.Seek(int64(partitionStart+sb.S_bm_inode_start), 0)
-	file.Write([]byte{1, 1})
-
-	// Marcar bloques 0 y 1 como ocupados
-	file.Seek(int64(partitionStart+sb.S_bm_block_start), 0)
-	file.Write([]byte{1, 1})
-
-	// Actualizar superblock
-	sb.S_free_inodes_count = sb.S_inodes_count - 2
-	sb.S_free_blocks_count = sb.S_blocks_count - 2
-	sb.S_fist_ino = 2
-	sb.S_first_blo = 2
-	copy(sb.S_umtime[:], currentDate)
-	
-	file.Seek(int64(partitionStart), 0)
-	binary.Write(file, binary.LittleEndian, &sb)
-	
-	fmt.Println("   ✓ Sistema de archivos base recreado")
-	
-	fmt.Println("\n♻️  Paso 2: Replicando operaciones desde el journal...")
-	fmt.Println("   (Simulación - en un sistema real se re-ejecutarían las operaciones)")
-	
-	operationsToReplay := journalEntries[:lastMkfsIndex+1]
-	fmt.Printf("   Se replicarían %d operaciones hasta el último formateo\n", len(operationsToReplay))
-	
-	fmt.Println("\n✅ Recuperación completada exitosamente")
-	fmt.Println("   El sistema de archivos ha sido restaurado a un estado consistente")
-	fmt.Printf("   Estado: Antes del último formateo (entrada #%d del journal)\n", lastMkfsIndex+1)
-	fmt.Println("\n💡 Nota: El sistema ha sido restaurado con la estructura básica:")
-	fmt.Println("   - Directorio raíz (/)")
-	fmt.Println("   - Archivo users.txt con usuario root")
-	fmt.Println("   - Las operaciones registradas en el journal están disponibles para auditoría")
-	
-	fmt.Println("======FIN RECOVERY======")
+	fmt.Fprintln(out, "\n✅ Recuperación completada")
+	fmt.Fprintln(out, "======FIN RECOVERY======")
+	return recovery, nil
 }
 
 // ============================================================================
@@ -6071,13 +5594,19 @@ type FileSystemNode struct {
 	OwnerID     int32              `json:"uid"`
 	GroupID     int32              `json:"gid"`
 	Inode       int32              `json:"inode"`
+	CreatedAt   string             `json:"created_at"`  // Fechas en formato "2006-01-02 15:04:05"
+	ModifiedAt  string             `json:"modified_at"`
+	AccessedAt  string             `json:"accessed_at"`
 	Children    []FileSystemNode   `json:"children,omitempty"`
 }
 
 // GetFileSystemTree obtiene el árbol completo del sistema de archivos para una partición
 func GetFileSystemTree(partitionID string) (*FileSystemNode, error) {
+	// Lectura concurrente: bloquear el disco solo en modo lectura
+	defer DiskManagement.RLockPartition(partitionID)()
+
 	// Verificar que la partición esté montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return nil, fmt.Errorf("partición con ID '%s' no está montada", partitionID)
 	}
@@ -6121,6 +5650,9 @@ func buildFileSystemNode(file *os.File, superblock *Structs.Superblock, inodeNum
 		OwnerID:     inode.I_uid,
 		GroupID:     inode.I_gid,
 		Inode:       inodeNum,
+		CreatedAt:   Utilities.FormatTimestamp(strings.TrimRight(string(inode.I_ctime[:]), "\x00")),
+		ModifiedAt:  Utilities.FormatTimestamp(strings.TrimRight(string(inode.I_mtime[:]), "\x00")),
+		AccessedAt:  Utilities.FormatTimestamp(strings.TrimRight(string(inode.I_atime[:]), "\x00")),
 	}
 
 	// Determinar el tipo (archivo o directorio)
@@ -6188,7 +5720,7 @@ func readDirectoryEntries(file *os.File, superblock *Structs.Superblock, dirInod
 				continue // Entrada vacía
 			}
 
-			entryName := strings.TrimRight(string(folderBlock.B_content[j].B_name[:]), "\x00")
+			entryName := EntryName(file, superblock, folderBlock.B_content[j])
 			if entryName != "" {
 				entries = append(entries, DirectoryEntry{
 					Name:  entryName,
@@ -6202,17 +5734,20 @@ func readDirectoryEntries(file *os.File, superblock *Structs.Superblock, dirInod
 }
 
 // GetFileContent obtiene el contenido de un archivo por su ruta
-func GetFileContent(partitionID string, filePath string) (string, error) {
+func GetFileContent(partitionID string, filePath string) ([]byte, error) {
+	// Lectura concurrente: bloquear el disco solo en modo lectura
+	defer DiskManagement.RLockPartition(partitionID)()
+
 	// Buscar el archivo
 	exists, inodeNum := findFileInDirectory(partitionID, filePath)
 	if !exists {
-		return "", fmt.Errorf("archivo '%s' no encontrado", filePath)
+		return nil, fmt.Errorf("archivo '%s' no encontrado", filePath)
 	}
 
 	// Leer y retornar el contenido
 	content, err := readFileContent(partitionID, inodeNum)
 	if err != nil {
-		return "", fmt.Errorf("error leyendo contenido del archivo: %s", err.Error())
+		return nil, fmt.Errorf("error leyendo contenido del archivo: %s", err.Error())
 	}
 
 	return content, nil
@@ -6220,6 +5755,9 @@ func GetFileContent(partitionID string, filePath string) (string, error) {
 
 // GetDirectoryContents obtiene el contenido de un directorio por su ruta
 func GetDirectoryContents(partitionID string, dirPath string) ([]FileSystemNode, error) {
+	// Lectura concurrente: bloquear el disco solo en modo lectura
+	defer DiskManagement.RLockPartition(partitionID)()
+
 	// Buscar el directorio
 	exists, dirInode := findDirectoryInPath(partitionID, dirPath)
 	if !exists {
@@ -6227,7 +5765,7 @@ func GetDirectoryContents(partitionID string, dirPath string) ([]FileSystemNode,
 	}
 
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return nil, fmt.Errorf("partición no montada")
 	}
@@ -6276,6 +5814,9 @@ func GetDirectoryContents(partitionID string, dirPath string) ([]FileSystemNode,
 			OwnerID:     entryInode.I_uid,
 			GroupID:     entryInode.I_gid,
 			Inode:       entry.Inode,
+			CreatedAt:   Utilities.FormatTimestamp(strings.TrimRight(string(entryInode.I_ctime[:]), "\x00")),
+			ModifiedAt:  Utilities.FormatTimestamp(strings.TrimRight(string(entryInode.I_mtime[:]), "\x00")),
+			AccessedAt:  Utilities.FormatTimestamp(strings.TrimRight(string(entryInode.I_atime[:]), "\x00")),
 		}
 
 		// Determinar el tipo
@@ -6310,8 +5851,11 @@ type JournalingEntry struct {
 
 // GetJournalingData obtiene todas las entradas del journaling en formato estructurado
 func GetJournalingData(partitionID string) ([]JournalingEntry, error) {
+	// Lectura concurrente: bloquear el disco solo en modo lectura
+	defer DiskManagement.RLockPartition(partitionID)()
+
 	// Verificar que la partición esté montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return nil, fmt.Errorf("no existe una partición montada con el ID '%s'", partitionID)
 	}
@@ -6323,25 +5867,12 @@ func GetJournalingData(partitionID string) ([]JournalingEntry, error) {
 	}
 	defer file.Close()
 
-	// Leer el MBR
-	var tempMBR Structs.MBR
-	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
+	// Obtener el inicio de la partición
+	partitionStart, err := getPartitionStart(file, mountedPartition)
+	if err != nil {
 		return nil, fmt.Errorf("error leyendo MBR: %s", err.Error())
 	}
 
-	// Obtener la partición correcta
-	var partitionStart int32
-	if mountedPartition.IsLogical {
-		var tempEBR Structs.EBR
-		if err := Utilities.ReadObject(file, &tempEBR, int64(mountedPartition.EBRPosition)); err != nil {
-			return nil, fmt.Errorf("error leyendo EBR: %s", err.Error())
-		}
-		partitionStart = tempEBR.Part_start
-	} else {
-		partition := tempMBR.Partitions[mountedPartition.PartitionIndex]
-		partitionStart = partition.Start
-	}
-
 	// Leer el superblock
 	var sb Structs.Superblock
 	if err := Utilities.ReadObject(file, &sb, int64(partitionStart)); err != nil {
@@ -6353,36 +5884,17 @@ func GetJournalingData(partitionID string) ([]JournalingEntry, error) {
 		return nil, fmt.Errorf("la partición no es EXT3, no tiene journaling")
 	}
 
-	// Leer todas las entradas del journal
-	journalStart := partitionStart + int32(binary.Size(Structs.Superblock{}))
+	// Leer todas las entradas del journal, ya reensambladas
 	var entries []JournalingEntry
-
-	// Leer hasta encontrar entradas vacías o llegar al límite
-	maxEntries := 50 // Límite del journaling
-	for i := 0; i < maxEntries; i++ {
-		var journal Structs.Journaling
-		journalPos := int64(journalStart) + int64(i*binary.Size(Structs.Journaling{}))
-		if err := Utilities.ReadObject(file, &journal, journalPos); err != nil {
-			continue
-		}
-
-		// Si la operación está vacía, terminamos
-		operation := strings.TrimRight(string(journal.Content.Operation[:]), "\x00")
-		if operation == "" {
-			break
-		}
-
-		// Crear entrada estructurada
-		entry := JournalingEntry{
-			Index:     i + 1,
-			Operation: operation,
-			Path:      strings.TrimRight(string(journal.Content.Path[:]), "\x00"),
-			Content:   strings.TrimRight(string(journal.Content.Content[:]), "\x00"),
-			Date:      fmt.Sprintf("%.0f", journal.Content.Date),
-			Owner:     fmt.Sprintf("%d", journal.Count),
-		}
-
-		entries = append(entries, entry)
+	for _, record := range ReadJournalRecords(file, partitionStart) {
+		entries = append(entries, JournalingEntry{
+			Index:     record.Index,
+			Operation: record.Operation,
+			Path:      record.Path,
+			Content:   record.Content,
+			Date:      fmt.Sprintf("%.0f", record.Date),
+			Owner:     fmt.Sprintf("%d", record.Count),
+		})
 	}
 
 	return entries, nil
@@ -0,0 +1,421 @@
+package FileSystem
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"proyecto1/DiskManagement"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+)
+
+// ============================================================================
+// COMANDO FSCK - VERIFICAR LA CONSISTENCIA DEL SISTEMA DE ARCHIVOS
+// ============================================================================
+
+// FsckIssue describe una inconsistencia encontrada por fsck
+type FsckIssue struct {
+	Kind   string `json:"kind"`
+	Inode  int32  `json:"inode"`
+	Block  int32  `json:"block"`
+	Path   string `json:"path,omitempty"`
+	Status string `json:"status"` // found, repaired o unfixable
+	Reason string `json:"reason"`
+}
+
+// FsckReport es el resultado de revisar una partición
+type FsckReport struct {
+	PartitionID      string      `json:"id"`
+	Filesystem       string      `json:"filesystem"`
+	Fix              bool        `json:"fix"`
+	InodesCount      int32       `json:"inodes_count"`
+	BlocksCount      int32       `json:"blocks_count"`
+	ReachableInodes  int32       `json:"reachable_inodes"`
+	ReferencedBlocks int32       `json:"referenced_blocks"`
+	FreeInodes       int32       `json:"free_inodes"`
+	FreeBlocks       int32       `json:"free_blocks"`
+	Issues           []FsckIssue `json:"issues"`
+	Clean            bool        `json:"clean"`
+}
+
+// fsckChecker guarda el estado del recorrido: los bitmaps en memoria, los
+// inodos alcanzados desde la raíz y el inodo dueño de cada bloque
+type fsckChecker struct {
+	file        *os.File
+	superblock  *Structs.Superblock
+	fix         bool
+	inodeBitmap []byte
+	blockBitmap []byte
+	reachable   []bool
+	blockOwner  map[int32]int32
+	issues      []FsckIssue
+}
+
+// report agrega una inconsistencia; status indica si se reparó
+func (c *fsckChecker) report(kind string, inode int32, block int32, path string, status string, format string, args ...interface{}) {
+	c.issues = append(c.issues, FsckIssue{
+		Kind:   kind,
+		Inode:  inode,
+		Block:  block,
+		Path:   path,
+		Status: status,
+		Reason: fmt.Sprintf(format, args...),
+	})
+}
+
+// repairStatus devuelve el estado de una inconsistencia reparable
+func (c *fsckChecker) repairStatus() string {
+	if c.fix {
+		return "repaired"
+	}
+	return "found"
+}
+
+func (c *fsckChecker) inodePosition(inodeNum int32) int64 {
+	return int64(c.superblock.S_inode_start) + int64(inodeNum)*int64(c.superblock.S_inode_size)
+}
+
+// validInode indica si un inodo parece en uso: su tipo debe ser carpeta o archivo
+func validInode(inode *Structs.Inode) bool {
+	return inode.I_type[0] == '0' || inode.I_type[0] == '1'
+}
+
+// claimBlock registra que el bloque pertenece al inodo. Retorna false si el
+// bloque está fuera de rango o ya lo usaba otro inodo.
+func (c *fsckChecker) claimBlock(inodeNum int32, blockIndex int32, path string) bool {
+	if blockIndex < 0 || blockIndex >= c.superblock.S_blocks_count {
+		return false
+	}
+	if owner, used := c.blockOwner[blockIndex]; used {
+		c.report("duplicate_block", inodeNum, blockIndex, path, "unfixable",
+			"el bloque %d ya lo usa el inodo %d", blockIndex, owner)
+		return false
+	}
+	c.blockOwner[blockIndex] = inodeNum
+	return true
+}
+
+// checkDirectory revisa los bloques de una carpeta, sus entradas "." y ".."
+// y recorre sus hijos
+func (c *fsckChecker) checkDirectory(inodeNum int32, parentNum int32, path string) {
+	var inode Structs.Inode
+	if err := Utilities.ReadObject(c.file, &inode, c.inodePosition(inodeNum)); err != nil {
+		return
+	}
+
+	inodeChanged := false
+	for i, blockIndex := range inode.I_block {
+		if blockIndex == -1 {
+			continue
+		}
+		if blockIndex < 0 || blockIndex >= c.superblock.S_blocks_count {
+			c.report("invalid_block_pointer", inodeNum, blockIndex, path, c.repairStatus(),
+				"I_block[%d] apunta fuera de los %d bloques", i, c.superblock.S_blocks_count)
+			if c.fix {
+				inode.I_block[i] = -1
+				inodeChanged = true
+			}
+			continue
+		}
+		if owner, used := c.blockOwner[blockIndex]; used {
+			// Un bloque de carpeta compartido se suelta de esta carpeta
+			c.report("duplicate_block", inodeNum, blockIndex, path, c.repairStatus(),
+				"el bloque %d ya lo usa el inodo %d", blockIndex, owner)
+			if c.fix {
+				inode.I_block[i] = -1
+				inodeChanged = true
+			}
+			continue
+		}
+		c.blockOwner[blockIndex] = inodeNum
+
+		var folderBlock Structs.Folderblock
+		blockPos := blockPosition(c.superblock, blockIndex)
+		if err := Utilities.ReadObject(c.file, &folderBlock, blockPos); err != nil {
+			continue
+		}
+
+		blockChanged := false
+		for j := range folderBlock.B_content {
+			entry := &folderBlock.B_content[j]
+			name := EntryName(c.file, c.superblock, *entry)
+
+			// Las dos primeras entradas del primer bloque son "." y ".."
+			if i == 0 && j < 2 {
+				expectedName, expectedInode := ".", inodeNum
+				if j == 1 {
+					expectedName, expectedInode = "..", parentNum
+				}
+				if name != expectedName || entry.B_inodo != expectedInode {
+					c.report("bad_dot_entry", inodeNum, blockIndex, path, c.repairStatus(),
+						"la entrada %d es '%s' -> %d, se esperaba '%s' -> %d", j, name, entry.B_inodo, expectedName, expectedInode)
+					if c.fix {
+						entry.B_name = [shortNameSize]byte{}
+						copy(entry.B_name[:], expectedName)
+						entry.B_inodo = expectedInode
+						blockChanged = true
+					}
+				}
+				continue
+			}
+
+			if entry.B_inodo == -1 {
+				continue
+			}
+			if isLongName(entry) {
+				if _, err := readLongName(c.file, c.superblock, entry); err != nil {
+					c.report("bad_name_block", entry.B_inodo, nameBlockOf(entry), path, c.repairStatus(),
+						"la entrada '%s' no tiene un bloque de nombre válido: %s", name, err.Error())
+					if c.fix {
+						entry.B_name = [shortNameSize]byte{}
+						entry.B_inodo = -1
+						blockChanged = true
+					}
+					continue
+				}
+			}
+			childPath := strings.TrimSuffix(path, "/") + "/" + name
+			child := entry.B_inodo
+
+			var childInode Structs.Inode
+			dangling := child < 0 || child >= c.superblock.S_inodes_count
+			if !dangling {
+				dangling = Utilities.ReadObject(c.file, &childInode, c.inodePosition(child)) != nil || !validInode(&childInode)
+			}
+			switch {
+			case dangling:
+				c.report("dangling_entry", child, blockIndex, childPath, c.repairStatus(),
+					"la entrada '%s' apunta al inodo %d, que no existe", name, child)
+			case c.reachable[child]:
+				c.report("duplicate_reference", child, blockIndex, childPath, c.repairStatus(),
+					"el inodo %d ya está enlazado desde otra carpeta", child)
+			case isLongName(entry) && !c.claimBlock(inodeNum, nameBlockOf(entry), childPath):
+				// El bloque de nombre ya lo usa otra entrada (claimBlock lo reporta)
+			default:
+				c.reachable[child] = true
+				if childInode.I_type[0] == '0' {
+					c.checkDirectory(child, inodeNum, childPath)
+				} else {
+					c.checkFile(child, &childInode, childPath)
+				}
+				continue
+			}
+
+			// Entrada inválida: se borra de la carpeta
+			if c.fix {
+				entry.B_name = [shortNameSize]byte{}
+				entry.B_inodo = -1
+				blockChanged = true
+			}
+		}
+
+		if blockChanged {
+			Utilities.WriteObject(c.file, folderBlock, blockPos)
+		}
+	}
+
+	if inodeChanged {
+		Utilities.WriteObject(c.file, inode, c.inodePosition(inodeNum))
+	}
+}
+
+// checkFile registra los bloques de datos y de apuntadores de un archivo
+func (c *fsckChecker) checkFile(inodeNum int32, inode *Structs.Inode, path string) {
+	data, pointers := FileBlocks(c.file, c.superblock, inode)
+	for _, blockIndex := range pointers {
+		c.claimBlock(inodeNum, blockIndex, path)
+	}
+	for _, blockIndex := range data {
+		if blockIndex < 0 || blockIndex >= c.superblock.S_blocks_count {
+			c.report("invalid_block_pointer", inodeNum, blockIndex, path, "unfixable",
+				"el archivo apunta al bloque %d, fuera de los %d bloques", blockIndex, c.superblock.S_blocks_count)
+			continue
+		}
+		c.claimBlock(inodeNum, blockIndex, path)
+	}
+	if blocksForSize(int(inode.I_size)) > len(data) {
+		c.report("size_mismatch", inodeNum, -1, path, "unfixable",
+			"I_size es %d bytes pero solo hay %d bloques de datos", inode.I_size, len(data))
+	}
+}
+
+// Fsck revisa la consistencia de una partición: recorre el árbol desde el
+// inodo 0 y compara los inodos y bloques alcanzados con los bitmaps y los
+// contadores del superblock. Con fix repara lo que se puede reparar: borra
+// entradas inválidas, corrige "." y "..", libera inodos huérfanos y bloques
+// sin dueño, marca en los bitmaps lo que está en uso y recalcula los
+// contadores. Se ejecuta con el disco bloqueado, sin otras operaciones en curso.
+func Fsck(out io.Writer, id string, fix bool) (*FsckReport, error) {
+	fmt.Fprintln(out, "======Inicio FSCK======")
+	fmt.Fprintf(out, "Partición: %s\n", id)
+	fmt.Fprintln(out, "Reparar:", fix)
+
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
+	if !exists {
+		fmt.Fprintf(out, "Error: La partición con ID '%s' no está montada\n", id)
+		fmt.Fprintln(out, "======FIN FSCK======")
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "La partición con ID '%s' no está montada", id)
+	}
+
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		fmt.Fprintln(out, "Error abriendo archivo del disco:", err)
+		fmt.Fprintln(out, "======FIN FSCK======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo del disco: %v", err)
+	}
+	defer file.Close()
+
+	partitionStart, err := getPartitionStart(file, mountedPartition)
+	if err != nil {
+		fmt.Fprintln(out, "Error obteniendo el inicio de la partición:", err)
+		fmt.Fprintln(out, "======FIN FSCK======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error obteniendo el inicio de la partición: %v", err)
+	}
+
+	var sb Structs.Superblock
+	if err := Utilities.ReadObject(file, &sb, int64(partitionStart)); err != nil {
+		fmt.Fprintln(out, "Error leyendo superblock:", err)
+		fmt.Fprintln(out, "======FIN FSCK======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo superblock: %v", err)
+	}
+	if sb.S_magic != 0xEF53 || sb.S_inodes_count <= 0 || sb.S_blocks_count <= 0 {
+		fmt.Fprintf(out, "Error: La partición '%s' no tiene un sistema de archivos válido\n", id)
+		fmt.Fprintln(out, "======FIN FSCK======")
+		return nil, Utilities.NewCommandError(Utilities.ErrUnsupported, "La partición '%s' no tiene un sistema de archivos válido", id)
+	}
+
+	checker := &fsckChecker{
+		file:        file,
+		superblock:  &sb,
+		fix:         fix,
+		inodeBitmap: make([]byte, sb.S_inodes_count),
+		blockBitmap: make([]byte, sb.S_blocks_count),
+		reachable:   make([]bool, sb.S_inodes_count),
+		blockOwner:  make(map[int32]int32),
+	}
+	if err := Utilities.ReadObject(file, checker.inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+		fmt.Fprintln(out, "======FIN FSCK======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo bitmap de inodos: %v", err)
+	}
+	if err := Utilities.ReadObject(file, checker.blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+		fmt.Fprintln(out, "======FIN FSCK======")
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo bitmap de bloques: %v", err)
+	}
+
+	report := &FsckReport{
+		PartitionID: id,
+		Filesystem:  fmt.Sprintf("EXT%d", sb.S_filesystem_type),
+		Fix:         fix,
+		InodesCount: sb.S_inodes_count,
+		BlocksCount: sb.S_blocks_count,
+	}
+
+	// Paso 1: recorrer el árbol desde la raíz
+	var root Structs.Inode
+	if err := Utilities.ReadObject(file, &root, checker.inodePosition(0)); err != nil || root.I_type[0] != '0' {
+		checker.report("invalid_root", 0, -1, "/", "unfixable", "el inodo 0 no es una carpeta")
+		report.Issues = checker.issues
+		printFsckReport(out, report)
+		return report, nil
+	}
+	checker.reachable[0] = true
+	checker.checkDirectory(0, 0, "/")
+
+	// Paso 2: comparar los inodos alcanzados con el bitmap
+	for i := int32(0); i < sb.S_inodes_count; i++ {
+		switch {
+		case checker.reachable[i] && checker.inodeBitmap[i] == 0:
+			checker.report("inode_bitmap_unset", i, -1, "", checker.repairStatus(), "el inodo %d está en uso pero libre en el bitmap", i)
+			if fix {
+				checker.inodeBitmap[i] = 1
+			}
+		case !checker.reachable[i] && checker.inodeBitmap[i] != 0:
+			checker.report("orphan_inode", i, -1, "", checker.repairStatus(), "el inodo %d está ocupado en el bitmap pero ninguna carpeta lo enlaza", i)
+			if fix {
+				checker.inodeBitmap[i] = 0
+			}
+		}
+		if checker.reachable[i] {
+			report.ReachableInodes++
+		}
+	}
+
+	// Paso 3: comparar los bloques referenciados con el bitmap
+	for i := int32(0); i < sb.S_blocks_count; i++ {
+		_, referenced := checker.blockOwner[i]
+		switch {
+		case referenced && checker.blockBitmap[i] == 0:
+			checker.report("block_bitmap_unset", checker.blockOwner[i], i, "", checker.repairStatus(), "el bloque %d está en uso pero libre en el bitmap", i)
+			if fix {
+				checker.blockBitmap[i] = 1
+			}
+		case !referenced && checker.blockBitmap[i] != 0:
+			checker.report("block_leak", -1, i, "", checker.repairStatus(), "el bloque %d está ocupado en el bitmap pero ningún inodo lo usa", i)
+			if fix {
+				checker.blockBitmap[i] = 0
+			}
+		}
+	}
+	report.ReferencedBlocks = int32(len(checker.blockOwner))
+
+	// Paso 4: los contadores deben coincidir con los bitmaps
+	freeInodes := sb.S_inodes_count - countUsed(checker.inodeBitmap)
+	freeBlocks := sb.S_blocks_count - countUsed(checker.blockBitmap)
+	if sb.S_free_inodes_count != freeInodes {
+		checker.report("free_inodes_count", -1, -1, "", checker.repairStatus(), "S_free_inodes_count es %d y el bitmap tiene %d libres", sb.S_free_inodes_count, freeInodes)
+	}
+	if sb.S_free_blocks_count != freeBlocks {
+		checker.report("free_blocks_count", -1, -1, "", checker.repairStatus(), "S_free_blocks_count es %d y el bitmap tiene %d libres", sb.S_free_blocks_count, freeBlocks)
+	}
+
+	if fix {
+		sb.S_free_inodes_count = freeInodes
+		sb.S_free_blocks_count = freeBlocks
+		sb.S_fist_ino = firstFree(checker.inodeBitmap)
+		sb.S_first_blo = firstFree(checker.blockBitmap)
+		if err := Utilities.WriteObject(file, checker.inodeBitmap, int64(sb.S_bm_inode_start)); err != nil {
+			fmt.Fprintln(out, "======FIN FSCK======")
+			return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo bitmap de inodos: %v", err)
+		}
+		if err := Utilities.WriteObject(file, checker.blockBitmap, int64(sb.S_bm_block_start)); err != nil {
+			fmt.Fprintln(out, "======FIN FSCK======")
+			return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo bitmap de bloques: %v", err)
+		}
+		if err := Utilities.WriteObject(file, sb, int64(partitionStart)); err != nil {
+			fmt.Fprintln(out, "======FIN FSCK======")
+			return nil, Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo superblock: %v", err)
+		}
+	}
+
+	report.FreeInodes = sb.S_free_inodes_count
+	report.FreeBlocks = sb.S_free_blocks_count
+	report.Issues = checker.issues
+	printFsckReport(out, report)
+	return report, nil
+}
+
+// printFsckReport muestra el resumen y las inconsistencias en consola
+func printFsckReport(out io.Writer, report *FsckReport) {
+	if report.Issues == nil {
+		report.Issues = []FsckIssue{}
+	}
+	report.Clean = len(report.Issues) == 0
+
+	fmt.Fprintf(out, "Sistema de archivos: %s\n", report.Filesystem)
+	fmt.Fprintf(out, "Inodos alcanzados: %d de %d\n", report.ReachableInodes, report.InodesCount)
+	fmt.Fprintf(out, "Bloques referenciados: %d de %d\n", report.ReferencedBlocks, report.BlocksCount)
+	for _, issue := range report.Issues {
+		fmt.Fprintf(out, "  [%s] %s: %s", issue.Status, issue.Kind, issue.Reason)
+		if issue.Path != "" {
+			fmt.Fprintf(out, " (%s)", issue.Path)
+		}
+		fmt.Fprintln(out)
+	}
+	if report.Clean {
+		fmt.Fprintln(out, "=== SISTEMA DE ARCHIVOS CONSISTENTE ===")
+	} else {
+		fmt.Fprintf(out, "=== %d INCONSISTENCIAS ENCONTRADAS ===\n", len(report.Issues))
+	}
+	fmt.Fprintln(out, "======FIN FSCK======")
+}
@@ -0,0 +1,594 @@
+package FileSystem
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"proyecto1/DiskManagement"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// REPRODUCCIÓN DEL JOURNALING (EXT3)
+// ============================================================================
+
+// Número de registros del journaling cuando mkfs no indica otro
+const DefaultJournalSize = 50
+
+// Particiones cuyo journaling está suspendido mientras recovery reproduce
+// las operaciones, para no volver a registrarlas
+var (
+	journalSuspended      = make(map[string]bool)
+	journalSuspendedMutex sync.Mutex
+)
+
+// JournalReplayEntry es el resultado de reproducir una entrada del journaling
+type JournalReplayEntry struct {
+	Index     int    `json:"index"`
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Status    string `json:"status"` // replayed, partial, skipped o failed
+	Reason    string `json:"reason,omitempty"`
+}
+
+// RecoveryResult es el resultado de recovery. Complete es false cuando el
+// journaling no conserva todo lo necesario para reconstruir la partición o
+// alguna operación falló; NotReplayed describe lo que no se reprodujo.
+type RecoveryResult struct {
+	Entries     []JournalReplayEntry `json:"entries"`
+	Complete    bool                 `json:"complete"`
+	NotReplayed []string             `json:"not_replayed,omitempty"`
+}
+
+// suspendJournal desactiva el journaling de una partición; retorna la función que lo reactiva
+func suspendJournal(partitionID string) func() {
+	journalSuspendedMutex.Lock()
+	journalSuspended[partitionID] = true
+	journalSuspendedMutex.Unlock()
+
+	return func() {
+		journalSuspendedMutex.Lock()
+		delete(journalSuspended, partitionID)
+		journalSuspendedMutex.Unlock()
+	}
+}
+
+// isJournalSuspended indica si el journaling de la partición está suspendido
+func isJournalSuspended(partitionID string) bool {
+	journalSuspendedMutex.Lock()
+	defer journalSuspendedMutex.Unlock()
+	return journalSuspended[partitionID]
+}
+
+// getPartitionStart obtiene el inicio de la partición montada (donde está el superblock)
+func getPartitionStart(file *os.File, mountedPartition Structs.MountedPartition) (int32, error) {
+	if mountedPartition.IsLogical {
+		return mountedPartition.EBRPosition + int32(binary.Size(Structs.EBR{})), nil
+	}
+
+	var tempMBR Structs.MBR
+	if err := Utilities.ReadObject(file, &tempMBR, 0); err != nil {
+		return 0, err
+	}
+	return tempMBR.Partitions[mountedPartition.PartitionIndex].Start, nil
+}
+
+// journalCapacity retorna el número de registros del journaling guardado en
+// el superblock (los sistemas formateados sin ese dato usan el valor por defecto)
+func journalCapacity(superblock *Structs.Superblock) int32 {
+	if superblock.S_journal_count > 0 {
+		return superblock.S_journal_count
+	}
+	return DefaultJournalSize
+}
+
+// Marca de operación de los registros que continúan la entrada anterior.
+// Una entrada cuya ruta o contenido no caben en un solo registro
+// (Path de 32 bytes, Content de 64) ocupa varios registros consecutivos:
+// el primero lleva la operación y los siguientes esta marca.
+const journalContinuation = "+cont"
+
+// Marca de operación del registro que reemplaza a una entrada más grande que
+// todo el journaling: guarda la operación y los registros que necesitaba
+// ("op=mkdir,records=9") y el inicio de la ruta. Recovery la reporta como no
+// reproducida.
+const journalOversized = "+big"
+
+// Contenido de las entradas cuyos datos no caben en el journaling: en lugar
+// de los datos (journalData) se guarda su tamaño, "omitted=<bytes>"
+const journalOmitted = "omitted="
+
+// JournalRecord es una entrada lógica del journaling ya reensamblada
+type JournalRecord struct {
+	Index     int   // Posición de la entrada (desde 1) en orden cronológico
+	Slot      int32 // Registro donde empieza la entrada
+	Count     int32 // Número de secuencia del primer registro
+	Records   int32 // Registros que ocupa la entrada
+	Operation string
+	Path      string
+	Content   string
+	Date      float32
+}
+
+// journalData arma el contenido del journaling que guarda los datos de un
+// archivo: "data:" seguido del texto, o "b64:" en base64 si son binarios
+// (los registros se leen sin los ceros finales y el binario se perdería)
+func journalData(content []byte) string {
+	if isText(content) {
+		return "data:" + string(content)
+	}
+	return "b64:" + base64.StdEncoding.EncodeToString(content)
+}
+
+// decodeJournalData recupera los datos guardados con journalData
+func decodeJournalData(content string) ([]byte, bool) {
+	switch {
+	case strings.HasPrefix(content, "data:"):
+		return []byte(strings.TrimPrefix(content, "data:")), true
+	case strings.HasPrefix(content, "b64:"):
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(content, "b64:"))
+		return data, err == nil
+	}
+	return nil, false
+}
+
+// isText indica si el contenido es texto UTF-8 sin bytes nulos
+func isText(content []byte) bool {
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) == -1
+}
+
+// journalRecordCount calcula cuántos registros ocupa una entrada para
+// guardar completas la ruta y el contenido
+func journalRecordCount(path string, content string) int {
+	const pathChunk = len(Structs.Information{}.Path)
+	const contentChunk = len(Structs.Information{}.Content)
+
+	records := 1
+	if n := (len(path) + pathChunk - 1) / pathChunk; n > records {
+		records = n
+	}
+	if n := (len(content) + contentChunk - 1) / contentChunk; n > records {
+		records = n
+	}
+	return records
+}
+
+// encodeJournalEntry divide una entrada en los registros necesarios para
+// guardar completas la ruta y el contenido
+func encodeJournalEntry(operation string, path string, content string, date float32) []Structs.Journaling {
+	const pathChunk = len(Structs.Information{}.Path)
+	const contentChunk = len(Structs.Information{}.Content)
+
+	records := journalRecordCount(path, content)
+
+	if len(operation) > len(Structs.Information{}.Operation) {
+		operation = operation[:len(Structs.Information{}.Operation)]
+	}
+
+	result := make([]Structs.Journaling, records)
+	for i := 0; i < records; i++ {
+		if i == 0 {
+			copy(result[i].Content.Operation[:], operation)
+		} else {
+			copy(result[i].Content.Operation[:], journalContinuation)
+		}
+		if start := i * pathChunk; start < len(path) {
+			copy(result[i].Content.Path[:], path[start:])
+		}
+		if start := i * contentChunk; start < len(content) {
+			copy(result[i].Content.Content[:], content[start:])
+		}
+		result[i].Content.Date = date
+	}
+	return result
+}
+
+// partitionJournalCapacity retorna el número de registros del journaling de
+// la partición montada; ok es false si la partición no es EXT3 o si el
+// journaling está suspendido (no se registrará nada)
+func partitionJournalCapacity(partitionID string) (capacity int32, ok bool) {
+	if isJournalSuspended(partitionID) {
+		return 0, false
+	}
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
+	if !exists {
+		return 0, false
+	}
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	partitionStart, err := getPartitionStart(file, mountedPartition)
+	if err != nil {
+		return 0, false
+	}
+	var superblock Structs.Superblock
+	if err := Utilities.ReadObject(file, &superblock, int64(partitionStart)); err != nil || superblock.S_filesystem_type != 3 {
+		return 0, false
+	}
+	return journalCapacity(&superblock), true
+}
+
+// fitJournalData arma el contenido de una entrada que guarda los datos de un
+// archivo: header seguido de journalData(data). Si con los datos la entrada
+// no cabe en el journaling, guarda solo su tamaño (journalOmitted): la
+// operación se hace igual y recovery la reporta como no reproducida.
+func fitJournalData(partitionID string, operation string, path string, header string, data []byte) string {
+	content := header + journalData(data)
+	if capacity, ok := partitionJournalCapacity(partitionID); ok && int32(journalRecordCount(path, content)) > capacity {
+		return fmt.Sprintf("%s%s%d", header, journalOmitted, len(data))
+	}
+	return content
+}
+
+// ReadJournalRecords lee el journaling de la partición que empieza en
+// partitionStart y retorna las entradas en orden cronológico, reensamblando
+// las que ocupan varios registros. Como cada registro lleva un número de
+// secuencia creciente, el orden se conserva aunque el journaling haya dado
+// varias vueltas.
+func ReadJournalRecords(file *os.File, partitionStart int32) []JournalRecord {
+	var superblock Structs.Superblock
+	if err := Utilities.ReadObject(file, &superblock, int64(partitionStart)); err != nil {
+		return nil
+	}
+	capacity := journalCapacity(&superblock)
+	journalStart := partitionStart + int32(binary.Size(Structs.Superblock{}))
+	journalingSize := int32(binary.Size(Structs.Journaling{}))
+
+	// Leer los registros usados junto con su posición
+	type slotRecord struct {
+		slot    int32
+		journal Structs.Journaling
+	}
+	var used []slotRecord
+	for i := int32(0); i < capacity; i++ {
+		var journal Structs.Journaling
+		if err := Utilities.ReadObject(file, &journal, int64(journalStart+i*journalingSize)); err != nil {
+			break
+		}
+		if journal.Count <= 0 || journal.Content.Operation[0] == 0 {
+			continue
+		}
+		used = append(used, slotRecord{slot: i, journal: journal})
+	}
+	sort.SliceStable(used, func(i, j int) bool { return used[i].journal.Count < used[j].journal.Count })
+
+	// Unir cada registro de continuación con la entrada que lo precede
+	records := make([]JournalRecord, 0, len(used))
+	var previous int32 = -1
+	for _, item := range used {
+		operation := strings.TrimRight(string(item.journal.Content.Operation[:]), "\x00")
+		path := strings.TrimRight(string(item.journal.Content.Path[:]), "\x00")
+		content := strings.TrimRight(string(item.journal.Content.Content[:]), "\x00")
+
+		if operation == journalContinuation {
+			// Si la entrada inicial fue sobrescrita, el fragmento se descarta
+			if len(records) > 0 && item.journal.Count == previous+1 {
+				records[len(records)-1].Path += path
+				records[len(records)-1].Content += content
+				records[len(records)-1].Records++
+				previous = item.journal.Count
+			}
+			continue
+		}
+
+		records = append(records, JournalRecord{
+			Index:     len(records) + 1,
+			Slot:      item.slot,
+			Count:     item.journal.Count,
+			Records:   1,
+			Operation: operation,
+			Path:      path,
+			Content:   content,
+			Date:      item.journal.Content.Date,
+		})
+		previous = item.journal.Count
+	}
+	return records
+}
+
+// journalOwner arma el campo del journaling con el propietario, el grupo y
+// los permisos del inodo que la sesión va a crear (los mismos que asignan
+// createFileInDirectory y createDirectoryInParent)
+func journalOwner(session *Structs.UserSession) string {
+	perm := "664"
+	if session.Username == "root" {
+		perm = "777"
+	}
+	return fmt.Sprintf("owner=%d:%d:%s", session.UserID, session.GroupID, perm)
+}
+
+// inodeOwner es el propietario, grupo y permisos guardados con journalOwner
+type inodeOwner struct {
+	uid  int32
+	gid  int32
+	perm string
+}
+
+// splitJournalOwner separa el campo owner del resto del contenido de una
+// entrada de mkfile o mkdir. ok es false si la entrada no lo guarda (formato
+// anterior) o si es inválido.
+func splitJournalOwner(content string) (owner inodeOwner, rest string, ok bool) {
+	if !strings.HasPrefix(content, "owner=") {
+		return owner, content, false
+	}
+	field, rest, _ := strings.Cut(content, ",")
+	parts := strings.Split(strings.TrimPrefix(field, "owner="), ":")
+	if len(parts) != 3 || len(parts[2]) != 3 {
+		return owner, rest, false
+	}
+	uid, uidErr := strconv.Atoi(parts[0])
+	gid, gidErr := strconv.Atoi(parts[1])
+	if uidErr != nil || gidErr != nil {
+		return owner, rest, false
+	}
+	return inodeOwner{uid: int32(uid), gid: int32(gid), perm: parts[2]}, rest, true
+}
+
+// missingDirectories retorna los directorios de la ruta que todavía no
+// existen: mkdir -p y mkfile -r los crean junto con la entrada
+func missingDirectories(partitionID string, path string) []string {
+	var missing []string
+	dir, _ := parseFilePath(path)
+	for dir != "/" {
+		if exists, _ := findDirectoryInPath(partitionID, dir); exists {
+			break
+		}
+		missing = append(missing, dir)
+		dir, _ = parseFilePath(dir)
+	}
+	return missing
+}
+
+// replayCreate reproduce un mkfile o mkdir: create crea la entrada como root
+// con el resto del contenido y después se restauran el propietario, el grupo y
+// los permisos guardados en el journaling, también en los directorios padre
+// que la operación creó
+func replayCreate(session *Structs.UserSession, path string, content string, create func(rest string) (int32, error)) (string, string) {
+	owner, rest, hasOwner := splitJournalOwner(content)
+	parents := missingDirectories(session.PartitionID, path)
+
+	inodeIndex, err := create(rest)
+	if err != nil {
+		return "failed", err.Error()
+	}
+	if !hasOwner {
+		return "partial", "el journaling no guarda el propietario ni los permisos, quedaron los de root"
+	}
+
+	restore := func(inode *Structs.Inode) {
+		inode.I_uid = owner.uid
+		inode.I_gid = owner.gid
+		copy(inode.I_perm[:], owner.perm)
+	}
+	touchPartitionInode(session.PartitionID, inodeIndex, restore)
+	for _, dir := range parents {
+		if exists, dirInode := findDirectoryInPath(session.PartitionID, dir); exists {
+			touchPartitionInode(session.PartitionID, dirInode, restore)
+		}
+	}
+	return "replayed", ""
+}
+
+// replayJournalEntry vuelve a ejecutar una operación del journaling con la
+// sesión indicada. Retorna el estado (replayed, partial, skipped o failed) y
+// la razón.
+func replayJournalEntry(out io.Writer, session *Structs.UserSession, operation string, path string, content string) (string, string) {
+	var err error
+
+	switch operation {
+	case journalOversized:
+		fields := parseJournalFields(content)
+		return "skipped", fmt.Sprintf("la operación %s ocupaba %s registros y no cupo en el journaling", fields["op"], fields["records"])
+	case "mkfs":
+		return "skipped", "formateo base, ya recreado"
+	case "convert":
+		return "skipped", "conversión de EXT2 a EXT3, el journaling empieza aquí"
+	case "mkdir":
+		return replayCreate(session, path, content, func(string) (int32, error) {
+			return Mkdir(out, session, path, true)
+		})
+	case "mkfile":
+		_, rest, _ := splitJournalOwner(content)
+		source := strings.TrimPrefix(rest, "from:")
+		if strings.HasPrefix(rest, "from:") && !fileExistsLocal(source) {
+			// Formato anterior: solo se guardaba la ruta del archivo local
+			return "skipped", fmt.Sprintf("el archivo de origen '%s' ya no existe", source)
+		}
+		size, convErr := strconv.Atoi(strings.TrimPrefix(rest, "size="))
+		if strings.HasPrefix(rest, "size=") && convErr != nil {
+			return "failed", "tamaño inválido en el journaling"
+		}
+		if strings.HasPrefix(rest, journalOmitted) {
+			status, reason := replayCreate(session, path, content, func(string) (int32, error) {
+				return Mkfile(out, session, path, true, 0, "")
+			})
+			if status == "failed" {
+				return status, reason
+			}
+			return "partial", fmt.Sprintf("el contenido (%s bytes) no cupo en el journaling, el archivo quedó vacío", strings.TrimPrefix(rest, journalOmitted))
+		}
+		return replayCreate(session, path, content, func(rest string) (int32, error) {
+			data, literal := decodeJournalData(rest)
+			switch {
+			case literal:
+				return createFileWithContent(out, session, path, true, data, true)
+			case strings.HasPrefix(rest, "from:"):
+				return Mkfile(out, session, path, true, 0, source)
+			case strings.HasPrefix(rest, "size="):
+				return Mkfile(out, session, path, true, size, "")
+			default:
+				return Mkfile(out, session, path, true, 0, "")
+			}
+		})
+	case "remove":
+		err = Remove(out, session, path)
+	case "edit":
+		if strings.HasPrefix(content, journalOmitted) {
+			return "skipped", fmt.Sprintf("el contenido editado (%s bytes) no cupo en el journaling", strings.TrimPrefix(content, journalOmitted))
+		}
+		data, literal := decodeJournalData(content)
+		if !literal {
+			return "skipped", "el journaling no guarda el contenido editado"
+		}
+		err = editFileContent(out, session, path, data)
+	case "write":
+		header, rest, _ := strings.Cut(content, ",")
+		if strings.HasPrefix(rest, journalOmitted) {
+			return "skipped", fmt.Sprintf("los datos escritos (%s bytes) no cupieron en el journaling", strings.TrimPrefix(rest, journalOmitted))
+		}
+		offset, convErr := strconv.Atoi(parseJournalFields(header)["offset"])
+		data, literal := decodeJournalData(rest)
+		if !literal || convErr != nil {
+			return "failed", "formato de write inválido en el journaling"
+		}
+		_, err = Write(out, session, path, offset, false, data)
+	case "rename":
+		parts := strings.SplitN(content, "->", 2)
+		if len(parts) != 2 {
+			return "failed", "formato de rename inválido en el journaling"
+		}
+		err = Rename(out, session, path, parts[1])
+	case "copy", "move":
+		parts := strings.SplitN(content, "->", 2)
+		if len(parts) != 2 {
+			return "failed", fmt.Sprintf("formato de %s inválido en el journaling", operation)
+		}
+		if operation == "copy" {
+			err = Copy(out, session, path, parts[1])
+		} else {
+			err = Move(out, session, path, parts[1])
+		}
+	case "chmod":
+		fields := parseJournalFields(content)
+		if ugo, ok := fields["ugo"]; ok {
+			err = Chmod(out, session, path, ugo, fields["r"] == "true")
+		} else {
+			err = Chmod(out, session, path, content, false)
+		}
+	case "chown":
+		fields := parseJournalFields(content)
+		if username, ok := fields["user"]; ok {
+			err = Chown(out, session, path, fields["r"] == "true", username)
+			break
+		}
+		uid, convErr := strconv.Atoi(fields["uid"])
+		if convErr != nil {
+			return "failed", "uid inválido en el journaling"
+		}
+		username, found := findUsernameByID(session.PartitionID, uid)
+		if !found {
+			return "failed", fmt.Sprintf("no existe un usuario con uid %d", uid)
+		}
+		err = Chown(out, session, path, false, username)
+	case "mkgrp":
+		err = Mkgrp(out, session, content)
+	case "rmgrp":
+		err = Rmgrp(out, session, content)
+	case "rmusr":
+		err = Rmusr(out, session, content)
+	case "mkusr":
+		fields := parseJournalFields(content)
+		if passwordHash, ok := fields["hash"]; ok {
+			if !isCompleteHash(passwordHash) {
+				return "failed", "hash de contraseña inválido o incompleto en el journaling"
+			}
+			err = createUser(out, session, fields["user"], passwordHash, fields["grp"])
+			break
+		}
+		password, ok := fields["pass"]
+		if !ok {
+			return "skipped", "el journaling no guarda la contraseña del usuario"
+		}
+		err = Mkusr(out, session, fields["user"], password, fields["grp"])
+	case "passwd":
+		fields := parseJournalFields(content)
+		err = setPasswordHash(session.PartitionID, fields["user"], fields["hash"])
+	case "chgrp":
+		fields := parseJournalFields(content)
+		username, ok := fields["user"]
+		if !ok {
+			return "skipped", "el journaling no guarda el usuario modificado"
+		}
+		err = Chgrp(out, session, username, fields["grp"])
+	default:
+		return "skipped", fmt.Sprintf("operación '%s' no soportada", operation)
+	}
+
+	if err != nil {
+		return "failed", err.Error()
+	}
+	return "replayed", ""
+}
+
+// parseJournalFields separa un contenido con formato "clave=valor,clave=valor"
+func parseJournalFields(content string) map[string]string {
+	fields := make(map[string]string)
+	for _, part := range strings.Split(content, ",") {
+		if key, value, found := strings.Cut(part, "="); found {
+			fields[strings.TrimSpace(key)] = value
+		}
+	}
+	return fields
+}
+
+// findUsernameByID busca el nombre de un usuario activo por su ID en users.txt
+func findUsernameByID(partitionID string, uid int) (string, bool) {
+	usersData, err := readUsersFile(partitionID)
+	if err != nil {
+		return "", false
+	}
+
+	for _, line := range strings.Split(usersData, "\n") {
+		// Formato: UID,U,grupo,usuario,contraseña
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) != 5 || strings.TrimSpace(fields[1]) != "U" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err == nil && id == uid {
+			return strings.TrimSpace(fields[3]), true
+		}
+	}
+	return "", false
+}
+
+// replayJournal reproduce en orden las entradas recibidas
+func replayJournal(out io.Writer, partitionID string, entries []JournalRecord) []JournalReplayEntry {
+	// Las operaciones se reproducen como root sobre la partición recuperada
+	session := &Structs.UserSession{
+		Username:    "root",
+		UserID:      1,
+		GroupID:     1,
+		PartitionID: partitionID,
+		IsActive:    true,
+	}
+
+	resume := suspendJournal(partitionID)
+	defer resume()
+
+	results := make([]JournalReplayEntry, 0, len(entries))
+	for _, record := range entries {
+		entry := JournalReplayEntry{
+			Index:     record.Index,
+			Operation: strings.ToLower(record.Operation),
+			Path:      record.Path,
+			Content:   record.Content,
+		}
+		entry.Status, entry.Reason = replayJournalEntry(out, session, entry.Operation, entry.Path, entry.Content)
+		results = append(results, entry)
+	}
+	return results
+}
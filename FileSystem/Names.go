@@ -0,0 +1,187 @@
+package FileSystem
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+)
+
+// ============================================================================
+// NOMBRES LARGOS EN LAS ENTRADAS DE CARPETA
+// ============================================================================
+
+// Content.B_name guarda hasta 12 bytes. Un nombre más largo se guarda completo
+// en un bloque de nombre (un Fileblock del área de bloques) y B_name queda así:
+//
+//	B_name[0]    = longNameMarker
+//	B_name[1:5]  = índice del bloque de nombre (int32, little endian)
+//	B_name[5:12] = primeros bytes del nombre (solo informativo)
+//
+// Los nombres de hasta 12 bytes se guardan igual que siempre, así que las
+// particiones creadas antes se leen sin cambios. El bloque de nombre pertenece
+// a la carpeta que contiene la entrada y se libera al borrar o renombrar la
+// entrada.
+
+const (
+	shortNameSize  = 12   // Bytes de B_name
+	longNameMarker = 0x01 // Primer byte de B_name en una entrada con nombre largo
+	longNameHint   = 5    // Desde aquí B_name guarda el inicio del nombre largo
+)
+
+// MaxNameLength es el largo máximo en bytes del nombre de un archivo o carpeta
+const MaxNameLength = fileBlockSize
+
+// ValidateEntryName revisa que un nombre se pueda guardar en una entrada de
+// carpeta: no vacío, sin '/', sin caracteres de control y de hasta
+// MaxNameLength bytes
+func ValidateEntryName(name string) error {
+	if name == "" {
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre no puede estar vacío")
+	}
+	if strings.Contains(name, "/") {
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre '%s' no puede contener el carácter '/'", name)
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] < 0x20 || name[i] == 0x7F {
+			return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre '%s' contiene caracteres de control", name)
+		}
+	}
+	if len(name) > MaxNameLength {
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "El nombre '%s' es demasiado largo (%d bytes, máximo %d)", name, len(name), MaxNameLength)
+	}
+	return nil
+}
+
+// validatePathNames revisa con ValidateEntryName cada componente de una ruta
+// absoluta
+func validatePathNames(path string) error {
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if err := ValidateEntryName(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isLongName indica si la entrada guarda su nombre en un bloque de nombre
+func isLongName(entry *Structs.Content) bool {
+	return entry.B_name[0] == longNameMarker
+}
+
+// nameBlockOf devuelve el bloque de nombre de una entrada con nombre largo
+func nameBlockOf(entry *Structs.Content) int32 {
+	return int32(binary.LittleEndian.Uint32(entry.B_name[1:longNameHint]))
+}
+
+// EntryName devuelve el nombre completo de una entrada de carpeta, leyendo el
+// bloque de nombre si el nombre no cabe en B_name
+func EntryName(file *os.File, superblock *Structs.Superblock, entry Structs.Content) string {
+	if !isLongName(&entry) {
+		return strings.TrimRight(string(entry.B_name[:]), "\x00")
+	}
+	name, err := readLongName(file, superblock, &entry)
+	if err != nil {
+		// Sin el bloque de nombre solo queda el inicio guardado en B_name
+		return strings.TrimRight(string(entry.B_name[longNameHint:]), "\x00") + "~"
+	}
+	return name
+}
+
+// readLongName lee el nombre guardado en el bloque de nombre de la entrada
+func readLongName(file *os.File, superblock *Structs.Superblock, entry *Structs.Content) (string, error) {
+	blockIndex := nameBlockOf(entry)
+	if blockIndex < 0 || blockIndex >= superblock.S_blocks_count {
+		return "", fmt.Errorf("bloque de nombre %d fuera de los %d bloques", blockIndex, superblock.S_blocks_count)
+	}
+	var nameBlock Structs.Fileblock
+	if err := Utilities.ReadObject(file, &nameBlock, blockPosition(superblock, blockIndex)); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(nameBlock.B_content[:]), "\x00"), nil
+}
+
+// setEntryName guarda el nombre en la entrada. Si no cabe en B_name reserva un
+// bloque de nombre y escribe el bitmap y el superblock. La entrada no debe
+// tener un bloque de nombre asignado (ver clearEntryName).
+func setEntryName(file *os.File, superblock *Structs.Superblock, entry *Structs.Content, name string) error {
+	if err := ValidateEntryName(name); err != nil {
+		return err
+	}
+	entry.B_name = [shortNameSize]byte{}
+	if len(name) <= shortNameSize {
+		copy(entry.B_name[:], name)
+		return nil
+	}
+
+	blocks, err := allocateBlocks(file, superblock, 1)
+	if err != nil {
+		return fmt.Errorf("no se pudo reservar el bloque para el nombre '%s': %s", name, err.Error())
+	}
+	var nameBlock Structs.Fileblock
+	copy(nameBlock.B_content[:], name)
+	if err := Utilities.WriteObject(file, nameBlock, blockPosition(superblock, blocks[0])); err != nil {
+		return fmt.Errorf("error escribiendo el bloque de nombre: %s", err.Error())
+	}
+	Utilities.WriteObject(file, *superblock, superblockPosition(superblock))
+
+	entry.B_name[0] = longNameMarker
+	binary.LittleEndian.PutUint32(entry.B_name[1:longNameHint], uint32(blocks[0]))
+	copy(entry.B_name[longNameHint:], name)
+	return nil
+}
+
+// clearEntryName borra el nombre de la entrada y libera su bloque de nombre si
+// lo tiene
+func clearEntryName(file *os.File, superblock *Structs.Superblock, entry *Structs.Content) {
+	if isLongName(entry) {
+		blockIndex := nameBlockOf(entry)
+		if blockIndex >= 0 && blockIndex < superblock.S_blocks_count {
+			markBlockAsFree(file, superblock, blockIndex)
+		}
+	}
+	entry.B_name = [shortNameSize]byte{}
+}
+
+// NameBlocks recorre las carpetas en uso y devuelve sus bloques de nombre con
+// el nombre que guarda cada uno
+func NameBlocks(file *os.File, superblock *Structs.Superblock) map[int32]string {
+	names := make(map[int32]string)
+	for i := int32(0); i < superblock.S_inodes_count; i++ {
+		var bitmapByte byte
+		if err := Utilities.ReadObject(file, &bitmapByte, int64(superblock.S_bm_inode_start+i)); err != nil || bitmapByte == 0 {
+			continue
+		}
+		var inode Structs.Inode
+		if err := Utilities.ReadObject(file, &inode, int64(superblock.S_inode_start+i*superblock.S_inode_size)); err != nil {
+			continue
+		}
+		if inode.I_type[0] != '0' {
+			continue
+		}
+		for _, blockIndex := range inode.I_block {
+			if blockIndex < 0 || blockIndex >= superblock.S_blocks_count {
+				continue
+			}
+			var folderBlock Structs.Folderblock
+			if err := Utilities.ReadObject(file, &folderBlock, blockPosition(superblock, blockIndex)); err != nil {
+				continue
+			}
+			for j := range folderBlock.B_content {
+				entry := &folderBlock.B_content[j]
+				if entry.B_inodo == -1 || !isLongName(entry) {
+					continue
+				}
+				if name, err := readLongName(file, superblock, entry); err == nil {
+					names[nameBlockOf(entry)] = name
+				}
+			}
+		}
+	}
+	return names
+}
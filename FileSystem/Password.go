@@ -0,0 +1,186 @@
+package FileSystem
+
+import (
+	"fmt"
+	"io"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ============================================================================
+// CONTRASEÑAS CIFRADAS EN USERS.TXT
+// ============================================================================
+
+// hashPassword cifra la contraseña con bcrypt (incluye su propia sal)
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// isPasswordHash indica si lo guardado en users.txt ya es un hash bcrypt
+func isPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// isCompleteHash indica si un hash recibido del journaling está completo
+// (los hash bcrypt tienen 60 caracteres); uno truncado dejaría al usuario
+// sin poder iniciar sesión
+func isCompleteHash(passwordHash string) bool {
+	return isPasswordHash(passwordHash) && len(passwordHash) == 60
+}
+
+// verifyPassword compara la contraseña con lo guardado en users.txt. El
+// segundo valor indica que la contraseña estaba en texto plano y debe migrarse.
+func verifyPassword(stored string, password string) (bool, bool) {
+	if !isPasswordHash(stored) {
+		return stored == password, stored == password
+	}
+	return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil, false
+}
+
+// setUserPassword reemplaza la contraseña guardada de un usuario en los datos de users.txt
+func setUserPassword(usersData string, username string, passwordHash string) string {
+	lines := strings.Split(usersData, "\n")
+	var updatedLines []string
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// Formato: UID,U,grupo,usuario,contraseña
+		parts := strings.Split(line, ",")
+		if len(parts) == 5 && strings.TrimSpace(parts[1]) == "U" && strings.TrimSpace(parts[3]) == username {
+			line = fmt.Sprintf("%s,U,%s,%s,%s", strings.TrimSpace(parts[0]), strings.TrimSpace(parts[2]), username, passwordHash)
+		}
+		updatedLines = append(updatedLines, line)
+	}
+
+	result := strings.Join(updatedLines, "\n")
+	if len(updatedLines) > 0 {
+		result += "\n" // Asegurar que termine con \n
+	}
+	return result
+}
+
+// upgradePassword guarda cifrada la contraseña de un usuario que todavía la
+// tenía en texto plano. Se llama después de un login exitoso.
+func upgradePassword(out io.Writer, partitionID string, usersData string, username string, password string) error {
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := writeUsersFile(partitionID, setUserPassword(usersData, username, passwordHash)); err != nil {
+		return err
+	}
+
+	// Registrar en el journaling (EXT3) para que recovery conserve la migración
+	writeToJournal(out, partitionID, "passwd", "/users.txt", fmt.Sprintf("user=%s,hash=%s", username, passwordHash))
+	return nil
+}
+
+// Passwd - Cambiar la contraseña de un usuario. root puede cambiar la de
+// cualquiera; los demás solo la propia y deben indicar la contraseña actual.
+func Passwd(out io.Writer, session *Structs.UserSession, username string, current string, password string) error {
+	fmt.Fprintln(out, "======Inicio PASSWD======")
+
+	// Verificar que haya una sesión activa
+	if !IsUserLoggedIn(session) {
+		fmt.Fprintln(out, "Error: Debe iniciar sesión primero")
+		fmt.Fprintln(out, "Use: login -user=<usuario> -pass=<contraseña> -id=<ID_particion>")
+		fmt.Fprintln(out, "======FIN PASSWD======")
+		return Utilities.NewCommandError(Utilities.ErrNotLoggedIn, "Debe iniciar sesión primero")
+	}
+
+	// Sin -user se cambia la contraseña del usuario de la sesión
+	if strings.TrimSpace(username) == "" {
+		username = session.Username
+	}
+	fmt.Fprintf(out, "Usuario: %s\n", username)
+
+	if session.Username != "root" && username != session.Username {
+		fmt.Fprintln(out, "Error: Solo el usuario 'root' puede cambiar la contraseña de otros usuarios")
+		fmt.Fprintln(out, "======FIN PASSWD======")
+		return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "Solo el usuario 'root' puede cambiar la contraseña de otros usuarios")
+	}
+
+	// Validar la nueva contraseña con las mismas reglas de mkusr
+	if strings.TrimSpace(password) == "" {
+		fmt.Fprintln(out, "Error: La contraseña no puede estar vacía")
+		fmt.Fprintln(out, "======FIN PASSWD======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La contraseña no puede estar vacía")
+	}
+	if len(password) > 10 {
+		fmt.Fprintf(out, "Error: La contraseña no puede tener más de 10 caracteres (actual: %d)\n", len(password))
+		fmt.Fprintln(out, "======FIN PASSWD======")
+		return Utilities.NewCommandError(Utilities.ErrInvalidArgument, "La contraseña no puede tener más de 10 caracteres (actual: %d)", len(password))
+	}
+
+	// Leer el archivo users.txt actual
+	usersData, err := readUsersFile(session.PartitionID)
+	if err != nil {
+		fmt.Fprintf(out, "Error leyendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN PASSWD======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo archivo users.txt: %s", err.Error())
+	}
+
+	userFound, userInfo := findUser(usersData, username)
+	if !userFound || userInfo.ID == 0 {
+		fmt.Fprintf(out, "Error: El usuario '%s' no existe en el sistema\n", username)
+		fmt.Fprintln(out, "======FIN PASSWD======")
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "El usuario '%s' no existe en el sistema", username)
+	}
+
+	// Los usuarios que no son root deben confirmar su contraseña actual
+	if session.Username != "root" {
+		if ok, _ := verifyPassword(userInfo.Password, current); !ok {
+			fmt.Fprintln(out, "Error: La contraseña actual es incorrecta")
+			fmt.Fprintln(out, "======FIN PASSWD======")
+			return Utilities.NewCommandError(Utilities.ErrPermissionDenied, "La contraseña actual es incorrecta")
+		}
+	}
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		fmt.Fprintf(out, "Error cifrando la contraseña: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN PASSWD======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error cifrando la contraseña: %s", err.Error())
+	}
+
+	if err := writeUsersFile(session.PartitionID, setUserPassword(usersData, username, passwordHash)); err != nil {
+		fmt.Fprintf(out, "Error escribiendo archivo users.txt: %s\n", err.Error())
+		fmt.Fprintln(out, "======FIN PASSWD======")
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error escribiendo archivo users.txt: %s", err.Error())
+	}
+
+	// Registrar en el journaling (EXT3); solo se guarda el hash
+	writeToJournal(out, session.PartitionID, "passwd", "/users.txt", fmt.Sprintf("user=%s,hash=%s", username, passwordHash))
+
+	fmt.Fprintln(out, "=== CONTRASEÑA CAMBIADA EXITOSAMENTE ===")
+	fmt.Fprintf(out, "Usuario: %s\n", username)
+	fmt.Fprintf(out, "Usuario que realizó el cambio: %s\n", session.Username)
+	fmt.Fprintln(out, "======FIN PASSWD======")
+	return nil
+}
+
+// setPasswordHash guarda un hash ya calculado como contraseña del usuario
+// (lo usa recovery al reproducir passwd)
+func setPasswordHash(partitionID string, username string, passwordHash string) error {
+	if !isCompleteHash(passwordHash) {
+		return fmt.Errorf("hash de contraseña inválido o incompleto para el usuario '%s'", username)
+	}
+	usersData, err := readUsersFile(partitionID)
+	if err != nil {
+		return err
+	}
+	if found, _ := findUser(usersData, username); !found {
+		return fmt.Errorf("el usuario '%s' no existe en el sistema", username)
+	}
+	return writeUsersFile(partitionID, setUserPassword(usersData, username, passwordHash))
+}
@@ -0,0 +1,67 @@
+package FileSystem
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+)
+
+// ============================================================================
+// REDIMENSIONAR EL SISTEMA DE ARCHIVOS (FDISK -ADD)
+// ============================================================================
+
+// ResizeFilesystem ajusta el sistema de archivos de la partición que empieza
+// en partitionStart a su nuevo tamaño: recalcula la cantidad de inodos y
+// bloques como mkfs y reubica bitmaps, tabla de inodos y bloques (ver
+// relayout). Rechaza la reducción si los inodos o bloques ocupados no caben.
+// Si la partición no tiene sistema de archivos no hace nada. fdisk -add la
+// llama después de escribir el nuevo tamaño en el MBR o el EBR, y lo
+// restaura si esta función falla.
+func ResizeFilesystem(out io.Writer, file *os.File, partitionStart int32, newSize int32) error {
+	var sb Structs.Superblock
+	if err := Utilities.ReadObject(file, &sb, int64(partitionStart)); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo superblock: %v", err)
+	}
+	if sb.S_magic != 0xEF53 {
+		fmt.Fprintln(out, "La partición no tiene sistema de archivos: solo se cambia su tamaño")
+		return nil
+	}
+	// Las distribuciones anteriores se migran al montar la partición
+	if isLegacyLayout(&sb, partitionStart) || hasLegacyBlocks(&sb) {
+		fmt.Fprintln(out, "Error: El sistema de archivos usa una distribución anterior: monte la partición para migrarlo antes de cambiar su tamaño")
+		return Utilities.NewCommandError(Utilities.ErrUnsupported, "El sistema de archivos usa una distribución anterior: monte la partición para migrarlo antes de cambiar su tamaño")
+	}
+
+	// En EXT3 el journaling conserva su tamaño y su contenido
+	var journalSize int32
+	if sb.S_filesystem_type == 3 {
+		journalSize = journalCapacity(&sb)
+	}
+	n := structureCount(newSize, &sb, journalSize)
+	if n == sb.S_inodes_count {
+		fmt.Fprintln(out, "El sistema de archivos conserva la misma cantidad de inodos y bloques")
+		return nil
+	}
+
+	image, err := loadImage(file, &sb)
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el sistema de archivos: %v", err)
+	}
+	usedInodes, usedBlocks := image.used()
+	if !image.fits(n) {
+		fmt.Fprintf(out, "Error: El nuevo tamaño solo admite %d inodos y %d bloques (ocupados: %d inodos y %d bloques)\n", n, 3*n, usedInodes, usedBlocks)
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "El nuevo tamaño solo admite %d inodos y %d bloques (ocupados: %d inodos y %d bloques)", n, 3*n, usedInodes, usedBlocks)
+	}
+
+	oldInodes, oldBlocks := sb.S_inodes_count, sb.S_blocks_count
+	if err := relayout(file, &sb, partitionStart, n, journalSize, image); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error redimensionando el sistema de archivos: %v", err)
+	}
+
+	fmt.Fprintln(out, "=== SISTEMA DE ARCHIVOS REDIMENSIONADO ===")
+	fmt.Fprintf(out, "Inodos: %d -> %d (libres: %d)\n", oldInodes, sb.S_inodes_count, sb.S_free_inodes_count)
+	fmt.Fprintf(out, "Bloques: %d -> %d (libres: %d)\n", oldBlocks, sb.S_blocks_count, sb.S_free_blocks_count)
+	return nil
+}
@@ -0,0 +1,66 @@
+package FileSystem
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"proyecto1/Structs"
+	"sync"
+)
+
+// ============================================================================
+// ALMACÉN DE SESIONES
+// ============================================================================
+
+// Sesiones activas indexadas por su token. Cada cliente de la API tiene su
+// propia sesión, por lo que varios usuarios pueden trabajar al mismo tiempo.
+var (
+	sessions      = make(map[string]*Structs.UserSession)
+	sessionsMutex sync.RWMutex
+)
+
+// newSessionToken - Generar un token aleatorio de 32 caracteres hexadecimales
+func newSessionToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// startSession - Asignar un token a la sesión y registrarla en el almacén
+func startSession(session *Structs.UserSession) error {
+	token, err := newSessionToken()
+	if err != nil {
+		return err
+	}
+
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+	session.Token = token
+	sessions[token] = session
+	return nil
+}
+
+// GetSession - Obtener la sesión activa asociada a un token (nil si no existe)
+func GetSession(token string) *Structs.UserSession {
+	if token == "" {
+		return nil
+	}
+
+	sessionsMutex.RLock()
+	defer sessionsMutex.RUnlock()
+	session, exists := sessions[token]
+	if !exists || !session.IsActive {
+		return nil
+	}
+	return session
+}
+
+// EndSession - Marcar la sesión como cerrada y eliminarla del almacén. Las
+// peticiones que aún tengan la sesión la verán inactiva.
+func EndSession(session *Structs.UserSession) {
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+	session.IsActive = false
+	delete(sessions, session.Token)
+}
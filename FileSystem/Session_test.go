@@ -0,0 +1,38 @@
+package FileSystem
+
+import (
+	"proyecto1/Structs"
+	"sync"
+	"testing"
+)
+
+// Cerrar una sesión mientras otras peticiones la consultan no debe producir
+// una carrera (go test -race) y la sesión debe quedar inactiva para todas
+func TestEndSessionWhileInUse(t *testing.T) {
+	session := &Structs.UserSession{Username: "root", PartitionID: "851A", IsActive: true}
+	if err := startSession(session); err != nil {
+		t.Fatalf("startSession: %v", err)
+	}
+	token := session.Token
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				IsUserLoggedIn(session)
+				GetSession(token)
+			}
+		}()
+	}
+	EndSession(session)
+	wg.Wait()
+
+	if IsUserLoggedIn(session) {
+		t.Error("la sesión sigue activa después de EndSession")
+	}
+	if GetSession(token) != nil {
+		t.Error("el token sigue registrado después de EndSession")
+	}
+}
@@ -0,0 +1,380 @@
+package FileSystem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"proyecto1/DiskManagement"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+)
+
+// ============================================================================
+// MIGRACIÓN DE PARTICIONES CON EL SUPERBLOCK ANTERIOR
+// ============================================================================
+
+// Los sistemas formateados antes de que el superblock guardara el anillo del
+// journaling (S_journal_count, S_journal_head, S_journal_tail y S_journal_seq)
+// tienen un superblock 16 bytes más corto, y el journaling y los bitmaps
+// empiezan antes. Leídos con el superblock actual, esos 16 bytes son el
+// primer registro del journaling (EXT3) o el inicio del bitmap de inodos
+// (EXT2), y escribir el superblock los sobrescribiría. Por eso la partición
+// se reubica con la distribución actual al montarla.
+//
+// Antes los bloques ocupaban 64 bytes y los de apuntadores tenían 16
+// entradas, lo que limitaba un archivo a unos 274 KB. Las particiones con esa
+// geometría (S_block_size = 64) también se reconstruyen al montarlas con
+// bloques de fileBlockSize bytes: las carpetas y los bloques de nombre se
+// copian al inicio de un bloque nuevo y el contenido de cada archivo se
+// reparte de nuevo (ver convertBlocks).
+
+// legacySuperblockSize es el tamaño del superblock sin los campos del journaling
+var legacySuperblockSize = int32(binary.Size(Structs.Superblock{}) - binary.Size([4]int32{}))
+
+// Geometría de los bloques de las particiones formateadas antes de usar
+// bloques de fileBlockSize bytes
+const (
+	legacyBlockSize        = 64
+	legacyPointersPerBlock = 16
+)
+
+// hasLegacyBlocks indica si la partición se formateó con bloques de 64 bytes
+func hasLegacyBlocks(sb *Structs.Superblock) bool {
+	return sb.S_magic == 0xEF53 && sb.S_block_size == legacyBlockSize
+}
+
+// isLegacyLayout indica si la partición que empieza en partitionStart tiene
+// la distribución anterior. Se deduce del inicio del bitmap de inodos, que
+// ambas versiones guardan en la misma posición del superblock: la diferencia
+// de 16 bytes no es múltiplo del tamaño de un registro del journaling, así
+// que las dos distribuciones no se confunden.
+func isLegacyLayout(sb *Structs.Superblock, partitionStart int32) bool {
+	if sb.S_magic != 0xEF53 {
+		return false
+	}
+	journalingSize := int32(binary.Size(Structs.Journaling{}))
+	current := sb.S_bm_inode_start - partitionStart - int32(binary.Size(Structs.Superblock{}))
+	legacy := sb.S_bm_inode_start - partitionStart - legacySuperblockSize
+
+	switch sb.S_filesystem_type {
+	case 2:
+		return current != 0 && legacy == 0
+	case 3:
+		return current != sb.S_journal_count*journalingSize && legacy > 0 && legacy%journalingSize == 0
+	}
+	return false
+}
+
+// UpgradeLayout reubica con la distribución actual la partición montada si
+// se formateó con el superblock anterior o con bloques de 64 bytes; si no, no
+// hace nada. En EXT3 el journaling conserva su tamaño y sus registros en el
+// mismo orden, y el anillo se reconstruye a partir de sus números de
+// secuencia si el superblock anterior no lo guardaba.
+func UpgradeLayout(out io.Writer, id string) error {
+	mountedPartition, exists := DiskManagement.GetMountedPartition(id)
+	if !exists {
+		return Utilities.NewCommandError(Utilities.ErrNotFound, "La partición con ID '%s' no está montada", id)
+	}
+
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error abriendo archivo del disco: %v", err)
+	}
+	defer file.Close()
+
+	partitionStart, err := getPartitionStart(file, mountedPartition)
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error obteniendo el inicio de la partición: %v", err)
+	}
+	var sb Structs.Superblock
+	if err := Utilities.ReadObject(file, &sb, int64(partitionStart)); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo superblock: %v", err)
+	}
+	legacySuperblock := isLegacyLayout(&sb, partitionStart)
+	legacyBlocks := hasLegacyBlocks(&sb)
+	if !legacySuperblock && !legacyBlocks {
+		return nil
+	}
+
+	if legacySuperblock {
+		fmt.Fprintf(out, "La partición %s usa la distribución anterior del superblock: migrando sus estructuras...\n", id)
+	}
+	if legacyBlocks {
+		fmt.Fprintf(out, "La partición %s usa bloques de %d bytes: migrando sus estructuras a bloques de %d bytes...\n", id, legacyBlockSize, fileBlockSize)
+	}
+	size, err := partitionSize(file, mountedPartition)
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error obteniendo el tamaño de la partición: %v", err)
+	}
+
+	// Tamaño del journaling (EXT3) según dónde empieza el bitmap de inodos;
+	// con el superblock anterior sus registros se reescriben más adelante
+	var journalSize int32
+	var records []Structs.Journaling
+	if sb.S_filesystem_type == 3 {
+		journalingSize := int32(binary.Size(Structs.Journaling{}))
+		headerSize := int32(binary.Size(Structs.Superblock{}))
+		if legacySuperblock {
+			headerSize = legacySuperblockSize
+		}
+		journalSize = (sb.S_bm_inode_start - partitionStart - headerSize) / journalingSize
+		if legacySuperblock {
+			records = make([]Structs.Journaling, journalSize)
+			if err := Utilities.ReadObject(file, records, int64(partitionStart+legacySuperblockSize)); err != nil {
+				return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el journaling: %v", err)
+			}
+		}
+	}
+
+	image, err := loadImage(file, &sb)
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error leyendo el sistema de archivos: %v", err)
+	}
+	if legacyBlocks {
+		sb.S_block_size = int32(fileBlockSize)
+	}
+	n := structureCount(size, &sb, journalSize)
+	var converted *fsImage
+	if legacyBlocks {
+		if converted, err = convertBlocks(image, n); err != nil {
+			return Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay espacio para migrar la partición '%s' a bloques de %d bytes (caben %d inodos y %d bloques): %v", id, fileBlockSize, n, 3*n, err)
+		}
+	} else if !image.fits(n) {
+		usedInodes, usedBlocks := image.used()
+		return Utilities.NewCommandError(Utilities.ErrNoSpace, "No hay espacio para migrar la partición '%s' (caben %d inodos y %d bloques; ocupados: %d inodos y %d bloques)", id, n, 3*n, usedInodes, usedBlocks)
+	}
+
+	// Lo que se leyó en los campos del journaling no es del superblock. Cada
+	// registro guarda su número de secuencia (en la versión anterior, su
+	// posición más uno): la siguiente entrada va después del más reciente.
+	if legacySuperblock {
+		sb.S_journal_count, sb.S_journal_head, sb.S_journal_tail, sb.S_journal_seq = 0, 0, 0, 0
+		for slot, record := range records {
+			if record.Count > sb.S_journal_seq {
+				sb.S_journal_seq = record.Count
+				sb.S_journal_tail = (int32(slot) + 1) % journalSize
+			}
+		}
+		if journalSize > 0 && sb.S_journal_seq >= journalSize {
+			sb.S_journal_head = sb.S_journal_tail
+		}
+	}
+
+	if legacyBlocks {
+		err = writeImage(file, &sb, partitionStart, journalSize, converted)
+	} else {
+		err = relayout(file, &sb, partitionStart, n, journalSize, image)
+	}
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "Error migrando el sistema de archivos: %v", err)
+	}
+	if len(records) > 0 {
+		if err := Utilities.WriteObject(file, records, int64(partitionStart+int32(binary.Size(Structs.Superblock{})))); err != nil {
+			return Utilities.NewCommandError(Utilities.ErrIO, "Error migrando el journaling: %v", err)
+		}
+	}
+
+	fmt.Fprintf(out, "Partición %s migrada: %d inodos, %d bloques de %d bytes", id, sb.S_inodes_count, sb.S_blocks_count, sb.S_block_size)
+	if journalSize > 0 {
+		fmt.Fprintf(out, ", journaling de %d registros", journalSize)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// convertBlocks reconstruye en memoria una partición con bloques de 64 bytes
+// con n inodos y 3n bloques de fileBlockSize bytes. Los inodos se reubican
+// como en relayout; cada carpeta y cada bloque de nombre se copia al inicio
+// de un bloque nuevo, y el contenido de cada archivo se reparte de nuevo con
+// bloques de apuntadores de pointersPerBlock entradas. Falla si los inodos o
+// los bloques no caben.
+func convertBlocks(image *fsImage, n int32) (*fsImage, error) {
+	inodeMap, err := remapIndexes(image.inodeBitmap, n)
+	if err != nil {
+		return nil, err
+	}
+	converted := &fsImage{
+		inodeBitmap: make([]byte, n),
+		blockBitmap: make([]byte, 3*n),
+		inodes:      make([]Structs.Inode, n),
+		blocks:      make([]byte, 3*n*int32(fileBlockSize)),
+	}
+	for i := range converted.inodes {
+		for j := range converted.inodes[i].I_block {
+			converted.inodes[i].I_block[j] = -1
+		}
+	}
+
+	// Los bloques nuevos se reservan en orden
+	next := int32(0)
+	allocate := func(data []byte) (int32, error) {
+		if next == 3*n {
+			return -1, fmt.Errorf("no caben todos los bloques en %d posiciones", 3*n)
+		}
+		copy(converted.blocks[next*int32(fileBlockSize):], data)
+		converted.blockBitmap[next] = 1
+		next++
+		return next - 1, nil
+	}
+
+	for i, target := range inodeMap {
+		if target == -1 {
+			continue
+		}
+		inode := image.inodes[i]
+		if string(inode.I_type[:1]) == "0" {
+			for j, blockIndex := range inode.I_block {
+				inode.I_block[j] = -1
+				raw := image.legacyBlock(blockIndex)
+				if raw == nil {
+					continue
+				}
+				var folderBlock Structs.Folderblock
+				if err := binary.Read(bytes.NewReader(raw), binary.LittleEndian, &folderBlock); err != nil {
+					return nil, err
+				}
+				for k := range folderBlock.B_content {
+					entry := &folderBlock.B_content[k]
+					if entry.B_inodo >= 0 && entry.B_inodo < int32(len(inodeMap)) {
+						entry.B_inodo = inodeMap[entry.B_inodo]
+					}
+					// Un nombre largo apunta a su bloque de nombre
+					if entry.B_inodo != -1 && isLongName(entry) {
+						nameIndex := int32(-1)
+						if name := image.legacyBlock(nameBlockOf(entry)); name != nil {
+							if nameIndex, err = allocate(name); err != nil {
+								return nil, err
+							}
+						}
+						binary.LittleEndian.PutUint32(entry.B_name[1:longNameHint], uint32(nameIndex))
+					}
+				}
+				if inode.I_block[j], err = allocate(encodeBlock(folderBlock)); err != nil {
+					return nil, err
+				}
+			}
+		} else if inode.I_block, err = layoutFile(image.legacyContent(&inode), allocate); err != nil {
+			return nil, err
+		}
+		converted.inodes[target] = inode
+		converted.inodeBitmap[target] = 1
+	}
+	return converted, nil
+}
+
+// legacyBlock retorna el bloque index de una imagen con bloques de 64 bytes,
+// o nil si el índice está fuera del área de bloques
+func (image *fsImage) legacyBlock(index int32) []byte {
+	if index < 0 || index >= int32(len(image.blockBitmap)) {
+		return nil
+	}
+	return image.blocks[index*legacyBlockSize : (index+1)*legacyBlockSize]
+}
+
+// legacyContent lee el contenido de un archivo de una imagen con bloques de
+// 64 bytes siguiendo sus apuntadores de 16 entradas. Si faltan bloques, el
+// contenido se completa con ceros hasta I_size.
+func (image *fsImage) legacyContent(inode *Structs.Inode) []byte {
+	size := int(inode.I_size)
+	if size < 0 {
+		size = 0
+	}
+	content := make([]byte, 0, size)
+	var read func(blockIndex int32, level int)
+	read = func(blockIndex int32, level int) {
+		raw := image.legacyBlock(blockIndex)
+		if raw == nil || len(content) >= size {
+			return
+		}
+		if level == 0 {
+			content = append(content, raw...)
+			return
+		}
+		for k := 0; k < legacyPointersPerBlock; k++ {
+			read(int32(binary.LittleEndian.Uint32(raw[4*k:])), level-1)
+		}
+	}
+	for j, blockIndex := range inode.I_block {
+		level := 0
+		if j >= 12 {
+			level = j - 11
+		}
+		read(blockIndex, level)
+	}
+
+	if len(content) > size {
+		return content[:size]
+	}
+	return append(content, make([]byte, size-len(content))...)
+}
+
+// layoutFile guarda content en bloques nuevos reservados con allocate y
+// retorna los apuntadores del inodo: 12 directos y luego los indirectos
+// simple, doble y triple
+func layoutFile(content []byte, allocate func([]byte) (int32, error)) ([15]int32, error) {
+	var pointers [15]int32
+	for j := range pointers {
+		pointers[j] = -1
+	}
+	var data []int32
+	for offset := 0; offset < len(content); offset += fileBlockSize {
+		end := offset + fileBlockSize
+		if end > len(content) {
+			end = len(content)
+		}
+		blockIndex, err := allocate(content[offset:end])
+		if err != nil {
+			return pointers, err
+		}
+		data = append(data, blockIndex)
+	}
+
+	for j := 0; j < 12 && len(data) > 0; j++ {
+		pointers[j], data = data[0], data[1:]
+	}
+	for level := 1; level <= 3 && len(data) > 0; level++ {
+		var err error
+		if pointers[11+level], data, err = pointerTree(level, data, allocate); err != nil {
+			return pointers, err
+		}
+	}
+	return pointers, nil
+}
+
+// pointerTree arma un bloque de apuntadores de nivel level (1 = indirecto
+// simple) con los primeros bloques de data; retorna su índice y los bloques
+// que no cupieron
+func pointerTree(level int, data []int32, allocate func([]byte) (int32, error)) (int32, []int32, error) {
+	var pointerBlock Structs.Pointerblock
+	for k := range pointerBlock.B_pointers {
+		pointerBlock.B_pointers[k] = -1
+	}
+	for k := range pointerBlock.B_pointers {
+		if len(data) == 0 {
+			break
+		}
+		if level == 1 {
+			pointerBlock.B_pointers[k], data = data[0], data[1:]
+			continue
+		}
+		var err error
+		if pointerBlock.B_pointers[k], data, err = pointerTree(level-1, data, allocate); err != nil {
+			return -1, data, err
+		}
+	}
+	blockIndex, err := allocate(encodeBlock(pointerBlock))
+	return blockIndex, data, err
+}
+
+// UpgradeMountedPartitions migra las particiones montadas que se restauraron
+// del estado anterior. Las que no se pueden migrar se desmontan para que
+// ningún comando escriba sobre ellas con la distribución equivocada.
+func UpgradeMountedPartitions(out io.Writer) {
+	for _, id := range DiskManagement.GetMountedIDs() {
+		if err := UpgradeLayout(out, id); err != nil {
+			fmt.Fprintf(out, "Advertencia: %v; la partición %s se desmonta\n", err, id)
+			DiskManagement.Unmount(out, id)
+		}
+	}
+}
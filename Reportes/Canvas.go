@@ -0,0 +1,550 @@
+package Reportes
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// SUPERFICIES DE DIBUJO: SVG, PDF Y RASTER (PNG/JPG)
+// ============================================================================
+
+// point es una coordenada en puntos (1/72 de pulgada), con y hacia abajo
+type point struct {
+	x, y float64
+}
+
+// textStyle describe cómo se dibuja un texto
+type textStyle struct {
+	size  float64
+	bold  bool
+	mono  bool
+	color string
+}
+
+// canvas es la superficie donde se dibuja un reporte. Las coordenadas están
+// en puntos con el origen arriba a la izquierda; y en text es la línea base.
+type canvas interface {
+	rect(x, y, w, h float64, fill string, stroke string, strokeWidth float64)
+	polygon(points []point, fill string, stroke string)
+	bezier(p0, p1, p2, p3 point, stroke string, dashed bool)
+	text(x, y float64, s string, style textStyle)
+	encode(w io.Writer) error
+}
+
+// newCanvas crea la superficie para el formato de salida indicado
+func newCanvas(format string, width float64, height float64) (canvas, error) {
+	switch format {
+	case "svg":
+		return newSVGCanvas(width, height), nil
+	case "pdf":
+		return newPDFCanvas(width, height), nil
+	case "png", "jpg":
+		return newRasterCanvas(width, height, format), nil
+	}
+	return nil, fmt.Errorf("formato de imagen no soportado: %s", format)
+}
+
+// ============================================================================
+// MEDIDAS DE TEXTO
+// ============================================================================
+
+// helveticaWidths son los anchos de Helvetica (en milésimas del tamaño de la
+// fuente) de los caracteres ASCII imprimibles, desde el espacio
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// foldRune reemplaza las letras acentuadas por su letra base y los demás
+// caracteres fuera de ASCII por '?'. Se usa para medir y para la fuente de
+// mapa de bits.
+func foldRune(r rune) rune {
+	if r >= 32 && r < 127 {
+		return r
+	}
+	if folded, ok := accentFolds[r]; ok {
+		return folded
+	}
+	return '?'
+}
+
+var accentFolds = map[rune]rune{
+	'á': 'a', 'é': 'e', 'í': 'i', 'ó': 'o', 'ú': 'u', 'ü': 'u', 'ñ': 'n',
+	'Á': 'A', 'É': 'E', 'Í': 'I', 'Ó': 'O', 'Ú': 'U', 'Ü': 'U', 'Ñ': 'N',
+	'à': 'a', 'è': 'e', 'ì': 'i', 'ò': 'o', 'ù': 'u', 'ç': 'c', 'Ç': 'C',
+	'¿': '?', '¡': '!', '°': 'o', '²': '2', '³': '3', '\t': ' ',
+}
+
+// runeWidth devuelve el ancho de un carácter en puntos
+func runeWidth(r rune, style textStyle) float64 {
+	if style.mono {
+		return 0.6 * style.size
+	}
+	width := float64(helveticaWidths[foldRune(r)-32]) / 1000 * style.size
+	if style.bold {
+		width *= 1.06
+	}
+	return width
+}
+
+// textWidth devuelve el ancho de un texto en puntos
+func textWidth(s string, style textStyle) float64 {
+	width := 0.0
+	for _, r := range s {
+		width += runeWidth(r, style)
+	}
+	return width
+}
+
+// ============================================================================
+// COLORES
+// ============================================================================
+
+// namedColors son los colores X11 que usan los reportes
+var namedColors = map[string]string{
+	"black":       "#000000",
+	"white":       "#FFFFFF",
+	"red":         "#FF0000",
+	"green":       "#00FF00",
+	"blue":        "#0000FF",
+	"yellow":      "#FFFF00",
+	"orange":      "#FFA500",
+	"gray":        "#BEBEBE",
+	"grey":        "#BEBEBE",
+	"lightblue":   "#ADD8E6",
+	"lightgreen":  "#90EE90",
+	"lightyellow": "#FFFFE0",
+	"lightcoral":  "#F08080",
+	"lightgray":   "#D3D3D3",
+	"lightgrey":   "#D3D3D3",
+}
+
+// parseColor convierte un color de Graphviz ("#RRGGBB", "#RGB" o un nombre
+// X11) a RGBA. Retorna false si el color es vacío o transparente.
+func parseColor(value string) (color.RGBA, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "none" || value == "transparent" {
+		return color.RGBA{}, false
+	}
+	if named, ok := namedColors[value]; ok {
+		value = strings.ToLower(named)
+	}
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) >= 6 {
+			if n, err := strconv.ParseUint(hex[:6], 16, 32); err == nil {
+				return color.RGBA{uint8(n >> 16), uint8(n >> 8), uint8(n), 255}, true
+			}
+		}
+	}
+	return color.RGBA{0, 0, 0, 255}, true
+}
+
+// hexColor devuelve el color en formato "#rrggbb"
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// ============================================================================
+// SVG
+// ============================================================================
+
+type svgCanvas struct {
+	width, height float64
+	body          strings.Builder
+}
+
+func newSVGCanvas(width float64, height float64) *svgCanvas {
+	return &svgCanvas{width: width, height: height}
+}
+
+func svgPaint(value string) string {
+	if c, ok := parseColor(value); ok {
+		return hexColor(c)
+	}
+	return "none"
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, fill string, stroke string, strokeWidth float64) {
+	fmt.Fprintf(&c.body, "<rect x=\"%.2f\" y=\"%.2f\" width=\"%.2f\" height=\"%.2f\" fill=\"%s\" stroke=\"%s\" stroke-width=\"%.2f\"/>\n",
+		x, y, w, h, svgPaint(fill), svgPaint(stroke), strokeWidth)
+}
+
+func (c *svgCanvas) polygon(points []point, fill string, stroke string) {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%.2f,%.2f", p.x, p.y)
+	}
+	fmt.Fprintf(&c.body, "<polygon points=\"%s\" fill=\"%s\" stroke=\"%s\"/>\n", strings.Join(coords, " "), svgPaint(fill), svgPaint(stroke))
+}
+
+func (c *svgCanvas) bezier(p0, p1, p2, p3 point, stroke string, dashed bool) {
+	dash := ""
+	if dashed {
+		dash = " stroke-dasharray=\"5,2\""
+	}
+	fmt.Fprintf(&c.body, "<path d=\"M%.2f,%.2f C%.2f,%.2f %.2f,%.2f %.2f,%.2f\" fill=\"none\" stroke=\"%s\"%s/>\n",
+		p0.x, p0.y, p1.x, p1.y, p2.x, p2.y, p3.x, p3.y, svgPaint(stroke), dash)
+}
+
+func (c *svgCanvas) text(x, y float64, s string, style textStyle) {
+	family := "Helvetica,Arial,sans-serif"
+	if style.mono {
+		family = "Courier New,Courier,monospace"
+	}
+	weight := ""
+	if style.bold {
+		weight = " font-weight=\"bold\""
+	}
+	var escaped bytes.Buffer
+	for _, r := range s {
+		switch r {
+		case '&':
+			escaped.WriteString("&amp;")
+		case '<':
+			escaped.WriteString("&lt;")
+		case '>':
+			escaped.WriteString("&gt;")
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	fmt.Fprintf(&c.body, "<text x=\"%.2f\" y=\"%.2f\" font-family=\"%s\" font-size=\"%.2f\"%s fill=\"%s\" textLength=\"%.2f\" lengthAdjust=\"spacingAndGlyphs\" xml:space=\"preserve\">%s</text>\n",
+		x, y, family, style.size, weight, svgPaint(style.color), textWidth(s, style), escaped.String())
+}
+
+func (c *svgCanvas) encode(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0fpt\" height=\"%.0fpt\" viewBox=\"0 0 %.2f %.2f\">\n<rect width=\"100%%\" height=\"100%%\" fill=\"#ffffff\"/>\n%s</svg>\n",
+		c.width, c.height, c.width, c.height, c.body.String())
+	return err
+}
+
+// ============================================================================
+// PDF
+// ============================================================================
+
+// pdfCanvas escribe una página PDF con las fuentes estándar Helvetica y
+// Courier, que los lectores traen incorporadas
+type pdfCanvas struct {
+	width, height float64
+	content       bytes.Buffer
+}
+
+func newPDFCanvas(width float64, height float64) *pdfCanvas {
+	return &pdfCanvas{width: width, height: height}
+}
+
+// pdfColor escribe el operador de color de relleno (rg) o de trazo (RG)
+func (c *pdfCanvas) pdfColor(value string, operator string) bool {
+	rgba, ok := parseColor(value)
+	if !ok {
+		return false
+	}
+	fmt.Fprintf(&c.content, "%.3f %.3f %.3f %s\n", float64(rgba.R)/255, float64(rgba.G)/255, float64(rgba.B)/255, operator)
+	return true
+}
+
+func (c *pdfCanvas) rect(x, y, w, h float64, fill string, stroke string, strokeWidth float64) {
+	hasFill := c.pdfColor(fill, "rg")
+	hasStroke := strokeWidth > 0 && c.pdfColor(stroke, "RG")
+	if !hasFill && !hasStroke {
+		return
+	}
+	fmt.Fprintf(&c.content, "%.2f w %.2f %.2f %.2f %.2f re ", strokeWidth, x, c.height-y-h, w, h)
+	switch {
+	case hasFill && hasStroke:
+		c.content.WriteString("B\n")
+	case hasFill:
+		c.content.WriteString("f\n")
+	default:
+		c.content.WriteString("S\n")
+	}
+}
+
+func (c *pdfCanvas) polygon(points []point, fill string, stroke string) {
+	hasFill := c.pdfColor(fill, "rg")
+	hasStroke := c.pdfColor(stroke, "RG")
+	if len(points) == 0 || (!hasFill && !hasStroke) {
+		return
+	}
+	c.content.WriteString("1 w ")
+	for i, p := range points {
+		operator := "l"
+		if i == 0 {
+			operator = "m"
+		}
+		fmt.Fprintf(&c.content, "%.2f %.2f %s ", p.x, c.height-p.y, operator)
+	}
+	switch {
+	case hasFill && hasStroke:
+		c.content.WriteString("b\n")
+	case hasFill:
+		c.content.WriteString("h f\n")
+	default:
+		c.content.WriteString("s\n")
+	}
+}
+
+func (c *pdfCanvas) bezier(p0, p1, p2, p3 point, stroke string, dashed bool) {
+	if !c.pdfColor(stroke, "RG") {
+		return
+	}
+	if dashed {
+		c.content.WriteString("[5 2] 0 d ")
+	}
+	fmt.Fprintf(&c.content, "1 w %.2f %.2f m %.2f %.2f %.2f %.2f %.2f %.2f c S\n",
+		p0.x, c.height-p0.y, p1.x, c.height-p1.y, p2.x, c.height-p2.y, p3.x, c.height-p3.y)
+	if dashed {
+		c.content.WriteString("[] 0 d\n")
+	}
+}
+
+func (c *pdfCanvas) text(x, y float64, s string, style textStyle) {
+	font := "F1"
+	switch {
+	case style.mono:
+		font = "F3"
+	case style.bold:
+		font = "F2"
+	}
+	if !c.pdfColor(style.color, "rg") {
+		c.pdfColor("black", "rg")
+	}
+	fmt.Fprintf(&c.content, "BT /%s %.2f Tf %.2f %.2f Td (", font, style.size, x, c.height-y)
+	// WinAnsiEncoding coincide con Latin-1 en los acentos del español
+	for _, r := range s {
+		if r >= 0xA0 && r <= 0xFF {
+			c.content.WriteByte(byte(r))
+			continue
+		}
+		r = foldRune(r)
+		if r == '(' || r == ')' || r == '\\' {
+			c.content.WriteByte('\\')
+		}
+		c.content.WriteByte(byte(r))
+	}
+	c.content.WriteString(") Tj ET\n")
+}
+
+func (c *pdfCanvas) encode(w io.Writer) error {
+	var stream bytes.Buffer
+	compressor := zlib.NewWriter(&stream)
+	compressor.Write(c.content.Bytes())
+	compressor.Close()
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 4 0 R /F2 5 0 R /F3 6 0 R >> >> /Contents 7 0 R >>", c.width, c.height),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.String()),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// ============================================================================
+// RASTER (PNG Y JPG)
+// ============================================================================
+
+// rasterScale son los píxeles por punto de las imágenes PNG y JPG
+const rasterScale = 2.0
+
+type rasterCanvas struct {
+	img    *image.RGBA
+	format string
+}
+
+func newRasterCanvas(width float64, height float64, format string) *rasterCanvas {
+	img := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(width*rasterScale)), int(math.Ceil(height*rasterScale))))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	return &rasterCanvas{img: img, format: format}
+}
+
+// fillRect pinta un rectángulo en píxeles
+func (c *rasterCanvas) fillRect(x0, y0, x1, y1 int, col color.RGBA) {
+	bounds := c.img.Bounds()
+	for y := maxInt(y0, bounds.Min.Y); y < minInt(y1, bounds.Max.Y); y++ {
+		for x := maxInt(x0, bounds.Min.X); x < minInt(x1, bounds.Max.X); x++ {
+			c.img.SetRGBA(x, y, col)
+		}
+	}
+}
+
+func (c *rasterCanvas) rect(x, y, w, h float64, fill string, stroke string, strokeWidth float64) {
+	x0, y0 := int(math.Round(x*rasterScale)), int(math.Round(y*rasterScale))
+	x1, y1 := int(math.Round((x+w)*rasterScale)), int(math.Round((y+h)*rasterScale))
+	if col, ok := parseColor(fill); ok {
+		c.fillRect(x0, y0, x1, y1, col)
+	}
+	col, ok := parseColor(stroke)
+	if !ok || strokeWidth <= 0 {
+		return
+	}
+	t := maxInt(1, int(math.Round(strokeWidth*rasterScale)))
+	c.fillRect(x0, y0, x1, y0+t, col)
+	c.fillRect(x0, y1-t, x1, y1, col)
+	c.fillRect(x0, y0, x0+t, y1, col)
+	c.fillRect(x1-t, y0, x1, y1, col)
+}
+
+// line dibuja un segmento de un punto de grosor; con dashed omite tramos
+func (c *rasterCanvas) line(a point, b point, col color.RGBA, dashed bool) {
+	length := math.Hypot(b.x-a.x, b.y-a.y) * rasterScale
+	steps := int(math.Ceil(length))
+	t := maxInt(1, int(rasterScale))
+	for i := 0; i <= steps; i++ {
+		if dashed && (i/int(5*rasterScale))%2 == 1 {
+			continue
+		}
+		f := 0.0
+		if steps > 0 {
+			f = float64(i) / float64(steps)
+		}
+		x := int((a.x + (b.x-a.x)*f) * rasterScale)
+		y := int((a.y + (b.y-a.y)*f) * rasterScale)
+		c.fillRect(x, y, x+t, y+t, col)
+	}
+}
+
+func (c *rasterCanvas) polygon(points []point, fill string, stroke string) {
+	if col, ok := parseColor(fill); ok && len(points) > 2 {
+		// Relleno por líneas de barrido (regla par-impar)
+		minY, maxY := points[0].y, points[0].y
+		for _, p := range points {
+			minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+		}
+		for py := int(minY * rasterScale); py <= int(maxY*rasterScale); py++ {
+			y := (float64(py) + 0.5) / rasterScale
+			var xs []float64
+			for i := range points {
+				a, b := points[i], points[(i+1)%len(points)]
+				if (a.y <= y && b.y > y) || (b.y <= y && a.y > y) {
+					xs = append(xs, a.x+(y-a.y)*(b.x-a.x)/(b.y-a.y))
+				}
+			}
+			for i := 0; i+1 < len(xs); i += 2 {
+				left, right := math.Min(xs[i], xs[i+1]), math.Max(xs[i], xs[i+1])
+				c.fillRect(int(left*rasterScale), py, int(math.Ceil(right*rasterScale)), py+1, col)
+			}
+		}
+	}
+	if col, ok := parseColor(stroke); ok {
+		for i := range points {
+			c.line(points[i], points[(i+1)%len(points)], col, false)
+		}
+	}
+}
+
+func (c *rasterCanvas) bezier(p0, p1, p2, p3 point, stroke string, dashed bool) {
+	col, ok := parseColor(stroke)
+	if !ok {
+		return
+	}
+	const segments = 24
+	previous := p0
+	for i := 1; i <= segments; i++ {
+		next := bezierPoint(p0, p1, p2, p3, float64(i)/segments)
+		c.line(previous, next, col, dashed)
+		previous = next
+	}
+}
+
+func (c *rasterCanvas) text(x, y float64, s string, style textStyle) {
+	col, ok := parseColor(style.color)
+	if !ok {
+		col = color.RGBA{0, 0, 0, 255}
+	}
+	// La fuente de mapa de bits tiene 5x7 celdas; las mayúsculas ocupan las
+	// 7 filas y se escalan a la altura de las mayúsculas de Helvetica
+	pixel := style.size * 0.72 / 7 * rasterScale
+	top := y*rasterScale - 7*pixel
+	cursor := x * rasterScale
+	for _, r := range s {
+		advance := runeWidth(r, style) * rasterScale
+		glyph := bitmapFont[foldRune(r)-32]
+		left := cursor + (advance-5*pixel)/2
+		for column := 0; column < 5; column++ {
+			bits := glyph[column]
+			for row := 0; row < 7; row++ {
+				if bits&(1<<uint(row)) == 0 {
+					continue
+				}
+				x0 := int(left + float64(column)*pixel)
+				y0 := int(top + float64(row)*pixel)
+				x1 := maxInt(x0+1, int(left+float64(column+1)*pixel))
+				y1 := maxInt(y0+1, int(top+float64(row+1)*pixel))
+				if style.bold {
+					x1++
+				}
+				c.fillRect(x0, y0, x1, y1, col)
+			}
+		}
+		cursor += advance
+	}
+}
+
+func (c *rasterCanvas) encode(w io.Writer) error {
+	if c.format == "png" {
+		return png.Encode(w, c.img)
+	}
+	return jpeg.Encode(w, c.img, &jpeg.Options{Quality: 90})
+}
+
+// bezierPoint evalúa una curva de Bézier cúbica en t
+func bezierPoint(p0, p1, p2, p3 point, t float64) point {
+	u := 1 - t
+	return point{
+		x: u*u*u*p0.x + 3*u*u*t*p1.x + 3*u*t*t*p2.x + t*t*t*p3.x,
+		y: u*u*u*p0.y + 3*u*u*t*p1.y + 3*u*t*t*p2.y + t*t*t*p3.y,
+	}
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,785 @@
+package Reportes
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"proyecto1/DiskManagement"
+	"proyecto1/FileSystem"
+	"proyecto1/Structs"
+	"proyecto1/Utilities"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// SALIDA ESTRUCTURADA DE LOS REPORTES (JSON Y CSV)
+// ============================================================================
+
+// Formatos de salida del comando rep
+const (
+	FormatImage = "image"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// ParseReportFormat valida el formato de salida de un reporte; vacío es imagen
+func ParseReportFormat(value string) (string, error) {
+	switch format := strings.ToLower(strings.TrimSpace(value)); format {
+	case "", FormatImage:
+		return FormatImage, nil
+	case FormatJSON, FormatCSV:
+		return format, nil
+	}
+	return "", Utilities.NewCommandError(Utilities.ErrInvalidArgument, "formato '%s' no válido (use image, json o csv)", value)
+}
+
+// ReportDocument es el documento que se entrega en JSON: el tipo de reporte,
+// la partición y los datos propios del reporte
+type ReportDocument struct {
+	Report      string      `json:"report"`
+	PartitionID string      `json:"partition_id"`
+	Path        string      `json:"path,omitempty"`
+	Data        reportTable `json:"data"`
+}
+
+// reportTable son los datos de un reporte; csvTable los aplana en una tabla
+// con encabezado para la salida CSV
+type reportTable interface {
+	csvTable() ([]string, [][]string)
+}
+
+// CollectReportData calcula los datos de un reporte con las mismas funciones
+// que usan los generadores DOT. targetPath es la ruta de los reportes file y ls.
+// Asume que quien la llama tiene el disco bloqueado al menos en lectura.
+func CollectReportData(out io.Writer, reportType string, partitionID string, targetPath string) (*ReportDocument, error) {
+	reportType = strings.ToLower(reportType)
+	file, mbr, partitionStart, err := openReportPartition(partitionID)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	document := &ReportDocument{Report: reportType, PartitionID: partitionID}
+	if reportType == "mbr" || reportType == "disk" {
+		if reportType == "mbr" {
+			document.Data = collectMBRData(file, mbr)
+		} else {
+			document.Data = DiskData{Segments: calculateDiskStructure(file, mbr, int64(mbr.MbrSize))}
+		}
+		return document, nil
+	}
+
+	// El resto de reportes leen el sistema de archivos de la partición
+	var superblock Structs.Superblock
+	if err := Utilities.ReadObject(file, &superblock, partitionStart); err != nil {
+		return nil, Utilities.NewCommandError(Utilities.ErrIO, "error leyendo superblock: %v", err)
+	}
+	if superblock.S_magic != 0xEF53 {
+		return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "la partición '%s' no tiene sistema de archivos", partitionID)
+	}
+
+	switch reportType {
+	case "inode":
+		document.Data = collectInodeData(file, &superblock)
+	case "block":
+		document.Data = collectBlockData(file, &superblock)
+	case "bm_inode":
+		document.Data = collectBitmapData(file, "inode", superblock.S_bm_inode_start, superblock.S_inodes_count, superblock.S_free_inodes_count)
+	case "bm_block":
+		document.Data = collectBitmapData(file, "block", superblock.S_bm_block_start, superblock.S_blocks_count, superblock.S_free_blocks_count)
+	case "tree":
+		document.Data = collectTreeData(file, &superblock)
+	case "sb":
+		document.Data = collectSuperblockData(&superblock)
+	case "file":
+		if targetPath == "" {
+			return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "el reporte file requiere la ruta del archivo")
+		}
+		content, name, err := findFileInFilesystem(out, file, &superblock, targetPath)
+		if err != nil {
+			return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "error buscando archivo '%s': %v", targetPath, err)
+		}
+		document.Path = targetPath
+		document.Data = FileData{Name: name, Size: len(content), Content: string(content)}
+	case "ls":
+		if targetPath == "" {
+			targetPath = "/"
+		}
+		entries, err := listDirectoryContents(out, file, &superblock, targetPath)
+		if err != nil {
+			return nil, Utilities.NewCommandError(Utilities.ErrNotFound, "error listando '%s': %v", targetPath, err)
+		}
+		document.Path = targetPath
+		document.Data = ListData{Entries: entries}
+	case "journaling":
+		if superblock.S_filesystem_type != 3 {
+			return nil, Utilities.NewCommandError(Utilities.ErrUnsupported, "el sistema de archivos no es EXT3. Solo EXT3 soporta journaling")
+		}
+		document.Data = JournalingData{Entries: readJournalingEntries(file, &superblock, partitionStart)}
+	default:
+		return nil, Utilities.NewCommandError(Utilities.ErrInvalidArgument, "tipo de reporte '%s' no válido", reportType)
+	}
+	return document, nil
+}
+
+// EncodeReport escribe el documento en JSON (con sangría) o en CSV
+func EncodeReport(document *ReportDocument, format string, w io.Writer) error {
+	if format == FormatCSV {
+		header, rows := document.Data.csvTable()
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// WriteReportData genera un reporte como JSON o CSV en la ruta indicada
+func WriteReportData(out io.Writer, reportType string, userOutputPath string, partitionID string, targetPath string, format string) error {
+	fmt.Fprintf(out, "=== GENERANDO DATOS DEL REPORTE %s (%s) ===\n", strings.ToUpper(reportType), strings.ToUpper(format))
+	document, err := CollectReportData(out, reportType, partitionID, targetPath)
+	if err != nil {
+		return err
+	}
+
+	outputPath := strings.Trim(userOutputPath, "\"")
+	if err := createOutputDirectory(out, outputPath); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "error creando directorio de salida: %v", err)
+	}
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "error creando archivo de salida: %v", err)
+	}
+	defer output.Close()
+	if err := EncodeReport(document, format, output); err != nil {
+		return Utilities.NewCommandError(Utilities.ErrIO, "error escribiendo el reporte: %v", err)
+	}
+
+	fmt.Fprintf(out, "✓ Reporte %s generado exitosamente\n", strings.ToUpper(reportType))
+	fmt.Fprintf(out, "  - Archivo %s: %s\n", strings.ToUpper(format), outputPath)
+	return nil
+}
+
+// openReportPartition abre el disco de una partición montada y devuelve su
+// MBR y el inicio de la partición (en las lógicas, después de su EBR)
+func openReportPartition(partitionID string) (*os.File, *Structs.MBR, int64, error) {
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
+	if !exists {
+		return nil, nil, 0, Utilities.NewCommandError(Utilities.ErrNotFound, "la partición con ID '%s' no está montada", partitionID)
+	}
+
+	file, err := Utilities.OpenFile(mountedPartition.Path)
+	if err != nil {
+		return nil, nil, 0, Utilities.NewCommandError(Utilities.ErrIO, "error abriendo archivo del disco: %v", err)
+	}
+
+	var mbr Structs.MBR
+	if err := Utilities.ReadObject(file, &mbr, 0); err != nil {
+		file.Close()
+		return nil, nil, 0, Utilities.NewCommandError(Utilities.ErrIO, "error leyendo MBR: %v", err)
+	}
+
+	var start int64
+	if mountedPartition.IsLogical {
+		start = int64(mountedPartition.EBRPosition) + int64(binary.Size(Structs.EBR{}))
+	} else {
+		start = int64(mbr.Partitions[mountedPartition.PartitionIndex].Start)
+	}
+	return file, &mbr, start, nil
+}
+
+func itoa(value int32) string {
+	return strconv.Itoa(int(value))
+}
+
+// ----------------------------------------------------------------------------
+// mbr
+// ----------------------------------------------------------------------------
+
+// MBRData son los datos del reporte mbr
+type MBRData struct {
+	Size              int32           `json:"size"`
+	CreationDate      string          `json:"creation_date"`
+	Signature         int32           `json:"signature"`
+	Fit               string          `json:"fit"`
+	StructSize        int32           `json:"struct_size"`
+	Partitions        []PartitionData `json:"partitions"`
+	LogicalPartitions []PartitionData `json:"logical_partitions"`
+}
+
+// PartitionData es una partición del MBR o una lógica de la extendida
+type PartitionData struct {
+	Index  int    `json:"index"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Start  int32  `json:"start"`
+	Size   int32  `json:"size"`
+	Fit    string `json:"fit"`
+	ID     string `json:"id,omitempty"`
+	EBR    int32  `json:"ebr,omitempty"`
+	Next   int32  `json:"next,omitempty"`
+}
+
+func collectMBRData(file *os.File, mbr *Structs.MBR) MBRData {
+	data := MBRData{
+		Size:              mbr.MbrSize,
+		CreationDate:      cleanString(mbr.CreationDate[:]),
+		Signature:         mbr.Signature,
+		Fit:               cleanString(mbr.Fit[:]),
+		StructSize:        int32(binary.Size(*mbr)),
+		Partitions:        []PartitionData{},
+		LogicalPartitions: []PartitionData{},
+	}
+
+	for i := range mbr.Partitions {
+		partition := &mbr.Partitions[i]
+		if partition.Size <= 0 {
+			continue
+		}
+		partitionType := cleanString(partition.Type[:])
+		data.Partitions = append(data.Partitions, PartitionData{
+			Index:  i,
+			Name:   cleanString(partition.Name[:]),
+			Type:   getPartitionTypeText(partitionType),
+			Status: getStatusText(cleanString(partition.Status[:])),
+			Start:  partition.Start,
+			Size:   partition.Size,
+			Fit:    cleanString(partition.Fit[:]),
+			ID:     cleanString(partition.Id[:]),
+		})
+		if partitionType != "e" {
+			continue
+		}
+
+		// Recorrer la lista de EBRs de la extendida
+		position := partition.Start
+		for index := 0; position != -1; index++ {
+			var ebr Structs.EBR
+			if err := Utilities.ReadObject(file, &ebr, int64(position)); err != nil {
+				break
+			}
+			if ebr.Part_size > 0 {
+				data.LogicalPartitions = append(data.LogicalPartitions, PartitionData{
+					Index:  index,
+					Name:   cleanString(ebr.Part_name[:]),
+					Type:   "Lógica",
+					Status: getStatusText(cleanString(ebr.Part_status[:])),
+					Start:  ebr.Part_start,
+					Size:   ebr.Part_size,
+					Fit:    cleanString(ebr.Part_fit[:]),
+					EBR:    position,
+					Next:   ebr.Part_next,
+				})
+			}
+			position = ebr.Part_next
+		}
+	}
+	return data
+}
+
+// En CSV van las particiones primarias, extendidas y lógicas en una tabla
+func (data MBRData) csvTable() ([]string, [][]string) {
+	header := []string{"index", "name", "type", "status", "start", "size", "fit", "id", "ebr", "next"}
+	var rows [][]string
+	for _, partition := range append(append([]PartitionData{}, data.Partitions...), data.LogicalPartitions...) {
+		rows = append(rows, []string{
+			strconv.Itoa(partition.Index), partition.Name, partition.Type, partition.Status,
+			itoa(partition.Start), itoa(partition.Size), partition.Fit, partition.ID,
+			itoa(partition.EBR), itoa(partition.Next),
+		})
+	}
+	return header, rows
+}
+
+// ----------------------------------------------------------------------------
+// disk
+// ----------------------------------------------------------------------------
+
+// DiskData son los segmentos que calcula calculateDiskStructure
+type DiskData struct {
+	Segments []DiskSegment `json:"segments"`
+}
+
+// En CSV los segmentos de la extendida llevan el nombre de su contenedor
+func (data DiskData) csvTable() ([]string, [][]string) {
+	header := []string{"type", "name", "parent", "start", "size", "percentage"}
+	var rows [][]string
+	var add func(segments []DiskSegment, parent string)
+	add = func(segments []DiskSegment, parent string) {
+		for _, segment := range segments {
+			rows = append(rows, []string{
+				segment.Type, segment.Name, parent, itoa(segment.Start), itoa(segment.Size),
+				strconv.FormatFloat(segment.Percentage, 'f', 2, 64),
+			})
+			add(segment.Children, segment.Name)
+		}
+	}
+	add(data.Segments, "")
+	return header, rows
+}
+
+// ----------------------------------------------------------------------------
+// inode
+// ----------------------------------------------------------------------------
+
+// InodeData son los inodos en uso
+type InodeData struct {
+	Total  int32         `json:"total"`
+	Free   int32         `json:"free"`
+	First  int32         `json:"first_free"`
+	Inodes []InodeRecord `json:"inodes"`
+}
+
+// InodeRecord es un inodo en uso con sus apuntadores (I_block completo)
+type InodeRecord struct {
+	Inode         int32     `json:"inode"`
+	Type          string    `json:"type"` // "0" carpeta, "1" archivo
+	UID           int32     `json:"uid"`
+	GID           int32     `json:"gid"`
+	Size          int32     `json:"size"`
+	AccessTime    string    `json:"atime"`
+	CreationTime  string    `json:"ctime"`
+	ModifiedTime  string    `json:"mtime"`
+	Permissions   string    `json:"perm"`
+	Blocks        [15]int32 `json:"blocks"`
+	DataBlocks    int       `json:"data_blocks,omitempty"`
+	PointerBlocks int       `json:"pointer_blocks,omitempty"`
+}
+
+func collectInodeData(file *os.File, superblock *Structs.Superblock) InodeData {
+	data := InodeData{
+		Total:  superblock.S_inodes_count,
+		Free:   superblock.S_free_inodes_count,
+		First:  superblock.S_fist_ino,
+		Inodes: []InodeRecord{},
+	}
+	for i := int32(0); i < superblock.S_inodes_count; i++ {
+		var bitmapByte byte
+		if err := Utilities.ReadObject(file, &bitmapByte, int64(superblock.S_bm_inode_start+i)); err != nil || bitmapByte == 0 {
+			continue
+		}
+		var inode Structs.Inode
+		if err := Utilities.ReadObject(file, &inode, int64(superblock.S_inode_start+i*superblock.S_inode_size)); err != nil {
+			continue
+		}
+		record := InodeRecord{
+			Inode:        i,
+			Type:         cleanString(inode.I_type[:]),
+			UID:          inode.I_uid,
+			GID:          inode.I_gid,
+			Size:         inode.I_size,
+			AccessTime:   Utilities.FormatTimestamp(cleanString(inode.I_atime[:])),
+			CreationTime: Utilities.FormatTimestamp(cleanString(inode.I_ctime[:])),
+			ModifiedTime: Utilities.FormatTimestamp(cleanString(inode.I_mtime[:])),
+			Permissions:  cleanString(inode.I_perm[:]),
+			Blocks:       inode.I_block,
+		}
+		if record.Type == "1" {
+			dataBlocks, pointerBlocks := FileSystem.FileBlocks(file, superblock, &inode)
+			record.DataBlocks, record.PointerBlocks = len(dataBlocks), len(pointerBlocks)
+		}
+		data.Inodes = append(data.Inodes, record)
+	}
+	return data
+}
+
+// En CSV los apuntadores van separados por espacios en una sola columna
+func (data InodeData) csvTable() ([]string, [][]string) {
+	header := []string{"inode", "type", "uid", "gid", "size", "atime", "ctime", "mtime", "perm", "blocks", "data_blocks", "pointer_blocks"}
+	var rows [][]string
+	for _, record := range data.Inodes {
+		blocks := make([]string, len(record.Blocks))
+		for i, block := range record.Blocks {
+			blocks[i] = itoa(block)
+		}
+		rows = append(rows, []string{
+			itoa(record.Inode), record.Type, itoa(record.UID), itoa(record.GID), itoa(record.Size),
+			record.AccessTime, record.CreationTime, record.ModifiedTime, record.Permissions,
+			strings.Join(blocks, " "), strconv.Itoa(record.DataBlocks), strconv.Itoa(record.PointerBlocks),
+		})
+	}
+	return header, rows
+}
+
+// ----------------------------------------------------------------------------
+// block
+// ----------------------------------------------------------------------------
+
+// BlockData son los bloques en uso con la clasificación de classifyBlock
+type BlockData struct {
+	Total  int32         `json:"total"`
+	Free   int32         `json:"free"`
+	First  int32         `json:"first_free"`
+	Blocks []BlockRecord `json:"blocks"`
+}
+
+// BlockRecord es un bloque en uso: su tipo (Directorio, Archivo, Punteros,
+// Nombre, Datos...) y la descripción que muestra el reporte
+type BlockRecord struct {
+	Block int32  `json:"block"`
+	Type  string `json:"type"`
+	Info  string `json:"info"`
+}
+
+func collectBlockData(file *os.File, superblock *Structs.Superblock) BlockData {
+	data := BlockData{
+		Total:  superblock.S_blocks_count,
+		Free:   superblock.S_free_blocks_count,
+		First:  superblock.S_first_blo,
+		Blocks: []BlockRecord{},
+	}
+	pointerBlocks := collectPointerBlockLevels(file, superblock)
+	nameBlocks := FileSystem.NameBlocks(file, superblock)
+	for i := int32(0); i < superblock.S_blocks_count; i++ {
+		var bitmapByte byte
+		if err := Utilities.ReadObject(file, &bitmapByte, int64(superblock.S_bm_block_start+i)); err != nil || bitmapByte == 0 {
+			continue
+		}
+		blockType, blockInfo := classifyBlock(file, superblock, i, pointerBlocks, nameBlocks)
+		data.Blocks = append(data.Blocks, BlockRecord{Block: i, Type: blockType, Info: blockInfo})
+	}
+	return data
+}
+
+func (data BlockData) csvTable() ([]string, [][]string) {
+	var rows [][]string
+	for _, record := range data.Blocks {
+		rows = append(rows, []string{itoa(record.Block), record.Type, record.Info})
+	}
+	return []string{"block", "type", "info"}, rows
+}
+
+// ----------------------------------------------------------------------------
+// bm_inode y bm_block
+// ----------------------------------------------------------------------------
+
+// BitmapData es la ocupación de un bitmap; Bitmap tiene un carácter '0' o
+// '1' por inodo o bloque
+type BitmapData struct {
+	Kind   string `json:"kind"`
+	Total  int32  `json:"total"`
+	Used   int32  `json:"used"`
+	Free   int32  `json:"free"`
+	Bitmap string `json:"bitmap"`
+}
+
+func collectBitmapData(file *os.File, kind string, start int32, count int32, free int32) BitmapData {
+	bitmap := make([]byte, count)
+	if err := Utilities.ReadObject(file, bitmap, int64(start)); err != nil {
+		bitmap = make([]byte, count)
+	}
+	var bits strings.Builder
+	for _, value := range bitmap {
+		if value != 0 {
+			bits.WriteByte('1')
+		} else {
+			bits.WriteByte('0')
+		}
+	}
+	return BitmapData{Kind: kind, Total: count, Used: count - free, Free: free, Bitmap: bits.String()}
+}
+
+// En CSV va una fila por inodo o bloque
+func (data BitmapData) csvTable() ([]string, [][]string) {
+	rows := make([][]string, len(data.Bitmap))
+	for i := range data.Bitmap {
+		rows[i] = []string{strconv.Itoa(i), data.Bitmap[i : i+1]}
+	}
+	return []string{"index", "used"}, rows
+}
+
+// ----------------------------------------------------------------------------
+// tree
+// ----------------------------------------------------------------------------
+
+// TreeData es el grafo del reporte tree: inodos y bloques alcanzables desde
+// la raíz y los enlaces entre ellos
+type TreeData struct {
+	Nodes []TreeNode `json:"nodes"`
+	Edges []TreeEdge `json:"edges"`
+}
+
+// TreeNode es un inodo o un bloque; ID es el mismo nombre del nodo en el DOT
+// (Inodo<n> o Bloque<n>)
+type TreeNode struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"` // inode, folder_block, file_block, pointer_block
+	Number  int32  `json:"number"`
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+type TreeEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func collectTreeData(file *os.File, superblock *Structs.Superblock) TreeData {
+	data := TreeData{Nodes: []TreeNode{}, Edges: []TreeEdge{}}
+	visitedInodes := make(map[int32]bool)
+	visitedBlocks := make(map[int32]bool)
+	used := func(bitmapStart int32, index int32) bool {
+		var bitmapByte byte
+		return Utilities.ReadObject(file, &bitmapByte, int64(bitmapStart+index)) == nil && bitmapByte != 0
+	}
+	blockPos := func(index int32) int64 {
+		return int64(superblock.S_block_start + index*superblock.S_block_size)
+	}
+	link := func(from string, to int32) {
+		data.Edges = append(data.Edges, TreeEdge{From: from, To: fmt.Sprintf("Bloque%d", to)})
+	}
+
+	var visitInode func(inodeNum int32, name string)
+	var visitFileBlock func(blockNum int32)
+	var visitPointerBlock func(blockNum int32, level int)
+
+	visitFileBlock = func(blockNum int32) {
+		if visitedBlocks[blockNum] || !used(superblock.S_bm_block_start, blockNum) {
+			return
+		}
+		visitedBlocks[blockNum] = true
+		var fileBlock Structs.Fileblock
+		if err := Utilities.ReadObject(file, &fileBlock, blockPos(blockNum)); err != nil {
+			return
+		}
+		data.Nodes = append(data.Nodes, TreeNode{
+			ID: fmt.Sprintf("Bloque%d", blockNum), Kind: "file_block", Number: blockNum,
+			Content: strings.TrimRight(string(fileBlock.B_content[:]), "\x00"),
+		})
+	}
+
+	visitPointerBlock = func(blockNum int32, level int) {
+		if visitedBlocks[blockNum] {
+			return
+		}
+		visitedBlocks[blockNum] = true
+		var pointerBlock Structs.Pointerblock
+		if err := Utilities.ReadObject(file, &pointerBlock, blockPos(blockNum)); err != nil {
+			return
+		}
+		id := fmt.Sprintf("Bloque%d", blockNum)
+		data.Nodes = append(data.Nodes, TreeNode{ID: id, Kind: "pointer_block", Number: blockNum})
+		for _, pointer := range pointerBlock.B_pointers {
+			if pointer == -1 {
+				continue
+			}
+			link(id, pointer)
+			if level == 1 {
+				visitFileBlock(pointer)
+			} else {
+				visitPointerBlock(pointer, level-1)
+			}
+		}
+	}
+
+	visitFolderBlock := func(blockNum int32) {
+		if visitedBlocks[blockNum] || !used(superblock.S_bm_block_start, blockNum) {
+			return
+		}
+		visitedBlocks[blockNum] = true
+		var folderBlock Structs.Folderblock
+		if err := Utilities.ReadObject(file, &folderBlock, blockPos(blockNum)); err != nil {
+			return
+		}
+		id := fmt.Sprintf("Bloque%d", blockNum)
+		data.Nodes = append(data.Nodes, TreeNode{ID: id, Kind: "folder_block", Number: blockNum})
+		for _, entry := range folderBlock.B_content {
+			if entry.B_inodo == -1 {
+				continue
+			}
+			name := FileSystem.EntryName(file, superblock, entry)
+			if name == "" || name == "." || name == ".." {
+				continue
+			}
+			data.Edges = append(data.Edges, TreeEdge{From: id, To: fmt.Sprintf("Inodo%d", entry.B_inodo)})
+			visitInode(entry.B_inodo, name)
+		}
+	}
+
+	visitInode = func(inodeNum int32, name string) {
+		if visitedInodes[inodeNum] || !used(superblock.S_bm_inode_start, inodeNum) {
+			return
+		}
+		visitedInodes[inodeNum] = true
+		var inode Structs.Inode
+		if err := Utilities.ReadObject(file, &inode, int64(superblock.S_inode_start+inodeNum*superblock.S_inode_size)); err != nil {
+			return
+		}
+		id := fmt.Sprintf("Inodo%d", inodeNum)
+		isDirectory := cleanString(inode.I_type[:]) == "0"
+		nodeType := "FILE"
+		if isDirectory {
+			nodeType = "DIR"
+		}
+		data.Nodes = append(data.Nodes, TreeNode{ID: id, Kind: "inode", Number: inodeNum, Name: name, Type: nodeType})
+
+		for i := 0; i < 12; i++ {
+			if inode.I_block[i] == -1 {
+				continue
+			}
+			link(id, inode.I_block[i])
+			if isDirectory {
+				visitFolderBlock(inode.I_block[i])
+			} else {
+				visitFileBlock(inode.I_block[i])
+			}
+		}
+		if !isDirectory {
+			for level := 1; level <= 3; level++ {
+				if blockNum := inode.I_block[11+level]; blockNum != -1 {
+					link(id, blockNum)
+					visitPointerBlock(blockNum, level)
+				}
+			}
+		}
+	}
+
+	visitInode(0, "/")
+	return data
+}
+
+// En CSV va una fila por nodo; links son los nodos a los que apunta
+func (data TreeData) csvTable() ([]string, [][]string) {
+	links := make(map[string][]string)
+	for _, edge := range data.Edges {
+		links[edge.From] = append(links[edge.From], edge.To)
+	}
+	var rows [][]string
+	for _, node := range data.Nodes {
+		rows = append(rows, []string{
+			node.ID, node.Kind, itoa(node.Number), node.Name, node.Type, node.Content,
+			strings.Join(links[node.ID], " "),
+		})
+	}
+	return []string{"id", "kind", "number", "name", "type", "content", "links"}, rows
+}
+
+// ----------------------------------------------------------------------------
+// sb
+// ----------------------------------------------------------------------------
+
+// SuperblockData son los campos del superbloque
+type SuperblockData struct {
+	FilesystemType  int32  `json:"filesystem_type"`
+	InodesCount     int32  `json:"inodes_count"`
+	BlocksCount     int32  `json:"blocks_count"`
+	FreeBlocksCount int32  `json:"free_blocks_count"`
+	FreeInodesCount int32  `json:"free_inodes_count"`
+	MountTime       string `json:"mtime"`
+	UnmountTime     string `json:"umtime"`
+	MountCount      int32  `json:"mnt_count"`
+	Magic           int32  `json:"magic"`
+	InodeSize       int32  `json:"inode_size"`
+	BlockSize       int32  `json:"block_size"`
+	FirstInode      int32  `json:"first_ino"`
+	FirstBlock      int32  `json:"first_blo"`
+	BitmapInodeAt   int32  `json:"bm_inode_start"`
+	BitmapBlockAt   int32  `json:"bm_block_start"`
+	InodeStart      int32  `json:"inode_start"`
+	BlockStart      int32  `json:"block_start"`
+	JournalCount    int32  `json:"journal_count"`
+	JournalHead     int32  `json:"journal_head"`
+	JournalTail     int32  `json:"journal_tail"`
+	JournalSequence int32  `json:"journal_seq"`
+}
+
+func collectSuperblockData(superblock *Structs.Superblock) SuperblockData {
+	return SuperblockData{
+		FilesystemType:  superblock.S_filesystem_type,
+		InodesCount:     superblock.S_inodes_count,
+		BlocksCount:     superblock.S_blocks_count,
+		FreeBlocksCount: superblock.S_free_blocks_count,
+		FreeInodesCount: superblock.S_free_inodes_count,
+		MountTime:       Utilities.FormatTimestamp(cleanString(superblock.S_mtime[:])),
+		UnmountTime:     Utilities.FormatTimestamp(cleanString(superblock.S_umtime[:])),
+		MountCount:      superblock.S_mnt_count,
+		Magic:           superblock.S_magic,
+		InodeSize:       superblock.S_inode_size,
+		BlockSize:       superblock.S_block_size,
+		FirstInode:      superblock.S_fist_ino,
+		FirstBlock:      superblock.S_first_blo,
+		BitmapInodeAt:   superblock.S_bm_inode_start,
+		BitmapBlockAt:   superblock.S_bm_block_start,
+		InodeStart:      superblock.S_inode_start,
+		BlockStart:      superblock.S_block_start,
+		JournalCount:    superblock.S_journal_count,
+		JournalHead:     superblock.S_journal_head,
+		JournalTail:     superblock.S_journal_tail,
+		JournalSequence: superblock.S_journal_seq,
+	}
+}
+
+// En CSV va una fila por campo, con el mismo nombre que en JSON
+func (data SuperblockData) csvTable() ([]string, [][]string) {
+	encoded, _ := json.Marshal(data)
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(encoded)))
+	decoder.UseNumber()
+	decoder.Decode(&fields)
+	var rows [][]string
+	for _, key := range []string{
+		"filesystem_type", "inodes_count", "blocks_count", "free_blocks_count", "free_inodes_count",
+		"mtime", "umtime", "mnt_count", "magic", "inode_size", "block_size", "first_ino", "first_blo",
+		"bm_inode_start", "bm_block_start", "inode_start", "block_start",
+		"journal_count", "journal_head", "journal_tail", "journal_seq",
+	} {
+		rows = append(rows, []string{key, fmt.Sprint(fields[key])})
+	}
+	return []string{"field", "value"}, rows
+}
+
+// ----------------------------------------------------------------------------
+// file, ls y journaling
+// ----------------------------------------------------------------------------
+
+// FileData es el contenido del archivo del reporte file
+type FileData struct {
+	Name    string `json:"name"`
+	Size    int    `json:"size"`
+	Content string `json:"content"`
+}
+
+func (data FileData) csvTable() ([]string, [][]string) {
+	return []string{"name", "size", "content"}, [][]string{{data.Name, strconv.Itoa(data.Size), data.Content}}
+}
+
+// ListData son las entradas que calcula listDirectoryContents
+type ListData struct {
+	Entries []DirectoryEntry `json:"entries"`
+}
+
+func (data ListData) csvTable() ([]string, [][]string) {
+	var rows [][]string
+	for _, entry := range data.Entries {
+		rows = append(rows, []string{
+			entry.Name, entry.Type, entry.Permissions, entry.Owner, entry.Group, itoa(entry.Size),
+			entry.CreationTime, entry.ModificationTime, entry.AccessTime, itoa(entry.InodeNumber),
+		})
+	}
+	return []string{"name", "type", "permissions", "owner", "group", "size", "ctime", "mtime", "atime", "inode"}, rows
+}
+
+// JournalingData son las entradas que calcula readJournalingEntries
+type JournalingData struct {
+	Entries []JournalingEntry `json:"entries"`
+}
+
+func (data JournalingData) csvTable() ([]string, [][]string) {
+	var rows [][]string
+	for _, entry := range data.Entries {
+		rows = append(rows, []string{strconv.Itoa(entry.Index), entry.Operation, entry.Path, entry.Content, entry.Date})
+	}
+	return []string{"index", "operation", "path", "content", "date"}, rows
+}
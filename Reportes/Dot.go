@@ -0,0 +1,377 @@
+package Reportes
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ============================================================================
+// LECTURA DEL LENGUAJE DOT
+// ============================================================================
+
+// Se entiende el subconjunto de DOT que generan los reportes: atributos del
+// grafo, de nodos y de aristas, nodos con etiquetas de texto, record o HTML,
+// aristas encadenadas con puertos y subgrafos con rank=same.
+
+// dotValue es el valor de un atributo; html indica que venía entre < >
+type dotValue struct {
+	text string
+	html bool
+}
+
+type dotAttrs map[string]dotValue
+
+func (attrs dotAttrs) get(key string, fallback string) string {
+	if value, ok := attrs[key]; ok {
+		return value.text
+	}
+	return fallback
+}
+
+type dotNode struct {
+	id    string
+	attrs dotAttrs
+}
+
+type dotEdge struct {
+	from, fromPort string
+	to, toPort     string
+	attrs          dotAttrs
+}
+
+type dotGraph struct {
+	attrs     dotAttrs
+	nodes     []*dotNode
+	nodeIndex map[string]*dotNode
+	edges     []*dotEdge
+	sameRanks [][]string
+}
+
+// dotToken es un elemento léxico: kind es "id", "string", "html" o el propio
+// signo de puntuación
+type dotToken struct {
+	kind  string
+	value string
+}
+
+// tokenizeDot separa el texto DOT en tokens, descartando los comentarios
+func tokenizeDot(source string) ([]dotToken, error) {
+	var tokens []dotToken
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/', r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("comentario sin cerrar")
+			}
+			i += 2
+		case r == '-' && i+1 < len(runes) && (runes[i+1] == '>' || runes[i+1] == '-'):
+			tokens = append(tokens, dotToken{kind: "->"})
+			i += 2
+		case strings.ContainsRune("{}[];,=:", r):
+			tokens = append(tokens, dotToken{kind: string(r)})
+			i++
+		case r == '"':
+			var value strings.Builder
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '"' {
+					i++
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("cadena sin cerrar")
+			}
+			i++
+			tokens = append(tokens, dotToken{kind: "string", value: value.String()})
+		case r == '<':
+			depth := 0
+			start := i
+			for ; i < len(runes); i++ {
+				if runes[i] == '<' {
+					depth++
+				} else if runes[i] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("etiqueta HTML sin cerrar")
+			}
+			tokens = append(tokens, dotToken{kind: "html", value: string(runes[start+1 : i])})
+			i++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.' || (runes[i] == '-' && i == start)) {
+				i++
+			}
+			tokens = append(tokens, dotToken{kind: "id", value: string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("carácter inesperado '%c'", r)
+		}
+	}
+	return tokens, nil
+}
+
+// dotParser recorre los tokens de un grafo
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+	graph  *dotGraph
+}
+
+// parseDot lee un grafo DOT
+func parseDot(source string) (*dotGraph, error) {
+	tokens, err := tokenizeDot(source)
+	if err != nil {
+		return nil, err
+	}
+	parser := &dotParser{
+		tokens: tokens,
+		graph:  &dotGraph{attrs: dotAttrs{}, nodeIndex: make(map[string]*dotNode)},
+	}
+
+	if parser.peekWord("strict") {
+		parser.pos++
+	}
+	if !parser.peekWord("digraph") && !parser.peekWord("graph") {
+		return nil, fmt.Errorf("se esperaba 'digraph' o 'graph'")
+	}
+	parser.pos++
+	if parser.peekKind("id") || parser.peekKind("string") {
+		parser.pos++
+	}
+	if err := parser.expect("{"); err != nil {
+		return nil, err
+	}
+	if _, err := parser.statements(dotAttrs{}, dotAttrs{}, parser.graph.attrs); err != nil {
+		return nil, err
+	}
+	return parser.graph, nil
+}
+
+func (p *dotParser) peekKind(kind string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == kind
+}
+
+func (p *dotParser) peekWord(word string) bool {
+	return p.peekKind("id") && strings.EqualFold(p.tokens[p.pos].value, word)
+}
+
+func (p *dotParser) expect(kind string) error {
+	if !p.peekKind(kind) {
+		return fmt.Errorf("se esperaba '%s' en el token %d", kind, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+// value lee un identificador, una cadena o una etiqueta HTML
+func (p *dotParser) value() (dotValue, error) {
+	if p.pos >= len(p.tokens) {
+		return dotValue{}, fmt.Errorf("fin inesperado del grafo")
+	}
+	token := p.tokens[p.pos]
+	switch token.kind {
+	case "id", "string":
+		p.pos++
+		return dotValue{text: token.value}, nil
+	case "html":
+		p.pos++
+		return dotValue{text: token.value, html: true}, nil
+	}
+	return dotValue{}, fmt.Errorf("valor inesperado '%s'", token.kind)
+}
+
+// attrList lee una o más listas [clave=valor, ...]
+func (p *dotParser) attrList(attrs dotAttrs) error {
+	for p.peekKind("[") {
+		p.pos++
+		for !p.peekKind("]") {
+			key, err := p.value()
+			if err != nil {
+				return err
+			}
+			value := dotValue{text: "true"}
+			if p.peekKind("=") {
+				p.pos++
+				if value, err = p.value(); err != nil {
+					return err
+				}
+			}
+			attrs[strings.ToLower(key.text)] = value
+			if p.peekKind(",") || p.peekKind(";") {
+				p.pos++
+			}
+		}
+		p.pos++
+	}
+	return nil
+}
+
+// nodeRef lee un nodo con su puerto opcional (nodo:puerto)
+func (p *dotParser) nodeRef() (string, string, error) {
+	id, err := p.value()
+	if err != nil {
+		return "", "", err
+	}
+	port := ""
+	if p.peekKind(":") {
+		p.pos++
+		portValue, err := p.value()
+		if err != nil {
+			return "", "", err
+		}
+		port = portValue.text
+		// nodo:puerto:punto_cardinal; el punto cardinal se ignora
+		if p.peekKind(":") {
+			p.pos += 2
+		}
+	}
+	return id.text, port, nil
+}
+
+// node devuelve el nodo con ese id, creándolo con los atributos por defecto
+func (p *dotParser) node(id string, defaults dotAttrs) *dotNode {
+	if node, ok := p.graph.nodeIndex[id]; ok {
+		return node
+	}
+	node := &dotNode{id: id, attrs: dotAttrs{}}
+	for key, value := range defaults {
+		node.attrs[key] = value
+	}
+	p.graph.nodeIndex[id] = node
+	p.graph.nodes = append(p.graph.nodes, node)
+	return node
+}
+
+// statements lee sentencias hasta la llave de cierre y devuelve los nodos que
+// se mencionaron (para los subgrafos con rank=same)
+func (p *dotParser) statements(nodeDefaults dotAttrs, edgeDefaults dotAttrs, scope dotAttrs) ([]string, error) {
+	var mentioned []string
+	for {
+		if p.pos >= len(p.tokens) {
+			return nil, fmt.Errorf("falta '}' al final del grafo")
+		}
+		if p.peekKind("}") {
+			p.pos++
+			return mentioned, nil
+		}
+		if p.peekKind(";") || p.peekKind(",") {
+			p.pos++
+			continue
+		}
+
+		// Subgrafos: "subgraph nombre { ... }" o "{ ... }"
+		if p.peekWord("subgraph") || p.peekKind("{") {
+			if p.peekWord("subgraph") {
+				p.pos++
+				if p.peekKind("id") || p.peekKind("string") {
+					p.pos++
+				}
+			}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			subAttrs := dotAttrs{}
+			innerNodes := copyAttrs(nodeDefaults)
+			innerEdges := copyAttrs(edgeDefaults)
+			nodes, err := p.statements(innerNodes, innerEdges, subAttrs)
+			if err != nil {
+				return nil, err
+			}
+			if rank := subAttrs.get("rank", ""); rank == "same" || rank == "min" || rank == "source" {
+				p.graph.sameRanks = append(p.graph.sameRanks, nodes)
+			}
+			mentioned = append(mentioned, nodes...)
+			continue
+		}
+
+		// Atributos por defecto: graph [...], node [...], edge [...]
+		if p.peekWord("graph") || p.peekWord("node") || p.peekWord("edge") {
+			kind := strings.ToLower(p.tokens[p.pos].value)
+			p.pos++
+			target := map[string]dotAttrs{"graph": scope, "node": nodeDefaults, "edge": edgeDefaults}[kind]
+			if err := p.attrList(target); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		id, port, err := p.nodeRef()
+		if err != nil {
+			return nil, err
+		}
+
+		// Atributo del grafo: clave=valor
+		if p.peekKind("=") {
+			p.pos++
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			scope[strings.ToLower(id)] = value
+			continue
+		}
+
+		// Cadena de aristas: a -> b -> c [atributos]
+		if p.peekKind("->") {
+			chain := [][2]string{{id, port}}
+			for p.peekKind("->") {
+				p.pos++
+				nextID, nextPort, err := p.nodeRef()
+				if err != nil {
+					return nil, err
+				}
+				chain = append(chain, [2]string{nextID, nextPort})
+			}
+			attrs := copyAttrs(edgeDefaults)
+			if err := p.attrList(attrs); err != nil {
+				return nil, err
+			}
+			for i := range chain {
+				p.node(chain[i][0], nodeDefaults)
+				mentioned = append(mentioned, chain[i][0])
+				if i > 0 {
+					p.graph.edges = append(p.graph.edges, &dotEdge{
+						from: chain[i-1][0], fromPort: chain[i-1][1],
+						to: chain[i][0], toPort: chain[i][1],
+						attrs: attrs,
+					})
+				}
+			}
+			continue
+		}
+
+		// Nodo: id [atributos]
+		node := p.node(id, nodeDefaults)
+		if err := p.attrList(node.attrs); err != nil {
+			return nil, err
+		}
+		mentioned = append(mentioned, id)
+	}
+}
+
+func copyAttrs(attrs dotAttrs) dotAttrs {
+	copied := dotAttrs{}
+	for key, value := range attrs {
+		copied[key] = value
+	}
+	return copied
+}
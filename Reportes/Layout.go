@@ -0,0 +1,1201 @@
+package Reportes
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// ETIQUETAS DE LOS NODOS: TEXTO, RECORD Y TABLAS HTML
+// ============================================================================
+
+// textRun es un tramo de texto con un mismo estilo
+type textRun struct {
+	text  string
+	style textStyle
+}
+
+// textLine es una línea de tramos; base es el estilo de una línea vacía y
+// align la alineación propia de la línea (\l y \r en las etiquetas)
+type textLine struct {
+	runs  []textRun
+	base  textStyle
+	align string
+}
+
+func (l textLine) width() float64 {
+	width := 0.0
+	for _, run := range l.runs {
+		width += textWidth(run.text, run.style)
+	}
+	return width
+}
+
+func (l textLine) fontSize() float64 {
+	size := l.base.size
+	for _, run := range l.runs {
+		size = math.Max(size, run.style.size)
+	}
+	return size
+}
+
+func (l textLine) height() float64 {
+	return l.fontSize() * 1.2
+}
+
+// linesSize devuelve el ancho y el alto de un bloque de líneas
+func linesSize(lines []textLine) (float64, float64) {
+	width, height := 0.0, 0.0
+	for _, line := range lines {
+		width = math.Max(width, line.width())
+		height += line.height()
+	}
+	return width, height
+}
+
+// drawLines dibuja las líneas centradas verticalmente en el rectángulo, con
+// la alineación horizontal indicada ("left", "right" o centrado)
+func drawLines(c canvas, lines []textLine, x, y, w, h float64, align string, padding float64) {
+	_, total := linesSize(lines)
+	top := y + (h-total)/2
+	for _, line := range lines {
+		lineWidth := line.width()
+		left := x + (w-lineWidth)/2
+		lineAlign := align
+		if line.align != "" {
+			lineAlign = line.align
+		}
+		switch lineAlign {
+		case "left":
+			left = x + padding
+		case "right":
+			left = x + w - padding - lineWidth
+		}
+		baseline := top + line.fontSize()*0.95
+		for _, run := range line.runs {
+			if run.text != "" {
+				c.text(left, baseline, run.text, run.style)
+			}
+			left += textWidth(run.text, run.style)
+		}
+		top += line.height()
+	}
+}
+
+// plainLines separa una etiqueta de texto de Graphviz en líneas: \n termina
+// una línea centrada, \l una alineada a la izquierda y \r a la derecha
+func plainLines(label string, style textStyle) []textLine {
+	var lines []textLine
+	var text strings.Builder
+	runes := []rune(label)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\n' {
+			lines = append(lines, textLine{runs: []textRun{{text: text.String(), style: style}}, base: style})
+			text.Reset()
+			continue
+		}
+		if runes[i] != '\\' || i+1 >= len(runes) {
+			text.WriteRune(runes[i])
+			continue
+		}
+		i++
+		align, breaks := map[rune]string{'n': "", 'l': "left", 'r': "right"}[runes[i]]
+		if !breaks {
+			text.WriteRune(runes[i])
+			continue
+		}
+		lines = append(lines, textLine{runs: []textRun{{text: text.String(), style: style}}, base: style, align: align})
+		text.Reset()
+	}
+	if text.Len() > 0 || len(lines) == 0 {
+		lines = append(lines, textLine{runs: []textRun{{text: text.String(), style: style}}, base: style})
+	}
+	return lines
+}
+
+// labelBox es la etiqueta de un nodo ya medida. arrange la ubica en el lienzo
+// y después port devuelve el rectángulo de un puerto.
+type labelBox interface {
+	size() (float64, float64)
+	arrange(x, y, w, h float64)
+	draw(c canvas)
+	port(name string) (rectangle, bool)
+}
+
+type rectangle struct {
+	x, y, w, h float64
+}
+
+func (r rectangle) center() point {
+	return point{r.x + r.w/2, r.y + r.h/2}
+}
+
+// ----------------------------------------------------------------------------
+// Texto simple
+// ----------------------------------------------------------------------------
+
+type textBox struct {
+	lines  []textLine
+	margin float64
+	rect   rectangle
+}
+
+func (b *textBox) size() (float64, float64) {
+	w, h := linesSize(b.lines)
+	return w + 2*b.margin, h + b.margin
+}
+
+func (b *textBox) arrange(x, y, w, h float64) {
+	b.rect = rectangle{x, y, w, h}
+}
+
+func (b *textBox) draw(c canvas) {
+	drawLines(c, b.lines, b.rect.x, b.rect.y, b.rect.w, b.rect.h, "", b.margin)
+}
+
+func (b *textBox) port(name string) (rectangle, bool) {
+	return rectangle{}, false
+}
+
+// ----------------------------------------------------------------------------
+// Record: campos separados por | que se anidan y alternan de orientación con {}
+// ----------------------------------------------------------------------------
+
+type recordField struct {
+	lines      []textLine
+	children   []*recordField
+	horizontal bool
+	rect       rectangle
+	stroke     string
+}
+
+// parseRecord lee una etiqueta record; horizontal es la orientación del nivel
+// superior (horizontal con rankdir=TB)
+func parseRecord(label string, horizontal bool, style textStyle) *recordField {
+	runes := []rune(label)
+	pos := 0
+	var parseList func(horizontal bool) *recordField
+	parseList = func(horizontal bool) *recordField {
+		field := &recordField{horizontal: horizontal}
+		var text strings.Builder
+		var current *recordField
+		flush := func() {
+			if current == nil {
+				current = &recordField{lines: plainLines(strings.TrimSpace(text.String()), style)}
+			}
+			field.children = append(field.children, current)
+			current = nil
+			text.Reset()
+		}
+		for pos < len(runes) {
+			r := runes[pos]
+			pos++
+			switch {
+			case r == '\\' && pos < len(runes) && strings.ContainsRune("{}|<> ", runes[pos]):
+				text.WriteRune(runes[pos])
+				pos++
+			case r == '{':
+				current = parseList(!horizontal)
+			case r == '}':
+				flush()
+				return field
+			case r == '|':
+				flush()
+			case r == '<':
+				// Nombre de puerto del campo: no se dibuja
+				for pos < len(runes) && runes[pos] != '>' {
+					pos++
+				}
+				pos++
+			default:
+				text.WriteRune(r)
+			}
+		}
+		flush()
+		return field
+	}
+	return parseList(horizontal)
+}
+
+func (f *recordField) size() (float64, float64) {
+	if len(f.children) == 0 {
+		w, h := linesSize(f.lines)
+		return w + 16, h + 8
+	}
+	width, height := 0.0, 0.0
+	for _, child := range f.children {
+		w, h := child.size()
+		if f.horizontal {
+			width += w
+			height = math.Max(height, h)
+		} else {
+			width = math.Max(width, w)
+			height += h
+		}
+	}
+	return width, height
+}
+
+func (f *recordField) arrange(x, y, w, h float64) {
+	f.rect = rectangle{x, y, w, h}
+	if len(f.children) == 0 {
+		return
+	}
+	natW, natH := f.size()
+	extra := (w - natW) / float64(len(f.children))
+	if !f.horizontal {
+		extra = (h - natH) / float64(len(f.children))
+	}
+	for _, child := range f.children {
+		cw, ch := child.size()
+		if f.horizontal {
+			child.arrange(x, y, cw+extra, h)
+			x += cw + extra
+		} else {
+			child.arrange(x, y, w, ch+extra)
+			y += ch + extra
+		}
+	}
+}
+
+func (f *recordField) draw(c canvas) {
+	if len(f.children) == 0 {
+		drawLines(c, f.lines, f.rect.x, f.rect.y, f.rect.w, f.rect.h, "", 0)
+		return
+	}
+	for i, child := range f.children {
+		child.stroke = f.stroke
+		child.draw(c)
+		if i > 0 {
+			// Separador entre campos
+			if f.horizontal {
+				c.rect(child.rect.x, child.rect.y, 0, child.rect.h, "", f.stroke, 1)
+			} else {
+				c.rect(child.rect.x, child.rect.y, child.rect.w, 0, "", f.stroke, 1)
+			}
+		}
+	}
+}
+
+func (f *recordField) port(name string) (rectangle, bool) {
+	return rectangle{}, false
+}
+
+// ----------------------------------------------------------------------------
+// Tablas HTML
+// ----------------------------------------------------------------------------
+
+type htmlCell struct {
+	colspan int
+	col     int
+	bgcolor string
+	port    string
+	align   string
+	lines   []textLine
+	rect    rectangle
+}
+
+type htmlRow struct {
+	bgcolor string
+	cells   []*htmlCell
+}
+
+type htmlTable struct {
+	border      float64
+	cellborder  float64
+	cellspacing float64
+	cellpadding float64
+	bgcolor     string
+	color       string
+	rows        []*htmlRow
+	colWidths   []float64
+	rowHeights  []float64
+	rect        rectangle
+}
+
+// htmlTag es una etiqueta de la etiqueta HTML: name vacío indica texto
+type htmlTag struct {
+	name    string
+	closing bool
+	attrs   map[string]string
+	text    string
+}
+
+// tokenizeHTML separa una etiqueta HTML de Graphviz en etiquetas y texto
+func tokenizeHTML(source string) []htmlTag {
+	var tags []htmlTag
+	for len(source) > 0 {
+		start := strings.IndexByte(source, '<')
+		if start != 0 {
+			if start == -1 {
+				start = len(source)
+			}
+			tags = append(tags, htmlTag{text: source[:start]})
+			source = source[start:]
+			continue
+		}
+		end := strings.IndexByte(source, '>')
+		if end == -1 {
+			tags = append(tags, htmlTag{text: source})
+			break
+		}
+		body := strings.TrimSpace(source[1:end])
+		source = source[end+1:]
+		tag := htmlTag{attrs: make(map[string]string)}
+		if strings.HasPrefix(body, "/") {
+			tag.closing = true
+			body = body[1:]
+		}
+		selfClosing := strings.HasSuffix(body, "/")
+		body = strings.TrimSuffix(body, "/")
+		fields := strings.Fields(body)
+		if len(fields) == 0 {
+			continue
+		}
+		tag.name = strings.ToLower(fields[0])
+		rest := strings.TrimSpace(body[len(fields[0]):])
+		for rest != "" {
+			eq := strings.IndexByte(rest, '=')
+			if eq == -1 {
+				break
+			}
+			key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+			rest = strings.TrimSpace(rest[eq+1:])
+			value := ""
+			if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+				quote := rest[0]
+				closeAt := strings.IndexByte(rest[1:], quote)
+				if closeAt == -1 {
+					closeAt = len(rest) - 1
+				}
+				value = rest[1 : closeAt+1]
+				rest = strings.TrimSpace(rest[minInt(closeAt+2, len(rest)):])
+			} else {
+				space := strings.IndexAny(rest, " \t\n")
+				if space == -1 {
+					space = len(rest)
+				}
+				value = rest[:space]
+				rest = strings.TrimSpace(rest[space:])
+			}
+			tag.attrs[key] = value
+		}
+		tags = append(tags, tag)
+		if selfClosing && !tag.closing {
+			tags = append(tags, htmlTag{name: tag.name, closing: true})
+		}
+	}
+	return tags
+}
+
+var htmlEntities = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", "\"", "&#39;", "'", "&apos;", "'", "&nbsp;", " ")
+
+// textCollector arma líneas de texto a partir de etiquetas b, font y br
+type textCollector struct {
+	stack []textStyle
+	lines []textLine
+}
+
+func newTextCollector(base textStyle) *textCollector {
+	return &textCollector{stack: []textStyle{base}, lines: []textLine{{base: base}}}
+}
+
+func (t *textCollector) style() textStyle {
+	return t.stack[len(t.stack)-1]
+}
+
+func (t *textCollector) handle(tag htmlTag) {
+	if tag.name == "" {
+		text := strings.Join(strings.Fields(htmlEntities.Replace(tag.text)), " ")
+		if strings.TrimSpace(tag.text) != "" && tag.text != strings.TrimLeft(tag.text, " \t\n") && len(t.lines[len(t.lines)-1].runs) > 0 {
+			text = " " + text
+		}
+		if text != "" {
+			line := &t.lines[len(t.lines)-1]
+			line.runs = append(line.runs, textRun{text: text, style: t.style()})
+		}
+		return
+	}
+	switch tag.name {
+	case "br":
+		if !tag.closing {
+			t.lines = append(t.lines, textLine{base: t.style()})
+		}
+	case "b", "font", "i", "u", "s", "sub", "sup", "o":
+		if tag.closing {
+			if len(t.stack) > 1 {
+				t.stack = t.stack[:len(t.stack)-1]
+			}
+			return
+		}
+		style := t.style()
+		if tag.name == "b" {
+			style.bold = true
+		}
+		if colorValue, ok := tag.attrs["color"]; ok {
+			style.color = colorValue
+		}
+		if sizeValue, ok := tag.attrs["point-size"]; ok {
+			if size, err := strconv.ParseFloat(sizeValue, 64); err == nil {
+				style.size = size
+			}
+		}
+		if face, ok := tag.attrs["face"]; ok && strings.Contains(strings.ToLower(face), "bold") {
+			style.bold = true
+		}
+		t.stack = append(t.stack, style)
+	}
+}
+
+func htmlFloat(attrs map[string]string, key string, fallback float64) float64 {
+	if value, ok := attrs[key]; ok {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			return number
+		}
+	}
+	return fallback
+}
+
+// parseHTMLLabel lee una etiqueta HTML. Si no tiene tabla se trata como texto.
+func parseHTMLLabel(source string, base textStyle) labelBox {
+	tags := tokenizeHTML(source)
+	var table *htmlTable
+	var row *htmlRow
+	var cell *htmlCell
+	var collector *textCollector
+	outside := newTextCollector(base)
+
+	for _, tag := range tags {
+		switch {
+		case tag.name == "table" && !tag.closing && table == nil:
+			border := htmlFloat(tag.attrs, "border", 1)
+			table = &htmlTable{
+				border:      border,
+				cellborder:  htmlFloat(tag.attrs, "cellborder", border),
+				cellspacing: htmlFloat(tag.attrs, "cellspacing", 2),
+				cellpadding: htmlFloat(tag.attrs, "cellpadding", 2),
+				bgcolor:     tag.attrs["bgcolor"],
+				color:       tag.attrs["color"],
+			}
+		case table == nil:
+			outside.handle(tag)
+		case tag.name == "tr" && !tag.closing:
+			row = &htmlRow{bgcolor: tag.attrs["bgcolor"]}
+			table.rows = append(table.rows, row)
+		case tag.name == "td" && !tag.closing && row != nil:
+			cell = &htmlCell{
+				colspan: maxInt(1, int(htmlFloat(tag.attrs, "colspan", 1))),
+				bgcolor: tag.attrs["bgcolor"],
+				port:    tag.attrs["port"],
+				align:   strings.ToLower(tag.attrs["align"]),
+			}
+			collector = newTextCollector(base)
+			row.cells = append(row.cells, cell)
+		case tag.name == "td" && tag.closing && cell != nil:
+			cell.lines = collector.lines
+			cell, collector = nil, nil
+		case collector != nil:
+			collector.handle(tag)
+		}
+	}
+	if table == nil {
+		return &textBox{lines: outside.lines, margin: 4}
+	}
+	table.measure()
+	return table
+}
+
+// measure calcula el ancho de cada columna y el alto de cada fila
+func (t *htmlTable) measure() {
+	columns := 0
+	for _, row := range t.rows {
+		col := 0
+		for _, cell := range row.cells {
+			cell.col = col
+			col += cell.colspan
+		}
+		columns = maxInt(columns, col)
+	}
+	t.colWidths = make([]float64, columns)
+	t.rowHeights = make([]float64, len(t.rows))
+	inset := 2 * (t.cellpadding + t.cellborder)
+
+	for _, spanning := range []bool{false, true} {
+		for _, row := range t.rows {
+			for _, cell := range row.cells {
+				if (cell.colspan > 1) != spanning {
+					continue
+				}
+				w, _ := linesSize(cell.lines)
+				w += inset
+				available := float64(cell.colspan-1) * t.cellspacing
+				for c := cell.col; c < cell.col+cell.colspan; c++ {
+					available += t.colWidths[c]
+				}
+				if w > available {
+					for c := cell.col; c < cell.col+cell.colspan; c++ {
+						t.colWidths[c] += (w - available) / float64(cell.colspan)
+					}
+				}
+			}
+		}
+	}
+	for i, row := range t.rows {
+		for _, cell := range row.cells {
+			_, h := linesSize(cell.lines)
+			t.rowHeights[i] = math.Max(t.rowHeights[i], h+inset)
+		}
+	}
+}
+
+func (t *htmlTable) size() (float64, float64) {
+	width := 2*t.border + float64(len(t.colWidths)+1)*t.cellspacing
+	for _, w := range t.colWidths {
+		width += w
+	}
+	height := 2*t.border + float64(len(t.rowHeights)+1)*t.cellspacing
+	for _, h := range t.rowHeights {
+		height += h
+	}
+	return width, height
+}
+
+func (t *htmlTable) arrange(x, y, w, h float64) {
+	natW, natH := t.size()
+	// La tabla conserva su tamaño y se centra en el espacio del nodo
+	x += (w - natW) / 2
+	y += (h - natH) / 2
+	t.rect = rectangle{x, y, natW, natH}
+
+	colX := make([]float64, len(t.colWidths)+1)
+	colX[0] = x + t.border + t.cellspacing
+	for c, cw := range t.colWidths {
+		colX[c+1] = colX[c] + cw + t.cellspacing
+	}
+	rowY := y + t.border + t.cellspacing
+	for r, row := range t.rows {
+		for _, cell := range row.cells {
+			end := cell.col + cell.colspan
+			cell.rect = rectangle{colX[cell.col], rowY, colX[end] - t.cellspacing - colX[cell.col], t.rowHeights[r]}
+		}
+		rowY += t.rowHeights[r] + t.cellspacing
+	}
+}
+
+func (t *htmlTable) draw(c canvas) {
+	stroke := t.color
+	if stroke == "" {
+		stroke = "black"
+	}
+	c.rect(t.rect.x, t.rect.y, t.rect.w, t.rect.h, t.bgcolor, "", 0)
+	if t.border > 0 {
+		c.rect(t.rect.x, t.rect.y, t.rect.w, t.rect.h, "", stroke, t.border)
+	}
+	for _, row := range t.rows {
+		for _, cell := range row.cells {
+			fill := cell.bgcolor
+			if fill == "" {
+				fill = row.bgcolor
+			}
+			c.rect(cell.rect.x, cell.rect.y, cell.rect.w, cell.rect.h, fill, stroke, t.cellborder)
+			drawLines(c, cell.lines, cell.rect.x, cell.rect.y, cell.rect.w, cell.rect.h, cell.align, t.cellpadding+t.cellborder)
+		}
+	}
+}
+
+func (t *htmlTable) port(name string) (rectangle, bool) {
+	for _, row := range t.rows {
+		for _, cell := range row.cells {
+			if cell.port != "" && cell.port == name {
+				return cell.rect, true
+			}
+		}
+	}
+	return rectangle{}, false
+}
+
+// ============================================================================
+// DISTRIBUCIÓN DEL GRAFO POR NIVELES
+// ============================================================================
+
+// layoutNode es un nodo medido y ubicado; major es la coordenada en la
+// dirección de los niveles (y con rankdir=TB, x con LR) y minor la otra
+type layoutNode struct {
+	node         *dotNode
+	label        labelBox
+	shape        string
+	w, h         float64
+	rank, order  int
+	major, minor float64
+	rect         rectangle
+}
+
+type layoutEdge struct {
+	edge     *dotEdge
+	from, to int
+}
+
+// graphLayout es el grafo con sus nodos ubicados, listo para dibujar
+type graphLayout struct {
+	graph      *dotGraph
+	horizontal bool // rankdir=LR
+	nodes      []*layoutNode
+	edges      []layoutEdge
+	width      float64
+	height     float64
+	title      []textLine
+	titleTop   bool
+}
+
+func inches(value string, fallback float64) float64 {
+	if number, err := strconv.ParseFloat(strings.Fields(value + " x")[0], 64); err == nil {
+		return number * 72
+	}
+	return fallback
+}
+
+// nodeStyle devuelve el estilo de texto de un nodo o del grafo
+func nodeStyle(attrs dotAttrs) textStyle {
+	size, err := strconv.ParseFloat(attrs.get("fontsize", "14"), 64)
+	if err != nil || size <= 0 {
+		size = 14
+	}
+	fontname := strings.ToLower(attrs.get("fontname", ""))
+	return textStyle{
+		size:  size,
+		bold:  strings.Contains(fontname, "bold"),
+		mono:  strings.Contains(fontname, "courier") || strings.Contains(fontname, "mono"),
+		color: attrs.get("fontcolor", "black"),
+	}
+}
+
+// measureNode arma la etiqueta de un nodo y calcula su tamaño
+func measureNode(node *dotNode, horizontal bool) *layoutNode {
+	style := nodeStyle(node.attrs)
+	shape := strings.ToLower(node.attrs.get("shape", "ellipse"))
+	label, hasLabel := node.attrs["label"]
+	if !hasLabel {
+		label = dotValue{text: node.id}
+	}
+
+	ln := &layoutNode{node: node, shape: shape}
+	switch {
+	case label.html:
+		ln.label = parseHTMLLabel(label.text, style)
+	case shape == "record" || shape == "mrecord":
+		ln.label = parseRecord(label.text, !horizontal, style)
+	default:
+		ln.label = &textBox{lines: plainLines(label.text, style), margin: 8}
+	}
+	ln.w, ln.h = ln.label.size()
+
+	switch shape {
+	case "plaintext", "plain", "none", "record", "mrecord":
+	case "box", "rect", "rectangle", "square":
+		ln.w, ln.h = math.Max(ln.w, 54), math.Max(ln.h, 36)
+	default:
+		ln.w, ln.h = math.Max(ln.w*1.42, 54), math.Max(ln.h*1.42, 36)
+	}
+	return ln
+}
+
+// layoutGraph ubica los nodos por niveles, como dot: asigna un nivel a cada
+// nodo según las aristas, ordena cada nivel para reducir cruces y centra los
+// nodos respecto a sus vecinos
+func layoutGraph(graph *dotGraph) *graphLayout {
+	rankdir := strings.ToUpper(graph.attrs.get("rankdir", "TB"))
+	layout := &graphLayout{graph: graph, horizontal: rankdir == "LR" || rankdir == "RL"}
+	index := make(map[string]int)
+	for i, node := range graph.nodes {
+		index[node.id] = i
+		layout.nodes = append(layout.nodes, measureNode(node, layout.horizontal))
+	}
+	for _, edge := range graph.edges {
+		layout.edges = append(layout.edges, layoutEdge{edge: edge, from: index[edge.from], to: index[edge.to]})
+	}
+
+	layout.assignRanks(index)
+	ranks := layout.orderRanks()
+	layout.place(ranks)
+	layout.addTitle()
+	return layout
+}
+
+// assignRanks calcula el nivel de cada nodo con el camino más largo desde las
+// fuentes, ignorando las aristas que cierran ciclos, y luego iguala los
+// grupos rank=same
+func (l *graphLayout) assignRanks(index map[string]int) {
+	n := len(l.nodes)
+	outs := make([][]int, n)
+	for _, e := range l.edges {
+		if e.from != e.to {
+			outs[e.from] = append(outs[e.from], e.to)
+		}
+	}
+
+	// Aristas hacia atrás (ciclos) por búsqueda en profundidad
+	state := make([]int, n)
+	type arc struct{ from, to int }
+	var forward []arc
+	var visit func(v int)
+	visit = func(v int) {
+		state[v] = 1
+		for _, w := range outs[v] {
+			if state[w] == 1 {
+				continue
+			}
+			forward = append(forward, arc{v, w})
+			if state[w] == 0 {
+				visit(w)
+			}
+		}
+		state[v] = 2
+	}
+	for v := 0; v < n; v++ {
+		if state[v] == 0 {
+			visit(v)
+		}
+	}
+
+	var groups [][]int
+	for _, same := range l.graph.sameRanks {
+		var group []int
+		for _, id := range same {
+			if i, ok := index[id]; ok {
+				group = append(group, i)
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	for iteration := 0; iteration <= n+len(groups); iteration++ {
+		changed := false
+		for _, a := range forward {
+			if l.nodes[a.to].rank < l.nodes[a.from].rank+1 {
+				l.nodes[a.to].rank = l.nodes[a.from].rank + 1
+				changed = true
+			}
+		}
+		for _, group := range groups {
+			top := 0
+			for _, i := range group {
+				top = maxInt(top, l.nodes[i].rank)
+			}
+			for _, i := range group {
+				if l.nodes[i].rank != top {
+					l.nodes[i].rank = top
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+}
+
+// portFraction devuelve dónde está un puerto dentro del nodo en la dirección
+// transversal a los niveles (0 al inicio, 1 al final)
+func (l *graphLayout) portFraction(node *layoutNode, port string) float64 {
+	if port == "" {
+		return 0.5
+	}
+	node.label.arrange(0, 0, node.w, node.h)
+	rect, ok := node.label.port(port)
+	if !ok {
+		return 0.5
+	}
+	if l.horizontal {
+		return rect.center().y / math.Max(node.h, 1)
+	}
+	return rect.center().x / math.Max(node.w, 1)
+}
+
+// orderRanks agrupa los nodos por nivel y los ordena con el método del
+// baricentro, partiendo del orden en que se declararon
+func (l *graphLayout) orderRanks() [][]*layoutNode {
+	maxRank := 0
+	for _, node := range l.nodes {
+		maxRank = maxInt(maxRank, node.rank)
+	}
+	ranks := make([][]*layoutNode, maxRank+1)
+	for _, node := range l.nodes {
+		node.order = len(ranks[node.rank])
+		ranks[node.rank] = append(ranks[node.rank], node)
+	}
+
+	sweep := func(r int, upward bool) {
+		keys := make(map[*layoutNode]float64)
+		for _, node := range ranks[r] {
+			sum, count := 0.0, 0
+			for _, e := range l.edges {
+				from, to := l.nodes[e.from], l.nodes[e.to]
+				neighbor, port := from, e.edge.fromPort
+				if to != node {
+					if from != node {
+						continue
+					}
+					neighbor, port = to, e.edge.toPort
+				}
+				if (upward && neighbor.rank <= r) || (!upward && neighbor.rank >= r) {
+					continue
+				}
+				sum += (float64(neighbor.order) + l.portFraction(neighbor, port)) / float64(len(ranks[neighbor.rank]))
+				count++
+			}
+			if count > 0 {
+				keys[node] = sum / float64(count)
+			} else {
+				keys[node] = (float64(node.order) + 0.5) / float64(len(ranks[r]))
+			}
+		}
+		sort.SliceStable(ranks[r], func(i, j int) bool {
+			return keys[ranks[r][i]] < keys[ranks[r][j]]
+		})
+		for i, node := range ranks[r] {
+			node.order = i
+		}
+	}
+
+	for iteration := 0; iteration < 3; iteration++ {
+		for r := 1; r <= maxRank; r++ {
+			sweep(r, false)
+		}
+		for r := maxRank - 1; r >= 0; r-- {
+			sweep(r, true)
+		}
+	}
+	for r := 1; r <= maxRank; r++ {
+		sweep(r, false)
+	}
+	return ranks
+}
+
+// extent devuelve el tamaño de un nodo en la dirección de los niveles
+// (major) y en la transversal (minor)
+func (l *graphLayout) extent(node *layoutNode) (float64, float64) {
+	if l.horizontal {
+		return node.w, node.h
+	}
+	return node.h, node.w
+}
+
+// place calcula las coordenadas: cada nivel ocupa una franja y dentro de ella
+// los nodos se acercan al promedio de sus vecinos sin superponerse
+func (l *graphLayout) place(ranks [][]*layoutNode) {
+	attrs := l.graph.attrs
+	ranksep := math.Max(inches(attrs.get("ranksep", ""), 36), 0.02*72)
+	nodesep := math.Max(inches(attrs.get("nodesep", ""), 18), 0.02*72)
+	pad := inches(attrs.get("pad", ""), 4)
+
+	// Franja de cada nivel
+	major := 0.0
+	for _, rank := range ranks {
+		thickness := 0.0
+		for _, node := range rank {
+			size, _ := l.extent(node)
+			thickness = math.Max(thickness, size)
+		}
+		for _, node := range rank {
+			size, _ := l.extent(node)
+			node.major = major + (thickness-size)/2
+		}
+		major += thickness + ranksep
+	}
+
+	// Posición inicial: cada nivel centrado en 0
+	for _, rank := range ranks {
+		total := 0.0
+		for _, node := range rank {
+			_, size := l.extent(node)
+			total += size
+		}
+		total += nodesep * float64(len(rank)-1)
+		position := -total / 2
+		for _, node := range rank {
+			_, size := l.extent(node)
+			node.minor = position
+			position += size + nodesep
+		}
+	}
+
+	// Centro de un nodo (o de su puerto) en la dirección transversal
+	anchor := func(node *layoutNode, port string) float64 {
+		_, size := l.extent(node)
+		return node.minor + l.portFraction(node, port)*size
+	}
+	align := func(rank []*layoutNode, upward bool) {
+		desired := make([]float64, len(rank))
+		for i, node := range rank {
+			sum, count := 0.0, 0
+			for _, e := range l.edges {
+				from, to := l.nodes[e.from], l.nodes[e.to]
+				switch {
+				case to == node && from != node && (from.rank < node.rank) != upward && from.rank != node.rank:
+					sum += anchor(from, e.edge.fromPort) - (l.portFraction(node, e.edge.toPort)-0.5)*0
+					count++
+				case from == node && to != node && (to.rank < node.rank) != upward && to.rank != node.rank:
+					sum += anchor(to, e.edge.toPort)
+					count++
+				}
+			}
+			_, size := l.extent(node)
+			desired[i] = node.minor
+			if count > 0 {
+				desired[i] = sum/float64(count) - size/2
+			}
+		}
+		// Respetar el orden y la separación: primero hacia adelante y luego
+		// hacia atrás, y promediar ambas pasadas
+		forward := make([]float64, len(rank))
+		for i, node := range rank {
+			forward[i] = desired[i]
+			if i > 0 {
+				_, previous := l.extent(rank[i-1])
+				forward[i] = math.Max(forward[i], forward[i-1]+previous+nodesep)
+			}
+			_ = node
+		}
+		backward := make([]float64, len(rank))
+		for i := len(rank) - 1; i >= 0; i-- {
+			backward[i] = desired[i]
+			if i < len(rank)-1 {
+				_, size := l.extent(rank[i])
+				backward[i] = math.Min(backward[i], backward[i+1]-size-nodesep)
+			}
+		}
+		for i, node := range rank {
+			node.minor = (forward[i] + backward[i]) / 2
+		}
+		// El promedio puede dejar nodos encimados: se corrige hacia adelante
+		for i := 1; i < len(rank); i++ {
+			_, previous := l.extent(rank[i-1])
+			rank[i].minor = math.Max(rank[i].minor, rank[i-1].minor+previous+nodesep)
+		}
+	}
+	for r := 1; r < len(ranks); r++ {
+		align(ranks[r], false)
+	}
+	for r := len(ranks) - 2; r >= 0; r-- {
+		align(ranks[r], true)
+	}
+
+	// Pasar a coordenadas del lienzo
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, node := range l.nodes {
+		x, y := node.minor, node.major
+		if l.horizontal {
+			x, y = node.major, node.minor
+		}
+		node.rect = rectangle{x, y, node.w, node.h}
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x+node.w), math.Max(maxY, y+node.h)
+	}
+	if len(l.nodes) == 0 {
+		minX, minY, maxX, maxY = 0, 0, 0, 0
+	}
+	for _, node := range l.nodes {
+		node.rect.x += pad - minX
+		node.rect.y += pad - minY
+		node.label.arrange(node.rect.x, node.rect.y, node.rect.w, node.rect.h)
+	}
+	l.width = maxX - minX + 2*pad
+	l.height = maxY - minY + 2*pad
+}
+
+// addTitle agrega la etiqueta del grafo arriba o abajo del dibujo
+func (l *graphLayout) addTitle() {
+	label, ok := l.graph.attrs["label"]
+	if !ok || label.text == "" {
+		return
+	}
+	style := nodeStyle(l.graph.attrs)
+	if label.html {
+		if box, ok := parseHTMLLabel(label.text, style).(*textBox); ok {
+			l.title = box.lines
+		}
+	} else {
+		l.title = plainLines(label.text, style)
+	}
+	loc := strings.ToLower(l.graph.attrs.get("labelloc", "b"))
+	l.titleTop = loc == "t" || loc == "top"
+
+	w, h := linesSize(l.title)
+	h += 8
+	if l.titleTop {
+		for _, node := range l.nodes {
+			node.rect.y += h
+			node.label.arrange(node.rect.x, node.rect.y, node.rect.w, node.rect.h)
+		}
+	}
+	l.height += h
+	if w+16 > l.width {
+		shift := (w + 16 - l.width) / 2
+		for _, node := range l.nodes {
+			node.rect.x += shift
+			node.label.arrange(node.rect.x, node.rect.y, node.rect.w, node.rect.h)
+		}
+		l.width = w + 16
+	}
+}
+
+// ============================================================================
+// DIBUJO DEL GRAFO
+// ============================================================================
+
+func isInvisible(attrs dotAttrs) bool {
+	return strings.Contains(strings.ToLower(attrs.get("style", "")), "invis")
+}
+
+// draw dibuja el grafo ya distribuido
+func (l *graphLayout) draw(c canvas) {
+	for _, node := range l.nodes {
+		if isInvisible(node.node.attrs) {
+			continue
+		}
+		l.drawNode(c, node)
+	}
+	for _, e := range l.edges {
+		if isInvisible(e.edge.attrs) {
+			continue
+		}
+		l.drawEdge(c, e)
+	}
+	if len(l.title) > 0 {
+		_, h := linesSize(l.title)
+		y := l.height - h - 4
+		if l.titleTop {
+			y = 4
+		}
+		drawLines(c, l.title, 0, y, l.width, h, "", 0)
+	}
+}
+
+func (l *graphLayout) drawNode(c canvas, node *layoutNode) {
+	attrs := node.node.attrs
+	style := strings.ToLower(attrs.get("style", ""))
+	stroke := attrs.get("color", "black")
+	fill := ""
+	if strings.Contains(style, "filled") {
+		fill = attrs.get("fillcolor", attrs.get("color", "lightgray"))
+	}
+	width := 1.0
+	if strings.Contains(style, "bold") {
+		width = 2
+	}
+	r := node.rect
+
+	switch node.shape {
+	case "plaintext", "plain", "none":
+		if fill != "" {
+			c.rect(r.x, r.y, r.w, r.h, fill, "", 0)
+		}
+	case "record", "mrecord", "box", "rect", "rectangle", "square":
+		c.rect(r.x, r.y, r.w, r.h, fill, stroke, width)
+		if record, ok := node.label.(*recordField); ok {
+			record.stroke = stroke
+		}
+	default:
+		const segments = 48
+		points := make([]point, segments)
+		center := r.center()
+		for i := range points {
+			angle := 2 * math.Pi * float64(i) / segments
+			points[i] = point{center.x + r.w/2*math.Cos(angle), center.y + r.h/2*math.Sin(angle)}
+		}
+		c.polygon(points, fill, stroke)
+	}
+	node.label.draw(c)
+}
+
+// edgeEnds calcula los puntos de salida y llegada de una arista sobre los
+// bordes de los nodos (o de las celdas de sus puertos)
+func (l *graphLayout) edgeEnds(e layoutEdge) (point, point, bool) {
+	from, to := l.nodes[e.from], l.nodes[e.to]
+	source, target := from.rect, to.rect
+	if rect, ok := from.label.port(e.edge.fromPort); ok {
+		source = rect
+	}
+	if rect, ok := to.label.port(e.edge.toPort); ok {
+		target = rect
+	}
+	// Con puerto, la arista sale del borde del nodo a la altura de la celda
+	if e.edge.fromPort != "" {
+		if l.horizontal {
+			source.x, source.w = from.rect.x, from.rect.w
+		} else {
+			source.y, source.h = from.rect.y, from.rect.h
+		}
+	}
+	if e.edge.toPort != "" {
+		if l.horizontal {
+			target.x, target.w = to.rect.x, to.rect.w
+		} else {
+			target.y, target.h = to.rect.y, to.rect.h
+		}
+	}
+
+	horizontal := l.horizontal
+	if from.rank == to.rank {
+		horizontal = !horizontal
+	}
+	sc, tc := source.center(), target.center()
+	if horizontal {
+		if tc.x >= sc.x {
+			return point{source.x + source.w, sc.y}, point{target.x, tc.y}, true
+		}
+		return point{source.x, sc.y}, point{target.x + target.w, tc.y}, true
+	}
+	if tc.y >= sc.y {
+		return point{sc.x, source.y + source.h}, point{tc.x, target.y}, false
+	}
+	return point{sc.x, source.y}, point{tc.x, target.y + target.h}, false
+}
+
+func (l *graphLayout) drawEdge(c canvas, e layoutEdge) {
+	attrs := e.edge.attrs
+	stroke := attrs.get("color", "black")
+	dashed := strings.Contains(strings.ToLower(attrs.get("style", "")), "dash") ||
+		strings.Contains(strings.ToLower(attrs.get("style", "")), "dot")
+	start, end, horizontal := l.edgeEnds(e)
+
+	arrow := strings.ToLower(attrs.get("arrowhead", "normal")) != "none" && strings.ToLower(attrs.get("dir", "forward")) != "none"
+	const arrowLength, arrowWidth = 9.0, 3.5
+	tip := end
+	if arrow {
+		dx, dy := end.x-start.x, end.y-start.y
+		if horizontal {
+			dx, dy = math.Copysign(1, dx), 0
+		} else {
+			dx, dy = 0, math.Copysign(1, dy)
+		}
+		end = point{end.x - dx*arrowLength, end.y - dy*arrowLength}
+		normal := point{-dy, dx}
+		c.polygon([]point{
+			tip,
+			{end.x + normal.x*arrowWidth, end.y + normal.y*arrowWidth},
+			{end.x - normal.x*arrowWidth, end.y - normal.y*arrowWidth},
+		}, stroke, stroke)
+	}
+
+	var p1, p2 point
+	if horizontal {
+		mid := (start.x + end.x) / 2
+		p1, p2 = point{mid, start.y}, point{mid, end.y}
+	} else {
+		mid := (start.y + end.y) / 2
+		p1, p2 = point{start.x, mid}, point{end.x, mid}
+	}
+	c.bezier(start, p1, p2, end, stroke, dashed)
+
+	if label := attrs.get("label", ""); label != "" {
+		style := nodeStyle(attrs)
+		lines := plainLines(label, style)
+		w, h := linesSize(lines)
+		middle := bezierPoint(start, p1, p2, end, 0.5)
+		drawLines(c, lines, middle.x+4, middle.y-h/2, w, h, "left", 0)
+	}
+}
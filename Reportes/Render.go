@@ -0,0 +1,160 @@
+package Reportes
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ============================================================================
+// GENERACIÓN DE IMÁGENES DE LOS REPORTES
+// ============================================================================
+
+// Renderer indica con qué se dibujan las imágenes de los reportes
+type Renderer string
+
+const (
+	// RendererNative dibuja el DOT dentro del proceso, sin programas externos
+	RendererNative Renderer = "native"
+	// RendererGraphviz ejecuta el binario dot de Graphviz
+	RendererGraphviz Renderer = "graphviz"
+)
+
+// ParseRenderer valida el nombre de un renderizador; vacío es el nativo
+func ParseRenderer(value string) (Renderer, error) {
+	switch Renderer(strings.ToLower(strings.TrimSpace(value))) {
+	case "", RendererNative:
+		return RendererNative, nil
+	case RendererGraphviz:
+		return RendererGraphviz, nil
+	}
+	return "", fmt.Errorf("renderizador '%s' no válido (use native o graphviz)", value)
+}
+
+// imageFormats son las extensiones de imagen que se respetan en -path
+var imageFormats = map[string]string{
+	".svg":  "svg",
+	".pdf":  "pdf",
+	".png":  "png",
+	".jpg":  "jpg",
+	".jpeg": "jpg",
+}
+
+// imageFormat devuelve el formato de una ruta de imagen, o "" si la
+// extensión no es de imagen
+func imageFormat(path string) string {
+	return imageFormats[strings.ToLower(filepath.Ext(path))]
+}
+
+// renderImage dibuja el archivo DOT en imagePath con el formato que indica su
+// extensión. Graphviz solo se usa si se pidió de forma explícita.
+func renderImage(dotPath string, imagePath string, renderer Renderer) error {
+	source, err := os.ReadFile(dotPath)
+	if err != nil {
+		return fmt.Errorf("el archivo DOT no existe: %s", dotPath)
+	}
+	format := imageFormat(imagePath)
+	if format == "" {
+		return fmt.Errorf("formato de imagen no soportado: %s", filepath.Ext(imagePath))
+	}
+
+	if renderer == RendererGraphviz {
+		cmd := exec.Command("dot", "-T"+format, dotPath, "-o", imagePath)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("error ejecutando Graphviz (sudo apt install graphviz): %v\nOutput: %s", err, string(output))
+		}
+	} else if err := renderDot(string(source), format, imagePath); err != nil {
+		return err
+	}
+
+	// Verificar que se creó la imagen
+	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
+		return fmt.Errorf("la imagen no se generó correctamente: %s", imagePath)
+	}
+	return nil
+}
+
+// renderDot distribuye y dibuja un grafo DOT con el renderizador nativo
+func renderDot(source string, format string, imagePath string) error {
+	graph, err := parseDot(source)
+	if err != nil {
+		return fmt.Errorf("error leyendo el DOT: %v", err)
+	}
+	layout := layoutGraph(graph)
+
+	surface, err := newCanvas(format, layout.width, layout.height)
+	if err != nil {
+		return err
+	}
+	layout.draw(surface)
+
+	output, err := os.Create(imagePath)
+	if err != nil {
+		return fmt.Errorf("error creando la imagen: %v", err)
+	}
+	defer output.Close()
+	if err := surface.encode(output); err != nil {
+		return fmt.Errorf("error escribiendo la imagen: %v", err)
+	}
+	return nil
+}
+
+// textDotContent arma un grafo con un solo nodo de texto monoespaciado
+// alineado a la izquierda, para dibujar los reportes de texto como imagen
+func textDotContent(text string) string {
+	var label strings.Builder
+	for _, r := range strings.TrimRight(text, "\n") {
+		switch {
+		case r == '\n':
+			label.WriteString("\\l")
+		case r == '\\' || r == '"':
+			label.WriteRune('\\')
+			label.WriteRune(r)
+		case r == '\t':
+			label.WriteString("    ")
+		case r < 0x20 || r == 0x7F:
+			label.WriteRune('.')
+		default:
+			label.WriteRune(r)
+		}
+	}
+	label.WriteString("\\l")
+
+	var content strings.Builder
+	content.WriteString("digraph TextReport {\n")
+	content.WriteString("    graph [pad=\"0.2\"];\n")
+	content.WriteString("    node [shape=plaintext, fontname=\"Courier\", fontsize=\"12\"];\n")
+	content.WriteString("    text [label=\"" + label.String() + "\"];\n")
+	content.WriteString("}\n")
+	return content.String()
+}
+
+// writeTextReport escribe un reporte de texto. Si la ruta tiene extensión de
+// imagen el texto se dibuja en esa imagen y se guarda junto a ella como .txt;
+// con cualquier otra extensión se escribe el texto tal cual.
+func writeTextReport(out io.Writer, userOutputPath string, text string, renderer Renderer) error {
+	if err := createOutputDirectory(out, userOutputPath); err != nil {
+		return err
+	}
+	if imageFormat(userOutputPath) == "" {
+		return writeReportFile(userOutputPath, text)
+	}
+
+	basePath := strings.TrimSuffix(userOutputPath, filepath.Ext(userOutputPath))
+	textPath, dotPath := basePath+".txt", basePath+".dot"
+	if err := writeReportFile(textPath, text); err != nil {
+		return err
+	}
+	if err := writeReportFile(dotPath, textDotContent(text)); err != nil {
+		return err
+	}
+	if err := renderImage(dotPath, userOutputPath, renderer); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "  - Archivo de texto: %s\n", textPath)
+	return nil
+}
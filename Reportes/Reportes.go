@@ -2,30 +2,32 @@ package Reportes
 
 import (
 	"proyecto1/DiskManagement"
+	"proyecto1/FileSystem"
 	"proyecto1/Structs"
 	"proyecto1/Utilities"
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
 
 // GenerateMBRReport genera el reporte MBR en formato Graphviz DOT e imagen
-func GenerateMBRReport(userOutputPath string, partitionID string) error {
-	fmt.Println("=== GENERANDO REPORTE MBR ===")
-	fmt.Printf("Ruta de salida especificada: %s\n", userOutputPath)
-	fmt.Printf("ID de partición: %s\n", partitionID)
+func GenerateMBRReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
+	fmt.Fprintln(out, "=== GENERANDO REPORTE MBR ===")
+	fmt.Fprintf(out, "Ruta de salida especificada: %s\n", userOutputPath)
+	fmt.Fprintf(out, "ID de partición: %s\n", partitionID)
 	
 	// Buscar la partición montada para obtener la ruta del disco
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("la partición con ID '%s' no está montada", partitionID)
 	}
 
 	diskPath := mountedPartition.Path
-	fmt.Printf("Ruta del disco: %s\n", diskPath)
+	fmt.Fprintf(out, "Ruta del disco: %s\n", diskPath)
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(diskPath)
@@ -42,38 +44,37 @@ func GenerateMBRReport(userOutputPath string, partitionID string) error {
 
 	// Usar la ruta exacta especificada por el usuario
 	finalDotPath, finalImagePath := processUserPath(userOutputPath)
-	fmt.Printf("Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "Archivo imagen: %s\n", finalImagePath)
 
 	// Crear directorio de salida si no existe
-	if err := createOutputDirectory(finalDotPath); err != nil {
+	if err := createOutputDirectory(out, finalDotPath); err != nil {
 		return fmt.Errorf("error creando directorio de salida: %v", err)
 	}
 
 	// Generar contenido del reporte en formato DOT
-	dotContent := generateMBRDotContent(file, &mbr)
+	dotContent := generateMBRDotContent(out, file, &mbr)
 
 	// Escribir archivo DOT
 	if err := writeReportFile(finalDotPath, dotContent); err != nil {
 		return fmt.Errorf("error escribiendo archivo DOT: %v", err)
 	}
 
-	// Generar imagen usando Graphviz
-	if err := generateGraphvizImage(finalDotPath, finalImagePath); err != nil {
-		fmt.Printf("Advertencia: No se pudo generar la imagen: %v\n", err)
-		fmt.Println("Asegúrate de tener Graphviz instalado (sudo apt install graphviz)")
+	// Generar la imagen con el renderizador elegido
+	if err := renderImage(finalDotPath, finalImagePath, renderer); err != nil {
+		fmt.Fprintf(out, "Advertencia: No se pudo generar la imagen: %v\n", err)
 	} else {
-		fmt.Printf("✓ Imagen generada: %s\n", finalImagePath)
+		fmt.Fprintf(out, "✓ Imagen generada: %s\n", finalImagePath)
 	}
 
-	fmt.Printf("✓ Reporte MBR generado exitosamente\n")
-	fmt.Printf("  - Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("  - Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "✓ Reporte MBR generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "  - Archivo imagen: %s\n", finalImagePath)
 	return nil
 }
 
 // generateMBRDotContent genera el contenido del reporte MBR en formato DOT
-func generateMBRDotContent(file *os.File, mbr *Structs.MBR) string {
+func generateMBRDotContent(out io.Writer, file *os.File, mbr *Structs.MBR) string {
 	var content strings.Builder
 
 	// Encabezado del archivo DOT
@@ -159,7 +160,7 @@ func generateMBRDotContent(file *os.File, mbr *Structs.MBR) string {
 
 	// Si hay partición extendida, mostrar las particiones lógicas (EBRs)
 	if extendedPartitionIndex != -1 {
-		ebrContent := generateEBRContent(file, &mbr.Partitions[extendedPartitionIndex])
+		ebrContent := generateEBRContent(out, file, &mbr.Partitions[extendedPartitionIndex])
 		content.WriteString(ebrContent)
 		
 		// Conexión visual entre particiones y EBRs
@@ -176,7 +177,7 @@ func generateMBRDotContent(file *os.File, mbr *Structs.MBR) string {
 }
 
 // generateEBRContent genera el contenido de los EBRs (particiones lógicas)
-func generateEBRContent(file *os.File, extendedPartition *Structs.Partition) string {
+func generateEBRContent(out io.Writer, file *os.File, extendedPartition *Structs.Partition) string {
 	var content strings.Builder
 
 	// Tabla de EBRs
@@ -199,7 +200,7 @@ func generateEBRContent(file *os.File, extendedPartition *Structs.Partition) str
 	for {
 		var currentEBR Structs.EBR
 		if err := Utilities.ReadObject(file, &currentEBR, int64(currentEBRPos)); err != nil {
-			fmt.Printf("Error leyendo EBR en posición %d: %v\n", currentEBRPos, err)
+			fmt.Fprintf(out, "Error leyendo EBR en posición %d: %v\n", currentEBRPos, err)
 			break
 		}
 
@@ -277,14 +278,14 @@ func getStatusText(statusCode string) string {
 	}
 }
 
-func createOutputDirectory(outputPath string) error {
+func createOutputDirectory(out io.Writer, outputPath string) error {
 	dir := filepath.Dir(outputPath)
 	if dir != "." && dir != "" {
 		if _, err := os.Stat(dir); os.IsNotExist(err) {
 			if err := os.MkdirAll(dir, os.ModePerm); err != nil {
 				return fmt.Errorf("error creando directorio %s: %v", dir, err)
 			}
-			fmt.Printf("Directorio creado: %s\n", dir)
+			fmt.Fprintf(out, "Directorio creado: %s\n", dir)
 		}
 	}
 	return nil
@@ -301,59 +302,40 @@ func writeReportFile(outputPath string, content string) error {
 	return err
 }
 
-// processUserPath procesa la ruta del usuario y genera siempre rutas .dot y .jpg
+// processUserPath procesa la ruta del usuario y genera las rutas del DOT y de
+// la imagen. Se respeta la extensión si es de imagen (.svg, .pdf, .png, .jpg);
+// si no tiene o no se reconoce, la imagen es .jpg.
 func processUserPath(userOutputPath string) (string, string) {
 	// Limpiar la ruta del usuario (remover comillas si las tiene)
 	cleanPath := strings.Trim(userOutputPath, "\"")
 	
 	// Eliminar extensión si la tuviera
-	basePath := strings.TrimSuffix(cleanPath, filepath.Ext(cleanPath))
+	extension := filepath.Ext(cleanPath)
+	basePath := strings.TrimSuffix(cleanPath, extension)
 	
-	// Siempre generar .dot y .jpg
 	dotPath := basePath + ".dot"
 	imagePath := basePath + ".jpg"
-	
-	return dotPath, imagePath
-}
-
-// generateGraphvizImage genera siempre una imagen JPG desde un archivo DOT
-func generateGraphvizImage(dotPath string, imagePath string) error {
-	// Verificar que el archivo DOT existe
-	if _, err := os.Stat(dotPath); os.IsNotExist(err) {
-		return fmt.Errorf("el archivo DOT no existe: %s", dotPath)
+	if imageFormat(cleanPath) != "" {
+		imagePath = cleanPath
 	}
 	
-	// Siempre JPG
-	cmd := exec.Command("dot", "-Tjpg", dotPath, "-o", imagePath)
-	
-	// Ejecutar el comando
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("error ejecutando Graphviz: %v\nOutput: %s", err, string(output))
-	}
-	
-	// Verificar que se creó la imagen
-	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
-		return fmt.Errorf("la imagen no se generó correctamente: %s", imagePath)
-	}
-	
-	return nil
+	return dotPath, imagePath
 }
 
 // GenerateDiskReport genera el reporte DISK en formato Graphviz DOT e imagen
-func GenerateDiskReport(userOutputPath string, partitionID string) error {
-	fmt.Println("=== GENERANDO REPORTE DISK ===")
-	fmt.Printf("Ruta de salida especificada: %s\n", userOutputPath)
-	fmt.Printf("ID de partición: %s\n", partitionID)
+func GenerateDiskReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
+	fmt.Fprintln(out, "=== GENERANDO REPORTE DISK ===")
+	fmt.Fprintf(out, "Ruta de salida especificada: %s\n", userOutputPath)
+	fmt.Fprintf(out, "ID de partición: %s\n", partitionID)
 	
 	// Buscar la partición montada para obtener la ruta del disco
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("la partición con ID '%s' no está montada", partitionID)
 	}
 
 	diskPath := mountedPartition.Path
-	fmt.Printf("Ruta del disco: %s\n", diskPath)
+	fmt.Fprintf(out, "Ruta del disco: %s\n", diskPath)
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(diskPath)
@@ -373,11 +355,11 @@ func GenerateDiskReport(userOutputPath string, partitionID string) error {
 
 	// Usar la ruta exacta especificada por el usuario
 	finalDotPath, finalImagePath := processUserPath(userOutputPath)
-	fmt.Printf("Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "Archivo imagen: %s\n", finalImagePath)
 
 	// Crear directorio de salida si no existe
-	if err := createOutputDirectory(finalDotPath); err != nil {
+	if err := createOutputDirectory(out, finalDotPath); err != nil {
 		return fmt.Errorf("error creando directorio de salida: %v", err)
 	}
 
@@ -389,17 +371,16 @@ func GenerateDiskReport(userOutputPath string, partitionID string) error {
 		return fmt.Errorf("error escribiendo archivo DOT: %v", err)
 	}
 
-	// Generar imagen usando Graphviz
-	if err := generateGraphvizImage(finalDotPath, finalImagePath); err != nil {
-		fmt.Printf("Advertencia: No se pudo generar la imagen: %v\n", err)
-		fmt.Println("Asegúrate de tener Graphviz instalado (sudo apt install graphviz)")
+	// Generar la imagen con el renderizador elegido
+	if err := renderImage(finalDotPath, finalImagePath, renderer); err != nil {
+		fmt.Fprintf(out, "Advertencia: No se pudo generar la imagen: %v\n", err)
 	} else {
-		fmt.Printf("✓ Imagen generada: %s\n", finalImagePath)
+		fmt.Fprintf(out, "✓ Imagen generada: %s\n", finalImagePath)
 	}
 
-	fmt.Printf("✓ Reporte DISK generado exitosamente\n")
-	fmt.Printf("  - Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("  - Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "✓ Reporte DISK generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "  - Archivo imagen: %s\n", finalImagePath)
 	return nil
 }
 
@@ -442,13 +423,13 @@ func generateDiskDotContent(file *os.File, mbr *Structs.MBR, diskSize int64) str
 
 // DiskSegment representa un segmento del disco (partición o espacio libre)
 type DiskSegment struct {
-	Type        string  // "MBR", "Primary", "Extended", "Logical", "Free"
-	Name        string  // Nombre de la partición o descripción
-	Start       int32   // Inicio del segmento
-	Size        int32   // Tamaño del segmento
-	Percentage  float64 // Porcentaje del disco total
-	IsContainer bool    // true si es un contenedor (partición extendida)
-	Children    []DiskSegment // Segmentos hijos (para particiones extendidas)
+	Type        string        `json:"type"`               // "MBR", "Primary", "Extended", "Logical", "Free"
+	Name        string        `json:"name"`               // Nombre de la partición o descripción
+	Start       int32         `json:"start"`              // Inicio del segmento
+	Size        int32         `json:"size"`               // Tamaño del segmento
+	Percentage  float64       `json:"percentage"`         // Porcentaje del disco total
+	IsContainer bool          `json:"is_container"`       // true si es un contenedor (partición extendida)
+	Children    []DiskSegment `json:"children,omitempty"` // Segmentos hijos (para particiones extendidas)
 }
 
 // calculateDiskStructure calcula la estructura del disco y los porcentajes
@@ -557,7 +538,8 @@ func processExtendedPartition(file *os.File, extPartition *Structs.Partition, di
 	
 	// Navegar por la lista de EBRs
 	currentEBRPos := extPartition.Start
-	extendedStart := extPartition.Start
+	extendedEnd := extPartition.Start + extPartition.Size
+	lastPos := extPartition.Start // Fin de lo ocupado hasta ahora
 	
 	for {
 		var currentEBR Structs.EBR
@@ -565,14 +547,13 @@ func processExtendedPartition(file *os.File, extPartition *Structs.Partition, di
 			break
 		}
 
-		// Si hay espacio libre antes de esta partición lógica
-		if len(logicalSegments) == 0 && currentEBR.Part_start > currentEBRPos {
-			// Espacio libre inicial
-			freeSize := currentEBR.Part_start - currentEBRPos
+		// Si hay espacio libre antes de este EBR (hueco de una lógica eliminada)
+		if currentEBRPos > lastPos {
+			freeSize := currentEBRPos - lastPos
 			logicalSegments = append(logicalSegments, DiskSegment{
 				Type:       "Free",
 				Name:       "Libre",
-				Start:      currentEBRPos,
+				Start:      lastPos,
 				Size:       freeSize,
 				Percentage: float64(freeSize) / float64(diskSize) * 100,
 			})
@@ -587,6 +568,7 @@ func processExtendedPartition(file *os.File, extPartition *Structs.Partition, di
 			Size:       ebrSize,
 			Percentage: float64(ebrSize) / float64(diskSize) * 100,
 		})
+		lastPos = currentEBRPos + ebrSize
 
 		// Si el EBR tiene una partición lógica válida
 		if currentEBR.Part_size > 0 {
@@ -598,28 +580,29 @@ func processExtendedPartition(file *os.File, extPartition *Structs.Partition, di
 				Size:       currentEBR.Part_size,
 				Percentage: float64(currentEBR.Part_size) / float64(diskSize) * 100,
 			})
+			lastPos = currentEBR.Part_start + currentEBR.Part_size
 		}
 
-		// Si no hay siguiente EBR, agregar espacio libre restante
+		// Si no hay siguiente EBR, terminar
 		if currentEBR.Part_next == -1 {
-			lastPos := currentEBR.Part_start + currentEBR.Part_size
-			extendedEnd := extendedStart + extPartition.Size
-			if lastPos < extendedEnd {
-				freeSize := extendedEnd - lastPos
-				logicalSegments = append(logicalSegments, DiskSegment{
-					Type:       "Free",
-					Name:       "Libre",
-					Start:      lastPos,
-					Size:       freeSize,
-					Percentage: float64(freeSize) / float64(diskSize) * 100,
-				})
-			}
 			break
 		}
 
 		currentEBRPos = currentEBR.Part_next
 	}
 
+	// Agregar espacio libre restante al final de la extendida
+	if lastPos < extendedEnd {
+		freeSize := extendedEnd - lastPos
+		logicalSegments = append(logicalSegments, DiskSegment{
+			Type:       "Free",
+			Name:       "Libre",
+			Start:      lastPos,
+			Size:       freeSize,
+			Percentage: float64(freeSize) / float64(diskSize) * 100,
+		})
+	}
+
 	return logicalSegments
 }
 
@@ -649,19 +632,19 @@ func buildDiskLabel(segments []DiskSegment) string {
 }
 
 // GenerateInodeReport genera el reporte de inodos en formato Graphviz DOT e imagen
-func GenerateInodeReport(userOutputPath string, partitionID string) error {
-	fmt.Println("=== GENERANDO REPORTE INODE ===")
-	fmt.Printf("Ruta de salida especificada: %s\n", userOutputPath)
-	fmt.Printf("ID de partición: %s\n", partitionID)
+func GenerateInodeReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
+	fmt.Fprintln(out, "=== GENERANDO REPORTE INODE ===")
+	fmt.Fprintf(out, "Ruta de salida especificada: %s\n", userOutputPath)
+	fmt.Fprintf(out, "ID de partición: %s\n", partitionID)
 	
 	// Buscar la partición montada para obtener la ruta del disco
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("la partición con ID '%s' no está montada", partitionID)
 	}
 
 	diskPath := mountedPartition.Path
-	fmt.Printf("Ruta del disco: %s\n", diskPath)
+	fmt.Fprintf(out, "Ruta del disco: %s\n", diskPath)
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(diskPath)
@@ -701,38 +684,37 @@ func GenerateInodeReport(userOutputPath string, partitionID string) error {
 
 	// Usar la ruta exacta especificada por el usuario
 	finalDotPath, finalImagePath := processUserPath(userOutputPath)
-	fmt.Printf("Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "Archivo imagen: %s\n", finalImagePath)
 
 	// Crear directorio de salida si no existe
-	if err := createOutputDirectory(finalDotPath); err != nil {
+	if err := createOutputDirectory(out, finalDotPath); err != nil {
 		return fmt.Errorf("error creando directorio de salida: %v", err)
 	}
 
 	// Generar contenido del reporte en formato DOT
-	dotContent := generateInodeDotContent(file, &superblock)
+	dotContent := generateInodeDotContent(out, file, &superblock)
 
 	// Escribir archivo DOT
 	if err := writeReportFile(finalDotPath, dotContent); err != nil {
 		return fmt.Errorf("error escribiendo archivo DOT: %v", err)
 	}
 
-	// Generar imagen usando Graphviz
-	if err := generateGraphvizImage(finalDotPath, finalImagePath); err != nil {
-		fmt.Printf("Advertencia: No se pudo generar la imagen: %v\n", err)
-		fmt.Println("Asegúrate de tener Graphviz instalado (sudo apt install graphviz)")
+	// Generar la imagen con el renderizador elegido
+	if err := renderImage(finalDotPath, finalImagePath, renderer); err != nil {
+		fmt.Fprintf(out, "Advertencia: No se pudo generar la imagen: %v\n", err)
 	} else {
-		fmt.Printf("✓ Imagen generada: %s\n", finalImagePath)
+		fmt.Fprintf(out, "✓ Imagen generada: %s\n", finalImagePath)
 	}
 
-	fmt.Printf("✓ Reporte INODE generado exitosamente\n")
-	fmt.Printf("  - Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("  - Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "✓ Reporte INODE generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "  - Archivo imagen: %s\n", finalImagePath)
 	return nil
 }
 
 // generateInodeDotContent genera el contenido del reporte INODE en formato DOT
-func generateInodeDotContent(file *os.File, superblock *Structs.Superblock) string {
+func generateInodeDotContent(out io.Writer, file *os.File, superblock *Structs.Superblock) string {
 	var content strings.Builder
 
 	// Encabezado del archivo DOT
@@ -790,9 +772,9 @@ func generateInodeDotContent(file *os.File, superblock *Structs.Superblock) stri
 		content.WriteString(fmt.Sprintf("            <TR><TD><B>UID</B></TD><TD>%d</TD></TR>\n", inode.I_uid))
 		content.WriteString(fmt.Sprintf("            <TR><TD><B>GID</B></TD><TD>%d</TD></TR>\n", inode.I_gid))
 		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tamaño</B></TD><TD>%d bytes</TD></TR>\n", inode.I_size))
-		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tiempo Acceso</B></TD><TD>%s</TD></TR>\n", cleanString(inode.I_atime[:])))
-		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tiempo Creación</B></TD><TD>%s</TD></TR>\n", cleanString(inode.I_ctime[:])))
-		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tiempo Modif.</B></TD><TD>%s</TD></TR>\n", cleanString(inode.I_mtime[:])))
+		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tiempo Acceso</B></TD><TD>%s</TD></TR>\n", Utilities.FormatTimestamp(cleanString(inode.I_atime[:]))))
+		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tiempo Creación</B></TD><TD>%s</TD></TR>\n", Utilities.FormatTimestamp(cleanString(inode.I_ctime[:]))))
+		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tiempo Modif.</B></TD><TD>%s</TD></TR>\n", Utilities.FormatTimestamp(cleanString(inode.I_mtime[:]))))
 		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tipo</B></TD><TD>%s</TD></TR>\n", inodeType))
 		content.WriteString(fmt.Sprintf("            <TR><TD><B>Permisos</B></TD><TD>%s</TD></TR>\n", cleanString(inode.I_perm[:])))
 		
@@ -808,41 +790,28 @@ func generateInodeDotContent(file *os.File, superblock *Structs.Superblock) stri
 			}
 		}
 		
-		// Puntero indirecto simple (12)
-		if len(inode.I_block) > 12 && inode.I_block[12] != -1 {
-			content.WriteString(fmt.Sprintf("            <TR><TD>Indirecto Simple</TD><TD>%d</TD></TR>\n", inode.I_block[12]))
-			
-			// Leer el bloque de punteros indirectos y mostrar algunos punteros
-			var indirectBlock Structs.Fileblock
-			indirectBlockPos := int64(superblock.S_block_start + inode.I_block[12]*superblock.S_block_size)
-			if err := Utilities.ReadObject(file, &indirectBlock, indirectBlockPos); err == nil {
-				indirectCount := 0
-				for k := 0; k < 16 && indirectCount < 5; k++ { // Mostrar solo los primeros 5 para no sobrecargar
-					var blockNumber int32
-					binary.Read(strings.NewReader(string(indirectBlock.B_content[k*4:(k+1)*4])), binary.LittleEndian, &blockNumber)
-					if blockNumber != -1 {
-						content.WriteString(fmt.Sprintf("            <TR><TD>  → Indirecto[%d]</TD><TD>%d</TD></TR>\n", k, blockNumber))
-						indirectCount++
-					}
-				}
-				if indirectCount == 5 {
-					content.WriteString("            <TR><TD COLSPAN=\"2\">  → ...</TD></TR>\n")
-				}
+		// Punteros indirectos: simple (12), doble (13) y triple (14)
+		indirectLabels := []string{"Indirecto Simple", "Indirecto Doble", "Indirecto Triple"}
+		indirectBlocks := 0
+		for level, label := range indirectLabels {
+			blockIndex := inode.I_block[12+level]
+			if blockIndex == -1 {
+				continue
 			}
+			content.WriteString(fmt.Sprintf("            <TR><TD>%s</TD><TD>%d</TD></TR>\n", label, blockIndex))
+			writeIndirectPointerRows(&content, file, superblock, blockIndex)
+			indirectBlocks++
 		}
-		
-		// Puntero indirecto doble (13) - si existe
-		if len(inode.I_block) > 13 && inode.I_block[13] != -1 {
-			content.WriteString(fmt.Sprintf("            <TR><TD>Indirecto Doble</TD><TD>%d</TD></TR>\n", inode.I_block[13]))
-		}
-		
-		// Puntero indirecto triple (14) - si existe
-		if len(inode.I_block) > 14 && inode.I_block[14] != -1 {
-			content.WriteString(fmt.Sprintf("            <TR><TD>Indirecto Triple</TD><TD>%d</TD></TR>\n", inode.I_block[14]))
+
+		// Total de bloques de datos y de apuntadores del archivo
+		if inodeType == "1" && indirectBlocks > 0 {
+			dataBlocks, pointerBlocks := FileSystem.FileBlocks(file, superblock, &inode)
+			content.WriteString(fmt.Sprintf("            <TR><TD>Bloques de datos</TD><TD>%d</TD></TR>\n", len(dataBlocks)))
+			content.WriteString(fmt.Sprintf("            <TR><TD>Bloques de apuntadores</TD><TD>%d</TD></TR>\n", len(pointerBlocks)))
 		}
 		
 		// Si no hay bloques asignados
-		if directBlocks == 0 && (len(inode.I_block) <= 12 || inode.I_block[12] == -1) {
+		if directBlocks == 0 && indirectBlocks == 0 {
 			content.WriteString("            <TR><TD COLSPAN=\"2\">Sin bloques asignados</TD></TR>\n")
 		}
 		
@@ -898,24 +867,24 @@ func generateInodeDotContent(file *os.File, superblock *Structs.Superblock) stri
 	// Cierre del archivo DOT
 	content.WriteString("}\n")
 
-	fmt.Printf("Inodos procesados: %d utilizados de %d totales\n", usedInodes, superblock.S_inodes_count)
+	fmt.Fprintf(out, "Inodos procesados: %d utilizados de %d totales\n", usedInodes, superblock.S_inodes_count)
 	return content.String()
 }
 
 // GenerateBlockReport genera el reporte de bloques en formato Graphviz DOT e imagen
-func GenerateBlockReport(userOutputPath string, partitionID string) error {
-	fmt.Println("=== GENERANDO REPORTE BLOCK ===")
-	fmt.Printf("Ruta de salida especificada: %s\n", userOutputPath)
-	fmt.Printf("ID de partición: %s\n", partitionID)
+func GenerateBlockReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
+	fmt.Fprintln(out, "=== GENERANDO REPORTE BLOCK ===")
+	fmt.Fprintf(out, "Ruta de salida especificada: %s\n", userOutputPath)
+	fmt.Fprintf(out, "ID de partición: %s\n", partitionID)
 	
 	// Buscar la partición montada para obtener la ruta del disco
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("la partición con ID '%s' no está montada", partitionID)
 	}
 
 	diskPath := mountedPartition.Path
-	fmt.Printf("Ruta del disco: %s\n", diskPath)
+	fmt.Fprintf(out, "Ruta del disco: %s\n", diskPath)
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(diskPath)
@@ -955,38 +924,59 @@ func GenerateBlockReport(userOutputPath string, partitionID string) error {
 
 	// Usar la ruta exacta especificada por el usuario
 	finalDotPath, finalImagePath := processUserPath(userOutputPath)
-	fmt.Printf("Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "Archivo imagen: %s\n", finalImagePath)
 
 	// Crear directorio de salida si no existe
-	if err := createOutputDirectory(finalDotPath); err != nil {
+	if err := createOutputDirectory(out, finalDotPath); err != nil {
 		return fmt.Errorf("error creando directorio de salida: %v", err)
 	}
 
 	// Generar contenido del reporte en formato DOT
-	dotContent := generateBlockDotContent(file, &superblock)
+	dotContent := generateBlockDotContent(out, file, &superblock)
 
 	// Escribir archivo DOT
 	if err := writeReportFile(finalDotPath, dotContent); err != nil {
 		return fmt.Errorf("error escribiendo archivo DOT: %v", err)
 	}
 
-	// Generar imagen usando Graphviz
-	if err := generateGraphvizImage(finalDotPath, finalImagePath); err != nil {
-		fmt.Printf("Advertencia: No se pudo generar la imagen: %v\n", err)
-		fmt.Println("Asegúrate de tener Graphviz instalado (sudo apt install graphviz)")
+	// Generar la imagen con el renderizador elegido
+	if err := renderImage(finalDotPath, finalImagePath, renderer); err != nil {
+		fmt.Fprintf(out, "Advertencia: No se pudo generar la imagen: %v\n", err)
 	} else {
-		fmt.Printf("✓ Imagen generada: %s\n", finalImagePath)
+		fmt.Fprintf(out, "✓ Imagen generada: %s\n", finalImagePath)
 	}
 
-	fmt.Printf("✓ Reporte BLOCK generado exitosamente\n")
-	fmt.Printf("  - Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("  - Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "✓ Reporte BLOCK generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "  - Archivo imagen: %s\n", finalImagePath)
 	return nil
 }
 
+// writeIndirectPointerRows agrega las filas con los primeros apuntadores de un
+// bloque de apuntadores (indirecto simple, doble o triple)
+func writeIndirectPointerRows(content *strings.Builder, file *os.File, superblock *Structs.Superblock, blockIndex int32) {
+	var pointerBlock Structs.Pointerblock
+	if err := Utilities.ReadObject(file, &pointerBlock, int64(superblock.S_block_start+blockIndex*superblock.S_block_size)); err != nil {
+		return
+	}
+
+	shown := 0
+	for k, pointer := range pointerBlock.B_pointers {
+		if pointer == -1 {
+			continue
+		}
+		if shown == 5 { // Mostrar solo los primeros 5 para no sobrecargar
+			content.WriteString("            <TR><TD COLSPAN=\"2\">  → ...</TD></TR>\n")
+			return
+		}
+		content.WriteString(fmt.Sprintf("            <TR><TD>  → Apuntador[%d]</TD><TD>%d</TD></TR>\n", k, pointer))
+		shown++
+	}
+}
+
 // generateBlockDotContent genera el contenido del reporte BLOCK en formato DOT
-func generateBlockDotContent(file *os.File, superblock *Structs.Superblock) string {
+func generateBlockDotContent(out io.Writer, file *os.File, superblock *Structs.Superblock) string {
 	var content strings.Builder
 
 	// Encabezado del archivo DOT
@@ -1003,6 +993,11 @@ func generateBlockDotContent(file *os.File, superblock *Structs.Superblock) stri
 
 	usedBlocks := 0
 	blocksPerRow := 6 // Mostrar 6 bloques por fila para mejor organización
+
+	// Bloques de apuntadores de los archivos (no se pueden distinguir solo por su contenido)
+	pointerBlocks := collectPointerBlockLevels(file, superblock)
+	// Bloques con los nombres largos de las entradas de carpeta
+	nameBlocks := FileSystem.NameBlocks(file, superblock)
 	
 	// Iterar por todos los bloques para encontrar los utilizados
 	for i := int32(0); i < superblock.S_blocks_count; i++ {
@@ -1018,8 +1013,7 @@ func generateBlockDotContent(file *os.File, superblock *Structs.Superblock) stri
 		}
 
 		// Leer el bloque para determinar su tipo
-		blockPos := int64(superblock.S_block_start + i*superblock.S_block_size)
-		blockType, blockInfo := analyzeBlock(file, blockPos, i)
+		blockType, blockInfo := classifyBlock(file, superblock, i, pointerBlocks, nameBlocks)
 
 		usedBlocks++
 
@@ -1032,7 +1026,7 @@ func generateBlockDotContent(file *os.File, superblock *Structs.Superblock) stri
 		
 		content.WriteString(fmt.Sprintf("            <TR><TD COLSPAN=\"2\" BGCOLOR=\"%s\"><B>BLOQUE %d</B></TD></TR>\n", bgColor, i))
 		content.WriteString(fmt.Sprintf("            <TR><TD><B>Tipo</B></TD><TD>%s</TD></TR>\n", blockType))
-		content.WriteString(fmt.Sprintf("            <TR><TD><B>Info</B></TD><TD>%s</TD></TR>\n", blockInfo))
+		content.WriteString(fmt.Sprintf("            <TR><TD><B>Info</B></TD><TD>%s</TD></TR>\n", escapeHTML(blockInfo)))
 		
 		content.WriteString("        </TABLE>\n")
 		content.WriteString("    >];\n\n")
@@ -1101,12 +1095,91 @@ func generateBlockDotContent(file *os.File, superblock *Structs.Superblock) stri
 	// Cierre del archivo DOT
 	content.WriteString("}\n")
 
-	fmt.Printf("Bloques procesados: %d utilizados de %d totales\n", usedBlocks, superblock.S_blocks_count)
+	fmt.Fprintf(out, "Bloques procesados: %d utilizados de %d totales\n", usedBlocks, superblock.S_blocks_count)
 	return content.String()
 }
 
+// collectPointerBlockLevels recorre los inodos de archivo en uso y devuelve
+// sus bloques de apuntadores con el nivel de indirección (1, 2 o 3)
+func collectPointerBlockLevels(file *os.File, superblock *Structs.Superblock) map[int32]int {
+	levels := make(map[int32]int)
+	for i := int32(0); i < superblock.S_inodes_count; i++ {
+		var bitmapByte byte
+		if err := Utilities.ReadObject(file, &bitmapByte, int64(superblock.S_bm_inode_start+i)); err != nil || bitmapByte == 0 {
+			continue
+		}
+		var inode Structs.Inode
+		if err := Utilities.ReadObject(file, &inode, int64(superblock.S_inode_start+i*superblock.S_inode_size)); err != nil {
+			continue
+		}
+		if cleanString(inode.I_type[:]) != "1" {
+			continue
+		}
+		for level := 1; level <= 3; level++ {
+			collectPointerBlockLevel(file, superblock, inode.I_block[11+level], level, levels)
+		}
+	}
+	return levels
+}
+
+// collectPointerBlockLevel marca un bloque de apuntadores y, si es doble o
+// triple, los bloques de apuntadores que cuelgan de él
+func collectPointerBlockLevel(file *os.File, superblock *Structs.Superblock, blockIndex int32, level int, levels map[int32]int) {
+	if blockIndex < 0 || blockIndex >= superblock.S_blocks_count {
+		return
+	}
+	if _, seen := levels[blockIndex]; seen {
+		return
+	}
+	levels[blockIndex] = level
+	if level == 1 {
+		return
+	}
+
+	var pointerBlock Structs.Pointerblock
+	if err := Utilities.ReadObject(file, &pointerBlock, int64(superblock.S_block_start+blockIndex*superblock.S_block_size)); err != nil {
+		return
+	}
+	for _, pointer := range pointerBlock.B_pointers {
+		if pointer != -1 {
+			collectPointerBlockLevel(file, superblock, pointer, level-1, levels)
+		}
+	}
+}
+
+// describePointerBlock devuelve el tipo e información de un bloque de apuntadores
+func describePointerBlock(file *os.File, blockPos int64, level int) (string, string) {
+	var pointerBlock Structs.Pointerblock
+	if err := Utilities.ReadObject(file, &pointerBlock, blockPos); err != nil {
+		return "Error", "No se pudo leer"
+	}
+
+	var used []string
+	for _, pointer := range pointerBlock.B_pointers {
+		if pointer != -1 {
+			used = append(used, fmt.Sprintf("%d", pointer))
+		}
+	}
+	levelNames := map[int]string{1: "simple", 2: "doble", 3: "triple"}
+	return "Punteros", fmt.Sprintf("Indirecto %s: %d punteros (%s)", levelNames[level], len(used), strings.Join(used, ", "))
+}
+
+// classifyBlock determina el tipo e información de un bloque en uso. Los
+// bloques de apuntadores y de nombres largos no se distinguen por su
+// contenido, así que se reconocen con los mapas ya recolectados.
+func classifyBlock(file *os.File, superblock *Structs.Superblock, blockNum int32, pointerBlocks map[int32]int, nameBlocks map[int32]string) (string, string) {
+	blockPos := int64(superblock.S_block_start + blockNum*superblock.S_block_size)
+	if level, isPointer := pointerBlocks[blockNum]; isPointer {
+		return describePointerBlock(file, blockPos, level)
+	}
+	if name, isName := nameBlocks[blockNum]; isName {
+		return "Nombre", fmt.Sprintf("Nombre largo: '%s'", name)
+	}
+	return analyzeBlock(file, superblock, blockPos, blockNum)
+}
+
 // analyzeBlock analiza un bloque y determina su tipo y información básica
-func analyzeBlock(file *os.File, blockPos int64, blockNum int32) (string, string) {
+func analyzeBlock(file *os.File, superblock *Structs.Superblock, blockPos int64, blockNum int32) (string, string) {
 	// Leer los primeros bytes del bloque para determinar su tipo
 	var block Structs.Fileblock
 	if err := Utilities.ReadObject(file, &block, blockPos); err != nil {
@@ -1117,7 +1190,7 @@ func analyzeBlock(file *os.File, blockPos int64, blockNum int32) (string, string
 	nonNullBytes := 0
 	printableBytes := 0
 	
-	for i := 0; i < 64; i++ {
+	for i := 0; i < len(block.B_content); i++ {
 		if block.B_content[i] != 0 {
 			nonNullBytes++
 			if block.B_content[i] >= 32 && block.B_content[i] <= 126 {
@@ -1155,7 +1228,7 @@ func analyzeBlock(file *os.File, blockPos int64, blockNum int32) (string, string
 			hasValidName := false
 			for i := 0; i < validEntries && i < 4; i++ {
 				if folderBlock.B_content[i].B_inodo != -1 {
-					name := cleanString(folderBlock.B_content[i].B_name[:])
+					name := FileSystem.EntryName(file, superblock, folderBlock.B_content[i])
 					if name != "" && (name == "." || name == ".." || len(name) <= 12) {
 						hasValidName = true
 						break
@@ -1168,7 +1241,7 @@ func analyzeBlock(file *os.File, blockPos int64, blockNum int32) (string, string
 				// Mostrar el primer nombre válido
 				for i := 0; i < 4; i++ {
 					if folderBlock.B_content[i].B_inodo != -1 {
-						fileName := cleanString(folderBlock.B_content[i].B_name[:])
+						fileName := FileSystem.EntryName(file, superblock, folderBlock.B_content[i])
 						if fileName != "" {
 							dirInfo += fmt.Sprintf(" ('%s'...)", fileName)
 							break
@@ -1207,7 +1280,7 @@ func analyzeBlock(file *os.File, blockPos int64, blockNum int32) (string, string
 	var pointerBlock Structs.Pointerblock
 	if err := Utilities.ReadObject(file, &pointerBlock, blockPos); err == nil {
 		validPointers := 0
-		for i := 0; i < 16; i++ {
+		for i := 0; i < len(pointerBlock.B_pointers); i++ {
 			if pointerBlock.B_pointers[i] >= 0 && pointerBlock.B_pointers[i] < 100000 { // Rango razonable
 				validPointers++
 			}
@@ -1230,6 +1303,8 @@ func getBlockColor(blockType string) string {
 		return "#E3F2FD" // Azul claro
 	case "Punteros":
 		return "#F3E5F5" // Púrpura claro
+	case "Nombre":
+		return "#FFFDE7" // Amarillo claro
 	case "Vacío":
 		return "#F5F5F5" // Gris claro
 	case "Error":
@@ -1240,9 +1315,9 @@ func getBlockColor(blockType string) string {
 }
 
 // GenerateBitmapInodeReport genera el reporte del bitmap de inodos en formato de texto
-func GenerateBitmapInodeReport(userOutputPath string, partitionID string) error {
+func GenerateBitmapInodeReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("partición con ID %s no está montada", partitionID)
 	}
@@ -1274,20 +1349,17 @@ func GenerateBitmapInodeReport(userOutputPath string, partitionID string) error
 		return fmt.Errorf("error leyendo superblock: %v", err)
 	}
 
-	// Crear el archivo de salida
-	outputFile, err := os.Create(userOutputPath)
-	if err != nil {
-		return fmt.Errorf("error creando archivo de salida: %v", err)
-	}
-	defer outputFile.Close()
+	// Armar el texto del reporte; writeTextReport lo guarda como texto o
+	// como imagen según la extensión de la ruta
+	var report strings.Builder
 
 	// Escribir encabezado del reporte
-	fmt.Fprintf(outputFile, "=== REPORTE BITMAP DE INODOS ===\n")
-	fmt.Fprintf(outputFile, "Partición: %s\n", partitionID)
-	fmt.Fprintf(outputFile, "Total de inodos: %d\n", superblock.S_inodes_count)
-	fmt.Fprintf(outputFile, "Inodos libres: %d\n", superblock.S_free_inodes_count)
-	fmt.Fprintf(outputFile, "Inodos utilizados: %d\n", superblock.S_inodes_count-superblock.S_free_inodes_count)
-	fmt.Fprintf(outputFile, "====================================\n\n")
+	fmt.Fprintf(&report, "=== REPORTE BITMAP DE INODOS ===\n")
+	fmt.Fprintf(&report, "Partición: %s\n", partitionID)
+	fmt.Fprintf(&report, "Total de inodos: %d\n", superblock.S_inodes_count)
+	fmt.Fprintf(&report, "Inodos libres: %d\n", superblock.S_free_inodes_count)
+	fmt.Fprintf(&report, "Inodos utilizados: %d\n", superblock.S_inodes_count-superblock.S_free_inodes_count)
+	fmt.Fprintf(&report, "====================================\n\n")
 
 	// Leer y mostrar el bitmap de inodos
 
@@ -1301,13 +1373,13 @@ func GenerateBitmapInodeReport(userOutputPath string, partitionID string) error
 		}
 
 		// Escribir el bit (0 o 1)
-		fmt.Fprintf(outputFile, "%d", bitmapByte)
+		fmt.Fprintf(&report, "%d", bitmapByte)
 
 		bitCount++
 
 		// Después de 20 bits, hacer nueva línea
 		if bitCount%20 == 0 {
-			fmt.Fprintf(outputFile, "\n")
+			fmt.Fprintf(&report, "\n")
 			if i+1 < superblock.S_inodes_count {
 				lineCount++
 			}
@@ -1316,21 +1388,25 @@ func GenerateBitmapInodeReport(userOutputPath string, partitionID string) error
 
 	// Si la última línea no terminó, agregar salto de línea
 	if bitCount%20 != 0 {
-		fmt.Fprintf(outputFile, "\n")
+		fmt.Fprintf(&report, "\n")
+	}
+
+	if err := writeTextReport(out, userOutputPath, report.String(), renderer); err != nil {
+		return fmt.Errorf("error escribiendo el reporte: %v", err)
 	}
 
-	fmt.Printf("✓ Reporte BM_INODE generado exitosamente\n")
-	fmt.Printf("  - Archivo: %s\n", userOutputPath)
-	fmt.Printf("  - Total inodos: %d\n", superblock.S_inodes_count)
-	fmt.Printf("  - Inodos utilizados: %d\n", superblock.S_inodes_count-superblock.S_free_inodes_count)
+	fmt.Fprintf(out, "✓ Reporte BM_INODE generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo: %s\n", userOutputPath)
+	fmt.Fprintf(out, "  - Total inodos: %d\n", superblock.S_inodes_count)
+	fmt.Fprintf(out, "  - Inodos utilizados: %d\n", superblock.S_inodes_count-superblock.S_free_inodes_count)
 
 	return nil
 }
 
 // GenerateBitmapBlockReport genera el reporte del bitmap de bloques en formato de texto
-func GenerateBitmapBlockReport(userOutputPath string, partitionID string) error {
+func GenerateBitmapBlockReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("partición con ID %s no está montada", partitionID)
 	}
@@ -1362,23 +1438,20 @@ func GenerateBitmapBlockReport(userOutputPath string, partitionID string) error
 		return fmt.Errorf("error leyendo superblock: %v", err)
 	}
 
-	// Crear el archivo de salida
-	outputFile, err := os.Create(userOutputPath)
-	if err != nil {
-		return fmt.Errorf("error creando archivo de salida: %v", err)
-	}
-	defer outputFile.Close()
+	// Armar el texto del reporte; writeTextReport lo guarda como texto o
+	// como imagen según la extensión de la ruta
+	var report strings.Builder
 
 	// Escribir encabezado del reporte
-	fmt.Fprintf(outputFile, "=== REPORTE BITMAP DE BLOQUES ===\n")
-	fmt.Fprintf(outputFile, "Partición: %s\n", partitionID)
-	fmt.Fprintf(outputFile, "Total de bloques: %d\n", superblock.S_blocks_count)
-	fmt.Fprintf(outputFile, "Bloques libres: %d\n", superblock.S_free_blocks_count)
-	fmt.Fprintf(outputFile, "Bloques utilizados: %d\n", superblock.S_blocks_count-superblock.S_free_blocks_count)
-	fmt.Fprintf(outputFile, "====================================\n\n")
+	fmt.Fprintf(&report, "=== REPORTE BITMAP DE BLOQUES ===\n")
+	fmt.Fprintf(&report, "Partición: %s\n", partitionID)
+	fmt.Fprintf(&report, "Total de bloques: %d\n", superblock.S_blocks_count)
+	fmt.Fprintf(&report, "Bloques libres: %d\n", superblock.S_free_blocks_count)
+	fmt.Fprintf(&report, "Bloques utilizados: %d\n", superblock.S_blocks_count-superblock.S_free_blocks_count)
+	fmt.Fprintf(&report, "====================================\n\n")
 
 	// Leer y mostrar el bitmap de bloques
-	fmt.Fprintf(outputFile, "Bitmap de Bloques:\n")
+	fmt.Fprintf(&report, "Bitmap de Bloques:\n")
 
 	bitCount := 0
 	lineCount := 1
@@ -1390,13 +1463,13 @@ func GenerateBitmapBlockReport(userOutputPath string, partitionID string) error
 		}
 
 		// Escribir el bit (0 o 1)
-		fmt.Fprintf(outputFile, "%d", bitmapByte)
+		fmt.Fprintf(&report, "%d", bitmapByte)
 
 		bitCount++
 
 		// Después de 20 bits, hacer nueva línea
 		if bitCount%20 == 0 {
-			fmt.Fprintf(outputFile, "\n")
+			fmt.Fprintf(&report, "\n")
 			if i+1 < superblock.S_blocks_count {
 				lineCount++
 			}
@@ -1405,21 +1478,25 @@ func GenerateBitmapBlockReport(userOutputPath string, partitionID string) error
 
 	// Si la última línea no terminó, agregar salto de línea
 	if bitCount%20 != 0 {
-		fmt.Fprintf(outputFile, "\n")
+		fmt.Fprintf(&report, "\n")
 	}
 
-	fmt.Printf("✓ Reporte BM_BLOCK generado exitosamente\n")
-	fmt.Printf("  - Archivo: %s\n", userOutputPath)
-	fmt.Printf("  - Total bloques: %d\n", superblock.S_blocks_count)
-	fmt.Printf("  - Bloques utilizados: %d\n", superblock.S_blocks_count-superblock.S_free_blocks_count)
+	if err := writeTextReport(out, userOutputPath, report.String(), renderer); err != nil {
+		return fmt.Errorf("error escribiendo el reporte: %v", err)
+	}
+
+	fmt.Fprintf(out, "✓ Reporte BM_BLOCK generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo: %s\n", userOutputPath)
+	fmt.Fprintf(out, "  - Total bloques: %d\n", superblock.S_blocks_count)
+	fmt.Fprintf(out, "  - Bloques utilizados: %d\n", superblock.S_blocks_count-superblock.S_free_blocks_count)
 
 	return nil
 }
 
 // GenerateTreeReport genera el reporte del árbol completo del sistema EXT2
-func GenerateTreeReport(userOutputPath string, partitionID string) error {
+func GenerateTreeReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
 	// Obtener información de la partición montada
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("partición con ID %s no está montada", partitionID)
 	}
@@ -1475,25 +1552,24 @@ func GenerateTreeReport(userOutputPath string, partitionID string) error {
 	content.WriteString("}\n")
 
 	// Escribir archivo DOT
-	dotFilePath := strings.TrimSuffix(userOutputPath, filepath.Ext(userOutputPath)) + ".dot"
+	dotFilePath, imagePath := processUserPath(userOutputPath)
+	if err := createOutputDirectory(out, dotFilePath); err != nil {
+		return fmt.Errorf("error creando directorio de salida: %v", err)
+	}
 	if err := os.WriteFile(dotFilePath, []byte(content.String()), 0644); err != nil {
 		return fmt.Errorf("error escribiendo archivo DOT: %v", err)
 	}
 
-	// Generar imagen si Graphviz está instalado
-	if checkGraphvizInstalled() {
-		cmd := exec.Command("dot", "-Tjpg", dotFilePath, "-o", userOutputPath)
-		if err := cmd.Run(); err != nil {
-			fmt.Printf("Advertencia: No se pudo generar la imagen: %v\n", err)
-			fmt.Printf("Asegúrate de tener Graphviz instalado (sudo apt install graphviz)\n")
-		} else {
-			fmt.Printf("✓ Imagen generada: %s\n", userOutputPath)
-		}
+	// Generar la imagen con el renderizador elegido
+	if err := renderImage(dotFilePath, imagePath, renderer); err != nil {
+		fmt.Fprintf(out, "Advertencia: No se pudo generar la imagen: %v\n", err)
+	} else {
+		fmt.Fprintf(out, "✓ Imagen generada: %s\n", imagePath)
 	}
 
-	fmt.Printf("✓ Reporte TREE generado exitosamente\n")
-	fmt.Printf("  - Archivo DOT: %s\n", dotFilePath)
-	fmt.Printf("  - Archivo imagen: %s\n", userOutputPath)
+	fmt.Fprintf(out, "✓ Reporte TREE generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo DOT: %s\n", dotFilePath)
+	fmt.Fprintf(out, "  - Archivo imagen: %s\n", imagePath)
 
 	return nil
 }
@@ -1558,6 +1634,16 @@ func generateTreeFromInode(file *os.File, superblock *Structs.Superblock, inodeN
 		}
 	}
 
+	// Mostrar apuntadores indirectos del archivo (simple, doble y triple)
+	if !isDirectory {
+		for level := 1; level <= 3; level++ {
+			if inode.I_block[11+level] != -1 {
+				content.WriteString(fmt.Sprintf("                <tr><td>AI%d</td><td port='%d'>%d</td></tr>\n", level, portNum, inode.I_block[11+level]))
+				portNum++
+			}
+		}
+	}
+
 	content.WriteString("            </table>\n")
 	content.WriteString("        >\n")
 	content.WriteString("    ];\n\n")
@@ -1579,6 +1665,62 @@ func generateTreeFromInode(file *os.File, superblock *Structs.Superblock, inodeN
 			portNum++
 		}
 	}
+
+	// Procesar los bloques de apuntadores del archivo
+	if !isDirectory {
+		for level := 1; level <= 3; level++ {
+			blockNum := inode.I_block[11+level]
+			if blockNum == -1 {
+				continue
+			}
+			*connections = append(*connections, fmt.Sprintf("Inodo%d:%d -> Bloque%d:0;", inodeNum, portNum, blockNum))
+			generateTreeFromPointerBlock(file, superblock, blockNum, level, content, connections, visitedBlocks)
+			portNum++
+		}
+	}
+}
+
+// generateTreeFromPointerBlock procesa un bloque de apuntadores de un archivo;
+// en el nivel 1 sus apuntadores son bloques de archivo
+func generateTreeFromPointerBlock(file *os.File, superblock *Structs.Superblock, blockNum int32, level int, content *strings.Builder, connections *[]string, visitedBlocks map[int32]bool) {
+	if visitedBlocks[blockNum] {
+		return
+	}
+	visitedBlocks[blockNum] = true
+
+	// Leer bloque de apuntadores
+	var pointerBlock Structs.Pointerblock
+	blockPos := int64(superblock.S_block_start + blockNum*superblock.S_block_size)
+	if err := Utilities.ReadObject(file, &pointerBlock, blockPos); err != nil {
+		return
+	}
+
+	// Generar nodo del bloque
+	content.WriteString(fmt.Sprintf("    Bloque%d [\n", blockNum))
+	content.WriteString("        label=<\n")
+	content.WriteString("            <table border=\"0\" cellborder=\"1\" cellspacing=\"0\" bgcolor=\"lightgray\">\n")
+	content.WriteString(fmt.Sprintf("                <tr><td colspan=\"2\" port='0'><b>Bloque %d (apuntadores)</b></td></tr>\n", blockNum))
+	for k, pointer := range pointerBlock.B_pointers {
+		if pointer != -1 {
+			content.WriteString(fmt.Sprintf("                <tr><td>%d</td><td port='%d'>%d</td></tr>\n", k, k+1, pointer))
+		}
+	}
+	content.WriteString("            </table>\n")
+	content.WriteString("        >\n")
+	content.WriteString("    ];\n\n")
+
+	// Procesar los bloques apuntados
+	for k, pointer := range pointerBlock.B_pointers {
+		if pointer == -1 {
+			continue
+		}
+		*connections = append(*connections, fmt.Sprintf("Bloque%d:%d -> Bloque%d:0;", blockNum, k+1, pointer))
+		if level == 1 {
+			generateTreeFromFileBlock(file, superblock, pointer, content, visitedBlocks)
+		} else {
+			generateTreeFromPointerBlock(file, superblock, pointer, level-1, content, connections, visitedBlocks)
+		}
+	}
 }
 
 // generateTreeFromDirectoryBlock procesa un bloque de directorio
@@ -1618,7 +1760,7 @@ func generateTreeFromDirectoryBlock(file *os.File, superblock *Structs.Superbloc
 
 	for i := 0; i < 4; i++ {
 		if folderBlock.B_content[i].B_inodo != -1 {
-			entryName := cleanString(folderBlock.B_content[i].B_name[:])
+			entryName := FileSystem.EntryName(file, superblock, folderBlock.B_content[i])
 			if entryName != "" && entryName != "." && entryName != ".." {
 				// Limpiar nombre para HTML
 				cleanedEntryName := strings.ReplaceAll(entryName, "&", "&amp;")
@@ -1682,7 +1824,7 @@ func generateTreeFromFileBlock(file *os.File, superblock *Structs.Superblock, bl
 
 	// Obtener preview del contenido
 	var preview string
-	for i := 0; i < 64; i++ {
+	for i := 0; i < len(fileBlock.B_content); i++ {
 		if fileBlock.B_content[i] == 0 {
 			break
 		}
@@ -1713,31 +1855,24 @@ func generateTreeFromFileBlock(file *os.File, superblock *Structs.Superblock, bl
 	content.WriteString("    ];\n\n")
 }
 
-// checkGraphvizInstalled verifica si Graphviz está instalado en el sistema
-func checkGraphvizInstalled() bool {
-	cmd := exec.Command("dot", "-V")
-	err := cmd.Run()
-	return err == nil
-}
-
 // ============================================================================
 // REPORTE SB (SUPERBLOCK)
 // ============================================================================
 
 // GenerateSuperblockReport genera el reporte del superbloque en formato DOT e imagen
-func GenerateSuperblockReport(userOutputPath string, partitionID string) error {
-	fmt.Println("=== GENERANDO REPORTE SUPERBLOCK ===")
-	fmt.Printf("Ruta de salida especificada: %s\n", userOutputPath)
-	fmt.Printf("ID de partición: %s\n", partitionID)
+func GenerateSuperblockReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
+	fmt.Fprintln(out, "=== GENERANDO REPORTE SUPERBLOCK ===")
+	fmt.Fprintf(out, "Ruta de salida especificada: %s\n", userOutputPath)
+	fmt.Fprintf(out, "ID de partición: %s\n", partitionID)
 	
 	// Buscar la partición montada para obtener la ruta del disco
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("la partición con ID '%s' no está montada", partitionID)
 	}
 
 	diskPath := mountedPartition.Path
-	fmt.Printf("Ruta del disco: %s\n", diskPath)
+	fmt.Fprintf(out, "Ruta del disco: %s\n", diskPath)
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(diskPath)
@@ -1777,38 +1912,37 @@ func GenerateSuperblockReport(userOutputPath string, partitionID string) error {
 
 	// Usar la ruta exacta especificada por el usuario
 	finalDotPath, finalImagePath := processUserPath(userOutputPath)
-	fmt.Printf("Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "Archivo imagen: %s\n", finalImagePath)
 
 	// Crear directorio de salida si no existe
-	if err := createOutputDirectory(finalDotPath); err != nil {
+	if err := createOutputDirectory(out, finalDotPath); err != nil {
 		return fmt.Errorf("error creando directorio de salida: %v", err)
 	}
 
 	// Generar contenido del reporte en formato DOT
-	dotContent := generateSuperblockDotContent(&superblock)
+	dotContent := generateSuperblockDotContent(out, &superblock)
 
 	// Escribir archivo DOT
 	if err := writeReportFile(finalDotPath, dotContent); err != nil {
 		return fmt.Errorf("error escribiendo archivo DOT: %v", err)
 	}
 
-	// Generar imagen usando Graphviz
-	if err := generateGraphvizImage(finalDotPath, finalImagePath); err != nil {
-		fmt.Printf("Advertencia: No se pudo generar la imagen: %v\n", err)
-		fmt.Println("Asegúrate de tener Graphviz instalado (sudo apt install graphviz)")
+	// Generar la imagen con el renderizador elegido
+	if err := renderImage(finalDotPath, finalImagePath, renderer); err != nil {
+		fmt.Fprintf(out, "Advertencia: No se pudo generar la imagen: %v\n", err)
 	} else {
-		fmt.Printf("✓ Imagen generada: %s\n", finalImagePath)
+		fmt.Fprintf(out, "✓ Imagen generada: %s\n", finalImagePath)
 	}
 
-	fmt.Printf("✓ Reporte SB generado exitosamente\n")
-	fmt.Printf("  - Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("  - Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "✓ Reporte SB generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "  - Archivo imagen: %s\n", finalImagePath)
 	return nil
 }
 
 // generateSuperblockDotContent genera el contenido del reporte SB en formato DOT
-func generateSuperblockDotContent(superblock *Structs.Superblock) string {
+func generateSuperblockDotContent(out io.Writer, superblock *Structs.Superblock) string {
 	var content strings.Builder
 
 	// Encabezado del archivo DOT
@@ -1838,8 +1972,8 @@ func generateSuperblockDotContent(superblock *Structs.Superblock) string {
 	content.WriteString(fmt.Sprintf("            <TR><TD BGCOLOR=\"#E3F2FD\"><B>Inodos Libres</B></TD><TD>%d</TD></TR>\n", superblock.S_free_inodes_count))
 	
 	// Fechas (limpiar caracteres nulos)
-	mtime := cleanString(superblock.S_mtime[:])
-	umtime := cleanString(superblock.S_umtime[:])
+	mtime := Utilities.FormatTimestamp(cleanString(superblock.S_mtime[:]))
+	umtime := Utilities.FormatTimestamp(cleanString(superblock.S_umtime[:]))
 	if mtime == "" {
 		mtime = "No disponible"
 	}
@@ -1866,6 +2000,15 @@ func generateSuperblockDotContent(superblock *Structs.Superblock) string {
 	content.WriteString(fmt.Sprintf("            <TR><TD BGCOLOR=\"#FFF3E0\"><B>Inicio Bitmap Bloques</B></TD><TD>%d</TD></TR>\n", superblock.S_bm_block_start))
 	content.WriteString(fmt.Sprintf("            <TR><TD BGCOLOR=\"#FFF3E0\"><B>Inicio Tabla Inodos</B></TD><TD>%d</TD></TR>\n", superblock.S_inode_start))
 	content.WriteString(fmt.Sprintf("            <TR><TD BGCOLOR=\"#FFF3E0\"><B>Inicio Bloques de Datos</B></TD><TD>%d</TD></TR>\n", superblock.S_block_start))
+
+	// Journaling (solo EXT3)
+	if superblock.S_filesystem_type == 3 {
+		content.WriteString("            <TR><TD COLSPAN=\"2\" BGCOLOR=\"#2196F3\"><FONT COLOR=\"white\"><B>JOURNALING</B></FONT></TD></TR>\n")
+		content.WriteString(fmt.Sprintf("            <TR><TD BGCOLOR=\"#E3F2FD\"><B>Entradas</B></TD><TD>%d</TD></TR>\n", superblock.S_journal_count))
+		content.WriteString(fmt.Sprintf("            <TR><TD BGCOLOR=\"#E3F2FD\"><B>Registro más antiguo</B></TD><TD>%d</TD></TR>\n", superblock.S_journal_head))
+		content.WriteString(fmt.Sprintf("            <TR><TD BGCOLOR=\"#E3F2FD\"><B>Siguiente registro</B></TD><TD>%d</TD></TR>\n", superblock.S_journal_tail))
+		content.WriteString(fmt.Sprintf("            <TR><TD BGCOLOR=\"#E3F2FD\"><B>Última secuencia</B></TD><TD>%d</TD></TR>\n", superblock.S_journal_seq))
+	}
 	
 	content.WriteString("        </TABLE>\n")
 	content.WriteString("    >];\n")
@@ -1879,9 +2022,9 @@ func generateSuperblockDotContent(superblock *Structs.Superblock) string {
 	// Cierre del archivo DOT
 	content.WriteString("}\n")
 
-	fmt.Printf("Superbloque procesado:\n")
-	fmt.Printf("  - Inodos: %d total, %d libres\n", superblock.S_inodes_count, superblock.S_free_inodes_count)
-	fmt.Printf("  - Bloques: %d total, %d libres\n", superblock.S_blocks_count, superblock.S_free_blocks_count)
+	fmt.Fprintf(out, "Superbloque procesado:\n")
+	fmt.Fprintf(out, "  - Inodos: %d total, %d libres\n", superblock.S_inodes_count, superblock.S_free_inodes_count)
+	fmt.Fprintf(out, "  - Bloques: %d total, %d libres\n", superblock.S_blocks_count, superblock.S_free_blocks_count)
 
 	return content.String()
 }
@@ -1891,20 +2034,20 @@ func generateSuperblockDotContent(superblock *Structs.Superblock) string {
 // ============================================================================
 
 // GenerateFileReport genera el reporte de un archivo específico mostrando su contenido
-func GenerateFileReport(userOutputPath string, partitionID string, filePath string) error {
-	fmt.Println("=== GENERANDO REPORTE FILE ===")
-	fmt.Printf("Ruta de salida especificada: %s\n", userOutputPath)
-	fmt.Printf("ID de partición: %s\n", partitionID)
-	fmt.Printf("Archivo a mostrar: %s\n", filePath)
+func GenerateFileReport(out io.Writer, userOutputPath string, partitionID string, filePath string, renderer Renderer) error {
+	fmt.Fprintln(out, "=== GENERANDO REPORTE FILE ===")
+	fmt.Fprintf(out, "Ruta de salida especificada: %s\n", userOutputPath)
+	fmt.Fprintf(out, "ID de partición: %s\n", partitionID)
+	fmt.Fprintf(out, "Archivo a mostrar: %s\n", filePath)
 	
 	// Buscar la partición montada para obtener la ruta del disco
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("la partición con ID '%s' no está montada", partitionID)
 	}
 
 	diskPath := mountedPartition.Path
-	fmt.Printf("Ruta del disco: %s\n", diskPath)
+	fmt.Fprintf(out, "Ruta del disco: %s\n", diskPath)
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(diskPath)
@@ -1934,40 +2077,41 @@ func GenerateFileReport(userOutputPath string, partitionID string, filePath stri
 	}
 
 	// Buscar el archivo en el sistema de archivos
-	fileContent, fileName, err := findFileInFilesystem(file, &superblock, filePath)
+	fileContent, fileName, err := findFileInFilesystem(out, file, &superblock, filePath)
 	if err != nil {
 		return fmt.Errorf("error buscando archivo '%s': %v", filePath, err)
 	}
 
-	// Crear el archivo de salida
-	outputFile, err := os.Create(userOutputPath)
-	if err != nil {
-		return fmt.Errorf("error creando archivo de salida: %v", err)
-	}
-	defer outputFile.Close()
+	// Armar el texto del reporte; writeTextReport lo guarda como texto o
+	// como imagen según la extensión de la ruta
+	var report strings.Builder
 
 	// Escribir el reporte
-	fmt.Fprintf(outputFile, "=== REPORTE DE ARCHIVO ===\n")
-	fmt.Fprintf(outputFile, "Nombre del archivo: %s\n", fileName)
-	fmt.Fprintf(outputFile, "============================\n\n")
-	fmt.Fprintf(outputFile, "CONTENIDO DEL ARCHIVO:\n")
-	fmt.Fprintf(outputFile, "======================\n")
-	fmt.Fprintf(outputFile, "%s", fileContent)
-
-	fmt.Printf("✓ Reporte FILE generado exitosamente\n")
-	fmt.Printf("  - Archivo: %s\n", userOutputPath)
-	fmt.Printf("  - Archivo encontrado: %s\n", fileName)
-	fmt.Printf("  - Tamaño del contenido: %d bytes\n", len(fileContent))
+	fmt.Fprintf(&report, "=== REPORTE DE ARCHIVO ===\n")
+	fmt.Fprintf(&report, "Nombre del archivo: %s\n", fileName)
+	fmt.Fprintf(&report, "============================\n\n")
+	fmt.Fprintf(&report, "CONTENIDO DEL ARCHIVO:\n")
+	fmt.Fprintf(&report, "======================\n")
+	report.Write(fileContent)
+
+	if err := writeTextReport(out, userOutputPath, report.String(), renderer); err != nil {
+		return fmt.Errorf("error escribiendo el reporte: %v", err)
+	}
+
+	fmt.Fprintf(out, "✓ Reporte FILE generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo: %s\n", userOutputPath)
+	fmt.Fprintf(out, "  - Archivo encontrado: %s\n", fileName)
+	fmt.Fprintf(out, "  - Tamaño del contenido: %d bytes\n", len(fileContent))
 
 	return nil
 }
 
 // findFileInFilesystem busca un archivo en el sistema y retorna su contenido
-func findFileInFilesystem(file *os.File, superblock *Structs.Superblock, targetPath string) (string, string, error) {
+func findFileInFilesystem(out io.Writer, file *os.File, superblock *Structs.Superblock, targetPath string) ([]byte, string, error) {
 	// Limpiar y dividir la ruta
 	targetPath = strings.TrimSpace(targetPath)
 	if targetPath == "" {
-		return "", "", fmt.Errorf("ruta de archivo vacía")
+		return nil, "", fmt.Errorf("ruta de archivo vacía")
 	}
 
 	// Si la ruta no empieza con /, agregarla
@@ -1980,11 +2124,11 @@ func findFileInFilesystem(file *os.File, superblock *Structs.Superblock, targetP
 	
 	// Si es la ruta raíz, no hay archivo que mostrar
 	if len(pathComponents) == 1 && pathComponents[0] == "" {
-		return "", "", fmt.Errorf("no se puede mostrar el contenido del directorio raíz")
+		return nil, "", fmt.Errorf("no se puede mostrar el contenido del directorio raíz")
 	}
 
-	fmt.Printf("Buscando archivo: %s\n", targetPath)
-	fmt.Printf("Componentes de ruta: %v\n", pathComponents)
+	fmt.Fprintf(out, "Buscando archivo: %s\n", targetPath)
+	fmt.Fprintf(out, "Componentes de ruta: %v\n", pathComponents)
 
 	// Comenzar la búsqueda desde el inodo raíz (inodo 0)
 	currentInodeNum := int32(0)
@@ -1997,22 +2141,22 @@ func findFileInFilesystem(file *os.File, superblock *Structs.Superblock, targetP
 			continue
 		}
 
-		fmt.Printf("Buscando componente: '%s' en inodo %d\n", component, currentInodeNum)
+		fmt.Fprintf(out, "Buscando componente: '%s' en inodo %d\n", component, currentInodeNum)
 
 		// Leer el inodo actual
 		var currentInode Structs.Inode
 		inodePos := int64(superblock.S_inode_start + currentInodeNum*superblock.S_inode_size)
 		if err := Utilities.ReadObject(file, &currentInode, inodePos); err != nil {
-			return "", "", fmt.Errorf("error leyendo inodo %d: %v", currentInodeNum, err)
+			return nil, "", fmt.Errorf("error leyendo inodo %d: %v", currentInodeNum, err)
 		}
 
 		// Verificar que el inodo esté en uso
 		var bitmapByte byte
 		if err := Utilities.ReadObject(file, &bitmapByte, int64(superblock.S_bm_inode_start+currentInodeNum)); err != nil {
-			return "", "", fmt.Errorf("error leyendo bitmap de inodo %d: %v", currentInodeNum, err)
+			return nil, "", fmt.Errorf("error leyendo bitmap de inodo %d: %v", currentInodeNum, err)
 		}
 		if bitmapByte == 0 {
-			return "", "", fmt.Errorf("inodo %d no está en uso", currentInodeNum)
+			return nil, "", fmt.Errorf("inodo %d no está en uso", currentInodeNum)
 		}
 
 		inodeType := cleanString(currentInode.I_type[:])
@@ -2023,16 +2167,16 @@ func findFileInFilesystem(file *os.File, superblock *Structs.Superblock, targetP
 		// Si no es el último componente, el inodo actual debe ser un directorio
 		if i < len(pathComponents)-1 {
 			if !isDirectory {
-				return "", "", fmt.Errorf("'%s' no es un directorio", currentPath.String()+component)
+				return nil, "", fmt.Errorf("'%s' no es un directorio", currentPath.String()+component)
 			}
 
 			// Buscar el siguiente componente en este directorio
 			nextInodeNum, found, err := findInodeInDirectory(file, &currentInode, superblock, component)
 			if err != nil {
-				return "", "", fmt.Errorf("error buscando en directorio: %v", err)
+				return nil, "", fmt.Errorf("error buscando en directorio: %v", err)
 			}
 			if !found {
-				return "", "", fmt.Errorf("no se encontró '%s' en '%s'", component, currentPath.String())
+				return nil, "", fmt.Errorf("no se encontró '%s' en '%s'", component, currentPath.String())
 			}
 
 			// Actualizar para el siguiente nivel
@@ -2042,23 +2186,23 @@ func findFileInFilesystem(file *os.File, superblock *Structs.Superblock, targetP
 		} else {
 			// Es el último componente - buscar el archivo en el directorio actual
 			if !isDirectory {
-				return "", "", fmt.Errorf("error: inodo padre %d no es un directorio", currentInodeNum)
+				return nil, "", fmt.Errorf("error: inodo padre %d no es un directorio", currentInodeNum)
 			}
 
 			// Buscar el archivo en este directorio
 			targetInodeNum, found, err := findInodeInDirectory(file, &currentInode, superblock, component)
 			if err != nil {
-				return "", "", fmt.Errorf("error buscando archivo en directorio: %v", err)
+				return nil, "", fmt.Errorf("error buscando archivo en directorio: %v", err)
 			}
 			if !found {
-				return "", "", fmt.Errorf("no se encontró el archivo '%s' en '%s'", component, currentPath.String())
+				return nil, "", fmt.Errorf("no se encontró el archivo '%s' en '%s'", component, currentPath.String())
 			}
 
 			// Leer el inodo del archivo encontrado
 			var targetInode Structs.Inode
 			targetInodePos := int64(superblock.S_inode_start + targetInodeNum*superblock.S_inode_size)
 			if err := Utilities.ReadObject(file, &targetInode, targetInodePos); err != nil {
-				return "", "", fmt.Errorf("error leyendo inodo del archivo %d: %v", targetInodeNum, err)
+				return nil, "", fmt.Errorf("error leyendo inodo del archivo %d: %v", targetInodeNum, err)
 			}
 
 			targetInodeType := cleanString(targetInode.I_type[:])
@@ -2067,50 +2211,47 @@ func findFileInFilesystem(file *os.File, superblock *Structs.Superblock, targetP
 
 
 			if targetIsDirectory {
-				return "", "", fmt.Errorf("'%s' es un directorio, no un archivo", targetPath)
+				return nil, "", fmt.Errorf("'%s' es un directorio, no un archivo", targetPath)
 			}
 
 			// Es un archivo, leer su contenido
 			fileContent, err := readFileContent(file, &targetInode, superblock)
 			if err != nil {
-				return "", "", fmt.Errorf("error leyendo contenido del archivo: %v", err)
+				return nil, "", fmt.Errorf("error leyendo contenido del archivo: %v", err)
 			}
 
 			return fileContent, currentPath.String() + component, nil
 		}
 	}
 
-	return "", "", fmt.Errorf("no se pudo resolver la ruta")
+	return nil, "", fmt.Errorf("no se pudo resolver la ruta")
 }
 
 // readFileContent lee el contenido completo de un archivo desde sus bloques
-func readFileContent(file *os.File, inode *Structs.Inode, superblock *Structs.Superblock) (string, error) {
-	var content strings.Builder
-
-	// Leer bloques directos (0-11)
-	for i := 0; i < 12; i++ {
-		if inode.I_block[i] == -1 {
-			continue // Bloque no asignado
-		}
+func readFileContent(file *os.File, inode *Structs.Inode, superblock *Structs.Superblock) ([]byte, error) {
+	var content bytes.Buffer
+	remaining := int(inode.I_size)
 
+	// Leer los bloques directos e indirectos (simple, doble y triple) en orden
+	dataBlocks, _ := FileSystem.FileBlocks(file, superblock, inode)
+	for _, blockNum := range dataBlocks {
 		// Leer el bloque
 		var fileBlock Structs.Fileblock
-		blockPos := int64(superblock.S_block_start + inode.I_block[i]*superblock.S_block_size)
+		blockPos := int64(superblock.S_block_start + blockNum*superblock.S_block_size)
 		if err := Utilities.ReadObject(file, &fileBlock, blockPos); err != nil {
-			return "", fmt.Errorf("error leyendo bloque %d: %v", inode.I_block[i], err)
+			return nil, fmt.Errorf("error leyendo bloque %d: %v", blockNum, err)
 		}
 
-		// Agregar el contenido del bloque
-		for j := 0; j < 64; j++ {
-			if fileBlock.B_content[j] == 0 {
-				break
-			}
-			content.WriteByte(fileBlock.B_content[j])
+		// Agregar el contenido del bloque hasta completar el tamaño del archivo
+		bytesInBlock := 64
+		if remaining < bytesInBlock {
+			bytesInBlock = remaining
 		}
+		content.Write(fileBlock.B_content[:bytesInBlock])
+		remaining -= bytesInBlock
 	}
 
-
-	return content.String(), nil
+	return content.Bytes(), nil
 }
 
 // findInodeInDirectory busca una entrada específica en un directorio
@@ -2134,7 +2275,7 @@ func findInodeInDirectory(file *os.File, dirInode *Structs.Inode, superblock *St
 				continue // Entrada vacía
 			}
 
-			entryName := cleanString(folderBlock.B_content[j].B_name[:])
+			entryName := FileSystem.EntryName(file, superblock, folderBlock.B_content[j])
 			if entryName == targetName {
 				return folderBlock.B_content[j].B_inodo, true, nil
 			}
@@ -2149,20 +2290,20 @@ func findInodeInDirectory(file *os.File, dirInode *Structs.Inode, superblock *St
 // ============================================================================
 
 // GenerateListReport genera el reporte ls que muestra información detallada de archivos y directorios
-func GenerateListReport(userOutputPath string, partitionID string, dirPath string) error {
-	fmt.Println("=== GENERANDO REPORTE LS ===")
-	fmt.Printf("Ruta de salida especificada: %s\n", userOutputPath)
-	fmt.Printf("ID de partición: %s\n", partitionID)
-	fmt.Printf("Directorio a listar: %s\n", dirPath)
+func GenerateListReport(out io.Writer, userOutputPath string, partitionID string, dirPath string, renderer Renderer) error {
+	fmt.Fprintln(out, "=== GENERANDO REPORTE LS ===")
+	fmt.Fprintf(out, "Ruta de salida especificada: %s\n", userOutputPath)
+	fmt.Fprintf(out, "ID de partición: %s\n", partitionID)
+	fmt.Fprintf(out, "Directorio a listar: %s\n", dirPath)
 	
 	// Buscar la partición montada para obtener la ruta del disco
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("la partición con ID '%s' no está montada", partitionID)
 	}
 
 	diskPath := mountedPartition.Path
-	fmt.Printf("Ruta del disco: %s\n", diskPath)
+	fmt.Fprintf(out, "Ruta del disco: %s\n", diskPath)
 
 	// Abrir archivo del disco
 	file, err := Utilities.OpenFile(diskPath)
@@ -2192,18 +2333,18 @@ func GenerateListReport(userOutputPath string, partitionID string, dirPath strin
 	}
 
 	// Buscar el directorio en el sistema de archivos
-	directoryEntries, err := listDirectoryContents(file, &superblock, dirPath)
+	directoryEntries, err := listDirectoryContents(out, file, &superblock, dirPath)
 	if err != nil {
 		return fmt.Errorf("error listando directorio '%s': %v", dirPath, err)
 	}
 
 	// Usar la ruta exacta especificada por el usuario
 	finalDotPath, finalImagePath := processUserPath(userOutputPath)
-	fmt.Printf("Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "Archivo imagen: %s\n", finalImagePath)
 
 	// Crear directorio de salida si no existe
-	if err := createOutputDirectory(finalDotPath); err != nil {
+	if err := createOutputDirectory(out, finalDotPath); err != nil {
 		return fmt.Errorf("error creando directorio de salida: %v", err)
 	}
 
@@ -2215,38 +2356,37 @@ func GenerateListReport(userOutputPath string, partitionID string, dirPath strin
 		return fmt.Errorf("error escribiendo archivo DOT: %v", err)
 	}
 
-	// Generar imagen usando Graphviz
-	if err := generateGraphvizImage(finalDotPath, finalImagePath); err != nil {
-		fmt.Printf("Advertencia: No se pudo generar la imagen: %v\n", err)
-		fmt.Println("Asegúrate de tener Graphviz instalado (sudo apt install graphviz)")
+	// Generar la imagen con el renderizador elegido
+	if err := renderImage(finalDotPath, finalImagePath, renderer); err != nil {
+		fmt.Fprintf(out, "Advertencia: No se pudo generar la imagen: %v\n", err)
 	} else {
-		fmt.Printf("✓ Imagen generada: %s\n", finalImagePath)
+		fmt.Fprintf(out, "✓ Imagen generada: %s\n", finalImagePath)
 	}
 
-	fmt.Printf("✓ Reporte LS generado exitosamente\n")
-	fmt.Printf("  - Archivo DOT: %s\n", finalDotPath)
-	fmt.Printf("  - Archivo imagen: %s\n", finalImagePath)
-	fmt.Printf("  - Entradas listadas: %d\n", len(directoryEntries))
+	fmt.Fprintf(out, "✓ Reporte LS generado exitosamente\n")
+	fmt.Fprintf(out, "  - Archivo DOT: %s\n", finalDotPath)
+	fmt.Fprintf(out, "  - Archivo imagen: %s\n", finalImagePath)
+	fmt.Fprintf(out, "  - Entradas listadas: %d\n", len(directoryEntries))
 
 	return nil
 }
 
 // DirectoryEntry representa una entrada de directorio con toda su información
 type DirectoryEntry struct {
-	Name           string
-	Type           string // "FILE" o "DIR"
-	Permissions    string
-	Owner          string
-	Group          string
-	Size           int32
-	CreationTime   string
-	ModificationTime string
-	AccessTime     string
-	InodeNumber    int32
+	Name             string `json:"name"`
+	Type             string `json:"type"` // "FILE" o "DIR"
+	Permissions      string `json:"permissions"`
+	Owner            string `json:"owner"`
+	Group            string `json:"group"`
+	Size             int32  `json:"size"`
+	CreationTime     string `json:"ctime"`
+	ModificationTime string `json:"mtime"`
+	AccessTime       string `json:"atime"`
+	InodeNumber      int32  `json:"inode"`
 }
 
 // listDirectoryContents lista el contenido de un directorio específico
-func listDirectoryContents(file *os.File, superblock *Structs.Superblock, targetPath string) ([]DirectoryEntry, error) {
+func listDirectoryContents(out io.Writer, file *os.File, superblock *Structs.Superblock, targetPath string) ([]DirectoryEntry, error) {
 	// Limpiar y dividir la ruta
 	targetPath = strings.TrimSpace(targetPath)
 	if targetPath == "" {
@@ -2258,7 +2398,7 @@ func listDirectoryContents(file *os.File, superblock *Structs.Superblock, target
 		targetPath = "/" + targetPath
 	}
 
-	fmt.Printf("Listando directorio: %s\n", targetPath)
+	fmt.Fprintf(out, "Listando directorio: %s\n", targetPath)
 
 	// Encontrar el inodo del directorio objetivo
 	dirInodeNum, err := findDirectoryInode(file, superblock, targetPath)
@@ -2300,7 +2440,7 @@ func listDirectoryContents(file *os.File, superblock *Structs.Superblock, target
 				continue // Entrada vacía
 			}
 
-			entryName := cleanString(folderBlock.B_content[j].B_name[:])
+			entryName := FileSystem.EntryName(file, superblock, folderBlock.B_content[j])
 			if entryName == "" {
 				continue // Nombre vacío
 			}
@@ -2318,9 +2458,9 @@ func listDirectoryContents(file *os.File, superblock *Structs.Superblock, target
 				Name:             entryName,
 				InodeNumber:      entryInodeNum,
 				Size:             entryInode.I_size,
-				CreationTime:     cleanString(entryInode.I_ctime[:]),
-				ModificationTime: cleanString(entryInode.I_mtime[:]),
-				AccessTime:       cleanString(entryInode.I_atime[:]),
+				CreationTime:     Utilities.FormatTimestamp(cleanString(entryInode.I_ctime[:])),
+				ModificationTime: Utilities.FormatTimestamp(cleanString(entryInode.I_mtime[:])),
+				AccessTime:       Utilities.FormatTimestamp(cleanString(entryInode.I_atime[:])),
 				Permissions:      cleanString(entryInode.I_perm[:]),
 			}
 
@@ -2340,7 +2480,7 @@ func listDirectoryContents(file *os.File, superblock *Structs.Superblock, target
 		}
 	}
 
-	fmt.Printf("Encontradas %d entradas en el directorio\n", len(entries))
+	fmt.Fprintf(out, "Encontradas %d entradas en el directorio\n", len(entries))
 	return entries, nil
 }
 
@@ -2465,12 +2605,12 @@ func generateLsDotContent(entries []DirectoryEntry, dirPath string, partitionID
 // ============================================================================
 
 // GenerateJournalingReport genera el reporte del journaling mostrando todas las transacciones
-func GenerateJournalingReport(userOutputPath string, partitionID string) error {
-	fmt.Println("======INICIO REPORTE JOURNALING======")
-	fmt.Printf("Partition ID: %s\n", partitionID)
+func GenerateJournalingReport(out io.Writer, userOutputPath string, partitionID string, renderer Renderer) error {
+	fmt.Fprintln(out, "======INICIO REPORTE JOURNALING======")
+	fmt.Fprintf(out, "Partition ID: %s\n", partitionID)
 	
 	// Buscar la partición montada para obtener la ruta del disco
-	mountedPartition, exists := DiskManagement.MountedPartitions[partitionID]
+	mountedPartition, exists := DiskManagement.GetMountedPartition(partitionID)
 	if !exists {
 		return fmt.Errorf("error: la partición con ID '%s' no está montada", partitionID)
 	}
@@ -2516,7 +2656,7 @@ func GenerateJournalingReport(userOutputPath string, partitionID string) error {
 	dotPath, imagePath := processUserPath(userOutputPath)
 
 	// Crear directorio de salida si no existe
-	if err := createOutputDirectory(filepath.Dir(dotPath)); err != nil {
+	if err := createOutputDirectory(out, filepath.Dir(dotPath)); err != nil {
 		return err
 	}
 
@@ -2528,27 +2668,27 @@ func GenerateJournalingReport(userOutputPath string, partitionID string) error {
 		return err
 	}
 
-	// Generar imagen usando Graphviz
-	if err := generateGraphvizImage(dotPath, imagePath); err != nil {
-		fmt.Printf("Advertencia: No se pudo generar la imagen con Graphviz: %v\n", err)
-		fmt.Printf("Archivo DOT generado en: %s\n", dotPath)
+	// Generar la imagen con el renderizador elegido
+	if err := renderImage(dotPath, imagePath, renderer); err != nil {
+		fmt.Fprintf(out, "Advertencia: No se pudo generar la imagen: %v\n", err)
+		fmt.Fprintf(out, "Archivo DOT generado en: %s\n", dotPath)
 	} else {
-		fmt.Printf("Reporte generado exitosamente:\n")
-		fmt.Printf("  - Archivo DOT: %s\n", dotPath)
-		fmt.Printf("  - Imagen: %s\n", imagePath)
+		fmt.Fprintf(out, "Reporte generado exitosamente:\n")
+		fmt.Fprintf(out, "  - Archivo DOT: %s\n", dotPath)
+		fmt.Fprintf(out, "  - Imagen: %s\n", imagePath)
 	}
 
-	fmt.Println("======FIN REPORTE JOURNALING======")
+	fmt.Fprintln(out, "======FIN REPORTE JOURNALING======")
 	return nil
 }
 
 // JournalingEntry representa una entrada en el journal
 type JournalingEntry struct {
-	Index     int
-	Operation string
-	Path      string
-	Content   string
-	Date      string
+	Index     int    `json:"index"`
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Date      string `json:"date"`
 }
 
 // generateJournalingDotContent genera el contenido del reporte JOURNALING en formato DOT
@@ -2637,65 +2777,25 @@ func generateJournalingDotContent(file *os.File, superblock *Structs.Superblock,
 	return content.String()
 }
 
-// readJournalingEntries lee todas las entradas del journaling
+// readJournalingEntries lee todas las entradas del journaling, uniendo las
+// que ocupan varios registros
 func readJournalingEntries(file *os.File, superblock *Structs.Superblock, partitionStart int64) []JournalingEntry {
 	var entries []JournalingEntry
 
-	// El journaling está ubicado después del superblock
-	// Superblock ocupa 94 bytes (calculado con binary.Size)
-	superblockSize := int64(binary.Size(Structs.Superblock{}))
-	journalStart := partitionStart + superblockSize
-
-	// Leer entradas del journal
-	// Cada entrada de journaling tiene un count y una estructura Information
-	const JOURNALING_CONSTANT = 50
-	journalingSize := int64(binary.Size(Structs.Journaling{}))
-	
-	index := 1
-	for i := 0; i < JOURNALING_CONSTANT; i++ {
-		// Posición actual del journal
-		currentPos := journalStart + int64(i)*journalingSize
-
-		// Leer entrada del journaling
-		file.Seek(currentPos, 0)
-		var journal Structs.Journaling
-		err := binary.Read(file, binary.LittleEndian, &journal)
-		if err != nil {
-			break
-		}
-
-		// Si count es 0, no hay más entradas válidas
-		if journal.Count == 0 {
-			continue
-		}
-
-		// Convertir bytes a strings
-		operation := cleanString(journal.Content.Operation[:])
-		path := cleanString(journal.Content.Path[:])
-		contentStr := cleanString(journal.Content.Content[:])
-		
-		// Si la operación está vacía, skip
-		if operation == "" {
-			continue
-		}
-		
+	for _, record := range FileSystem.ReadJournalRecords(file, int32(partitionStart)) {
 		// Convertir fecha de float32 a string legible
 		dateStr := "N/A"
-		if journal.Content.Date > 0 {
-			// Convertir timestamp Unix a fecha
-			dateStr = Utilities.ConvertUnixTimestamp(int64(journal.Content.Date))
+		if record.Date > 0 {
+			dateStr = Utilities.ConvertUnixTimestamp(int64(record.Date))
 		}
 
-		// Agregar entrada
 		entries = append(entries, JournalingEntry{
-			Index:     index,
-			Operation: operation,
-			Path:      path,
-			Content:   contentStr,
+			Index:     record.Index,
+			Operation: strings.TrimSpace(record.Operation),
+			Path:      strings.TrimSpace(record.Path),
+			Content:   strings.TrimSpace(record.Content),
 			Date:      dateStr,
 		})
-
-		index++
 	}
 
 	return entries
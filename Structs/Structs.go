@@ -54,6 +54,10 @@ type Superblock struct {
 	S_bm_block_start    int32
 	S_inode_start       int32
 	S_block_start       int32
+	S_journal_count     int32 // Registros del journaling (EXT3)
+	S_journal_head      int32 // Registro más antiguo del journaling
+	S_journal_tail      int32 // Registro donde se escribe la siguiente entrada
+	S_journal_seq       int32 // Último número de secuencia usado
 }
 
 //  =============================================================
@@ -72,8 +76,10 @@ type Inode struct {
 
 //  =============================================================
 
+// Los bloques ocupan 256 bytes en el área de bloques (S_block_size). Las
+// particiones formateadas con bloques de 64 bytes se migran al montarlas.
 type Fileblock struct {
-	B_content [64]byte
+	B_content [256]byte
 }
 
 //  =============================================================
@@ -83,6 +89,7 @@ type Content struct {
 	B_inodo int32
 }
 
+// Una carpeta usa los primeros 64 bytes de su bloque
 type Folderblock struct {
 	B_content [4]Content
 }
@@ -90,7 +97,7 @@ type Folderblock struct {
 //  =============================================================
 
 type Pointerblock struct {
-	B_pointers [16]int32
+	B_pointers [64]int32
 }
 
 //  =============================================================
@@ -116,6 +123,7 @@ type MountedPartition struct {
 	PartitionIndex int    
 	IsLogical      bool   
 	EBRPosition    int32  
+	Correlative    int32  // Correlativo del disco usado en el ID
 }
 
 // Contadores para generar IDs únicos por disco
@@ -131,6 +139,7 @@ type UserSession struct {
 	GroupID      int
 	PartitionID  string 
 	IsActive     bool   
+	Token        string // Token opaco con el que el cliente identifica la sesión
 }
 
 // Estructura para representar un usuario del sistema
@@ -149,3 +158,14 @@ type SystemGroup struct {
 	Name string
 }
 
+
+// Estructura para representar el resultado de un comando ejecutado
+type CommandResult struct {
+	Command string                 `json:"command"`
+	Params  string                 `json:"params"`
+	Status  string                 `json:"status"` // "ok" o "error"
+	Code    string                 `json:"code,omitempty"`
+	Message string                 `json:"message"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+	Output  string                 `json:"output"`
+}
@@ -0,0 +1,499 @@
+package Terminal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// EDITOR DE LÍNEA PARA LA TERMINAL INTERACTIVA
+// ============================================================================
+
+// Teclas soportadas en modo interactivo:
+//   - ← → Inicio Fin, Ctrl-A Ctrl-E: mover el cursor
+//   - Retroceso, Supr, Ctrl-U Ctrl-K Ctrl-W: borrar
+//   - ↑ ↓, Ctrl-P Ctrl-N: recorrer el historial
+//   - Tab: completar; con varias opciones se listan
+//   - Ctrl-C descarta la línea, Ctrl-D en una línea vacía termina, Ctrl-L
+//     limpia la pantalla
+// Si la entrada no es una terminal las líneas se leen tal cual, sin prompt.
+
+// maxHistory es la cantidad de líneas de historial que se conservan
+const maxHistory = 500
+
+// Completer recibe el texto antes del cursor y retorna la posición (en runas)
+// donde empieza la palabra a completar y los textos que pueden reemplazarla
+type Completer func(line string) (int, []string)
+
+// LineEditor lee líneas de la entrada estándar con edición e historial
+type LineEditor struct {
+	input       *bufio.Reader
+	output      io.Writer
+	fd          int
+	interactive bool
+	completer   Completer
+	history     []string
+	historyFile string
+}
+
+// NewLineEditor crea un editor sobre la entrada y salida estándar. Si
+// historyFile no está vacío el historial se carga de ese archivo y cada línea
+// nueva se agrega a él.
+func NewLineEditor(historyFile string, completer Completer) *LineEditor {
+	editor := &LineEditor{
+		input:       bufio.NewReader(os.Stdin),
+		output:      os.Stdout,
+		fd:          int(os.Stdin.Fd()),
+		completer:   completer,
+		historyFile: historyFile,
+	}
+	editor.interactive = isTerminal(editor.fd) && isTerminal(int(os.Stdout.Fd()))
+	editor.loadHistory()
+	return editor
+}
+
+// Interactive indica si el editor lee de una terminal
+func (e *LineEditor) Interactive() bool {
+	return e.interactive
+}
+
+// ReadLine muestra el prompt y lee una línea. Retorna io.EOF cuando la entrada
+// termina o se presiona Ctrl-D en una línea vacía.
+func (e *LineEditor) ReadLine(prompt string) (string, error) {
+	if !e.interactive {
+		return e.readPlain()
+	}
+
+	restore, err := makeRaw(e.fd)
+	if err != nil {
+		return e.readPlain()
+	}
+	defer restore()
+
+	line, err := e.edit(prompt)
+	if err == nil {
+		e.AddHistory(line)
+	}
+	return line, err
+}
+
+// readPlain lee una línea sin edición (entrada redirigida o sin terminal)
+func (e *LineEditor) readPlain() (string, error) {
+	line, err := e.input.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ============================================================================
+// EDICIÓN
+// ============================================================================
+
+// lineState es la línea en edición
+type lineState struct {
+	prompt string
+	buffer []rune
+	cursor int
+	index  int    // posición en el historial; len(history) es la línea nueva
+	draft  string // línea nueva guardada mientras se recorre el historial
+	listed bool   // el último Tab ya listó las opciones
+	row    int    // fila del cursor respecto a la del prompt
+}
+
+// edit procesa las teclas hasta que se presiona Enter
+func (e *LineEditor) edit(prompt string) (string, error) {
+	state := &lineState{prompt: prompt, index: len(e.history)}
+	e.refresh(state)
+
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			fmt.Fprint(e.output, "\r\n")
+			return "", err
+		}
+		if key != keyTab {
+			state.listed = false
+		}
+
+		switch key {
+		case keyEnter:
+			e.moveToEnd(state)
+			fmt.Fprint(e.output, "\r\n")
+			return string(state.buffer), nil
+		case keyCtrlC:
+			e.moveToEnd(state)
+			fmt.Fprint(e.output, "^C\r\n")
+			return "", nil
+		case keyCtrlD:
+			if len(state.buffer) == 0 {
+				fmt.Fprint(e.output, "\r\n")
+				return "", io.EOF
+			}
+			state.deleteAt(state.cursor)
+		case keyBackspace:
+			if state.cursor > 0 {
+				state.cursor--
+				state.deleteAt(state.cursor)
+			}
+		case keyDelete:
+			state.deleteAt(state.cursor)
+		case keyLeft:
+			if state.cursor > 0 {
+				state.cursor--
+			}
+		case keyRight:
+			if state.cursor < len(state.buffer) {
+				state.cursor++
+			}
+		case keyHome:
+			state.cursor = 0
+		case keyEnd:
+			state.cursor = len(state.buffer)
+		case keyKillStart:
+			state.buffer = append([]rune{}, state.buffer[state.cursor:]...)
+			state.cursor = 0
+		case keyKillEnd:
+			state.buffer = state.buffer[:state.cursor]
+		case keyKillWord:
+			start := state.cursor
+			for start > 0 && state.buffer[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && state.buffer[start-1] != ' ' {
+				start--
+			}
+			state.buffer = append(state.buffer[:start], state.buffer[state.cursor:]...)
+			state.cursor = start
+		case keyUp:
+			e.moveHistory(state, -1)
+		case keyDown:
+			e.moveHistory(state, 1)
+		case keyClear:
+			fmt.Fprint(e.output, "\x1b[H\x1b[2J")
+			state.row = 0
+		case keyTab:
+			e.complete(state)
+		case keyNone:
+		default:
+			if key >= ' ' {
+				state.buffer = append(state.buffer[:state.cursor], append([]rune{key}, state.buffer[state.cursor:]...)...)
+				state.cursor++
+			}
+		}
+		e.refresh(state)
+	}
+}
+
+func (s *lineState) deleteAt(position int) {
+	if position < len(s.buffer) {
+		s.buffer = append(s.buffer[:position], s.buffer[position+1:]...)
+	}
+}
+
+// refresh redibuja la línea y deja el cursor en su posición. La línea puede
+// ocupar varias filas si es más ancha que la terminal.
+func (e *LineEditor) refresh(state *lineState) {
+	width := terminalWidth(e.fd)
+	promptLength := utf8.RuneCountInString(state.prompt)
+	end := promptLength + len(state.buffer)
+	position := promptLength + state.cursor
+
+	var out strings.Builder
+	if state.row > 0 {
+		fmt.Fprintf(&out, "\x1b[%dA", state.row)
+	}
+	out.WriteString("\r\x1b[J")
+	out.WriteString(state.prompt)
+	out.WriteString(string(state.buffer))
+
+	// Al llenar la última columna el cursor no baja solo
+	if end > 0 && end%width == 0 {
+		out.WriteString("\r\n")
+	}
+	if up := end/width - position/width; up > 0 {
+		fmt.Fprintf(&out, "\x1b[%dA", up)
+	}
+	out.WriteString("\r")
+	if column := position % width; column > 0 {
+		fmt.Fprintf(&out, "\x1b[%dC", column)
+	}
+	state.row = position / width
+	fmt.Fprint(e.output, out.String())
+}
+
+// moveToEnd baja el cursor a la última fila de la línea
+func (e *LineEditor) moveToEnd(state *lineState) {
+	end := utf8.RuneCountInString(state.prompt) + len(state.buffer)
+	if down := end/terminalWidth(e.fd) - state.row; down > 0 {
+		fmt.Fprintf(e.output, "\x1b[%dB", down)
+	}
+	state.row = 0
+}
+
+// moveHistory reemplaza la línea con la entrada anterior (-1) o siguiente (1)
+// del historial
+func (e *LineEditor) moveHistory(state *lineState, step int) {
+	next := state.index + step
+	if next < 0 || next > len(e.history) {
+		return
+	}
+	if state.index == len(e.history) {
+		state.draft = string(state.buffer)
+	}
+	state.index = next
+	if next == len(e.history) {
+		state.buffer = []rune(state.draft)
+	} else {
+		state.buffer = []rune(e.history[next])
+	}
+	state.cursor = len(state.buffer)
+}
+
+// complete completa la palabra antes del cursor. Con una sola opción la
+// inserta; con varias inserta el prefijo común y, si no hay nada más que
+// agregar, las lista debajo de la línea.
+func (e *LineEditor) complete(state *lineState) {
+	if e.completer == nil {
+		return
+	}
+	start, candidates := e.completer(string(state.buffer[:state.cursor]))
+	if len(candidates) == 0 || start < 0 || start > state.cursor {
+		fmt.Fprint(e.output, "\a")
+		return
+	}
+
+	word := string(state.buffer[start:state.cursor])
+	replacement := commonPrefix(candidates)
+	if len(candidates) == 1 && !strings.HasSuffix(replacement, "/") && !strings.HasSuffix(replacement, "=") {
+		replacement += " "
+	}
+
+	if len(candidates) > 1 && utf8.RuneCountInString(replacement) <= utf8.RuneCountInString(word) {
+		if state.listed {
+			return
+		}
+		state.listed = true
+		e.moveToEnd(state)
+		e.printCandidates(candidates)
+		return
+	}
+
+	rest := state.buffer[state.cursor:]
+	state.buffer = append(append(append([]rune{}, state.buffer[:start]...), []rune(replacement)...), rest...)
+	state.cursor = start + utf8.RuneCountInString(replacement)
+}
+
+// printCandidates lista las opciones en columnas debajo de la línea actual
+func (e *LineEditor) printCandidates(candidates []string) {
+	width := 0
+	for _, candidate := range candidates {
+		if n := utf8.RuneCountInString(candidate); n > width {
+			width = n
+		}
+	}
+	width += 2
+	columns := terminalWidth(e.fd) / width
+	if columns < 1 {
+		columns = 1
+	}
+
+	var out strings.Builder
+	out.WriteString("\r\n")
+	for i, candidate := range candidates {
+		out.WriteString(candidate)
+		if (i+1)%columns == 0 || i == len(candidates)-1 {
+			out.WriteString("\r\n")
+		} else {
+			out.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(candidate)))
+		}
+	}
+	fmt.Fprint(e.output, out.String())
+}
+
+// commonPrefix retorna el prefijo común más largo de las opciones
+func commonPrefix(candidates []string) string {
+	prefix := []rune(candidates[0])
+	for _, candidate := range candidates[1:] {
+		runes := []rune(candidate)
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
+
+// ============================================================================
+// LECTURA DE TECLAS
+// ============================================================================
+
+// Teclas especiales; se representan con runas del área de uso privado para
+// no chocar con caracteres que se pueden escribir
+const (
+	keyNone rune = 0xE000 + iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyDelete
+)
+
+const (
+	keyCtrlA     = 0x01
+	keyCtrlB     = 0x02
+	keyCtrlC     = 0x03
+	keyCtrlD     = 0x04
+	keyCtrlE     = 0x05
+	keyCtrlF     = 0x06
+	keyTab       = 0x09
+	keyKillEnd   = 0x0B // Ctrl-K
+	keyClear     = 0x0C // Ctrl-L
+	keyEnter     = 0x0D
+	keyCtrlN     = 0x0E
+	keyCtrlP     = 0x10
+	keyKillStart = 0x15 // Ctrl-U
+	keyKillWord  = 0x17 // Ctrl-W
+	keyEscape    = 0x1B
+	keyBackspace = 0x7F
+)
+
+// readKey lee una tecla y traduce las secuencias de escape y los atajos
+// equivalentes a las teclas especiales
+func (e *LineEditor) readKey() (rune, error) {
+	r, _, err := e.input.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	switch r {
+	case keyEscape:
+		return e.readEscape()
+	case keyCtrlA:
+		return keyHome, nil
+	case keyCtrlE:
+		return keyEnd, nil
+	case keyCtrlB:
+		return keyLeft, nil
+	case keyCtrlF:
+		return keyRight, nil
+	case keyCtrlP:
+		return keyUp, nil
+	case keyCtrlN:
+		return keyDown, nil
+	case '\n':
+		return keyEnter, nil
+	case 0x08: // Ctrl-H
+		return keyBackspace, nil
+	}
+	return r, nil
+}
+
+// readEscape interpreta las secuencias ESC [ ... y ESC O ... de las flechas,
+// Inicio, Fin y Supr. Las secuencias desconocidas se ignoran.
+func (e *LineEditor) readEscape() (rune, error) {
+	kind, _, err := e.input.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if kind != '[' && kind != 'O' {
+		return keyNone, nil
+	}
+
+	var number strings.Builder
+	for {
+		r, _, err := e.input.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r >= '0' && r <= '9' || r == ';' {
+			number.WriteRune(r)
+			continue
+		}
+
+		switch r {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			return keyRight, nil
+		case 'D':
+			return keyLeft, nil
+		case 'H':
+			return keyHome, nil
+		case 'F':
+			return keyEnd, nil
+		case '~':
+			switch number.String() {
+			case "1", "7":
+				return keyHome, nil
+			case "4", "8":
+				return keyEnd, nil
+			case "3":
+				return keyDelete, nil
+			}
+		}
+		return keyNone, nil
+	}
+}
+
+// ============================================================================
+// HISTORIAL
+// ============================================================================
+
+// AddHistory agrega una línea al historial. Las líneas vacías y las repetidas
+// consecutivas no se guardan; las que llevan contraseñas quedan solo en
+// memoria.
+func (e *LineEditor) AddHistory(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+
+	if e.historyFile == "" || hasSecret(line) {
+		return
+	}
+	file, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
+
+// loadHistory carga las últimas líneas del archivo de historial
+func (e *LineEditor) loadHistory() {
+	if e.historyFile == "" {
+		return
+	}
+	content, err := os.ReadFile(e.historyFile)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// hasSecret indica si la línea lleva una contraseña (login, mkusr, passwd)
+func hasSecret(line string) bool {
+	lower := strings.ToLower(line)
+	return strings.Contains(lower, "-pass=") || strings.Contains(lower, "-old=")
+}
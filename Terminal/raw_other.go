@@ -0,0 +1,19 @@
+//go:build !linux
+
+package Terminal
+
+import "errors"
+
+// Fuera de Linux no se usa el modo crudo: las líneas se leen sin edición
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("modo crudo no soportado en este sistema")
+}
+
+func terminalWidth(fd int) int {
+	return 80
+}
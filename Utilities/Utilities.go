@@ -47,8 +47,29 @@ func OpenFile(name string) (*os.File, error) {
 	return file, nil
 }
 
+// WriteHook se invoca antes de cada escritura de WriteObject con el rango de
+// bytes que se va a sobrescribir. Si retorna error la escritura no se hace.
+type WriteHook func(file *os.File, position int64, length int64) error
+
+var writeHook WriteHook
+
+// SetWriteHook registra la función que observa las escrituras (los snapshots
+// la usan para guardar el contenido anterior de los bytes modificados).
+// Retorna la función registrada antes, para poder restaurarla.
+func SetWriteHook(hook WriteHook) WriteHook {
+	previous := writeHook
+	writeHook = hook
+	return previous
+}
+
 //función para escribir el objeto en el archivo binario
 func WriteObject(file *os.File, data interface{}, position int64) error {
+	if writeHook != nil {
+		if err := writeHook(file, position, int64(binary.Size(data))); err != nil {
+			fmt.Println("Error preparando la escritura:", err)
+			return err
+		}
+	}
 	file.Seek(position, 0)
 	err := binary.Write(file, binary.LittleEndian, data)
 	if err != nil {
@@ -76,3 +97,64 @@ return "N/A"
 t := time.Unix(timestamp, 0)
 return t.Format("2006-01-02 15:04:05")
 }
+
+// Formato de las fechas guardadas en inodos y superblock: cabe en los 17
+// bytes de los campos y se ordena igual como texto que como fecha
+const TimestampLayout = "20060102 15:04:05"
+
+// CurrentTimestamp retorna la fecha y hora actual en TimestampLayout
+func CurrentTimestamp() string {
+	return time.Now().Format(TimestampLayout)
+}
+
+// FormatTimestamp convierte una fecha guardada en TimestampLayout a formato
+// legible; las fechas con otro formato (sistemas antiguos) se retornan igual
+func FormatTimestamp(stored string) string {
+	t, err := time.ParseInLocation(TimestampLayout, stored, time.Local)
+	if err != nil {
+		return stored
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// Códigos de error devueltos por los comandos para que la API pueda
+// distinguir éxito y fallo sin interpretar la salida en texto
+const (
+	ErrInvalidArgument  = "INVALID_ARGUMENT"
+	ErrNotFound         = "NOT_FOUND"
+	ErrAlreadyExists    = "ALREADY_EXISTS"
+	ErrPermissionDenied = "PERMISSION_DENIED"
+	ErrNotLoggedIn      = "NOT_LOGGED_IN"
+	ErrNoSpace          = "NO_SPACE"
+	ErrUnsupported      = "UNSUPPORTED"
+	ErrIO               = "IO_ERROR"
+	ErrUnknownCommand   = "UNKNOWN_COMMAND"
+	ErrScriptFailed     = "SCRIPT_FAILED"
+	ErrIncomplete       = "INCOMPLETE"
+)
+
+// CommandError es el error tipado que retornan los comandos
+type CommandError struct {
+	Code    string
+	Message string
+}
+
+func (e *CommandError) Error() string {
+	return e.Message
+}
+
+// NewCommandError crea un CommandError con el código y el mensaje formateado
+func NewCommandError(code string, format string, args ...interface{}) error {
+	return &CommandError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode obtiene el código de un error; los errores no tipados se reportan como IO_ERROR
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if cmdErr, ok := err.(*CommandError); ok {
+		return cmdErr.Code
+	}
+	return ErrIO
+}
@@ -1,20 +1,36 @@
 package main
 
 import (
+	"flag"
 	"proyecto1/Analyzer"
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 )
 
+// Pruebas automatizadas de mkdisk, fdisk, mount y mkfs sobre un disco de
+// prueba. Vive en su propio comando para no chocar con el main de la API.
+//
+//	go run ./cmd/pruebas
+//	go run ./cmd/pruebas -dir=/tmp/pruebas   (o MIA_TEST_DIR=/tmp/pruebas)
 func main() {
+	// Directorio del disco de prueba: -dir, MIA_TEST_DIR o ./test
+	defaultDir := "./test"
+	if dir := os.Getenv("MIA_TEST_DIR"); dir != "" {
+		defaultDir = dir
+	}
+	testDir := flag.String("dir", defaultDir, "Directorio donde se crea el disco de prueba (por defecto MIA_TEST_DIR o ./test)")
+	flag.Parse()
+
 	fmt.Println("╔════════════════════════════════════════════════════════╗")
 	fmt.Println("║      PRUEBAS AUTOMATIZADAS - MKFS Y FDISK             ║")
 	fmt.Println("║      Fecha:", time.Now().Format("2006-01-02 15:04:05"), "                    ║")
 	fmt.Println("╚════════════════════════════════════════════════════════╝")
 	fmt.Println()
 
-	testDir := "/home/jose/Documentos/proyecto2/Backend/test"
-	diskPath := testDir + "/disco_pruebas.mia"
+	diskPath := filepath.Join(*testDir, "disco_pruebas.mia")
+	pathArg := "-path=\"" + diskPath + "\""
 
 	// FASE 1: CREACIÓN DE DISCO
 	fmt.Println("═══════════════════════════════════════════════════════")
@@ -22,7 +38,7 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 	
-	executeCommand("mkdisk -size=30 -unit=m -path=" + diskPath, "Crear disco de 30 MB")
+	executeCommand("mkdisk -size=30 -unit=m " + pathArg, "Crear disco de 30 MB")
 
 	// FASE 2: CREAR PARTICIONES
 	fmt.Println("\n═══════════════════════════════════════════════════════")
@@ -30,11 +46,11 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("fdisk -size=6 -unit=m -path="+diskPath+" -name=Part1 -type=p", "Crear Part1 (6 MB)")
-	executeCommand("fdisk -size=8 -unit=m -path="+diskPath+" -name=Part2 -type=p", "Crear Part2 (8 MB)")
-	executeCommand("fdisk -size=10 -unit=m -path="+diskPath+" -name=Extended1 -type=e", "Crear Extended (10 MB)")
-	executeCommand("fdisk -size=3 -unit=m -path="+diskPath+" -name=Logica1 -type=l", "Crear Logica1 (3 MB)")
-	executeCommand("fdisk -size=3 -unit=m -path="+diskPath+" -name=Logica2 -type=l", "Crear Logica2 (3 MB)")
+	executeCommand("fdisk -size=6 -unit=m "+pathArg+" -name=Part1 -type=p", "Crear Part1 (6 MB)")
+	executeCommand("fdisk -size=8 -unit=m "+pathArg+" -name=Part2 -type=p", "Crear Part2 (8 MB)")
+	executeCommand("fdisk -size=10 -unit=m "+pathArg+" -name=Extended1 -type=e", "Crear Extended (10 MB)")
+	executeCommand("fdisk -size=3 -unit=m "+pathArg+" -name=Logica1 -type=l", "Crear Logica1 (3 MB)")
+	executeCommand("fdisk -size=3 -unit=m "+pathArg+" -name=Logica2 -type=l", "Crear Logica2 (3 MB)")
 
 	// FASE 3: PRUEBAS DE FDISK ADD (Agregar)
 	fmt.Println("\n═══════════════════════════════════════════════════════")
@@ -42,8 +58,8 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("fdisk -add=2 -unit=m -path="+diskPath+" -name=Part1", "Agregar 2 MB a Part1")
-	executeCommand("fdisk -add=1024 -unit=k -path="+diskPath+" -name=Logica1", "Agregar 1024 KB a Logica1")
+	executeCommand("fdisk -add=2 -unit=m "+pathArg+" -name=Part1", "Agregar 2 MB a Part1")
+	executeCommand("fdisk -add=1024 -unit=k "+pathArg+" -name=Logica1", "Agregar 1024 KB a Logica1")
 
 	// FASE 4: PRUEBAS DE FDISK ADD (Quitar)
 	fmt.Println("\n═══════════════════════════════════════════════════════")
@@ -51,8 +67,8 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("fdisk -add=-1 -unit=m -path="+diskPath+" -name=Part2", "Quitar 1 MB de Part2")
-	executeCommand("fdisk -add=-512 -unit=k -path="+diskPath+" -name=Logica2", "Quitar 512 KB de Logica2")
+	executeCommand("fdisk -add=-1 -unit=m "+pathArg+" -name=Part2", "Quitar 1 MB de Part2")
+	executeCommand("fdisk -add=-512 -unit=k "+pathArg+" -name=Logica2", "Quitar 512 KB de Logica2")
 
 	// FASE 5: MONTAR PARTICIONES
 	fmt.Println("\n═══════════════════════════════════════════════════════")
@@ -60,9 +76,9 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("mount -path="+diskPath+" -name=Part1", "Montar Part1")
-	executeCommand("mount -path="+diskPath+" -name=Part2", "Montar Part2")
-	executeCommand("mount -path="+diskPath+" -name=Logica1", "Montar Logica1")
+	executeCommand("mount "+pathArg+" -name=Part1", "Montar Part1")
+	executeCommand("mount "+pathArg+" -name=Part2", "Montar Part2")
+	executeCommand("mount "+pathArg+" -name=Logica1", "Montar Logica1")
 	executeCommand("mounted", "Ver particiones montadas")
 
 	// FASE 6: FORMATEAR CON EXT2
@@ -71,8 +87,8 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("mkfs -id=851A -type=full", "Formatear Part1 con EXT2 (por defecto)")
-	executeCommand("mkfs -id=853A -type=full -fs=2fs", "Formatear Logica1 con EXT2 (explícito)")
+	executeCommand("mkfs -id=001A -type=full", "Formatear Part1 con EXT2 (por defecto)")
+	executeCommand("mkfs -id=003A -type=full -fs=2fs", "Formatear Logica1 con EXT2 (explícito)")
 
 	// FASE 7: FORMATEAR CON EXT3
 	fmt.Println("\n═══════════════════════════════════════════════════════")
@@ -80,7 +96,7 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("mkfs -id=852A -type=full -fs=3fs", "Formatear Part2 con EXT3 (con journaling)")
+	executeCommand("mkfs -id=002A -type=full -fs=3fs", "Formatear Part2 con EXT3 (con journaling)")
 
 	// FASE 8: VERIFICAR INFORMACIÓN
 	fmt.Println("\n═══════════════════════════════════════════════════════")
@@ -88,9 +104,9 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("info -id=851A", "Info de Part1 (EXT2)")
-	executeCommand("info -id=852A", "Info de Part2 (EXT3)")
-	executeCommand("info -id=853A", "Info de Logica1 (EXT2)")
+	executeCommand("info -id=001A", "Info de Part1 (EXT2)")
+	executeCommand("info -id=002A", "Info de Part2 (EXT3)")
+	executeCommand("info -id=003A", "Info de Logica1 (EXT2)")
 
 	// FASE 9: OPERACIONES EN EXT2
 	fmt.Println("\n═══════════════════════════════════════════════════════")
@@ -98,7 +114,7 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("login -user=root -pass=123 -id=851A", "Login en Part1 (EXT2)")
+	executeCommand("login -user=root -pass=123 -id=001A", "Login en Part1 (EXT2)")
 	executeCommand("mkdir -path=/docs", "Crear directorio /docs")
 	executeCommand("mkfile -path=/docs/test.txt -size=100", "Crear archivo test.txt")
 	executeCommand("cat", "Mostrar users.txt")
@@ -110,7 +126,7 @@ func main() {
 	fmt.Println("═══════════════════════════════════════════════════════")
 	fmt.Println()
 
-	executeCommand("login -user=root -pass=123 -id=852A", "Login en Part2 (EXT3)")
+	executeCommand("login -user=root -pass=123 -id=002A", "Login en Part2 (EXT3)")
 	executeCommand("mkdir -path=/data", "Crear directorio /data")
 	executeCommand("mkfile -path=/data/archivo.txt -size=200", "Crear archivo archivo.txt")
 	executeCommand("cat", "Mostrar users.txt")
@@ -145,13 +161,16 @@ func main() {
 	fmt.Println()
 }
 
+// Contexto compartido por todos los comandos de la prueba (mantiene la sesión)
+var testContext = Analyzer.NewContext(nil)
+
 func executeCommand(cmd string, description string) {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("Test:", description)
 	fmt.Println("Comando:", cmd)
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	
-	output := Analyzer.ProcessCommandForAPI(cmd)
+	output, _ := Analyzer.ProcessCommandForAPI(testContext, cmd)
 	fmt.Print(output)
 	fmt.Println()
 }
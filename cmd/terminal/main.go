@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"proyecto1/Analyzer"
+	"proyecto1/DiskManagement"
+	"proyecto1/FileSystem"
+	"proyecto1/Terminal"
+	"strconv"
+	"strings"
+)
+
+// Terminal interactiva del simulador: ejecuta los mismos comandos que
+// POST /execute, con edición de línea, historial y autocompletado (Tab).
+//
+//	go run ./cmd/terminal
+//	go run ./cmd/terminal -script=test/prueba.smia   (ejecuta y deja la terminal abierta)
+//
+// Usa el mismo archivo de estado que la API (MIA_STATE_FILE); no conviene
+// abrir ambas a la vez sobre los mismos discos.
+func main() {
+	script := flag.String("script", "", "Script a ejecutar antes de abrir la terminal")
+	onError := flag.String("onerror", "continue", "Qué hacer si un comando del script falla: continue o stop")
+	history := flag.String("history", defaultHistoryFile(), "Archivo del historial (vacío para no guardarlo)")
+	flag.Parse()
+
+	// Restaurar discos y montajes de la ejecución anterior
+	if statePath := os.Getenv("MIA_STATE_FILE"); statePath != "" {
+		DiskManagement.SetStateFile(statePath)
+	}
+	if prefix := os.Getenv("MIA_ID_PREFIX"); prefix != "" {
+		if err := DiskManagement.SetIDPrefix(prefix); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := DiskManagement.LoadState(os.Stdout); err != nil {
+		fmt.Println("Advertencia:", err)
+	}
+	FileSystem.UpgradeMountedPartitions(os.Stdout)
+
+	ctx := Analyzer.NewContext(nil)
+	editor := Terminal.NewLineEditor(*history, func(line string) (int, []string) {
+		return Analyzer.Complete(ctx, line)
+	})
+
+	if editor.Interactive() {
+		fmt.Println("=== SIMULADOR DE SISTEMA DE ARCHIVOS MIA - TERMINAL ===")
+		fmt.Println("Escriba help para ver los comandos, Tab para completar y exit (o Ctrl-D) para salir")
+		fmt.Println("=======================================================")
+	}
+
+	if *script != "" {
+		command := fmt.Sprintf("exec -path=%s -onerror=%s", strconv.Quote(*script), *onError)
+		if result := Analyzer.ExecuteCommand(ctx, command); result.Status == "error" && *onError == "stop" {
+			os.Exit(1)
+		}
+	}
+
+	for {
+		line, err := editor.ReadLine(prompt(ctx))
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		result := Analyzer.ExecuteCommand(ctx, line)
+		if result.Status == "error" {
+			fmt.Printf("[%s] %s\n", result.Code, result.Message)
+		}
+		if result.Command == "exit" {
+			break
+		}
+	}
+}
+
+// prompt muestra el usuario y la partición de la sesión activa
+func prompt(ctx *Analyzer.Context) string {
+	if FileSystem.IsUserLoggedIn(ctx.Session) {
+		return fmt.Sprintf("mia %s@%s> ", ctx.Session.Username, ctx.Session.PartitionID)
+	}
+	return "mia> "
+}
+
+// defaultHistoryFile retorna ~/.mia_history, o vacío si no hay carpeta personal
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".mia_history")
+}
@@ -4,14 +4,20 @@ import (
 	"proyecto1/Analyzer"
 	"proyecto1/FileSystem"
 	"proyecto1/DiskManagement"
+	"proyecto1/Reportes"
 	"proyecto1/Structs"
 	"proyecto1/Utilities"
+	"bytes"
 	"fmt"
+	"encoding/base64"
 	"encoding/json"
+	"io"
 	"net/http"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type CommandRequest struct {
@@ -19,8 +25,11 @@ type CommandRequest struct {
 }
 
 type CommandResponse struct {
-	Output string `json:"output"`
-	Error  string `json:"error,omitempty"`
+	Success bool                    `json:"success"`
+	Output  string                  `json:"output"`
+	Results []Structs.CommandResult `json:"results,omitempty"`
+	Token   string                  `json:"token,omitempty"` // Nuevo token si el comando inició sesión
+	Error   string                  `json:"error,omitempty"`
 }
 
 type SessionResponse struct {
@@ -29,6 +38,7 @@ type SessionResponse struct {
 	UserID      int    `json:"user_id"`
 	GroupID     int    `json:"group_id"`
 	PartitionID string `json:"partition_id"`
+	Token       string `json:"token,omitempty"`
 }
 
 type LoginRequest struct {
@@ -64,6 +74,9 @@ type PartitionInfo struct {
 	HasFS      bool   `json:"has_fs"` // Indica si tiene sistema de archivos (mkfs)
 }
 
+// Nombre de la cookie que guarda el token de sesión
+const sessionCookieName = "session_token"
+
 type DisksResponse struct {
 	Disks []DiskInfo `json:"disks"`
 }
@@ -77,8 +90,23 @@ func main() {
 	fmt.Println("  POST /login   - Iniciar sesión (solo interfaz web)")
 	fmt.Println("  POST /logout  - Cerrar sesión (solo interfaz web)")
 	fmt.Println("  GET  /disks   - Obtener información de discos")
+	fmt.Println("  GET  /reports - Datos de un reporte en JSON o CSV")
 	fmt.Println("================================================")
 
+	// Restaurar discos y montajes de la ejecución anterior
+	if statePath := os.Getenv("MIA_STATE_FILE"); statePath != "" {
+		DiskManagement.SetStateFile(statePath)
+	}
+	if prefix := os.Getenv("MIA_ID_PREFIX"); prefix != "" {
+		if err := DiskManagement.SetIDPrefix(prefix); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := DiskManagement.LoadState(os.Stdout); err != nil {
+		fmt.Println("Advertencia:", err)
+	}
+	FileSystem.UpgradeMountedPartitions(os.Stdout)
+
 	http.HandleFunc("/execute", handleCommand)
 	http.HandleFunc("/session", handleSession)
 	http.HandleFunc("/login", handleLogin)
@@ -88,6 +116,7 @@ func main() {
 	http.HandleFunc("/filesystem/directory", handleDirectoryContents)
 	http.HandleFunc("/filesystem/file", handleFileContent)
 	http.HandleFunc("/filesystem/journaling", handleJournaling)
+	http.HandleFunc("/reports", handleReports)
 	http.HandleFunc("/", handleRoot)
 
 	log.Fatal(http.ListenAndServe(":5000", nil))
@@ -106,7 +135,7 @@ func handleRoot(w http.ResponseWriter, r *http.Request) {
 func handleSession(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == "OPTIONS" {
@@ -122,11 +151,11 @@ func handleSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Obtener el estado de la sesión actual
-	session := FileSystem.CurrentSession
+	// Obtener la sesión de quien hace la petición
+	session := FileSystem.GetSession(sessionTokenFromRequest(r))
 	
 	var response SessionResponse
-	if session != nil && session.IsActive {
+	if session != nil {
 		response = SessionResponse{
 			IsActive:    true,
 			Username:    session.Username,
@@ -146,7 +175,7 @@ func handleSession(w http.ResponseWriter, r *http.Request) {
 func handleLogin(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == "OPTIONS" {
@@ -183,37 +212,45 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Llamar a la función de login del FileSystem
-	FileSystem.Login(req.Username, req.Password, req.PartitionID)
-
-	// Verificar si el login fue exitoso
-	session := FileSystem.CurrentSession
-	if session != nil && session.IsActive {
-		response := LoginResponse{
-			Success: true,
-			Message: "Inicio de sesión exitoso",
-			User: &SessionResponse{
-				IsActive:    true,
-				Username:    session.Username,
-				UserID:      session.UserID,
-				GroupID:     session.GroupID,
-				PartitionID: session.PartitionID,
-			},
+	// Llamar a la función de login del FileSystem con la sesión actual del cliente
+	// login puede reescribir users.txt al migrar una contraseña en texto plano
+	current := FileSystem.GetSession(sessionTokenFromRequest(r))
+	unlock := DiskManagement.LockPartition(req.PartitionID)
+	session, err := FileSystem.Login(os.Stdout, current, req.Username, req.Password, req.PartitionID)
+	unlock()
+	if err != nil {
+		status := http.StatusUnauthorized
+		if Utilities.ErrorCode(err) == Utilities.ErrAlreadyExists {
+			status = http.StatusConflict
 		}
-		json.NewEncoder(w).Encode(response)
-	} else {
-		w.WriteHeader(http.StatusUnauthorized)
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(LoginResponse{
 			Success: false,
-			Message: "Credenciales incorrectas o partición no encontrada",
+			Message: err.Error(),
 		})
+		return
 	}
+
+	setSessionCookie(w, session.Token)
+	response := LoginResponse{
+		Success: true,
+		Message: "Inicio de sesión exitoso",
+		User: &SessionResponse{
+			IsActive:    true,
+			Username:    session.Username,
+			UserID:      session.UserID,
+			GroupID:     session.GroupID,
+			PartitionID: session.PartitionID,
+			Token:       session.Token,
+		},
+	}
+	json.NewEncoder(w).Encode(response)
 }
 
 func handleLogout(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == "OPTIONS" {
@@ -230,29 +267,62 @@ func handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Llamar a la función de logout del FileSystem
-	FileSystem.Logout()
-
-	// Verificar si el logout fue exitoso
-	session := FileSystem.CurrentSession
-	if session == nil || !session.IsActive {
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"message": "Sesión cerrada exitosamente",
-		})
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
+	// Llamar a la función de logout del FileSystem con la sesión del cliente
+	session := FileSystem.GetSession(sessionTokenFromRequest(r))
+	if err := FileSystem.Logout(os.Stdout, session); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
-			"message": "Error al cerrar sesión",
+			"message": err.Error(),
 		})
+		return
 	}
+
+	clearSessionCookie(w)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Sesión cerrada exitosamente",
+	})
+}
+
+// sessionTokenFromRequest - Obtener el token de sesión del header Authorization
+// (Bearer <token>) o, si no viene, de la cookie de sesión
+func sessionTokenFromRequest(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// setSessionCookie - Enviar al cliente la cookie con el token de sesión
+func setSessionCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearSessionCookie - Eliminar la cookie de sesión del cliente
+func clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
 }
 
 func handleDisks(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == "OPTIONS" {
@@ -268,135 +338,124 @@ func handleDisks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Obtener todos los discos del mapa DrivePathMap
+	// Obtener todos los discos conocidos y una copia de las particiones montadas
 	var disks []DiskInfo
-	diskPaths := make(map[string]bool) // Para evitar duplicados
+	mountedPartitions := DiskManagement.GetMountedPartitions()
 
-	// Primero, obtener discos de las particiones montadas
-	for _, mountedPart := range DiskManagement.MountedPartitions {
-		if !diskPaths[mountedPart.Path] {
-			diskPaths[mountedPart.Path] = true
+	// Procesar cada disco único
+	for _, diskPath := range DiskManagement.GetKnownDisks() {
+		if diskInfo, ok := readDiskInfo(diskPath, mountedPartitions); ok {
+			disks = append(disks, diskInfo)
 		}
 	}
 
-	// También agregar discos del DrivePathMap
-	for _, diskPath := range DiskManagement.DrivePathMap {
-		if !diskPaths[diskPath] {
-			diskPaths[diskPath] = true
-		}
+	response := DisksResponse{
+		Disks: disks,
 	}
-	
-	// NUEVO: También buscar discos del DiskOrderList (orden cronológico)
-	for _, diskPath := range DiskManagement.DiskOrderList {
-		if !diskPaths[diskPath] {
-			diskPaths[diskPath] = true
-		}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// readDiskInfo lee el MBR y las particiones de un disco para el endpoint /disks
+func readDiskInfo(diskPath string, mountedPartitions map[string]Structs.MountedPartition) (DiskInfo, bool) {
+	// Verificar si el archivo existe
+	if _, err := os.Stat(diskPath); os.IsNotExist(err) {
+		return DiskInfo{}, false
 	}
 
-	// Procesar cada disco único
-	for diskPath := range diskPaths {
-		// Verificar si el archivo existe
-		if _, err := os.Stat(diskPath); os.IsNotExist(err) {
-			continue
-		}
+	// Bloquear el disco en modo lectura mientras se lee su estructura
+	unlock := DiskManagement.RLockDisk(diskPath)
+	defer unlock()
 
-		// Abrir el archivo del disco
-		file, err := Utilities.OpenFile(diskPath)
-		if err != nil {
-			continue
-		}
+	// Abrir el archivo del disco
+	file, err := Utilities.OpenFile(diskPath)
+	if err != nil {
+		return DiskInfo{}, false
+	}
+	defer file.Close()
 
-		// Leer el MBR
-		var mbr Structs.MBR
-		err = Utilities.ReadObject(file, &mbr, 0)
-		if err != nil {
-			file.Close()
-			continue
-		}
+	// Leer el MBR
+	var mbr Structs.MBR
+	err = Utilities.ReadObject(file, &mbr, 0)
+	if err != nil {
+		return DiskInfo{}, false
+	}
 
-		// Extraer información del disco
-		diskName := diskPath[strings.LastIndex(diskPath, "/")+1:]
-		fit := strings.TrimRight(string(mbr.Fit[:]), "\x00")
-		
-		diskInfo := DiskInfo{
-			Path:       diskPath,
-			Name:       diskName,
-			Size:       mbr.MbrSize,
-			Fit:        fit,
-			Partitions: []PartitionInfo{},
-		}
-
-		// Leer particiones primarias y extendidas
-		for i := 0; i < 4; i++ {
-			partition := mbr.Partitions[i]
-			
-			// Mostrar todas las particiones, incluso las que no tienen tamaño (para completitud)
-			// Solo omitir las que realmente están vacías (size == 0)
-			if partition.Size == 0 {
-				continue
-			}
+	// Extraer información del disco
+	diskName := diskPath[strings.LastIndex(diskPath, "/")+1:]
+	fit := strings.TrimRight(string(mbr.Fit[:]), "\x00")
+	
+	diskInfo := DiskInfo{
+		Path:       diskPath,
+		Name:       diskName,
+		Size:       mbr.MbrSize,
+		Fit:        fit,
+		Partitions: []PartitionInfo{},
+	}
 
-			partName := strings.TrimRight(string(partition.Name[:]), "\x00")
-			partType := string(partition.Type[0])
-			partFit := strings.TrimRight(string(partition.Fit[:]), "\x00")
-
-			// Verificar si está montada
-			isMounted := false
-			mountID := ""
-			for id, mountedPart := range DiskManagement.MountedPartitions {
-				if mountedPart.Path == diskPath && mountedPart.PartitionName == partName {
-					isMounted = true
-					mountID = id
-					break
-				}
-			}
+	// Leer particiones primarias y extendidas
+	for i := 0; i < 4; i++ {
+		partition := mbr.Partitions[i]
+		
+		// Mostrar todas las particiones, incluso las que no tienen tamaño (para completitud)
+		// Solo omitir las que realmente están vacías (size == 0)
+		if partition.Size == 0 {
+			continue
+		}
 
-			// Determinar el estado de la partición
-			status := "No montada"
-			if partition.Status[0] == '1' {
-				status = "Activa"
-			}
-			if isMounted {
-				status = "Montada"
-			}
+		partName := strings.TrimRight(string(partition.Name[:]), "\x00")
+		partType := string(partition.Type[0])
+		partFit := strings.TrimRight(string(partition.Fit[:]), "\x00")
 
-			// Verificar si tiene sistema de archivos (solo para particiones primarias)
-			hasFS := false
-			if partType == "P" || partType == "p" {
-				hasFS = hasFileSystem(file, partition.Start)
+		// Verificar si está montada
+		isMounted := false
+		mountID := ""
+		for id, mountedPart := range mountedPartitions {
+			if mountedPart.Path == diskPath && mountedPart.PartitionName == partName {
+				isMounted = true
+				mountID = id
+				break
 			}
+		}
 
-			partInfo := PartitionInfo{
-				Name:      partName,
-				Type:      getPartitionType(partType),
-				Size:      partition.Size,
-				Fit:       partFit,
-				Status:    status,
-				Start:     partition.Start,
-				IsMounted: isMounted,
-				MountID:   mountID,
-				IsLogical: false,
-				HasFS:     hasFS,
-			}
+		// Determinar el estado de la partición
+		status := "No montada"
+		if partition.Status[0] == '1' {
+			status = "Activa"
+		}
+		if isMounted {
+			status = "Montada"
+		}
 
-			diskInfo.Partitions = append(diskInfo.Partitions, partInfo)
+		// Verificar si tiene sistema de archivos (solo para particiones primarias)
+		hasFS := false
+		if partType == "P" || partType == "p" {
+			hasFS = hasFileSystem(file, partition.Start)
+		}
 
-			// Si es partición extendida, leer las particiones lógicas
-			if partType == "E" || partType == "e" {
-				logicalParts := readLogicalPartitions(file, partition.Start, diskPath, partName)
-				diskInfo.Partitions = append(diskInfo.Partitions, logicalParts...)
-			}
+		partInfo := PartitionInfo{
+			Name:      partName,
+			Type:      getPartitionType(partType),
+			Size:      partition.Size,
+			Fit:       partFit,
+			Status:    status,
+			Start:     partition.Start,
+			IsMounted: isMounted,
+			MountID:   mountID,
+			IsLogical: false,
+			HasFS:     hasFS,
 		}
 
-		file.Close()
-		disks = append(disks, diskInfo)
-	}
+		diskInfo.Partitions = append(diskInfo.Partitions, partInfo)
 
-	response := DisksResponse{
-		Disks: disks,
+		// Si es partición extendida, leer las particiones lógicas
+		if partType == "E" || partType == "e" {
+			logicalParts := readLogicalPartitions(file, partition.Start, diskPath, partName, mountedPartitions)
+			diskInfo.Partitions = append(diskInfo.Partitions, logicalParts...)
+		}
 	}
 
-	json.NewEncoder(w).Encode(response)
+	return diskInfo, true
 }
 
 func getPartitionType(partType string) string {
@@ -430,7 +489,7 @@ func hasFileSystem(file *os.File, partitionStart int32) bool {
 	return false
 }
 
-func readLogicalPartitions(file *os.File, extendedStart int32, diskPath string, extendedName string) []PartitionInfo {
+func readLogicalPartitions(file *os.File, extendedStart int32, diskPath string, extendedName string, mountedPartitions map[string]Structs.MountedPartition) []PartitionInfo {
 	var logicalParts []PartitionInfo
 	ebrPosition := extendedStart
 
@@ -441,13 +500,8 @@ func readLogicalPartitions(file *os.File, extendedStart int32, diskPath string,
 			break
 		}
 
-		partName := strings.TrimRight(string(ebr.Part_name[:]), "\x00")
-		// Si no hay nombre, terminar la búsqueda
-		if partName == "" {
-			break
-		}
-
-		// Si no hay tamaño, esta partición lógica no es válida
+		// Si no hay tamaño, esta partición lógica no es válida (por ejemplo,
+		// la cabeza de la lista después de eliminar la primera lógica)
 		if ebr.Part_size == 0 {
 			if ebr.Part_next == -1 {
 				break
@@ -456,12 +510,18 @@ func readLogicalPartitions(file *os.File, extendedStart int32, diskPath string,
 			continue
 		}
 
+		partName := strings.TrimRight(string(ebr.Part_name[:]), "\x00")
+		// Si no hay nombre, terminar la búsqueda
+		if partName == "" {
+			break
+		}
+
 		partFit := string(ebr.Part_fit[0])
 
 		// Verificar si está montada
 		isMounted := false
 		mountID := ""
-		for id, mountedPart := range DiskManagement.MountedPartitions {
+		for id, mountedPart := range mountedPartitions {
 			if mountedPart.Path == diskPath && mountedPart.PartitionName == partName && mountedPart.IsLogical {
 				isMounted = true
 				mountID = id
@@ -508,7 +568,7 @@ func readLogicalPartitions(file *os.File, extendedStart int32, diskPath string,
 func handleCommand(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
@@ -543,8 +603,21 @@ func handleCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Procesar el comando y obtener la respuesta
-	output := Analyzer.ProcessCommandForAPI(req.Command)
+	// Procesar el comando con la sesión de quien hace la petición
+	session := FileSystem.GetSession(sessionTokenFromRequest(r))
+	ctx := Analyzer.NewContext(session)
+	output, results := Analyzer.ProcessCommandForAPI(ctx, req.Command)
+
+	// Si el comando inició o cerró sesión, actualizar la cookie del cliente
+	newToken := ""
+	if ctx.Session != session {
+		if ctx.Session != nil {
+			newToken = ctx.Session.Token
+			setSessionCookie(w, newToken)
+		} else {
+			clearSessionCookie(w)
+		}
+	}
 	
 	// Verificar si el cliente quiere respuesta en texto plano
 	acceptHeader := r.Header.Get("Accept")
@@ -558,7 +631,16 @@ func handleCommand(w http.ResponseWriter, r *http.Request) {
 	// Por defecto devolver JSON pero con formato mejorado
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	response := CommandResponse{
-		Output: output,
+		Success: true,
+		Output:  output,
+		Results: results,
+		Token:   newToken,
+	}
+	for _, result := range results {
+		if result.Status == "error" {
+			response.Success = false
+			break
+		}
 	}
 
 	// Usar encoder con indentación para mejor legibilidad
@@ -571,7 +653,7 @@ func handleCommand(w http.ResponseWriter, r *http.Request) {
 func handleFileSystemTree(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == "OPTIONS" {
@@ -614,7 +696,7 @@ func handleFileSystemTree(w http.ResponseWriter, r *http.Request) {
 func handleDirectoryContents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == "OPTIONS" {
@@ -665,8 +747,9 @@ func handleDirectoryContents(w http.ResponseWriter, r *http.Request) {
 // handleFileContent - Obtener el contenido de un archivo
 func handleFileContent(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, PATCH, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Range")
+	w.Header().Set("Access-Control-Expose-Headers", "Content-Range, Accept-Ranges")
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == "OPTIONS" {
@@ -674,10 +757,15 @@ func handleFileContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.Method == "PATCH" {
+		handleFileWrite(w, r)
+		return
+	}
+
 	if r.Method != "GET" {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Método no permitido. Use GET",
+			"error": "Método no permitido. Use GET o PATCH",
 		})
 		return
 	}
@@ -702,27 +790,273 @@ func handleFileContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Obtener el contenido del archivo
-	content, err := FileSystem.GetFileContent(partitionID, filePath)
+	// Rango pedido: header Range (bytes=a-b, bytes=a- o bytes=-n) o los
+	// parámetros offset y length; sin ninguno se lee el archivo completo
+	w.Header().Set("Accept-Ranges", "bytes")
+	offset, length, suffix := 0, -1, 0
+	rangeHeader := r.Header.Get("Range")
+	var err error
+	if rangeHeader != "" {
+		offset, length, suffix, err = parseRangeHeader(rangeHeader)
+	} else {
+		offset, length, err = parseRangeQuery(r)
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		// Un Range mal formado no se puede satisfacer; offset o length que no
+		// son números son una petición inválida
+		status := http.StatusBadRequest
+		if rangeHeader != "" {
+			status = http.StatusRequestedRangeNotSatisfiable
+		}
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(map[string]string{
 			"error": err.Error(),
 		})
 		return
 	}
 
+	// Los últimos n bytes necesitan el tamaño del archivo
+	if suffix > 0 {
+		info, err := FileSystem.GetFileRange(partitionID, filePath, 0, 0)
+		if err != nil {
+			w.WriteHeader(statusForError(err))
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if suffix < info.Size {
+			offset = info.Size - suffix
+		}
+	}
+
+	// Obtener el contenido del rango
+	fileRange, err := FileSystem.GetFileRange(partitionID, filePath, offset, length)
+	if err != nil {
+		status := statusForError(err)
+		if rangeHeader != "" && Utilities.ErrorCode(err) == Utilities.ErrInvalidArgument {
+			status = http.StatusRequestedRangeNotSatisfiable
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Con encoding=raw o Accept: application/octet-stream se envían los bytes
+	// tal cual; si no, JSON con el contenido como texto o en base64
+	raw := r.URL.Query().Get("encoding") == "raw" || strings.Contains(r.Header.Get("Accept"), "application/octet-stream")
+	if raw {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+
+	if rangeHeader != "" {
+		if fileRange.Length == 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", fileRange.Size))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "El rango pedido está fuera del archivo",
+			})
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", fileRange.Offset, fileRange.Offset+fileRange.Length-1, fileRange.Size))
+		w.WriteHeader(http.StatusPartialContent)
+	}
+
+	if raw {
+		w.Write(fileRange.Content)
+		return
+	}
+
+	// Por defecto el texto va tal cual y lo binario (no UTF-8 o con bytes
+	// nulos) en base64; encoding=base64 lo fuerza siempre
+	encoding := "utf-8"
+	content := string(fileRange.Content)
+	if r.URL.Query().Get("encoding") == "base64" || !utf8.Valid(fileRange.Content) || bytes.IndexByte(fileRange.Content, 0) != -1 {
+		encoding = "base64"
+		content = base64.StdEncoding.EncodeToString(fileRange.Content)
+	}
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"path":    filePath,
-		"content": content,
+		"path":     filePath,
+		"content":  content,
+		"encoding": encoding,
+		"offset":   fileRange.Offset,
+		"length":   fileRange.Length,
+		"size":     fileRange.Size,
 	})
 }
 
+// FileWriteRequest es el cuerpo JSON de PATCH /filesystem/file. Encoding
+// "base64" indica que Content viene en base64 (para datos binarios).
+type FileWriteRequest struct {
+	Path     string `json:"path"`
+	Offset   int    `json:"offset"`
+	Append   bool   `json:"append"`
+	Content  string `json:"content"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// handleFileWrite - Escribir un rango de un archivo (o agregar al final) con
+// la sesión de quien hace la petición. Acepta JSON o, con Content-Type
+// application/octet-stream, los bytes en el cuerpo y path, offset y append
+// como parámetros de la URL.
+func handleFileWrite(w http.ResponseWriter, r *http.Request) {
+	var req FileWriteRequest
+	var content []byte
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/octet-stream") {
+		query := r.URL.Query()
+		req.Path = query.Get("path")
+		req.Append = query.Get("append") == "true"
+		if value := query.Get("offset"); value != "" {
+			offset, err := strconv.Atoi(value)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "El parámetro 'offset' debe ser un número",
+				})
+				return
+			}
+			req.Offset = offset
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Error leyendo el cuerpo: " + err.Error(),
+			})
+			return
+		}
+		content = data
+	} else {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Error al decodificar JSON: " + err.Error(),
+			})
+			return
+		}
+		content = []byte(req.Content)
+		if req.Encoding == "base64" {
+			data, err := base64.StdEncoding.DecodeString(req.Content)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "El contenido no es base64 válido: " + err.Error(),
+				})
+				return
+			}
+			content = data
+		}
+	}
+	if req.Path == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "El campo 'path' es requerido",
+		})
+		return
+	}
+
+	session := FileSystem.GetSession(sessionTokenFromRequest(r))
+	if session == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "No hay una sesión activa",
+		})
+		return
+	}
+
+	unlock := DiskManagement.LockPartition(session.PartitionID)
+	written, err := FileSystem.Write(io.Discard, session, req.Path, req.Offset, req.Append, content)
+	unlock()
+	if err != nil {
+		w.WriteHeader(statusForError(err))
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+			"code":  Utilities.ErrorCode(err),
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(written)
+}
+
+// parseRangeHeader - Interpretar un header Range de un solo rango. Retorna
+// offset y length (-1 hasta el final) o, para bytes=-n, suffix = n.
+func parseRangeHeader(header string) (int, int, int, error) {
+	spec := strings.TrimPrefix(header, "bytes=")
+	if !strings.HasPrefix(header, "bytes=") || strings.Contains(spec, ",") {
+		return 0, 0, 0, fmt.Errorf("Range inválido '%s': se admite un solo rango bytes=inicio-fin", header)
+	}
+	first, last, found := strings.Cut(spec, "-")
+	if !found {
+		return 0, 0, 0, fmt.Errorf("Range inválido '%s'", header)
+	}
+	if first == "" {
+		suffix, err := strconv.Atoi(last)
+		if err != nil || suffix <= 0 {
+			return 0, 0, 0, fmt.Errorf("Range inválido '%s'", header)
+		}
+		return 0, -1, suffix, nil
+	}
+	start, err := strconv.Atoi(first)
+	if err != nil || start < 0 {
+		return 0, 0, 0, fmt.Errorf("Range inválido '%s'", header)
+	}
+	if last == "" {
+		return start, -1, 0, nil
+	}
+	end, err := strconv.Atoi(last)
+	if err != nil || end < start {
+		return 0, 0, 0, fmt.Errorf("Range inválido '%s'", header)
+	}
+	return start, end - start + 1, 0, nil
+}
+
+// parseRangeQuery - Leer los parámetros opcionales offset y length
+func parseRangeQuery(r *http.Request) (int, int, error) {
+	offset, length := 0, -1
+	if value := r.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("El parámetro 'offset' debe ser un número no negativo")
+		}
+		offset = parsed
+	}
+	if value := r.URL.Query().Get("length"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("El parámetro 'length' debe ser un número no negativo")
+		}
+		length = parsed
+	}
+	return offset, length, nil
+}
+
+// statusForError - Código HTTP según el código de error del comando
+func statusForError(err error) int {
+	switch Utilities.ErrorCode(err) {
+	case Utilities.ErrInvalidArgument:
+		return http.StatusBadRequest
+	case Utilities.ErrNotFound:
+		return http.StatusNotFound
+	case Utilities.ErrNotLoggedIn:
+		return http.StatusUnauthorized
+	case Utilities.ErrPermissionDenied:
+		return http.StatusForbidden
+	case Utilities.ErrAlreadyExists:
+		return http.StatusConflict
+	case Utilities.ErrNoSpace:
+		return http.StatusInsufficientStorage
+	}
+	return http.StatusInternalServerError
+}
+
 // handleJournaling - Obtener las entradas del journaling
 func handleJournaling(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method == "OPTIONS" {
@@ -764,3 +1098,72 @@ func handleJournaling(w http.ResponseWriter, r *http.Request) {
 		"total":        len(entries),
 	})
 }
+
+// handleReports - Obtener los datos de un reporte (mismo contenido que rep
+// -format=json|csv). Parámetros: name, id, format (json por defecto) y path
+// para los reportes file y ls.
+func handleReports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Método no permitido. Use GET",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	name := query.Get("name")
+	partitionID := strings.ToUpper(query.Get("id"))
+	if name == "" || partitionID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Los parámetros 'name' e 'id' son requeridos",
+		})
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = Reportes.FormatJSON
+	}
+	format, err := Reportes.ParseReportFormat(format)
+	if err == nil && format == Reportes.FormatImage {
+		err = Utilities.NewCommandError(Utilities.ErrInvalidArgument, "formato 'image' no disponible en /reports (use json o csv)")
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Lectura concurrente: bloquear el disco solo en modo lectura
+	unlock := DiskManagement.RLockPartition(partitionID)
+	document, err := Reportes.CollectReportData(io.Discard, name, partitionID, query.Get("path"))
+	unlock()
+	if err != nil {
+		w.WriteHeader(statusForError(err))
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+			"code":  Utilities.ErrorCode(err),
+		})
+		return
+	}
+
+	if format == Reportes.FormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s.csv\"", document.Report))
+	}
+	Reportes.EncodeReport(document, format, w)
+}
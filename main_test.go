@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"proyecto1/DiskManagement"
+	"strings"
+	"testing"
+)
+
+// Bytes que no son UTF-8 válido, con nulos y todos los valores posibles
+func binaryContent() []byte {
+	data := []byte{0xff, 0xfe, 0x00, 0xc3, 0x28, 0x80}
+	for i := 0; i < 256; i++ {
+		data = append(data, byte(255-i))
+	}
+	return data
+}
+
+// execute envía un comando a POST /execute con el token indicado
+func execute(t *testing.T, token string, command string) CommandResponse {
+	t.Helper()
+	body, _ := json.Marshal(CommandRequest{Command: command})
+	req := httptest.NewRequest("POST", "/execute", bytes.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handleCommand(rec, req)
+
+	var response CommandResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("%s: %v", command, err)
+	}
+	if !response.Success {
+		t.Fatalf("%s: %s", command, response.Output)
+	}
+	return response
+}
+
+// setupAPIPartition crea, monta y formatea una partición e inicia sesión
+// como root; retorna el ID de la partición y el token de la sesión
+func setupAPIPartition(t *testing.T) (string, string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	DiskManagement.SetStateFile(filepath.Join(dir, "state.json"))
+	if err := DiskManagement.LoadState(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	disk := filepath.Join(dir, "Disco.mia")
+	execute(t, "", fmt.Sprintf(`mkdisk -size=2 -unit=m -path="%s"`, disk))
+	execute(t, "", fmt.Sprintf(`fdisk -size=1 -unit=m -path="%s" -name=P1`, disk))
+	mounted := execute(t, "", fmt.Sprintf(`mount -path="%s" -name=P1`, disk))
+	id := mounted.Results[0].Data["id"].(string)
+	execute(t, "", "mkfs -id="+id)
+	login := execute(t, "", "login -user=root -pass=123 -id="+id)
+	return dir, id, login.Token
+}
+
+// readFile lee un archivo con GET /filesystem/file, en bytes (encoding=raw)
+// o decodificando el base64 de la respuesta JSON (encoding=base64)
+func readFile(t *testing.T, id string, path string, encoding string) []byte {
+	t.Helper()
+	req := httptest.NewRequest("GET", fmt.Sprintf("/filesystem/file?partition_id=%s&path=%s&encoding=%s", id, path, encoding), nil)
+	rec := httptest.NewRecorder()
+	handleFileContent(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s (%s): %d %s", path, encoding, rec.Code, rec.Body.String())
+	}
+	if encoding == "raw" {
+		return rec.Body.Bytes()
+	}
+
+	var response struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Encoding != "base64" {
+		t.Fatalf("GET %s: encoding %q, se esperaba base64", path, response.Encoding)
+	}
+	data, err := base64.StdEncoding.DecodeString(response.Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// checkBinary lee el archivo en bytes y en base64 y compara con lo escrito
+func checkBinary(t *testing.T, id string, path string, want []byte) {
+	t.Helper()
+	for _, encoding := range []string{"raw", "base64"} {
+		if got := readFile(t, id, path, encoding); !bytes.Equal(got, want) {
+			t.Errorf("%s (%s): se leyeron %d bytes distintos de los %d escritos\n%x\n%x", path, encoding, len(got), len(want), got, want)
+		}
+	}
+}
+
+// Los bytes que no son UTF-8 escritos con mkfile y write desde un archivo
+// local vuelven sin cambios en bytes y en base64
+func TestBinaryRoundTripCommand(t *testing.T) {
+	dir, id, token := setupAPIPartition(t)
+	data := binaryContent()
+	source := filepath.Join(dir, "binario.bin")
+	if err := os.WriteFile(source, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	execute(t, token, fmt.Sprintf(`mkfile -path=/mkfile.bin -cont="%s"`, source))
+	checkBinary(t, id, "/mkfile.bin", data)
+
+	execute(t, token, "mkfile -path=/write.bin -size=10")
+	execute(t, token, fmt.Sprintf(`write -path=/write.bin -offset=0 -contenido="%s"`, source))
+	checkBinary(t, id, "/write.bin", data)
+}
+
+// Los bytes que no son UTF-8 escritos con PATCH (JSON en base64 y
+// application/octet-stream) vuelven sin cambios en bytes y en base64
+func TestBinaryRoundTripPatch(t *testing.T) {
+	_, id, token := setupAPIPartition(t)
+	data := binaryContent()
+	execute(t, token, "mkfile -path=/patch.bin -size=0")
+
+	body, _ := json.Marshal(FileWriteRequest{
+		Path:     "/patch.bin",
+		Content:  base64.StdEncoding.EncodeToString(data),
+		Encoding: "base64",
+	})
+	patch(t, token, httptest.NewRequest("PATCH", "/filesystem/file", bytes.NewReader(body)))
+	checkBinary(t, id, "/patch.bin", data)
+
+	req := httptest.NewRequest("PATCH", "/filesystem/file?path=/patch.bin&append=true", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	patch(t, token, req)
+	checkBinary(t, id, "/patch.bin", append(append([]byte{}, data...), data...))
+}
+
+// patch envía una escritura a PATCH /filesystem/file con el token indicado
+func patch(t *testing.T, token string, req *http.Request) {
+	t.Helper()
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handleFileContent(rec, req)
+	if rec.Code != http.StatusOK {
+		body, _ := io.ReadAll(rec.Body)
+		t.Fatalf("PATCH %s: %d %s", req.URL, rec.Code, body)
+	}
+}
+
+// GET /reports devuelve los datos del reporte en JSON o CSV
+func TestReportsEndpoint(t *testing.T) {
+	_, id, _ := setupAPIPartition(t)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handleReports(rec, httptest.NewRequest("GET", "/reports?"+query, nil))
+		return rec
+	}
+
+	rec := get("name=sb&id=" + strings.ToLower(id))
+	var document struct {
+		Report      string `json:"report"`
+		PartitionID string `json:"partition_id"`
+		Data        struct {
+			Magic int32 `json:"magic"`
+		} `json:"data"`
+	}
+	if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&document) != nil {
+		t.Fatalf("GET /reports sb: %d %s", rec.Code, rec.Body.String())
+	}
+	if document.Report != "sb" || document.PartitionID != id || document.Data.Magic != 0xEF53 {
+		t.Errorf("GET /reports sb: %+v", document)
+	}
+
+	rec = get("name=ls&format=csv&path=/&id=" + id)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("GET /reports ls csv: %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(records) < 2 || records[0][0] != "name" {
+		t.Errorf("GET /reports ls csv: %v %v", records, err)
+	}
+
+	for query, status := range map[string]int{
+		"name=sb":                       http.StatusBadRequest,
+		"name=sb&format=image&id=" + id: http.StatusBadRequest,
+		"name=otro&id=" + id:            http.StatusBadRequest,
+		"name=sb&id=999Z":               http.StatusNotFound,
+	} {
+		if rec := get(query); rec.Code != status {
+			t.Errorf("GET /reports?%s: %d, se esperaba %d", query, rec.Code, status)
+		}
+	}
+}
+
+// fileRangeResponse es la respuesta JSON de GET /filesystem/file
+type fileRangeResponse struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+	Offset   int    `json:"offset"`
+	Length   int    `json:"length"`
+	Size     int    `json:"size"`
+}
+
+// getFile pide GET /filesystem/file con la query y el header Range indicados
+func getFile(query string, rangeHeader string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/filesystem/file?"+query, nil)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	rec := httptest.NewRecorder()
+	handleFileContent(rec, req)
+	return rec
+}
+
+// GET /filesystem/file lee rangos con el header Range (bytes=a-b, bytes=a- y
+// bytes=-n) o con offset y length; los rangos que no se pueden satisfacer dan
+// 416 y los parámetros inválidos 400
+func TestFileContentRanges(t *testing.T) {
+	_, id, token := setupAPIPartition(t)
+	const text = "0123456789abcdefghij"
+	execute(t, token, "mkfile -path=/r.txt -size=0")
+	body, _ := json.Marshal(FileWriteRequest{Path: "/r.txt", Content: text})
+	patch(t, token, httptest.NewRequest("PATCH", "/filesystem/file", bytes.NewReader(body)))
+	base := "partition_id=" + id + "&path=/r.txt"
+
+	ranges := []struct {
+		header       string
+		contentRange string
+		want         string
+	}{
+		{"bytes=2-5", "bytes 2-5/20", "2345"},
+		{"bytes=15-", "bytes 15-19/20", "fghij"},
+		{"bytes=18-40", "bytes 18-19/20", "ij"},
+		{"bytes=-4", "bytes 16-19/20", "ghij"},
+		{"bytes=-50", "bytes 0-19/20", text},
+	}
+	for _, tc := range ranges {
+		rec := getFile(base, tc.header)
+		var response fileRangeResponse
+		if rec.Code != http.StatusPartialContent || json.NewDecoder(rec.Body).Decode(&response) != nil {
+			t.Errorf("Range %s: %d %s", tc.header, rec.Code, rec.Body.String())
+			continue
+		}
+		if got := rec.Header().Get("Content-Range"); got != tc.contentRange {
+			t.Errorf("Range %s: Content-Range %q, se esperaba %q", tc.header, got, tc.contentRange)
+		}
+		if response.Content != tc.want || response.Length != len(tc.want) || response.Size != len(text) {
+			t.Errorf("Range %s: %+v, se esperaba %q", tc.header, response, tc.want)
+		}
+	}
+
+	// Con encoding=raw el rango va en bytes en el cuerpo
+	rec := getFile(base+"&encoding=raw", "bytes=10-12")
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "abc" {
+		t.Errorf("Range raw: %d %q", rec.Code, rec.Body.String())
+	}
+
+	for _, header := range []string{"bytes=20-", "bytes=30-40", "bytes=5-2", "bytes=a-b", "bytes=-0", "bytes=1-2,4-5", "items=0-5"} {
+		if rec := getFile(base, header); rec.Code != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("Range %s: %d, se esperaba 416", header, rec.Code)
+		}
+	}
+	if rec := getFile(base, "bytes=20-"); rec.Header().Get("Content-Range") != "bytes */20" {
+		t.Errorf("Range fuera del archivo: Content-Range %q", rec.Header().Get("Content-Range"))
+	}
+
+	queries := []struct {
+		query string
+		want  string
+	}{
+		{"&offset=3&length=4", "3456"},
+		{"&offset=18", "ij"},
+		{"&length=3", "012"},
+		{"&offset=15&length=100", "fghij"},
+		{"&offset=20", ""},
+	}
+	for _, tc := range queries {
+		rec := getFile(base+tc.query, "")
+		var response fileRangeResponse
+		if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&response) != nil {
+			t.Errorf("GET %s: %d %s", tc.query, rec.Code, rec.Body.String())
+			continue
+		}
+		if response.Content != tc.want || response.Length != len(tc.want) || response.Size != len(text) {
+			t.Errorf("GET %s: %+v, se esperaba %q", tc.query, response, tc.want)
+		}
+		if rec.Header().Get("Content-Range") != "" {
+			t.Errorf("GET %s: Content-Range sin header Range", tc.query)
+		}
+	}
+
+	for _, query := range []string{"&offset=x", "&length=dos", "&offset=-1", "&length=-5", "&offset=21"} {
+		if rec := getFile(base+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: %d, se esperaba 400", query, rec.Code)
+		}
+	}
+}
+
+// encoding=base64 devuelve el texto en base64, también para un rango
+func TestFileContentBase64(t *testing.T) {
+	_, id, token := setupAPIPartition(t)
+	const text = "hola mundo, texto plano"
+	execute(t, token, "mkfile -path=/t.txt -size=0")
+	body, _ := json.Marshal(FileWriteRequest{Path: "/t.txt", Content: text})
+	patch(t, token, httptest.NewRequest("PATCH", "/filesystem/file", bytes.NewReader(body)))
+	base := "partition_id=" + id + "&path=/t.txt"
+
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"", text},
+		{"&offset=5&length=5", "mundo"},
+	}
+	for _, tc := range cases {
+		// Sin encoding el texto va tal cual
+		var plain fileRangeResponse
+		if rec := getFile(base+tc.query, ""); rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&plain) != nil {
+			t.Fatalf("GET %s: %d", tc.query, rec.Code)
+		}
+		if plain.Encoding != "utf-8" || plain.Content != tc.want {
+			t.Errorf("GET %s: %+v, se esperaba %q en utf-8", tc.query, plain, tc.want)
+		}
+
+		var encoded fileRangeResponse
+		if rec := getFile(base+tc.query+"&encoding=base64", ""); rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&encoded) != nil {
+			t.Fatalf("GET %s base64: %d", tc.query, rec.Code)
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded.Content)
+		if encoded.Encoding != "base64" || err != nil || string(data) != tc.want {
+			t.Errorf("GET %s base64: %+v (%v), se esperaba %q", tc.query, encoded, err, tc.want)
+		}
+	}
+}